@@ -0,0 +1,97 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+	"github.com/reyhanfikridz/ecom-product-service/internal/permission"
+)
+
+// GetAdminProductsHandler handling route get products across all sellers
+// filtered by url parameters 'seller_id', 'status' (active, hidden,
+// deleted, or all), 'min_price', 'max_price', 'created_after', and
+// 'created_before', not deleted products of any status returned if 'status'
+// is empty (method: GET, user: admin)
+func (a *API) GetAdminProductsHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// check user role is allowed to access this API
+	if !a.isAllowed(u, permission.ProductListAny) {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this API",
+		})
+	}
+
+	// get filters from url
+	sellerID, err := parseSellerID(c)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+	query := model.ProductQuery{UserID: sellerID, Search: c.Query("search")}
+
+	switch c.Query("status") {
+	case "":
+	case "active":
+		query.ExcludeHidden = true
+	case "hidden":
+		query.OnlyHidden = true
+	case "deleted":
+		query.Deleted = true
+	case "all":
+		query.IncludeDeleted = true
+	default:
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": "parameter 'status' invalid, must be empty, " +
+				"'active', 'hidden', 'deleted', or 'all'",
+		})
+	}
+
+	for _, field := range []struct {
+		name  string
+		value *model.Money
+	}{
+		{"min_price", &query.MinPrice},
+		{"max_price", &query.MaxPrice},
+	} {
+		raw := c.Query(field.name)
+		if raw == "" {
+			continue
+		}
+
+		price, err := model.ParseMoney(raw)
+		if err != nil || price < 0 {
+			return c.Status(http.StatusBadRequest).JSON(map[string]string{
+				"message": "parameter '" + field.name + "' invalid, " +
+					"must be non-negative amount",
+			})
+		}
+		*field.value = price
+	}
+	if query.MaxPrice > 0 && query.MinPrice > query.MaxPrice {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": "parameter 'min_price' can't be greater than " +
+				"'max_price'",
+		})
+	}
+
+	err = parseCreatedRange(c, &query)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	// get products from database
+	return a.sendProducts(c, query)
+}
@@ -0,0 +1,143 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+)
+
+// queryRecordingRepository product repository in memory recording
+// the last query of GetProducts
+type queryRecordingRepository struct {
+	fakeRepository
+	query *model.ProductQuery
+}
+
+// GetProducts record the query then get no products
+func (r queryRecordingRepository) GetProducts(ctx context.Context,
+	query model.ProductQuery) ([]model.Product, error) {
+	*r.query = query
+	return []model.Product{}, nil
+}
+
+// TestGetAdminProductsHandler test GetAdminProductsHandler
+// parsing filters into product query
+func TestGetAdminProductsHandler(t *testing.T) {
+	repo := queryRecordingRepository{query: &model.ProductQuery{}}
+	a := API{Repo: repo, FiberApp: fiber.New()}
+	a.FiberApp.Get("/api/admin/products/",
+		AuthorizationMiddlewareForTest(middleware.User{ID: 1, Role: "admin"}),
+		a.GetAdminProductsHandler)
+
+	createdFrom := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	createdTo := time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	// create testing table
+	testTable := []struct {
+		TestName           string
+		Query              string
+		ExpectedStatusCode int
+		ExpectedQuery      model.ProductQuery
+	}{
+		{
+			TestName:           "No filters",
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedQuery:      model.ProductQuery{},
+		},
+		{
+			TestName: "All filters",
+			Query: "seller_id=7&status=hidden&min_price=1000&" +
+				"max_price=2500.50&created_from=2022-01-01T00:00:00Z&" +
+				"created_to=2022-02-01T00:00:00Z&limit=10",
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedQuery: model.ProductQuery{
+				UserID:      7,
+				OnlyHidden:  true,
+				MinPrice:    100000,
+				MaxPrice:    250050,
+				CreatedFrom: &createdFrom,
+				CreatedTo:   &createdTo,
+				Limit:       11,
+			},
+		},
+		{
+			TestName:           "All statuses",
+			Query:              "status=all",
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedQuery:      model.ProductQuery{IncludeDeleted: true},
+		},
+		{
+			TestName:           "Seller ID invalid",
+			Query:              "seller_id=abc",
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			TestName:           "Status invalid",
+			Query:              "status=archived",
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			TestName:           "Price range invalid",
+			Query:              "min_price=200&max_price=100",
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			TestName: "Created date range",
+			Query: "created_after=2022-01-01T00:00:00Z&" +
+				"created_before=2022-02-01T00:00:00Z",
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedQuery: model.ProductQuery{
+				CreatedFrom: &createdFrom,
+				CreatedTo:   &createdTo,
+			},
+		},
+		{
+			TestName:           "Created date invalid",
+			Query:              "created_from=2022-01-01",
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			TestName: "Created date range invalid",
+			Query: "created_after=2022-02-01T00:00:00Z&" +
+				"created_before=2022-01-01T00:00:00Z",
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		*repo.query = model.ProductQuery{}
+
+		req, _ := http.NewRequest("GET", "/api/admin/products/?"+test.Query,
+			nil)
+		response, err := a.FiberApp.Test(req)
+		if err != nil {
+			t.Fatalf("[%s] There's an error serve http testing => %s",
+				test.TestName, err.Error())
+		}
+		response.Body.Close()
+
+		if response.StatusCode != test.ExpectedStatusCode {
+			t.Errorf("[%s] Expected status %d got %d", test.TestName,
+				test.ExpectedStatusCode, response.StatusCode)
+			continue
+		}
+		if response.StatusCode != http.StatusOK {
+			continue
+		}
+
+		if !reflect.DeepEqual(*repo.query, test.ExpectedQuery) {
+			t.Errorf("[%s] Expected query %+v, but got %+v", test.TestName,
+				test.ExpectedQuery, *repo.query)
+		}
+	}
+}
@@ -4,120 +4,1210 @@ Package api containing API initialization and API route handler
 package api
 
 import (
+	"context"
 	"database/sql"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"math"
 	"net/http"
+	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
+	"github.com/reyhanfikridz/ecom-product-service/internal/account"
+	"github.com/reyhanfikridz/ecom-product-service/internal/cache"
 	"github.com/reyhanfikridz/ecom-product-service/internal/config"
+	"github.com/reyhanfikridz/ecom-product-service/internal/dbmetrics"
+	"github.com/reyhanfikridz/ecom-product-service/internal/event"
+	"github.com/reyhanfikridz/ecom-product-service/internal/imaging"
 	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/migration"
 	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+	"github.com/reyhanfikridz/ecom-product-service/internal/permission"
+	"github.com/reyhanfikridz/ecom-product-service/internal/scanner"
+	"github.com/reyhanfikridz/ecom-product-service/internal/scheduler"
+	"github.com/reyhanfikridz/ecom-product-service/internal/search"
 	"github.com/reyhanfikridz/ecom-product-service/internal/validator"
+	"github.com/reyhanfikridz/ecom-product-service/internal/watch"
+	"github.com/reyhanfikridz/ecom-product-service/internal/webhook"
 )
 
-// API contain database connection and router GoFiber for product service API
+// API contain database connection with its query metrics, product
+// repository, router GoFiber, event publisher, webhook dispatcher,
+// product cache, search provider, and task scheduler for product
+// service API
 type API struct {
-	DB       *sql.DB
-	FiberApp *fiber.App
+	DB        *sql.DB
+	DBMetrics *dbmetrics.Recorder
+	Repo      model.ProductRepository
+	FiberApp  *fiber.App
+	Publisher event.Publisher
+	Webhooks  *webhook.Dispatcher
+	Cache     cache.ProductCache
+	Search    search.SearchProvider
+	Scheduler *scheduler.Scheduler
+	Accounts  *account.Client
+
+	// Maintenance maintenance mode rejecting mutating routes,
+	// disabled if nil
+	Maintenance *Maintenance
+
+	// Streams hub of stock and price changes streamed to storefront
+	Streams *StreamHub
+
+	// ExportJobs queue of asynchronous product exports
+	ExportJobs *ExportJobQueue
+
+	// Journal journal of product events watched by internal services,
+	// events aren't recorded if nil
+	Journal *watch.Journal
+
+	// Permissions roles allowed to do each action,
+	// default role-permission matrix if nil
+	Permissions permission.Matrix
+}
+
+// InitDB initialize API database connection, and read replica
+// connection if config "replica_dsn" is set, latency of their queries
+// are recorded in API database metrics
+func (a *API) InitDB(DBConfig map[string]string) error {
+	if a.DBMetrics == nil {
+		a.DBMetrics = dbmetrics.NewRecorder(config.DBSlowQueryThreshold)
+	}
+
+	// connect to db
+	var err error
+	a.DB, err = a.openDB(config.GetDBConnString(DBConfig))
+	if err != nil {
+		return err
+	}
+
+	// use the database as product repository, reading products
+	// from read replica if any
+	if DBConfig["replica_dsn"] == "" {
+		a.Repo = model.NewPostgresRepository(a.DB)
+	} else {
+		replica, err := a.openDB(DBConfig["replica_dsn"])
+		if err != nil {
+			return err
+		}
+		a.Repo = model.NewPostgresRepositoryWithReplica(a.DB, replica)
+	}
+
+	// migrate database schema on start except in production,
+	// where command migrate is run on deploy instead
+	if config.Env != "production" {
+		_, err = migration.Up(context.Background(), a.DB)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// openDB open database connection pool of connection string recording
+// its queries, limited so it doesn't exhaust database connections
+func (a *API) openDB(connString string) (*sql.DB, error) {
+	connector, err := pq.NewConnector(connString)
+	if err != nil {
+		return nil, err
+	}
+	DB := sql.OpenDB(a.DBMetrics.Connector(connector))
+
+	DB.SetMaxOpenConns(config.DBMaxOpenConns)
+	DB.SetMaxIdleConns(config.DBMaxIdleConns)
+	DB.SetConnMaxLifetime(config.DBConnMaxLifetime)
+	DB.SetConnMaxIdleTime(config.DBConnMaxIdleTime)
+
+	return DB, nil
+}
+
+// InitPublisher initialize API event publisher to message broker,
+// events are discarded if broker URL is empty
+func (a *API) InitPublisher(brokerURL string, exchange string) error {
+	if brokerURL == "" {
+		a.Publisher = event.NopPublisher{}
+		return nil
+	}
+
+	publisher, err := event.NewRabbitMQPublisher(brokerURL, exchange)
+	if err != nil {
+		return err
+	}
+	a.Publisher = publisher
+
+	return nil
+}
+
+// InitWebhooks initialize API webhook dispatcher and start its workers
+func (a *API) InitWebhooks(lowStockThreshold int) {
+	a.Webhooks = webhook.NewDispatcher(a.DB, lowStockThreshold)
+	a.Webhooks.Start(4)
+}
+
+// InitJournal initialize API journal recording product events in
+// product repository
+func (a *API) InitJournal() {
+	a.Journal = watch.NewJournal(a.Repo)
+}
+
+// InitCache initialize API product cache in Redis,
+// products are not cached if Redis URL is empty
+func (a *API) InitCache(redisURL string, ttl time.Duration) error {
+	if redisURL == "" {
+		a.Cache = cache.NopCache{}
+		return nil
+	}
+
+	productCache, err := cache.NewRedisCache(redisURL, ttl)
+	if err != nil {
+		return err
+	}
+	a.Cache = productCache
+
+	return nil
+}
+
+// InitSearch initialize API search provider of backend, products are
+// indexed in external search engine at URL with index and API key
+// unless backend is postgres, must be called after InitDB
+func (a *API) InitSearch(backend string, URL string, index string,
+	apiKey string) error {
+	provider, err := search.NewProvider(backend, URL, index, apiKey, a.Repo)
+	if err != nil {
+		return err
+	}
+	a.Search = provider
+
+	return nil
+}
+
+// InitScanner initialize scanner of saved product image files of backend,
+// ClamAV daemon at address or scanning API at address URL with API key,
+// files aren't scanned if backend is none
+func (a *API) InitScanner(backend string, address string,
+	apiKey string) error {
+	productImageScanner, err := scanner.NewScanner(backend, address, apiKey)
+	if err != nil {
+		return err
+	}
+	model.ProductImageScanner = productImageScanner
+
+	return nil
+}
+
+// InitAccounts initialize client of account service at base URL
+func (a *API) InitAccounts(baseURL string) {
+	a.Accounts = account.NewClient(baseURL)
+}
+
+// getAccounts get client of account service of API, client of
+// account service URL config if it's not initialized
+func (a *API) getAccounts() *account.Client {
+	if a.Accounts == nil {
+		return account.NewClient(config.AccountServiceURL)
+	}
+
+	return a.Accounts
+}
+
+// InitPermissions initialize role-permission matrix of the default
+// matrix with roles of actions replaced by overrides
+func (a *API) InitPermissions(overrides map[string][]string) error {
+	permissions, err := permission.NewMatrix(overrides)
+	if err != nil {
+		return err
+	}
+	a.Permissions = permissions
+
+	return nil
+}
+
+// getPermissions get role-permission matrix of API,
+// the default matrix if it's not initialized
+func (a *API) getPermissions() permission.Matrix {
+	if a.Permissions == nil {
+		return permission.DefaultMatrix()
+	}
+
+	return a.Permissions
+}
+
+// isAllowed check if any role of user is allowed to do action
+func (a *API) isAllowed(u middleware.User, action string) bool {
+	return a.getPermissions().AllowsAny(u.GetRoles(), action)
+}
+
+// InitWatermark initialize watermark stamped onto saved product images
+// from image file at path, images aren't watermarked if path is empty
+func (a *API) InitWatermark(path string) error {
+	if path == "" {
+		model.ProductImageWatermark = nil
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	watermark, _, err := imaging.Decode(f)
+	if err != nil {
+		return fmt.Errorf("watermark '%s' invalid => %s", path, err.Error())
+	}
+	model.ProductImageWatermark = watermark
+
+	return nil
+}
+
+// PublishEvent invalidate cached product of the event and reindex it
+// in search provider, then publish product domain event to message
+// broker and subscribed webhooks, failure is only logged so it doesn't
+// fail the request which already committed
+func (a *API) PublishEvent(e event.Event) {
+	if a.Cache != nil {
+		err := a.Cache.Delete(e.SKU)
+		if err != nil {
+			log.Printf("There's an error when invalidating cached product "+
+				"of SKU %s => %s", e.SKU, err.Error())
+		}
+	}
+
+	// stock isn't indexed, so stock changes don't need reindexing
+	if a.Search != nil && e.Type != event.StockChanged {
+		err := a.Search.Index(context.Background(), e.SKU)
+		if err != nil {
+			log.Printf("There's an error when indexing product "+
+				"of SKU %s => %s", e.SKU, err.Error())
+		}
+	}
+
+	if a.Webhooks != nil {
+		a.Webhooks.Dispatch(e)
+	}
+
+	if a.Streams != nil && a.isStreamPublished(e) {
+		a.Streams.Publish(e)
+	}
+
+	if a.Journal != nil {
+		err := a.Journal.Record(context.Background(), e)
+		if err != nil {
+			log.Printf("There's an error when recording event %s of SKU %s "+
+				"=> %s", e.Type, e.SKU, err.Error())
+		}
+	}
+
+	if a.Publisher == nil {
+		return
+	}
+
+	err := a.Publisher.Publish(e)
+	if err != nil {
+		log.Printf("There's an error when publishing event %s of SKU %s => %s",
+			e.Type, e.SKU, err.Error())
+	}
+}
+
+// GetFiberConfig get GoFiber app config of API
+func GetFiberConfig() fiber.Config {
+	bodyLimit := config.BodyLimit
+	if bodyLimit <= 0 {
+		bodyLimit = fiber.DefaultBodyLimit
+	}
+
+	return fiber.Config{
+		BodyLimit:    bodyLimit,
+		ErrorHandler: ErrorHandler,
+	}
+}
+
+// ErrorHandler handling error returned by handlers or the server
+// (e.g. request body too large) as JSON message
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	code := http.StatusInternalServerError
+	message := err.Error()
+
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		code = fiberErr.Code
+	}
+	if code == http.StatusRequestEntityTooLarge {
+		message = fmt.Sprintf("request body too large, maximum %d bytes",
+			c.App().Config().BodyLimit)
+	}
+
+	return c.Status(code).JSON(map[string]string{
+		"message": message,
+	})
+}
+
+// InitRouter initialize GoFiber router for API
+func (a *API) InitRouter() {
+	a.FiberApp = fiber.New(GetFiberConfig())
+
+	// add middleware CORS and logger to all route
+	allowHeaders := "Authorization, Origin, Content-Type, Accept"
+	if config.DevAuth {
+		allowHeaders += ", " + middleware.DevUserIDHeader + ", " +
+			middleware.DevUserRoleHeader
+	}
+	a.FiberApp.Use(
+		cors.New(
+			cors.Config{
+				AllowOrigins: fmt.Sprintf("%s,%s",
+					config.FrontendURL, config.AccountServiceURL),
+				AllowHeaders: allowHeaders,
+			},
+		),
+	)
+	a.FiberApp.Use(logger.New())
+
+	// reject mutating routes while maintenance mode enabled
+	a.FiberApp.Use(a.MaintenanceMiddleware())
+
+	// route stream stock and price changes of products as server-sent
+	// events, registered before main router group since browser event
+	// source can't send authorization header
+	a.FiberApp.Get("/api/products/stream/", a.StreamProductsHandler)
+	a.getStreams()
+
+	// route Google Merchant Center product feed, registered before
+	// main router group so it's authorized by feed token instead of user
+	a.FiberApp.Get("/api/feeds/google-merchant.xml",
+		a.GetGoogleMerchantFeedHandler)
+
+	// route download file of export job, registered before main router
+	// group so it's authorized by signed download URL instead of user
+	a.FiberApp.Get("/api/products/export-jobs/:id/download/",
+		a.DownloadExportJobHandler)
+	a.getExportJobs()
+
+	// route delete all products of a user, called by account service
+	// when the user deleted, registered before main router group so it's
+	// authorized by internal service token instead of user
+	a.FiberApp.Delete("/api/products/user/:id/",
+		middleware.ServiceAuthorizationMiddleware(),
+		a.DeleteUserProductsHandler)
+
+	// route set rating aggregate of a product, called by review service
+	// when reviews of the product change, authorized by internal service
+	// token instead of user
+	a.FiberApp.Put("/api/product/rating/",
+		middleware.ServiceAuthorizationMiddleware(),
+		a.SetProductRatingHandler)
+
+	// route increment popularity counters of products, called by wishlist
+	// and order service, authorized by internal service token instead
+	// of user
+	a.FiberApp.Post("/api/products/popularity/",
+		middleware.ServiceAuthorizationMiddleware(),
+		a.ReportPopularityHandler)
+
+	// route get changes of change log after sequence number, called by
+	// downstream systems syncing incrementally, authorized by internal
+	// service token instead of user
+	a.FiberApp.Get("/api/changes/",
+		middleware.ServiceAuthorizationMiddleware(),
+		a.GetChangesHandler)
+
+	// create main router group (prefix: "/api") with middleware authorization
+	// and idempotency key
+	mainRouter := a.FiberApp.Group("/api", a.authorizationMiddleware(),
+		middleware.IdempotencyMiddleware(a.DB))
+
+	//// route add product
+	mainRouter.Post("/product/", a.AddProductHandler)
+
+	//// route add many products in one transaction
+	mainRouter.Post("/products/batch/", a.AddProductsBatchHandler)
+
+	//// route import products from CSV or XLSX
+	mainRouter.Post("/products/import/", a.ImportProductsHandler)
+
+	//// route import products from Shopify or WooCommerce export
+	mainRouter.Post("/products/import/:platform/", a.ImportCatalogHandler)
+
+	//// route get products
+	mainRouter.Get("/products/", a.GetProductsHandler)
+
+	//// route get products by user ID
+	mainRouter.Get("/products/user/", a.GetProductsByUserIDHandler)
+
+	//// route export products by user ID as CSV or JSON
+	mainRouter.Get("/products/user/export/", a.ExportProductsHandler)
+
+	//// route queue export of products by user ID as CSV or JSON
+	mainRouter.Post("/products/export-jobs/", a.AddExportJobHandler)
+
+	//// route get export job by ID with its download URL
+	mainRouter.Get("/products/export-jobs/:id/", a.GetExportJobHandler)
+
+	//// route get inventory snapshot with stock value by user ID
+	mainRouter.Get("/products/user/inventory/",
+		a.GetSellerInventorySnapshotHandler)
+
+	//// route get low stock products by user ID
+	mainRouter.Get("/products/user/low-stock/", a.GetLowStockProductsHandler)
+
+	//// route get effective price and stock of products by skus
+	mainRouter.Get("/products/snapshot/", a.GetProductSnapshotsHandler)
+
+	//// route set stock of many products by sku
+	mainRouter.Put("/products/stock/batch/", a.BatchUpdateStockHandler)
+
+	//// route check product exists by sku, registered before route get
+	//// product since it also handles method HEAD
+	mainRouter.Head("/product/", a.HeadProductHandler)
+
+	//// route get product by sku
+	mainRouter.Get("/product/", a.GetProductHandler)
+
+	//// route get product images by sku
+	mainRouter.Get("/product/images/", a.GetProductImagesHandler)
+
+	//// route add images to product by sku
+	mainRouter.Post("/product/images/", a.AddProductImagesHandler)
+
+	//// route download product images by sku as zip
+	mainRouter.Get("/product/images/zip/", a.GetProductImagesZipHandler)
+
+	//// route record product view by sku
+	mainRouter.Post("/product/view/", a.RecordProductViewHandler)
+
+	//// route get products by barcode
+	mainRouter.Get("/product/barcode/:code/", a.GetProductsByBarcodeHandler)
+
+	//// route update product by sku
+	mainRouter.Put("/product/", a.UpdateProductHandler)
+
+	//// route delete product by sku
+	mainRouter.Delete("/product/", a.DeleteProductHandler)
+
+	//// route restore deleted product by sku
+	mainRouter.Put("/product/restore/", a.RestoreProductHandler)
+
+	//// route hide or show product by sku
+	mainRouter.Put("/product/visibility/", a.SetProductVisibilityHandler)
+
+	//// route get deleted products
+	mainRouter.Get("/products/deleted/", a.GetDeletedProductsHandler)
+
+	//// route decrease product stock by sku
+	mainRouter.Put("/product/decrease/stock/", a.DecreaseStockHandler)
+
+	//// route decrease stock of many products by sku in one transaction
+	mainRouter.Put("/products/decrease/stock/", a.BatchDecreaseStockHandler)
+
+	//// route get product stock history by sku
+	mainRouter.Get("/product/:sku/stock-history/", a.GetStockHistoryHandler)
+
+	//// route get product versions by sku
+	mainRouter.Get("/product/:sku/versions/", a.GetProductVersionsHandler)
+
+	//// route replace product price tiers by sku
+	mainRouter.Put("/product/:sku/price-tiers/", a.SetPriceTiersHandler)
+
+	//// route get product price quote by sku and quantity
+	mainRouter.Get("/product/:sku/quote/", a.GetPriceQuoteHandler)
+
+	//// route get product translations by sku
+	mainRouter.Get("/product/:sku/translations/",
+		a.GetProductTranslationsHandler)
+
+	//// route add or replace product translation by sku and locale
+	mainRouter.Put("/product/:sku/translations/:locale/",
+		a.SetProductTranslationHandler)
+
+	//// route delete product translation by sku and locale
+	mainRouter.Delete("/product/:sku/translations/:locale/",
+		a.DeleteProductTranslationHandler)
+
+	//// route roll back product to a previous version by sku
+	mainRouter.Put("/product/:sku/rollback/", a.RollbackProductHandler)
+
+	//// route get inventory snapshot at a past instant
+	mainRouter.Get("/admin/inventory/snapshot/", a.GetInventorySnapshotHandler)
+
+	//// route get products across all sellers
+	mainRouter.Get("/admin/products/", a.GetAdminProductsHandler)
+
+	//// route transfer products of a user to another user
+	mainRouter.Put("/admin/products/transfer/", a.TransferProductsHandler)
+
+	//// route get marketplace-wide product stats
+	mainRouter.Get("/admin/stats/", a.GetMarketplaceStatsHandler)
+
+	//// route get run metrics of scheduled tasks
+	mainRouter.Get("/admin/scheduler/", a.GetSchedulerStatsHandler)
+
+	//// route get latency metrics of database queries
+	mainRouter.Get("/admin/db/metrics/", a.GetDBMetricsHandler)
+
+	//// route get maintenance mode status
+	mainRouter.Get("/admin/maintenance/", a.GetMaintenanceHandler)
+
+	//// route enable or disable maintenance mode
+	mainRouter.Put("/admin/maintenance/", a.SetMaintenanceHandler)
+
+	//// route set shop profile of the seller
+	mainRouter.Put("/shop/", a.SetShopHandler)
+
+	//// route get shop profile by slug
+	mainRouter.Get("/shop/:slug/", a.GetShopHandler)
+
+	//// route get products of shop by slug
+	mainRouter.Get("/shop/:slug/products/", a.GetShopProductsHandler)
+
+	//// route report product by SKU
+	mainRouter.Post("/product/report/", a.ReportProductHandler)
+
+	//// route get product reports to review
+	mainRouter.Get("/admin/reports/", a.GetProductReportsHandler)
+
+	//// route dismiss or take down reported product by SKU
+	mainRouter.Put("/admin/reports/review/", a.ReviewProductReportsHandler)
+
+	//// route add webhook subscription
+	mainRouter.Post("/webhooks/", a.AddWebhookSubscriptionHandler)
+
+	//// route get webhook subscriptions
+	mainRouter.Get("/webhooks/", a.GetWebhookSubscriptionsHandler)
+
+	//// route delete webhook subscription by ID
+	mainRouter.Delete("/webhooks/", a.DeleteWebhookSubscriptionHandler)
+
+	// create graphql router group (prefix: "/graphql")
+	// with middleware authorization
+	graphqlRouter := a.FiberApp.Group("/graphql", a.authorizationMiddleware())
+
+	//// route graphql query
+	graphqlRouter.Get("/", a.GraphQLHandler)
+	graphqlRouter.Post("/", a.GraphQLHandler)
+
+	// route resized media
+	a.FiberApp.Get("/media/resize/", a.optionalAuthorizationMiddleware(),
+		a.ResizeMediaHandler)
+
+	// route static media with HTTP caching and range requests,
+	// images of unpublished products only served to their seller
+	a.FiberApp.Use("/media", a.optionalAuthorizationMiddleware(),
+		a.MediaAccessMiddleware(),
+		MediaCacheMiddleware(config.MediaCacheMaxAge))
+	a.FiberApp.Static("/media", config.MediaRoot, fiber.Static{
+		ByteRange: true,
+	})
+}
+
+// authorizationMiddleware get authorization middleware of API routes,
+// which authorize as fake user without account service
+// if mock authentication enabled for local development
+func (a *API) authorizationMiddleware() fiber.Handler {
+	if !config.DevAuth {
+		return middleware.AuthorizationMiddleware(a.getAccounts())
+	}
+
+	log.Printf("WARNING: mock authentication enabled, all requests are "+
+		"authorized as user ID %d role %s", config.DevAuthUserID,
+		config.DevAuthRole)
+	roles := middleware.ParseRoles(config.DevAuthRole)
+	u := middleware.User{
+		ID:       config.DevAuthUserID,
+		Email:    "dev@localhost",
+		FullName: "Dev User",
+		Roles:    roles,
+	}
+	if len(roles) > 0 {
+		u.Role = roles[0]
+	}
+	return middleware.DevAuthorizationMiddleware(u)
+}
+
+// optionalAuthorizationMiddleware authorize request by authorization
+// middleware only if it has token, so the route also serves
+// anonymous requests without user
+func (a *API) optionalAuthorizationMiddleware() fiber.Handler {
+	authorize := a.authorizationMiddleware()
+
+	return func(c *fiber.Ctx) error {
+		if !config.DevAuth &&
+			middleware.GetTokenFromHeader(c.GetReqHeaders()) == "" {
+			return c.Next()
+		}
+
+		return authorize(c)
+	}
+}
+
+// AddProductHandler handling route add product (method: POST, user: seller)
+func (a *API) AddProductHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// check user role is allowed to access this API
+	if !a.isAllowed(u, permission.ProductCreate) {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this API",
+		})
+	}
+
+	// parse product info from form data
+	pInfo := model.ProductInfo{}
+	err := c.BodyParser(&pInfo)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+	if strings.TrimSpace(c.FormValue("stock")) == "" {
+		return sendValidationError(c, validator.FieldError{
+			Field:   "stock",
+			Code:    validator.CodeRequired,
+			Message: "stock empty/not found",
+		})
+	}
+	err = parseSaleSchedule(c, &pInfo)
+	if err != nil {
+		return sendValidationError(c, err)
+	}
+
+	// validate product info data
+	err = validator.IsProductInfoValid(pInfo)
+	if err != nil {
+		return sendValidationError(c, err)
+	}
+
+	// get image form (multi images)
+	imageForm, err := c.MultipartForm()
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	// validate product images count and size
+	fileHeaders := imageForm.File["product_images"]
+	err = validator.IsProductImagesValid(fileHeaders,
+		config.MaxProductImages, config.MaxProductImagesSize)
+	if err != nil {
+		return sendValidationError(c, err)
+	}
+
+	// save product images into media folder
+	images, err := saveProductImages(fileHeaders, u.ID)
+	if errors.Is(err, model.ErrProductImageInvalid) ||
+		errors.Is(err, model.ErrProductImageMalicious) {
+		return sendValidationError(c, validator.FieldError{
+			Field:   "product_images",
+			Code:    validator.CodeInvalid,
+			Message: err.Error(),
+		})
+	} else if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": fmt.Sprintf("There's an error when saving "+
+				"product images => %s", err.Error()),
+		})
+	}
+
+	// insert product info and its images into database in one transaction,
+	// product of unverified seller is inserted hidden as draft
+	pInfo.UserID = u.ID
+	pInfo.Hidden = !u.IsVerified()
+	pInfo, err = a.Repo.InsertProductWithImages(c.UserContext(), pInfo,
+		images)
+	if err != nil {
+		removeProductImages(images)
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	a.PublishEvent(event.NewEvent(event.ProductCreated, pInfo.SKU,
+		pInfo.UserID, pInfo))
+
+	return c.Status(http.StatusCreated).JSON(pInfo)
+}
+
+// GetProductsHandler handling route get products, of a seller if url
+// parameter seller_id set (method: GET, user: buyer)
+func (a *API) GetProductsHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// check user role is allowed to access this API
+	if !a.isAllowed(u, permission.ProductList) {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this API",
+		})
+	}
+
+	// get seller filter from url
+	sellerID, err := parseSellerID(c)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	// get products from database
+	return a.sendProducts(c, model.ProductQuery{
+		UserID:        sellerID,
+		Search:        c.Query("search"),
+		ExcludeHidden: true,
+	})
+}
+
+// GetProductsByUserIDHandler handling route get products by user ID,
+// filtered by minimum stock of url parameter 'min_stock' if it's not empty,
+// and by creation date range of url parameters 'created_after' and
+// 'created_before' (method: GET, user: seller)
+func (a *API) GetProductsByUserIDHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// check user role is allowed to access this API
+	if !a.isAllowed(u, permission.ProductReadOwn) {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this API",
+		})
+	}
+
+	// get minimum stock filter from url
+	minStock := 0.0
+	if rawMinStock := c.Query("min_stock"); rawMinStock != "" {
+		var err error
+		minStock, err = strconv.ParseFloat(rawMinStock, 64)
+		if err != nil || minStock < 0 || math.IsNaN(minStock) ||
+			math.IsInf(minStock, 0) {
+			return c.Status(http.StatusBadRequest).JSON(map[string]string{
+				"message": "parameter 'min_stock' invalid, " +
+					"must be non-negative number",
+			})
+		}
+	}
+
+	// get creation date range filter from url
+	query := model.ProductQuery{
+		UserID:   u.ID,
+		Search:   c.Query("search"),
+		MinStock: minStock,
+	}
+	err := parseCreatedRange(c, &query)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	// get products by user id from database
+	return a.sendProducts(c, query)
+}
+
+// GetProductHandler handling route get one product by SKU
+// (method: GET, user: all)
+func (a *API) GetProductHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// get SKU from url
+	SKU := c.Query("sku")
+	if strings.TrimSpace(SKU) == "" {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": "parameter 'sku' empty/not found",
+		})
+	}
+
+	// get product by sku from cache or database
+	p, err := a.GetCachedProductBySKU(c.UserContext(), SKU)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": fmt.Sprintf(
+				"There's an error when getting the product data => %s",
+				err.Error()),
+		})
+	}
+
+	// hidden product only can be seen by its seller and roles
+	// allowed to read hidden products
+	if p.ProductInfo.Hidden && u.ID != p.ProductInfo.UserID &&
+		!a.isAllowed(u, permission.ProductReadHidden) {
+		return c.Status(http.StatusNotFound).JSON(map[string]string{
+			"message": "product not found",
+		})
+	}
+
+	// localize product into requested locale
+	products := []model.Product{p}
+	err = a.localizeProducts(c, products)
+	if err == errLocaleInvalid {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	} else if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": fmt.Sprintf(
+				"There's an error when localizing the product data => %s",
+				err.Error()),
+		})
+	}
+	p = products[0]
+
+	// reply not modified if client cached the same product version
+	etag := GetProductETag(p)
+	c.Set(fiber.HeaderETag, etag)
+	c.Vary(fiber.HeaderAcceptLanguage)
+	c.Set(fiber.HeaderCacheControl, "private, no-cache")
+	if IsETagMatch(c.Get(fiber.HeaderIfNoneMatch), etag) {
+		return c.SendStatus(http.StatusNotModified)
+	}
+
+	// set seller info with API get user from account service
+	if c.Query("testing") != "1" {
+		p.SellerInfo, err = GetSellerInfo(c.UserContext(), a.getAccounts(),
+			p.ProductInfo.UserID)
+		if err != nil {
+			return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+				"message": err.Error(),
+			})
+		}
+	}
+
+	setProductImageURLs(c.BaseURL(), []model.Product{p})
+
+	return c.Status(http.StatusOK).JSON(p)
+}
+
+// HeadProductHandler handling route check product by SKU exists,
+// replying status only without getting seller info, hidden product
+// only exists for its seller and admin (method: HEAD, user: any)
+func (a *API) HeadProductHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.SendStatus(http.StatusInternalServerError)
+	}
+
+	// get SKU from url
+	SKU := c.Query("sku")
+	if strings.TrimSpace(SKU) == "" {
+		return c.SendStatus(http.StatusBadRequest)
+	}
+
+	// get product by sku from cache or database
+	p, err := a.GetCachedProductBySKU(c.UserContext(), SKU)
+	if err == sql.ErrNoRows {
+		return c.SendStatus(http.StatusNotFound)
+	} else if err != nil {
+		return c.SendStatus(http.StatusInternalServerError)
+	}
+
+	// hidden product only can be seen by its seller and roles
+	// allowed to read hidden products
+	if p.ProductInfo.Hidden && u.ID != p.ProductInfo.UserID &&
+		!a.isAllowed(u, permission.ProductReadHidden) {
+		return c.SendStatus(http.StatusNotFound)
+	}
+
+	return c.SendStatus(http.StatusOK)
+}
+
+// GetProductsByBarcodeHandler handling route get products by barcode,
+// hidden products only returned to admin (method: GET, user: any)
+func (a *API) GetProductsByBarcodeHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// get barcode from url
+	code := c.Params("code")
+	err := validator.IsBarcodeValid(code)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	// get products by barcode from database
+	products, err := a.Repo.GetProducts(c.UserContext(), model.ProductQuery{
+		Barcode:       code,
+		ExcludeHidden: !a.isAllowed(u, permission.ProductReadHidden),
+	})
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": fmt.Sprintf(
+				"There's an error when getting the products data => %s",
+				err.Error()),
+		})
+	}
+	if len(products) == 0 {
+		return c.Status(http.StatusNotFound).JSON(map[string]string{
+			"message": "product not found",
+		})
+	}
+
+	// localize products into requested locale
+	err = a.localizeProducts(c, products)
+	if err == errLocaleInvalid {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	} else if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": fmt.Sprintf(
+				"There's an error when localizing the products data => %s",
+				err.Error()),
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(products)
+}
+
+// parseSaleSchedule parse sale schedule of product info from
+// RFC3339 form values sale_starts_at and sale_ends_at, empty means no limit
+func parseSaleSchedule(c *fiber.Ctx, pInfo *model.ProductInfo) error {
+	for _, field := range []struct {
+		name  string
+		value **time.Time
+	}{
+		{"sale_starts_at", &pInfo.SaleStartsAt},
+		{"sale_ends_at", &pInfo.SaleEndsAt},
+	} {
+		raw := c.FormValue(field.name)
+		if raw == "" {
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return validator.FieldError{
+				Field:   field.name,
+				Code:    validator.CodeInvalid,
+				Message: field.name + " invalid, must be RFC3339 timestamp",
+			}
+		}
+		*field.value = &t
+	}
+
+	return nil
+}
+
+// parseKeepImageIDs parse image IDs of kept product images from form
+// values keep_image_ids, each may be comma separated IDs, empty value
+// means no image kept, return nil if there's no value
+func parseKeepImageIDs(values []string) ([]int, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	imageIDs := []int{}
+	for _, value := range values {
+		for _, rawID := range strings.Split(value, ",") {
+			rawID = strings.TrimSpace(rawID)
+			if rawID == "" {
+				continue
+			}
+
+			imageID, err := strconv.Atoi(rawID)
+			if err != nil || imageID <= 0 {
+				return nil, validator.FieldError{
+					Field: "keep_image_ids",
+					Code:  validator.CodeInvalid,
+					Message: fmt.Sprintf("image ID '%s' invalid, "+
+						"must be positive integer", rawID),
+				}
+			}
+			imageIDs = append(imageIDs, imageID)
+		}
+	}
+
+	return imageIDs, nil
 }
 
-// InitDB initialize API database connection
-func (a *API) InitDB(DBConfig map[string]string) error {
-	// connect to db
-	connString := fmt.Sprintf("user=%s password=%s dbname=%s sslmode=disable",
-		DBConfig["user"], DBConfig["password"], DBConfig["dbname"])
+// parseSellerID parse seller ID filter of products from url parameter
+// seller_id, 0 if it's empty
+func parseSellerID(c *fiber.Ctx) (int, error) {
+	rawSellerID := c.Query("seller_id")
+	if rawSellerID == "" {
+		return 0, nil
+	}
 
-	var err error
-	a.DB, err = sql.Open("postgres", connString)
-	if err != nil {
-		return err
+	sellerID, err := strconv.Atoi(rawSellerID)
+	if err != nil || sellerID <= 0 {
+		return 0, fmt.Errorf("parameter 'seller_id' invalid, " +
+			"must be positive integer")
 	}
 
-	// create db tables if not exist
-	tableCreationQuery := `
-		CREATE TABLE IF NOT EXISTS product_productinfo (
-			id SERIAL PRIMARY KEY NOT NULL,
-			sku VARCHAR(15) UNIQUE NOT NULL,
-			name VARCHAR(100) NOT NULL,
-			price NUMERIC NOT NULL,
-			weight REAL NOT NULL,
-			description TEXT,
-			stock INT NOT NULL,
-			account_user_id INT NOT NULL
-		);
-
-		CREATE TABLE IF NOT EXISTS product_productimage
-		(
-			id SERIAL PRIMARY KEY NOT NULL,
-			image_path VARCHAR(250) NOT NULL,
-			product_productinfo_id INT NOT NULL,
-			CONSTRAINT fk_product_productinfo
-				FOREIGN KEY(product_productinfo_id) 
-					REFERENCES product_productinfo(id)
-					ON DELETE CASCADE
-		);
-	`
-
-	_, err = a.DB.Exec(tableCreationQuery)
-	if err != nil {
-		return err
+	return sellerID, nil
+}
+
+// parseCreatedRange parse creation date range filter of products from url
+// parameters created_after and created_before (or their aliases
+// created_from and created_to) into query, both are RFC3339 timestamps
+// with created_after inclusive and created_before exclusive
+func parseCreatedRange(c *fiber.Ctx, query *model.ProductQuery) error {
+	for _, field := range []struct {
+		name  string
+		alias string
+		value **time.Time
+	}{
+		{"created_after", "created_from", &query.CreatedFrom},
+		{"created_before", "created_to", &query.CreatedTo},
+	} {
+		name, raw := field.name, c.Query(field.name)
+		if raw == "" {
+			name, raw = field.alias, c.Query(field.alias)
+		}
+		if raw == "" {
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return fmt.Errorf("parameter '%s' invalid, "+
+				"must be RFC3339 timestamp", name)
+		}
+		*field.value = &t
+	}
+	if query.CreatedFrom != nil && query.CreatedTo != nil &&
+		!query.CreatedFrom.Before(*query.CreatedTo) {
+		return fmt.Errorf("parameter 'created_after' must be before " +
+			"'created_before'")
 	}
 
 	return nil
 }
 
-// InitRouter initialize GoFiber router for API
-func (a *API) InitRouter() {
-	a.FiberApp = fiber.New()
+// sendValidationError send bad request response of validation error,
+// field errors are listed in errors so frontend can highlight the
+// invalid fields, other error is sent as message
+func sendValidationError(c *fiber.Ctx, err error) error {
+	var errs validator.Errors
+	var fieldErr validator.FieldError
+	if errors.As(err, &fieldErr) {
+		errs = validator.Errors{fieldErr}
+	} else if !errors.As(err, &errs) {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
 
-	// add middleware CORS and logger to all route
-	a.FiberApp.Use(
-		cors.New(
-			cors.Config{
-				AllowOrigins: fmt.Sprintf("%s,%s",
-					config.FrontendURL, config.AccountServiceURL),
-				AllowHeaders: "Authorization, Origin, Content-Type, Accept",
-			},
-		),
-	)
-	a.FiberApp.Use(logger.New())
+	return c.Status(http.StatusBadRequest).JSON(map[string]validator.Errors{
+		"errors": errs,
+	})
+}
 
-	// create main router group (prefix: "/api") with middleware authorization
-	mainRouter := a.FiberApp.Group("/api", middleware.AuthorizationMiddleware())
+// GetSellerInfo get seller info by user ID with API get user
+// from account service of accounts client
+func GetSellerInfo(ctx context.Context, accounts *account.Client,
+	userID int) (model.SellerInfo, error) {
+	sellerInfo := model.SellerInfo{}
 
-	//// route add product
-	mainRouter.Post("/product/", a.AddProductHandler)
+	// account service not used with mock authentication
+	if config.DevAuth {
+		sellerInfo.FullName = fmt.Sprintf("Dev Seller %d", userID)
+		sellerInfo.Email = fmt.Sprintf("seller%d@localhost", userID)
+		return sellerInfo, nil
+	}
 
-	//// route get products
-	mainRouter.Get("/products/", a.GetProductsHandler)
+	u, err := accounts.GetUser(ctx, userID)
+	if err != nil {
+		return sellerInfo, fmt.Errorf(
+			"There's an error when getting seller info => %s", err.Error())
+	}
 
-	//// route get products by user ID
-	mainRouter.Get("/products/user/", a.GetProductsByUserIDHandler)
+	sellerInfo.Email = u.Email
+	sellerInfo.FullName = u.FullName
+	sellerInfo.Address = u.Address
+	sellerInfo.PhoneNumber = u.PhoneNumber
 
-	//// route get product by sku
-	mainRouter.Get("/product/", a.GetProductHandler)
+	return sellerInfo, nil
+}
 
-	//// route update product by sku
-	mainRouter.Put("/product/", a.UpdateProductHandler)
+// GetCachedProductBySKU get product by SKU from cache,
+// or from database then cache it on cache miss
+//
+// cache failure is only logged so the database is still used
+func (a *API) GetCachedProductBySKU(ctx context.Context, SKU string) (
+	model.Product, error) {
+	if a.Cache != nil {
+		p, ok, err := a.Cache.Get(SKU)
+		if err != nil {
+			log.Printf("There's an error when getting cached product "+
+				"of SKU %s => %s", SKU, err.Error())
+		} else if ok {
+			// effective price may change since the product cached
+			p.ProductInfo.EffectivePrice = p.ProductInfo.GetEffectivePrice(
+				time.Now())
+			return p, nil
+		}
+	}
 
-	//// route delete product by sku
-	mainRouter.Delete("/product/", a.DeleteProductHandler)
+	// product to be cached is read from primary database, otherwise
+	// read replica lag would be cached for the whole cache TTL
+	readCtx := ctx
+	if _, ok := a.Cache.(cache.NopCache); a.Cache != nil && !ok {
+		readCtx = model.ReadFromPrimary(ctx)
+	}
+	p, err := a.Repo.GetProductBySKU(readCtx, SKU)
+	if err != nil {
+		return p, err
+	}
 
-	//// route decrease product stock by sku
-	mainRouter.Put("/product/decrease/stock/", a.DecreaseStockHandler)
+	if a.Cache != nil {
+		err = a.Cache.Set(p)
+		if err != nil {
+			log.Printf("There's an error when caching product "+
+				"of SKU %s => %s", SKU, err.Error())
+		}
+	}
 
-	// route static media
-	a.FiberApp.Static("/media", "./../media")
+	return p, nil
 }
 
-// AddProductHandler handling route add product (method: POST, user: seller)
-func (a *API) AddProductHandler(c *fiber.Ctx) error {
+// UpdateProductHandler handling route update product, uploaded images
+// replace the product images, or are appended after them if form value
+// 'image_mode' is 'append' or form values 'keep_image_ids' are given,
+// in which case product images not kept are removed first
+// (method: PUT, user: seller)
+func (a *API) UpdateProductHandler(c *fiber.Ctx) error {
 	// get user data
 	tmpU := c.Locals("user")
 	u, ok := tmpU.(middleware.User)
@@ -127,8 +1217,8 @@ func (a *API) AddProductHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	// check user role is seller
-	if u.Role != "seller" {
+	// check user role is allowed to access this API
+	if !a.isAllowed(u, permission.ProductUpdate) {
 		return c.Status(http.StatusForbidden).JSON(map[string]string{
 			"message": "user doesn't have authority to access this API",
 		})
@@ -138,25 +1228,47 @@ func (a *API) AddProductHandler(c *fiber.Ctx) error {
 	pInfo := model.ProductInfo{}
 	err := c.BodyParser(&pInfo)
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
 			"message": err.Error(),
 		})
 	}
-
-	// validate product info data
-	err = validator.IsProductInfoValid(pInfo)
+	if strings.TrimSpace(c.FormValue("stock")) == "" {
+		return sendValidationError(c, validator.FieldError{
+			Field:   "stock",
+			Code:    validator.CodeRequired,
+			Message: "stock empty/not found",
+		})
+	}
+	err = parseSaleSchedule(c, &pInfo)
 	if err != nil {
+		return sendValidationError(c, err)
+	}
+
+	// get SKU from url
+	SKU := c.Query("sku")
+	if strings.TrimSpace(SKU) == "" {
 		return c.Status(http.StatusBadRequest).JSON(map[string]string{
-			"message": err.Error(),
+			"message": "parameter 'sku' empty/not found",
 		})
 	}
+	pInfo.SKU = SKU
 
-	// insert product info into database
-	pInfo.UserID = u.ID
-	pInfo, err = model.InsertProductInfo(a.DB, pInfo)
+	// validate product info data
+	err = validator.IsProductInfoValid(pInfo)
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
-			"message": err.Error(),
+		return sendValidationError(c, err)
+	}
+
+	// get image mode, existing images are replaced by default
+	imageMode := c.FormValue("image_mode", model.ImageModeReplace)
+	if imageMode != model.ImageModeReplace &&
+		imageMode != model.ImageModeAppend {
+		return sendValidationError(c, validator.FieldError{
+			Field: "image_mode",
+			Code:  validator.CodeInvalid,
+			Message: fmt.Sprintf("image mode '%s' invalid, must be '%s' "+
+				"or '%s'", imageMode, model.ImageModeReplace,
+				model.ImageModeAppend),
 		})
 	}
 
@@ -168,55 +1280,82 @@ func (a *API) AddProductHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	// insert product images into database and media folder
-	fileHeaders := imageForm.File["product_images"]
-	if len(fileHeaders) > 0 {
-		err = model.InsertProductImages(a.DB, fileHeaders, pInfo)
-		if err != nil {
-			return c.Status(http.StatusInternalServerError).JSON(map[string]string{
-				"message": fmt.Sprintf("Product info data created successfully, "+
-					"but saving product images failed => %s", err.Error()),
+	// get image IDs of kept images if given, uploaded images are
+	// appended after them
+	keepImageIDs, err := parseKeepImageIDs(imageForm.Value["keep_image_ids"])
+	if err != nil {
+		return sendValidationError(c, err)
+	}
+	if keepImageIDs != nil {
+		if c.FormValue("image_mode") == model.ImageModeReplace {
+			return sendValidationError(c, validator.FieldError{
+				Field: "image_mode",
+				Code:  validator.CodeInvalid,
+				Message: "image mode 'replace' invalid with keep_image_ids, " +
+					"uploaded images are appended after kept images",
 			})
 		}
+		imageMode = model.ImageModeAppend
 	}
 
-	return c.Status(http.StatusCreated).JSON(pInfo)
-}
+	// validate product images count and size
+	fileHeaders := imageForm.File["product_images"]
+	err = validator.IsProductImagesValid(fileHeaders,
+		config.MaxProductImages, config.MaxProductImagesSize)
+	if err != nil {
+		return sendValidationError(c, err)
+	}
 
-// GetProductsHandler handling route get products (method: GET, user: buyer)
-func (a *API) GetProductsHandler(c *fiber.Ctx) error {
-	// get user data
-	tmpU := c.Locals("user")
-	u, ok := tmpU.(middleware.User)
-	if !ok {
+	// save product images into media folder
+	images, err := saveProductImages(fileHeaders, u.ID)
+	if errors.Is(err, model.ErrProductImageInvalid) ||
+		errors.Is(err, model.ErrProductImageMalicious) {
+		return sendValidationError(c, validator.FieldError{
+			Field:   "product_images",
+			Code:    validator.CodeInvalid,
+			Message: err.Error(),
+		})
+	} else if err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
-			"message": "user data invalid",
+			"message": fmt.Sprintf("There's an error when saving "+
+				"product images => %s", err.Error()),
 		})
 	}
 
-	// check user role is buyer
-	if u.Role != "buyer" {
-		return c.Status(http.StatusForbidden).JSON(map[string]string{
-			"message": "user doesn't have authority to access this API",
+	// update product info, remove its images not kept, and replace
+	// or append its images if any in database in one transaction
+	pInfo.UserID = u.ID
+	pInfo, err = a.Repo.UpdateProductWithImages(c.UserContext(), pInfo,
+		images, imageMode, keepImageIDs)
+	if errors.Is(err, model.ErrTooManyProductImages) {
+		removeProductImages(images)
+		return sendValidationError(c, validator.FieldError{
+			Field:   "product_images",
+			Code:    validator.CodeTooLarge,
+			Message: err.Error(),
 		})
-	}
-
-	// get products from database
-	products, err := model.GetProducts(a.DB, model.ProductInfo{}, c.Query("search"))
-	if err != nil {
+	} else if errors.Is(err, model.ErrProductImageNotFound) {
+		removeProductImages(images)
+		return sendValidationError(c, validator.FieldError{
+			Field:   "keep_image_ids",
+			Code:    validator.CodeInvalid,
+			Message: err.Error(),
+		})
+	} else if err != nil {
+		removeProductImages(images)
 		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
-			"message": fmt.Sprintf(
-				"There's an error when getting the products data => %s",
-				err.Error()),
+			"message": err.Error(),
 		})
 	}
 
-	return c.Status(http.StatusOK).JSON(products)
+	a.PublishEvent(event.NewEvent(event.ProductUpdated, pInfo.SKU,
+		pInfo.UserID, pInfo))
+
+	return c.Status(http.StatusOK).JSON(pInfo)
 }
 
-// GetProductsByUserIDHandler handling route get products by user ID
-// (method: GET, user: seller)
-func (a *API) GetProductsByUserIDHandler(c *fiber.Ctx) error {
+// DeleteProductHandler handling route delete product (method: DELETE, user: seller)
+func (a *API) DeleteProductHandler(c *fiber.Ctx) error {
 	// get user data
 	tmpU := c.Locals("user")
 	u, ok := tmpU.(middleware.User)
@@ -226,90 +1365,103 @@ func (a *API) GetProductsByUserIDHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	// check user role is seller
-	if u.Role != "seller" {
+	// check user role is allowed to access this API
+	if !a.isAllowed(u, permission.ProductDelete) {
 		return c.Status(http.StatusForbidden).JSON(map[string]string{
 			"message": "user doesn't have authority to access this API",
 		})
 	}
 
-	// get products by user id from database
-	products, err := model.GetProducts(a.DB, model.ProductInfo{UserID: u.ID},
-		c.Query("search"))
+	// get SKU from url
+	SKU := c.Query("sku")
+	if strings.TrimSpace(SKU) == "" {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": "parameter 'sku' empty/not found",
+		})
+	}
+
+	// delete product by SKU in database
+	err := a.Repo.DeleteProductBySKU(c.UserContext(), SKU)
 	if err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
-			"message": fmt.Sprintf(
-				"There's an error when getting the products data => %s",
-				err.Error()),
+			"message": err.Error(),
 		})
 	}
 
-	return c.Status(http.StatusOK).JSON(products)
+	a.PublishEvent(event.NewEvent(event.ProductDeleted, SKU, u.ID, nil))
+
+	return c.Status(http.StatusOK).JSON(map[string]string{
+		"message": "Delete product success!",
+	})
 }
 
-// GetProductHandler handling route get one product by SKU
-// (method: GET, user: all)
-func (a *API) GetProductHandler(c *fiber.Ctx) error {
+// SetProductVisibilityHandler handling route hide or show product by SKU
+// without editing it (method: PUT, user: seller owning the product)
+func (a *API) SetProductVisibilityHandler(c *fiber.Ctx) error {
 	// get user data
 	tmpU := c.Locals("user")
-	_, ok := tmpU.(middleware.User)
+	u, ok := tmpU.(middleware.User)
 	if !ok {
 		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
 			"message": "user data invalid",
 		})
 	}
 
-	// get SKU from url
+	// check user role is allowed to access this API
+	if !a.isAllowed(u, permission.ProductUpdate) {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this API",
+		})
+	}
+
+	// get SKU and visibility from url
 	SKU := c.Query("sku")
 	if strings.TrimSpace(SKU) == "" {
 		return c.Status(http.StatusBadRequest).JSON(map[string]string{
 			"message": "parameter 'sku' empty/not found",
 		})
 	}
-
-	// get product by sku from database
-	p, err := model.GetProductBySKU(a.DB, SKU)
+	hidden, err := strconv.ParseBool(c.Query("hidden"))
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
-			"message": fmt.Sprintf(
-				"There's an error when getting the product data => %s",
-				err.Error()),
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": "parameter 'hidden' empty/invalid, must be true or false",
 		})
 	}
 
-	// set seller info with API get user from account service
-	if c.Query("testing") != "1" {
-		resp, err := http.Get(config.AccountServiceURL + "/api/user/?id=" +
-			strconv.Itoa(p.ProductInfo.UserID))
-		if err != nil {
-			return c.Status(http.StatusInternalServerError).JSON(map[string]string{
-				"message": fmt.Sprintf(
-					"There's an error when getting seller info => %s",
-					err.Error()),
-			})
-		}
-		if resp.StatusCode != http.StatusOK {
-			return c.Status(http.StatusInternalServerError).JSON(map[string]string{
-				"message": fmt.Sprintf(
-					"Status code invalid when getting seller info  => %d",
-					resp.StatusCode),
-			})
-		}
-		err = json.NewDecoder(resp.Body).Decode(&p.SellerInfo)
-		if err != nil {
-			return c.Status(http.StatusInternalServerError).JSON(map[string]string{
-				"message": fmt.Sprintf(
-					"There's an error when decoding seller info => %s",
-					err.Error()),
-			})
-		}
+	// product can't be published until its seller verified
+	if !hidden && !u.IsVerified() {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "seller not verified, product can't be published " +
+				"until verification",
+		})
 	}
 
-	return c.Status(http.StatusOK).JSON(p)
+	// set product visibility by SKU in database
+	pInfo, err := a.Repo.SetProductVisibilityBySKU(c.UserContext(), SKU, u.ID,
+		hidden)
+	if err == sql.ErrNoRows {
+		return c.Status(http.StatusNotFound).JSON(map[string]string{
+			"message": "product not found",
+		})
+	} else if errors.Is(err, model.ErrProductTakenDown) {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "product taken down by admin, product can't be published",
+		})
+	} else if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	a.PublishEvent(event.NewEvent(event.ProductUpdated, SKU, pInfo.UserID,
+		pInfo))
+
+	return c.Status(http.StatusOK).JSON(pInfo)
 }
 
-// UpdateProductHandler handling route update product (method: PUT, user: seller)
-func (a *API) UpdateProductHandler(c *fiber.Ctx) error {
+// RestoreProductHandler handling route restore deleted product by SKU
+// (method: PUT, user: seller owning the product, admin)
+func (a *API) RestoreProductHandler(c *fiber.Ctx) error {
 	// get user data
 	tmpU := c.Locals("user")
 	u, ok := tmpU.(middleware.User)
@@ -319,30 +1471,17 @@ func (a *API) UpdateProductHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	// check user role is seller
-	if u.Role != "seller" {
+	// check user role is allowed to restore any product,
+	// or only their own products
+	userID := u.ID
+	if a.isAllowed(u, permission.ProductRestoreAny) {
+		userID = 0
+	} else if !a.isAllowed(u, permission.ProductRestore) {
 		return c.Status(http.StatusForbidden).JSON(map[string]string{
 			"message": "user doesn't have authority to access this API",
 		})
 	}
 
-	// parse product info from form data
-	pInfo := model.ProductInfo{}
-	err := c.BodyParser(&pInfo)
-	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
-			"message": err.Error(),
-		})
-	}
-
-	// validate product info data
-	err = validator.IsProductInfoValid(pInfo)
-	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(map[string]string{
-			"message": err.Error(),
-		})
-	}
-
 	// get SKU from url
 	SKU := c.Query("sku")
 	if strings.TrimSpace(SKU) == "" {
@@ -351,41 +1490,30 @@ func (a *API) UpdateProductHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	// update product info in database
-	pInfo.UserID = u.ID
-	pInfo.SKU = SKU
-	pInfo, err = model.UpdateProductInfoBySKU(a.DB, pInfo)
-	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
-			"message": err.Error(),
+	// restore product by SKU in database
+	pInfo, err := a.Repo.RestoreProductBySKU(c.UserContext(), SKU, userID)
+	if err == sql.ErrNoRows {
+		return c.Status(http.StatusNotFound).JSON(map[string]string{
+			"message": "deleted product not found",
 		})
-	}
-
-	// get image form (multi images)
-	imageForm, err := c.MultipartForm()
-	if err != nil {
+	} else if err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
 			"message": err.Error(),
 		})
 	}
 
-	// update product images in database and media folder
-	fileHeaders := imageForm.File["product_images"]
-	if len(fileHeaders) > 0 {
-		err = model.InsertProductImages(a.DB, fileHeaders, pInfo)
-		if err != nil {
-			return c.Status(http.StatusInternalServerError).JSON(map[string]string{
-				"message": fmt.Sprintf("Product info data updated successfully, "+
-					"but update product images failed => %s", err.Error()),
-			})
-		}
-	}
+	a.PublishEvent(event.NewEvent(event.ProductRestored, SKU, pInfo.UserID, nil))
 
-	return c.Status(http.StatusOK).JSON(pInfo)
+	return c.Status(http.StatusOK).JSON(map[string]interface{}{
+		"message":      "Restore product success!",
+		"product_info": pInfo,
+	})
 }
 
-// DeleteProductHandler handling route delete product (method: DELETE, user: seller)
-func (a *API) DeleteProductHandler(c *fiber.Ctx) error {
+// GetDeletedProductsHandler handling route get soft deleted products,
+// filtered by creation date range of url parameters 'created_after' and
+// 'created_before' (method: GET, user: seller for their own products, admin for all)
+func (a *API) GetDeletedProductsHandler(c *fiber.Ctx) error {
 	// get user data
 	tmpU := c.Locals("user")
 	u, ok := tmpU.(middleware.User)
@@ -395,35 +1523,36 @@ func (a *API) DeleteProductHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	// check user role is seller
-	if u.Role != "seller" {
+	// check user role is allowed to get any deleted product,
+	// or only their own deleted products
+	query := model.ProductQuery{
+		Search:  c.Query("search"),
+		Deleted: true,
+	}
+	if a.isAllowed(u, permission.ProductListDeletedAny) {
+		query.UserID = 0
+	} else if a.isAllowed(u, permission.ProductListDeleted) {
+		query.UserID = u.ID
+	} else {
 		return c.Status(http.StatusForbidden).JSON(map[string]string{
 			"message": "user doesn't have authority to access this API",
 		})
 	}
 
-	// get SKU from url
-	SKU := c.Query("sku")
-	if strings.TrimSpace(SKU) == "" {
-		return c.Status(http.StatusBadRequest).JSON(map[string]string{
-			"message": "parameter 'sku' empty/not found",
-		})
-	}
-
-	// delete product by SKU in database
-	err := model.DeleteProductBySKU(a.DB, SKU)
+	// get creation date range filter from url
+	err := parseCreatedRange(c, &query)
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
 			"message": err.Error(),
 		})
 	}
 
-	return c.Status(http.StatusOK).JSON(map[string]string{
-		"message": "Delete product success!",
-	})
+	// get deleted products from database
+	return a.sendProducts(c, query)
 }
 
-// DecreaseStockHandler handling route decrease product stock (method: PUT, user: seller)
+// DecreaseStockHandler handling route decrease product stock
+// (method: PUT, user: seller or service)
 func (a *API) DecreaseStockHandler(c *fiber.Ctx) error {
 	// get user data
 	tmpU := c.Locals("user")
@@ -434,16 +1563,17 @@ func (a *API) DecreaseStockHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	// check user role is seller
-	if u.Role != "seller" {
+	// check user role is allowed to access this API
+	if !a.isAllowed(u, permission.StockDecrease) {
 		return c.Status(http.StatusForbidden).JSON(map[string]string{
 			"message": "user doesn't have authority to access this API",
 		})
 	}
 
-	// parse order quantity from form data
+	// parse order quantity and related order ID from form data
 	type OrderQty struct {
-		Qty int `form:"qty"`
+		Qty     float64 `form:"qty"`
+		OrderID string  `form:"order_id"`
 	}
 	oQty := OrderQty{}
 	err := c.BodyParser(&oQty)
@@ -453,6 +1583,12 @@ func (a *API) DecreaseStockHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	if oQty.Qty <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": "qty must be greater than zero",
+		})
+	}
+
 	// get SKU from url
 	SKU := c.Query("sku")
 	if strings.TrimSpace(SKU) == "" {
@@ -461,23 +1597,46 @@ func (a *API) DecreaseStockHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	// get product data
-	p, err := model.GetProductBySKU(a.DB, SKU)
-	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
-			"message": err.Error(),
+	// decrease product stock atomically
+	pInfo, err := a.Repo.DecreaseStockBySKU(c.UserContext(), SKU, oQty.Qty,
+		model.StockMovement{
+			Reason:  model.StockReasonDecreased,
+			OrderID: oQty.OrderID,
+			UserID:  u.ID,
 		})
-	}
-
-	// update product stock
-	p.ProductInfo.Stock -= oQty.Qty
-	_, err = model.UpdateProductInfoBySKU(a.DB, p.ProductInfo)
-	if err != nil {
+	if err == sql.ErrNoRows {
+		return c.Status(http.StatusNotFound).JSON(map[string]string{
+			"message": "product not found",
+		})
+	} else if errors.Is(err, model.ErrInsufficientStock) {
+		return c.Status(http.StatusConflict).JSON(map[string]string{
+			"message": "product stock insufficient",
+		})
+	} else if errors.Is(err, model.ErrQuantityInvalid) {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": fmt.Sprintf("qty must be whole number for unit %s",
+				pInfo.Unit),
+		})
+	} else if errors.Is(err, model.ErrOrderQtyNotAllowed) {
+		message := fmt.Sprintf("qty must be at least %v", pInfo.MinOrderQty)
+		if oQty.Qty > pInfo.MinOrderQty {
+			message = fmt.Sprintf("qty must be at most %v", pInfo.MaxOrderQty)
+		}
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": message,
+		})
+	} else if err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
 			"message": err.Error(),
 		})
 	}
 
+	a.PublishEvent(event.NewEvent(event.StockChanged, SKU,
+		pInfo.UserID, event.StockChangedPayload{
+			Stock: pInfo.Stock,
+			Delta: -oQty.Qty,
+		}))
+
 	return c.Status(http.StatusOK).JSON(map[string]string{
 		"message": "Product stock updated!",
 	})
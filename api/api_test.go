@@ -5,6 +5,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"image"
@@ -95,12 +96,7 @@ func TestMain(m *testing.M) {
 func TestInitDB(t *testing.T) {
 	a := API{}
 
-	DBConfig := map[string]string{
-		"user":     config.DBUsername,
-		"password": config.DBPassword,
-		"dbname":   config.DBName,
-	}
-	err := a.InitDB(DBConfig)
+	err := a.InitDB(config.GetDBConfig(config.DBName))
 	if err != nil {
 		t.Errorf("Expected database connection success,"+
 			" but connection failed => %s", err.Error())
@@ -113,9 +109,9 @@ func TestAddProductHandler(t *testing.T) {
 	testTable := []struct {
 		TestName       string
 		FormData       map[string]string
-		ProductPrice   float64
+		ProductPrice   model.Money
 		ProductWeight  float32
-		ProductStock   int
+		ProductStock   float64
 		User           middleware.User
 		ExpectedStatus int
 	}{
@@ -128,7 +124,7 @@ func TestAddProductHandler(t *testing.T) {
 				"description": "Product description",
 				"stock":       "100",
 			},
-			ProductPrice:  1000000.50,
+			ProductPrice:  100000050,
 			ProductWeight: 1.5,
 			ProductStock:  100,
 			User: middleware.User{
@@ -296,7 +292,7 @@ func TestAddProductHandler(t *testing.T) {
 						test.TestName, test.FormData["name"], respPInfo.Name)
 				}
 				if test.ProductPrice != respPInfo.Price {
-					t.Errorf("[%s] Expected price %f, but got price %f",
+					t.Errorf("[%s] Expected price %s, but got price %s",
 						test.TestName, test.ProductPrice, respPInfo.Price)
 				}
 				if test.ProductWeight != respPInfo.Weight {
@@ -308,7 +304,7 @@ func TestAddProductHandler(t *testing.T) {
 						test.TestName, test.FormData["description"], respPInfo.Description)
 				}
 				if test.ProductStock != respPInfo.Stock {
-					t.Errorf("[%s] Expected Stock %d, but got Stock %d",
+					t.Errorf("[%s] Expected Stock %v, but got Stock %v",
 						test.TestName, test.ProductStock, respPInfo.Stock)
 				}
 				if test.User.ID != respPInfo.UserID {
@@ -383,7 +379,7 @@ func TestGetProductsHandler(t *testing.T) {
 		{
 			ProductInfo: model.ProductInfo{
 				Name:        "PRODUCT A",
-				Price:       1200000.55,
+				Price:       120000055,
 				Weight:      1.5,
 				Description: "Description PRODUCT A",
 				Stock:       100,
@@ -404,7 +400,7 @@ func TestGetProductsHandler(t *testing.T) {
 		{
 			ProductInfo: model.ProductInfo{
 				Name:        "PRODUCT B",
-				Price:       1200000.55,
+				Price:       120000055,
 				Weight:      1.5,
 				Description: "Description PRODUCT B",
 				Stock:       100,
@@ -419,7 +415,7 @@ func TestGetProductsHandler(t *testing.T) {
 		{
 			ProductInfo: model.ProductInfo{
 				Name:        "PRODUCT B",
-				Price:       1200000.55,
+				Price:       120000055,
 				Weight:      1.5,
 				Description: "Description PRODUCT B",
 				Stock:       100,
@@ -432,7 +428,8 @@ func TestGetProductsHandler(t *testing.T) {
 	// loop products
 	for i := range sop {
 		// insert product info into database
-		sop[i].ProductInfo, err = model.InsertProductInfo(a.DB, sop[i].ProductInfo)
+		sop[i].ProductInfo, err = model.InsertProductInfo(context.Background(),
+			a.DB, sop[i].ProductInfo)
 		if err != nil {
 			t.Errorf("There's an error when insert data product info => %s",
 				err.Error())
@@ -608,7 +605,7 @@ func TestGetProductsByUserIDHandler(t *testing.T) {
 		{
 			ProductInfo: model.ProductInfo{
 				Name:        "PRODUCT A",
-				Price:       1200000.55,
+				Price:       120000055,
 				Weight:      1.5,
 				Description: "Description PRODUCT A",
 				Stock:       100,
@@ -629,7 +626,7 @@ func TestGetProductsByUserIDHandler(t *testing.T) {
 		{
 			ProductInfo: model.ProductInfo{
 				Name:        "PRODUCT B",
-				Price:       1200000.55,
+				Price:       120000055,
 				Weight:      1.5,
 				Description: "Description PRODUCT B",
 				Stock:       100,
@@ -644,7 +641,7 @@ func TestGetProductsByUserIDHandler(t *testing.T) {
 		{
 			ProductInfo: model.ProductInfo{
 				Name:        "PRODUCT B",
-				Price:       1200000.55,
+				Price:       120000055,
 				Weight:      1.5,
 				Description: "Description PRODUCT B",
 				Stock:       100,
@@ -657,7 +654,8 @@ func TestGetProductsByUserIDHandler(t *testing.T) {
 	// loop products
 	for i := range sop {
 		// insert product info into database
-		sop[i].ProductInfo, err = model.InsertProductInfo(a.DB, sop[i].ProductInfo)
+		sop[i].ProductInfo, err = model.InsertProductInfo(context.Background(),
+			a.DB, sop[i].ProductInfo)
 		if err != nil {
 			t.Errorf("There's an error when insert data product info => %s",
 				err.Error())
@@ -833,7 +831,7 @@ func TestGetProductHandler(t *testing.T) {
 		{
 			ProductInfo: model.ProductInfo{
 				Name:        "PRODUCT A",
-				Price:       1200000.55,
+				Price:       120000055,
 				Weight:      1.5,
 				Description: "Description PRODUCT A",
 				Stock:       100,
@@ -854,7 +852,7 @@ func TestGetProductHandler(t *testing.T) {
 		{
 			ProductInfo: model.ProductInfo{
 				Name:        "PRODUCT B",
-				Price:       1200000.55,
+				Price:       120000055,
 				Weight:      1.5,
 				Description: "Description PRODUCT B",
 				Stock:       100,
@@ -869,7 +867,7 @@ func TestGetProductHandler(t *testing.T) {
 		{
 			ProductInfo: model.ProductInfo{
 				Name:        "PRODUCT B",
-				Price:       1200000.55,
+				Price:       120000055,
 				Weight:      1.5,
 				Description: "Description PRODUCT B",
 				Stock:       100,
@@ -882,7 +880,8 @@ func TestGetProductHandler(t *testing.T) {
 	// loop products
 	for i := range sop {
 		// insert product info into database
-		sop[i].ProductInfo, err = model.InsertProductInfo(a.DB, sop[i].ProductInfo)
+		sop[i].ProductInfo, err = model.InsertProductInfo(context.Background(),
+			a.DB, sop[i].ProductInfo)
 		if err != nil {
 			t.Errorf("There's an error when insert data product info => %s",
 				err.Error())
@@ -1004,7 +1003,7 @@ func TestGetProductHandler(t *testing.T) {
 					test.ExpectedData.ProductInfo.Name, pResult.ProductInfo.Name)
 			}
 			if test.ExpectedData.ProductInfo.Price != pResult.ProductInfo.Price {
-				t.Errorf("Expected Price %f, but got Price %f",
+				t.Errorf("Expected Price %s, but got Price %s",
 					test.ExpectedData.ProductInfo.Price, pResult.ProductInfo.Price)
 			}
 			if test.ExpectedData.ProductInfo.Weight != pResult.ProductInfo.Weight {
@@ -1016,7 +1015,7 @@ func TestGetProductHandler(t *testing.T) {
 					test.ExpectedData.ProductInfo.Description, pResult.ProductInfo.Description)
 			}
 			if test.ExpectedData.ProductInfo.Stock != pResult.ProductInfo.Stock {
-				t.Errorf("Expected Stock %d, but got Stock %d",
+				t.Errorf("Expected Stock %v, but got Stock %v",
 					test.ExpectedData.ProductInfo.Stock, pResult.ProductInfo.Stock)
 			}
 			if test.ExpectedData.ProductInfo.UserID != pResult.ProductInfo.UserID {
@@ -1065,9 +1064,9 @@ func TestUpdateProductHandler(t *testing.T) {
 		User              middleware.User
 		FormData          map[string]string
 		FormDataUpdate    map[string]string
-		PriceAfterUpdate  float64
+		PriceAfterUpdate  model.Money
 		WeightAfterUpdate float32
-		StockAfterUpdate  int
+		StockAfterUpdate  float64
 		ExpectedStatus    int
 	}{
 		{
@@ -1090,7 +1089,7 @@ func TestUpdateProductHandler(t *testing.T) {
 				"description": "After Update",
 				"stock":       "200",
 			},
-			PriceAfterUpdate:  2000000.50,
+			PriceAfterUpdate:  200000050,
 			WeightAfterUpdate: 2.5,
 			StockAfterUpdate:  200,
 			ExpectedStatus:    http.StatusOK,
@@ -1115,7 +1114,7 @@ func TestUpdateProductHandler(t *testing.T) {
 				"description": "After Update",
 				"stock":       "200",
 			},
-			PriceAfterUpdate:  2000000.50,
+			PriceAfterUpdate:  200000050,
 			WeightAfterUpdate: 2.5,
 			StockAfterUpdate:  200,
 			ExpectedStatus:    http.StatusForbidden,
@@ -1140,7 +1139,7 @@ func TestUpdateProductHandler(t *testing.T) {
 				"description": "After Update",
 				"stock":       "200",
 			},
-			PriceAfterUpdate:  2000000.50,
+			PriceAfterUpdate:  200000050,
 			WeightAfterUpdate: 2.5,
 			StockAfterUpdate:  200,
 			ExpectedStatus:    http.StatusBadRequest,
@@ -1165,7 +1164,7 @@ func TestUpdateProductHandler(t *testing.T) {
 				"description": "After Update",
 				"stock":       "200",
 			},
-			PriceAfterUpdate:  2000000.50,
+			PriceAfterUpdate:  200000050,
 			WeightAfterUpdate: 2.5,
 			StockAfterUpdate:  200,
 			ExpectedStatus:    http.StatusBadRequest,
@@ -1190,7 +1189,7 @@ func TestUpdateProductHandler(t *testing.T) {
 				"description": "After Update",
 				"stock":       "200",
 			},
-			PriceAfterUpdate:  2000000.50,
+			PriceAfterUpdate:  200000050,
 			WeightAfterUpdate: 2.5,
 			StockAfterUpdate:  200,
 			ExpectedStatus:    http.StatusBadRequest,
@@ -1439,7 +1438,7 @@ func TestUpdateProductHandler(t *testing.T) {
 							pResult.ProductInfo.Name)
 					}
 					if test.PriceAfterUpdate != pResult.ProductInfo.Price {
-						t.Errorf("Expected Price %f, but got Price %f",
+						t.Errorf("Expected Price %s, but got Price %s",
 							test.PriceAfterUpdate, pResult.ProductInfo.Price)
 					}
 					if test.WeightAfterUpdate != pResult.ProductInfo.Weight {
@@ -1453,7 +1452,7 @@ func TestUpdateProductHandler(t *testing.T) {
 							pResult.ProductInfo.Description)
 					}
 					if test.StockAfterUpdate != pResult.ProductInfo.Stock {
-						t.Errorf("Expected Stock %d, but got Stock %d",
+						t.Errorf("Expected Stock %v, but got Stock %v",
 							test.StockAfterUpdate, pResult.ProductInfo.Stock)
 					}
 
@@ -1497,7 +1496,7 @@ func TestDeleteProductHandler(t *testing.T) {
 		{
 			ProductInfo: model.ProductInfo{
 				Name:        "PRODUCT A",
-				Price:       1200000.55,
+				Price:       120000055,
 				Weight:      1.5,
 				Description: "Description PRODUCT A",
 				Stock:       100,
@@ -1518,7 +1517,7 @@ func TestDeleteProductHandler(t *testing.T) {
 		{
 			ProductInfo: model.ProductInfo{
 				Name:        "PRODUCT B",
-				Price:       1200000.55,
+				Price:       120000055,
 				Weight:      1.5,
 				Description: "Description PRODUCT B",
 				Stock:       100,
@@ -1533,7 +1532,7 @@ func TestDeleteProductHandler(t *testing.T) {
 		{
 			ProductInfo: model.ProductInfo{
 				Name:        "PRODUCT B",
-				Price:       1200000.55,
+				Price:       120000055,
 				Weight:      1.5,
 				Description: "Description PRODUCT B",
 				Stock:       100,
@@ -1546,7 +1545,8 @@ func TestDeleteProductHandler(t *testing.T) {
 	// loop products
 	for i := range sop {
 		// insert product info into database
-		sop[i].ProductInfo, err = model.InsertProductInfo(a.DB, sop[i].ProductInfo)
+		sop[i].ProductInfo, err = model.InsertProductInfo(context.Background(),
+			a.DB, sop[i].ProductInfo)
 		if err != nil {
 			t.Errorf("There's an error when insert data product info => %s",
 				err.Error())
@@ -1767,7 +1767,7 @@ func TestDecreaseStockHandler(t *testing.T) {
 		User             middleware.User
 		FormData         map[string]string
 		FormDataUpdate   map[string]string
-		StockAfterUpdate int
+		StockAfterUpdate float64
 		ExpectedStatus   int
 	}{
 		{
@@ -1807,6 +1807,42 @@ func TestDecreaseStockHandler(t *testing.T) {
 			},
 			ExpectedStatus: http.StatusForbidden,
 		},
+		{
+			TestName: "Test Decrease Stock Insufficient",
+			User: middleware.User{
+				ID:   1,
+				Role: "seller",
+			},
+			FormData: map[string]string{
+				"name":        "Before Update",
+				"price":       "1000000.50",
+				"weight":      "1.5",
+				"description": "Before Update",
+				"stock":       "100",
+			},
+			FormDataUpdate: map[string]string{
+				"qty": "101",
+			},
+			ExpectedStatus: http.StatusConflict,
+		},
+		{
+			TestName: "Test Decrease Stock Bad Request",
+			User: middleware.User{
+				ID:   1,
+				Role: "seller",
+			},
+			FormData: map[string]string{
+				"name":        "Before Update",
+				"price":       "1000000.50",
+				"weight":      "1.5",
+				"description": "Before Update",
+				"stock":       "100",
+			},
+			FormDataUpdate: map[string]string{
+				"qty": "0",
+			},
+			ExpectedStatus: http.StatusBadRequest,
+		},
 	}
 
 	// loop test in test table
@@ -2008,7 +2044,7 @@ func TestDecreaseStockHandler(t *testing.T) {
 							respPInfo.SKU, pResult.ProductInfo.SKU)
 					}
 					if test.StockAfterUpdate != pResult.ProductInfo.Stock {
-						t.Errorf("Expected Stock %d, but got Stock %d",
+						t.Errorf("Expected Stock %v, but got Stock %v",
 							test.StockAfterUpdate, pResult.ProductInfo.Stock)
 					}
 
@@ -2032,35 +2068,180 @@ func TestDecreaseStockHandler(t *testing.T) {
 	}
 }
 
+// TestRestoreProductHandler test GetDeletedProductsHandler
+// and RestoreProductHandler
+func TestRestoreProductHandler(t *testing.T) {
+	a, err := GetTestingAPI(middleware.User{ID: 1, Role: "seller"})
+	if err != nil {
+		t.Errorf("There's an error when getting testing API => %s",
+			err.Error())
+	}
+
+	// insert and soft delete product
+	pInfo, err := model.InsertProductInfo(context.Background(), a.DB,
+		model.ProductInfo{
+			Name:        "AAA",
+			Price:       10000000,
+			Weight:      1.5,
+			Description: "BBB",
+			Stock:       100,
+			UserID:      1,
+		})
+	if err != nil {
+		t.Errorf("There's an error when creating product data => %s",
+			err.Error())
+	}
+	err = model.DeleteProductBySKU(context.Background(), a.DB, pInfo.SKU)
+	if err != nil {
+		t.Errorf("There's an error when deleting product data => %s",
+			err.Error())
+	}
+
+	// get deleted products
+	req, _ := http.NewRequest("GET", "/api/products/deleted/", nil)
+	response, err := a.FiberApp.Test(req)
+	if err != nil {
+		t.Errorf("There's an error serve http testing => %s", err.Error())
+	}
+	defer response.Body.Close()
+
+	products := []model.Product{}
+	err = json.NewDecoder(response.Body).Decode(&products)
+	if err != nil {
+		t.Errorf("There's an error when unmarshal body response => %s",
+			err.Error())
+	}
+	if len(products) != 1 || products[0].ProductInfo.SKU != pInfo.SKU ||
+		products[0].ProductInfo.DeletedAt == nil {
+		t.Errorf("Expected one deleted product with SKU %s, but got %v",
+			pInfo.SKU, products)
+	}
+
+	// restore product twice
+	for _, expectedStatus := range []int{http.StatusOK, http.StatusNotFound} {
+		req, _ = http.NewRequest("PUT",
+			fmt.Sprintf("/api/product/restore/?sku=%s", pInfo.SKU), nil)
+		response, err = a.FiberApp.Test(req)
+		if err != nil {
+			t.Errorf("There's an error serve http testing => %s", err.Error())
+		}
+		defer response.Body.Close()
+
+		if response.StatusCode != expectedStatus {
+			t.Errorf("Expected status %d got %d",
+				expectedStatus, response.StatusCode)
+		}
+	}
+
+	// check restored product can be get again
+	_, err = model.GetProductBySKU(context.Background(), a.DB, pInfo.SKU)
+	if err != nil {
+		t.Errorf("Expected restored product found, but got error => %s",
+			err.Error())
+	}
+
+	// truncate tables after test
+	_, err = a.DB.Exec("TRUNCATE product_productinfo RESTART IDENTITY CASCADE")
+	if err != nil {
+		log.Fatalf("There's an error when truncating "+
+			"table product_productinfo => %s",
+			err.Error())
+	}
+}
+
 // GetTestingAPI get API for testing
 func GetTestingAPI(u middleware.User) (API, error) {
 	a := API{}
 
 	// init database
-	DBConfig := map[string]string{
-		"user":     config.DBUsername,
-		"password": config.DBPassword,
-		"dbname":   config.DBNameForAPITest,
-	}
-	err := a.InitDB(DBConfig)
+	err := a.InitDB(config.GetDBConfig(config.DBNameForAPITest))
 	if err != nil {
 		return a, err
 	}
 
 	// init router
-	a.FiberApp = fiber.New()
+	a.FiberApp = fiber.New(GetFiberConfig())
+	a.FiberApp.Get("/api/products/stream/", a.StreamProductsHandler)
+	a.FiberApp.Get("/api/feeds/google-merchant.xml",
+		a.GetGoogleMerchantFeedHandler)
+	a.FiberApp.Get("/api/products/export-jobs/:id/download/",
+		a.DownloadExportJobHandler)
+	a.FiberApp.Delete("/api/products/user/:id/",
+		middleware.ServiceAuthorizationMiddleware(),
+		a.DeleteUserProductsHandler)
+	a.FiberApp.Put("/api/product/rating/",
+		middleware.ServiceAuthorizationMiddleware(),
+		a.SetProductRatingHandler)
+	a.FiberApp.Post("/api/products/popularity/",
+		middleware.ServiceAuthorizationMiddleware(),
+		a.ReportPopularityHandler)
+	a.FiberApp.Get("/api/changes/",
+		middleware.ServiceAuthorizationMiddleware(),
+		a.GetChangesHandler)
 	mainRouter := a.FiberApp.Group("")
 	mainRouter.Use(AuthorizationMiddlewareForTest(u))
+	mainRouter.Use(middleware.IdempotencyMiddleware(a.DB))
 	mainRouter.Post("/api/product/", a.AddProductHandler)
+	mainRouter.Post("/api/products/batch/", a.AddProductsBatchHandler)
+	mainRouter.Post("/api/products/import/", a.ImportProductsHandler)
+	mainRouter.Post("/api/products/import/:platform/", a.ImportCatalogHandler)
 	mainRouter.Get("/api/products/", a.GetProductsHandler)
 	mainRouter.Get("/api/products/user/", a.GetProductsByUserIDHandler)
+	mainRouter.Get("/api/products/user/export/", a.ExportProductsHandler)
+	mainRouter.Post("/api/products/export-jobs/", a.AddExportJobHandler)
+	mainRouter.Get("/api/products/export-jobs/:id/", a.GetExportJobHandler)
+	mainRouter.Get("/api/products/user/inventory/",
+		a.GetSellerInventorySnapshotHandler)
+	mainRouter.Get("/api/products/user/low-stock/", a.GetLowStockProductsHandler)
+	mainRouter.Put("/api/products/stock/batch/", a.BatchUpdateStockHandler)
 	mainRouter.Get("/api/product/", a.GetProductHandler)
+	mainRouter.Get("/api/product/images/zip/", a.GetProductImagesZipHandler)
+	mainRouter.Post("/api/product/view/", a.RecordProductViewHandler)
+	mainRouter.Get("/api/product/barcode/:code/",
+		a.GetProductsByBarcodeHandler)
 	mainRouter.Put("/api/product/", a.UpdateProductHandler)
 	mainRouter.Delete("/api/product/", a.DeleteProductHandler)
+	mainRouter.Put("/api/product/restore/", a.RestoreProductHandler)
+	mainRouter.Put("/api/product/visibility/", a.SetProductVisibilityHandler)
+	mainRouter.Get("/api/products/deleted/", a.GetDeletedProductsHandler)
 	mainRouter.Put("/api/product/decrease/stock/", a.DecreaseStockHandler)
-
-	// change media folder to testing media folder
-	config.MediaFolder = "media-test"
+	mainRouter.Get("/api/product/:sku/stock-history/", a.GetStockHistoryHandler)
+	mainRouter.Get("/api/product/:sku/versions/", a.GetProductVersionsHandler)
+	mainRouter.Put("/api/product/:sku/price-tiers/", a.SetPriceTiersHandler)
+	mainRouter.Get("/api/product/:sku/quote/", a.GetPriceQuoteHandler)
+	mainRouter.Get("/api/product/:sku/translations/",
+		a.GetProductTranslationsHandler)
+	mainRouter.Put("/api/product/:sku/translations/:locale/",
+		a.SetProductTranslationHandler)
+	mainRouter.Delete("/api/product/:sku/translations/:locale/",
+		a.DeleteProductTranslationHandler)
+	mainRouter.Put("/api/product/:sku/rollback/", a.RollbackProductHandler)
+	mainRouter.Get("/api/admin/inventory/snapshot/", a.GetInventorySnapshotHandler)
+	mainRouter.Get("/api/admin/products/", a.GetAdminProductsHandler)
+	mainRouter.Put("/api/admin/products/transfer/", a.TransferProductsHandler)
+	mainRouter.Get("/api/admin/stats/", a.GetMarketplaceStatsHandler)
+	mainRouter.Get("/api/admin/scheduler/", a.GetSchedulerStatsHandler)
+	mainRouter.Get("/api/admin/db/metrics/", a.GetDBMetricsHandler)
+	mainRouter.Get("/api/admin/maintenance/", a.GetMaintenanceHandler)
+	mainRouter.Put("/api/admin/maintenance/", a.SetMaintenanceHandler)
+	mainRouter.Put("/api/shop/", a.SetShopHandler)
+	mainRouter.Get("/api/shop/:slug/", a.GetShopHandler)
+	mainRouter.Get("/api/shop/:slug/products/", a.GetShopProductsHandler)
+	mainRouter.Post("/api/product/report/", a.ReportProductHandler)
+	mainRouter.Get("/api/admin/reports/", a.GetProductReportsHandler)
+	mainRouter.Put("/api/admin/reports/review/",
+		a.ReviewProductReportsHandler)
+	mainRouter.Post("/api/webhooks/", a.AddWebhookSubscriptionHandler)
+	mainRouter.Get("/api/webhooks/", a.GetWebhookSubscriptionsHandler)
+	mainRouter.Delete("/api/webhooks/", a.DeleteWebhookSubscriptionHandler)
+	mainRouter.Get("/graphql/", a.GraphQLHandler)
+	mainRouter.Post("/graphql/", a.GraphQLHandler)
+
+	// change media root to testing media folder
+	config.MediaRoot, err = filepath.Abs("./../media-test")
+	if err != nil {
+		return a, err
+	}
 
 	return a, nil
 }
@@ -2103,3 +2284,40 @@ func AuthorizationMiddlewareForTest(u middleware.User) fiber.Handler {
 		return c.Next()
 	}
 }
+
+// TestErrorHandler test ErrorHandler replying JSON message
+// when request body too large
+func TestErrorHandler(t *testing.T) {
+	bodyLimit := config.BodyLimit
+	config.BodyLimit = 16
+	defer func() { config.BodyLimit = bodyLimit }()
+
+	// the server reply error request entity too large
+	// before any handler when body limit exceeded
+	app := fiber.New(GetFiberConfig())
+	app.Post("/", func(c *fiber.Ctx) error {
+		return fiber.ErrRequestEntityTooLarge
+	})
+
+	req, err := http.NewRequest("POST", "/", nil)
+	if err != nil {
+		t.Fatalf("There's an error when creating request => %s", err.Error())
+	}
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("There's an error serve http testing => %s", err.Error())
+	}
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status code %d, but got %d",
+			http.StatusRequestEntityTooLarge, resp.StatusCode)
+	}
+
+	body := map[string]string{}
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	if err != nil {
+		t.Fatalf("There's an error when decoding response => %s", err.Error())
+	}
+	if body["message"] != "request body too large, maximum 16 bytes" {
+		t.Errorf("Expected body limit message, but got '%s'", body["message"])
+	}
+}
@@ -0,0 +1,166 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/event"
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+	"github.com/reyhanfikridz/ecom-product-service/internal/permission"
+	"github.com/reyhanfikridz/ecom-product-service/internal/validator"
+)
+
+// maximum products in one batch creation
+const maxBatchProducts = 100
+
+// ProductBatchRequestItem contain a product info of batch creation
+// with optional image URLs downloaded as its images
+type ProductBatchRequestItem struct {
+	model.ProductInfo
+	ImageURLs []string `json:"image_urls"`
+}
+
+// ProductBatchResult contain result of a batch creation item,
+// SKU of the created product or error why it's invalid
+type ProductBatchResult struct {
+	Index int    `json:"index"`
+	SKU   string `json:"sku,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// AddProductsBatchHandler handling route add many products
+// in one transaction, or only validate them with url query 'dry_run'
+// true (method: POST, user: seller)
+func (a *API) AddProductsBatchHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// check user role is allowed to access this API
+	if !a.isAllowed(u, permission.ProductCreate) {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this API",
+		})
+	}
+
+	// get dry run from url
+	dryRun, err := parseDryRun(c)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	// parse product infos from JSON body
+	reqItems := []ProductBatchRequestItem{}
+	err = json.Unmarshal(c.Body(), &reqItems)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": "body must be JSON array of product infos",
+		})
+	}
+	if len(reqItems) == 0 || len(reqItems) > maxBatchProducts {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": fmt.Sprintf("products must be between 1 and %d",
+				maxBatchProducts),
+		})
+	}
+
+	// validate product infos then download their images
+	results := make([]ProductBatchResult, len(reqItems))
+	items := make([]model.ProductBatchItem, len(reqItems))
+	failed := false
+	for i, reqItem := range reqItems {
+		results[i].Index = i
+
+		pInfo := reqItem.ProductInfo
+		err = validator.IsProductInfoValid(pInfo)
+		if err != nil {
+			results[i].Error = err.Error()
+			failed = true
+			continue
+		}
+
+		pInfo.UserID = u.ID
+		pInfo.Hidden = !u.IsVerified()
+		items[i].ProductInfo = pInfo
+	}
+
+	// on dry run check image URLs of every valid product without
+	// downloading them, so all errors are reported, then create nothing
+	if dryRun {
+		for i, reqItem := range reqItems {
+			if results[i].Error != "" {
+				continue
+			}
+
+			err = checkProductImageURLs(reqItem.ImageURLs)
+			if err != nil {
+				results[i].Error = err.Error()
+				failed = true
+			}
+		}
+
+		status := http.StatusOK
+		message := fmt.Sprintf("%d products valid, no product created "+
+			"on dry run", len(reqItems))
+		if failed {
+			status = http.StatusUnprocessableEntity
+			message = "No product created => " +
+				model.ErrBatchItemInvalid.Error()
+		}
+
+		return c.Status(status).JSON(map[string]interface{}{
+			"message": message,
+			"dry_run": true,
+			"results": results,
+		})
+	}
+
+	for i, reqItem := range reqItems {
+		if failed {
+			break
+		}
+
+		items[i].ProductImages, err = downloadProductImages(reqItem.ImageURLs,
+			u.ID)
+		if err != nil {
+			results[i].Error = err.Error()
+			failed = true
+		}
+	}
+	if failed {
+		return c.Status(http.StatusUnprocessableEntity).JSON(map[string]interface{}{
+			"message": "No product created => " +
+				model.ErrBatchItemInvalid.Error(),
+			"results": results,
+		})
+	}
+
+	// insert products into database
+	pInfos, err := a.Repo.InsertProducts(c.UserContext(), items)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	for i, pInfo := range pInfos {
+		results[i].SKU = pInfo.SKU
+		a.PublishEvent(event.NewEvent(event.ProductCreated, pInfo.SKU,
+			pInfo.UserID, pInfo))
+	}
+
+	return c.Status(http.StatusCreated).JSON(map[string]interface{}{
+		"message": fmt.Sprintf("%d products created", len(pInfos)),
+		"results": results,
+	})
+}
@@ -0,0 +1,190 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+)
+
+// batchRepository product repository in memory recording
+// products inserted in batch
+type batchRepository struct {
+	model.ProductRepository
+	inserted []model.ProductInfo
+}
+
+// InsertProducts record products in memory
+func (r *batchRepository) InsertProducts(ctx context.Context,
+	items []model.ProductBatchItem) ([]model.ProductInfo, error) {
+	pInfos := []model.ProductInfo{}
+	for _, item := range items {
+		pInfo := item.ProductInfo
+		pInfo.SKU = fmt.Sprintf("SKU-%d", len(r.inserted)+1)
+		r.inserted = append(r.inserted, pInfo)
+		pInfos = append(pInfos, pInfo)
+	}
+
+	return pInfos, nil
+}
+
+// TestAddProductsBatchHandler test AddProductsBatchHandler
+// with product repository in memory
+func TestAddProductsBatchHandler(t *testing.T) {
+	// serve testing image checked on dry run
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/a.png" {
+				http.NotFound(w, r)
+				return
+			}
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte("png"))
+		}))
+	defer server.Close()
+
+	// testing server is on loopback, which image download client refuses
+	client := imageDownloadClient
+	imageDownloadClient = server.Client()
+	defer func() { imageDownloadClient = client }()
+
+	// create testing table
+	unverified := false
+	testTable := []struct {
+		TestName           string
+		Body               string
+		DryRun             bool
+		Verified           *bool
+		ExpectedStatusCode int
+		ExpectedResults    []ProductBatchResult
+		ExpectedInserted   int
+	}{
+		{
+			TestName: "Test Products Valid",
+			Body: `[{"name":"Mouse","price":1000,"weight":0.2,"stock":5},
+				{"name":"Hub","price":2000,"weight":0.1}]`,
+			ExpectedStatusCode: http.StatusCreated,
+			ExpectedResults: []ProductBatchResult{
+				{Index: 0, SKU: "SKU-1"},
+				{Index: 1, SKU: "SKU-2"},
+			},
+			ExpectedInserted: 2,
+		},
+		{
+			TestName:           "Test Unverified Seller Drafts",
+			Body:               `[{"name":"Mouse","price":1000,"weight":0.2}]`,
+			Verified:           &unverified,
+			ExpectedStatusCode: http.StatusCreated,
+			ExpectedResults:    []ProductBatchResult{{Index: 0, SKU: "SKU-1"}},
+			ExpectedInserted:   1,
+		},
+		{
+			TestName: "Test Product Invalid",
+			Body: `[{"name":"Mouse","price":1000,"weight":0.2},
+				{"name":"Hub","weight":0.1},
+				{"name":"Stand","price":1000,"weight":1,"stock":-1}]`,
+			ExpectedStatusCode: http.StatusUnprocessableEntity,
+			ExpectedResults: []ProductBatchResult{
+				{Index: 0},
+				{Index: 1, Error: "price empty/not found"},
+				{Index: 2, Error: "stock can't be negative"},
+			},
+		},
+		{
+			TestName: "Test Dry Run Valid",
+			Body: `[{"name":"Mouse","price":1000,"weight":0.2,
+				"image_urls":["` + server.URL + `/a.png"]}]`,
+			DryRun:             true,
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedResults:    []ProductBatchResult{{Index: 0}},
+		},
+		{
+			TestName: "Test Dry Run Invalid",
+			Body: `[{"name":"Mouse","price":1000,"weight":0.2,
+				"image_urls":["` + server.URL + `/b.png"]},
+				{"name":"Hub","weight":0.1}]`,
+			DryRun:             true,
+			ExpectedStatusCode: http.StatusUnprocessableEntity,
+			ExpectedResults: []ProductBatchResult{
+				{Index: 0, Error: "image URL '" + server.URL +
+					"/b.png' unreachable"},
+				{Index: 1, Error: "price empty/not found"},
+			},
+		},
+		{
+			TestName:           "Test Body Invalid",
+			Body:               `{"name":"Mouse"}`,
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			TestName:           "Test Body Empty Array",
+			Body:               `[]`,
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		repo := &batchRepository{}
+		a := API{Repo: repo, FiberApp: fiber.New()}
+		a.FiberApp.Post("/api/products/batch/",
+			AuthorizationMiddlewareForTest(
+				middleware.User{ID: 3, Role: "seller",
+					Verified: test.Verified}),
+			a.AddProductsBatchHandler)
+
+		req, _ := http.NewRequest("POST", fmt.Sprintf(
+			"/api/products/batch/?dry_run=%t", test.DryRun),
+			strings.NewReader(test.Body))
+		req.Header.Set("Content-Type", "application/json")
+		response, err := a.FiberApp.Test(req)
+		if err != nil {
+			t.Fatalf("[%s] There's an error serve http testing => %s",
+				test.TestName, err.Error())
+		}
+		defer response.Body.Close()
+
+		if response.StatusCode != test.ExpectedStatusCode {
+			t.Errorf("[%s] Expected status %d got %d", test.TestName,
+				test.ExpectedStatusCode, response.StatusCode)
+			continue
+		}
+		if len(repo.inserted) != test.ExpectedInserted {
+			t.Errorf("[%s] Expected %d products inserted, but got %d",
+				test.TestName, test.ExpectedInserted, len(repo.inserted))
+		}
+		for _, pInfo := range repo.inserted {
+			if pInfo.Hidden != (test.Verified != nil && !*test.Verified) {
+				t.Errorf("[%s] Expected only products of unverified seller "+
+					"hidden, but got hidden %t", test.TestName, pInfo.Hidden)
+			}
+		}
+		if test.ExpectedResults == nil {
+			continue
+		}
+
+		body := struct {
+			Results []ProductBatchResult `json:"results"`
+		}{}
+		err = json.NewDecoder(response.Body).Decode(&body)
+		if err != nil {
+			t.Fatalf("[%s] There's an error when decoding response => %s",
+				test.TestName, err.Error())
+		}
+		if !reflect.DeepEqual(body.Results, test.ExpectedResults) {
+			t.Errorf("[%s] Expected results %+v, but got %+v",
+				test.TestName, test.ExpectedResults, body.Results)
+		}
+	}
+}
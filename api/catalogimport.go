@@ -0,0 +1,711 @@
+package api
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/config"
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+	"github.com/reyhanfikridz/ecom-product-service/internal/permission"
+	"github.com/reyhanfikridz/ecom-product-service/internal/richtext"
+	"github.com/reyhanfikridz/ecom-product-service/internal/validator"
+)
+
+// platforms whose product exports can be imported
+const (
+	CatalogShopify     = "shopify"
+	CatalogWooCommerce = "woocommerce"
+)
+
+// weight units of catalog exports in kg, and dimension units in cm
+var (
+	catalogWeightUnits = map[string]float64{
+		"kg": 1, "g": 0.001, "lb": 0.45359237, "lbs": 0.45359237,
+		"oz": 0.028349523125,
+	}
+	catalogDimensionUnits = map[string]float64{
+		"cm": 1, "m": 100, "mm": 0.1, "in": 2.54, "yd": 91.44,
+	}
+)
+
+// ImportCatalogHandler handling route import products of the seller
+// from product export of platform by url param 'platform' (shopify or
+// woocommerce) as CSV or JSON, each product imported in its own
+// transaction with its remote images downloaded, or only validated with
+// url query 'dry_run' true (method: POST, user: seller)
+func (a *API) ImportCatalogHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// check user role is allowed to access this API
+	if !a.isAllowed(u, permission.ProductImport) {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this API",
+		})
+	}
+
+	// get platform and dry run from url
+	platform := c.Params("platform")
+	if platform != CatalogShopify && platform != CatalogWooCommerce {
+		return c.Status(http.StatusNotFound).JSON(map[string]string{
+			"message": fmt.Sprintf("platform '%s' not found, must be "+
+				"'shopify' or 'woocommerce'", platform),
+		})
+	}
+	dryRun, err := parseDryRun(c)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	// get export file from form file 'file', or from body
+	b, err := getImportFile(c)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	// parse products from export file
+	rows, err := ParseCatalogImport(platform, b)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	return a.replyProductImport(c, u, rows, dryRun)
+}
+
+// ParseCatalogImport parse products from product export of platform,
+// JSON if it starts with '{' or '[', otherwise CSV
+//
+// line of JSON products is their position in the export
+func ParseCatalogImport(platform string, b []byte) ([]ProductImportRow,
+	error) {
+	b = bytes.TrimPrefix(b, []byte("\xef\xbb\xbf")) // exported with BOM
+	trimmed := bytes.TrimSpace(b)
+	isJSON := len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[')
+
+	var items []catalogItem
+	var err error
+	switch {
+	case platform == CatalogShopify && isJSON:
+		items, err = parseShopifyJSON(trimmed)
+	case platform == CatalogShopify:
+		items, err = parseShopifyCSV(bytes.NewReader(b))
+	case platform == CatalogWooCommerce && isJSON:
+		items, err = parseWooCommerceJSON(trimmed)
+	case platform == CatalogWooCommerce:
+		items, err = parseWooCommerceCSV(bytes.NewReader(b))
+	default:
+		return nil, fmt.Errorf("platform '%s' invalid", platform)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(items) > maxImportRows {
+		return nil, fmt.Errorf("too many products, maximum %d products",
+			maxImportRows)
+	}
+
+	rows := []ProductImportRow{}
+	for _, item := range items {
+		rows = append(rows, item.toImportRow())
+	}
+
+	return rows, nil
+}
+
+// catalogItem product of a platform export before mapped onto product
+// info, numbers are text as exported, weight and dimensions are
+// multiplied by their scale into kg and cm
+type catalogItem struct {
+	Line           int
+	Name           string
+	Description    string
+	RegularPrice   string
+	SalePrice      string
+	Stock          string
+	Weight         string
+	WeightScale    float64
+	Dimensions     [3]string
+	DimensionScale float64
+	Barcode        string
+	ImageURLs      []string
+}
+
+// toImportRow map catalog item onto import row of product info, sale
+// price not less than regular price is the price, invalid barcode is
+// dropped, and images more than allowed are dropped
+func (item catalogItem) toImportRow() ProductImportRow {
+	row := ProductImportRow{Line: item.Line}
+	row.ProductInfo.Name = strings.TrimSpace(item.Name)
+	row.ProductInfo.Description = strings.TrimSpace(item.Description)
+	if row.ProductInfo.Description != "" {
+		row.ProductInfo.DescriptionFormat = richtext.FormatHTML
+	}
+	if validator.IsBarcodeValid(item.Barcode) == nil {
+		row.ProductInfo.Barcode = item.Barcode
+	}
+
+	regularPrice, err := parseCatalogMoney(item.RegularPrice)
+	if err != nil {
+		row.Err = fmt.Errorf("price '%s' invalid", item.RegularPrice)
+		return row
+	}
+	salePrice, err := parseCatalogMoney(item.SalePrice)
+	if err != nil {
+		row.Err = fmt.Errorf("sale price '%s' invalid", item.SalePrice)
+		return row
+	}
+	row.ProductInfo.Price = regularPrice
+	row.ProductInfo.SalePrice = salePrice
+	if salePrice > 0 && (regularPrice == 0 || salePrice >= regularPrice) {
+		row.ProductInfo.Price = salePrice
+		row.ProductInfo.SalePrice = 0
+	}
+
+	stock, err := parseCatalogNumber(item.Stock, 1)
+	if err != nil {
+		row.Err = fmt.Errorf("stock '%s' invalid", item.Stock)
+		return row
+	}
+	if stock > 0 { // overselling platforms export negative stock
+		row.ProductInfo.Stock = stock
+	}
+
+	weight, err := parseCatalogNumber(item.Weight, item.WeightScale)
+	if err != nil {
+		row.Err = fmt.Errorf("weight '%s' invalid", item.Weight)
+		return row
+	}
+	row.ProductInfo.Weight = float32(weight)
+	for i, d := range []*float32{&row.ProductInfo.Length,
+		&row.ProductInfo.Width, &row.ProductInfo.Height} {
+		size, err := parseCatalogNumber(item.Dimensions[i],
+			item.DimensionScale)
+		if err != nil {
+			row.Err = fmt.Errorf("dimension '%s' invalid", item.Dimensions[i])
+			return row
+		}
+		*d = float32(size)
+	}
+
+	row.ImageURLs = []string{}
+	for _, imageURL := range item.ImageURLs {
+		imageURL = strings.TrimSpace(imageURL)
+		if imageURL == "" || containsString(row.ImageURLs, imageURL) {
+			continue
+		}
+		if len(row.ImageURLs) == config.MaxProductImages {
+			break
+		}
+		row.ImageURLs = append(row.ImageURLs, imageURL)
+	}
+
+	row.Err = validator.IsProductInfoValid(row.ProductInfo)
+	return row
+}
+
+// parseCatalogMoney parse money of catalog export, 0 if it's empty,
+// zero decimals beyond minor units are ignored
+func parseCatalogMoney(s string) (model.Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	if whole, fraction, ok := strings.Cut(s, "."); ok && len(fraction) > 2 {
+		s = whole + "." + fraction[:2] + strings.TrimRight(fraction[2:], "0")
+	}
+
+	return model.ParseMoney(s)
+}
+
+// parseCatalogNumber parse number of catalog export multiplied by
+// scale, 0 if it's empty
+func parseCatalogNumber(s string, scale float64) (float64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return n * scale, nil
+}
+
+// catalogNumber number of catalog export JSON as text, exported either
+// as JSON number, string, or null
+type catalogNumber string
+
+// UnmarshalJSON unmarshal catalog number of JSON number, string, or null
+func (n *catalogNumber) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		*n = ""
+		return nil
+	}
+
+	var s string
+	err := json.Unmarshal(b, &s)
+	if err != nil {
+		var number json.Number
+		err = json.Unmarshal(b, &number)
+		s = string(number)
+	}
+	*n = catalogNumber(s)
+
+	return err
+}
+
+// containsString check if strs contain s
+func containsString(strs []string, s string) bool {
+	for _, str := range strs {
+		if str == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// catalogRecord record of catalog export CSV by lowercase column name
+type catalogRecord struct {
+	Line   int
+	Fields map[string]string
+}
+
+// get get trimmed field of record by column name
+func (r catalogRecord) get(name string) string {
+	return strings.TrimSpace(r.Fields[name])
+}
+
+// readCatalogCSV read records of catalog export CSV of platform
+// with header row containing required columns
+func readCatalogCSV(r io.Reader, platform string, required ...string) (
+	[]catalogRecord, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, errors.New("CSV empty, header row not found")
+	} else if err != nil {
+		return nil, fmt.Errorf("CSV invalid => %s", err.Error())
+	}
+	for i, name := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(name))
+	}
+	for _, name := range required {
+		if !containsString(header, name) {
+			return nil, fmt.Errorf("CSV column '%s' not found, must be "+
+				"%s product export", name, platform)
+		}
+	}
+
+	records := []catalogRecord{}
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("CSV invalid => %s", err.Error())
+		}
+
+		line, _ := cr.FieldPos(0)
+		fields := map[string]string{}
+		for i, value := range record {
+			if i < len(header) {
+				fields[header[i]] = value
+			}
+		}
+		records = append(records, catalogRecord{Line: line, Fields: fields})
+	}
+
+	return records, nil
+}
+
+// shopifyProduct product of Shopify export with its variants and images,
+// each variant is imported as a product
+type shopifyProduct struct {
+	Title    string           `json:"title"`
+	BodyHTML string           `json:"body_html"`
+	Variants []shopifyVariant `json:"variants"`
+	Images   []shopifyImage   `json:"images"`
+
+	line int
+}
+
+// shopifyVariant variant of Shopify product, image is either
+// image ID of JSON export or image URL of CSV export
+type shopifyVariant struct {
+	Title             string        `json:"title"`
+	Price             catalogNumber `json:"price"`
+	CompareAtPrice    catalogNumber `json:"compare_at_price"`
+	Grams             catalogNumber `json:"grams"`
+	Weight            catalogNumber `json:"weight"`
+	WeightUnit        string        `json:"weight_unit"`
+	InventoryQuantity catalogNumber `json:"inventory_quantity"`
+	Barcode           string        `json:"barcode"`
+	ImageID           int64         `json:"image_id"`
+
+	imageURL string
+	line     int
+}
+
+// shopifyImage image of Shopify product
+type shopifyImage struct {
+	ID       int64  `json:"id"`
+	Src      string `json:"src"`
+	Position int    `json:"position"`
+}
+
+// toCatalogItems map variants of Shopify product onto catalog items
+// named by product title and variant options, compare at price is
+// the regular price and price the sale price
+func (p shopifyProduct) toCatalogItems() []catalogItem {
+	sort.SliceStable(p.Images, func(i, j int) bool {
+		return p.Images[i].Position < p.Images[j].Position
+	})
+
+	items := []catalogItem{}
+	for _, v := range p.Variants {
+		item := catalogItem{
+			Line:           v.line,
+			Name:           p.Title,
+			Description:    p.BodyHTML,
+			RegularPrice:   string(v.CompareAtPrice),
+			SalePrice:      string(v.Price),
+			Stock:          string(v.InventoryQuantity),
+			Weight:         string(v.Grams),
+			WeightScale:    catalogWeightUnits["g"],
+			DimensionScale: 1,
+			Barcode:        v.Barcode,
+			ImageURLs:      []string{},
+		}
+		if v.CompareAtPrice == "" { // price without compare at isn't on sale
+			item.RegularPrice, item.SalePrice = string(v.Price), ""
+		}
+		if (v.Grams == "" || v.Grams == "0") &&
+			catalogWeightUnits[v.WeightUnit] != 0 {
+			item.Weight = string(v.Weight)
+			item.WeightScale = catalogWeightUnits[v.WeightUnit]
+		}
+		if len(p.Variants) > 1 && v.Title != "" &&
+			v.Title != "Default Title" {
+			item.Name += " - " + v.Title
+		}
+
+		// variant image first, then the product images
+		if v.imageURL != "" {
+			item.ImageURLs = append(item.ImageURLs, v.imageURL)
+		}
+		for _, image := range p.Images {
+			if image.ID != 0 && image.ID == v.ImageID {
+				item.ImageURLs = append([]string{image.Src}, item.ImageURLs...)
+			} else {
+				item.ImageURLs = append(item.ImageURLs, image.Src)
+			}
+		}
+
+		items = append(items, item)
+	}
+
+	return items
+}
+
+// parseShopifyJSON parse catalog items of Shopify products JSON,
+// either object with products, object with a product, or array
+// of products
+func parseShopifyJSON(b []byte) ([]catalogItem, error) {
+	export := struct {
+		Products []shopifyProduct `json:"products"`
+		Product  *shopifyProduct  `json:"product"`
+	}{}
+	var err error
+	if b[0] == '[' {
+		err = json.Unmarshal(b, &export.Products)
+	} else {
+		err = json.Unmarshal(b, &export)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("JSON invalid => %s", err.Error())
+	}
+	if export.Product != nil {
+		export.Products = append(export.Products, *export.Product)
+	}
+
+	items := []catalogItem{}
+	for i, p := range export.Products {
+		for j := range p.Variants {
+			p.Variants[j].line = i + 1
+		}
+		if len(p.Variants) == 0 {
+			p.Variants = []shopifyVariant{{line: i + 1}}
+		}
+		items = append(items, p.toCatalogItems()...)
+	}
+
+	return items, nil
+}
+
+// parseShopifyCSV parse catalog items of Shopify products CSV, where
+// rows of a product share its handle, the first row has its title,
+// and the rest rows have its other variants or images
+func parseShopifyCSV(r io.Reader) ([]catalogItem, error) {
+	records, err := readCatalogCSV(r, "Shopify", "handle", "title",
+		"variant price")
+	if err != nil {
+		return nil, err
+	}
+
+	products := []*shopifyProduct{}
+	byHandle := map[string]*shopifyProduct{}
+	for _, record := range records {
+		handle := record.get("handle")
+		p := byHandle[handle]
+		if p == nil {
+			p = &shopifyProduct{line: record.Line}
+			byHandle[handle] = p
+			products = append(products, p)
+		}
+		if p.Title == "" {
+			p.Title = record.get("title")
+			p.BodyHTML = record.get("body (html)")
+		}
+
+		if record.get("image src") != "" {
+			position, _ := strconv.Atoi(record.get("image position"))
+			p.Images = append(p.Images, shopifyImage{
+				Src:      record.get("image src"),
+				Position: position,
+			})
+		}
+
+		// rows with only image have no variant price
+		if record.get("variant price") == "" {
+			continue
+		}
+		options := []string{}
+		for _, column := range []string{"option1 value", "option2 value",
+			"option3 value"} {
+			if record.get(column) != "" {
+				options = append(options, record.get(column))
+			}
+		}
+		p.Variants = append(p.Variants, shopifyVariant{
+			Title:             strings.Join(options, " / "),
+			Price:             catalogNumber(record.get("variant price")),
+			CompareAtPrice:    catalogNumber(record.get("variant compare at price")),
+			Grams:             catalogNumber(record.get("variant grams")),
+			InventoryQuantity: catalogNumber(record.get("variant inventory qty")),
+			Barcode:           record.get("variant barcode"),
+			imageURL:          record.get("variant image"),
+			line:              record.Line,
+		})
+	}
+
+	items := []catalogItem{}
+	for _, p := range products {
+		if len(p.Variants) == 0 {
+			p.Variants = []shopifyVariant{{line: p.line}}
+		}
+		items = append(items, p.toCatalogItems()...)
+	}
+
+	return items, nil
+}
+
+// wooCommerceProduct product of WooCommerce REST API JSON
+type wooCommerceProduct struct {
+	Type             string        `json:"type"`
+	Name             string        `json:"name"`
+	Description      string        `json:"description"`
+	ShortDescription string        `json:"short_description"`
+	Price            string        `json:"price"`
+	RegularPrice     string        `json:"regular_price"`
+	SalePrice        string        `json:"sale_price"`
+	StockQuantity    catalogNumber `json:"stock_quantity"`
+	Weight           string        `json:"weight"`
+	Dimensions       struct {
+		Length string `json:"length"`
+		Width  string `json:"width"`
+		Height string `json:"height"`
+	} `json:"dimensions"`
+	Images []struct {
+		Src string `json:"src"`
+	} `json:"images"`
+}
+
+// parseWooCommerceJSON parse catalog items of WooCommerce products JSON
+// array, weight in kg and dimensions in cm, grouped products are skipped
+// since they have no price, and variable products, whose variations
+// aren't in the array, are imported with their price
+func parseWooCommerceJSON(b []byte) ([]catalogItem, error) {
+	products := []wooCommerceProduct{}
+	err := json.Unmarshal(b, &products)
+	if err != nil {
+		return nil, fmt.Errorf("JSON invalid, must be array of products "+
+			"=> %s", err.Error())
+	}
+
+	items := []catalogItem{}
+	for i, p := range products {
+		if p.Type == "grouped" {
+			continue
+		}
+
+		item := catalogItem{
+			Line:         i + 1,
+			Name:         p.Name,
+			Description:  p.Description,
+			RegularPrice: p.RegularPrice,
+			SalePrice:    p.SalePrice,
+			Stock:        string(p.StockQuantity),
+			Weight:       p.Weight,
+			WeightScale:  1,
+			Dimensions: [3]string{p.Dimensions.Length, p.Dimensions.Width,
+				p.Dimensions.Height},
+			DimensionScale: 1,
+			ImageURLs:      []string{},
+		}
+		if item.Description == "" {
+			item.Description = p.ShortDescription
+		}
+		if item.RegularPrice == "" {
+			item.RegularPrice = p.Price
+		}
+		for _, image := range p.Images {
+			item.ImageURLs = append(item.ImageURLs, image.Src)
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// parseWooCommerceCSV parse catalog items of WooCommerce products CSV,
+// weight and dimensions in unit of their column header, grouped and
+// variable products are skipped while their variations are imported,
+// inheriting description, images, weight, and dimensions of their parent
+// if they're empty
+func parseWooCommerceCSV(r io.Reader) ([]catalogItem, error) {
+	records, err := readCatalogCSV(r, "WooCommerce", "type", "name",
+		"regular price")
+	if err != nil {
+		return nil, err
+	}
+
+	// get weight and dimension columns with their unit, e.g. 'weight (kg)'
+	weightColumn, weightScale := "", 1.0
+	dimensionColumns, dimensionScale := [3]string{}, 1.0
+	if len(records) > 0 {
+		for column := range records[0].Fields {
+			name, unit, ok := strings.Cut(strings.TrimSuffix(column, ")"), " (")
+			if !ok {
+				continue
+			}
+			switch name {
+			case "weight":
+				if catalogWeightUnits[unit] == 0 {
+					return nil, fmt.Errorf("CSV column '%s' unit invalid",
+						column)
+				}
+				weightColumn, weightScale = column, catalogWeightUnits[unit]
+			case "length", "width", "height":
+				if catalogDimensionUnits[unit] == 0 {
+					return nil, fmt.Errorf("CSV column '%s' unit invalid",
+						column)
+				}
+				i := map[string]int{"length": 0, "width": 1, "height": 2}[name]
+				dimensionColumns[i] = column
+				dimensionScale = catalogDimensionUnits[unit]
+			}
+		}
+	}
+
+	items := []catalogItem{}
+	parents := map[string]catalogItem{}
+	for _, record := range records {
+		item := catalogItem{
+			Line:         record.Line,
+			Name:         record.get("name"),
+			Description:  record.get("description"),
+			RegularPrice: record.get("regular price"),
+			SalePrice:    record.get("sale price"),
+			Stock:        record.get("stock"),
+			Weight:       record.get(weightColumn),
+			WeightScale:  weightScale,
+			Dimensions: [3]string{record.get(dimensionColumns[0]),
+				record.get(dimensionColumns[1]),
+				record.get(dimensionColumns[2])},
+			DimensionScale: dimensionScale,
+			Barcode:        record.get("gtin, upc, ean, or isbn"),
+			ImageURLs:      []string{},
+		}
+		if item.Description == "" {
+			item.Description = record.get("short description")
+		}
+		for _, imageURL := range strings.Split(record.get("images"), ",") {
+			if strings.TrimSpace(imageURL) != "" {
+				item.ImageURLs = append(item.ImageURLs,
+					strings.TrimSpace(imageURL))
+			}
+		}
+
+		// parent referenced by variations as 'id:<ID>' or its SKU
+		types := strings.Split(record.get("type"), ",")
+		for i := range types {
+			types[i] = strings.TrimSpace(types[i])
+		}
+		if containsString(types, "variable") ||
+			containsString(types, "grouped") {
+			if record.get("id") != "" {
+				parents["id:"+record.get("id")] = item
+			}
+			if record.get("sku") != "" {
+				parents[record.get("sku")] = item
+			}
+			continue
+		}
+
+		if parent, ok := parents[record.get("parent")]; ok {
+			if item.Description == "" {
+				item.Description = parent.Description
+			}
+			item.ImageURLs = append(item.ImageURLs, parent.ImageURLs...)
+			if item.Weight == "" {
+				item.Weight = parent.Weight
+			}
+			if item.Dimensions == [3]string{} {
+				item.Dimensions = parent.Dimensions
+			}
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
@@ -0,0 +1,304 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+	"github.com/reyhanfikridz/ecom-product-service/internal/netguard"
+)
+
+// shopifyTestCSV Shopify products CSV of a product with two variants
+// and three images, and a product with invalid price
+const shopifyTestCSV = "Handle,Title,Body (HTML),Option1 Name,Option1 Value," +
+	"Variant Grams,Variant Inventory Qty,Variant Price," +
+	"Variant Compare At Price,Variant Barcode,Image Src,Image Position," +
+	"Variant Image\n" +
+	"tee,Tee,<p>Cotton</p>,Size,S,200,5,90.00,100.00,4006381333931," +
+	"https://example.com/tee-2.png,2,https://example.com/tee-s.png\n" +
+	"tee,,,,M,250,-2,100.00,,12345,https://example.com/tee-1.png,1,\n" +
+	"tee,,,,,,,,,,https://example.com/tee-3.png,3,\n" +
+	"mug,Mug,,Title,Default Title,300,1,abc,,,,,\n"
+
+// TestParseCatalogImport test ParseCatalogImport of Shopify and
+// WooCommerce exports as CSV and JSON
+func TestParseCatalogImport(t *testing.T) {
+	testTable := []struct {
+		TestName  string
+		Platform  string
+		Export    string
+		Expected  []ProductImportRow
+		ErrorRows map[int]string
+	}{
+		{
+			TestName: "Test Shopify CSV",
+			Platform: CatalogShopify,
+			Export:   "\xef\xbb\xbf" + shopifyTestCSV,
+			Expected: []ProductImportRow{
+				{
+					Line: 2,
+					ProductInfo: model.ProductInfo{Name: "Tee - S",
+						Price: 10000, SalePrice: 9000, Weight: 0.2, Stock: 5,
+						Barcode: "4006381333931", Description: "<p>Cotton</p>",
+						DescriptionFormat: "html"},
+					ImageURLs: []string{"https://example.com/tee-s.png",
+						"https://example.com/tee-1.png",
+						"https://example.com/tee-2.png",
+						"https://example.com/tee-3.png"},
+				},
+				{
+					Line: 3,
+					ProductInfo: model.ProductInfo{Name: "Tee - M",
+						Price: 10000, Weight: 0.25, Description: "<p>Cotton</p>",
+						DescriptionFormat: "html"},
+					ImageURLs: []string{"https://example.com/tee-1.png",
+						"https://example.com/tee-2.png",
+						"https://example.com/tee-3.png"},
+				},
+				{Line: 5},
+			},
+			ErrorRows: map[int]string{2: "price 'abc' invalid"},
+		},
+		{
+			TestName: "Test Shopify JSON",
+			Platform: CatalogShopify,
+			Export: `{"products":[{"title":"Lamp","body_html":null,
+				"variants":[{"title":"Default Title","price":"25.50",
+					"compare_at_price":null,"grams":0,"weight":1.5,
+					"weight_unit":"kg","inventory_quantity":3,"image_id":7}],
+				"images":[{"id":6,"src":"https://example.com/lamp-1.png",
+					"position":1},{"id":7,
+					"src":"https://example.com/lamp-2.png","position":2}]}]}`,
+			Expected: []ProductImportRow{
+				{
+					Line: 1,
+					ProductInfo: model.ProductInfo{Name: "Lamp", Price: 2550,
+						Weight: 1.5, Stock: 3},
+					ImageURLs: []string{"https://example.com/lamp-2.png",
+						"https://example.com/lamp-1.png"},
+				},
+			},
+		},
+		{
+			TestName: "Test WooCommerce CSV",
+			Platform: CatalogWooCommerce,
+			Export: "ID,Type,SKU,Name,Short description,Description," +
+				"Sale price,Regular price,Stock,Weight (g),Length (mm)," +
+				"Width (mm),Height (mm),Images,Parent\n" +
+				"10,variable,HOOD,Hoodie,Warm,,,,,500,300,200,50," +
+				"\"https://example.com/hood-1.png, https://example.com/hood-2.png\",\n" +
+				"11,variation,HOOD-B,Hoodie - Blue,,,,40000,7,,,,," +
+				"https://example.com/hood-blue.png,id:10\n" +
+				"12,\"simple, virtual\",CAP,Cap,,<p>Cap</p>,15000,12000,," +
+				"100,,,,,\n",
+			Expected: []ProductImportRow{
+				{
+					Line: 3,
+					ProductInfo: model.ProductInfo{Name: "Hoodie - Blue",
+						Price: 4000000, Weight: 0.5, Stock: 7, Length: 30,
+						Width: 20, Height: 5, Description: "Warm",
+						DescriptionFormat: "html"},
+					ImageURLs: []string{"https://example.com/hood-blue.png",
+						"https://example.com/hood-1.png",
+						"https://example.com/hood-2.png"},
+				},
+				{
+					Line: 4,
+					ProductInfo: model.ProductInfo{Name: "Cap",
+						Price: 1500000, Weight: 0.1, Description: "<p>Cap</p>",
+						DescriptionFormat: "html"},
+					ImageURLs: []string{},
+				},
+			},
+		},
+		{
+			TestName: "Test WooCommerce JSON",
+			Platform: CatalogWooCommerce,
+			Export: `[{"type":"grouped","name":"Set"},
+				{"type":"simple","name":"Desk","description":"",
+				"short_description":"Oak desk","price":"900",
+				"regular_price":"1000","sale_price":"900",
+				"stock_quantity":null,"weight":"20",
+				"dimensions":{"length":"120","width":"60","height":"75"},
+				"images":[{"src":"https://example.com/desk.png"}]}]`,
+			Expected: []ProductImportRow{
+				{
+					Line: 2,
+					ProductInfo: model.ProductInfo{Name: "Desk",
+						Price: 100000, SalePrice: 90000, Weight: 20,
+						Length: 120, Width: 60, Height: 75,
+						Description: "Oak desk", DescriptionFormat: "html"},
+					ImageURLs: []string{"https://example.com/desk.png"},
+				},
+			},
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		rows, err := ParseCatalogImport(test.Platform, []byte(test.Export))
+		if err != nil {
+			t.Errorf("[%s] Expected error nil, but got error => %s",
+				test.TestName, err.Error())
+			continue
+		}
+		if len(rows) != len(test.Expected) {
+			t.Errorf("[%s] Expected %d rows, but got %d => %+v",
+				test.TestName, len(test.Expected), len(rows), rows)
+			continue
+		}
+
+		for i, row := range rows {
+			if row.Line != test.Expected[i].Line {
+				t.Errorf("[%s] Expected row %d at line %d, but got %d",
+					test.TestName, i, test.Expected[i].Line, row.Line)
+			}
+			if expectedErr, ok := test.ErrorRows[i]; ok {
+				if row.Err == nil || row.Err.Error() != expectedErr {
+					t.Errorf("[%s] Expected row %d error '%s', but got %v",
+						test.TestName, i, expectedErr, row.Err)
+				}
+				continue
+			}
+			if row.Err != nil {
+				t.Errorf("[%s] Expected row %d valid, but got error => %s",
+					test.TestName, i, row.Err.Error())
+			}
+			if row.ProductInfo != test.Expected[i].ProductInfo ||
+				!reflect.DeepEqual(row.ImageURLs, test.Expected[i].ImageURLs) {
+				t.Errorf("[%s] Expected row %d %+v %v, but got %+v %v",
+					test.TestName, i, test.Expected[i].ProductInfo,
+					test.Expected[i].ImageURLs, row.ProductInfo, row.ImageURLs)
+			}
+		}
+	}
+
+	// export of other platform or malformed
+	for _, invalidExport := range []string{
+		"sku,name,price,weight\nA,Mouse,1000,1\n",
+		`{"products":[{"title":1}]}`,
+		"",
+	} {
+		_, err := ParseCatalogImport(CatalogShopify, []byte(invalidExport))
+		if err == nil {
+			t.Errorf("Expected error for Shopify export %q, but got nil",
+				invalidExport)
+		}
+	}
+}
+
+// TestImportCatalogHandler test ImportCatalogHandler
+// with product repository in memory
+func TestImportCatalogHandler(t *testing.T) {
+	repo := &importRepository{}
+	a := API{Repo: repo, FiberApp: fiber.New()}
+	a.FiberApp.Post("/api/products/import/:platform/",
+		AuthorizationMiddlewareForTest(middleware.User{ID: 3, Role: "seller"}),
+		a.ImportCatalogHandler)
+
+	// Shopify product without images
+	req, _ := http.NewRequest("POST", "/api/products/import/shopify/",
+		strings.NewReader("Handle,Title,Variant Grams,Variant Price\n"+
+			"mug,Mug,300,12.5\n"+
+			"cup,Cup,,\n"))
+	req.Header.Set("Content-Type", "text/csv")
+	response, err := a.FiberApp.Test(req)
+	if err != nil {
+		t.Fatalf("There's an error serve http testing => %s", err.Error())
+	}
+	defer response.Body.Close()
+
+	body := struct {
+		Message string                `json:"message"`
+		Results []ProductImportResult `json:"results"`
+	}{}
+	err = json.NewDecoder(response.Body).Decode(&body)
+	if err != nil {
+		t.Fatalf("There's an error when decoding response => %s", err.Error())
+	}
+
+	expected := []ProductImportResult{
+		{Line: 2, SKU: "SKU-1"},
+		{Line: 3, Error: "price empty/not found; weight empty/not found"},
+	}
+	if response.StatusCode != http.StatusOK ||
+		body.Message != "1 of 2 products imported" ||
+		!reflect.DeepEqual(body.Results, expected) {
+		t.Errorf("Expected status %d with '1 of 2 products imported' %+v, "+
+			"but got %d with '%s' %+v", http.StatusOK, expected,
+			response.StatusCode, body.Message, body.Results)
+	}
+	if len(repo.inserted) != 1 || repo.inserted[0].Name != "Mug" ||
+		repo.inserted[0].Price != 1250 || repo.inserted[0].Weight != 0.3 {
+		t.Errorf("Expected product Mug inserted, but got %+v", repo.inserted)
+	}
+
+	// platform not found
+	req, _ = http.NewRequest("POST", "/api/products/import/magento/",
+		strings.NewReader("sku\n"))
+	response, err = a.FiberApp.Test(req)
+	if err != nil {
+		t.Fatalf("There's an error serve http testing => %s", err.Error())
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status %d got %d",
+			http.StatusNotFound, response.StatusCode)
+	}
+}
+
+// TestImportCatalogHandlerInternalImage test ImportCatalogHandler
+// refusing image URLs of internal addresses in the catalog
+func TestImportCatalogHandlerInternalImage(t *testing.T) {
+	repo := &importRepository{}
+	a := API{Repo: repo, FiberApp: fiber.New()}
+	a.FiberApp.Post("/api/products/import/:platform/",
+		AuthorizationMiddlewareForTest(middleware.User{ID: 3, Role: "seller"}),
+		a.ImportCatalogHandler)
+
+	req, _ := http.NewRequest("POST", "/api/products/import/woocommerce/",
+		strings.NewReader(`[{"type":"simple","name":"Lamp",`+
+			`"regular_price":"10","weight":"1",`+
+			`"images":[{"src":"http://169.254.169.254/latest/meta-data/"}]},`+
+			`{"type":"simple","name":"Desk","regular_price":"20",`+
+			`"weight":"2","images":[{"src":"http://127.0.0.1:8080/a.png"}]}]`))
+	req.Header.Set("Content-Type", "application/json")
+	response, err := a.FiberApp.Test(req)
+	if err != nil {
+		t.Fatalf("There's an error serve http testing => %s", err.Error())
+	}
+	defer response.Body.Close()
+
+	body := struct {
+		Message string                `json:"message"`
+		Results []ProductImportResult `json:"results"`
+	}{}
+	err = json.NewDecoder(response.Body).Decode(&body)
+	if err != nil {
+		t.Fatalf("There's an error when decoding response => %s", err.Error())
+	}
+
+	if body.Message != "0 of 2 products imported" || len(body.Results) != 2 {
+		t.Fatalf("Expected '0 of 2 products imported', but got '%s' %+v",
+			body.Message, body.Results)
+	}
+	for _, result := range body.Results {
+		if !strings.Contains(result.Error,
+			netguard.ErrAddressNotAllowed.Error()) {
+			t.Errorf("Expected line %d error address not allowed, but got '%s'",
+				result.Line, result.Error)
+		}
+	}
+	if len(repo.inserted) != 0 {
+		t.Errorf("Expected no product inserted, but got %+v", repo.inserted)
+	}
+}
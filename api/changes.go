@@ -0,0 +1,89 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+)
+
+// default and maximum changes per page of change log
+const (
+	defaultChangesLimit = 100
+	maxChangesLimit     = 1000
+)
+
+// changesSettle duration before recorded change is listed in change log,
+// since changes recorded concurrently may be committed out of sequence
+var changesSettle = time.Second
+
+// ChangesPage contain page of change log, NextSince is sequence number
+// to get the next page from
+type ChangesPage struct {
+	Changes   []model.ProductEvent `json:"changes"`
+	NextSince int64                `json:"next_since"`
+	HasMore   bool                 `json:"has_more"`
+}
+
+// GetChangesHandler handling route get changes of append-only change log
+// after sequence number of url query 'since' (default: 0), at most
+// url query 'limit' changes (default: 100), so downstream systems can
+// sync incrementally (method: GET, user: internal service)
+//
+// reply gone if changes after 'since' were already purged, so the
+// downstream system must resync all products
+func (a *API) GetChangesHandler(c *fiber.Ctx) error {
+	// get since and limit from url
+	since, err := strconv.ParseInt(c.Query("since", "0"), 10, 64)
+	if err != nil || since < 0 {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": "parameter 'since' invalid, must be non-negative " +
+				"sequence number",
+		})
+	}
+	limit, err := strconv.Atoi(c.Query("limit",
+		strconv.Itoa(defaultChangesLimit)))
+	if err != nil || limit <= 0 || limit > maxChangesLimit {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": fmt.Sprintf("parameter 'limit' invalid, "+
+				"must be integer between 1 and %d", maxChangesLimit),
+		})
+	}
+
+	// check changes after since are still in change log
+	first, _, err := a.Repo.GetProductEventIDRange(c.UserContext())
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+	if first > 0 && since < first-1 {
+		return c.Status(http.StatusGone).JSON(map[string]string{
+			"message": fmt.Sprintf("changes since %d were purged, resync "+
+				"all products then get changes since %d", since, first-1),
+		})
+	}
+
+	// get one more change to know whether there's a next page
+	changes, err := a.Repo.GetProductEventsAfter(c.UserContext(), since,
+		changesSettle, limit+1)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	page := ChangesPage{Changes: changes, NextSince: since}
+	if len(changes) > limit {
+		page.Changes = changes[:limit]
+		page.HasMore = true
+	}
+	if len(page.Changes) > 0 {
+		page.NextSince = page.Changes[len(page.Changes)-1].ID
+	}
+
+	return c.Status(http.StatusOK).JSON(page)
+}
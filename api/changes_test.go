@@ -0,0 +1,130 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/config"
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+)
+
+// changesRepository product repository in memory storing change log
+// of sequence numbers 3 to 5, the previous changes were purged
+type changesRepository struct {
+	model.ProductRepository
+	changes []model.ProductEvent
+}
+
+// GetProductEventsAfter get changes after sequence number from memory
+func (r changesRepository) GetProductEventsAfter(ctx context.Context,
+	afterID int64, settle time.Duration, limit int) ([]model.ProductEvent,
+	error) {
+	changes := []model.ProductEvent{}
+	for _, change := range r.changes {
+		if change.ID > afterID && len(changes) < limit {
+			changes = append(changes, change)
+		}
+	}
+
+	return changes, nil
+}
+
+// GetProductEventIDRange get the first and the last sequence number
+// of change log in memory
+func (r changesRepository) GetProductEventIDRange(ctx context.Context) (
+	int64, int64, error) {
+	return r.changes[0].ID, r.changes[len(r.changes)-1].ID, nil
+}
+
+// TestGetChangesHandler test GetChangesHandler authorized by internal
+// service token
+func TestGetChangesHandler(t *testing.T) {
+	config.InternalServiceToken = "service-secret"
+	defer func() { config.InternalServiceToken = "" }()
+
+	repo := changesRepository{changes: []model.ProductEvent{
+		{ID: 3, Entity: model.ChangeEntityProduct,
+			Operation: model.ChangeOperationCreate, SKU: "SKU-A"},
+		{ID: 4, Entity: model.ChangeEntityStock,
+			Operation: model.ChangeOperationUpdate, SKU: "SKU-A"},
+		{ID: 5, Entity: model.ChangeEntityProduct,
+			Operation: model.ChangeOperationDelete, SKU: "SKU-A"},
+	}}
+	a := API{Repo: repo, FiberApp: fiber.New()}
+	a.FiberApp.Get("/api/changes/",
+		middleware.ServiceAuthorizationMiddleware(), a.GetChangesHandler)
+
+	// create testing table
+	testTable := []struct {
+		TestName           string
+		Token              string
+		Query              string
+		ExpectedStatusCode int
+		ExpectedPage       ChangesPage
+	}{
+		{"User token not allowed", "user-token", "?since=2",
+			http.StatusForbidden, ChangesPage{}},
+		{"Since invalid", "service-secret", "?since=-1",
+			http.StatusBadRequest, ChangesPage{}},
+		{"Limit invalid", "service-secret", "?since=2&limit=1001",
+			http.StatusBadRequest, ChangesPage{}},
+		{"Since purged", "service-secret", "?since=1", http.StatusGone,
+			ChangesPage{}},
+		{"First page", "service-secret", "?since=2&limit=2", http.StatusOK,
+			ChangesPage{Changes: repo.changes[:2], NextSince: 4,
+				HasMore: true}},
+		{"Last page", "service-secret", "?since=4&limit=2", http.StatusOK,
+			ChangesPage{Changes: repo.changes[2:], NextSince: 5}},
+		{"Up to date", "service-secret", "?since=5", http.StatusOK,
+			ChangesPage{Changes: []model.ProductEvent{}, NextSince: 5}},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		req, _ := http.NewRequest("GET", "/api/changes/"+test.Query, nil)
+		req.Header.Set("Authorization", "Bearer "+test.Token)
+		resp, err := a.FiberApp.Test(req)
+		if err != nil {
+			t.Fatalf("[%s] There's an error when testing request => %s",
+				test.TestName, err.Error())
+		}
+		if resp.StatusCode != test.ExpectedStatusCode {
+			t.Errorf("[%s] Expected status code %d, but got %d",
+				test.TestName, test.ExpectedStatusCode, resp.StatusCode)
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			continue
+		}
+
+		page := ChangesPage{}
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		if err != nil {
+			t.Fatalf("[%s] Expected error nil, but got error => %s",
+				test.TestName, err.Error())
+		}
+		if page.NextSince != test.ExpectedPage.NextSince ||
+			page.HasMore != test.ExpectedPage.HasMore ||
+			len(page.Changes) != len(test.ExpectedPage.Changes) {
+			t.Errorf("[%s] Expected page %+v, but got %+v", test.TestName,
+				test.ExpectedPage, page)
+			continue
+		}
+		for i, change := range page.Changes {
+			expected := test.ExpectedPage.Changes[i]
+			if change.ID != expected.ID || change.Entity != expected.Entity ||
+				change.Operation != expected.Operation {
+				t.Errorf("[%s] Expected change %+v, but got %+v",
+					test.TestName, expected, change)
+			}
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+)
+
+// GetProductETag get weak ETag of product from its version,
+// last update time, effective price, rating, locale, and images,
+// view count and popularity counters are left out since they change
+// on every view, wishlist add, and completed order
+func GetProductETag(p model.Product) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s:%d:%d:%v:%v:%d:%s", p.ProductInfo.SKU,
+		p.ProductInfo.Version, p.ProductInfo.UpdatedAt.UnixNano(),
+		p.ProductInfo.EffectivePrice, p.ProductInfo.AvgRating,
+		p.ProductInfo.ReviewCount, p.ProductInfo.Locale)
+	for _, pImage := range p.ProductImages {
+		fmt.Fprintf(h, ":%d", pImage.ID)
+	}
+
+	return `W/"` + hex.EncodeToString(h.Sum(nil))[:32] + `"`
+}
+
+// IsETagMatch check If-None-Match header value match the ETag
+// using weak comparison
+func IsETagMatch(ifNoneMatch string, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,91 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+)
+
+// TestGetProductETag test GetProductETag and IsETagMatch
+func TestGetProductETag(t *testing.T) {
+	p := model.Product{
+		ProductInfo: model.ProductInfo{
+			SKU:       "SKU-A",
+			Version:   1,
+			UpdatedAt: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		ProductImages: []model.ProductImage{{ID: 1}},
+	}
+	etag := GetProductETag(p)
+
+	updated := p
+	updated.ProductInfo.Version = 2
+	withNewImage := p
+	withNewImage.ProductImages = []model.ProductImage{{ID: 2}}
+	saleStarted := p
+	saleStarted.ProductInfo.EffectivePrice = 500
+
+	// create testing table
+	testTable := []struct {
+		TestName       string
+		IfNoneMatch    string
+		Product        model.Product
+		ExpectedResult bool
+	}{
+		{
+			TestName:       "Same product",
+			IfNoneMatch:    etag,
+			Product:        p,
+			ExpectedResult: true,
+		},
+		{
+			TestName:       "Same product in list without weak prefix",
+			IfNoneMatch:    `"other", ` + etag[2:],
+			Product:        p,
+			ExpectedResult: true,
+		},
+		{
+			TestName:       "Any",
+			IfNoneMatch:    "*",
+			Product:        p,
+			ExpectedResult: true,
+		},
+		{
+			TestName:       "Empty header",
+			IfNoneMatch:    "",
+			Product:        p,
+			ExpectedResult: false,
+		},
+		{
+			TestName:       "Updated product",
+			IfNoneMatch:    etag,
+			Product:        updated,
+			ExpectedResult: false,
+		},
+		{
+			TestName:       "Product images replaced",
+			IfNoneMatch:    etag,
+			Product:        withNewImage,
+			ExpectedResult: false,
+		},
+		{
+			TestName:       "Product sale started",
+			IfNoneMatch:    etag,
+			Product:        saleStarted,
+			ExpectedResult: false,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		result := IsETagMatch(test.IfNoneMatch, GetProductETag(test.Product))
+		if result != test.ExpectedResult {
+			t.Errorf("[%s] Expected %t, but got %t",
+				test.TestName, test.ExpectedResult, result)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+	"github.com/reyhanfikridz/ecom-product-service/internal/permission"
+)
+
+// exportPageSize products got from database per query when exporting
+var exportPageSize = 500
+
+// ProductExport contain product info with its image URLs and stock
+// of an exported product
+type ProductExport struct {
+	model.ProductInfo
+	ImageURLs []string `json:"image_urls"`
+}
+
+// productExporter write exported products in a file format
+type productExporter interface {
+	Write(p ProductExport) error
+	Close() error
+}
+
+// ExportProductsHandler handling route export all products of the seller
+// as CSV or JSON file, streamed page by page (method: GET, user: seller)
+func (a *API) ExportProductsHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// check user role is allowed to access this API
+	if !a.isAllowed(u, permission.ProductReadOwn) {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this API",
+		})
+	}
+
+	// get export format from url
+	format := c.Query("format", "csv")
+	if format != "csv" && format != "json" {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": "parameter 'format' invalid, must be 'csv' or 'json'",
+		})
+	}
+
+	// get first page before streaming so database error can be replied
+	query := model.ProductQuery{UserID: u.ID, Limit: exportPageSize}
+	products, err := a.Repo.GetProducts(c.UserContext(), query)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": fmt.Sprintf(
+				"There's an error when getting the products data => %s",
+				err.Error()),
+		})
+	}
+
+	if format == "csv" {
+		c.Set(fiber.HeaderContentType, "text/csv")
+	} else {
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	}
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(
+		"attachment; filename=\"products-%d-%s.%s\"",
+		u.ID, time.Now().UTC().Format("20060102T150405Z"), format))
+
+	// stream the rest pages after handler returned, so request context
+	// is not used anymore
+	originURL := c.BaseURL()
+	c.Status(http.StatusOK).Context().SetBodyStreamWriter(
+		func(w *bufio.Writer) {
+			_, err := a.writeProductExport(context.Background(), w, format,
+				originURL, query, products)
+			if err != nil {
+				log.Printf("There's an error when exporting products "+
+					"of user %d => %s", u.ID, err.Error())
+			}
+		})
+
+	return nil
+}
+
+// writeProductExport write products by query into w in the format,
+// starting from already got first page products, returning count of
+// written products
+func (a *API) writeProductExport(ctx context.Context, w io.Writer,
+	format string, originURL string, query model.ProductQuery,
+	products []model.Product) (int, error) {
+	var exporter productExporter
+	switch format {
+	case "csv":
+		exporter = newCSVProductExporter(w)
+	case "google-merchant":
+		exporter = newGoogleMerchantProductExporter(w, time.Now())
+	default:
+		exporter = newJSONProductExporter(w)
+	}
+
+	count := 0
+	for {
+		for _, p := range products {
+			export := ProductExport{
+				ProductInfo: p.ProductInfo,
+				ImageURLs:   []string{},
+			}
+			for _, pImage := range p.ProductImages {
+				export.ImageURLs = append(export.ImageURLs,
+					GetMediaURL(originURL, pImage.ImagePath,
+						isProductPublished(p.ProductInfo)))
+			}
+
+			err := exporter.Write(export)
+			if err != nil {
+				return count, err
+			}
+			count++
+		}
+
+		// the last page got
+		if len(products) < query.Limit {
+			break
+		}
+
+		cursor := model.NewProductCursor(query.Sort,
+			products[len(products)-1].ProductInfo)
+		query.After = &cursor
+
+		var err error
+		products, err = a.Repo.GetProducts(ctx, query)
+		if err != nil {
+			return count, err
+		}
+	}
+
+	return count, exporter.Close()
+}
+
+// csvProductExporter write exported products as CSV,
+// its columns can be imported back with ImportProductsHandler
+type csvProductExporter struct {
+	w *csv.Writer
+}
+
+// newCSVProductExporter create CSV product exporter with header row
+func newCSVProductExporter(w io.Writer) *csvProductExporter {
+	e := &csvProductExporter{w: csv.NewWriter(w)}
+	e.w.Write([]string{"sku", "name", "price", "weight", "length", "width",
+		"height", "stock", "unit", "description", "description_format",
+		"barcode", "image_urls", "created_at", "updated_at"})
+	return e
+}
+
+// Write write product as CSV row
+func (e *csvProductExporter) Write(p ProductExport) error {
+	return e.w.Write([]string{
+		p.SKU,
+		p.Name,
+		p.Price.String(),
+		strconv.FormatFloat(float64(p.Weight), 'f', -1, 32),
+		strconv.FormatFloat(float64(p.Length), 'f', -1, 32),
+		strconv.FormatFloat(float64(p.Width), 'f', -1, 32),
+		strconv.FormatFloat(float64(p.Height), 'f', -1, 32),
+		strconv.FormatFloat(p.Stock, 'f', -1, 64),
+		p.Unit,
+		p.Description,
+		p.DescriptionFormat,
+		p.Barcode,
+		strings.Join(p.ImageURLs, " "),
+		p.CreatedAt.UTC().Format(time.RFC3339),
+		p.UpdatedAt.UTC().Format(time.RFC3339),
+	})
+}
+
+// Close flush written CSV rows
+func (e *csvProductExporter) Close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// jsonProductExporter write exported products as JSON array
+type jsonProductExporter struct {
+	w     io.Writer
+	count int
+}
+
+// newJSONProductExporter create JSON product exporter
+func newJSONProductExporter(w io.Writer) *jsonProductExporter {
+	return &jsonProductExporter{w: w}
+}
+
+// Write write product as JSON array element
+func (e *jsonProductExporter) Write(p ProductExport) error {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	separator := ",\n"
+	if e.count == 0 {
+		separator = "[\n"
+	}
+	e.count++
+
+	_, err = e.w.Write(append([]byte(separator), b...))
+	return err
+}
+
+// Close close the JSON array
+func (e *jsonProductExporter) Close() error {
+	end := "\n]\n"
+	if e.count == 0 {
+		end = "[]\n"
+	}
+
+	_, err := io.WriteString(e.w, end)
+	return err
+}
@@ -0,0 +1,144 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+)
+
+// exportRepository product repository in memory with products
+// sorted by ID
+type exportRepository struct {
+	model.ProductRepository
+	products []model.Product
+	queries  int
+}
+
+// GetProducts get page of products of the query user from memory
+func (r *exportRepository) GetProducts(ctx context.Context,
+	query model.ProductQuery) ([]model.Product, error) {
+	r.queries++
+
+	products := []model.Product{}
+	for _, p := range r.products {
+		if p.ProductInfo.UserID != query.UserID ||
+			(query.After != nil && p.ProductInfo.ID <= query.After.ID) {
+			continue
+		}
+		if len(products) == query.Limit {
+			break
+		}
+		products = append(products, p)
+	}
+
+	return products, nil
+}
+
+// TestExportProductsHandler test ExportProductsHandler
+// with product repository in memory
+func TestExportProductsHandler(t *testing.T) {
+	pageSize := exportPageSize
+	exportPageSize = 2
+	defer func() { exportPageSize = pageSize }()
+
+	createdAt := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := &exportRepository{}
+	for i, name := range []string{"Mouse", "Keyboard", "Hub"} {
+		repo.products = append(repo.products, model.Product{
+			ProductInfo: model.ProductInfo{ID: i + 1, SKU: name[:1],
+				Name: name, Price: 100000, Weight: 0.5, Stock: float64(i),
+				Unit: "piece", UserID: 3, CreatedAt: createdAt, UpdatedAt: createdAt},
+			ProductImages: []model.ProductImage{
+				{ImagePath: "product-image/" + name + ".png"},
+			},
+		})
+	}
+	repo.products = append(repo.products, model.Product{
+		ProductInfo: model.ProductInfo{ID: 4, Name: "Other", UserID: 4},
+	})
+
+	a := API{Repo: repo, FiberApp: fiber.New()}
+	a.FiberApp.Get("/api/products/user/export/",
+		AuthorizationMiddlewareForTest(middleware.User{ID: 3, Role: "seller"}),
+		a.ExportProductsHandler)
+
+	// export as CSV
+	req, _ := http.NewRequest("GET", "http://example.com"+
+		"/api/products/user/export/?format=csv", nil)
+	response, err := a.FiberApp.Test(req)
+	if err != nil {
+		t.Fatalf("There's an error serve http testing => %s", err.Error())
+	}
+	defer response.Body.Close()
+
+	body, _ := io.ReadAll(response.Body)
+	expectedCSV := "sku,name,price,weight,length,width,height,stock,unit," +
+		"description,description_format,barcode,image_urls,created_at," +
+		"updated_at\n" +
+		"M,Mouse,1000,0.5,0,0,0,0,piece,,,," +
+		"http://example.com/media/product-image/Mouse.png," +
+		"2022-01-01T00:00:00Z,2022-01-01T00:00:00Z\n" +
+		"K,Keyboard,1000,0.5,0,0,0,1,piece,,,," +
+		"http://example.com/media/product-image/Keyboard.png," +
+		"2022-01-01T00:00:00Z,2022-01-01T00:00:00Z\n" +
+		"H,Hub,1000,0.5,0,0,0,2,piece,,,," +
+		"http://example.com/media/product-image/Hub.png," +
+		"2022-01-01T00:00:00Z,2022-01-01T00:00:00Z\n"
+	if response.StatusCode != http.StatusOK || string(body) != expectedCSV {
+		t.Errorf("Expected status %d with CSV:\n%s\nbut got %d with:\n%s",
+			http.StatusOK, expectedCSV, response.StatusCode, string(body))
+	}
+	if repo.queries != 2 {
+		t.Errorf("Expected 2 page queries, but got %d", repo.queries)
+	}
+
+	// export as JSON
+	req, _ = http.NewRequest("GET", "/api/products/user/export/?format=json",
+		nil)
+	response, err = a.FiberApp.Test(req)
+	if err != nil {
+		t.Fatalf("There's an error serve http testing => %s", err.Error())
+	}
+	defer response.Body.Close()
+
+	exported := []ProductExport{}
+	err = json.NewDecoder(response.Body).Decode(&exported)
+	if err != nil {
+		t.Fatalf("There's an error when decoding response => %s", err.Error())
+	}
+	SKUs := []string{}
+	for _, p := range exported {
+		SKUs = append(SKUs, p.SKU)
+	}
+	if !reflect.DeepEqual(SKUs, []string{"M", "K", "H"}) ||
+		!strings.HasSuffix(exported[0].ImageURLs[0], "/media/product-image/Mouse.png") {
+		t.Errorf("Expected products M, K, H with image URLs, but got %+v",
+			exported)
+	}
+
+	// invalid format
+	req, _ = http.NewRequest("GET", "/api/products/user/export/?format=xml",
+		nil)
+	response, err = a.FiberApp.Test(req)
+	if err != nil {
+		t.Fatalf("There's an error serve http testing => %s", err.Error())
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status %d got %d",
+			http.StatusBadRequest, response.StatusCode)
+	}
+}
@@ -0,0 +1,386 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/config"
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+	"github.com/reyhanfikridz/ecom-product-service/internal/permission"
+	"github.com/reyhanfikridz/ecom-product-service/internal/utils"
+)
+
+// statuses of export job
+const (
+	ExportJobQueued  = "queued"
+	ExportJobRunning = "running"
+	ExportJobDone    = "done"
+	ExportJobFailed  = "failed"
+)
+
+// maximum export jobs waiting in queue, and workers running them
+const (
+	maxQueuedExportJobs = 100
+	exportJobWorkers    = 2
+)
+
+// exportDownloadURLTTL how long signed download URL of an export job valid
+var exportDownloadURLTTL = time.Hour
+
+// errExportJobQueueFull error of export job queued when queue is full
+var errExportJobQueueFull = errors.New("too many export jobs queued, " +
+	"please try again later")
+
+// ExportJob contain status of an asynchronous export of products of
+// a seller, download URL is only set when the export done
+type ExportJob struct {
+	ID          string     `json:"id"`
+	UserID      int        `json:"user_id"`
+	Format      string     `json:"format"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	Count       int        `json:"count"`
+	CreatedAt   time.Time  `json:"created_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	DownloadURL string     `json:"download_url,omitempty"`
+
+	originURL string
+	path      string
+}
+
+// exportJobRunner write exported products of export job into w,
+// returning count of exported products
+type exportJobRunner func(ctx context.Context, job ExportJob,
+	w io.Writer) (int, error)
+
+// ExportJobQueue queue of export jobs run in background writing their
+// files into a directory, finished jobs and their files are removed
+// after TTL, safe to be used while serving requests
+type ExportJobQueue struct {
+	mu    sync.Mutex
+	dir   string
+	ttl   time.Duration
+	jobs  map[string]*ExportJob
+	queue chan string
+}
+
+// NewExportJobQueue create export job queue writing files into dir,
+// keeping finished jobs for ttl
+func NewExportJobQueue(dir string, ttl time.Duration) *ExportJobQueue {
+	return &ExportJobQueue{
+		dir:   dir,
+		ttl:   ttl,
+		jobs:  map[string]*ExportJob{},
+		queue: make(chan string, maxQueuedExportJobs),
+	}
+}
+
+// Start start workers running queued export jobs with run in background
+func (q *ExportJobQueue) Start(workers int, run exportJobRunner) {
+	for i := 0; i < workers; i++ {
+		go func() {
+			for ID := range q.queue {
+				q.run(ID, run)
+			}
+		}()
+	}
+}
+
+// Enqueue queue export job of products of user ID in format,
+// with image URLs of origin URL
+//
+// return error if queue is full
+func (q *ExportJobQueue) Enqueue(userID int, format string,
+	originURL string) (ExportJob, error) {
+	ID, err := utils.GetRandomSecret(16)
+	if err != nil {
+		return ExportJob{}, err
+	}
+
+	job := &ExportJob{
+		ID:        ID,
+		UserID:    userID,
+		Format:    format,
+		Status:    ExportJobQueued,
+		CreatedAt: time.Now().UTC(),
+		originURL: originURL,
+		path:      filepath.Join(q.dir, ID+"."+format),
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.prune(time.Now())
+
+	select {
+	case q.queue <- ID:
+	default:
+		return ExportJob{}, errExportJobQueueFull
+	}
+	q.jobs[ID] = job
+
+	return *job, nil
+}
+
+// Get get export job by ID, not found if it's expired
+func (q *ExportJobQueue) Get(ID string) (ExportJob, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.prune(time.Now())
+
+	job, ok := q.jobs[ID]
+	if !ok {
+		return ExportJob{}, false
+	}
+
+	return *job, true
+}
+
+// run run queued export job by ID writing its file with run
+func (q *ExportJobQueue) run(ID string, run exportJobRunner) {
+	q.mu.Lock()
+	job, ok := q.jobs[ID]
+	if !ok {
+		q.mu.Unlock()
+		return
+	}
+	job.Status = ExportJobRunning
+	snapshot := *job
+	q.mu.Unlock()
+
+	count, err := q.writeFile(snapshot, run)
+	if err != nil {
+		log.Printf("There's an error when running export job %s of user "+
+			"%d => %s", ID, snapshot.UserID, err.Error())
+		os.Remove(snapshot.path)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	finishedAt := time.Now().UTC()
+	job.FinishedAt = &finishedAt
+	job.Count = count
+	job.Status = ExportJobDone
+	if err != nil {
+		job.Status = ExportJobFailed
+		job.Error = err.Error()
+	}
+}
+
+// writeFile create file of export job then write exported products
+// into it with run, returning count of exported products
+func (q *ExportJobQueue) writeFile(job ExportJob, run exportJobRunner) (
+	int, error) {
+	err := os.MkdirAll(q.dir, 0755)
+	if err != nil {
+		return 0, err
+	}
+
+	f, err := os.Create(job.path)
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := run(context.Background(), job, f)
+	if err != nil {
+		f.Close()
+		return count, err
+	}
+
+	return count, f.Close()
+}
+
+// prune remove export jobs finished more than TTL before now with
+// their files, must be called while holding the lock
+func (q *ExportJobQueue) prune(now time.Time) {
+	for ID, job := range q.jobs {
+		if job.FinishedAt == nil || now.Sub(*job.FinishedAt) < q.ttl {
+			continue
+		}
+
+		err := os.Remove(job.path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Printf("There's an error when removing file of export "+
+				"job %s => %s", ID, err.Error())
+		}
+		delete(q.jobs, ID)
+	}
+}
+
+// InitExportJobs initialize API export job queue writing files into dir
+// and keeping finished jobs for ttl, then start its workers
+func (a *API) InitExportJobs(dir string, ttl time.Duration) {
+	a.ExportJobs = NewExportJobQueue(dir, ttl)
+	a.ExportJobs.Start(exportJobWorkers, a.runExportJob)
+}
+
+// getExportJobs get export job queue of API, initialized of export
+// job config if it's not initialized
+func (a *API) getExportJobs() *ExportJobQueue {
+	if a.ExportJobs == nil {
+		a.InitExportJobs(config.ExportJobDir, config.ExportJobTTL)
+	}
+
+	return a.ExportJobs
+}
+
+// runExportJob write all products of the export job user into w
+// in the export job format, returning count of exported products
+func (a *API) runExportJob(ctx context.Context, job ExportJob,
+	w io.Writer) (int, error) {
+	query := model.ProductQuery{UserID: job.UserID, Limit: exportPageSize}
+	products, err := a.Repo.GetProducts(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	return a.writeProductExport(ctx, w, job.Format, job.originURL, query,
+		products)
+}
+
+// SignExportDownload get signature of download URL of export job by ID
+// valid until expires (unix seconds)
+func SignExportDownload(ID string, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(config.JWTSecretKey))
+	mac.Write([]byte(fmt.Sprintf("%s:%d", ID, expires)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// getExportDownloadURL get signed download URL of export job by ID
+// at origin URL, valid for exportDownloadURLTTL from now
+func getExportDownloadURL(originURL string, ID string) string {
+	expires := time.Now().Add(exportDownloadURLTTL).Unix()
+	return fmt.Sprintf("%s/api/products/export-jobs/%s/download/"+
+		"?expires=%d&signature=%s", originURL, ID, expires,
+		SignExportDownload(ID, expires))
+}
+
+// AddExportJobHandler handling route queue export of all products of
+// the seller as CSV or JSON file, whose status and download URL
+// got from GetExportJobHandler (method: POST, user: seller)
+func (a *API) AddExportJobHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// check user role is allowed to access this API
+	if !a.isAllowed(u, permission.ProductReadOwn) {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this API",
+		})
+	}
+
+	// get export format from url
+	format := c.Query("format", "csv")
+	if format != "csv" && format != "json" {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": "parameter 'format' invalid, must be 'csv' or 'json'",
+		})
+	}
+
+	// queue export job
+	job, err := a.getExportJobs().Enqueue(u.ID, format, c.BaseURL())
+	if errors.Is(err, errExportJobQueueFull) {
+		return c.Status(http.StatusServiceUnavailable).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	} else if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": fmt.Sprintf(
+				"There's an error when queueing the export job => %s",
+				err.Error()),
+		})
+	}
+
+	c.Location(fmt.Sprintf("%s/api/products/export-jobs/%s/",
+		c.BaseURL(), job.ID))
+	return c.Status(http.StatusAccepted).JSON(job)
+}
+
+// GetExportJobHandler handling route get export job by ID, with
+// signed download URL when the export done (method: GET, user: seller)
+func (a *API) GetExportJobHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// check user role is allowed to access this API
+	if !a.isAllowed(u, permission.ProductReadOwn) {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this API",
+		})
+	}
+
+	// get export job of the user, other user's jobs are not found
+	job, ok := a.getExportJobs().Get(c.Params("id"))
+	if !ok || job.UserID != u.ID {
+		return c.Status(http.StatusNotFound).JSON(map[string]string{
+			"message": "export job not found",
+		})
+	}
+
+	if job.Status == ExportJobDone {
+		job.DownloadURL = getExportDownloadURL(c.BaseURL(), job.ID)
+	}
+
+	return c.Status(http.StatusOK).JSON(job)
+}
+
+// DownloadExportJobHandler handling route download file of done export
+// job by ID, authorized by signature of the download URL instead of user
+// (method: GET, user: anyone with the signed URL)
+func (a *API) DownloadExportJobHandler(c *fiber.Ctx) error {
+	// check download URL signed and not expired
+	ID := c.Params("id")
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil || !hmac.Equal([]byte(c.Query("signature")),
+		[]byte(SignExportDownload(ID, expires))) {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "parameter 'signature' invalid",
+		})
+	}
+	if time.Now().Unix() > expires {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "download URL expired",
+		})
+	}
+
+	// get done export job
+	job, ok := a.getExportJobs().Get(ID)
+	if !ok {
+		return c.Status(http.StatusNotFound).JSON(map[string]string{
+			"message": "export job not found",
+		})
+	}
+	if job.Status != ExportJobDone {
+		return c.Status(http.StatusConflict).JSON(map[string]string{
+			"message": fmt.Sprintf("export job is %s, not done", job.Status),
+		})
+	}
+
+	return c.Download(job.path, fmt.Sprintf("products-%d-%s.%s",
+		job.UserID, job.CreatedAt.Format("20060102T150405Z"), job.Format))
+}
@@ -0,0 +1,147 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+)
+
+// TestExportJobHandlers test AddExportJobHandler, GetExportJobHandler,
+// and DownloadExportJobHandler with product repository in memory
+func TestExportJobHandlers(t *testing.T) {
+	repo := &exportRepository{products: []model.Product{
+		{ProductInfo: model.ProductInfo{ID: 1, SKU: "M", Name: "Mouse",
+			UserID: 3}},
+		{ProductInfo: model.ProductInfo{ID: 2, SKU: "K", Name: "Keyboard",
+			UserID: 3}},
+		{ProductInfo: model.ProductInfo{ID: 3, SKU: "O", Name: "Other",
+			UserID: 4}},
+	}}
+
+	a := API{Repo: repo, FiberApp: fiber.New()}
+	a.InitExportJobs(t.TempDir(), time.Hour)
+	a.FiberApp.Get("/api/products/export-jobs/:id/download/",
+		a.DownloadExportJobHandler)
+	a.FiberApp.Post("/api/products/export-jobs/",
+		AuthorizationMiddlewareForTest(middleware.User{ID: 3, Role: "seller"}),
+		a.AddExportJobHandler)
+	a.FiberApp.Get("/api/products/export-jobs/:id/",
+		AuthorizationMiddlewareForTest(middleware.User{ID: 3, Role: "seller"}),
+		a.GetExportJobHandler)
+	a.FiberApp.Get("/other/export-jobs/:id/",
+		AuthorizationMiddlewareForTest(middleware.User{ID: 4, Role: "seller"}),
+		a.GetExportJobHandler)
+
+	// queue export job
+	req, _ := http.NewRequest("POST", "/api/products/export-jobs/?format=json",
+		nil)
+	response, err := a.FiberApp.Test(req)
+	if err != nil {
+		t.Fatalf("There's an error serve http testing => %s", err.Error())
+	}
+	defer response.Body.Close()
+
+	job := ExportJob{}
+	err = json.NewDecoder(response.Body).Decode(&job)
+	if err != nil {
+		t.Fatalf("There's an error when decoding response => %s", err.Error())
+	}
+	if response.StatusCode != http.StatusAccepted || job.ID == "" ||
+		job.Status != ExportJobQueued {
+		t.Fatalf("Expected status %d with queued job, but got %d with %+v",
+			http.StatusAccepted, response.StatusCode, job)
+	}
+
+	// wait export job done
+	for i := 0; i < 100 && job.Status != ExportJobDone; i++ {
+		time.Sleep(10 * time.Millisecond)
+
+		req, _ = http.NewRequest("GET", "/api/products/export-jobs/"+
+			job.ID+"/", nil)
+		response, err = a.FiberApp.Test(req)
+		if err != nil {
+			t.Fatalf("There's an error serve http testing => %s", err.Error())
+		}
+		defer response.Body.Close()
+
+		err = json.NewDecoder(response.Body).Decode(&job)
+		if err != nil {
+			t.Fatalf("There's an error when decoding response => %s",
+				err.Error())
+		}
+	}
+	if job.Status != ExportJobDone || job.Count != 2 ||
+		job.DownloadURL == "" {
+		t.Fatalf("Expected done job of 2 products with download URL, "+
+			"but got %+v", job)
+	}
+
+	// export job of other user not found
+	req, _ = http.NewRequest("GET", "/other/export-jobs/"+job.ID+"/", nil)
+	response, err = a.FiberApp.Test(req)
+	if err != nil {
+		t.Fatalf("There's an error serve http testing => %s", err.Error())
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status %d got %d",
+			http.StatusNotFound, response.StatusCode)
+	}
+
+	// download exported file with signed URL
+	downloadURL, err := url.Parse(job.DownloadURL)
+	if err != nil {
+		t.Fatalf("Expected download URL valid, but got error => %s",
+			err.Error())
+	}
+	req, _ = http.NewRequest("GET", downloadURL.RequestURI(), nil)
+	response, err = a.FiberApp.Test(req)
+	if err != nil {
+		t.Fatalf("There's an error serve http testing => %s", err.Error())
+	}
+	defer response.Body.Close()
+
+	body, _ := io.ReadAll(response.Body)
+	if response.StatusCode != http.StatusOK ||
+		!strings.Contains(string(body), `"sku":"M"`) ||
+		!strings.Contains(string(body), `"sku":"K"`) ||
+		strings.Contains(string(body), `"sku":"O"`) {
+		t.Errorf("Expected status %d with products M and K, but got %d "+
+			"with:\n%s", http.StatusOK, response.StatusCode, string(body))
+	}
+
+	// download with tampered or expired signature
+	query := downloadURL.Query()
+	query.Set("signature", strings.Repeat("0", 64))
+	expired := time.Now().Add(-time.Minute).Unix()
+	for _, requestURI := range []string{
+		downloadURL.Path + "?" + query.Encode(),
+		fmt.Sprintf("%s?expires=%d&signature=%s", downloadURL.Path,
+			expired, SignExportDownload(job.ID, expired)),
+	} {
+		req, _ = http.NewRequest("GET", requestURI, nil)
+		response, err = a.FiberApp.Test(req)
+		if err != nil {
+			t.Fatalf("There's an error serve http testing => %s", err.Error())
+		}
+		defer response.Body.Close()
+
+		if response.StatusCode != http.StatusForbidden {
+			t.Errorf("Expected status %d for %s, but got %d",
+				http.StatusForbidden, requestURI, response.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/config"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+)
+
+// GetGoogleMerchantFeedHandler handling route get Google Merchant Center
+// feed of all visible products as RSS 2.0 XML, streamed page by page
+// (method: GET, user: anyone with the feed token)
+func (a *API) GetGoogleMerchantFeedHandler(c *fiber.Ctx) error {
+	// check feed enabled and token matched
+	if config.FeedToken == "" {
+		return c.Status(http.StatusNotFound).JSON(map[string]string{
+			"message": "product feed disabled",
+		})
+	}
+	if subtle.ConstantTimeCompare([]byte(c.Query("token")),
+		[]byte(config.FeedToken)) != 1 {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "parameter 'token' invalid",
+		})
+	}
+
+	// get first page before streaming so database error can be replied
+	query := model.ProductQuery{ExcludeHidden: true, Limit: exportPageSize}
+	products, err := a.Repo.GetProducts(c.UserContext(), query)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": fmt.Sprintf(
+				"There's an error when getting the products data => %s",
+				err.Error()),
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationXMLCharsetUTF8)
+
+	// stream the rest pages after handler returned, so request context
+	// is not used anymore
+	originURL := c.BaseURL()
+	c.Status(http.StatusOK).Context().SetBodyStreamWriter(
+		func(w *bufio.Writer) {
+			_, err := a.writeProductExport(context.Background(), w,
+				"google-merchant", originURL, query, products)
+			if err != nil {
+				log.Printf("There's an error when writing Google Merchant "+
+					"feed => %s", err.Error())
+			}
+		})
+
+	return nil
+}
+
+// googleMerchantItem product item of Google Merchant Center feed
+type googleMerchantItem struct {
+	XMLName                xml.Name `xml:"item"`
+	ID                     string   `xml:"g:id"`
+	Title                  string   `xml:"g:title"`
+	Description            string   `xml:"g:description"`
+	Link                   string   `xml:"g:link"`
+	ImageLink              string   `xml:"g:image_link,omitempty"`
+	AdditionalImageLinks   []string `xml:"g:additional_image_link"`
+	Availability           string   `xml:"g:availability"`
+	Price                  string   `xml:"g:price"`
+	SalePrice              string   `xml:"g:sale_price,omitempty"`
+	SalePriceEffectiveDate string   `xml:"g:sale_price_effective_date,omitempty"`
+	Condition              string   `xml:"g:condition"`
+	GTIN                   string   `xml:"g:gtin,omitempty"`
+	IdentifierExists       string   `xml:"g:identifier_exists,omitempty"`
+	ShippingWeight         string   `xml:"g:shipping_weight"`
+}
+
+// maximum additional image links of a Google Merchant Center item
+const maxAdditionalImageLinks = 10
+
+// googleMerchantProductExporter write exported products as items of
+// Google Merchant Center RSS 2.0 feed
+type googleMerchantProductExporter struct {
+	w   io.Writer
+	enc *xml.Encoder
+	now time.Time
+	err error
+}
+
+// newGoogleMerchantProductExporter create Google Merchant Center product
+// exporter with feed channel header, sale prices are checked at the instant
+func newGoogleMerchantProductExporter(w io.Writer,
+	now time.Time) *googleMerchantProductExporter {
+	e := &googleMerchantProductExporter{w: w, enc: xml.NewEncoder(w), now: now}
+
+	var link bytes.Buffer
+	xml.EscapeText(&link, []byte(config.FrontendURL))
+	_, e.err = io.WriteString(w, xml.Header+
+		`<rss version="2.0" xmlns:g="http://base.google.com/ns/1.0">`+"\n"+
+		"<channel>\n"+
+		"<title>Products</title>\n"+
+		"<link>"+link.String()+"</link>\n"+
+		"<description>Product catalog feed</description>\n")
+	return e
+}
+
+// Write write product as feed item
+func (e *googleMerchantProductExporter) Write(p ProductExport) error {
+	if e.err != nil {
+		return e.err
+	}
+
+	item := googleMerchantItem{
+		ID:          p.SKU,
+		Title:       p.Name,
+		Description: p.Description,
+		Link: strings.TrimSuffix(config.FrontendURL, "/") + "/product/" +
+			url.PathEscape(p.SKU),
+		Availability: "out_of_stock",
+		Price:        p.Price.String() + " " + config.Currency,
+		Condition:    "new",
+		GTIN:         p.Barcode,
+		ShippingWeight: strconv.FormatFloat(float64(p.Weight), 'f', -1, 32) +
+			" kg",
+	}
+	if p.Stock > 0 {
+		item.Availability = "in_stock"
+	}
+	if len(p.ImageURLs) > 0 {
+		item.ImageLink = p.ImageURLs[0]
+		item.AdditionalImageLinks = p.ImageURLs[1:]
+		if len(item.AdditionalImageLinks) > maxAdditionalImageLinks {
+			item.AdditionalImageLinks =
+				item.AdditionalImageLinks[:maxAdditionalImageLinks]
+		}
+	}
+	if p.IsOnSale(e.now) {
+		item.SalePrice = p.SalePrice.String() + " " + config.Currency
+		if p.SaleStartsAt != nil && p.SaleEndsAt != nil {
+			item.SalePriceEffectiveDate =
+				p.SaleStartsAt.UTC().Format(time.RFC3339) + "/" +
+					p.SaleEndsAt.UTC().Format(time.RFC3339)
+		}
+	}
+	if p.Barcode == "" {
+		item.IdentifierExists = "no"
+	}
+
+	e.err = e.enc.Encode(item)
+	if e.err == nil {
+		_, e.err = io.WriteString(e.w, "\n")
+	}
+	return e.err
+}
+
+// Close close the feed channel
+func (e *googleMerchantProductExporter) Close() error {
+	if e.err != nil {
+		return e.err
+	}
+
+	_, err := io.WriteString(e.w, "</channel>\n</rss>\n")
+	return err
+}
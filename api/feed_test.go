@@ -0,0 +1,147 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/config"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+)
+
+// TestGetGoogleMerchantFeedHandler test GetGoogleMerchantFeedHandler
+// with product repository in memory
+func TestGetGoogleMerchantFeedHandler(t *testing.T) {
+	config.FrontendURL = "http://localhost:3000"
+	config.Currency = "IDR"
+	config.FeedToken = "feed-secret"
+	defer func() { config.FeedToken = "" }()
+
+	saleStartsAt := time.Now().Add(-time.Hour)
+	saleEndsAt := time.Now().Add(time.Hour)
+	a := API{
+		Repo: fakeRepository{products: map[string]model.Product{
+			"SKU-A": {
+				ProductInfo: model.ProductInfo{
+					SKU:          "SKU-A",
+					Name:         "Wireless Mouse & Pad",
+					Price:        15000050,
+					Weight:       0.5,
+					Stock:        3,
+					SalePrice:    12000000,
+					SaleStartsAt: &saleStartsAt,
+					SaleEndsAt:   &saleEndsAt,
+				},
+				ProductImages: []model.ProductImage{
+					{ImagePath: "product-image/1-a.png"},
+					{ImagePath: "product-image/2-a.png"},
+				},
+			},
+			"SKU-B": {ProductInfo: model.ProductInfo{
+				SKU:     "SKU-B",
+				Name:    "Keyboard",
+				Price:   25000000,
+				Weight:  1,
+				Barcode: "4006381333931",
+			}},
+			"SKU-C": {ProductInfo: model.ProductInfo{
+				SKU:    "SKU-C",
+				Name:   "Hidden Product",
+				Price:  10000,
+				Weight: 1,
+				Hidden: true,
+			}},
+		}},
+		FiberApp: fiber.New(),
+	}
+	a.FiberApp.Get("/api/feeds/google-merchant.xml",
+		a.GetGoogleMerchantFeedHandler)
+
+	// get feed with invalid token
+	req, _ := http.NewRequest("GET",
+		"/api/feeds/google-merchant.xml?token=wrong", nil)
+	response, err := a.FiberApp.Test(req)
+	if err != nil {
+		t.Fatalf("There's an error serve http testing => %s", err.Error())
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status %d got %d", http.StatusForbidden,
+			response.StatusCode)
+	}
+
+	// get feed
+	req, _ = http.NewRequest("GET",
+		"/api/feeds/google-merchant.xml?token=feed-secret", nil)
+	response, err = a.FiberApp.Test(req)
+	if err != nil {
+		t.Fatalf("There's an error serve http testing => %s", err.Error())
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d got %d", http.StatusOK,
+			response.StatusCode)
+	}
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatalf("There's an error when reading response => %s", err.Error())
+	}
+	feed := struct {
+		Items []struct {
+			ID                   string   `xml:"id"`
+			Title                string   `xml:"title"`
+			Link                 string   `xml:"link"`
+			ImageLink            string   `xml:"image_link"`
+			AdditionalImageLinks []string `xml:"additional_image_link"`
+			Availability         string   `xml:"availability"`
+			Price                string   `xml:"price"`
+			SalePrice            string   `xml:"sale_price"`
+			GTIN                 string   `xml:"gtin"`
+			IdentifierExists     string   `xml:"identifier_exists"`
+		} `xml:"channel>item"`
+	}{}
+	err = xml.Unmarshal(body, &feed)
+	if err != nil {
+		t.Fatalf("Expected valid XML feed, but got error => %s\n%s",
+			err.Error(), body)
+	}
+
+	if len(feed.Items) != 2 {
+		t.Fatalf("Expected 2 visible products in feed, but got %d\n%s",
+			len(feed.Items), body)
+	}
+	for _, item := range feed.Items {
+		switch item.ID {
+		case "SKU-A":
+			if item.Title != "Wireless Mouse & Pad" ||
+				item.Link != "http://localhost:3000/product/SKU-A" ||
+				!strings.HasSuffix(item.ImageLink,
+					"/media/product-image/1-a.png") ||
+				len(item.AdditionalImageLinks) != 1 ||
+				item.Availability != "in_stock" ||
+				item.Price != "150000.5 IDR" ||
+				item.SalePrice != "120000 IDR" ||
+				item.IdentifierExists != "no" {
+				t.Errorf("Expected SKU-A item on sale with images, "+
+					"but got %+v", item)
+			}
+		case "SKU-B":
+			if item.Availability != "out_of_stock" ||
+				item.Price != "250000 IDR" || item.SalePrice != "" ||
+				item.GTIN != "4006381333931" || item.IdentifierExists != "" {
+				t.Errorf("Expected SKU-B item out of stock with GTIN, "+
+					"but got %+v", item)
+			}
+		default:
+			t.Errorf("Expected no item %s in feed", item.ID)
+		}
+	}
+}
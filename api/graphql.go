@@ -0,0 +1,341 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/graphql-go/graphql"
+	"github.com/reyhanfikridz/ecom-product-service/internal/account"
+	"github.com/reyhanfikridz/ecom-product-service/internal/config"
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+	"github.com/reyhanfikridz/ecom-product-service/internal/permission"
+	"github.com/reyhanfikridz/ecom-product-service/internal/search"
+)
+
+// graphQLUserKey context key for user data in graphql resolver
+type graphQLUserKey struct{}
+
+// GraphQLRequest contain graphql request body
+type GraphQLRequest struct {
+	Query         string                 `json:"query" query:"query"`
+	OperationName string                 `json:"operationName" query:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+var (
+	// moneyType amount of money as decimal number of major units
+	moneyType = graphql.NewScalar(graphql.ScalarConfig{
+		Name:        "Money",
+		Description: "amount of money as decimal number of major units",
+		Serialize: func(value interface{}) interface{} {
+			m, ok := value.(model.Money)
+			if !ok {
+				return nil
+			}
+			return m.Float()
+		},
+	})
+
+	sellerInfoType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "SellerInfo",
+		Fields: graphql.Fields{
+			"email":        &graphql.Field{Type: graphql.String},
+			"full_name":    &graphql.Field{Type: graphql.String},
+			"address":      &graphql.Field{Type: graphql.String},
+			"phone_number": &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	productInfoType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "ProductInfo",
+		Fields: graphql.Fields{
+			"id":                 &graphql.Field{Type: graphql.Int},
+			"sku":                &graphql.Field{Type: graphql.String},
+			"name":               &graphql.Field{Type: graphql.String},
+			"price":              &graphql.Field{Type: moneyType},
+			"weight":             &graphql.Field{Type: graphql.Float},
+			"description":        &graphql.Field{Type: graphql.String},
+			"description_format": &graphql.Field{Type: graphql.String},
+			"description_html":   &graphql.Field{Type: graphql.String},
+			"stock":              &graphql.Field{Type: graphql.Float},
+			"unit":               &graphql.Field{Type: graphql.String},
+			"min_order_qty":      &graphql.Field{Type: graphql.Float},
+			"max_order_qty":      &graphql.Field{Type: graphql.Float},
+			"view_count":         &graphql.Field{Type: graphql.Int},
+			"avg_rating":         &graphql.Field{Type: graphql.Float},
+			"review_count":       &graphql.Field{Type: graphql.Int},
+			"wishlist_count":     &graphql.Field{Type: graphql.Int},
+			"order_count":        &graphql.Field{Type: graphql.Int},
+			"sale_price":         &graphql.Field{Type: moneyType},
+			"sale_starts_at":     &graphql.Field{Type: graphql.DateTime},
+			"sale_ends_at":       &graphql.Field{Type: graphql.DateTime},
+			"effective_price":    &graphql.Field{Type: moneyType},
+			"user_id":            &graphql.Field{Type: graphql.Int},
+			"created_at":         &graphql.Field{Type: graphql.DateTime},
+			"updated_at":         &graphql.Field{Type: graphql.DateTime},
+			"version":            &graphql.Field{Type: graphql.Int},
+			"hidden":             &graphql.Field{Type: graphql.Boolean},
+			"barcode":            &graphql.Field{Type: graphql.String},
+			"length":             &graphql.Field{Type: graphql.Float},
+			"width":              &graphql.Field{Type: graphql.Float},
+			"height":             &graphql.Field{Type: graphql.Float},
+			"volumetric_weight":  &graphql.Field{Type: graphql.Float},
+		},
+	})
+
+	productImageType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "ProductImage",
+		Fields: graphql.Fields{
+			"id":         &graphql.Field{Type: graphql.Int},
+			"image_path": &graphql.Field{Type: graphql.String},
+			"created_at": &graphql.Field{Type: graphql.DateTime},
+		},
+	})
+
+	priceTierType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "PriceTier",
+		Fields: graphql.Fields{
+			"min_qty": &graphql.Field{Type: graphql.Float},
+			"price":   &graphql.Field{Type: moneyType},
+		},
+	})
+
+	productType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Product",
+		Fields: graphql.Fields{
+			"product_info":   &graphql.Field{Type: productInfoType},
+			"product_images": &graphql.Field{Type: graphql.NewList(productImageType)},
+			"price_tiers":    &graphql.Field{Type: graphql.NewList(priceTierType)},
+			"seller_info": &graphql.Field{
+				Type: sellerInfoType,
+				// seller info only fetched from account service
+				// if it's requested
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					product, ok := p.Source.(model.Product)
+					if !ok {
+						return nil, nil
+					}
+					return GetSellerInfo(p.Context, getGraphQLAccounts(p),
+						product.ProductInfo.UserID)
+				},
+			},
+		},
+	})
+
+	queryType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"products": &graphql.Field{
+				Type: graphql.NewList(productType),
+				Description: "products of the market, or own products of " +
+					"seller if 'own' is true, defaulting to own products " +
+					"for user who can't list the market, at most 'limit' " +
+					"products (default and maximum 100)",
+				Args: graphql.FieldConfigArgument{
+					"own":     &graphql.ArgumentConfig{Type: graphql.Boolean},
+					"search":  &graphql.ArgumentConfig{Type: graphql.String},
+					"fuzzy":   &graphql.ArgumentConfig{Type: graphql.Boolean},
+					"sort":    &graphql.ArgumentConfig{Type: graphql.String},
+					"on_sale": &graphql.ArgumentConfig{Type: graphql.Boolean},
+					"limit":   &graphql.ArgumentConfig{Type: graphql.Int},
+					"offset":  &graphql.ArgumentConfig{Type: graphql.Int},
+				},
+				Resolve: resolveProducts,
+			},
+			"product": &graphql.Field{
+				Type: productType,
+				Args: graphql.FieldConfigArgument{
+					"sku": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.String),
+					},
+				},
+				Resolve: resolveProduct,
+			},
+		},
+	})
+
+	// ProductSchema graphql schema of product service
+	ProductSchema graphql.Schema
+)
+
+// init build graphql schema
+func init() {
+	var err error
+	ProductSchema, err = graphql.NewSchema(graphql.SchemaConfig{
+		Query: queryType,
+	})
+	if err != nil {
+		panic(fmt.Sprintf("graphql schema invalid => %s", err.Error()))
+	}
+}
+
+// resolveProducts resolve graphql query products
+func resolveProducts(p graphql.ResolveParams) (interface{}, error) {
+	repo, u, err := getGraphQLResolveData(p)
+	if err != nil {
+		return nil, err
+	}
+
+	// get scope of products, own products if user can't list the market
+	// and scope not given, so user with several roles can choose
+	permissions := getGraphQLPermissions(p)
+	own, ok := p.Args["own"].(bool)
+	if !ok {
+		own = !permissions.AllowsAny(u.GetRoles(), permission.ProductList)
+	}
+
+	pq := model.ProductQuery{}
+	if own {
+		if !permissions.AllowsAny(u.GetRoles(), permission.ProductReadOwn) {
+			return nil, fmt.Errorf("user doesn't have authority to access this API")
+		}
+		pq.UserID = u.ID
+	} else {
+		if !permissions.AllowsAny(u.GetRoles(), permission.ProductList) {
+			return nil, fmt.Errorf("user doesn't have authority to access this API")
+		}
+		pq.ExcludeHidden = true
+	}
+
+	pq.Search, _ = p.Args["search"].(string)
+	pq.Fuzzy, _ = p.Args["fuzzy"].(bool)
+	pq.Sort, _ = p.Args["sort"].(string)
+	pq.OnSale, _ = p.Args["on_sale"].(bool)
+
+	// paginate products in database, limited like product listing
+	pq.Limit = maxProductsLimit
+	if limit, ok := p.Args["limit"].(int); ok {
+		if limit <= 0 || limit > maxProductsLimit {
+			return nil, fmt.Errorf("argument 'limit' invalid, must be "+
+				"integer between 1 and %d", maxProductsLimit)
+		}
+		pq.Limit = limit
+	}
+	pq.Offset, _ = p.Args["offset"].(int)
+	if pq.Offset < 0 {
+		pq.Offset = 0
+	}
+
+	// resolve search by search provider
+	root, _ := p.Info.RootValue.(map[string]interface{})
+	if provider, ok := root["search"].(search.SearchProvider); ok {
+		pq, err = provider.Query(p.Context, pq)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return repo.GetProducts(p.Context, pq)
+}
+
+// resolveProduct resolve graphql query product
+func resolveProduct(p graphql.ResolveParams) (interface{}, error) {
+	repo, u, err := getGraphQLResolveData(p)
+	if err != nil {
+		return nil, err
+	}
+
+	SKU, _ := p.Args["sku"].(string)
+	product, err := repo.GetProductBySKU(p.Context, SKU)
+	if err != nil {
+		return nil, err
+	}
+
+	// hidden product only can be seen by its seller and roles
+	// allowed to read hidden products
+	permissions := getGraphQLPermissions(p)
+	if product.ProductInfo.Hidden && u.ID != product.ProductInfo.UserID &&
+		!permissions.AllowsAny(u.GetRoles(), permission.ProductReadHidden) {
+		return nil, fmt.Errorf("product not found")
+	}
+
+	return product, nil
+}
+
+// getGraphQLResolveData get product repository and user data
+// for graphql resolver
+func getGraphQLResolveData(p graphql.ResolveParams) (
+	model.ProductRepository, middleware.User, error) {
+	u, ok := p.Context.Value(graphQLUserKey{}).(middleware.User)
+	if !ok {
+		return nil, u, fmt.Errorf("user data invalid")
+	}
+
+	root, _ := p.Info.RootValue.(map[string]interface{})
+	repo, ok := root["repo"].(model.ProductRepository)
+	if !ok {
+		return nil, u, fmt.Errorf("product repository invalid")
+	}
+
+	return repo, u, nil
+}
+
+// getGraphQLPermissions get role-permission matrix for graphql resolver,
+// the default matrix if it's not set
+func getGraphQLPermissions(p graphql.ResolveParams) permission.Matrix {
+	root, _ := p.Info.RootValue.(map[string]interface{})
+	permissions, ok := root["permissions"].(permission.Matrix)
+	if !ok {
+		return permission.DefaultMatrix()
+	}
+
+	return permissions
+}
+
+// getGraphQLAccounts get client of account service for graphql resolver,
+// client of account service URL config if it's not set
+func getGraphQLAccounts(p graphql.ResolveParams) *account.Client {
+	root, _ := p.Info.RootValue.(map[string]interface{})
+	accounts, ok := root["accounts"].(*account.Client)
+	if !ok {
+		return account.NewClient(config.AccountServiceURL)
+	}
+
+	return accounts
+}
+
+// GraphQLHandler handling route graphql query (method: GET/POST, user: all)
+func (a *API) GraphQLHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// parse graphql request from body or url
+	req := GraphQLRequest{}
+	var err error
+	if c.Method() == http.MethodGet {
+		err = c.QueryParser(&req)
+	} else {
+		err = c.BodyParser(&req)
+	}
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	// execute graphql query
+	result := graphql.Do(graphql.Params{
+		Schema:         ProductSchema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		RootObject: map[string]interface{}{
+			"repo":        a.Repo,
+			"search":      a.Search,
+			"permissions": a.getPermissions(),
+			"accounts":    a.getAccounts(),
+		},
+		Context: context.WithValue(c.UserContext(), graphQLUserKey{}, u),
+	})
+
+	return c.Status(http.StatusOK).JSON(result)
+}
@@ -0,0 +1,167 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"testing"
+
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+)
+
+// TestGraphQLHandler test GraphQLHandler
+func TestGraphQLHandler(t *testing.T) {
+	// get testing API for create products
+	a, err := GetTestingAPI(middleware.User{})
+	if err != nil {
+		t.Errorf("There's an error when getting testing API => %s",
+			err.Error())
+	}
+
+	// insert products into database
+	for _, pInfo := range []model.ProductInfo{
+		{Name: "PRODUCT A", Price: 1000, Weight: 1, Stock: 10, UserID: 1},
+		{Name: "PRODUCT B", Price: 1000, Weight: 1, Stock: 10, UserID: 2},
+	} {
+		_, err = model.InsertProductInfo(context.Background(), a.DB, pInfo)
+		if err != nil {
+			t.Errorf("There's an error when insert data product info => %s",
+				err.Error())
+		}
+	}
+
+	// create testing table
+	testTable := []struct {
+		TestName       string
+		Query          string
+		User           middleware.User
+		ExpectedLength int
+		ExpectedError  bool
+	}{
+		{
+			TestName:       "Buyer Get All",
+			Query:          "{ products { product_info { sku name } } }",
+			User:           middleware.User{ID: 3, Role: "buyer"},
+			ExpectedLength: 2,
+		},
+		{
+			TestName:       "Buyer Get With Limit",
+			Query:          "{ products(limit: 1) { product_info { sku } } }",
+			User:           middleware.User{ID: 3, Role: "buyer"},
+			ExpectedLength: 1,
+		},
+		{
+			TestName:       "Buyer Get With Offset",
+			Query:          "{ products(limit: 5, offset: 1) { product_info { sku } } }",
+			User:           middleware.User{ID: 3, Role: "buyer"},
+			ExpectedLength: 1,
+		},
+		{
+			TestName:      "Buyer Get With Limit Zero",
+			Query:         "{ products(limit: 0) { product_info { sku } } }",
+			User:          middleware.User{ID: 3, Role: "buyer"},
+			ExpectedError: true,
+		},
+		{
+			TestName:      "Buyer Get With Limit Too Large",
+			Query:         "{ products(limit: 101) { product_info { sku } } }",
+			User:          middleware.User{ID: 3, Role: "buyer"},
+			ExpectedError: true,
+		},
+		{
+			TestName:      "Buyer Get Own",
+			Query:         "{ products(own: true) { product_info { sku } } }",
+			User:          middleware.User{ID: 3, Role: "buyer"},
+			ExpectedError: true,
+		},
+		{
+			TestName:       "Seller Get Own",
+			Query:          "{ products { product_info { sku user_id } } }",
+			User:           middleware.User{ID: 1, Role: "seller"},
+			ExpectedLength: 1,
+		},
+		{
+			TestName:       "Seller And Buyer Get All",
+			Query:          "{ products { product_info { sku } } }",
+			User:           middleware.User{ID: 1, Roles: []string{"seller", "buyer"}},
+			ExpectedLength: 2,
+		},
+		{
+			TestName:       "Seller And Buyer Get Own",
+			Query:          "{ products(own: true) { product_info { sku } } }",
+			User:           middleware.User{ID: 1, Roles: []string{"seller", "buyer"}},
+			ExpectedLength: 1,
+		},
+		{
+			TestName:      "Forbidden",
+			Query:         "{ products { product_info { sku } } }",
+			User:          middleware.User{ID: 1, Role: "admin"},
+			ExpectedError: true,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		// get testing API for graphql query
+		a, err = GetTestingAPI(test.User)
+		if err != nil {
+			t.Errorf("[%s] There's an error when getting testing API => %s",
+				test.TestName, err.Error())
+		}
+
+		// create new request
+		body, _ := json.Marshal(GraphQLRequest{Query: test.Query})
+		req, err := http.NewRequest("POST", "/graphql/", bytes.NewReader(body))
+		if err != nil {
+			t.Errorf("[%s] There's an error when creating "+
+				"request graphql => %s",
+				test.TestName, err.Error())
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		// run request
+		response, err := a.FiberApp.Test(req)
+		if err != nil {
+			t.Errorf("[%s] There's an error serve http testing => %s",
+				test.TestName, err.Error())
+		}
+		defer response.Body.Close()
+
+		// check response
+		var result struct {
+			Data struct {
+				Products []model.Product `json:"products"`
+			} `json:"data"`
+			Errors []map[string]interface{} `json:"errors"`
+		}
+		err = json.NewDecoder(response.Body).Decode(&result)
+		if err != nil {
+			t.Errorf("[%s] There's an error when unmarshal body response => %s",
+				test.TestName, err.Error())
+		}
+
+		if test.ExpectedError && len(result.Errors) == 0 {
+			t.Errorf("[%s] Expected errors, but got no error", test.TestName)
+		} else if !test.ExpectedError && len(result.Errors) > 0 {
+			t.Errorf("[%s] Expected no error, but got %v",
+				test.TestName, result.Errors)
+		} else if len(result.Data.Products) != test.ExpectedLength {
+			t.Errorf("[%s] Expected length data %d, but got %d",
+				test.TestName, test.ExpectedLength, len(result.Data.Products))
+		}
+	}
+
+	// truncate tables after test
+	_, err = a.DB.Exec("TRUNCATE product_productinfo RESTART IDENTITY CASCADE")
+	if err != nil {
+		log.Fatalf("There's an error when truncating "+
+			"table product_productinfo => %s",
+			err.Error())
+	}
+}
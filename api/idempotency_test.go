@@ -0,0 +1,109 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+)
+
+// TestIdempotencyKey test replaying request with the same Idempotency-Key
+//
+// Required for the test: AddProductHandler
+func TestIdempotencyKey(t *testing.T) {
+	a, err := GetTestingAPI(middleware.User{ID: 1, Role: "seller"})
+	if err != nil {
+		t.Errorf("There's an error when getting testing API => %s",
+			err.Error())
+	}
+
+	// transform form data to bytes, each with a new random boundary
+	newFormData := func(name string) ([]byte, string) {
+		var bFormData bytes.Buffer
+		w := multipart.NewWriter(&bFormData)
+		for key, r := range map[string]string{
+			"name":   name,
+			"price":  "1000",
+			"weight": "1.5",
+			"stock":  "100",
+		} {
+			fw, _ := w.CreateFormField(key)
+			io.Copy(fw, strings.NewReader(r))
+		}
+		w.Close()
+
+		return bFormData.Bytes(), w.FormDataContentType()
+	}
+
+	// send request add product twice with the same key, the retry
+	// rebuilding the form with another boundary
+	SKUs := []string{}
+	for i := 0; i < 2; i++ {
+		body, contentType := newFormData("Product 1")
+		req, _ := http.NewRequest("POST", "/api/product/", bytes.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set(middleware.IdempotencyKeyHeader, "key-1")
+
+		response, err := a.FiberApp.Test(req)
+		if err != nil {
+			t.Errorf("There's an error serve http testing => %s", err.Error())
+		}
+		defer response.Body.Close()
+
+		if response.StatusCode != http.StatusCreated {
+			t.Errorf("[%d] Expected status %d got %d",
+				i, http.StatusCreated, response.StatusCode)
+		}
+		if i == 1 && response.Header.Get("Idempotent-Replayed") != "true" {
+			t.Errorf("Expected second response replayed, but it's not")
+		}
+
+		pInfo := model.ProductInfo{}
+		err = json.NewDecoder(response.Body).Decode(&pInfo)
+		if err != nil {
+			t.Errorf("There's an error when unmarshal body response => %s",
+				err.Error())
+		}
+		SKUs = append(SKUs, pInfo.SKU)
+	}
+
+	if len(SKUs) == 2 && SKUs[0] != SKUs[1] {
+		t.Errorf("Expected same product replayed, but got SKU %s and %s",
+			SKUs[0], SKUs[1])
+	}
+
+	// send different request with the same key
+	body, contentType := newFormData("Product 2")
+	req, _ := http.NewRequest("POST", "/api/product/", bytes.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set(middleware.IdempotencyKeyHeader, "key-1")
+	response, err := a.FiberApp.Test(req)
+	if err != nil {
+		t.Errorf("There's an error serve http testing => %s", err.Error())
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status %d got %d",
+			http.StatusUnprocessableEntity, response.StatusCode)
+	}
+
+	// truncate tables after test
+	_, err = a.DB.Exec(`TRUNCATE product_productinfo, product_idempotencykey
+		RESTART IDENTITY CASCADE`)
+	if err != nil {
+		log.Fatalf("There's an error when truncating "+
+			"table product_productinfo => %s",
+			err.Error())
+	}
+}
@@ -0,0 +1,562 @@
+package api
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/config"
+	"github.com/reyhanfikridz/ecom-product-service/internal/event"
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+	"github.com/reyhanfikridz/ecom-product-service/internal/netguard"
+	"github.com/reyhanfikridz/ecom-product-service/internal/permission"
+	"github.com/reyhanfikridz/ecom-product-service/internal/validator"
+	"github.com/reyhanfikridz/ecom-product-service/internal/xlsx"
+)
+
+// maximum products in one CSV or XLSX import
+const maxImportRows = 1000
+
+// ProductImportRow contain a product parsed from a CSV or XLSX import row,
+// Err is not nil if the row invalid
+type ProductImportRow struct {
+	Line        int
+	ProductInfo model.ProductInfo
+	ImageURLs   []string
+	Err         error
+}
+
+// ProductImportResult contain import result of a CSV or XLSX row,
+// SKU of the created product or error why it's not imported
+type ProductImportResult struct {
+	Line  int    `json:"line"`
+	SKU   string `json:"sku,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// imageDownloadClient HTTP client downloading images of imported products,
+// refusing internal addresses since image URLs are given by sellers
+var imageDownloadClient = netguard.NewClient(10 * time.Second)
+
+// ImportProductsHandler handling route import products of the seller
+// from CSV or XLSX, each row imported in its own transaction, or only
+// validated with url query 'dry_run' true (method: POST, user: seller)
+func (a *API) ImportProductsHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// check user role is allowed to access this API
+	if !a.isAllowed(u, permission.ProductImport) {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this API",
+		})
+	}
+
+	// get dry run from url
+	dryRun, err := parseDryRun(c)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	// get CSV or XLSX from form file 'file', or from body
+	b, err := getImportFile(c)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	// parse products from CSV or XLSX
+	rows, err := ParseProductImport(b)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	return a.replyProductImport(c, u, rows, dryRun)
+}
+
+// getImportFile get content of import file from form file 'file',
+// or from body if there's no form file
+func getImportFile(c *fiber.Ctx) ([]byte, error) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Body(), nil
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return io.ReadAll(file)
+}
+
+// replyProductImport import every row of the user, skipping invalid ones,
+// then reply result of each row, or only check every row including its
+// image URLs without importing anything on dry run
+func (a *API) replyProductImport(c *fiber.Ctx, u middleware.User,
+	rows []ProductImportRow, dryRun bool) error {
+	if dryRun {
+		results := []ProductImportResult{}
+		valid := 0
+		for _, row := range rows {
+			result := ProductImportResult{Line: row.Line}
+
+			err := checkProductImportRow(row)
+			if err != nil {
+				result.Error = err.Error()
+			} else {
+				valid++
+			}
+
+			results = append(results, result)
+		}
+
+		return c.Status(http.StatusOK).JSON(map[string]interface{}{
+			"message": fmt.Sprintf("%d of %d products valid, nothing "+
+				"imported on dry run", valid, len(rows)),
+			"dry_run": true,
+			"results": results,
+		})
+	}
+
+	results := []ProductImportResult{}
+	imported := 0
+	for _, row := range rows {
+		result := ProductImportResult{Line: row.Line}
+
+		pInfo, err := a.importProduct(c, u, row)
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.SKU = pInfo.SKU
+			imported++
+		}
+
+		results = append(results, result)
+	}
+
+	return c.Status(http.StatusOK).JSON(map[string]interface{}{
+		"message": fmt.Sprintf("%d of %d products imported",
+			imported, len(rows)),
+		"results": results,
+	})
+}
+
+// importProduct download images of an import row then insert
+// the product with its images in one transaction
+func (a *API) importProduct(c *fiber.Ctx, u middleware.User,
+	row ProductImportRow) (model.ProductInfo, error) {
+	if row.Err != nil {
+		return row.ProductInfo, row.Err
+	}
+
+	images, err := downloadProductImages(row.ImageURLs, u.ID)
+	if err != nil {
+		removeProductImages(images)
+		return row.ProductInfo, err
+	}
+
+	pInfo := row.ProductInfo
+	pInfo.UserID = u.ID
+	pInfo.Hidden = !u.IsVerified()
+	pInfo, err = a.Repo.InsertProductWithImages(c.UserContext(), pInfo,
+		images)
+	if err != nil {
+		removeProductImages(images)
+		return pInfo, err
+	}
+
+	a.PublishEvent(event.NewEvent(event.ProductCreated, pInfo.SKU,
+		pInfo.UserID, pInfo))
+
+	return pInfo, nil
+}
+
+// checkProductImportRow check an import row valid and its image URLs
+// reachable without downloading them
+func checkProductImportRow(row ProductImportRow) error {
+	if row.Err != nil {
+		return row.Err
+	}
+
+	return checkProductImageURLs(row.ImageURLs)
+}
+
+// parseDryRun parse url query 'dry_run' of import routes,
+// false if it's empty
+func parseDryRun(c *fiber.Ctx) (bool, error) {
+	if c.Query("dry_run") == "" {
+		return false, nil
+	}
+
+	dryRun, err := strconv.ParseBool(c.Query("dry_run"))
+	if err != nil {
+		return false, errors.New("parameter 'dry_run' invalid, " +
+			"must be 'true' or 'false'")
+	}
+
+	return dryRun, nil
+}
+
+// ParseProductImport parse products from XLSX workbook if b is one,
+// otherwise from CSV
+func ParseProductImport(b []byte) ([]ProductImportRow, error) {
+	if xlsx.IsWorkbook(b) {
+		return ParseProductImportXLSX(bytes.NewReader(b), int64(len(b)))
+	}
+
+	return ParseProductImportCSV(bytes.NewReader(b))
+}
+
+// ParseProductImportCSV parse products from CSV with header row
+// containing columns name, price, weight, length, width, height, stock,
+// unit, description, description_format, barcode, and image_urls
+// (separated by whitespace), in any order
+//
+// return error if CSV malformed, while invalid rows are returned
+// with their error
+func ParseProductImportCSV(r io.Reader) ([]ProductImportRow, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	// get column index from header row
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, errors.New("CSV empty, header row not found")
+	} else if err != nil {
+		return nil, fmt.Errorf("CSV invalid => %s", err.Error())
+	}
+	columns, err := getProductImportColumns("CSV", header)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []ProductImportRow{}
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("CSV invalid => %s", err.Error())
+		}
+
+		if len(rows) == maxImportRows {
+			return nil, fmt.Errorf("too many CSV rows, maximum %d products",
+				maxImportRows)
+		}
+
+		line, _ := cr.FieldPos(0)
+		rows = append(rows, parseProductImportRecord(line, record, columns))
+	}
+
+	return rows, nil
+}
+
+// ParseProductImportXLSX parse products from the first worksheet of
+// XLSX workbook of size bytes read from r, with the same columns as
+// ParseProductImportCSV in its first row, result line is the worksheet
+// row number
+//
+// number cells are read as Excel stores them, so barcode with leading
+// zeros must be formatted as text
+//
+// return error if workbook malformed, while invalid rows are returned
+// with their error
+func ParseProductImportXLSX(r io.ReaderAt, size int64) (
+	[]ProductImportRow, error) {
+	sheetRows, err := xlsx.ReadRows(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("XLSX invalid => %s", err.Error())
+	}
+	if len(sheetRows) == 0 {
+		return nil, errors.New("XLSX empty, header row not found")
+	}
+
+	// get column index from header row
+	columns, err := getProductImportColumns("XLSX", sheetRows[0].Cells)
+	if err != nil {
+		return nil, err
+	}
+	if len(sheetRows)-1 > maxImportRows {
+		return nil, fmt.Errorf("too many XLSX rows, maximum %d products",
+			maxImportRows)
+	}
+
+	rows := []ProductImportRow{}
+	for _, sheetRow := range sheetRows[1:] {
+		rows = append(rows, parseProductImportRecord(sheetRow.Number,
+			sheetRow.Cells, columns))
+	}
+
+	return rows, nil
+}
+
+// getProductImportColumns get column index by name of header row
+// of import file format
+//
+// return error if required column not found
+func getProductImportColumns(format string, header []string) (
+	map[string]int, error) {
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"name", "price", "weight"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("%s column '%s' not found", format, name)
+		}
+	}
+
+	return columns, nil
+}
+
+// parseProductImportRecord parse product of a CSV or XLSX import record
+func parseProductImportRecord(line int, record []string,
+	columns map[string]int) ProductImportRow {
+	row := ProductImportRow{Line: line}
+
+	get := func(name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var err error
+	row.ProductInfo.Name = get("name")
+	row.ProductInfo.Description = get("description")
+	row.ProductInfo.DescriptionFormat = get("description_format")
+	row.ProductInfo.Barcode = get("barcode")
+	row.ProductInfo.Unit = get("unit")
+	row.ProductInfo.Price, err = model.ParseMoney(get("price"))
+	if err != nil {
+		row.Err = fmt.Errorf("price '%s' invalid", get("price"))
+		return row
+	}
+	weight, err := strconv.ParseFloat(get("weight"), 32)
+	if err != nil {
+		row.Err = fmt.Errorf("weight '%s' invalid", get("weight"))
+		return row
+	}
+	row.ProductInfo.Weight = float32(weight)
+	for _, d := range []struct {
+		name  string
+		value *float32
+	}{
+		{"length", &row.ProductInfo.Length},
+		{"width", &row.ProductInfo.Width},
+		{"height", &row.ProductInfo.Height},
+	} {
+		if get(d.name) == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(get(d.name), 32)
+		if err != nil {
+			row.Err = fmt.Errorf("%s '%s' invalid", d.name, get(d.name))
+			return row
+		}
+		*d.value = float32(value)
+	}
+	if get("stock") != "" {
+		row.ProductInfo.Stock, err = strconv.ParseFloat(get("stock"), 64)
+		if err != nil || row.ProductInfo.Stock < 0 {
+			row.Err = fmt.Errorf("stock '%s' invalid, must be "+
+				"non-negative number", get("stock"))
+			return row
+		}
+	}
+	row.ImageURLs = strings.Fields(get("image_urls"))
+
+	row.Err = validator.IsProductInfoValid(row.ProductInfo)
+	return row
+}
+
+// unsafeFilenameChars characters replaced in downloaded image file name
+var unsafeFilenameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// downloadProductImages download images of URLs of a product of seller
+// of user ID into media folder, returning the saved images
+//
+// return error if there are too many images or their total size too large
+func downloadProductImages(imageURLs []string, userID int) (
+	[]model.ProductImage, error) {
+	if len(imageURLs) > config.MaxProductImages {
+		return nil, fmt.Errorf("too many product images, "+
+			"maximum %d images but got %d",
+			config.MaxProductImages, len(imageURLs))
+	}
+
+	images := []model.ProductImage{}
+	remaining := config.MaxProductImagesSize
+	for _, imageURL := range imageURLs {
+		pImage, size, err := downloadProductImage(imageURL, remaining,
+			userID)
+		if err != nil {
+			return images, err
+		}
+
+		images = append(images, pImage)
+		remaining -= size
+	}
+
+	return images, nil
+}
+
+// downloadProductImage download image of URL of seller of user ID
+// into media folder, returning the saved image and downloaded size
+//
+// return error if it's not an image or larger than maxSize bytes
+func downloadProductImage(imageURL string, maxSize int64, userID int) (
+	model.ProductImage, int64, error) {
+	u, err := parseProductImageURL(imageURL)
+	if err != nil {
+		return model.ProductImage{}, 0, err
+	}
+
+	resp, err := imageDownloadClient.Get(u.String())
+	if err != nil {
+		return model.ProductImage{}, 0, fmt.Errorf("There's an error when downloading "+
+			"image %s => %s", imageURL, err.Error())
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return model.ProductImage{}, 0, fmt.Errorf("Status code invalid when downloading "+
+			"image %s => %d", imageURL, resp.StatusCode)
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !strings.HasPrefix(mediaType, "image/") {
+		return model.ProductImage{}, 0, fmt.Errorf("image URL '%s' is not an image", imageURL)
+	}
+
+	// read one more byte than allowed to detect too large image
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return model.ProductImage{}, 0, fmt.Errorf("There's an error when downloading "+
+			"image %s => %s", imageURL, err.Error())
+	}
+	if int64(len(b)) > maxSize {
+		return model.ProductImage{}, 0, fmt.Errorf("product images too large, maximum %d "+
+			"bytes in total", config.MaxProductImagesSize)
+	}
+
+	filename := unsafeFilenameChars.ReplaceAllString(path.Base(u.Path), "-")
+	if filename == "" || filename == "." || filename == "-" {
+		filename = "image"
+	}
+	if len(filename) > model.MaxImageFilenameLength {
+		filename = filename[len(filename)-model.MaxImageFilenameLength:]
+	}
+	pImage, err := model.SaveProductImageFile(filename, bytes.NewReader(b),
+		userID)
+	if err != nil {
+		return model.ProductImage{}, 0, err
+	}
+
+	return pImage, int64(len(b)), nil
+}
+
+// checkProductImageURLs check images of URLs of a product reachable
+// without downloading them
+//
+// return error if there are too many images, any of them isn't an image,
+// or their total size known from response headers too large
+func checkProductImageURLs(imageURLs []string) error {
+	if len(imageURLs) > config.MaxProductImages {
+		return fmt.Errorf("too many product images, "+
+			"maximum %d images but got %d",
+			config.MaxProductImages, len(imageURLs))
+	}
+
+	var total int64
+	for _, imageURL := range imageURLs {
+		size, err := checkProductImageURL(imageURL)
+		if err != nil {
+			return err
+		}
+
+		total += size
+		if total > config.MaxProductImagesSize {
+			return fmt.Errorf("product images too large, maximum %d "+
+				"bytes in total", config.MaxProductImagesSize)
+		}
+	}
+
+	return nil
+}
+
+// checkProductImageURL check image of URL reachable by requesting its
+// headers, falling back to GET if method HEAD isn't allowed, returning
+// its size or 0 if the size unknown
+//
+// connection errors and response status aren't reported, only that
+// the image unreachable, so checking can't be used to probe hosts
+func checkProductImageURL(imageURL string) (int64, error) {
+	u, err := parseProductImageURL(imageURL)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := imageDownloadClient.Head(u.String())
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed ||
+		resp.StatusCode == http.StatusNotImplemented) {
+		resp.Body.Close()
+		resp, err = imageDownloadClient.Get(u.String())
+	}
+	if err != nil {
+		return 0, fmt.Errorf("image URL '%s' unreachable", imageURL)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("image URL '%s' unreachable", imageURL)
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !strings.HasPrefix(mediaType, "image/") {
+		return 0, fmt.Errorf("image URL '%s' is not an image", imageURL)
+	}
+	if resp.ContentLength < 0 {
+		return 0, nil
+	}
+
+	return resp.ContentLength, nil
+}
+
+// parseProductImageURL parse URL of a product image
+//
+// return error if it's not http or https URL
+func parseProductImageURL(imageURL string) (*url.URL, error) {
+	u, err := url.Parse(imageURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("image URL '%s' invalid, must be "+
+			"http or https URL", imageURL)
+	}
+
+	return u, nil
+}
@@ -0,0 +1,402 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+	"github.com/reyhanfikridz/ecom-product-service/internal/netguard"
+)
+
+// TestParseProductImportCSV test ParseProductImportCSV
+func TestParseProductImportCSV(t *testing.T) {
+	csv := "Name,price,weight,stock,description,image_urls\n" +
+		"Mouse,150000,0.2,10,Wireless mouse," +
+		"\"https://example.com/a.png https://example.com/b.png\"\n" +
+		"Keyboard,abc,1,5,,\n" +
+		"Hub,50000,0.1,-1,,\n" +
+		",50000,0.1,1,,\n" +
+		"Stand,250000,1.5,,,\n"
+
+	rows, err := ParseProductImportCSV(strings.NewReader(csv))
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+
+	expected := []ProductImportRow{
+		{
+			Line: 2,
+			ProductInfo: model.ProductInfo{Name: "Mouse", Price: 15000000,
+				Weight: 0.2, Stock: 10, Description: "Wireless mouse"},
+			ImageURLs: []string{"https://example.com/a.png",
+				"https://example.com/b.png"},
+		},
+		{Line: 3, Err: fmt.Errorf("price 'abc' invalid")},
+		{Line: 4, Err: fmt.Errorf(
+			"stock '-1' invalid, must be non-negative number")},
+		{Line: 5, Err: fmt.Errorf("name empty/not found")},
+		{
+			Line: 6,
+			ProductInfo: model.ProductInfo{Name: "Stand", Price: 25000000,
+				Weight: 1.5},
+			ImageURLs: []string{},
+		},
+	}
+	if len(rows) != len(expected) {
+		t.Fatalf("Expected %d rows, but got %d", len(expected), len(rows))
+	}
+	for i, row := range rows {
+		if row.Line != expected[i].Line {
+			t.Errorf("Expected row %d at line %d, but got %d",
+				i, expected[i].Line, row.Line)
+		}
+		if expected[i].Err != nil {
+			if row.Err == nil || row.Err.Error() != expected[i].Err.Error() {
+				t.Errorf("Expected row %d error '%s', but got %v",
+					i, expected[i].Err.Error(), row.Err)
+			}
+			continue
+		}
+		if row.Err != nil {
+			t.Errorf("Expected row %d valid, but got error => %s",
+				i, row.Err.Error())
+		}
+		if row.ProductInfo != expected[i].ProductInfo ||
+			!reflect.DeepEqual(row.ImageURLs, expected[i].ImageURLs) {
+			t.Errorf("Expected row %d %+v %v, but got %+v %v", i,
+				expected[i].ProductInfo, expected[i].ImageURLs,
+				row.ProductInfo, row.ImageURLs)
+		}
+	}
+
+	// malformed CSV
+	for _, invalidCSV := range []string{"", "name,price\n", "name,price,weight\n\"a,1,1\n"} {
+		_, err = ParseProductImportCSV(strings.NewReader(invalidCSV))
+		if err == nil {
+			t.Errorf("Expected error for CSV %q, but got nil", invalidCSV)
+		}
+	}
+}
+
+// importRepository product repository in memory recording
+// imported products
+type importRepository struct {
+	model.ProductRepository
+	inserted []model.ProductInfo
+}
+
+// InsertProductWithImages record product in memory
+func (r *importRepository) InsertProductWithImages(ctx context.Context,
+	pInfo model.ProductInfo, images []model.ProductImage) (model.ProductInfo,
+	error) {
+	pInfo.SKU = fmt.Sprintf("SKU-%d", len(r.inserted)+1)
+	r.inserted = append(r.inserted, pInfo)
+	return pInfo, nil
+}
+
+// TestImportProductsHandler test ImportProductsHandler
+// with product repository in memory
+func TestImportProductsHandler(t *testing.T) {
+	repo := &importRepository{}
+	a := API{Repo: repo, FiberApp: fiber.New()}
+	a.FiberApp.Post("/api/products/import/",
+		AuthorizationMiddlewareForTest(middleware.User{ID: 3, Role: "seller"}),
+		a.ImportProductsHandler)
+
+	req, _ := http.NewRequest("POST", "/api/products/import/",
+		strings.NewReader("name,price,weight,stock\n"+
+			"Mouse,150000,0.2,10\n"+
+			"Keyboard,abc,1,5\n"))
+	req.Header.Set("Content-Type", "text/csv")
+	response, err := a.FiberApp.Test(req)
+	if err != nil {
+		t.Fatalf("There's an error serve http testing => %s", err.Error())
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d got %d",
+			http.StatusOK, response.StatusCode)
+	}
+
+	body := struct {
+		Message string                `json:"message"`
+		Results []ProductImportResult `json:"results"`
+	}{}
+	err = json.NewDecoder(response.Body).Decode(&body)
+	if err != nil {
+		t.Fatalf("There's an error when decoding response => %s", err.Error())
+	}
+
+	expected := []ProductImportResult{
+		{Line: 2, SKU: "SKU-1"},
+		{Line: 3, Error: "price 'abc' invalid"},
+	}
+	if body.Message != "1 of 2 products imported" ||
+		!reflect.DeepEqual(body.Results, expected) {
+		t.Errorf("Expected '1 of 2 products imported' %+v, but got '%s' %+v",
+			expected, body.Message, body.Results)
+	}
+	if len(repo.inserted) != 1 || repo.inserted[0].UserID != 3 {
+		t.Errorf("Expected 1 product inserted of user 3, but got %+v",
+			repo.inserted)
+	}
+}
+
+// TestImportProductsHandlerDryRun test ImportProductsHandler
+// validating rows and their image URLs without importing them
+func TestImportProductsHandlerDryRun(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/a.txt" {
+				w.Header().Set("Content-Type", "text/plain")
+			} else {
+				w.Header().Set("Content-Type", "image/png")
+			}
+			w.Write([]byte("png"))
+		}))
+	defer server.Close()
+
+	// testing server is on loopback, which image download client refuses
+	client := imageDownloadClient
+	imageDownloadClient = server.Client()
+	defer func() { imageDownloadClient = client }()
+
+	repo := &importRepository{}
+	a := API{Repo: repo, FiberApp: fiber.New()}
+	a.FiberApp.Post("/api/products/import/",
+		AuthorizationMiddlewareForTest(middleware.User{ID: 3, Role: "seller"}),
+		a.ImportProductsHandler)
+
+	req, _ := http.NewRequest("POST", "/api/products/import/?dry_run=true",
+		strings.NewReader("name,price,weight,image_urls\n"+
+			"Mouse,150000,0.2,"+server.URL+"/a.png\n"+
+			"Keyboard,abc,1,\n"+
+			"Hub,50000,0.1,"+server.URL+"/a.txt\n"))
+	req.Header.Set("Content-Type", "text/csv")
+	response, err := a.FiberApp.Test(req)
+	if err != nil {
+		t.Fatalf("There's an error serve http testing => %s", err.Error())
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d got %d",
+			http.StatusOK, response.StatusCode)
+	}
+
+	body := struct {
+		Message string                `json:"message"`
+		DryRun  bool                  `json:"dry_run"`
+		Results []ProductImportResult `json:"results"`
+	}{}
+	err = json.NewDecoder(response.Body).Decode(&body)
+	if err != nil {
+		t.Fatalf("There's an error when decoding response => %s", err.Error())
+	}
+
+	expected := []ProductImportResult{
+		{Line: 2},
+		{Line: 3, Error: "price 'abc' invalid"},
+		{Line: 4, Error: "image URL '" + server.URL +
+			"/a.txt' is not an image"},
+	}
+	if !body.DryRun || !reflect.DeepEqual(body.Results, expected) {
+		t.Errorf("Expected dry run results %+v, but got %+v",
+			expected, body.Results)
+	}
+	if len(repo.inserted) != 0 {
+		t.Errorf("Expected no product inserted on dry run, but got %+v",
+			repo.inserted)
+	}
+
+	// dry run invalid
+	req, _ = http.NewRequest("POST", "/api/products/import/?dry_run=maybe",
+		strings.NewReader("name,price,weight\n"))
+	response, err = a.FiberApp.Test(req)
+	if err != nil {
+		t.Fatalf("There's an error serve http testing => %s", err.Error())
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status %d got %d",
+			http.StatusBadRequest, response.StatusCode)
+	}
+}
+
+// newTestProductWorkbook create XLSX workbook whose first worksheet
+// has rows of inline string cells
+func newTestProductWorkbook(t *testing.T, rows [][]string) []byte {
+	sheet := &bytes.Buffer{}
+	sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/` +
+		`spreadsheetml/2006/main"><sheetData>`)
+	for i, row := range rows {
+		fmt.Fprintf(sheet, `<row r="%d">`, i+1)
+		for j, value := range row {
+			fmt.Fprintf(sheet, `<c r="%c%d" t="inlineStr"><is><t>%s</t>`+
+				`</is></c>`, 'A'+j, i+1, value)
+		}
+		sheet.WriteString(`</row>`)
+	}
+	sheet.WriteString(`</sheetData></worksheet>`)
+
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for name, content := range map[string]string{
+		"xl/workbook.xml": `<workbook xmlns:r="http://schemas.openxmlformats.org/` +
+			`officeDocument/2006/relationships"><sheets>` +
+			`<sheet name="Products" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships>` +
+			`<Relationship Id="rId1" Target="worksheets/sheet1.xml"/>` +
+			`</Relationships>`,
+		"xl/worksheets/sheet1.xml": sheet.String(),
+	} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("There's an error when creating workbook => %s",
+				err.Error())
+		}
+		w.Write([]byte(content))
+	}
+	err := zw.Close()
+	if err != nil {
+		t.Fatalf("There's an error when creating workbook => %s", err.Error())
+	}
+
+	return buf.Bytes()
+}
+
+// TestDownloadProductImageInternal test downloadProductImage refusing
+// image URL of internal address
+func TestDownloadProductImageInternal(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte("png"))
+		}))
+	defer server.Close()
+
+	for _, imageURL := range []string{
+		server.URL + "/a.png",
+		"http://169.254.169.254/latest/meta-data/",
+	} {
+		_, _, err := downloadProductImage(imageURL, 1024, 3)
+		if err == nil || !strings.Contains(err.Error(),
+			netguard.ErrAddressNotAllowed.Error()) {
+			t.Errorf("Expected error address not allowed of %s, but got %v",
+				imageURL, err)
+		}
+	}
+}
+
+// TestCheckProductImageURLInternal test checkProductImageURL reporting
+// image URL of internal address unreachable without its details
+func TestCheckProductImageURLInternal(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "internal", http.StatusForbidden)
+		}))
+	defer server.Close()
+
+	_, err := checkProductImageURL(server.URL + "/admin")
+	expected := "image URL '" + server.URL + "/admin' unreachable"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected error '%s', but got %v", expected, err)
+	}
+}
+
+// TestParseProductImportXLSX test ParseProductImportXLSX
+func TestParseProductImportXLSX(t *testing.T) {
+	b := newTestProductWorkbook(t, [][]string{
+		{"Name", "Price", "Weight", "Stock", "Image_URLs"},
+		{"Mouse", "150000", "0.2", "10", "https://example.com/a.png"},
+		{"Keyboard", "abc", "1", "5"},
+	})
+
+	rows, err := ParseProductImport(b)
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+
+	if len(rows) != 2 || rows[0].Line != 2 || rows[0].Err != nil ||
+		rows[0].ProductInfo != (model.ProductInfo{Name: "Mouse",
+			Price: 15000000, Weight: 0.2, Stock: 10}) ||
+		!reflect.DeepEqual(rows[0].ImageURLs,
+			[]string{"https://example.com/a.png"}) {
+		t.Fatalf("Expected row 2 of valid product Mouse, but got %+v", rows)
+	}
+	if rows[1].Line != 3 || rows[1].Err == nil ||
+		rows[1].Err.Error() != "price 'abc' invalid" {
+		t.Errorf("Expected row 3 with invalid price, but got %+v", rows[1])
+	}
+
+	// workbook without required column or rows
+	for _, invalidRows := range [][][]string{
+		{},
+		{{"name", "price"}, {"Mouse", "1000"}},
+	} {
+		b = newTestProductWorkbook(t, invalidRows)
+		_, err = ParseProductImportXLSX(bytes.NewReader(b), int64(len(b)))
+		if err == nil {
+			t.Errorf("Expected error for workbook %q, but got nil",
+				invalidRows)
+		}
+	}
+}
+
+// TestImportProductsHandlerXLSX test ImportProductsHandler
+// importing products from uploaded XLSX
+func TestImportProductsHandlerXLSX(t *testing.T) {
+	repo := &importRepository{}
+	a := API{Repo: repo, FiberApp: fiber.New()}
+	a.FiberApp.Post("/api/products/import/",
+		AuthorizationMiddlewareForTest(middleware.User{ID: 3, Role: "seller"}),
+		a.ImportProductsHandler)
+
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	fw, err := mw.CreateFormFile("file", "products.xlsx")
+	if err != nil {
+		t.Fatalf("There's an error when creating form file => %s",
+			err.Error())
+	}
+	fw.Write(newTestProductWorkbook(t, [][]string{
+		{"name", "price", "weight"},
+		{"Mouse", "150000", "0.2"},
+		{"Hub", "50000", "0.1"},
+	}))
+	mw.Close()
+
+	req, _ := http.NewRequest("POST", "/api/products/import/", body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	response, err := a.FiberApp.Test(req)
+	if err != nil {
+		t.Fatalf("There's an error serve http testing => %s", err.Error())
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d got %d",
+			http.StatusOK, response.StatusCode)
+	}
+	if len(repo.inserted) != 2 || repo.inserted[0].Name != "Mouse" ||
+		repo.inserted[1].Name != "Hub" {
+		t.Errorf("Expected products Mouse and Hub inserted, but got %+v",
+			repo.inserted)
+	}
+}
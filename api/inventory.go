@@ -0,0 +1,441 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/event"
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+	"github.com/reyhanfikridz/ecom-product-service/internal/permission"
+	"github.com/reyhanfikridz/ecom-product-service/internal/validator"
+)
+
+// GetInventorySnapshotHandler handling route get inventory snapshot
+// at a past instant, of seller by url parameter 'seller_id' or of all
+// sellers if it's empty (method: GET, user: admin)
+func (a *API) GetInventorySnapshotHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// check user role is allowed to access this API
+	if !a.isAllowed(u, permission.StockReadAny) {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this API",
+		})
+	}
+
+	// get seller ID from url
+	sellerID, err := parseSellerID(c)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	// get snapshot instant from url
+	rawAt := c.Query("at")
+	if strings.TrimSpace(rawAt) == "" {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": "parameter 'at' empty/not found",
+		})
+	}
+	at, err := time.Parse(time.RFC3339, rawAt)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": "parameter 'at' invalid, must be RFC3339 timestamp",
+		})
+	}
+
+	return a.sendInventorySnapshot(c, sellerID, at)
+}
+
+// GetSellerInventorySnapshotHandler handling route get inventory snapshot
+// of the seller with stock value of each product, at instant of url
+// parameter 'at' or now if it's empty (method: GET, user: seller)
+func (a *API) GetSellerInventorySnapshotHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// check user role is allowed to access this API
+	if !a.isAllowed(u, permission.StockRead) {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this API",
+		})
+	}
+
+	// get snapshot instant from url
+	at := time.Now()
+	if rawAt := c.Query("at"); strings.TrimSpace(rawAt) != "" {
+		var err error
+		at, err = time.Parse(time.RFC3339, rawAt)
+		if err != nil {
+			return c.Status(http.StatusBadRequest).JSON(map[string]string{
+				"message": "parameter 'at' invalid, must be RFC3339 timestamp",
+			})
+		}
+	}
+
+	return a.sendInventorySnapshot(c, u.ID, at)
+}
+
+// sendInventorySnapshot send inventory snapshot of user at instant
+// as JSON, or as CSV file if url parameter 'format' is csv,
+// of all users if userID is zero
+func (a *API) sendInventorySnapshot(c *fiber.Ctx, userID int,
+	at time.Time) error {
+	// get inventory snapshot from database
+	items, err := a.Repo.GetInventorySnapshot(c.UserContext(), userID, at)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": fmt.Sprintf(
+				"There's an error when getting the inventory snapshot => %s",
+				err.Error()),
+		})
+	}
+
+	// export as CSV if requested
+	if c.Query("format") == "csv" {
+		var b bytes.Buffer
+		err = WriteInventorySnapshotCSV(&b, items)
+		if err != nil {
+			return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+				"message": err.Error(),
+			})
+		}
+
+		c.Set(fiber.HeaderContentType, "text/csv")
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(
+			"attachment; filename=\"%s\"",
+			InventorySnapshotFileName(userID, at)))
+		return c.Status(http.StatusOK).Send(b.Bytes())
+	}
+
+	return c.Status(http.StatusOK).JSON(items)
+}
+
+// InventorySnapshotFileName get CSV file name of inventory snapshot
+// of user at instant, without user if userID is zero
+func InventorySnapshotFileName(userID int, at time.Time) string {
+	stamp := at.UTC().Format("20060102T150405Z")
+	if userID == 0 {
+		return fmt.Sprintf("inventory-snapshot-%s.csv", stamp)
+	}
+
+	return fmt.Sprintf("inventory-snapshot-%d-%s.csv", userID, stamp)
+}
+
+// WriteInventorySnapshotCSV write inventory snapshot items as CSV
+// with header row into w
+func WriteInventorySnapshotCSV(w io.Writer, items []model.InventorySnapshotItem) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"product_id", "sku", "name", "user_id", "stock",
+		"unit", "unit_value", "total_value"})
+	for _, item := range items {
+		cw.Write([]string{
+			strconv.Itoa(item.ProductID),
+			item.SKU,
+			item.Name,
+			strconv.Itoa(item.UserID),
+			strconv.FormatFloat(item.Stock, 'f', -1, 64),
+			item.Unit,
+			item.UnitValue.String(),
+			item.TotalValue.String(),
+		})
+	}
+	cw.Flush()
+
+	return cw.Error()
+}
+
+// GetStockHistoryHandler handling route get product stock movement history
+// by SKU (method: GET, user: seller owning the product)
+func (a *API) GetStockHistoryHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// check user role is allowed to access this API
+	if !a.isAllowed(u, permission.StockRead) {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this API",
+		})
+	}
+
+	// get product by sku from database
+	SKU := c.Params("sku")
+	p, err := a.Repo.GetProductBySKU(c.UserContext(), SKU)
+	if err == sql.ErrNoRows {
+		return c.Status(http.StatusNotFound).JSON(map[string]string{
+			"message": "product not found",
+		})
+	} else if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	// check product owned by the seller
+	if p.ProductInfo.UserID != u.ID {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this product",
+		})
+	}
+
+	// get stock movements from database
+	movements, err := a.Repo.GetStockMovementsBySKU(c.UserContext(), SKU)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": fmt.Sprintf(
+				"There's an error when getting the stock history => %s",
+				err.Error()),
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(movements)
+}
+
+// GetLowStockProductsHandler handling route get products of the seller
+// with stock at or below threshold (method: GET, user: seller)
+func (a *API) GetLowStockProductsHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// check user role is allowed to access this API
+	if !a.isAllowed(u, permission.StockRead) {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this API",
+		})
+	}
+
+	// get threshold from url
+	threshold, err := strconv.Atoi(c.Query("threshold"))
+	if err != nil || threshold < 0 {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": "parameter 'threshold' empty/invalid, " +
+				"must be non-negative integer",
+		})
+	}
+
+	// get low stock products from database
+	products, err := a.Repo.GetLowStockProducts(c.UserContext(), u.ID,
+		threshold)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": fmt.Sprintf(
+				"There's an error when getting the products data => %s",
+				err.Error()),
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(products)
+}
+
+// maximum SKUs of product snapshots in one request
+const maxSnapshotSKUs = 100
+
+// GetProductSnapshotsHandler handling route get current effective price
+// and stock of products by comma separated url parameter 'skus',
+// for validating cart at checkout, products not found or hidden
+// are omitted (method: GET, user: any)
+func (a *API) GetProductSnapshotsHandler(c *fiber.Ctx) error {
+	// get SKUs from url
+	SKUs := []string{}
+	for _, SKU := range strings.Split(c.Query("skus"), ",") {
+		SKU = strings.TrimSpace(SKU)
+		if SKU != "" {
+			SKUs = append(SKUs, SKU)
+		}
+	}
+	if len(SKUs) == 0 || len(SKUs) > maxSnapshotSKUs {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": fmt.Sprintf("parameter 'skus' empty/invalid, "+
+				"must be 1 to %d comma separated SKUs", maxSnapshotSKUs),
+		})
+	}
+
+	// get product snapshots from primary database, since stale stock
+	// of read replica could let checkout oversell
+	snapshots, err := a.Repo.GetProductSnapshots(
+		model.ReadFromPrimary(c.UserContext()), SKUs)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": fmt.Sprintf(
+				"There's an error when getting the products data => %s",
+				err.Error()),
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(snapshots)
+}
+
+// BatchUpdateStockHandler handling route set stock of many products
+// in one transaction (method: PUT, user: seller)
+func (a *API) BatchUpdateStockHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// check user role is allowed to access this API
+	if !a.isAllowed(u, permission.StockUpdate) {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this API",
+		})
+	}
+
+	// parse stock updates from JSON body
+	updates := []model.StockUpdate{}
+	err := json.Unmarshal(c.Body(), &updates)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": "body must be JSON array of {sku, stock}",
+		})
+	}
+
+	// validate stock updates data
+	err = validator.IsStockUpdatesValid(updates)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	// set stocks in database
+	results, err := a.Repo.SetStocks(c.UserContext(), u.ID, updates)
+	if errors.Is(err, model.ErrBatchItemInvalid) {
+		return c.Status(http.StatusUnprocessableEntity).JSON(map[string]interface{}{
+			"message": "No stock updated => " + err.Error(),
+			"results": results,
+		})
+	} else if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	for _, result := range results {
+		if result.Stock != result.PreviousStock {
+			a.PublishEvent(event.NewEvent(event.StockChanged, result.SKU, u.ID,
+				event.StockChangedPayload{
+					Stock: result.Stock,
+					Delta: result.Stock - result.PreviousStock,
+				}))
+		}
+	}
+
+	return c.Status(http.StatusOK).JSON(map[string]interface{}{
+		"message": "Product stocks updated!",
+		"results": results,
+	})
+}
+
+// BatchDecreaseStockHandler handling route decrease stock of many
+// products ordered together in one transaction, none decreased if any
+// of them can't be (method: PUT, user: seller or service)
+func (a *API) BatchDecreaseStockHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// check user role is allowed to access this API
+	if !a.isAllowed(u, permission.StockDecrease) {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this API",
+		})
+	}
+
+	// parse stock decreases and related order ID from JSON body
+	body := struct {
+		OrderID string                `json:"order_id"`
+		Items   []model.StockDecrease `json:"items"`
+	}{}
+	err := json.Unmarshal(c.Body(), &body)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": "body must be JSON object of " +
+				"{order_id, items: [{sku, qty}]}",
+		})
+	}
+
+	// validate stock decreases data
+	err = validator.IsStockDecreasesValid(body.Items)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	// decrease stocks in database
+	results, err := a.Repo.DecreaseStocks(c.UserContext(), body.Items,
+		model.StockMovement{
+			Reason:  model.StockReasonDecreased,
+			OrderID: body.OrderID,
+			UserID:  u.ID,
+		})
+	if errors.Is(err, model.ErrBatchItemInvalid) {
+		return c.Status(http.StatusUnprocessableEntity).JSON(map[string]interface{}{
+			"message": "No stock decreased => " + err.Error(),
+			"results": results,
+		})
+	} else if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	for _, result := range results {
+		a.PublishEvent(event.NewEvent(event.StockChanged, result.SKU,
+			result.UserID, event.StockChangedPayload{
+				Stock: result.Stock,
+				Delta: -result.Qty,
+			}))
+	}
+
+	return c.Status(http.StatusOK).JSON(map[string]interface{}{
+		"message": "Product stocks decreased!",
+		"results": results,
+	})
+}
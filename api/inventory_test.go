@@ -0,0 +1,377 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+)
+
+// TestGetInventorySnapshotHandler test GetInventorySnapshotHandler
+func TestGetInventorySnapshotHandler(t *testing.T) {
+	// get testing API for create products
+	a, err := GetTestingAPI(middleware.User{})
+	if err != nil {
+		t.Errorf("There's an error when getting testing API => %s",
+			err.Error())
+	}
+
+	// insert product info into database
+	_, err = model.InsertProductInfo(context.Background(), a.DB,
+		model.ProductInfo{
+			Name:        "PRODUCT A",
+			Price:       120000055,
+			Weight:      1.5,
+			Description: "Description PRODUCT A",
+			Stock:       100,
+			UserID:      1,
+		})
+	if err != nil {
+		t.Errorf("There's an error when insert data product info => %s",
+			err.Error())
+	}
+
+	// create testing table
+	testTable := []struct {
+		TestName       string
+		At             string
+		Format         string
+		User           middleware.User
+		ExpectedStatus int
+	}{
+		{
+			TestName:       "Snapshot JSON",
+			At:             time.Now().Add(time.Hour).Format(time.RFC3339),
+			User:           middleware.User{Role: "admin"},
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			TestName:       "Snapshot CSV",
+			At:             time.Now().Add(time.Hour).Format(time.RFC3339),
+			Format:         "csv",
+			User:           middleware.User{Role: "admin"},
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			TestName:       "Bad Request",
+			At:             "yesterday",
+			User:           middleware.User{Role: "admin"},
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			TestName:       "Forbidden",
+			At:             time.Now().Format(time.RFC3339),
+			User:           middleware.User{Role: "seller"},
+			ExpectedStatus: http.StatusForbidden,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		// get testing API for get inventory snapshot
+		a, err = GetTestingAPI(test.User)
+		if err != nil {
+			t.Errorf("[%s] There's an error when getting testing API => %s",
+				test.TestName, err.Error())
+		}
+
+		// get url params
+		params := url.Values{}
+		params.Add("at", test.At)
+		params.Add("format", test.Format)
+
+		// create new request
+		req, err := http.NewRequest("GET", "/api/admin/inventory/snapshot/", nil)
+		req.URL.RawQuery = params.Encode()
+		if err != nil {
+			t.Errorf("[%s] There's an error when creating "+
+				"request API get inventory snapshot => %s",
+				test.TestName, err.Error())
+		}
+
+		// run request
+		response, err := a.FiberApp.Test(req)
+		if err != nil {
+			t.Errorf("[%s] There's an error serve http testing => %s",
+				test.TestName, err.Error())
+		}
+		defer response.Body.Close()
+
+		// check response
+		if response.StatusCode != test.ExpectedStatus {
+			t.Errorf("[%s] Expected status %d got %d",
+				test.TestName, test.ExpectedStatus, response.StatusCode)
+		} else if response.StatusCode == http.StatusOK {
+			if test.Format == "csv" {
+				if !strings.HasPrefix(response.Header.Get("Content-Type"), "text/csv") {
+					t.Errorf("[%s] Expected content type text/csv, but got %s",
+						test.TestName, response.Header.Get("Content-Type"))
+				}
+			} else {
+				var items []model.InventorySnapshotItem
+				err = json.NewDecoder(response.Body).Decode(&items)
+				if err != nil {
+					t.Errorf("[%s] There's an error when unmarshal body response => %s",
+						test.TestName, err.Error())
+				}
+
+				if len(items) != 1 || items[0].Stock != 100 {
+					t.Errorf("[%s] Expected one product with stock 100, but got %v",
+						test.TestName, items)
+				}
+			}
+		}
+	}
+
+	// truncate tables after test
+	_, err = a.DB.Exec("TRUNCATE product_productinfo RESTART IDENTITY CASCADE")
+	if err != nil {
+		log.Fatalf("There's an error when truncating "+
+			"table product_productinfo => %s",
+			err.Error())
+	}
+}
+
+// TestGetSellerInventorySnapshotHandler test
+// GetSellerInventorySnapshotHandler
+func TestGetSellerInventorySnapshotHandler(t *testing.T) {
+	// get testing API for create products
+	a, err := GetTestingAPI(middleware.User{})
+	if err != nil {
+		t.Errorf("There's an error when getting testing API => %s",
+			err.Error())
+	}
+
+	// insert product infos of two sellers into database
+	for _, userID := range []int{1, 2} {
+		_, err = model.InsertProductInfo(context.Background(), a.DB,
+			model.ProductInfo{
+				Name:   "PRODUCT A",
+				Price:  1050,
+				Weight: 1.5,
+				Stock:  4,
+				Unit:   "kg",
+				UserID: userID,
+			})
+		if err != nil {
+			t.Errorf("There's an error when insert data product info => %s",
+				err.Error())
+		}
+	}
+
+	// create testing table
+	testTable := []struct {
+		TestName       string
+		At             string
+		Format         string
+		User           middleware.User
+		ExpectedStatus int
+		ExpectedCSV    string
+	}{
+		{
+			TestName:       "Snapshot Now",
+			User:           middleware.User{ID: 1, Role: "seller"},
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			TestName:       "Snapshot CSV",
+			At:             time.Now().Add(time.Hour).Format(time.RFC3339),
+			Format:         "csv",
+			User:           middleware.User{ID: 1, Role: "seller"},
+			ExpectedStatus: http.StatusOK,
+			ExpectedCSV: "product_id,sku,name,user_id,stock,unit," +
+				"unit_value,total_value\n",
+		},
+		{
+			TestName:       "Bad Request",
+			At:             "yesterday",
+			User:           middleware.User{ID: 1, Role: "seller"},
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			TestName:       "Forbidden",
+			User:           middleware.User{ID: 1, Role: "buyer"},
+			ExpectedStatus: http.StatusForbidden,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		a, err = GetTestingAPI(test.User)
+		if err != nil {
+			t.Errorf("[%s] There's an error when getting testing API => %s",
+				test.TestName, err.Error())
+		}
+
+		params := url.Values{}
+		params.Add("at", test.At)
+		params.Add("format", test.Format)
+
+		req, err := http.NewRequest("GET", "/api/products/user/inventory/", nil)
+		req.URL.RawQuery = params.Encode()
+		if err != nil {
+			t.Errorf("[%s] There's an error when creating "+
+				"request API get seller inventory snapshot => %s",
+				test.TestName, err.Error())
+		}
+
+		response, err := a.FiberApp.Test(req)
+		if err != nil {
+			t.Errorf("[%s] There's an error serve http testing => %s",
+				test.TestName, err.Error())
+		}
+		defer response.Body.Close()
+
+		if response.StatusCode != test.ExpectedStatus {
+			t.Errorf("[%s] Expected status %d got %d",
+				test.TestName, test.ExpectedStatus, response.StatusCode)
+		} else if response.StatusCode == http.StatusOK {
+			if test.Format == "csv" {
+				body, _ := io.ReadAll(response.Body)
+				if !strings.HasPrefix(string(body), test.ExpectedCSV) ||
+					!strings.HasSuffix(string(body), ",kg,10.5,42\n") {
+					t.Errorf("[%s] Expected CSV row valued 42, but got %s",
+						test.TestName, string(body))
+				}
+			} else {
+				var items []model.InventorySnapshotItem
+				err = json.NewDecoder(response.Body).Decode(&items)
+				if err != nil {
+					t.Errorf("[%s] There's an error when unmarshal body response => %s",
+						test.TestName, err.Error())
+				}
+
+				if len(items) != 1 || items[0].UserID != 1 ||
+					items[0].UnitValue != 1050 || items[0].TotalValue != 4200 {
+					t.Errorf("[%s] Expected one product of the seller "+
+						"valued 42, but got %v", test.TestName, items)
+				}
+			}
+		}
+	}
+
+	// truncate tables after test
+	_, err = a.DB.Exec("TRUNCATE product_productinfo RESTART IDENTITY CASCADE")
+	if err != nil {
+		log.Fatalf("There's an error when truncating "+
+			"table product_productinfo => %s",
+			err.Error())
+	}
+}
+
+// TestGetStockHistoryHandler test GetStockHistoryHandler
+func TestGetStockHistoryHandler(t *testing.T) {
+	// get testing API for create products
+	a, err := GetTestingAPI(middleware.User{})
+	if err != nil {
+		t.Errorf("There's an error when getting testing API => %s",
+			err.Error())
+	}
+
+	// insert product info into database
+	pInfo, err := model.InsertProductInfo(context.Background(), a.DB,
+		model.ProductInfo{
+			Name:   "PRODUCT A",
+			Price:  1000,
+			Weight: 1.5,
+			Stock:  100,
+			UserID: 1,
+		})
+	if err != nil {
+		t.Errorf("There's an error when insert data product info => %s",
+			err.Error())
+	}
+
+	// create testing table
+	testTable := []struct {
+		TestName       string
+		SKU            string
+		User           middleware.User
+		ExpectedStatus int
+	}{
+		{
+			TestName:       "Owner",
+			SKU:            pInfo.SKU,
+			User:           middleware.User{ID: 1, Role: "seller"},
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			TestName:       "Not Owner",
+			SKU:            pInfo.SKU,
+			User:           middleware.User{ID: 2, Role: "seller"},
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			TestName:       "Not Found",
+			SKU:            "unknown",
+			User:           middleware.User{ID: 1, Role: "seller"},
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			TestName:       "Buyer",
+			SKU:            pInfo.SKU,
+			User:           middleware.User{ID: 1, Role: "buyer"},
+			ExpectedStatus: http.StatusForbidden,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		a, err = GetTestingAPI(test.User)
+		if err != nil {
+			t.Errorf("[%s] There's an error when getting testing API => %s",
+				test.TestName, err.Error())
+		}
+
+		req, err := http.NewRequest("GET",
+			"/api/product/"+test.SKU+"/stock-history/", nil)
+		if err != nil {
+			t.Errorf("[%s] There's an error when creating "+
+				"request API get stock history => %s",
+				test.TestName, err.Error())
+		}
+
+		response, err := a.FiberApp.Test(req)
+		if err != nil {
+			t.Errorf("[%s] There's an error serve http testing => %s",
+				test.TestName, err.Error())
+		}
+		defer response.Body.Close()
+
+		if response.StatusCode != test.ExpectedStatus {
+			t.Errorf("[%s] Expected status %d got %d",
+				test.TestName, test.ExpectedStatus, response.StatusCode)
+		} else if response.StatusCode == http.StatusOK {
+			var movements []model.StockMovement
+			err = json.NewDecoder(response.Body).Decode(&movements)
+			if err != nil {
+				t.Errorf("[%s] There's an error when unmarshal body response => %s",
+					test.TestName, err.Error())
+			}
+
+			if len(movements) != 1 || movements[0].Delta != 100 {
+				t.Errorf("[%s] Expected one movement with delta 100, but got %v",
+					test.TestName, movements)
+			}
+		}
+	}
+
+	// truncate tables after test
+	_, err = a.DB.Exec("TRUNCATE product_productinfo RESTART IDENTITY CASCADE")
+	if err != nil {
+		log.Fatalf("There's an error when truncating "+
+			"table product_productinfo => %s",
+			err.Error())
+	}
+}
@@ -0,0 +1,176 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/permission"
+)
+
+// default message of mutating routes replied in maintenance mode
+const defaultMaintenanceMessage = "service is under maintenance, " +
+	"please try again later"
+
+// routes served in maintenance mode whatever their method, so
+// maintenance mode can be turned off and graphql queries still work
+var maintenanceExemptPaths = []string{
+	"/api/admin/maintenance/",
+	"/graphql",
+}
+
+// MaintenanceStatus contain whether maintenance mode is enabled
+// and message replied to mutating routes
+type MaintenanceStatus struct {
+	Enabled bool   `json:"enabled"`
+	Message string `json:"message"`
+}
+
+// Maintenance maintenance (read-only) mode of API, safe to be toggled
+// while serving requests
+type Maintenance struct {
+	mu     sync.RWMutex
+	status MaintenanceStatus
+}
+
+// NewMaintenance create maintenance mode of status
+func NewMaintenance(status MaintenanceStatus) *Maintenance {
+	m := &Maintenance{}
+	m.Set(status)
+
+	return m
+}
+
+// Status get maintenance mode status
+func (m *Maintenance) Status() MaintenanceStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.status
+}
+
+// Set set maintenance mode status, empty message replaced by
+// the default message
+func (m *Maintenance) Set(status MaintenanceStatus) {
+	if strings.TrimSpace(status.Message) == "" {
+		status.Message = defaultMaintenanceMessage
+	}
+
+	m.mu.Lock()
+	m.status = status
+	m.mu.Unlock()
+}
+
+// InitMaintenance initialize maintenance mode of API enabled or not
+// with message replied to mutating routes
+func (a *API) InitMaintenance(enabled bool, message string) {
+	a.Maintenance = NewMaintenance(MaintenanceStatus{
+		Enabled: enabled,
+		Message: message,
+	})
+	if enabled {
+		log.Print("WARNING: maintenance mode enabled, all mutating " +
+			"routes reply service unavailable")
+	}
+}
+
+// getMaintenance get maintenance mode of API,
+// initialized disabled if it's not initialized
+func (a *API) getMaintenance() *Maintenance {
+	if a.Maintenance == nil {
+		a.Maintenance = NewMaintenance(MaintenanceStatus{})
+	}
+
+	return a.Maintenance
+}
+
+// MaintenanceMiddleware reply service unavailable to mutating routes
+// (method other than GET, HEAD, and OPTIONS) while maintenance mode
+// is enabled, reads continue to work
+func (a *API) MaintenanceMiddleware() fiber.Handler {
+	maintenance := a.getMaintenance()
+
+	return func(c *fiber.Ctx) error {
+		switch c.Method() {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			return c.Next()
+		}
+		for _, path := range maintenanceExemptPaths {
+			if strings.HasPrefix(c.Path(), path) {
+				return c.Next()
+			}
+		}
+
+		status := maintenance.Status()
+		if !status.Enabled {
+			return c.Next()
+		}
+
+		return c.Status(http.StatusServiceUnavailable).JSON(map[string]string{
+			"message": status.Message,
+		})
+	}
+}
+
+// GetMaintenanceHandler handling route get maintenance mode status
+// (method: GET, user: admin)
+func (a *API) GetMaintenanceHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// check user role is allowed to access this API
+	if !a.isAllowed(u, permission.MaintenanceManage) {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this API",
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(a.getMaintenance().Status())
+}
+
+// SetMaintenanceHandler handling route enable or disable maintenance
+// mode, e.g. for database migrations and incident response
+// (method: PUT, user: admin)
+func (a *API) SetMaintenanceHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// check user role is allowed to access this API
+	if !a.isAllowed(u, permission.MaintenanceManage) {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this API",
+		})
+	}
+
+	// parse maintenance mode status from JSON body
+	status := MaintenanceStatus{}
+	err := json.Unmarshal(c.Body(), &status)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": "body must be JSON object of {enabled, message}",
+		})
+	}
+
+	maintenance := a.getMaintenance()
+	maintenance.Set(status)
+	log.Printf("Maintenance mode enabled %t by user ID %d", status.Enabled,
+		u.ID)
+
+	return c.Status(http.StatusOK).JSON(maintenance.Status())
+}
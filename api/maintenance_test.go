@@ -0,0 +1,116 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+)
+
+// TestMaintenanceMiddleware test MaintenanceMiddleware rejecting
+// mutating routes while maintenance mode toggled by admin is enabled
+func TestMaintenanceMiddleware(t *testing.T) {
+	a := API{FiberApp: fiber.New()}
+	a.InitMaintenance(false, "")
+	a.FiberApp.Use(a.MaintenanceMiddleware())
+	admin := AuthorizationMiddlewareForTest(middleware.User{ID: 1,
+		Role: "admin"})
+	a.FiberApp.Get("/api/admin/maintenance/", admin, a.GetMaintenanceHandler)
+	a.FiberApp.Put("/api/admin/maintenance/", admin, a.SetMaintenanceHandler)
+	a.FiberApp.Put("/api/admin/maintenance/seller/",
+		AuthorizationMiddlewareForTest(middleware.User{ID: 2, Role: "seller"}),
+		a.SetMaintenanceHandler)
+	a.FiberApp.All("/api/product/", func(c *fiber.Ctx) error {
+		return c.SendStatus(http.StatusOK)
+	})
+
+	// create testing table
+	testTable := []struct {
+		TestName           string
+		Method             string
+		Path               string
+		Body               string
+		ExpectedStatusCode int
+		ExpectedMessage    string
+	}{
+		{"Write Before Maintenance", "PUT", "/api/product/", "",
+			http.StatusOK, ""},
+		{"Enable By Seller", "PUT", "/api/admin/maintenance/seller/",
+			`{"enabled":true}`, http.StatusForbidden, ""},
+		{"Enable Body Invalid", "PUT", "/api/admin/maintenance/", `enabled`,
+			http.StatusBadRequest, ""},
+		{"Enable", "PUT", "/api/admin/maintenance/",
+			`{"enabled":true,"message":"migrating database"}`,
+			http.StatusOK, ""},
+		{"Read In Maintenance", "GET", "/api/product/", "", http.StatusOK, ""},
+		{"Head In Maintenance", "HEAD", "/api/product/", "", http.StatusOK, ""},
+		{"Add In Maintenance", "POST", "/api/product/", "",
+			http.StatusServiceUnavailable, "migrating database"},
+		{"Update In Maintenance", "PUT", "/api/product/", "",
+			http.StatusServiceUnavailable, "migrating database"},
+		{"Delete In Maintenance", "DELETE", "/api/product/", "",
+			http.StatusServiceUnavailable, "migrating database"},
+		{"Enable Default Message", "PUT", "/api/admin/maintenance/",
+			`{"enabled":true}`, http.StatusOK, ""},
+		{"Update In Maintenance Default Message", "PUT", "/api/product/", "",
+			http.StatusServiceUnavailable, defaultMaintenanceMessage},
+		{"Disable", "PUT", "/api/admin/maintenance/", `{"enabled":false}`,
+			http.StatusOK, ""},
+		{"Write After Maintenance", "DELETE", "/api/product/", "",
+			http.StatusOK, ""},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		req, err := http.NewRequest(test.Method, test.Path,
+			strings.NewReader(test.Body))
+		if err != nil {
+			t.Fatalf("[%s] There's an error when creating request => %s",
+				test.TestName, err.Error())
+		}
+		resp, err := a.FiberApp.Test(req)
+		if err != nil {
+			t.Fatalf("[%s] There's an error when testing request => %s",
+				test.TestName, err.Error())
+		}
+		if resp.StatusCode != test.ExpectedStatusCode {
+			t.Errorf("[%s] Expected status code %d, but got %d",
+				test.TestName, test.ExpectedStatusCode, resp.StatusCode)
+			continue
+		}
+		if test.ExpectedMessage == "" {
+			continue
+		}
+
+		body := map[string]string{}
+		err = json.NewDecoder(resp.Body).Decode(&body)
+		if err != nil {
+			t.Fatalf("[%s] Expected error nil, but got error => %s",
+				test.TestName, err.Error())
+		}
+		if body["message"] != test.ExpectedMessage {
+			t.Errorf("[%s] Expected message '%s', but got '%s'",
+				test.TestName, test.ExpectedMessage, body["message"])
+		}
+	}
+
+	// maintenance status readable by admin
+	req, _ := http.NewRequest("GET", "/api/admin/maintenance/", nil)
+	resp, err := a.FiberApp.Test(req)
+	if err != nil {
+		t.Fatalf("There's an error when testing request => %s", err.Error())
+	}
+	status := MaintenanceStatus{}
+	err = json.NewDecoder(resp.Body).Decode(&status)
+	if err != nil || status.Enabled ||
+		status.Message != defaultMaintenanceMessage {
+		t.Errorf("Expected maintenance disabled, but got %+v (%v)",
+			status, err)
+	}
+}
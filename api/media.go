@@ -0,0 +1,633 @@
+package api
+
+import (
+	"archive/zip"
+	"bufio"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/config"
+	"github.com/reyhanfikridz/ecom-product-service/internal/event"
+	"github.com/reyhanfikridz/ecom-product-service/internal/imaging"
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+	"github.com/reyhanfikridz/ecom-product-service/internal/permission"
+	"github.com/reyhanfikridz/ecom-product-service/internal/validator"
+)
+
+// GetMediaETag get weak ETag of media file from its path
+// and last modification time
+func GetMediaETag(path string, modTime time.Time) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s:%d", path, modTime.Unix())
+
+	return `W/"` + hex.EncodeToString(h.Sum(nil))[:32] + `"`
+}
+
+// errMediaNotFound error of media file not found or not readable by user
+var errMediaNotFound = errors.New("media not found")
+
+// MediaAccessMiddleware allow media file served by the next static
+// handler only if it's readable by user of request, other files are
+// not found so their existence isn't revealed
+//
+// images of unpublished products are not cached by shared caches
+func (a *API) MediaAccessMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// image paths are stored decoded, but request path is escaped
+		imagePath, err := url.PathUnescape(
+			strings.TrimPrefix(c.Path(), "/media/"))
+		if err != nil {
+			return c.Status(http.StatusBadRequest).JSON(map[string]string{
+				"message": "media path invalid",
+			})
+		}
+
+		public, err := a.getMediaAccess(c, imagePath)
+		if err == errMediaNotFound {
+			return c.Status(http.StatusNotFound).JSON(map[string]string{
+				"message": err.Error(),
+			})
+		} else if err != nil {
+			return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+				"message": err.Error(),
+			})
+		}
+		if public {
+			return c.Next()
+		}
+
+		err = c.Next()
+		c.Set(fiber.HeaderCacheControl, "private, no-cache")
+		return err
+	}
+}
+
+// getMediaAccess check media file of image path is readable by user
+// of request, i.e. it's an image of published product, or of unpublished
+// product (hidden or deleted) to the seller owning it, returning
+// whether it's public
+//
+// return errMediaNotFound if it's not readable
+func (a *API) getMediaAccess(c *fiber.Ctx, imagePath string) (bool, error) {
+	access, err := a.Repo.GetProductImageAccess(c.UserContext(), imagePath)
+	if err == sql.ErrNoRows {
+		return false, errMediaNotFound
+	} else if err != nil {
+		return false, err
+	}
+	if access.Published {
+		return true, nil
+	}
+
+	u, ok := c.Locals("user").(middleware.User)
+	if !ok || !a.isAllowed(u, permission.ProductReadOwn) ||
+		u.ID != access.UserID {
+		return false, errMediaNotFound
+	}
+
+	return false, nil
+}
+
+// ResizeMediaHandler handling route get product image of url parameter
+// 'path' resized to fit within width and height of url parameters 'w'
+// and 'h', which must be one of allowed sizes, resized images are cached
+// in media folder (method: GET, user: all, images of unpublished product
+// only its seller)
+func (a *API) ResizeMediaHandler(c *fiber.Ctx) error {
+	// get image path from url
+	imagePath := c.Query("path")
+	if !strings.HasPrefix(imagePath, "product-image/") ||
+		path.Clean(imagePath) != imagePath {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": "parameter 'path' invalid, must be product image path",
+		})
+	}
+
+	// get size from url, must be allowed
+	width, errW := strconv.Atoi(c.Query("w"))
+	height, errH := strconv.Atoi(c.Query("h"))
+	allowed := false
+	sizes := []string{}
+	for _, size := range config.MediaResizeSizes {
+		allowed = allowed || (size.Width == width && size.Height == height)
+		sizes = append(sizes, fmt.Sprintf("%dx%d", size.Width, size.Height))
+	}
+	if errW != nil || errH != nil || !allowed {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": "parameter 'w' and 'h' invalid, must be one of " +
+				"sizes " + strings.Join(sizes, ", "),
+		})
+	}
+
+	// check image is readable by user
+	public, err := a.getMediaAccess(c, imagePath)
+	if err == errMediaNotFound {
+		return c.Status(http.StatusNotFound).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	} else if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	// get resized image from cache, or resize it
+	resizedPath, err := resizeProductImage(imagePath, width, height)
+	if errors.Is(err, os.ErrNotExist) {
+		return c.Status(http.StatusNotFound).JSON(map[string]string{
+			"message": errMediaNotFound.Error(),
+		})
+	} else if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": fmt.Sprintf("There's an error when resizing "+
+				"image %s => %s", imagePath, err.Error()),
+		})
+	}
+
+	// resized image is immutable like its image
+	if public {
+		c.Set(fiber.HeaderCacheControl, fmt.Sprintf(
+			"public, max-age=%d, immutable",
+			int(config.MediaCacheMaxAge.Seconds())))
+	} else {
+		c.Set(fiber.HeaderCacheControl, "private, no-cache")
+	}
+
+	return c.SendFile(resizedPath)
+}
+
+// resizeProductImage get file path of product image of image path resized
+// to fit within width x height, resizing it and caching the result
+// in media folder if it's not cached yet
+func resizeProductImage(imagePath string, width, height int) (string,
+	error) {
+	dst := filepath.Join(config.MediaRoot,
+		model.GetResizedImagePath(imagePath, width, height))
+	_, err := os.Stat(dst)
+	if err == nil {
+		return dst, nil
+	}
+
+	// decode the image
+	f, err := os.Open(filepath.Join(config.MediaRoot, imagePath))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	img, format, err := imaging.Decode(f)
+	if err != nil {
+		return "", err
+	}
+
+	// write the resized image into temporary file first, so concurrent
+	// requests never serve partially written image
+	err = os.MkdirAll(filepath.Dir(dst), os.ModePerm)
+	if err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".resize-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name()) // remove temporary file if fail
+
+	err = imaging.Encode(tmp, imaging.Resize(img, width, height), format)
+	if err != nil {
+		tmp.Close()
+		return "", err
+	}
+	err = tmp.Close()
+	if err != nil {
+		return "", err
+	}
+
+	return dst, os.Rename(tmp.Name(), dst)
+}
+
+// MediaCacheMiddleware add HTTP caching headers to media files served
+// by the next static handler, and reply not modified if client cached
+// the same file
+//
+// media files are immutable since every upload get a unique file name,
+// so they are cached by browsers and CDNs for maxAge
+func MediaCacheMiddleware(maxAge time.Duration) fiber.Handler {
+	cacheControl := fmt.Sprintf("public, max-age=%d, immutable",
+		int(maxAge.Seconds()))
+
+	return func(c *fiber.Ctx) error {
+		err := c.Next()
+		if err != nil {
+			return err
+		}
+
+		resp := c.Response()
+		status := resp.StatusCode()
+		if status != http.StatusOK && status != http.StatusPartialContent {
+			return nil
+		}
+
+		c.Set(fiber.HeaderCacheControl, cacheControl)
+
+		modTime, err := http.ParseTime(
+			string(resp.Header.Peek(fiber.HeaderLastModified)))
+		if err != nil {
+			return nil
+		}
+		mediaPath, err := url.PathUnescape(c.Path())
+		if err != nil {
+			return nil
+		}
+		etag := GetMediaETag(mediaPath, modTime)
+		c.Set(fiber.HeaderETag, etag)
+
+		if status == http.StatusOK &&
+			IsETagMatch(c.Get(fiber.HeaderIfNoneMatch), etag) {
+			resp.ResetBody()
+			resp.SetStatusCode(http.StatusNotModified)
+		}
+
+		return nil
+	}
+}
+
+// GetProductImagesZipHandler handling route download image files of
+// product by SKU as zip archive, streamed file by file
+// (method: GET, user: seller owning the product)
+func (a *API) GetProductImagesZipHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// check user role is allowed to access this API
+	if !a.isAllowed(u, permission.ProductReadOwn) {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this API",
+		})
+	}
+
+	// get SKU from url
+	SKU := c.Query("sku")
+	if strings.TrimSpace(SKU) == "" {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": "parameter 'sku' empty/not found",
+		})
+	}
+
+	// get product by sku from database
+	p, err := a.Repo.GetProductBySKU(c.UserContext(), SKU)
+	if err == sql.ErrNoRows {
+		return c.Status(http.StatusNotFound).JSON(map[string]string{
+			"message": "product not found",
+		})
+	} else if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	// check product owned by the seller
+	if p.ProductInfo.UserID != u.ID {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this product",
+		})
+	}
+
+	if len(p.ProductImages) == 0 {
+		return c.Status(http.StatusNotFound).JSON(map[string]string{
+			"message": "product has no images",
+		})
+	}
+
+	imagePaths := []string{}
+	for _, pImage := range p.ProductImages {
+		imagePaths = append(imagePaths, pImage.ImagePath)
+	}
+
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(
+		"attachment; filename=\"%s-images.zip\"", p.ProductInfo.SKU))
+
+	// stream the image files after handler returned
+	mediaDir := config.MediaRoot
+	c.Status(http.StatusOK).Context().SetBodyStreamWriter(
+		func(w *bufio.Writer) {
+			err := writeImagesZip(w, mediaDir, imagePaths)
+			if err != nil {
+				log.Printf("There's an error when zipping images "+
+					"of product %s => %s", p.ProductInfo.SKU, err.Error())
+			}
+		})
+
+	return nil
+}
+
+// writeImagesZip write image files at paths relative to media folder
+// into w as zip archive named by their file names, images are stored
+// without compression since they're already compressed
+func writeImagesZip(w io.Writer, mediaDir string, imagePaths []string) error {
+	zw := zip.NewWriter(w)
+
+	for _, imagePath := range imagePaths {
+		err := writeZipFile(zw, filepath.Join(mediaDir, imagePath))
+		if err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+// writeZipFile write file at path into zip archive
+func writeZipFile(zw *zip.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	fw, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     filepath.Base(path),
+		Method:   zip.Store,
+		Modified: info.ModTime(),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(fw, f)
+	return err
+}
+
+// ProductImageRecord contain image of a product with its order
+// among images of the product, the first image is the primary image
+type ProductImageRecord struct {
+	ID          int       `json:"id"`
+	ImagePath   string    `json:"image_path"`
+	ImageURL    string    `json:"image_url"`
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	Size        int64     `json:"size"`
+	ContentType string    `json:"content_type"`
+	Order       int       `json:"order"`
+	Primary     bool      `json:"primary"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// GetProductImageRecords get image records of product images in order,
+// starting from order 1 of the primary image
+func GetProductImageRecords(originURL string,
+	p model.Product) []ProductImageRecord {
+	public := isProductPublished(p.ProductInfo)
+
+	records := []ProductImageRecord{}
+	for i, pImage := range p.ProductImages {
+		records = append(records, ProductImageRecord{
+			ID:          pImage.ID,
+			ImagePath:   pImage.ImagePath,
+			ImageURL:    GetMediaURL(originURL, pImage.ImagePath, public),
+			Width:       pImage.Width,
+			Height:      pImage.Height,
+			Size:        pImage.Size,
+			ContentType: pImage.ContentType,
+			Order:       i + 1,
+			Primary:     i == 0,
+			CreatedAt:   pImage.CreatedAt,
+		})
+	}
+
+	return records
+}
+
+// GetProductImagesHandler handling route get image records of product
+// by SKU without the rest of the product, images of hidden product
+// only returned to its seller and admin (method: GET, user: any)
+func (a *API) GetProductImagesHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// get SKU from url
+	SKU := c.Query("sku")
+	if strings.TrimSpace(SKU) == "" {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": "parameter 'sku' empty/not found",
+		})
+	}
+
+	// get product by sku from cache or database
+	p, err := a.GetCachedProductBySKU(c.UserContext(), SKU)
+	if err == sql.ErrNoRows {
+		return c.Status(http.StatusNotFound).JSON(map[string]string{
+			"message": "product not found",
+		})
+	} else if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": fmt.Sprintf(
+				"There's an error when getting the product data => %s",
+				err.Error()),
+		})
+	}
+
+	// hidden product only can be seen by its seller and roles
+	// allowed to read hidden products
+	if p.ProductInfo.Hidden && u.ID != p.ProductInfo.UserID &&
+		!a.isAllowed(u, permission.ProductReadHidden) {
+		return c.Status(http.StatusNotFound).JSON(map[string]string{
+			"message": "product not found",
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(GetProductImageRecords(c.BaseURL(),
+		p))
+}
+
+// AddProductImagesHandler handling route upload images appended after
+// images of product by SKU, without resubmitting product info
+// (method: POST, user: seller owning the product)
+func (a *API) AddProductImagesHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// check user role is allowed to access this API
+	if !a.isAllowed(u, permission.ProductUpdate) {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this API",
+		})
+	}
+
+	// get SKU from url
+	SKU := c.Query("sku")
+	if strings.TrimSpace(SKU) == "" {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": "parameter 'sku' empty/not found",
+		})
+	}
+
+	// get image form (multi images)
+	imageForm, err := c.MultipartForm()
+	if err != nil {
+		return sendValidationError(c, validator.FieldError{
+			Field:   "product_images",
+			Code:    validator.CodeRequired,
+			Message: "product images empty/not found",
+		})
+	}
+
+	// validate product images count and size
+	fileHeaders := imageForm.File["product_images"]
+	if len(fileHeaders) == 0 {
+		return sendValidationError(c, validator.FieldError{
+			Field:   "product_images",
+			Code:    validator.CodeRequired,
+			Message: "product images empty/not found",
+		})
+	}
+	err = validator.IsProductImagesValid(fileHeaders,
+		config.MaxProductImages, config.MaxProductImagesSize)
+	if err != nil {
+		return sendValidationError(c, err)
+	}
+
+	// save product images into media folder
+	images, err := saveProductImages(fileHeaders, u.ID)
+	if errors.Is(err, model.ErrProductImageInvalid) ||
+		errors.Is(err, model.ErrProductImageMalicious) {
+		return sendValidationError(c, validator.FieldError{
+			Field:   "product_images",
+			Code:    validator.CodeInvalid,
+			Message: err.Error(),
+		})
+	} else if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": fmt.Sprintf("There's an error when saving "+
+				"product images => %s", err.Error()),
+		})
+	}
+
+	// append the images after images of the product in database
+	p, err := a.Repo.AddProductImagesBySKU(c.UserContext(), SKU, u.ID,
+		images)
+	if err != nil {
+		removeProductImages(images)
+	}
+	if err == sql.ErrNoRows {
+		return c.Status(http.StatusNotFound).JSON(map[string]string{
+			"message": "product not found",
+		})
+	} else if errors.Is(err, model.ErrTooManyProductImages) {
+		return sendValidationError(c, validator.FieldError{
+			Field:   "product_images",
+			Code:    validator.CodeTooLarge,
+			Message: err.Error(),
+		})
+	} else if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	a.PublishEvent(event.NewEvent(event.ProductUpdated, p.ProductInfo.SKU,
+		p.ProductInfo.UserID, p.ProductInfo))
+
+	setProductImageURLs(c.BaseURL(), []model.Product{p})
+
+	return c.Status(http.StatusCreated).JSON(p)
+}
+
+// saveProductImages save uploaded product image files of seller of user ID
+// into media folder, returning the saved images, already saved files
+// are removed if any of them failed
+func saveProductImages(fileHeaders []*multipart.FileHeader, userID int) (
+	[]model.ProductImage, error) {
+	images := []model.ProductImage{}
+	for _, fileHeader := range fileHeaders {
+		pImage, err := model.SaveProductImage(fileHeader, userID)
+		if err != nil {
+			removeProductImages(images)
+			return nil, err
+		}
+
+		images = append(images, pImage)
+	}
+
+	return images, nil
+}
+
+// removeProductImages remove files of product images not stored
+// in database, failure is only logged since the files are also removed
+// later by cleanup-media command
+func removeProductImages(images []model.ProductImage) {
+	for _, pImage := range images {
+		err := model.RemoveProductImageFile(pImage.ImagePath)
+		if err != nil {
+			log.Printf("There's an error when removing product image %s "+
+				"=> %s", pImage.ImagePath, err.Error())
+		}
+	}
+}
+
+// GetMediaURL get URL of media file of image path, served by media CDN
+// if configured and the file is public, otherwise by origin URL
+func GetMediaURL(originURL string, imagePath string, public bool) string {
+	if config.MediaCDNURL != "" && public {
+		originURL = config.MediaCDNURL
+	}
+
+	return originURL + "/media/" + imagePath
+}
+
+// setProductImageURLs set URL of every image of products, images
+// of published products are served by media CDN if configured since
+// images of unpublished ones are only served by origin to their seller
+func setProductImageURLs(originURL string, products []model.Product) {
+	for i := range products {
+		public := isProductPublished(products[i].ProductInfo)
+		for j := range products[i].ProductImages {
+			products[i].ProductImages[j].ImageURL = GetMediaURL(originURL,
+				products[i].ProductImages[j].ImagePath, public)
+		}
+	}
+}
+
+// isProductPublished check product is visible and not deleted
+func isProductPublished(pInfo model.ProductInfo) bool {
+	return !pInfo.Hidden && pInfo.DeletedAt == nil
+}
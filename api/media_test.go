@@ -0,0 +1,748 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/config"
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+)
+
+// TestMediaCacheMiddleware test MediaCacheMiddleware with static media
+func TestMediaCacheMiddleware(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "image.png"),
+		[]byte("0123456789"), 0644)
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+
+	app := fiber.New()
+	app.Use("/media", MediaCacheMiddleware(time.Hour))
+	app.Static("/media", dir, fiber.Static{ByteRange: true})
+
+	// get the image first to get its ETag
+	req, err := http.NewRequest("GET", "/media/image.png", nil)
+	if err != nil {
+		t.Fatalf("There's an error when creating request => %s", err.Error())
+	}
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("There's an error serve http testing => %s", err.Error())
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status code %d, but got %d",
+			http.StatusOK, resp.StatusCode)
+	}
+	if resp.Header.Get("Cache-Control") != "public, max-age=3600, immutable" {
+		t.Errorf("Expected immutable Cache-Control, but got '%s'",
+			resp.Header.Get("Cache-Control"))
+	}
+	etag := resp.Header.Get("ETag")
+	if etag == "" || resp.Header.Get("Last-Modified") == "" {
+		t.Fatalf("Expected ETag and Last-Modified set")
+	}
+
+	// create testing table
+	testTable := []struct {
+		TestName           string
+		Headers            map[string]string
+		ExpectedStatusCode int
+		ExpectedBody       string
+	}{
+		{
+			TestName:           "Cached by client",
+			Headers:            map[string]string{"If-None-Match": etag},
+			ExpectedStatusCode: http.StatusNotModified,
+		},
+		{
+			TestName:           "Stale client cache",
+			Headers:            map[string]string{"If-None-Match": `W/"stale"`},
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedBody:       "0123456789",
+		},
+		{
+			TestName:           "Range request",
+			Headers:            map[string]string{"Range": "bytes=2-5"},
+			ExpectedStatusCode: http.StatusPartialContent,
+			ExpectedBody:       "2345",
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		req, err := http.NewRequest("GET", "/media/image.png", nil)
+		if err != nil {
+			t.Fatalf("[%s] There's an error when creating request => %s",
+				test.TestName, err.Error())
+		}
+		for key, value := range test.Headers {
+			req.Header.Set(key, value)
+		}
+
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("[%s] There's an error serve http testing => %s",
+				test.TestName, err.Error())
+		}
+		if resp.StatusCode != test.ExpectedStatusCode {
+			t.Errorf("[%s] Expected status code %d, but got %d",
+				test.TestName, test.ExpectedStatusCode, resp.StatusCode)
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("[%s] There's an error when reading body => %s",
+				test.TestName, err.Error())
+		}
+		if string(body) != test.ExpectedBody {
+			t.Errorf("[%s] Expected body '%s', but got '%s'",
+				test.TestName, test.ExpectedBody, string(body))
+		}
+	}
+}
+
+// TestGetProductImagesZipHandler test GetProductImagesZipHandler
+// with product repository in memory
+func TestGetProductImagesZipHandler(t *testing.T) {
+	mediaRoot := config.MediaRoot
+	config.MediaRoot = t.TempDir()
+	defer func() { config.MediaRoot = mediaRoot }()
+
+	// create image files in testing media folder
+	imageDir := filepath.Join(config.MediaRoot, "product-image")
+	err := os.MkdirAll(imageDir, os.ModePerm)
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+	images := map[string]string{
+		"zip-test-a.png": "image a",
+		"zip-test-b.jpg": "image b",
+	}
+	for name, content := range images {
+		err = os.WriteFile(filepath.Join(imageDir, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatalf("Expected error nil, but got error => %s", err.Error())
+		}
+		defer os.Remove(filepath.Join(imageDir, name))
+	}
+
+	repo := fakeRepository{products: map[string]model.Product{
+		"SKU-A": {
+			ProductInfo: model.ProductInfo{SKU: "SKU-A", UserID: 1},
+			ProductImages: []model.ProductImage{
+				{ImagePath: "product-image/zip-test-a.png"},
+				{ImagePath: "product-image/zip-test-b.jpg"},
+			},
+		},
+		"SKU-B": {ProductInfo: model.ProductInfo{SKU: "SKU-B", UserID: 1}},
+	}}
+
+	// create testing table
+	testTable := []struct {
+		TestName           string
+		SKU                string
+		User               middleware.User
+		ExpectedStatusCode int
+	}{
+		{
+			TestName:           "Owner",
+			SKU:                "SKU-A",
+			User:               middleware.User{ID: 1, Role: "seller"},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			TestName:           "Not Owner",
+			SKU:                "SKU-A",
+			User:               middleware.User{ID: 2, Role: "seller"},
+			ExpectedStatusCode: http.StatusForbidden,
+		},
+		{
+			TestName:           "Buyer",
+			SKU:                "SKU-A",
+			User:               middleware.User{ID: 1, Role: "buyer"},
+			ExpectedStatusCode: http.StatusForbidden,
+		},
+		{
+			TestName:           "No Images",
+			SKU:                "SKU-B",
+			User:               middleware.User{ID: 1, Role: "seller"},
+			ExpectedStatusCode: http.StatusNotFound,
+		},
+		{
+			TestName:           "Not Found",
+			SKU:                "SKU-C",
+			User:               middleware.User{ID: 1, Role: "seller"},
+			ExpectedStatusCode: http.StatusNotFound,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		a := API{Repo: repo, FiberApp: fiber.New()}
+		a.FiberApp.Get("/api/product/images/zip/",
+			AuthorizationMiddlewareForTest(test.User),
+			a.GetProductImagesZipHandler)
+
+		req, _ := http.NewRequest("GET",
+			"/api/product/images/zip/?sku="+test.SKU, nil)
+		response, err := a.FiberApp.Test(req)
+		if err != nil {
+			t.Fatalf("[%s] There's an error serve http testing => %s",
+				test.TestName, err.Error())
+		}
+		defer response.Body.Close()
+
+		if response.StatusCode != test.ExpectedStatusCode {
+			t.Errorf("[%s] Expected status %d got %d", test.TestName,
+				test.ExpectedStatusCode, response.StatusCode)
+			continue
+		} else if response.StatusCode != http.StatusOK {
+			continue
+		}
+
+		// check zipped image files
+		body, err := io.ReadAll(response.Body)
+		if err != nil {
+			t.Fatalf("[%s] Expected error nil, but got error => %s",
+				test.TestName, err.Error())
+		}
+		zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+		if err != nil {
+			t.Fatalf("[%s] Expected zip archive, but got error => %s",
+				test.TestName, err.Error())
+		}
+		if len(zr.File) != len(images) {
+			t.Errorf("[%s] Expected %d files, but got %d", test.TestName,
+				len(images), len(zr.File))
+		}
+		for _, f := range zr.File {
+			rc, err := f.Open()
+			if err != nil {
+				t.Fatalf("[%s] Expected error nil, but got error => %s",
+					test.TestName, err.Error())
+			}
+			content, _ := io.ReadAll(rc)
+			rc.Close()
+
+			if string(content) != images[f.Name] {
+				t.Errorf("[%s] Expected file %s content '%s', but got '%s'",
+					test.TestName, f.Name, images[f.Name], string(content))
+			}
+		}
+	}
+}
+
+// TestGetProductImagesHandler test GetProductImagesHandler
+func TestGetProductImagesHandler(t *testing.T) {
+	repo := fakeRepository{products: map[string]model.Product{
+		"SKU-A": {
+			ProductInfo: model.ProductInfo{SKU: "SKU-A", UserID: 1},
+			ProductImages: []model.ProductImage{
+				{ID: 3, ImagePath: "product-image/a-1.png"},
+				{ID: 5, ImagePath: "product-image/a-2.png"},
+			},
+		},
+		"SKU-B": {
+			ProductInfo: model.ProductInfo{SKU: "SKU-B", UserID: 1,
+				Hidden: true},
+			ProductImages: []model.ProductImage{
+				{ID: 7, ImagePath: "product-image/b-1.png"},
+			},
+		},
+	}}
+
+	// create testing table
+	testTable := []struct {
+		TestName           string
+		SKU                string
+		User               middleware.User
+		ExpectedStatusCode int
+		ExpectedIDs        []int
+	}{
+		{"Buyer", "SKU-A", middleware.User{ID: 2, Role: "buyer"},
+			http.StatusOK, []int{3, 5}},
+		{"Hidden Owner", "SKU-B", middleware.User{ID: 1, Role: "seller"},
+			http.StatusOK, []int{7}},
+		{"Hidden Buyer", "SKU-B", middleware.User{ID: 2, Role: "buyer"},
+			http.StatusNotFound, nil},
+		{"Not Found", "SKU-C", middleware.User{ID: 2, Role: "buyer"},
+			http.StatusNotFound, nil},
+		{"SKU Empty", "", middleware.User{ID: 2, Role: "buyer"},
+			http.StatusBadRequest, nil},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		a := API{Repo: repo, FiberApp: fiber.New()}
+		a.FiberApp.Get("/api/product/images/",
+			AuthorizationMiddlewareForTest(test.User),
+			a.GetProductImagesHandler)
+
+		req, _ := http.NewRequest("GET", "/api/product/images/?sku="+test.SKU,
+			nil)
+		response, err := a.FiberApp.Test(req)
+		if err != nil {
+			t.Fatalf("[%s] There's an error serve http testing => %s",
+				test.TestName, err.Error())
+		}
+		defer response.Body.Close()
+
+		if response.StatusCode != test.ExpectedStatusCode {
+			t.Errorf("[%s] Expected status %d got %d", test.TestName,
+				test.ExpectedStatusCode, response.StatusCode)
+			continue
+		} else if response.StatusCode != http.StatusOK {
+			continue
+		}
+
+		// check image records in order with the first as primary
+		records := []ProductImageRecord{}
+		err = json.NewDecoder(response.Body).Decode(&records)
+		if err != nil {
+			t.Fatalf("[%s] Expected error nil, but got error => %s",
+				test.TestName, err.Error())
+		}
+		if len(records) != len(test.ExpectedIDs) {
+			t.Fatalf("[%s] Expected %d images, but got %+v", test.TestName,
+				len(test.ExpectedIDs), records)
+		}
+		for i, record := range records {
+			if record.ID != test.ExpectedIDs[i] || record.Order != i+1 ||
+				record.Primary != (i == 0) || record.ImageURL == "" {
+				t.Errorf("[%s] Expected image %d at order %d, but got %+v",
+					test.TestName, test.ExpectedIDs[i], i+1, record)
+			}
+		}
+	}
+}
+
+// addImagesRepository product repository in memory appending images
+// to products of seller of ID 1 with their current images count
+type addImagesRepository struct {
+	model.ProductRepository
+	imagesCount map[string]int
+}
+
+// AddProductImagesBySKU append images to product in memory
+func (r addImagesRepository) AddProductImagesBySKU(ctx context.Context,
+	SKU string, userID int, images []model.ProductImage) (model.Product,
+	error) {
+	count, ok := r.imagesCount[SKU]
+	if !ok || userID != 1 {
+		return model.Product{}, sql.ErrNoRows
+	}
+	if count+len(images) > config.MaxProductImages {
+		return model.Product{}, model.ErrTooManyProductImages
+	}
+
+	return model.Product{
+		ProductInfo:   model.ProductInfo{SKU: SKU, UserID: userID},
+		ProductImages: images,
+	}, nil
+}
+
+// TestAddProductImagesHandler test AddProductImagesHandler
+func TestAddProductImagesHandler(t *testing.T) {
+	mediaRoot := config.MediaRoot
+	maxProductImages := config.MaxProductImages
+	maxProductImagesSize := config.MaxProductImagesSize
+	config.MediaRoot = t.TempDir()
+	config.MaxProductImages = 3
+	config.MaxProductImagesSize = 1024 * 1024
+	defer func() {
+		config.MediaRoot = mediaRoot
+		config.MaxProductImages = maxProductImages
+		config.MaxProductImagesSize = maxProductImagesSize
+	}()
+
+	repo := addImagesRepository{imagesCount: map[string]int{
+		"SKU-A": 1,
+		"SKU-B": 3,
+	}}
+
+	// create testing table
+	testTable := []struct {
+		TestName           string
+		SKU                string
+		User               middleware.User
+		ImagesCount        int
+		ExpectedStatusCode int
+	}{
+		{"Success", "SKU-A", middleware.User{ID: 1, Role: "seller"}, 2,
+			http.StatusCreated},
+		{"No Images", "SKU-A", middleware.User{ID: 1, Role: "seller"}, 0,
+			http.StatusBadRequest},
+		{"Too Many Images", "SKU-B", middleware.User{ID: 1, Role: "seller"}, 1,
+			http.StatusBadRequest},
+		{"Too Many Uploaded", "SKU-A", middleware.User{ID: 1, Role: "seller"},
+			4, http.StatusBadRequest},
+		{"Not Owner", "SKU-A", middleware.User{ID: 2, Role: "seller"}, 1,
+			http.StatusNotFound},
+		{"Not Found", "SKU-C", middleware.User{ID: 1, Role: "seller"}, 1,
+			http.StatusNotFound},
+		{"Buyer", "SKU-A", middleware.User{ID: 1, Role: "buyer"}, 1,
+			http.StatusForbidden},
+		{"SKU Empty", "", middleware.User{ID: 1, Role: "seller"}, 1,
+			http.StatusBadRequest},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		a := API{Repo: repo, FiberApp: fiber.New()}
+		a.FiberApp.Post("/api/product/images/",
+			AuthorizationMiddlewareForTest(test.User),
+			a.AddProductImagesHandler)
+
+		// create form data of product images
+		var bFormData bytes.Buffer
+		w := multipart.NewWriter(&bFormData)
+		for i := 0; i < test.ImagesCount; i++ {
+			ffw, err := w.CreateFormFile("product_images",
+				fmt.Sprintf("image-%d.png", i))
+			if err != nil {
+				t.Fatalf("[%s] Expected error nil, but got error => %s",
+					test.TestName, err.Error())
+			}
+			err = png.Encode(ffw, image.NewRGBA(image.Rect(0, 0, 10, 10)))
+			if err != nil {
+				t.Fatalf("[%s] Expected error nil, but got error => %s",
+					test.TestName, err.Error())
+			}
+		}
+		w.Close()
+
+		req, _ := http.NewRequest("POST",
+			"/api/product/images/?sku="+test.SKU, &bFormData)
+		req.Header.Set("Content-Type", w.FormDataContentType())
+		response, err := a.FiberApp.Test(req)
+		if err != nil {
+			t.Fatalf("[%s] There's an error serve http testing => %s",
+				test.TestName, err.Error())
+		}
+		defer response.Body.Close()
+
+		if response.StatusCode != test.ExpectedStatusCode {
+			t.Errorf("[%s] Expected status %d got %d", test.TestName,
+				test.ExpectedStatusCode, response.StatusCode)
+			continue
+		} else if response.StatusCode != http.StatusCreated {
+			continue
+		}
+
+		// check the appended images returned with their URLs
+		p := model.Product{}
+		err = json.NewDecoder(response.Body).Decode(&p)
+		if err != nil {
+			t.Fatalf("[%s] Expected error nil, but got error => %s",
+				test.TestName, err.Error())
+		}
+		if len(p.ProductImages) != test.ImagesCount {
+			t.Errorf("[%s] Expected %d images, but got %d", test.TestName,
+				test.ImagesCount, len(p.ProductImages))
+		}
+		for _, pImage := range p.ProductImages {
+			if pImage.ImageURL == "" {
+				t.Errorf("[%s] Expected image URL, but got empty",
+					test.TestName)
+			}
+		}
+	}
+
+	// check images of failed upload removed from media folder
+	files, _ := filepath.Glob(filepath.Join(config.MediaRoot,
+		"product-image", "*"))
+	if len(files) != 2 {
+		t.Errorf("Expected 2 image files saved, but got %d", len(files))
+	}
+}
+
+// TestMediaAccessMiddleware test MediaAccessMiddleware serving images
+// of unpublished products only to their seller
+func TestMediaAccessMiddleware(t *testing.T) {
+	dir := t.TempDir()
+	err := os.MkdirAll(filepath.Join(dir, "product-image"), os.ModePerm)
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+	for _, name := range []string{"published.png", "hidden.png",
+		"orphan.png", "my image.png"} {
+		err = os.WriteFile(filepath.Join(dir, "product-image", name),
+			[]byte(name), 0644)
+		if err != nil {
+			t.Fatalf("Expected error nil, but got error => %s", err.Error())
+		}
+	}
+
+	repo := fakeRepository{products: map[string]model.Product{
+		"SKU-A": {
+			ProductInfo: model.ProductInfo{SKU: "SKU-A", UserID: 1},
+			ProductImages: []model.ProductImage{
+				{ImagePath: "product-image/published.png"},
+				{ImagePath: "product-image/my image.png"},
+			},
+		},
+		"SKU-B": {
+			ProductInfo: model.ProductInfo{SKU: "SKU-B", UserID: 1,
+				Hidden: true},
+			ProductImages: []model.ProductImage{
+				{ImagePath: "product-image/hidden.png"},
+			},
+		},
+	}}
+
+	// create testing table
+	testTable := []struct {
+		TestName             string
+		Path                 string
+		User                 *middleware.User
+		ExpectedStatusCode   int
+		ExpectedCacheControl string
+	}{
+		{
+			TestName:             "Published Anonymous",
+			Path:                 "/media/product-image/published.png",
+			ExpectedStatusCode:   http.StatusOK,
+			ExpectedCacheControl: "public, max-age=3600, immutable",
+		},
+		{
+			TestName:             "Published Escaped Path",
+			Path:                 "/media/product-image/my%20image.png",
+			ExpectedStatusCode:   http.StatusOK,
+			ExpectedCacheControl: "public, max-age=3600, immutable",
+		},
+		{
+			TestName:           "Path Invalid",
+			Path:               "/media/product-image/my%zzimage.png",
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			TestName:           "Unpublished Anonymous",
+			Path:               "/media/product-image/hidden.png",
+			ExpectedStatusCode: http.StatusNotFound,
+		},
+		{
+			TestName:             "Unpublished Owner",
+			Path:                 "/media/product-image/hidden.png",
+			User:                 &middleware.User{ID: 1, Role: "seller"},
+			ExpectedStatusCode:   http.StatusOK,
+			ExpectedCacheControl: "private, no-cache",
+		},
+		{
+			TestName:           "Unpublished Other Seller",
+			Path:               "/media/product-image/hidden.png",
+			User:               &middleware.User{ID: 2, Role: "seller"},
+			ExpectedStatusCode: http.StatusNotFound,
+		},
+		{
+			TestName:           "Not Product Image",
+			Path:               "/media/product-image/orphan.png",
+			User:               &middleware.User{ID: 1, Role: "seller"},
+			ExpectedStatusCode: http.StatusNotFound,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		a := API{Repo: repo, FiberApp: fiber.New()}
+		if test.User != nil {
+			a.FiberApp.Use(AuthorizationMiddlewareForTest(*test.User))
+		}
+		a.FiberApp.Use("/media", a.MediaAccessMiddleware(),
+			MediaCacheMiddleware(time.Hour))
+		a.FiberApp.Static("/media", dir)
+
+		req, err := http.NewRequest("GET", "/", nil)
+		if err != nil {
+			t.Fatalf("[%s] There's an error when creating request => %s",
+				test.TestName, err.Error())
+		}
+		req.URL.Opaque = test.Path // sent as is, even if escaping invalid
+		resp, err := a.FiberApp.Test(req)
+		if err != nil {
+			t.Fatalf("[%s] There's an error serve http testing => %s",
+				test.TestName, err.Error())
+		}
+		if resp.StatusCode != test.ExpectedStatusCode {
+			t.Errorf("[%s] Expected status code %d, but got %d",
+				test.TestName, test.ExpectedStatusCode, resp.StatusCode)
+		}
+		if resp.Header.Get("Cache-Control") != test.ExpectedCacheControl {
+			t.Errorf("[%s] Expected Cache-Control '%s', but got '%s'",
+				test.TestName, test.ExpectedCacheControl,
+				resp.Header.Get("Cache-Control"))
+		}
+	}
+}
+
+// TestGetMediaURL test GetMediaURL
+func TestGetMediaURL(t *testing.T) {
+	mediaCDNURL := config.MediaCDNURL
+	defer func() { config.MediaCDNURL = mediaCDNURL }()
+
+	// create testing table
+	testTable := []struct {
+		TestName    string
+		MediaCDNURL string
+		Public      bool
+		ExpectedURL string
+	}{
+		{
+			TestName:    "No CDN",
+			Public:      true,
+			ExpectedURL: "http://localhost:8020/media/product-image/a.png",
+		},
+		{
+			TestName:    "CDN",
+			MediaCDNURL: "https://cdn.example.com",
+			Public:      true,
+			ExpectedURL: "https://cdn.example.com/media/product-image/a.png",
+		},
+		{
+			TestName:    "CDN Not Public",
+			MediaCDNURL: "https://cdn.example.com",
+			ExpectedURL: "http://localhost:8020/media/product-image/a.png",
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		config.MediaCDNURL = test.MediaCDNURL
+		URL := GetMediaURL("http://localhost:8020", "product-image/a.png",
+			test.Public)
+		if URL != test.ExpectedURL {
+			t.Errorf("[%s] Expected URL '%s', but got '%s'",
+				test.TestName, test.ExpectedURL, URL)
+		}
+	}
+}
+
+// TestResizeMediaHandler test ResizeMediaHandler resizing and caching
+// product images
+func TestResizeMediaHandler(t *testing.T) {
+	mediaRoot := config.MediaRoot
+	resizeSizes := config.MediaResizeSizes
+	config.MediaRoot = t.TempDir()
+	config.MediaResizeSizes = []config.ImageSize{{Width: 10, Height: 10}}
+	defer func() {
+		config.MediaRoot = mediaRoot
+		config.MediaResizeSizes = resizeSizes
+	}()
+
+	// create 40x20 image file in testing media folder
+	err := os.MkdirAll(filepath.Join(config.MediaRoot, "product-image"),
+		os.ModePerm)
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+	f, err := os.Create(filepath.Join(config.MediaRoot,
+		"product-image/resize.png"))
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+	err = png.Encode(f, image.NewRGBA(image.Rect(0, 0, 40, 20)))
+	f.Close()
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+
+	a := API{
+		Repo: fakeRepository{products: map[string]model.Product{
+			"SKU-A": {
+				ProductInfo: model.ProductInfo{SKU: "SKU-A", UserID: 1},
+				ProductImages: []model.ProductImage{
+					{ImagePath: "product-image/resize.png"},
+				},
+			},
+		}},
+		FiberApp: fiber.New(),
+	}
+	a.FiberApp.Get("/media/resize/", a.ResizeMediaHandler)
+
+	// create testing table
+	testTable := []struct {
+		TestName           string
+		Query              string
+		ExpectedStatusCode int
+	}{
+		{
+			TestName:           "Resize",
+			Query:              "?path=product-image/resize.png&w=10&h=10",
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			TestName:           "Cached",
+			Query:              "?path=product-image/resize.png&w=10&h=10",
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			TestName:           "Size Not Allowed",
+			Query:              "?path=product-image/resize.png&w=20&h=20",
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			TestName:           "Path Invalid",
+			Query:              "?path=product-image/../../secret.png&w=10&h=10",
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			TestName:           "Not Product Image",
+			Query:              "?path=product-image/other.png&w=10&h=10",
+			ExpectedStatusCode: http.StatusNotFound,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		req, err := http.NewRequest("GET", "/media/resize/"+test.Query, nil)
+		if err != nil {
+			t.Fatalf("[%s] There's an error when creating request => %s",
+				test.TestName, err.Error())
+		}
+		resp, err := a.FiberApp.Test(req)
+		if err != nil {
+			t.Fatalf("[%s] There's an error serve http testing => %s",
+				test.TestName, err.Error())
+		}
+		if resp.StatusCode != test.ExpectedStatusCode {
+			t.Errorf("[%s] Expected status code %d, but got %d",
+				test.TestName, test.ExpectedStatusCode, resp.StatusCode)
+		}
+		if resp.StatusCode != http.StatusOK {
+			continue
+		}
+
+		// check image resized keeping its aspect ratio
+		img, err := png.Decode(resp.Body)
+		if err != nil || img.Bounds().Dx() != 10 || img.Bounds().Dy() != 5 {
+			t.Errorf("[%s] Expected 10x5 PNG image, but got %v (%v)",
+				test.TestName, img, err)
+		}
+	}
+
+	// check resized image cached in media folder
+	_, err = os.Stat(filepath.Join(config.MediaRoot,
+		"resized/10x10/product-image/resize.png"))
+	if err != nil {
+		t.Errorf("Expected resized image cached, but got error => %s",
+			err.Error())
+	}
+}
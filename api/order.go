@@ -0,0 +1,72 @@
+package api
+
+import (
+	"context"
+
+	"github.com/reyhanfikridz/ecom-product-service/internal/event"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+)
+
+// HandleOrderEvent handling order event from order service,
+// decrease stock when order placed and restore it when order cancelled,
+// and count completed order of ordered products when order completed,
+// all once per order even if the event redelivered
+func (a *API) HandleOrderEvent(e event.OrderEvent) error {
+	if e.Type == event.OrderCompleted {
+		// each product counted once per order
+		SKUs := []string{}
+		counted := map[string]bool{}
+		for _, item := range e.Items {
+			if counted[item.SKU] {
+				continue
+			}
+			counted[item.SKU] = true
+
+			SKUs = append(SKUs, item.SKU)
+		}
+
+		_, err := a.Repo.CountCompletedOrder(context.Background(), e.OrderID,
+			SKUs)
+		return err
+	}
+
+	// get stock adjustments from order items,
+	// quantities of the same product summed into one adjustment
+	adjustments := []model.StockAdjustment{}
+	indexes := map[string]int{}
+	for _, item := range e.Items {
+		delta, reason := -item.Qty, model.StockReasonOrderPlaced
+		if e.Type == event.OrderCancelled {
+			delta, reason = item.Qty, model.StockReasonOrderCancelled
+		}
+
+		if i, ok := indexes[item.SKU]; ok {
+			adjustments[i].Delta += delta
+			continue
+		}
+		indexes[item.SKU] = len(adjustments)
+
+		adjustments = append(adjustments, model.StockAdjustment{
+			SKU:     item.SKU,
+			Delta:   delta,
+			Reason:  reason,
+			OrderID: e.OrderID,
+		})
+	}
+
+	// apply all stock adjustments at once
+	adjustments, err := a.Repo.AdjustStocks(context.Background(), adjustments)
+	if err != nil {
+		return err
+	}
+
+	for _, adj := range adjustments {
+		a.PublishEvent(event.NewEvent(event.StockChanged, adj.SKU,
+			adj.UserID, event.StockChangedPayload{
+				Stock: adj.Stock,
+				Delta: adj.Delta,
+			}))
+	}
+
+	return nil
+}
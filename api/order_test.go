@@ -0,0 +1,56 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/reyhanfikridz/ecom-product-service/internal/event"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+)
+
+// orderRepository product repository in memory recording
+// the last applied stock adjustments
+type orderRepository struct {
+	fakeRepository
+	adjustments *[]model.StockAdjustment
+}
+
+// AdjustStocks record the adjustments
+func (r orderRepository) AdjustStocks(ctx context.Context,
+	adjustments []model.StockAdjustment) ([]model.StockAdjustment, error) {
+	*r.adjustments = adjustments
+	return adjustments, nil
+}
+
+// TestHandleOrderEventPlaced test HandleOrderEvent adjusting stock
+// once for each ordered product, keyed by the order
+func TestHandleOrderEventPlaced(t *testing.T) {
+	repo := orderRepository{adjustments: &[]model.StockAdjustment{}}
+	a := API{Repo: repo}
+
+	err := a.HandleOrderEvent(event.OrderEvent{
+		Type:    event.OrderPlaced,
+		OrderID: "ORDER-1",
+		Items: []event.OrderItem{
+			{SKU: "SKU-A", Qty: 2}, {SKU: "SKU-B", Qty: 1}, {SKU: "SKU-A", Qty: 1},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+
+	expected := []model.StockAdjustment{
+		{SKU: "SKU-A", Delta: -3, Reason: model.StockReasonOrderPlaced,
+			OrderID: "ORDER-1"},
+		{SKU: "SKU-B", Delta: -1, Reason: model.StockReasonOrderPlaced,
+			OrderID: "ORDER-1"},
+	}
+	if !reflect.DeepEqual(*repo.adjustments, expected) {
+		t.Errorf("Expected adjustments %+v, but got %+v", expected,
+			*repo.adjustments)
+	}
+}
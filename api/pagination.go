@@ -0,0 +1,145 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+)
+
+// maximum products per page of product listing
+const maxProductsLimit = 100
+
+// NextCursorHeader response header containing cursor of the next page
+// of product listing, not set on the last page
+const NextCursorHeader = "X-Next-Cursor"
+
+// sendProducts send products by query with sort order, filters, and page
+// taken from url parameters 'sort', 'fuzzy', 'on_sale', 'in_stock',
+// 'limit', and 'cursor', localized into locale
+// of url parameter 'locale' or header Accept-Language
+//
+// products are not paginated if 'limit' is empty, otherwise cursor of
+// the next page is set into response header X-Next-Cursor, and products
+// have highlights of where they matched the search if query has search
+func (a *API) sendProducts(c *fiber.Ctx, query model.ProductQuery) error {
+	query.Sort = c.Query("sort")
+
+	// get sale filter from url
+	if rawOnSale := c.Query("on_sale"); rawOnSale != "" {
+		var err error
+		query.OnSale, err = strconv.ParseBool(rawOnSale)
+		if err != nil {
+			return c.Status(http.StatusBadRequest).JSON(map[string]string{
+				"message": "parameter 'on_sale' invalid, must be boolean",
+			})
+		}
+	}
+
+	// get fuzzy search flag from url
+	if rawFuzzy := c.Query("fuzzy"); rawFuzzy != "" {
+		var err error
+		query.Fuzzy, err = strconv.ParseBool(rawFuzzy)
+		if err != nil {
+			return c.Status(http.StatusBadRequest).JSON(map[string]string{
+				"message": "parameter 'fuzzy' invalid, must be boolean",
+			})
+		}
+	}
+
+	// get stock filter from url
+	if rawInStock := c.Query("in_stock"); rawInStock != "" {
+		var err error
+		query.InStock, err = strconv.ParseBool(rawInStock)
+		if err != nil {
+			return c.Status(http.StatusBadRequest).JSON(map[string]string{
+				"message": "parameter 'in_stock' invalid, must be boolean",
+			})
+		}
+	}
+
+	// get page from url
+	limit := 0
+	if rawLimit := c.Query("limit"); rawLimit != "" {
+		var err error
+		limit, err = strconv.Atoi(rawLimit)
+		if err != nil || limit <= 0 || limit > maxProductsLimit {
+			return c.Status(http.StatusBadRequest).JSON(map[string]string{
+				"message": fmt.Sprintf("parameter 'limit' invalid, "+
+					"must be integer between 1 and %d", maxProductsLimit),
+			})
+		}
+
+		// get one more product to know whether there's a next page
+		query.Limit = limit + 1
+	}
+	if rawCursor := c.Query("cursor"); rawCursor != "" {
+		cursor, err := model.DecodeProductCursor(rawCursor)
+		if err != nil {
+			return c.Status(http.StatusBadRequest).JSON(map[string]string{
+				"message": "parameter 'cursor' invalid",
+			})
+		}
+		query.After = &cursor
+	}
+
+	// resolve search by search provider
+	rawSearch := query.Search
+	if a.Search != nil {
+		var err error
+		query, err = a.Search.Query(c.UserContext(), query)
+		if err != nil {
+			return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+				"message": fmt.Sprintf(
+					"There's an error when searching the products => %s",
+					err.Error()),
+			})
+		}
+	}
+
+	// get products from database
+	products, err := a.Repo.GetProducts(c.UserContext(), query)
+	if err == model.ErrProductSortInvalid || err == model.ErrProductCursorInvalid {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	} else if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": fmt.Sprintf(
+				"There's an error when getting the products data => %s",
+				err.Error()),
+		})
+	}
+
+	// set cursor of the next page
+	if limit > 0 && len(products) > limit {
+		products = products[:limit]
+		c.Set(NextCursorHeader, model.NewProductCursor(query.Sort,
+			products[limit-1].ProductInfo).Encode())
+	}
+
+	// localize products into requested locale
+	err = a.localizeProducts(c, products)
+	if err == errLocaleInvalid {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	} else if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": fmt.Sprintf(
+				"There's an error when localizing the products data => %s",
+				err.Error()),
+		})
+	}
+
+	// highlight where search matched products
+	if rawSearch != "" {
+		model.HighlightProducts(products, rawSearch)
+	}
+
+	setProductImageURLs(c.BaseURL(), products)
+
+	return c.Status(http.StatusOK).JSON(products)
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+	"github.com/reyhanfikridz/ecom-product-service/internal/validator"
+)
+
+// PopularityReport contain popularity counters reported at once
+type PopularityReport struct {
+	Counters []model.PopularityCounter `json:"counters"`
+}
+
+// ReportPopularityHandler handling route increment popularity counters
+// of products, called by wishlist and order service, counters of
+// products not found are ignored (method: POST, user: internal service)
+func (a *API) ReportPopularityHandler(c *fiber.Ctx) error {
+	// get counters from body
+	report := PopularityReport{}
+	err := c.BodyParser(&report)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	// validate counters
+	err = validator.IsPopularityCountersValid(report.Counters)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	// increment counters in database
+	updated, err := a.Repo.IncrementPopularityCounters(c.UserContext(),
+		report.Counters)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": fmt.Sprintf(
+				"There's an error when incrementing the popularity "+
+					"counters => %s", err.Error()),
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(map[string]interface{}{
+		"message":          "Report popularity success!",
+		"updated_products": updated,
+	})
+}
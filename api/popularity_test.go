@@ -0,0 +1,148 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/config"
+	"github.com/reyhanfikridz/ecom-product-service/internal/event"
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+)
+
+// popularityRepository product repository in memory recording
+// the last incremented popularity counters and products counted
+// for completed orders
+type popularityRepository struct {
+	fakeRepository
+	counters *[]model.PopularityCounter
+	orders   map[string][]string
+}
+
+// IncrementPopularityCounters record the counters
+func (r popularityRepository) IncrementPopularityCounters(
+	ctx context.Context, counters []model.PopularityCounter) (int, error) {
+	*r.counters = counters
+	return len(counters), nil
+}
+
+// CountCompletedOrder record SKUs of the order not counted yet
+func (r popularityRepository) CountCompletedOrder(ctx context.Context,
+	orderID string, SKUs []string) (int, error) {
+	counted := 0
+	for _, SKU := range SKUs {
+		if containsString(r.orders[orderID], SKU) {
+			continue
+		}
+		r.orders[orderID] = append(r.orders[orderID], SKU)
+		counted++
+	}
+
+	return counted, nil
+}
+
+// TestReportPopularityHandler test ReportPopularityHandler
+// authorized by internal service token
+func TestReportPopularityHandler(t *testing.T) {
+	config.InternalServiceToken = "service-secret"
+	defer func() { config.InternalServiceToken = "" }()
+
+	repo := popularityRepository{counters: &[]model.PopularityCounter{}}
+	a := API{Repo: repo, FiberApp: fiber.New()}
+	a.FiberApp.Post("/api/products/popularity/",
+		middleware.ServiceAuthorizationMiddleware(),
+		a.ReportPopularityHandler)
+
+	// create testing table
+	testTable := []struct {
+		TestName           string
+		Token              string
+		Body               string
+		ExpectedStatusCode int
+		ExpectedCounters   []model.PopularityCounter
+	}{
+		{
+			TestName:           "User token not allowed",
+			Token:              "user-token",
+			Body:               `{"counters":[{"sku":"SKU-A","wishlist_adds":1}]}`,
+			ExpectedStatusCode: http.StatusForbidden,
+		},
+		{
+			TestName:           "Counters empty",
+			Token:              "service-secret",
+			Body:               `{"counters":[]}`,
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			TestName: "Report counters",
+			Token:    "service-secret",
+			Body: `{"counters":[{"sku":"SKU-A","wishlist_adds":3},` +
+				`{"sku":"SKU-B","completed_orders":1}]}`,
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedCounters: []model.PopularityCounter{
+				{SKU: "SKU-A", WishlistAdds: 3},
+				{SKU: "SKU-B", CompletedOrders: 1},
+			},
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		*repo.counters = nil
+
+		req, _ := http.NewRequest("POST", "/api/products/popularity/",
+			strings.NewReader(test.Body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+test.Token)
+		response, err := a.FiberApp.Test(req)
+		if err != nil {
+			t.Fatalf("[%s] There's an error serve http testing => %s",
+				test.TestName, err.Error())
+		}
+		response.Body.Close()
+
+		if response.StatusCode != test.ExpectedStatusCode {
+			t.Errorf("[%s] Expected status %d got %d", test.TestName,
+				test.ExpectedStatusCode, response.StatusCode)
+		}
+		if !reflect.DeepEqual(*repo.counters, test.ExpectedCounters) {
+			t.Errorf("[%s] Expected counters %+v, but got %+v", test.TestName,
+				test.ExpectedCounters, *repo.counters)
+		}
+	}
+}
+
+// TestHandleOrderEventCompleted test HandleOrderEvent counting
+// completed order once for each ordered product, even if redelivered
+func TestHandleOrderEventCompleted(t *testing.T) {
+	repo := popularityRepository{orders: map[string][]string{}}
+	a := API{Repo: repo}
+
+	// deliver the same event twice
+	for i := 0; i < 2; i++ {
+		err := a.HandleOrderEvent(event.OrderEvent{
+			Type:    event.OrderCompleted,
+			OrderID: "ORDER-1",
+			Items: []event.OrderItem{
+				{SKU: "SKU-A", Qty: 2}, {SKU: "SKU-B", Qty: 1},
+				{SKU: "SKU-A", Qty: 1},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Expected error nil, but got error => %s", err.Error())
+		}
+	}
+
+	expectedOrders := map[string][]string{"ORDER-1": {"SKU-A", "SKU-B"}}
+	if !reflect.DeepEqual(repo.orders, expectedOrders) {
+		t.Errorf("Expected counted orders %+v, but got %+v", expectedOrders,
+			repo.orders)
+	}
+}
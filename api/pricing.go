@@ -0,0 +1,158 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/event"
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+	"github.com/reyhanfikridz/ecom-product-service/internal/permission"
+	"github.com/reyhanfikridz/ecom-product-service/internal/validator"
+)
+
+// SetPriceTiersHandler handling route replace quantity based price tiers
+// of product by SKU (method: PUT, user: seller owning the product)
+func (a *API) SetPriceTiersHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// check user role is allowed to access this API
+	if !a.isAllowed(u, permission.ProductUpdate) {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this API",
+		})
+	}
+
+	// parse price tiers from JSON body
+	tiers := []model.PriceTier{}
+	err := json.Unmarshal(c.Body(), &tiers)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": "body must be JSON array of {min_qty, price}",
+		})
+	}
+
+	// get product by sku from database
+	SKU := c.Params("sku")
+	p, err := a.Repo.GetProductBySKU(c.UserContext(), SKU)
+	if err == sql.ErrNoRows {
+		return c.Status(http.StatusNotFound).JSON(map[string]string{
+			"message": "product not found",
+		})
+	} else if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	// check product owned by the seller
+	if p.ProductInfo.UserID != u.ID {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this product",
+		})
+	}
+
+	// validate price tiers data
+	err = validator.IsPriceTiersValid(tiers, p.ProductInfo.Unit,
+		p.ProductInfo.Price)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	// replace price tiers in database
+	p, err = a.Repo.SetPriceTiersBySKU(c.UserContext(), SKU, u.ID, tiers)
+	if err == sql.ErrNoRows {
+		return c.Status(http.StatusNotFound).JSON(map[string]string{
+			"message": "product not found",
+		})
+	} else if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	a.PublishEvent(event.NewEvent(event.ProductUpdated, SKU,
+		p.ProductInfo.UserID, p.ProductInfo))
+
+	return c.Status(http.StatusOK).JSON(p.PriceTiers)
+}
+
+// GetPriceQuoteHandler handling route get price of product by SKU
+// for a quantity, applying sale price and price tiers
+// (method: GET, user: all)
+func (a *API) GetPriceQuoteHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// get quantity from url
+	qty, err := strconv.ParseFloat(c.Query("qty"), 64)
+	if err != nil || qty <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": "parameter 'qty' empty/invalid, must be positive number",
+		})
+	}
+
+	// get product by sku from cache or database
+	p, err := a.GetCachedProductBySKU(c.UserContext(), c.Params("sku"))
+	if err == sql.ErrNoRows {
+		return c.Status(http.StatusNotFound).JSON(map[string]string{
+			"message": "product not found",
+		})
+	} else if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": fmt.Sprintf(
+				"There's an error when getting the product data => %s",
+				err.Error()),
+		})
+	}
+
+	// hidden product only can be seen by its seller and roles
+	// allowed to read hidden products
+	if p.ProductInfo.Hidden && u.ID != p.ProductInfo.UserID &&
+		!a.isAllowed(u, permission.ProductReadHidden) {
+		return c.Status(http.StatusNotFound).JSON(map[string]string{
+			"message": "product not found",
+		})
+	}
+
+	// check quantity can be ordered
+	if !model.IsQuantityValid(p.ProductInfo.Unit, qty) {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": fmt.Sprintf("qty must be whole number for unit %s",
+				p.ProductInfo.Unit),
+		})
+	}
+	if !p.ProductInfo.IsOrderQtyAllowed(qty) {
+		message := fmt.Sprintf("qty must be at least %v",
+			p.ProductInfo.MinOrderQty)
+		if qty > p.ProductInfo.MinOrderQty {
+			message = fmt.Sprintf("qty must be at most %v",
+				p.ProductInfo.MaxOrderQty)
+		}
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": message,
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(p.GetPriceQuote(qty, time.Now()))
+}
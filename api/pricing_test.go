@@ -0,0 +1,160 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+)
+
+// priceTierRepository product repository in memory storing price tiers
+type priceTierRepository struct {
+	fakeRepository
+}
+
+// SetPriceTiersBySKU replace price tiers of product in memory
+func (r priceTierRepository) SetPriceTiersBySKU(ctx context.Context,
+	SKU string, userID int, tiers []model.PriceTier) (model.Product, error) {
+	p, ok := r.products[SKU]
+	if !ok || p.ProductInfo.UserID != userID {
+		return p, sql.ErrNoRows
+	}
+
+	p.PriceTiers = tiers
+	r.products[SKU] = p
+	return p, nil
+}
+
+// TestPriceTierHandlers test SetPriceTiersHandler and GetPriceQuoteHandler
+// with product repository in memory
+func TestPriceTierHandlers(t *testing.T) {
+	repo := priceTierRepository{fakeRepository{
+		products: map[string]model.Product{
+			"SKU-A": {ProductInfo: model.ProductInfo{
+				SKU:    "SKU-A",
+				Price:  100000,
+				UserID: 1,
+				Unit:   model.UnitPiece,
+			}},
+		},
+	}}
+	a := API{Repo: repo, FiberApp: fiber.New()}
+	a.FiberApp.Put("/api/product/:sku/price-tiers/",
+		AuthorizationMiddlewareForTest(middleware.User{ID: 1, Role: "seller"}),
+		a.SetPriceTiersHandler)
+	a.FiberApp.Get("/api/product/:sku/quote/",
+		AuthorizationMiddlewareForTest(middleware.User{ID: 2, Role: "buyer"}),
+		a.GetPriceQuoteHandler)
+
+	// set invalid price tiers
+	req, _ := http.NewRequest("PUT", "/api/product/SKU-A/price-tiers/",
+		strings.NewReader(`[{"min_qty": 10, "price": 1200}]`))
+	response, err := a.FiberApp.Test(req)
+	if err != nil {
+		t.Fatalf("There's an error serve http testing => %s", err.Error())
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status %d got %d",
+			http.StatusBadRequest, response.StatusCode)
+	}
+
+	// set price tiers
+	req, _ = http.NewRequest("PUT", "/api/product/SKU-A/price-tiers/",
+		strings.NewReader(`[{"min_qty": 100, "price": 800},
+			{"min_qty": 10, "price": 900}]`))
+	response, err = a.FiberApp.Test(req)
+	if err != nil {
+		t.Fatalf("There's an error serve http testing => %s", err.Error())
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d got %d",
+			http.StatusOK, response.StatusCode)
+	}
+
+	// create testing table
+	testTable := []struct {
+		TestName           string
+		Qty                string
+		ExpectedStatusCode int
+		ExpectedUnitPrice  model.Money
+		ExpectedTotal      model.Money
+	}{
+		{
+			TestName:           "Quote below first tier",
+			Qty:                "9",
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedUnitPrice:  100000,
+			ExpectedTotal:      900000,
+		},
+		{
+			TestName:           "Quote at first tier",
+			Qty:                "10",
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedUnitPrice:  90000,
+			ExpectedTotal:      900000,
+		},
+		{
+			TestName:           "Quote above last tier",
+			Qty:                "150",
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedUnitPrice:  80000,
+			ExpectedTotal:      12000000,
+		},
+		{
+			TestName:           "Quote fractional quantity of piece",
+			Qty:                "1.5",
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			TestName:           "Quote invalid quantity",
+			Qty:                "abc",
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		req, _ := http.NewRequest("GET",
+			"/api/product/SKU-A/quote/?qty="+test.Qty, nil)
+		response, err := a.FiberApp.Test(req)
+		if err != nil {
+			t.Fatalf("[%s] There's an error serve http testing => %s",
+				test.TestName, err.Error())
+		}
+		defer response.Body.Close()
+
+		if response.StatusCode != test.ExpectedStatusCode {
+			t.Errorf("[%s] Expected status %d got %d", test.TestName,
+				test.ExpectedStatusCode, response.StatusCode)
+			continue
+		}
+		if response.StatusCode != http.StatusOK {
+			continue
+		}
+
+		quote := model.PriceQuote{}
+		err = json.NewDecoder(response.Body).Decode(&quote)
+		if err != nil {
+			t.Fatalf("[%s] There's an error when decoding response => %s",
+				test.TestName, err.Error())
+		}
+		if quote.UnitPrice != test.ExpectedUnitPrice ||
+			quote.Total != test.ExpectedTotal {
+			t.Errorf("[%s] Expected unit price %v total %v, but got "+
+				"unit price %v total %v", test.TestName,
+				test.ExpectedUnitPrice, test.ExpectedTotal,
+				quote.UnitPrice, quote.Total)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+	"github.com/reyhanfikridz/ecom-product-service/internal/validator"
+)
+
+// SetProductRatingHandler handling route set rating aggregate of product
+// by SKU, called by review service whenever reviews of the product change
+// (method: PUT, user: internal service)
+func (a *API) SetProductRatingHandler(c *fiber.Ctx) error {
+	// get SKU from url
+	SKU := c.Query("sku")
+	if strings.TrimSpace(SKU) == "" {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": "parameter 'sku' empty/not found",
+		})
+	}
+
+	// get rating from body
+	rating := model.ProductRating{}
+	err := c.BodyParser(&rating)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	// validate rating
+	err = validator.IsProductRatingValid(rating)
+	if err != nil {
+		return sendValidationError(c, err)
+	}
+
+	// set product rating in database
+	err = a.Repo.SetProductRatingBySKU(c.UserContext(), SKU, rating)
+	if err == sql.ErrNoRows {
+		return c.Status(http.StatusNotFound).JSON(map[string]string{
+			"message": "product not found",
+		})
+	} else if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": fmt.Sprintf(
+				"There's an error when setting the product rating => %s",
+				err.Error()),
+		})
+	}
+
+	// invalidate cached product, no event published since the product
+	// itself isn't changed by its reviews
+	if a.Cache != nil {
+		err = a.Cache.Delete(SKU)
+		if err != nil {
+			log.Printf("There's an error when invalidating cached product "+
+				"of SKU %s => %s", SKU, err.Error())
+		}
+	}
+
+	return c.Status(http.StatusOK).JSON(map[string]interface{}{
+		"message": "Set product rating success!",
+		"rating":  rating,
+	})
+}
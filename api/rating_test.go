@@ -0,0 +1,128 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/config"
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+)
+
+// ratingRepository product repository in memory setting product rating
+type ratingRepository struct {
+	fakeRepository
+}
+
+// SetProductRatingBySKU set rating of product in memory
+func (r ratingRepository) SetProductRatingBySKU(ctx context.Context,
+	SKU string, rating model.ProductRating) error {
+	p, ok := r.products[SKU]
+	if !ok {
+		return sql.ErrNoRows
+	}
+
+	p.ProductInfo.AvgRating = rating.AvgRating
+	p.ProductInfo.ReviewCount = rating.ReviewCount
+	r.products[SKU] = p
+	return nil
+}
+
+// TestSetProductRatingHandler test SetProductRatingHandler
+// authorized by internal service token
+func TestSetProductRatingHandler(t *testing.T) {
+	config.InternalServiceToken = "service-secret"
+	defer func() { config.InternalServiceToken = "" }()
+
+	repo := ratingRepository{fakeRepository{
+		products: map[string]model.Product{
+			"SKU-A": {ProductInfo: model.ProductInfo{SKU: "SKU-A"}},
+		},
+	}}
+	a := API{Repo: repo, FiberApp: fiber.New()}
+	a.FiberApp.Put("/api/product/rating/",
+		middleware.ServiceAuthorizationMiddleware(),
+		a.SetProductRatingHandler)
+
+	// create testing table
+	testTable := []struct {
+		TestName            string
+		SKU                 string
+		Token               string
+		Body                string
+		ExpectedStatusCode  int
+		ExpectedAvgRating   float64
+		ExpectedReviewCount int
+	}{
+		{
+			TestName:           "User token not allowed",
+			SKU:                "SKU-A",
+			Token:              "user-token",
+			Body:               `{"avg_rating":4.5,"review_count":2}`,
+			ExpectedStatusCode: http.StatusForbidden,
+		},
+		{
+			TestName:           "Rating too high",
+			SKU:                "SKU-A",
+			Token:              "service-secret",
+			Body:               `{"avg_rating":5.5,"review_count":2}`,
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			TestName:           "Rating without reviews",
+			SKU:                "SKU-A",
+			Token:              "service-secret",
+			Body:               `{"avg_rating":4,"review_count":0}`,
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			TestName:           "Product not found",
+			SKU:                "SKU-X",
+			Token:              "service-secret",
+			Body:               `{"avg_rating":4.5,"review_count":2}`,
+			ExpectedStatusCode: http.StatusNotFound,
+		},
+		{
+			TestName:            "Set rating",
+			SKU:                 "SKU-A",
+			Token:               "service-secret",
+			Body:                `{"avg_rating":4.5,"review_count":2}`,
+			ExpectedStatusCode:  http.StatusOK,
+			ExpectedAvgRating:   4.5,
+			ExpectedReviewCount: 2,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		req, _ := http.NewRequest("PUT", "/api/product/rating/?sku="+test.SKU,
+			strings.NewReader(test.Body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+test.Token)
+		response, err := a.FiberApp.Test(req)
+		if err != nil {
+			t.Fatalf("[%s] There's an error serve http testing => %s",
+				test.TestName, err.Error())
+		}
+		response.Body.Close()
+
+		if response.StatusCode != test.ExpectedStatusCode {
+			t.Errorf("[%s] Expected status %d got %d", test.TestName,
+				test.ExpectedStatusCode, response.StatusCode)
+		}
+		pInfo := repo.products["SKU-A"].ProductInfo
+		if pInfo.AvgRating != test.ExpectedAvgRating ||
+			pInfo.ReviewCount != test.ExpectedReviewCount {
+			t.Errorf("[%s] Expected rating %v of %d reviews, but got %v of %d",
+				test.TestName, test.ExpectedAvgRating, test.ExpectedReviewCount,
+				pInfo.AvgRating, pInfo.ReviewCount)
+		}
+	}
+}
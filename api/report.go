@@ -0,0 +1,192 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/event"
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+	"github.com/reyhanfikridz/ecom-product-service/internal/permission"
+	"github.com/reyhanfikridz/ecom-product-service/internal/validator"
+)
+
+// ReportProductHandler handling route report published product by SKU
+// with JSON body of {reason, note} (method: POST, user: buyer)
+func (a *API) ReportProductHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// check user role is allowed to access this API
+	if !a.isAllowed(u, permission.ProductReport) {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this API",
+		})
+	}
+
+	// get SKU
+	SKU := c.Query("sku")
+	if SKU == "" {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": "parameter 'sku' empty/not found",
+		})
+	}
+
+	// parse report from JSON body
+	report := model.ProductReport{}
+	err := json.Unmarshal(c.Body(), &report)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": "body must be JSON object of {reason, note}",
+		})
+	}
+	report = model.ProductReport{
+		SKU:            SKU,
+		Reason:         report.Reason,
+		Note:           report.Note,
+		ReporterUserID: u.ID,
+	}
+
+	// validate report
+	err = validator.IsProductReportValid(report)
+	if err != nil {
+		return sendValidationError(c, err)
+	}
+
+	// insert report into database
+	report, err = a.Repo.InsertProductReport(c.UserContext(), report)
+	if err == sql.ErrNoRows {
+		return c.Status(http.StatusNotFound).JSON(map[string]string{
+			"message": "product not found",
+		})
+	} else if errors.Is(err, model.ErrReportDuplicate) {
+		return c.Status(http.StatusConflict).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	} else if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": fmt.Sprintf("There's an error when saving "+
+				"the report => %s", err.Error()),
+		})
+	}
+
+	return c.Status(http.StatusCreated).JSON(report)
+}
+
+// GetProductReportsHandler handling route get product reports of
+// url query 'status' (default: open, 'all' for every status) oldest first
+// (method: GET, user: admin)
+func (a *API) GetProductReportsHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// check user role is allowed to access this API
+	if !a.isAllowed(u, permission.ReportReview) {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this API",
+		})
+	}
+
+	// get status
+	status := c.Query("status", model.ReportStatusOpen)
+	switch status {
+	case model.ReportStatusOpen, model.ReportStatusDismissed,
+		model.ReportStatusTakenDown:
+	case "all":
+		status = ""
+	default:
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": "parameter 'status' invalid, must be open, dismissed, " +
+				"taken_down, or all",
+		})
+	}
+
+	// get reports from database
+	reports, err := a.Repo.GetProductReports(c.UserContext(), status)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(reports)
+}
+
+// ReviewProductReportsHandler handling route review open reports of
+// product by SKU with url query 'action' dismiss or take_down, taking
+// the product down hides it and its seller can't publish it again
+// (method: PUT, user: admin)
+func (a *API) ReviewProductReportsHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// check user role is allowed to access this API
+	if !a.isAllowed(u, permission.ReportReview) {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this API",
+		})
+	}
+
+	// get SKU and action
+	SKU := c.Query("sku")
+	if SKU == "" {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": "parameter 'sku' empty/not found",
+		})
+	}
+	action := c.Query("action")
+	if action != model.ReportActionDismiss &&
+		action != model.ReportActionTakeDown {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": "parameter 'action' empty/invalid, must be dismiss " +
+				"or take_down",
+		})
+	}
+
+	// resolve open reports of the product in database
+	pInfo, resolved, err := a.Repo.ReviewProductReportsBySKU(c.UserContext(),
+		SKU, action, u.ID)
+	if err == sql.ErrNoRows {
+		return c.Status(http.StatusNotFound).JSON(map[string]string{
+			"message": "open reports of the product not found",
+		})
+	} else if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	if action == model.ReportActionTakeDown {
+		log.Printf("Product %s taken down by user ID %d", SKU, u.ID)
+		a.PublishEvent(event.NewEvent(event.ProductUpdated, SKU, pInfo.UserID,
+			pInfo))
+	}
+
+	return c.Status(http.StatusOK).JSON(map[string]interface{}{
+		"product":  pInfo,
+		"resolved": resolved,
+	})
+}
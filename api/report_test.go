@@ -0,0 +1,212 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+)
+
+// reportRepository product repository in memory storing reports
+// of published products
+type reportRepository struct {
+	model.ProductRepository
+	published map[string]bool
+	reports   *[]model.ProductReport
+}
+
+// InsertProductReport insert report of published product in memory,
+// open report of the same reporter can't be duplicated
+func (r reportRepository) InsertProductReport(ctx context.Context,
+	report model.ProductReport) (model.ProductReport, error) {
+	if !r.published[report.SKU] {
+		return model.ProductReport{}, sql.ErrNoRows
+	}
+	for _, other := range *r.reports {
+		if other.SKU == report.SKU && other.ReporterUserID ==
+			report.ReporterUserID && other.Status == model.ReportStatusOpen {
+			return model.ProductReport{}, model.ErrReportDuplicate
+		}
+	}
+
+	report.ID = len(*r.reports) + 1
+	report.Status = model.ReportStatusOpen
+	*r.reports = append(*r.reports, report)
+	return report, nil
+}
+
+// GetProductReports get reports of status from memory
+func (r reportRepository) GetProductReports(ctx context.Context,
+	status string) ([]model.ProductReport, error) {
+	reports := []model.ProductReport{}
+	for _, report := range *r.reports {
+		if status == "" || report.Status == status {
+			reports = append(reports, report)
+		}
+	}
+
+	return reports, nil
+}
+
+// ReviewProductReportsBySKU resolve open reports of product in memory
+func (r reportRepository) ReviewProductReportsBySKU(ctx context.Context,
+	SKU string, action string, reviewerUserID int) (model.ProductInfo, int,
+	error) {
+	status := model.ReportStatusDismissed
+	if action == model.ReportActionTakeDown {
+		status = model.ReportStatusTakenDown
+	}
+
+	resolved := 0
+	for i, report := range *r.reports {
+		if report.SKU == SKU && report.Status == model.ReportStatusOpen {
+			(*r.reports)[i].Status = status
+			(*r.reports)[i].ReviewerUserID = reviewerUserID
+			resolved++
+		}
+	}
+	if resolved == 0 {
+		return model.ProductInfo{}, 0, sql.ErrNoRows
+	}
+
+	return model.ProductInfo{SKU: SKU, UserID: 2,
+		Hidden: action == model.ReportActionTakeDown}, resolved, nil
+}
+
+// TestProductReportHandlers test ReportProductHandler,
+// GetProductReportsHandler, and ReviewProductReportsHandler
+func TestProductReportHandlers(t *testing.T) {
+	repo := reportRepository{
+		published: map[string]bool{"SKU-A": true},
+		reports:   &[]model.ProductReport{},
+	}
+	a := API{Repo: repo, FiberApp: fiber.New()}
+	a.FiberApp.Post("/api/product/report/",
+		AuthorizationMiddlewareForTest(middleware.User{ID: 1, Role: "buyer"}),
+		a.ReportProductHandler)
+	a.FiberApp.Post("/api/seller/product/report/",
+		AuthorizationMiddlewareForTest(middleware.User{ID: 2, Role: "seller"}),
+		a.ReportProductHandler)
+	a.FiberApp.Get("/api/admin/reports/",
+		AuthorizationMiddlewareForTest(middleware.User{ID: 3, Role: "admin"}),
+		a.GetProductReportsHandler)
+	a.FiberApp.Put("/api/admin/reports/review/",
+		AuthorizationMiddlewareForTest(middleware.User{ID: 3, Role: "admin"}),
+		a.ReviewProductReportsHandler)
+
+	// create testing table of reporting product
+	testTable := []struct {
+		TestName           string
+		Path               string
+		Body               string
+		ExpectedStatusCode int
+	}{
+		{"Valid", "/api/product/report/?sku=SKU-A",
+			`{"reason":"counterfeit","note":"fake brand"}`, http.StatusCreated},
+		{"Duplicate", "/api/product/report/?sku=SKU-A",
+			`{"reason":"prohibited"}`, http.StatusConflict},
+		{"Reason Invalid", "/api/product/report/?sku=SKU-A",
+			`{"reason":"ugly"}`, http.StatusBadRequest},
+		{"Body Invalid", "/api/product/report/?sku=SKU-A", `reason`,
+			http.StatusBadRequest},
+		{"SKU Empty", "/api/product/report/", `{"reason":"other"}`,
+			http.StatusBadRequest},
+		{"Product Not Published", "/api/product/report/?sku=SKU-B",
+			`{"reason":"other"}`, http.StatusNotFound},
+		{"Seller", "/api/seller/product/report/?sku=SKU-A",
+			`{"reason":"other"}`, http.StatusForbidden},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		req, _ := http.NewRequest("POST", test.Path,
+			bytes.NewBufferString(test.Body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := a.FiberApp.Test(req)
+		if err != nil {
+			t.Fatalf("[%s] There's an error when testing request => %s",
+				test.TestName, err.Error())
+		}
+		if resp.StatusCode != test.ExpectedStatusCode {
+			t.Errorf("[%s] Expected status code %d, but got %d",
+				test.TestName, test.ExpectedStatusCode, resp.StatusCode)
+		}
+	}
+
+	// get open reports in review queue
+	req, _ := http.NewRequest("GET", "/api/admin/reports/", nil)
+	resp, err := a.FiberApp.Test(req)
+	if err != nil {
+		t.Fatalf("There's an error when testing request => %s", err.Error())
+	}
+	reports := []model.ProductReport{}
+	err = json.NewDecoder(resp.Body).Decode(&reports)
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+	if resp.StatusCode != http.StatusOK || len(reports) != 1 ||
+		reports[0].ReporterUserID != 1 || reports[0].Note != "fake brand" {
+		t.Errorf("Expected 1 open report of user ID 1, but got %d %+v",
+			resp.StatusCode, reports)
+	}
+
+	// create testing table of reviewing reports
+	reviewTestTable := []struct {
+		TestName           string
+		Query              string
+		ExpectedStatusCode int
+	}{
+		{"Action Invalid", "?sku=SKU-A&action=delete", http.StatusBadRequest},
+		{"SKU Empty", "?action=dismiss", http.StatusBadRequest},
+		{"Take Down", "?sku=SKU-A&action=take_down", http.StatusOK},
+		{"No Open Reports", "?sku=SKU-A&action=dismiss", http.StatusNotFound},
+	}
+
+	// loop test in test table
+	for _, test := range reviewTestTable {
+		req, _ := http.NewRequest("PUT", "/api/admin/reports/review/"+
+			test.Query, nil)
+		resp, err := a.FiberApp.Test(req)
+		if err != nil {
+			t.Fatalf("[%s] There's an error when testing request => %s",
+				test.TestName, err.Error())
+		}
+		if resp.StatusCode != test.ExpectedStatusCode {
+			t.Errorf("[%s] Expected status code %d, but got %d",
+				test.TestName, test.ExpectedStatusCode, resp.StatusCode)
+		}
+	}
+	if (*repo.reports)[0].Status != model.ReportStatusTakenDown ||
+		(*repo.reports)[0].ReviewerUserID != 3 {
+		t.Errorf("Expected report taken down by user ID 3, but got %+v",
+			(*repo.reports)[0])
+	}
+
+	// review queue empty, but report still listed in every status
+	for path, expectedCount := range map[string]int{
+		"/api/admin/reports/":            0,
+		"/api/admin/reports/?status=all": 1,
+	} {
+		req, _ = http.NewRequest("GET", path, nil)
+		resp, err = a.FiberApp.Test(req)
+		if err != nil {
+			t.Fatalf("There's an error when testing request => %s",
+				err.Error())
+		}
+		reports = []model.ProductReport{}
+		json.NewDecoder(resp.Body).Decode(&reports)
+		if len(reports) != expectedCount {
+			t.Errorf("Expected %d reports of %s, but got %d", expectedCount,
+				path, len(reports))
+		}
+	}
+}
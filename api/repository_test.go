@@ -0,0 +1,821 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+	"github.com/reyhanfikridz/ecom-product-service/internal/permission"
+)
+
+// fakeRepository product repository in memory for testing handlers
+// without database, not implemented methods panic
+type fakeRepository struct {
+	model.ProductRepository
+	products map[string]model.Product
+}
+
+// GetProductBySKU get product from memory
+func (r fakeRepository) GetProductBySKU(ctx context.Context,
+	SKU string) (model.Product, error) {
+	p, ok := r.products[SKU]
+	if !ok {
+		return p, sql.ErrNoRows
+	}
+
+	return p, nil
+}
+
+// GetProducts get products with the query barcode if any from memory
+func (r fakeRepository) GetProducts(ctx context.Context,
+	query model.ProductQuery) ([]model.Product, error) {
+	products := []model.Product{}
+	for _, p := range r.products {
+		if (query.Barcode != "" && p.ProductInfo.Barcode != query.Barcode) ||
+			(query.ExcludeHidden && p.ProductInfo.Hidden) {
+			continue
+		}
+		products = append(products, p)
+	}
+
+	return products, nil
+}
+
+// GetProductImageAccess get owner and publication of product of image
+// path from memory
+func (r fakeRepository) GetProductImageAccess(ctx context.Context,
+	imagePath string) (model.ProductImageAccess, error) {
+	for _, p := range r.products {
+		for _, pImage := range p.ProductImages {
+			if pImage.ImagePath == imagePath {
+				return model.ProductImageAccess{
+					UserID: p.ProductInfo.UserID,
+					Published: !p.ProductInfo.Hidden &&
+						p.ProductInfo.DeletedAt == nil,
+				}, nil
+			}
+		}
+	}
+
+	return model.ProductImageAccess{}, sql.ErrNoRows
+}
+
+// TestGetProductHandlerWithFakeRepository test GetProductHandler
+// with product repository in memory
+func TestGetProductHandlerWithFakeRepository(t *testing.T) {
+	a := API{
+		Repo: fakeRepository{products: map[string]model.Product{
+			"SKU-A": {ProductInfo: model.ProductInfo{
+				SKU:       "SKU-A",
+				Name:      "PRODUCT A",
+				Version:   1,
+				UpdatedAt: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+			}},
+		}},
+		FiberApp: fiber.New(),
+	}
+	a.FiberApp.Get("/api/product/",
+		AuthorizationMiddlewareForTest(middleware.User{ID: 1, Role: "buyer"}),
+		a.GetProductHandler)
+
+	// get product
+	req, _ := http.NewRequest("GET", "/api/product/?sku=SKU-A&testing=1", nil)
+	response, err := a.FiberApp.Test(req)
+	if err != nil {
+		t.Fatalf("There's an error serve http testing => %s", err.Error())
+	}
+	defer response.Body.Close()
+
+	etag := response.Header.Get(fiber.HeaderETag)
+	if response.StatusCode != http.StatusOK || etag == "" {
+		t.Fatalf("Expected status %d with ETag, but got %d with ETag '%s'",
+			http.StatusOK, response.StatusCode, etag)
+	}
+
+	// get product again with the ETag
+	req, _ = http.NewRequest("GET", "/api/product/?sku=SKU-A&testing=1", nil)
+	req.Header.Set(fiber.HeaderIfNoneMatch, etag)
+	response, err = a.FiberApp.Test(req)
+	if err != nil {
+		t.Fatalf("There's an error serve http testing => %s", err.Error())
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusNotModified {
+		t.Errorf("Expected status %d got %d",
+			http.StatusNotModified, response.StatusCode)
+	}
+}
+
+// TestGetProductHandlerHiddenProduct test GetProductHandler
+// hiding hidden product from other than its owner or admin
+func TestGetProductHandlerHiddenProduct(t *testing.T) {
+	repo := fakeRepository{products: map[string]model.Product{
+		"SKU-H": {ProductInfo: model.ProductInfo{
+			SKU:    "SKU-H",
+			Name:   "HIDDEN PRODUCT",
+			UserID: 1,
+			Hidden: true,
+		}},
+	}}
+
+	// create testing table
+	testTable := []struct {
+		TestName           string
+		User               middleware.User
+		ExpectedStatusCode int
+	}{
+		{
+			TestName:           "Get by buyer",
+			User:               middleware.User{ID: 2, Role: "buyer"},
+			ExpectedStatusCode: http.StatusNotFound,
+		},
+		{
+			TestName:           "Get by other seller",
+			User:               middleware.User{ID: 3, Role: "seller"},
+			ExpectedStatusCode: http.StatusNotFound,
+		},
+		{
+			TestName:           "Get by owner",
+			User:               middleware.User{ID: 1, Role: "seller"},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			TestName:           "Get by admin",
+			User:               middleware.User{ID: 4, Role: "admin"},
+			ExpectedStatusCode: http.StatusOK,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		a := API{Repo: repo, FiberApp: fiber.New()}
+		a.FiberApp.Get("/api/product/",
+			AuthorizationMiddlewareForTest(test.User), a.GetProductHandler)
+
+		req, _ := http.NewRequest("GET", "/api/product/?sku=SKU-H&testing=1",
+			nil)
+		response, err := a.FiberApp.Test(req)
+		if err != nil {
+			t.Fatalf("[%s] There's an error serve http testing => %s",
+				test.TestName, err.Error())
+		}
+		response.Body.Close()
+
+		if response.StatusCode != test.ExpectedStatusCode {
+			t.Errorf("[%s] Expected status %d got %d", test.TestName,
+				test.ExpectedStatusCode, response.StatusCode)
+		}
+	}
+}
+
+// TestHeadProductHandler test HeadProductHandler replying status
+// of product existence without body, routed before GetProductHandler
+func TestHeadProductHandler(t *testing.T) {
+	repo := fakeRepository{products: map[string]model.Product{
+		"SKU-A": {ProductInfo: model.ProductInfo{SKU: "SKU-A", UserID: 1}},
+		"SKU-H": {ProductInfo: model.ProductInfo{SKU: "SKU-H", UserID: 1,
+			Hidden: true}},
+	}}
+
+	// create testing table
+	testTable := []struct {
+		TestName           string
+		SKU                string
+		User               middleware.User
+		ExpectedStatusCode int
+	}{
+		{"Exists", "SKU-A", middleware.User{ID: 2, Role: "buyer"},
+			http.StatusOK},
+		{"Not Found", "SKU-B", middleware.User{ID: 2, Role: "buyer"},
+			http.StatusNotFound},
+		{"Hidden by buyer", "SKU-H", middleware.User{ID: 2, Role: "buyer"},
+			http.StatusNotFound},
+		{"Hidden by owner", "SKU-H", middleware.User{ID: 1, Role: "seller"},
+			http.StatusOK},
+		{"SKU Empty", "", middleware.User{ID: 2, Role: "buyer"},
+			http.StatusBadRequest},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		a := API{Repo: repo, FiberApp: fiber.New()}
+		a.FiberApp.Head("/api/product/",
+			AuthorizationMiddlewareForTest(test.User), a.HeadProductHandler)
+		a.FiberApp.Get("/api/product/",
+			AuthorizationMiddlewareForTest(test.User), a.GetProductHandler)
+
+		// seller info isn't requested from account service,
+		// so no 'testing' parameter needed
+		req, _ := http.NewRequest("HEAD", "/api/product/?sku="+test.SKU, nil)
+		response, err := a.FiberApp.Test(req)
+		if err != nil {
+			t.Fatalf("[%s] There's an error serve http testing => %s",
+				test.TestName, err.Error())
+		}
+		body, _ := io.ReadAll(response.Body)
+		response.Body.Close()
+
+		if response.StatusCode != test.ExpectedStatusCode || len(body) != 0 {
+			t.Errorf("[%s] Expected status %d without body, but got %d "+
+				"with body '%s'", test.TestName, test.ExpectedStatusCode,
+				response.StatusCode, string(body))
+		}
+	}
+}
+
+// TestGetProductsByBarcodeHandler test GetProductsByBarcodeHandler
+// with product repository in memory
+func TestGetProductsByBarcodeHandler(t *testing.T) {
+	repo := fakeRepository{products: map[string]model.Product{
+		"SKU-A": {ProductInfo: model.ProductInfo{
+			SKU:     "SKU-A",
+			Barcode: "4006381333931",
+		}},
+		"SKU-H": {ProductInfo: model.ProductInfo{
+			SKU:     "SKU-H",
+			Barcode: "96385074",
+			Hidden:  true,
+		}},
+	}}
+
+	// create testing table
+	testTable := []struct {
+		TestName           string
+		Code               string
+		User               middleware.User
+		ExpectedStatusCode int
+	}{
+		{
+			TestName:           "Get by valid barcode",
+			Code:               "4006381333931",
+			User:               middleware.User{ID: 2, Role: "buyer"},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			TestName:           "Get by invalid barcode",
+			Code:               "4006381333932",
+			User:               middleware.User{ID: 2, Role: "buyer"},
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			TestName:           "Get by unknown barcode",
+			Code:               "036000291452",
+			User:               middleware.User{ID: 2, Role: "buyer"},
+			ExpectedStatusCode: http.StatusNotFound,
+		},
+		{
+			TestName:           "Get hidden product by buyer",
+			Code:               "96385074",
+			User:               middleware.User{ID: 2, Role: "buyer"},
+			ExpectedStatusCode: http.StatusNotFound,
+		},
+		{
+			TestName:           "Get hidden product by admin",
+			Code:               "96385074",
+			User:               middleware.User{ID: 4, Role: "admin"},
+			ExpectedStatusCode: http.StatusOK,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		a := API{Repo: repo, FiberApp: fiber.New()}
+		a.FiberApp.Get("/api/product/barcode/:code/",
+			AuthorizationMiddlewareForTest(test.User),
+			a.GetProductsByBarcodeHandler)
+
+		req, _ := http.NewRequest("GET",
+			"/api/product/barcode/"+test.Code+"/", nil)
+		response, err := a.FiberApp.Test(req)
+		if err != nil {
+			t.Fatalf("[%s] There's an error serve http testing => %s",
+				test.TestName, err.Error())
+		}
+		response.Body.Close()
+
+		if response.StatusCode != test.ExpectedStatusCode {
+			t.Errorf("[%s] Expected status %d got %d", test.TestName,
+				test.ExpectedStatusCode, response.StatusCode)
+		}
+	}
+}
+
+// TestGetProductsHandlerSellerFilter test GetProductsHandler filtering
+// products by seller for buyers
+func TestGetProductsHandlerSellerFilter(t *testing.T) {
+	repo := queryRecordingRepository{query: &model.ProductQuery{}}
+	a := API{Repo: repo, FiberApp: fiber.New()}
+	a.FiberApp.Get("/api/products/",
+		AuthorizationMiddlewareForTest(middleware.User{ID: 2, Role: "buyer"}),
+		a.GetProductsHandler)
+
+	// create testing table
+	testTable := []struct {
+		TestName           string
+		Query              string
+		ExpectedStatusCode int
+		ExpectedUserID     int
+	}{
+		{
+			TestName:           "All sellers",
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			TestName:           "Products of a seller",
+			Query:              "seller_id=5",
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedUserID:     5,
+		},
+		{
+			TestName:           "Seller ID invalid",
+			Query:              "seller_id=-1",
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		*repo.query = model.ProductQuery{}
+
+		req, _ := http.NewRequest("GET", "/api/products/?"+test.Query, nil)
+		response, err := a.FiberApp.Test(req)
+		if err != nil {
+			t.Fatalf("[%s] There's an error serve http testing => %s",
+				test.TestName, err.Error())
+		}
+		response.Body.Close()
+
+		if response.StatusCode != test.ExpectedStatusCode {
+			t.Errorf("[%s] Expected status %d got %d", test.TestName,
+				test.ExpectedStatusCode, response.StatusCode)
+			continue
+		}
+		if response.StatusCode == http.StatusOK &&
+			(repo.query.UserID != test.ExpectedUserID ||
+				!repo.query.ExcludeHidden) {
+			t.Errorf("[%s] Expected visible products of user %d, but got "+
+				"query %+v", test.TestName, test.ExpectedUserID, *repo.query)
+		}
+	}
+}
+
+// TestGetProductsByUserIDHandlerFilters test GetProductsByUserIDHandler
+// parsing stock and creation date filters into product query
+func TestGetProductsByUserIDHandlerFilters(t *testing.T) {
+	repo := queryRecordingRepository{query: &model.ProductQuery{}}
+	a := API{Repo: repo, FiberApp: fiber.New()}
+	a.FiberApp.Get("/api/products/user/",
+		AuthorizationMiddlewareForTest(middleware.User{ID: 3, Role: "seller"}),
+		a.GetProductsByUserIDHandler)
+
+	createdAfter := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	// create testing table
+	testTable := []struct {
+		TestName           string
+		Query              string
+		ExpectedStatusCode int
+		ExpectedQuery      model.ProductQuery
+	}{
+		{
+			TestName:           "No stock filters",
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedQuery:      model.ProductQuery{UserID: 3},
+		},
+		{
+			TestName:           "In stock and minimum stock",
+			Query:              "in_stock=true&min_stock=2.5",
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedQuery: model.ProductQuery{
+				UserID:   3,
+				InStock:  true,
+				MinStock: 2.5,
+			},
+		},
+		{
+			TestName:           "In stock invalid",
+			Query:              "in_stock=yes",
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			TestName:           "Minimum stock negative",
+			Query:              "min_stock=-1",
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			TestName:           "Created after",
+			Query:              "created_after=2022-03-01T00:00:00Z",
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedQuery: model.ProductQuery{
+				UserID:      3,
+				CreatedFrom: &createdAfter,
+			},
+		},
+		{
+			TestName:           "Created before invalid",
+			Query:              "created_before=yesterday",
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			TestName:           "Minimum stock not a number",
+			Query:              "min_stock=NaN",
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		*repo.query = model.ProductQuery{}
+
+		req, _ := http.NewRequest("GET", "/api/products/user/?"+test.Query,
+			nil)
+		response, err := a.FiberApp.Test(req)
+		if err != nil {
+			t.Fatalf("[%s] There's an error serve http testing => %s",
+				test.TestName, err.Error())
+		}
+		response.Body.Close()
+
+		if response.StatusCode != test.ExpectedStatusCode {
+			t.Errorf("[%s] Expected status %d got %d", test.TestName,
+				test.ExpectedStatusCode, response.StatusCode)
+			continue
+		}
+		if response.StatusCode == http.StatusOK &&
+			!reflect.DeepEqual(*repo.query, test.ExpectedQuery) {
+			t.Errorf("[%s] Expected query %+v, but got %+v", test.TestName,
+				test.ExpectedQuery, *repo.query)
+		}
+	}
+}
+
+// snapshotRepository product repository in memory getting snapshots
+// of products by SKU, recording the requested SKUs
+type snapshotRepository struct {
+	model.ProductRepository
+	snapshots map[string]model.ProductSnapshot
+	SKUs      []string
+}
+
+// GetProductSnapshots get snapshots of products found in memory
+func (r *snapshotRepository) GetProductSnapshots(ctx context.Context,
+	SKUs []string) ([]model.ProductSnapshot, error) {
+	r.SKUs = SKUs
+
+	snapshots := []model.ProductSnapshot{}
+	for _, SKU := range SKUs {
+		if snapshot, ok := r.snapshots[SKU]; ok {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+
+	return snapshots, nil
+}
+
+// TestGetProductSnapshotsHandler test GetProductSnapshotsHandler
+// parsing url parameter 'skus'
+func TestGetProductSnapshotsHandler(t *testing.T) {
+	manySKUs := strings.Repeat("SKU,", maxSnapshotSKUs) + "SKU"
+
+	// create testing table
+	testTable := []struct {
+		TestName       string
+		RawSKUs        string
+		ExpectedStatus int
+		ExpectedSKUs   []string
+		ExpectedCount  int
+	}{
+		{"Found", "SKU-B,SKU-A", http.StatusOK, []string{"SKU-B", "SKU-A"},
+			2},
+		{"Spaces And Not Found", " SKU-A , ,SKU-C", http.StatusOK,
+			[]string{"SKU-A", "SKU-C"}, 1},
+		{"Empty", " , ", http.StatusBadRequest, nil, 0},
+		{"Too Many", manySKUs, http.StatusBadRequest, nil, 0},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		repo := &snapshotRepository{snapshots: map[string]model.ProductSnapshot{
+			"SKU-A": {SKU: "SKU-A", EffectivePrice: 1000, Stock: 5},
+			"SKU-B": {SKU: "SKU-B", EffectivePrice: 800, Stock: 0},
+		}}
+		a := API{Repo: repo, FiberApp: fiber.New()}
+		a.FiberApp.Get("/api/products/snapshot/",
+			AuthorizationMiddlewareForTest(middleware.User{ID: 1,
+				Role: "buyer"}),
+			a.GetProductSnapshotsHandler)
+
+		req, _ := http.NewRequest("GET", "/api/products/snapshot/?skus="+
+			url.QueryEscape(test.RawSKUs), nil)
+		response, err := a.FiberApp.Test(req)
+		if err != nil {
+			t.Fatalf("[%s] There's an error serve http testing => %s",
+				test.TestName, err.Error())
+		}
+		snapshots := []model.ProductSnapshot{}
+		json.NewDecoder(response.Body).Decode(&snapshots)
+		response.Body.Close()
+
+		if response.StatusCode != test.ExpectedStatus ||
+			!reflect.DeepEqual(repo.SKUs, test.ExpectedSKUs) {
+			t.Errorf("[%s] Expected status %d getting %v, "+
+				"but got %d getting %v", test.TestName, test.ExpectedStatus,
+				test.ExpectedSKUs, response.StatusCode, repo.SKUs)
+		} else if response.StatusCode == http.StatusOK &&
+			len(snapshots) != test.ExpectedCount {
+			t.Errorf("[%s] Expected %d snapshots, but got %+v",
+				test.TestName, test.ExpectedCount, snapshots)
+		}
+	}
+}
+
+// stockRepository product repository in memory decreasing stock
+// of products by SKU, recording the audit of the last decrease
+type stockRepository struct {
+	model.ProductRepository
+	stocks map[string]float64
+	audit  model.StockMovement
+}
+
+// DecreaseStocks decrease stocks in memory, none if any product
+// not found or its stock is not enough
+func (r *stockRepository) DecreaseStocks(ctx context.Context,
+	decreases []model.StockDecrease, audit model.StockMovement) (
+	[]model.StockDecreaseResult, error) {
+	r.audit = audit
+
+	results := []model.StockDecreaseResult{}
+	failed := false
+	for _, decrease := range decreases {
+		result := model.StockDecreaseResult{SKU: decrease.SKU,
+			Qty: decrease.Qty}
+		stock, ok := r.stocks[decrease.SKU]
+		if !ok {
+			result.Error = "product not found"
+			failed = true
+		} else if stock < decrease.Qty {
+			result.Error = "product stock insufficient"
+			failed = true
+		}
+		result.Stock = stock - decrease.Qty
+		results = append(results, result)
+	}
+	if failed {
+		return results, model.ErrBatchItemInvalid
+	}
+
+	for _, result := range results {
+		r.stocks[result.SKU] = result.Stock
+	}
+
+	return results, nil
+}
+
+// TestBatchDecreaseStockHandler test BatchDecreaseStockHandler
+func TestBatchDecreaseStockHandler(t *testing.T) {
+	// create testing table
+	testTable := []struct {
+		TestName       string
+		User           middleware.User
+		Permissions    permission.Matrix
+		Body           string
+		ExpectedStatus int
+		ExpectedStocks map[string]float64
+	}{
+		{"Success", middleware.User{ID: 1, Role: "seller"}, nil,
+			`{"order_id":"order-1","items":[{"sku":"SKU-A","qty":2},` +
+				`{"sku":"SKU-B","qty":1}]}`,
+			http.StatusOK, map[string]float64{"SKU-A": 3, "SKU-B": 0}},
+		{"Insufficient Stock", middleware.User{ID: 1, Role: "seller"}, nil,
+			`{"items":[{"sku":"SKU-A","qty":2},{"sku":"SKU-B","qty":2}]}`,
+			http.StatusUnprocessableEntity,
+			map[string]float64{"SKU-A": 5, "SKU-B": 1}},
+		{"Qty Invalid", middleware.User{ID: 1, Role: "seller"}, nil,
+			`{"items":[{"sku":"SKU-A","qty":0}]}`, http.StatusBadRequest,
+			map[string]float64{"SKU-A": 5, "SKU-B": 1}},
+		{"Body Invalid", middleware.User{ID: 1, Role: "seller"}, nil,
+			`[{"sku":"SKU-A","qty":1}]`, http.StatusBadRequest,
+			map[string]float64{"SKU-A": 5, "SKU-B": 1}},
+		{"Service", middleware.User{Role: middleware.RoleService}, nil,
+			`{"order_id":"order-1","items":[{"sku":"SKU-A","qty":5}]}`,
+			http.StatusOK, map[string]float64{"SKU-A": 0, "SKU-B": 1}},
+		{"Buyer And Seller", middleware.User{ID: 1, Role: "buyer",
+			Roles: []string{"buyer", "seller"}}, nil,
+			`{"order_id":"order-1","items":[{"sku":"SKU-B","qty":1}]}`,
+			http.StatusOK, map[string]float64{"SKU-A": 5, "SKU-B": 0}},
+		{"Seller Not Allowed", middleware.User{ID: 1, Role: "seller"},
+			permission.Matrix{permission.StockDecrease: {"service"}},
+			`{"items":[{"sku":"SKU-A","qty":1}]}`, http.StatusForbidden,
+			map[string]float64{"SKU-A": 5, "SKU-B": 1}},
+		{"Buyer", middleware.User{ID: 1, Role: "buyer"}, nil,
+			`{"items":[{"sku":"SKU-A","qty":1}]}`, http.StatusForbidden,
+			map[string]float64{"SKU-A": 5, "SKU-B": 1}},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		repo := &stockRepository{stocks: map[string]float64{
+			"SKU-A": 5,
+			"SKU-B": 1,
+		}}
+		a := API{Repo: repo, FiberApp: fiber.New(),
+			Permissions: test.Permissions}
+		a.FiberApp.Put("/api/products/decrease/stock/",
+			AuthorizationMiddlewareForTest(test.User),
+			a.BatchDecreaseStockHandler)
+
+		req, _ := http.NewRequest("PUT", "/api/products/decrease/stock/",
+			strings.NewReader(test.Body))
+		req.Header.Set("Content-Type", "application/json")
+		response, err := a.FiberApp.Test(req)
+		if err != nil {
+			t.Fatalf("[%s] There's an error serve http testing => %s",
+				test.TestName, err.Error())
+		}
+		response.Body.Close()
+
+		if response.StatusCode != test.ExpectedStatus ||
+			!reflect.DeepEqual(repo.stocks, test.ExpectedStocks) {
+			t.Errorf("[%s] Expected status %d with stocks %v, "+
+				"but got %d with %v", test.TestName, test.ExpectedStatus,
+				test.ExpectedStocks, response.StatusCode, repo.stocks)
+		}
+		if test.ExpectedStatus == http.StatusOK &&
+			(repo.audit.OrderID != "order-1" ||
+				repo.audit.UserID != test.User.ID) {
+			t.Errorf("[%s] Expected decrease of order-1 by user %d, "+
+				"but got %+v", test.TestName, test.User.ID, repo.audit)
+		}
+	}
+}
+
+// imageModeRepository product repository in memory recording image mode
+// and kept image IDs of updated product
+type imageModeRepository struct {
+	model.ProductRepository
+	imageMode    string
+	keepImageIDs []int
+}
+
+// UpdateProductWithImages record image mode and kept image IDs
+// of updated product
+func (r *imageModeRepository) UpdateProductWithImages(ctx context.Context,
+	pInfo model.ProductInfo, images []model.ProductImage,
+	imageMode string, keepImageIDs []int) (model.ProductInfo, error) {
+	r.imageMode = imageMode
+	r.keepImageIDs = keepImageIDs
+	return pInfo, nil
+}
+
+// TestUpdateProductHandlerImageMode test UpdateProductHandler
+// validating form values 'image_mode' and 'keep_image_ids' and passing
+// them to repository
+func TestUpdateProductHandlerImageMode(t *testing.T) {
+	// create testing table
+	testTable := []struct {
+		TestName             string
+		ImageMode            string
+		KeepImageIDs         []string
+		ExpectedStatus       int
+		ExpectedImageMode    string
+		ExpectedKeepImageIDs []int
+	}{
+		{"Default", "", nil, http.StatusOK, model.ImageModeReplace, nil},
+		{"Replace", "replace", nil, http.StatusOK, model.ImageModeReplace,
+			nil},
+		{"Append", "append", nil, http.StatusOK, model.ImageModeAppend, nil},
+		{"Invalid", "merge", nil, http.StatusBadRequest, "", nil},
+		{"Keep Images", "", []string{"3", "1,2"}, http.StatusOK,
+			model.ImageModeAppend, []int{3, 1, 2}},
+		{"Keep No Image", "", []string{""}, http.StatusOK,
+			model.ImageModeAppend, []int{}},
+		{"Keep Images Replace", "replace", []string{"1"},
+			http.StatusBadRequest, "", nil},
+		{"Keep Image ID Invalid", "", []string{"1,a"},
+			http.StatusBadRequest, "", nil},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		repo := &imageModeRepository{}
+		a := API{Repo: repo, FiberApp: fiber.New()}
+		a.FiberApp.Put("/api/product/",
+			AuthorizationMiddlewareForTest(middleware.User{ID: 1,
+				Role: "seller"}),
+			a.UpdateProductHandler)
+
+		// create form data of product without images
+		var bFormData bytes.Buffer
+		w := multipart.NewWriter(&bFormData)
+		for key, value := range map[string]string{
+			"name":       "Product A",
+			"price":      "1000",
+			"weight":     "1",
+			"stock":      "10",
+			"image_mode": test.ImageMode,
+		} {
+			w.WriteField(key, value)
+		}
+		for _, value := range test.KeepImageIDs {
+			w.WriteField("keep_image_ids", value)
+		}
+		w.Close()
+
+		req, _ := http.NewRequest("PUT", "/api/product/?sku=SKU-A",
+			&bFormData)
+		req.Header.Set("Content-Type", w.FormDataContentType())
+		response, err := a.FiberApp.Test(req)
+		if err != nil {
+			t.Fatalf("[%s] There's an error serve http testing => %s",
+				test.TestName, err.Error())
+		}
+		response.Body.Close()
+
+		if response.StatusCode != test.ExpectedStatus ||
+			repo.imageMode != test.ExpectedImageMode ||
+			!reflect.DeepEqual(repo.keepImageIDs, test.ExpectedKeepImageIDs) {
+			t.Errorf("[%s] Expected status %d with image mode '%s' "+
+				"keeping %v, but got %d with '%s' keeping %v", test.TestName,
+				test.ExpectedStatus, test.ExpectedImageMode,
+				test.ExpectedKeepImageIDs, response.StatusCode,
+				repo.imageMode, repo.keepImageIDs)
+		}
+	}
+}
+
+// visibilityRepository product repository in memory recording
+// product visibility set
+type visibilityRepository struct {
+	model.ProductRepository
+	hidden map[string]bool
+}
+
+// SetProductVisibilityBySKU record product visibility in memory
+func (r visibilityRepository) SetProductVisibilityBySKU(ctx context.Context,
+	SKU string, userID int, hidden bool) (model.ProductInfo, error) {
+	r.hidden[SKU] = hidden
+	return model.ProductInfo{SKU: SKU, UserID: userID, Hidden: hidden}, nil
+}
+
+// TestSetProductVisibilityHandlerUnverified test SetProductVisibilityHandler
+// rejecting publishing product of unverified seller
+func TestSetProductVisibilityHandlerUnverified(t *testing.T) {
+	verified := true
+	unverified := false
+
+	// create testing table
+	testTable := []struct {
+		TestName           string
+		Verified           *bool
+		Hidden             string
+		ExpectedStatusCode int
+	}{
+		{"Unverified Publish", &unverified, "false", http.StatusForbidden},
+		{"Unverified Hide", &unverified, "true", http.StatusOK},
+		{"Verified Publish", &verified, "false", http.StatusOK},
+		{"Verification Unknown Publish", nil, "false", http.StatusOK},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		repo := visibilityRepository{hidden: map[string]bool{}}
+		a := API{Repo: repo, FiberApp: fiber.New()}
+		a.FiberApp.Put("/api/product/visibility/",
+			AuthorizationMiddlewareForTest(middleware.User{ID: 1,
+				Role: "seller", Verified: test.Verified}),
+			a.SetProductVisibilityHandler)
+
+		req, _ := http.NewRequest("PUT",
+			"/api/product/visibility/?sku=SKU-A&hidden="+test.Hidden, nil)
+		resp, err := a.FiberApp.Test(req)
+		if err != nil {
+			t.Fatalf("[%s] There's an error serve http testing => %s",
+				test.TestName, err.Error())
+		}
+		if resp.StatusCode != test.ExpectedStatusCode {
+			t.Errorf("[%s] Expected status code %d, but got %d",
+				test.TestName, test.ExpectedStatusCode, resp.StatusCode)
+		}
+		if _, ok := repo.hidden["SKU-A"]; ok !=
+			(test.ExpectedStatusCode == http.StatusOK) {
+			t.Errorf("[%s] Expected visibility set %t, but got %t",
+				test.TestName, !ok, ok)
+		}
+	}
+}
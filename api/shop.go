@@ -0,0 +1,152 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/config"
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+	"github.com/reyhanfikridz/ecom-product-service/internal/permission"
+	"github.com/reyhanfikridz/ecom-product-service/internal/validator"
+)
+
+// SetShopHandler handling route set shop profile of the seller from form
+// values 'slug' and 'display_name', and optional banner image file
+// 'banner' replacing the current banner (method: PUT, user: seller)
+func (a *API) SetShopHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// check user role is allowed to access this API
+	if !a.isAllowed(u, permission.ShopManage) {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this API",
+		})
+	}
+
+	// parse shop from form values
+	shop := model.Shop{}
+	err := c.BodyParser(&shop)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+	shop.UserID = u.ID
+
+	// validate shop data and banner if uploaded
+	err = validator.IsShopValid(shop)
+	if err != nil {
+		return sendValidationError(c, err)
+	}
+	fileHeader, err := c.FormFile("banner")
+	if err == nil {
+		err = validator.IsShopBannerValid(fileHeader,
+			config.MaxProductImagesSize)
+		if err != nil {
+			return sendValidationError(c, err)
+		}
+
+		// save banner into media folder
+		banner, err := model.SaveShopBanner(fileHeader)
+		if errors.Is(err, model.ErrProductImageInvalid) ||
+			errors.Is(err, model.ErrProductImageMalicious) {
+			return sendValidationError(c, validator.FieldError{
+				Field:   "banner",
+				Code:    validator.CodeInvalid,
+				Message: err.Error(),
+			})
+		} else if err != nil {
+			return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+				"message": fmt.Sprintf("There's an error when saving "+
+					"shop banner => %s", err.Error()),
+			})
+		}
+		shop.BannerImagePath = banner.ImagePath
+	}
+
+	// save shop into database
+	saved, err := a.Repo.SaveShop(c.UserContext(), shop)
+	if err != nil && shop.BannerImagePath != "" {
+		removeProductImages([]model.ProductImage{
+			{ImagePath: shop.BannerImagePath},
+		})
+	}
+	if errors.Is(err, model.ErrShopSlugTaken) {
+		return sendValidationError(c, validator.FieldError{
+			Field:   "slug",
+			Code:    validator.CodeInvalid,
+			Message: err.Error(),
+		})
+	} else if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": fmt.Sprintf("There's an error when saving "+
+				"the shop => %s", err.Error()),
+		})
+	}
+
+	setShopBannerURL(c.BaseURL(), &saved)
+
+	return c.Status(http.StatusOK).JSON(saved)
+}
+
+// GetShopHandler handling route get shop profile by url parameter
+// 'slug' (method: GET, user: any)
+func (a *API) GetShopHandler(c *fiber.Ctx) error {
+	shop, err := a.Repo.GetShopBySlug(c.UserContext(), c.Params("slug"))
+	if err == sql.ErrNoRows {
+		return c.Status(http.StatusNotFound).JSON(map[string]string{
+			"message": "shop not found",
+		})
+	} else if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	setShopBannerURL(c.BaseURL(), &shop)
+
+	return c.Status(http.StatusOK).JSON(shop)
+}
+
+// GetShopProductsHandler handling route get published products of shop
+// by url parameter 'slug', filtered and paginated like route get products
+// (method: GET, user: any)
+func (a *API) GetShopProductsHandler(c *fiber.Ctx) error {
+	shop, err := a.Repo.GetShopBySlug(c.UserContext(), c.Params("slug"))
+	if err == sql.ErrNoRows {
+		return c.Status(http.StatusNotFound).JSON(map[string]string{
+			"message": "shop not found",
+		})
+	} else if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	return a.sendProducts(c, model.ProductQuery{
+		UserID:        shop.UserID,
+		Search:        c.Query("search"),
+		ExcludeHidden: true,
+	})
+}
+
+// setShopBannerURL set URL of shop banner if any, always served
+// by media CDN if configured since shop is public
+func setShopBannerURL(originURL string, shop *model.Shop) {
+	if shop.BannerImagePath == "" {
+		return
+	}
+
+	shop.BannerImageURL = GetMediaURL(originURL, shop.BannerImagePath, true)
+}
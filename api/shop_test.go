@@ -0,0 +1,169 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+)
+
+// shopRepository product repository in memory storing shops by user ID,
+// recording the last query of GetProducts
+type shopRepository struct {
+	queryRecordingRepository
+	shops map[int]model.Shop
+}
+
+// SaveShop save shop in memory, slug can't be used by shop of another user
+func (r shopRepository) SaveShop(ctx context.Context, shop model.Shop) (
+	model.Shop, error) {
+	for userID, other := range r.shops {
+		if other.Slug == shop.Slug && userID != shop.UserID {
+			return model.Shop{}, model.ErrShopSlugTaken
+		}
+	}
+
+	r.shops[shop.UserID] = shop
+	return shop, nil
+}
+
+// GetShopBySlug get shop from memory
+func (r shopRepository) GetShopBySlug(ctx context.Context, slug string) (
+	model.Shop, error) {
+	for _, shop := range r.shops {
+		if shop.Slug == slug {
+			return shop, nil
+		}
+	}
+
+	return model.Shop{}, sql.ErrNoRows
+}
+
+// TestShopHandlers test SetShopHandler, GetShopHandler,
+// and GetShopProductsHandler
+func TestShopHandlers(t *testing.T) {
+	repo := shopRepository{
+		queryRecordingRepository: queryRecordingRepository{
+			query: &model.ProductQuery{},
+		},
+		shops: map[int]model.Shop{
+			2: {UserID: 2, Slug: "toko-ani", DisplayName: "Toko Ani",
+				BannerImagePath: "product-image/banner.png"},
+		},
+	}
+	a := API{Repo: repo, FiberApp: fiber.New()}
+	a.FiberApp.Put("/api/shop/",
+		AuthorizationMiddlewareForTest(middleware.User{ID: 1, Role: "seller"}),
+		a.SetShopHandler)
+	a.FiberApp.Put("/api/buyer/shop/",
+		AuthorizationMiddlewareForTest(middleware.User{ID: 3, Role: "buyer"}),
+		a.SetShopHandler)
+	a.FiberApp.Get("/api/shop/:slug/", a.GetShopHandler)
+	a.FiberApp.Get("/api/shop/:slug/products/", a.GetShopProductsHandler)
+
+	// create testing table of setting shop
+	testTable := []struct {
+		TestName           string
+		Path               string
+		Form               map[string]string
+		ExpectedStatusCode int
+	}{
+		{"Valid", "/api/shop/",
+			map[string]string{"slug": "toko-budi", "display_name": "Toko Budi"},
+			http.StatusOK},
+		{"Slug Invalid", "/api/shop/",
+			map[string]string{"slug": "Toko Budi", "display_name": "Toko Budi"},
+			http.StatusBadRequest},
+		{"Slug Taken", "/api/shop/",
+			map[string]string{"slug": "toko-ani", "display_name": "Toko Budi"},
+			http.StatusBadRequest},
+		{"Buyer", "/api/buyer/shop/",
+			map[string]string{"slug": "toko-cici", "display_name": "Toko Cici"},
+			http.StatusForbidden},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		body := bytes.Buffer{}
+		w := multipart.NewWriter(&body)
+		for key, value := range test.Form {
+			w.WriteField(key, value)
+		}
+		w.Close()
+
+		req, _ := http.NewRequest("PUT", test.Path, &body)
+		req.Header.Set("Content-Type", w.FormDataContentType())
+		resp, err := a.FiberApp.Test(req)
+		if err != nil {
+			t.Fatalf("[%s] There's an error when testing request => %s",
+				test.TestName, err.Error())
+		}
+		if resp.StatusCode != test.ExpectedStatusCode {
+			t.Errorf("[%s] Expected status code %d, but got %d",
+				test.TestName, test.ExpectedStatusCode, resp.StatusCode)
+		}
+	}
+	if repo.shops[1].DisplayName != "Toko Budi" || repo.shops[1].Slug !=
+		"toko-budi" {
+		t.Errorf("Expected shop toko-budi saved, but got %+v", repo.shops[1])
+	}
+
+	// get shop with banner URL
+	req, _ := http.NewRequest("GET", "http://example.com/api/shop/toko-ani/",
+		nil)
+	resp, err := a.FiberApp.Test(req)
+	if err != nil {
+		t.Fatalf("There's an error when testing request => %s", err.Error())
+	}
+	shop := model.Shop{}
+	err = json.NewDecoder(resp.Body).Decode(&shop)
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+	if resp.StatusCode != http.StatusOK || shop.UserID != 2 ||
+		shop.BannerImageURL != "http://example.com/media/product-image/banner.png" {
+		t.Errorf("Expected shop toko-ani with banner URL, but got %d %+v",
+			resp.StatusCode, shop)
+	}
+
+	// get published products of shop seller
+	req, _ = http.NewRequest("GET", "/api/shop/toko-ani/products/?search=tea",
+		nil)
+	resp, err = a.FiberApp.Test(req)
+	if err != nil {
+		t.Fatalf("There's an error when testing request => %s", err.Error())
+	}
+	expectedQuery := model.ProductQuery{UserID: 2, Search: "tea",
+		ExcludeHidden: true}
+	if resp.StatusCode != http.StatusOK || repo.query.UserID !=
+		expectedQuery.UserID || repo.query.Search != expectedQuery.Search ||
+		!repo.query.ExcludeHidden {
+		t.Errorf("Expected products query %+v, but got %d %+v",
+			expectedQuery, resp.StatusCode, *repo.query)
+	}
+
+	// shop not found
+	for _, path := range []string{"/api/shop/toko-x/",
+		"/api/shop/toko-x/products/"} {
+		req, _ = http.NewRequest("GET", path, nil)
+		resp, err = a.FiberApp.Test(req)
+		if err != nil {
+			t.Fatalf("There's an error when testing request => %s",
+				err.Error())
+		}
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("Expected status code %d of %s, but got %d",
+				http.StatusNotFound, path, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/dbmetrics"
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/permission"
+	"github.com/reyhanfikridz/ecom-product-service/internal/scheduler"
+)
+
+// default and maximum days of products created per day in marketplace stats
+const (
+	defaultStatsDays = 30
+	maxStatsDays     = 366
+)
+
+// GetMarketplaceStatsHandler handling route get marketplace-wide
+// aggregates of products (method: GET, user: admin)
+func (a *API) GetMarketplaceStatsHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// check user role is allowed to access this API
+	if !a.isAllowed(u, permission.StatsRead) {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this API",
+		})
+	}
+
+	// get days of products created per day from url
+	days := defaultStatsDays
+	if rawDays := c.Query("days"); rawDays != "" {
+		var err error
+		days, err = strconv.Atoi(rawDays)
+		if err != nil || days < 1 || days > maxStatsDays {
+			return c.Status(http.StatusBadRequest).JSON(map[string]string{
+				"message": fmt.Sprintf("parameter 'days' invalid, must be "+
+					"integer between 1 and %d", maxStatsDays),
+			})
+		}
+	}
+
+	// get marketplace stats from database
+	stats, err := a.Repo.GetMarketplaceStats(c.UserContext(), days,
+		time.Now())
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": fmt.Sprintf(
+				"There's an error when getting the marketplace stats => %s",
+				err.Error()),
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(stats)
+}
+
+// GetSchedulerStatsHandler handling route get run metrics of every
+// enabled scheduled task (method: GET, user: admin)
+func (a *API) GetSchedulerStatsHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// check user role is allowed to access this API
+	if !a.isAllowed(u, permission.StatsRead) {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this API",
+		})
+	}
+
+	if a.Scheduler == nil {
+		return c.Status(http.StatusOK).JSON([]scheduler.TaskStats{})
+	}
+
+	return c.Status(http.StatusOK).JSON(a.Scheduler.Stats())
+}
+
+// GetDBMetricsHandler handling route get latency metrics of database
+// queries by query name since the service started (method: GET,
+// user: admin)
+func (a *API) GetDBMetricsHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// check user role is allowed to access this API
+	if !a.isAllowed(u, permission.StatsRead) {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this API",
+		})
+	}
+
+	if a.DBMetrics == nil {
+		return c.Status(http.StatusOK).JSON([]dbmetrics.QueryStats{})
+	}
+
+	return c.Status(http.StatusOK).JSON(a.DBMetrics.Stats())
+}
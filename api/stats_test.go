@@ -0,0 +1,213 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/dbmetrics"
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+	"github.com/reyhanfikridz/ecom-product-service/internal/scheduler"
+)
+
+// statsRepository product repository in memory returning
+// marketplace stats with requested days
+type statsRepository struct {
+	fakeRepository
+}
+
+// GetMarketplaceStats get marketplace stats with zero count every day
+func (r statsRepository) GetMarketplaceStats(ctx context.Context, days int,
+	now time.Time) (model.MarketplaceStats, error) {
+	return model.MarketplaceStats{
+		ProductCount:          3,
+		SellerCount:           2,
+		AveragePrice:          150000,
+		ProductsCreatedPerDay: make([]model.DailyProductCount, days),
+	}, nil
+}
+
+// TestGetMarketplaceStatsHandler test GetMarketplaceStatsHandler
+func TestGetMarketplaceStatsHandler(t *testing.T) {
+	a := API{Repo: statsRepository{}, FiberApp: fiber.New()}
+	a.FiberApp.Get("/api/admin/stats/",
+		func(c *fiber.Ctx) error {
+			c.Locals("user", middleware.User{ID: 1, Role: c.Query("role")})
+			return c.Next()
+		},
+		a.GetMarketplaceStatsHandler)
+
+	// create testing table
+	testTable := []struct {
+		TestName           string
+		Query              string
+		ExpectedStatusCode int
+		ExpectedDays       int
+	}{
+		{
+			TestName:           "Default days",
+			Query:              "role=admin",
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedDays:       30,
+		},
+		{
+			TestName:           "Requested days",
+			Query:              "role=admin&days=7",
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedDays:       7,
+		},
+		{
+			TestName:           "Days invalid",
+			Query:              "role=admin&days=0",
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			TestName:           "Days too many",
+			Query:              "role=admin&days=367",
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			TestName:           "Not admin",
+			Query:              "role=seller",
+			ExpectedStatusCode: http.StatusForbidden,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		req, _ := http.NewRequest("GET", "/api/admin/stats/?"+test.Query, nil)
+		response, err := a.FiberApp.Test(req)
+		if err != nil {
+			t.Fatalf("[%s] There's an error serve http testing => %s",
+				test.TestName, err.Error())
+		}
+		defer response.Body.Close()
+
+		if response.StatusCode != test.ExpectedStatusCode {
+			t.Errorf("[%s] Expected status %d got %d", test.TestName,
+				test.ExpectedStatusCode, response.StatusCode)
+			continue
+		}
+		if response.StatusCode != http.StatusOK {
+			continue
+		}
+
+		stats := model.MarketplaceStats{}
+		err = json.NewDecoder(response.Body).Decode(&stats)
+		if err != nil {
+			t.Fatalf("[%s] There's an error when decoding response => %s",
+				test.TestName, err.Error())
+		}
+		if stats.ProductCount != 3 || stats.AveragePrice != 150000 ||
+			len(stats.ProductsCreatedPerDay) != test.ExpectedDays {
+			t.Errorf("[%s] Expected 3 products with average price 150000 "+
+				"in %d days, but got %+v", test.TestName, test.ExpectedDays,
+				stats)
+		}
+	}
+}
+
+// TestGetSchedulerStatsHandler test GetSchedulerStatsHandler
+func TestGetSchedulerStatsHandler(t *testing.T) {
+	a := API{
+		Scheduler: scheduler.NewScheduler(scheduler.Task{
+			Name:     "cleanup-media",
+			Interval: time.Hour,
+			Run:      func(ctx context.Context) error { return nil },
+		}),
+		FiberApp: fiber.New(),
+	}
+	a.FiberApp.Get("/api/admin/scheduler/",
+		func(c *fiber.Ctx) error {
+			c.Locals("user", middleware.User{ID: 1, Role: c.Query("role")})
+			return c.Next()
+		},
+		a.GetSchedulerStatsHandler)
+
+	// get stats by non admin
+	req, _ := http.NewRequest("GET", "/api/admin/scheduler/?role=seller", nil)
+	response, err := a.FiberApp.Test(req)
+	if err != nil {
+		t.Fatalf("There's an error serve http testing => %s", err.Error())
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status %d got %d", http.StatusForbidden,
+			response.StatusCode)
+	}
+
+	// get stats by admin
+	req, _ = http.NewRequest("GET", "/api/admin/scheduler/?role=admin", nil)
+	response, err = a.FiberApp.Test(req)
+	if err != nil {
+		t.Fatalf("There's an error serve http testing => %s", err.Error())
+	}
+	defer response.Body.Close()
+
+	stats := []scheduler.TaskStats{}
+	err = json.NewDecoder(response.Body).Decode(&stats)
+	if err != nil {
+		t.Fatalf("There's an error when unmarshal body response => %s",
+			err.Error())
+	}
+	if response.StatusCode != http.StatusOK || len(stats) != 1 ||
+		stats[0].Name != "cleanup-media" || stats[0].Interval != "1h0m0s" ||
+		stats[0].Runs != 0 {
+		t.Errorf("Expected stats of task cleanup-media not run yet, "+
+			"but got status %d with %+v", response.StatusCode, stats)
+	}
+}
+
+// TestGetDBMetricsHandler test GetDBMetricsHandler
+func TestGetDBMetricsHandler(t *testing.T) {
+	a := API{DBMetrics: dbmetrics.NewRecorder(0), FiberApp: fiber.New()}
+	a.DBMetrics.Record("SELECT sku FROM product_productinfo", 0,
+		10*time.Millisecond, nil)
+	a.FiberApp.Get("/api/admin/db/metrics/",
+		func(c *fiber.Ctx) error {
+			c.Locals("user", middleware.User{ID: 1, Role: c.Query("role")})
+			return c.Next()
+		},
+		a.GetDBMetricsHandler)
+
+	// get metrics by non admin
+	req, _ := http.NewRequest("GET", "/api/admin/db/metrics/?role=seller", nil)
+	response, err := a.FiberApp.Test(req)
+	if err != nil {
+		t.Fatalf("There's an error serve http testing => %s", err.Error())
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status %d got %d", http.StatusForbidden,
+			response.StatusCode)
+	}
+
+	// get metrics by admin
+	req, _ = http.NewRequest("GET", "/api/admin/db/metrics/?role=admin", nil)
+	response, err = a.FiberApp.Test(req)
+	if err != nil {
+		t.Fatalf("There's an error serve http testing => %s", err.Error())
+	}
+	defer response.Body.Close()
+
+	stats := []dbmetrics.QueryStats{}
+	err = json.NewDecoder(response.Body).Decode(&stats)
+	if err != nil {
+		t.Fatalf("There's an error when unmarshal body response => %s",
+			err.Error())
+	}
+	if response.StatusCode != http.StatusOK || len(stats) != 1 ||
+		stats[0].Name != "SELECT product_productinfo" || stats[0].Count != 1 {
+		t.Errorf("Expected metrics of one query, but got status %d with %+v",
+			response.StatusCode, stats)
+	}
+}
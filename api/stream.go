@@ -0,0 +1,285 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/event"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+)
+
+// types of stream events, also used as server-sent event name
+const (
+	StreamEventStock = "stock"
+	StreamEventPrice = "price"
+)
+
+// maximum SKUs subscribed by a stream, and events buffered for
+// a subscriber before its events are dropped
+const (
+	maxStreamSKUs         = 50
+	streamSubscriberQueue = 16
+)
+
+// streamHeartbeatInterval interval of comments keeping idle stream
+// open through proxies, and streamMaxDuration duration before stream
+// closed for the client to reconnect, so no stream lives forever
+var (
+	streamHeartbeatInterval = 15 * time.Second
+	streamMaxDuration       = 30 * time.Minute
+)
+
+// StreamEvent contain stock or price change of a product streamed to
+// subscribers, price fields are only set on price event
+type StreamEvent struct {
+	Type           string       `json:"type"`
+	SKU            string       `json:"sku"`
+	Stock          float64      `json:"stock"`
+	Price          *model.Money `json:"price,omitempty"`
+	SalePrice      *model.Money `json:"sale_price,omitempty"`
+	EffectivePrice *model.Money `json:"effective_price,omitempty"`
+}
+
+// streamSubscriber subscriber of stream events of SKUs
+type streamSubscriber struct {
+	SKUs   []string
+	events chan StreamEvent
+}
+
+// StreamHub fan out stream events to subscribers of the product SKU,
+// safe to be used while serving requests
+type StreamHub struct {
+	mu          sync.Mutex
+	subscribers map[string]map[*streamSubscriber]bool
+}
+
+// NewStreamHub create stream hub without subscribers
+func NewStreamHub() *StreamHub {
+	return &StreamHub{
+		subscribers: map[string]map[*streamSubscriber]bool{},
+	}
+}
+
+// Subscribe subscribe stream events of SKUs, then get channel of the events
+// and function unsubscribing it
+func (h *StreamHub) Subscribe(SKUs []string) (<-chan StreamEvent, func()) {
+	s := &streamSubscriber{
+		SKUs:   SKUs,
+		events: make(chan StreamEvent, streamSubscriberQueue),
+	}
+
+	h.mu.Lock()
+	for _, SKU := range SKUs {
+		if h.subscribers[SKU] == nil {
+			h.subscribers[SKU] = map[*streamSubscriber]bool{}
+		}
+		h.subscribers[SKU][s] = true
+	}
+	h.mu.Unlock()
+
+	unsubscribe := func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		for _, SKU := range s.SKUs {
+			delete(h.subscribers[SKU], s)
+			if len(h.subscribers[SKU]) == 0 {
+				delete(h.subscribers, SKU)
+			}
+		}
+	}
+
+	return s.events, unsubscribe
+}
+
+// Subscribers get count of subscribers of SKU
+func (h *StreamHub) Subscribers(SKU string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.subscribers[SKU])
+}
+
+// Publish send stream event of product event, if any, to subscribers
+// of its SKU without waiting, event is dropped for subscriber whose
+// buffer is full
+func (h *StreamHub) Publish(e event.Event) {
+	streamEvent, ok := NewStreamEvent(e)
+	if !ok {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.subscribers[streamEvent.SKU] {
+		select {
+		case s.events <- streamEvent:
+		default:
+		}
+	}
+}
+
+// NewStreamEvent create stream event of product event, return false
+// if the event isn't stock or price change of a published product
+//
+// stock change payload doesn't tell whether the product is published,
+// so it must be checked with isStreamPublished before published
+func NewStreamEvent(e event.Event) (StreamEvent, bool) {
+	switch payload := e.Payload.(type) {
+	case event.StockChangedPayload:
+		return StreamEvent{
+			Type:  StreamEventStock,
+			SKU:   e.SKU,
+			Stock: payload.Stock,
+		}, e.Type == event.StockChanged
+	case model.ProductInfo:
+		return newPriceStreamEvent(e, payload)
+	case model.Product:
+		return newPriceStreamEvent(e, payload.ProductInfo)
+	}
+
+	return StreamEvent{}, false
+}
+
+// newPriceStreamEvent create price stream event of product updated event,
+// hidden product isn't streamed
+func newPriceStreamEvent(e event.Event, pInfo model.ProductInfo) (
+	StreamEvent, bool) {
+	if e.Type != event.ProductUpdated || pInfo.Hidden ||
+		pInfo.DeletedAt != nil {
+		return StreamEvent{}, false
+	}
+
+	effectivePrice := pInfo.GetEffectivePrice(time.Now())
+	return StreamEvent{
+		Type:           StreamEventPrice,
+		SKU:            e.SKU,
+		Stock:          pInfo.Stock,
+		Price:          &pInfo.Price,
+		SalePrice:      &pInfo.SalePrice,
+		EffectivePrice: &effectivePrice,
+	}, true
+}
+
+// isStreamPublished check product of event is published to be streamed,
+// product of stock change is looked up only if its SKU is subscribed,
+// hidden (including of unverified seller) and deleted product isn't
+func (a *API) isStreamPublished(e event.Event) bool {
+	if e.Type != event.StockChanged {
+		return true
+	}
+	if a.Streams.Subscribers(e.SKU) == 0 || a.Repo == nil {
+		return false
+	}
+
+	p, err := a.Repo.GetProductBySKU(context.Background(), e.SKU)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("There's an error when getting product of SKU %s "+
+				"to be streamed => %s", e.SKU, err.Error())
+		}
+		return false
+	}
+
+	return !p.ProductInfo.Hidden && p.ProductInfo.DeletedAt == nil
+}
+
+// getStreams get stream hub of API,
+// initialized without subscribers if it's not initialized
+func (a *API) getStreams() *StreamHub {
+	if a.Streams == nil {
+		a.Streams = NewStreamHub()
+	}
+
+	return a.Streams
+}
+
+// StreamProductsHandler handling route stream stock and price changes
+// of products by url query 'skus' (comma separated) as server-sent events,
+// the stream is closed after streamMaxDuration for the client
+// to reconnect (method: GET, user: any)
+func (a *API) StreamProductsHandler(c *fiber.Ctx) error {
+	// get SKUs
+	SKUs, err := parseStreamSKUs(c.Query("skus"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	streams := a.getStreams()
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		events, unsubscribe := streams.Subscribe(SKUs)
+		defer unsubscribe()
+
+		heartbeat := time.NewTicker(streamHeartbeatInterval)
+		defer heartbeat.Stop()
+		timeout := time.NewTimer(streamMaxDuration)
+		defer timeout.Stop()
+
+		// client reconnects a second after stream closed, and stream
+		// is closed once client disconnected since flushing it fails
+		fmt.Fprint(w, "retry: 1000\n\n")
+		if w.Flush() != nil {
+			return
+		}
+		for {
+			select {
+			case e := <-events:
+				data, err := json.Marshal(e)
+				if err != nil {
+					continue
+				}
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data)
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": heartbeat\n\n")
+			case <-timeout.C:
+				return
+			}
+			if w.Flush() != nil {
+				return
+			}
+		}
+	})
+
+	return nil
+}
+
+// parseStreamSKUs parse comma separated SKUs subscribed by stream,
+// empty and duplicate SKUs are skipped
+func parseStreamSKUs(query string) ([]string, error) {
+	SKUs := []string{}
+	seen := map[string]bool{}
+	for _, SKU := range strings.Split(query, ",") {
+		SKU = strings.TrimSpace(SKU)
+		if SKU == "" || seen[SKU] {
+			continue
+		}
+		seen[SKU] = true
+		SKUs = append(SKUs, SKU)
+	}
+
+	if len(SKUs) == 0 {
+		return nil, errors.New("parameter 'skus' empty/not found")
+	}
+	if len(SKUs) > maxStreamSKUs {
+		return nil, fmt.Errorf("parameter 'skus' too many, maximum %d SKUs",
+			maxStreamSKUs)
+	}
+
+	return SKUs, nil
+}
@@ -0,0 +1,136 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/event"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+)
+
+// TestNewStreamEvent test NewStreamEvent
+func TestNewStreamEvent(t *testing.T) {
+	// create testing table
+	testTable := []struct {
+		TestName     string
+		Event        event.Event
+		ExpectedType string
+		ExpectedOK   bool
+	}{
+		{"Stock Changed", event.NewEvent(event.StockChanged, "SKU-A", 1,
+			event.StockChangedPayload{Stock: 5, Delta: -1}),
+			StreamEventStock, true},
+		{"Product Updated", event.NewEvent(event.ProductUpdated, "SKU-A", 1,
+			model.ProductInfo{SKU: "SKU-A", Price: 1000, Stock: 5}),
+			StreamEventPrice, true},
+		{"Product Updated Hidden", event.NewEvent(event.ProductUpdated, "SKU-A",
+			1, model.ProductInfo{SKU: "SKU-A", Hidden: true}), "", false},
+		{"Product Created", event.NewEvent(event.ProductCreated, "SKU-A", 1,
+			model.ProductInfo{SKU: "SKU-A"}), "", false},
+		{"Product Deleted", event.NewEvent(event.ProductDeleted, "SKU-A", 1,
+			nil), "", false},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		e, ok := NewStreamEvent(test.Event)
+		if ok != test.ExpectedOK || e.Type != test.ExpectedType {
+			t.Errorf("[%s] Expected stream event %q (%t), but got %q (%t)",
+				test.TestName, test.ExpectedType, test.ExpectedOK, e.Type, ok)
+		}
+	}
+}
+
+// TestStreamProductsHandler test StreamProductsHandler streaming
+// changes of subscribed published SKUs only
+func TestStreamProductsHandler(t *testing.T) {
+	defaultMaxDuration := streamMaxDuration
+	streamMaxDuration = 300 * time.Millisecond
+	defer func() { streamMaxDuration = defaultMaxDuration }()
+
+	a := API{Streams: NewStreamHub(), FiberApp: fiber.New(),
+		Repo: fakeRepository{products: map[string]model.Product{
+			"SKU-A": {ProductInfo: model.ProductInfo{SKU: "SKU-A"}},
+			"SKU-B": {ProductInfo: model.ProductInfo{SKU: "SKU-B"}},
+			"SKU-H": {ProductInfo: model.ProductInfo{SKU: "SKU-H",
+				Hidden: true}},
+		}}}
+	a.FiberApp.Get("/api/products/stream/", a.StreamProductsHandler)
+
+	// SKUs required and limited
+	tooManySKUs := []string{}
+	for i := 0; i <= maxStreamSKUs; i++ {
+		tooManySKUs = append(tooManySKUs, fmt.Sprintf("SKU-%d", i))
+	}
+	for _, query := range []string{"", "?skus=,",
+		"?skus=" + strings.Join(tooManySKUs, ",")} {
+		req, _ := http.NewRequest("GET", "/api/products/stream/"+query, nil)
+		resp, err := a.FiberApp.Test(req)
+		if err != nil {
+			t.Fatalf("There's an error when testing request => %s",
+				err.Error())
+		}
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected status code %d of query %q, but got %d",
+				http.StatusBadRequest, query, resp.StatusCode)
+		}
+	}
+
+	// publish events once the stream subscribed
+	go func() {
+		for a.Streams.Subscribers("SKU-A") == 0 {
+			time.Sleep(10 * time.Millisecond)
+		}
+		a.PublishEvent(event.NewEvent(event.StockChanged, "SKU-B", 1,
+			event.StockChangedPayload{Stock: 9, Delta: -1}))
+		a.PublishEvent(event.NewEvent(event.StockChanged, "SKU-H", 1,
+			event.StockChangedPayload{Stock: 3, Delta: -1}))
+		a.PublishEvent(event.NewEvent(event.StockChanged, "SKU-C", 1,
+			event.StockChangedPayload{Stock: 2, Delta: -1}))
+		a.PublishEvent(event.NewEvent(event.StockChanged, "SKU-A", 1,
+			event.StockChangedPayload{Stock: 4, Delta: -1}))
+		a.PublishEvent(event.NewEvent(event.ProductUpdated, "SKU-A", 1,
+			model.ProductInfo{SKU: "SKU-A", Price: 1500, Stock: 4}))
+	}()
+
+	req, _ := http.NewRequest("GET",
+		"/api/products/stream/?skus=SKU-A,SKU-C,SKU-H", nil)
+	resp, err := a.FiberApp.Test(req, 2000)
+	if err != nil {
+		t.Fatalf("There's an error when testing request => %s", err.Error())
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("There's an error when reading response body => %s",
+			err.Error())
+	}
+	if resp.StatusCode != http.StatusOK ||
+		resp.Header.Get(fiber.HeaderContentType) != "text/event-stream" {
+		t.Errorf("Expected event stream, but got %d %s", resp.StatusCode,
+			resp.Header.Get(fiber.HeaderContentType))
+	}
+
+	expectedBody := "retry: 1000\n\n" +
+		"event: stock\ndata: {\"type\":\"stock\",\"sku\":\"SKU-A\"," +
+		"\"stock\":4}\n\n" +
+		"event: price\ndata: {\"type\":\"price\",\"sku\":\"SKU-A\"," +
+		"\"stock\":4,\"price\":15,\"sale_price\":0,\"effective_price\":15}" +
+		"\n\n"
+	if string(body) != expectedBody {
+		t.Errorf("Expected body %q, but got %q", expectedBody, string(body))
+	}
+
+	// stream unsubscribed once closed
+	if a.Streams.Subscribers("SKU-A") != 0 {
+		t.Errorf("Expected no subscribers after stream closed, but got %d",
+			a.Streams.Subscribers("SKU-A"))
+	}
+}
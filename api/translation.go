@@ -0,0 +1,298 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/config"
+	"github.com/reyhanfikridz/ecom-product-service/internal/event"
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+	"github.com/reyhanfikridz/ecom-product-service/internal/permission"
+	"github.com/reyhanfikridz/ecom-product-service/internal/validator"
+)
+
+// GetProductTranslationsHandler handling route get translations of product
+// by SKU (method: GET, user: seller owning the product)
+func (a *API) GetProductTranslationsHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// check user role is allowed to access this API
+	if !a.isAllowed(u, permission.ProductReadOwn) {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this API",
+		})
+	}
+
+	// get product by sku from database
+	SKU := c.Params("sku")
+	p, err := a.Repo.GetProductBySKU(c.UserContext(), SKU)
+	if err == sql.ErrNoRows {
+		return c.Status(http.StatusNotFound).JSON(map[string]string{
+			"message": "product not found",
+		})
+	} else if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	// check product owned by the seller
+	if p.ProductInfo.UserID != u.ID {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this product",
+		})
+	}
+
+	// get translations from database
+	translations, err := a.Repo.GetProductTranslationsBySKU(c.UserContext(),
+		SKU)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(translations)
+}
+
+// SetProductTranslationHandler handling route add or replace translation
+// of product by SKU in a locale (method: PUT, user: seller owning the product)
+func (a *API) SetProductTranslationHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// check user role is allowed to access this API
+	if !a.isAllowed(u, permission.ProductUpdate) {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this API",
+		})
+	}
+
+	// parse translation from form data
+	t := model.ProductTranslation{}
+	err := c.BodyParser(&t)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+	t.Locale = model.NormalizeLocale(c.Params("locale"))
+
+	// validate translation data
+	err = validator.IsProductTranslationValid(t, config.DefaultLocale)
+	if err != nil {
+		return sendValidationError(c, err)
+	}
+
+	// get product by sku from database
+	SKU := c.Params("sku")
+	p, err := a.Repo.GetProductBySKU(c.UserContext(), SKU)
+	if err == sql.ErrNoRows {
+		return c.Status(http.StatusNotFound).JSON(map[string]string{
+			"message": "product not found",
+		})
+	} else if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	// check product owned by the seller
+	if p.ProductInfo.UserID != u.ID {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this product",
+		})
+	}
+
+	// set translation in database
+	t, err = a.Repo.SetProductTranslationBySKU(c.UserContext(), SKU, u.ID, t)
+	if err == sql.ErrNoRows {
+		return c.Status(http.StatusNotFound).JSON(map[string]string{
+			"message": "product not found",
+		})
+	} else if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	a.PublishEvent(event.NewEvent(event.ProductUpdated, SKU,
+		p.ProductInfo.UserID, p.ProductInfo))
+
+	return c.Status(http.StatusOK).JSON(t)
+}
+
+// DeleteProductTranslationHandler handling route delete translation
+// of product by SKU in a locale (method: DELETE, user: seller owning
+// the product)
+func (a *API) DeleteProductTranslationHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// check user role is allowed to access this API
+	if !a.isAllowed(u, permission.ProductUpdate) {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this API",
+		})
+	}
+
+	// get product by sku from database
+	SKU := c.Params("sku")
+	p, err := a.Repo.GetProductBySKU(c.UserContext(), SKU)
+	if err == sql.ErrNoRows {
+		return c.Status(http.StatusNotFound).JSON(map[string]string{
+			"message": "product not found",
+		})
+	} else if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	// check product owned by the seller
+	if p.ProductInfo.UserID != u.ID {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this product",
+		})
+	}
+
+	// delete translation from database
+	locale := model.NormalizeLocale(c.Params("locale"))
+	err = a.Repo.DeleteProductTranslationBySKU(c.UserContext(), SKU, u.ID,
+		locale)
+	if err == sql.ErrNoRows {
+		return c.Status(http.StatusNotFound).JSON(map[string]string{
+			"message": "translation not found",
+		})
+	} else if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	a.PublishEvent(event.NewEvent(event.ProductUpdated, SKU,
+		p.ProductInfo.UserID, p.ProductInfo))
+
+	return c.Status(http.StatusOK).JSON(map[string]string{
+		"message": "Product translation deleted!",
+	})
+}
+
+// localizeProducts localize products into locales requested by
+// url parameter 'locale' or header Accept-Language, products are
+// left untouched if no locale requested
+func (a *API) localizeProducts(c *fiber.Ctx, products []model.Product) error {
+	locales, err := GetRequestLocales(c.Query("locale"),
+		c.Get(fiber.HeaderAcceptLanguage))
+	if err != nil || len(locales) == 0 || len(products) == 0 {
+		return err
+	}
+
+	return a.Repo.LocalizeProducts(c.UserContext(), products, locales)
+}
+
+// errLocaleInvalid returned by GetRequestLocales if parameter 'locale'
+// is invalid
+var errLocaleInvalid = errors.New("parameter 'locale' invalid, " +
+	"must be language code like 'id' or 'en-us'")
+
+// GetRequestLocales get normalized locales requested by url parameter
+// locale, or by header Accept-Language sorted by quality if there's no
+// locale parameter, each locale followed by its language as fallback
+//
+// invalid locales in Accept-Language are skipped
+func GetRequestLocales(locale string, acceptLanguage string) ([]string,
+	error) {
+	requested := []string{}
+	if strings.TrimSpace(locale) != "" {
+		locale = model.NormalizeLocale(locale)
+		if !model.IsLocaleValid(locale) {
+			return nil, errLocaleInvalid
+		}
+		requested = append(requested, locale)
+	} else {
+		requested = parseAcceptLanguage(acceptLanguage)
+	}
+
+	locales := []string{}
+	seen := map[string]bool{}
+	add := func(l string) {
+		if !seen[l] {
+			seen[l] = true
+			locales = append(locales, l)
+		}
+	}
+	for _, l := range requested {
+		add(l)
+		if i := strings.Index(l, "-"); i > 0 {
+			add(l[:i])
+		}
+	}
+
+	return locales, nil
+}
+
+// parseAcceptLanguage get valid normalized locales of header
+// Accept-Language sorted by quality, highest first
+func parseAcceptLanguage(header string) []string {
+	type weightedLocale struct {
+		locale  string
+		quality float64
+	}
+
+	weighted := []weightedLocale{}
+	for _, part := range strings.Split(header, ",") {
+		locale, params, _ := strings.Cut(part, ";")
+		locale = model.NormalizeLocale(locale)
+		if !model.IsLocaleValid(locale) {
+			continue
+		}
+
+		quality := 1.0
+		params = strings.TrimSpace(params)
+		if strings.HasPrefix(params, "q=") {
+			q, err := strconv.ParseFloat(strings.TrimPrefix(params, "q="), 64)
+			if err != nil || q <= 0 || q > 1 {
+				continue
+			}
+			quality = q
+		}
+
+		weighted = append(weighted, weightedLocale{locale, quality})
+	}
+	sort.SliceStable(weighted, func(i, j int) bool {
+		return weighted[i].quality > weighted[j].quality
+	})
+
+	locales := []string{}
+	for _, w := range weighted {
+		locales = append(locales, w.locale)
+	}
+
+	return locales
+}
@@ -0,0 +1,235 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/config"
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+	"github.com/reyhanfikridz/ecom-product-service/internal/validator"
+)
+
+// translationRepository product repository in memory storing
+// product translations by SKU then locale
+type translationRepository struct {
+	fakeRepository
+	translations map[string]map[string]model.ProductTranslation
+}
+
+// LocalizeProducts localize products with translations in memory
+func (r translationRepository) LocalizeProducts(ctx context.Context,
+	products []model.Product, locales []string) error {
+	for i := range products {
+		products[i].ProductInfo.Localize(
+			r.translations[products[i].ProductInfo.SKU], locales,
+			config.DefaultLocale)
+	}
+
+	return nil
+}
+
+// TestGetRequestLocales test GetRequestLocales
+func TestGetRequestLocales(t *testing.T) {
+	// create testing table
+	testTable := []struct {
+		TestName        string
+		Locale          string
+		AcceptLanguage  string
+		ExpectedLocales []string
+		ExpectedError   error
+	}{
+		{
+			TestName:        "No locale requested",
+			ExpectedLocales: []string{},
+		},
+		{
+			TestName:        "Locale parameter with region",
+			Locale:          "id_ID",
+			AcceptLanguage:  "fr",
+			ExpectedLocales: []string{"id-id", "id"},
+		},
+		{
+			TestName:        "Accept-Language sorted by quality",
+			AcceptLanguage:  "en;q=0.5, fr-CA, *;q=0.1, fr;q=0.9",
+			ExpectedLocales: []string{"fr-ca", "fr", "en"},
+		},
+		{
+			TestName:        "Accept-Language invalid entries skipped",
+			AcceptLanguage:  "de;q=abc, 123, ja",
+			ExpectedLocales: []string{"ja"},
+		},
+		{
+			TestName:      "Locale parameter invalid",
+			Locale:        "english!",
+			ExpectedError: errLocaleInvalid,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		locales, err := GetRequestLocales(test.Locale, test.AcceptLanguage)
+		if err != test.ExpectedError {
+			t.Errorf("[%s] Expected error %v, but got %v",
+				test.TestName, test.ExpectedError, err)
+			continue
+		}
+		if err == nil && !reflect.DeepEqual(locales, test.ExpectedLocales) {
+			t.Errorf("[%s] Expected locales %v, but got %v",
+				test.TestName, test.ExpectedLocales, locales)
+		}
+	}
+}
+
+// TestGetProductHandlerLocalized test GetProductHandler localizing
+// product into requested locale with fallback to the default locale
+func TestGetProductHandlerLocalized(t *testing.T) {
+	config.DefaultLocale = "en"
+	repo := translationRepository{
+		fakeRepository: fakeRepository{products: map[string]model.Product{
+			"SKU-A": {ProductInfo: model.ProductInfo{
+				SKU:         "SKU-A",
+				Name:        "Wireless Mouse",
+				Description: "Silent click",
+			}},
+		}},
+		translations: map[string]map[string]model.ProductTranslation{
+			"SKU-A": {
+				"id": {Locale: "id", Name: "Mouse Nirkabel",
+					Description: "Klik senyap"},
+			},
+		},
+	}
+	a := API{Repo: repo, FiberApp: fiber.New()}
+	a.FiberApp.Get("/api/product/",
+		AuthorizationMiddlewareForTest(middleware.User{ID: 2, Role: "buyer"}),
+		a.GetProductHandler)
+
+	// create testing table
+	testTable := []struct {
+		TestName           string
+		Query              string
+		AcceptLanguage     string
+		ExpectedStatusCode int
+		ExpectedName       string
+		ExpectedLocale     string
+	}{
+		{
+			TestName:           "No locale requested",
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedName:       "Wireless Mouse",
+		},
+		{
+			TestName:           "Translated locale by Accept-Language",
+			AcceptLanguage:     "id-ID, en;q=0.8",
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedName:       "Mouse Nirkabel",
+			ExpectedLocale:     "id",
+		},
+		{
+			TestName:           "Untranslated locale falls back to default",
+			Query:              "&locale=fr",
+			AcceptLanguage:     "id",
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedName:       "Wireless Mouse",
+			ExpectedLocale:     "en",
+		},
+		{
+			TestName:           "Default locale preferred over translation",
+			AcceptLanguage:     "en, id;q=0.9",
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedName:       "Wireless Mouse",
+			ExpectedLocale:     "en",
+		},
+		{
+			TestName:           "Invalid locale parameter",
+			Query:              "&locale=!",
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		req, _ := http.NewRequest("GET",
+			"/api/product/?sku=SKU-A&testing=1"+test.Query, nil)
+		if test.AcceptLanguage != "" {
+			req.Header.Set(fiber.HeaderAcceptLanguage, test.AcceptLanguage)
+		}
+		response, err := a.FiberApp.Test(req)
+		if err != nil {
+			t.Fatalf("[%s] There's an error serve http testing => %s",
+				test.TestName, err.Error())
+		}
+		defer response.Body.Close()
+
+		if response.StatusCode != test.ExpectedStatusCode {
+			t.Errorf("[%s] Expected status %d got %d", test.TestName,
+				test.ExpectedStatusCode, response.StatusCode)
+			continue
+		}
+		if response.StatusCode != http.StatusOK {
+			continue
+		}
+
+		p := model.Product{}
+		err = json.NewDecoder(response.Body).Decode(&p)
+		if err != nil {
+			t.Fatalf("[%s] There's an error when decoding response => %s",
+				test.TestName, err.Error())
+		}
+		if p.ProductInfo.Name != test.ExpectedName ||
+			p.ProductInfo.Locale != test.ExpectedLocale {
+			t.Errorf("[%s] Expected name '%s' in locale '%s', but got "+
+				"'%s' in locale '%s'", test.TestName, test.ExpectedName,
+				test.ExpectedLocale, p.ProductInfo.Name, p.ProductInfo.Locale)
+		}
+	}
+}
+
+// TestSetProductTranslationHandlerValidation test SetProductTranslationHandler
+// responding every invalid field of translation
+func TestSetProductTranslationHandlerValidation(t *testing.T) {
+	config.DefaultLocale = "en"
+	a := API{Repo: translationRepository{}, FiberApp: fiber.New()}
+	a.FiberApp.Put("/api/product/:sku/translations/:locale/",
+		AuthorizationMiddlewareForTest(middleware.User{ID: 1, Role: "seller"}),
+		a.SetProductTranslationHandler)
+
+	req, _ := http.NewRequest("PUT", "/api/product/SKU-A/translations/en/",
+		strings.NewReader("description=Silent+click"))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationForm)
+	response, err := a.FiberApp.Test(req)
+	if err != nil {
+		t.Fatalf("There's an error serve http testing => %s", err.Error())
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected status %d got %d", http.StatusBadRequest,
+			response.StatusCode)
+	}
+
+	body := map[string]validator.Errors{}
+	err = json.NewDecoder(response.Body).Decode(&body)
+	if err != nil {
+		t.Fatalf("There's an error when decoding response => %s", err.Error())
+	}
+
+	expected := validator.Errors{
+		{Field: "locale", Code: validator.CodeInvalid, Message: "locale 'en' " +
+			"is the default locale, update the product instead"},
+		{Field: "name", Code: validator.CodeRequired,
+			Message: "name empty/not found"},
+	}
+	if !reflect.DeepEqual(body["errors"], expected) {
+		t.Errorf("Expected errors %+v, but got %+v", expected, body["errors"])
+	}
+}
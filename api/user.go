@@ -0,0 +1,121 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/event"
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+	"github.com/reyhanfikridz/ecom-product-service/internal/permission"
+	"github.com/reyhanfikridz/ecom-product-service/internal/validator"
+)
+
+// DeleteUserProductsHandler handling route permanently delete all products,
+// shop, and media of a user when the account service deleted the user
+// (method: DELETE, user: internal service)
+func (a *API) DeleteUserProductsHandler(c *fiber.Ctx) error {
+	// get user ID from url
+	userID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || userID <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": "parameter 'id' invalid, must be positive integer",
+		})
+	}
+
+	// delete products of the user in database
+	SKUs, imagePaths, err := a.Repo.DeleteProductsByUserID(c.UserContext(),
+		userID)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": fmt.Sprintf(
+				"There's an error when deleting the products of user => %s",
+				err.Error()),
+		})
+	}
+
+	// remove image files of the products and shop banner, failure is only
+	// logged since the files are also removed later by cleanup-media command
+	for _, imagePath := range imagePaths {
+		err = model.RemoveProductImageFile(imagePath)
+		if err != nil {
+			log.Printf("There's an error when removing product image %s "+
+				"of user %d => %s", imagePath, userID, err.Error())
+		}
+	}
+
+	for _, SKU := range SKUs {
+		a.PublishEvent(event.NewEvent(event.ProductDeleted, SKU, userID, nil))
+	}
+
+	return c.Status(http.StatusOK).JSON(map[string]interface{}{
+		"message":          "Products of user deleted!",
+		"deleted_products": len(SKUs),
+	})
+}
+
+// TransferProductsHandler handling route transfer a product, or all
+// products, of a user to another user when seller accounts are merged
+// (method: PUT, user: admin)
+func (a *API) TransferProductsHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// check user role is allowed to access this API
+	if !a.isAllowed(u, permission.ProductTransfer) {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this API",
+		})
+	}
+
+	// parse product transfer from JSON body
+	t := model.ProductTransfer{}
+	err := json.Unmarshal(c.Body(), &t)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": "body must be JSON object of " +
+				"{sku, from_user_id, to_user_id}",
+		})
+	}
+
+	// validate product transfer data
+	err = validator.IsProductTransferValid(t)
+	if err != nil {
+		return sendValidationError(c, err)
+	}
+
+	// transfer products in database
+	transfers, err := a.Repo.TransferProducts(c.UserContext(), t, u.ID)
+	if err == sql.ErrNoRows {
+		return c.Status(http.StatusNotFound).JSON(map[string]string{
+			"message": "product of from_user_id not found",
+		})
+	} else if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": fmt.Sprintf(
+				"There's an error when transferring the products => %s",
+				err.Error()),
+		})
+	}
+
+	for _, transfer := range transfers {
+		a.PublishEvent(event.NewEvent(event.ProductUpdated, transfer.SKU,
+			transfer.ToUserID, nil))
+	}
+
+	return c.Status(http.StatusOK).JSON(map[string]interface{}{
+		"message":   "Products transferred!",
+		"transfers": transfers,
+	})
+}
@@ -0,0 +1,325 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/config"
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+)
+
+// userProductsRepository product repository in memory deleting
+// products and shop by user ID
+type userProductsRepository struct {
+	fakeRepository
+	shops map[int]model.Shop
+}
+
+// DeleteProductsByUserID delete products of user ID from memory
+func (r userProductsRepository) DeleteProductsByUserID(ctx context.Context,
+	userID int) ([]string, []string, error) {
+	SKUs := []string{}
+	imagePaths := []string{}
+	for SKU, p := range r.products {
+		if p.ProductInfo.UserID != userID {
+			continue
+		}
+
+		for _, pImage := range p.ProductImages {
+			imagePaths = append(imagePaths, pImage.ImagePath)
+		}
+		SKUs = append(SKUs, SKU)
+		delete(r.products, SKU)
+	}
+	if shop, ok := r.shops[userID]; ok {
+		if shop.BannerImagePath != "" {
+			imagePaths = append(imagePaths, shop.BannerImagePath)
+		}
+		delete(r.shops, userID)
+	}
+
+	return SKUs, imagePaths, nil
+}
+
+// TransferProducts transfer products of user in memory
+func (r userProductsRepository) TransferProducts(ctx context.Context,
+	t model.ProductTransfer, adminUserID int) ([]model.OwnershipTransfer,
+	error) {
+	transfers := []model.OwnershipTransfer{}
+	for SKU, p := range r.products {
+		if p.ProductInfo.UserID != t.FromUserID ||
+			(t.SKU != "" && SKU != t.SKU) {
+			continue
+		}
+
+		p.ProductInfo.UserID = t.ToUserID
+		r.products[SKU] = p
+		transfers = append(transfers, model.OwnershipTransfer{
+			SKU:         SKU,
+			FromUserID:  t.FromUserID,
+			ToUserID:    t.ToUserID,
+			AdminUserID: adminUserID,
+		})
+	}
+	if len(transfers) == 0 {
+		return transfers, sql.ErrNoRows
+	}
+
+	return transfers, nil
+}
+
+// TestDeleteUserProductsHandler test DeleteUserProductsHandler
+// authorized by internal service token
+func TestDeleteUserProductsHandler(t *testing.T) {
+	config.InternalServiceToken = "service-secret"
+	defer func() { config.InternalServiceToken = "" }()
+	mediaRoot := config.MediaRoot
+	config.MediaRoot = t.TempDir()
+	defer func() { config.MediaRoot = mediaRoot }()
+
+	// banner file of shop of the deleted user
+	bannerPath := "product-image/banner.png"
+	err := os.MkdirAll(filepath.Join(config.MediaRoot, "product-image"),
+		os.ModePerm)
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+	err = os.WriteFile(filepath.Join(config.MediaRoot, bannerPath),
+		[]byte("banner"), 0644)
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+
+	repo := userProductsRepository{
+		fakeRepository: fakeRepository{
+			products: map[string]model.Product{
+				"SKU-A": {
+					ProductInfo: model.ProductInfo{SKU: "SKU-A", UserID: 1},
+					ProductImages: []model.ProductImage{
+						{ImagePath: "product-image/missing-a.png"},
+					},
+				},
+				"SKU-B": {ProductInfo: model.ProductInfo{SKU: "SKU-B", UserID: 1}},
+				"SKU-C": {ProductInfo: model.ProductInfo{SKU: "SKU-C", UserID: 2}},
+			},
+		},
+		shops: map[int]model.Shop{
+			1: {UserID: 1, Slug: "toko-budi", BannerImagePath: bannerPath},
+			2: {UserID: 2, Slug: "toko-ani"},
+		},
+	}
+	a := API{Repo: repo, FiberApp: fiber.New()}
+	a.FiberApp.Delete("/api/products/user/:id/",
+		middleware.ServiceAuthorizationMiddleware(),
+		a.DeleteUserProductsHandler)
+
+	// create testing table
+	testTable := []struct {
+		TestName               string
+		UserID                 string
+		Token                  string
+		ExpectedStatusCode     int
+		ExpectedDeleted        int
+		ExpectedRemainingCount int
+	}{
+		{
+			TestName:               "User token not allowed",
+			UserID:                 "1",
+			Token:                  "user-token",
+			ExpectedStatusCode:     http.StatusForbidden,
+			ExpectedRemainingCount: 3,
+		},
+		{
+			TestName:               "User ID invalid",
+			UserID:                 "abc",
+			Token:                  "service-secret",
+			ExpectedStatusCode:     http.StatusBadRequest,
+			ExpectedRemainingCount: 3,
+		},
+		{
+			TestName:               "Delete products of user",
+			UserID:                 "1",
+			Token:                  "service-secret",
+			ExpectedStatusCode:     http.StatusOK,
+			ExpectedDeleted:        2,
+			ExpectedRemainingCount: 1,
+		},
+		{
+			TestName:               "Delete again",
+			UserID:                 "1",
+			Token:                  "service-secret",
+			ExpectedStatusCode:     http.StatusOK,
+			ExpectedDeleted:        0,
+			ExpectedRemainingCount: 1,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		req, _ := http.NewRequest("DELETE",
+			"/api/products/user/"+test.UserID+"/", nil)
+		req.Header.Set("Authorization", "Bearer "+test.Token)
+		response, err := a.FiberApp.Test(req)
+		if err != nil {
+			t.Fatalf("[%s] There's an error serve http testing => %s",
+				test.TestName, err.Error())
+		}
+		defer response.Body.Close()
+
+		if response.StatusCode != test.ExpectedStatusCode {
+			t.Errorf("[%s] Expected status %d got %d", test.TestName,
+				test.ExpectedStatusCode, response.StatusCode)
+		}
+		if len(repo.products) != test.ExpectedRemainingCount {
+			t.Errorf("[%s] Expected %d remaining products, but got %d",
+				test.TestName, test.ExpectedRemainingCount,
+				len(repo.products))
+		}
+		if response.StatusCode != http.StatusOK {
+			continue
+		}
+
+		body := struct {
+			DeletedProducts int `json:"deleted_products"`
+		}{}
+		err = json.NewDecoder(response.Body).Decode(&body)
+		if err != nil {
+			t.Fatalf("[%s] There's an error when decoding response => %s",
+				test.TestName, err.Error())
+		}
+		if body.DeletedProducts != test.ExpectedDeleted {
+			t.Errorf("[%s] Expected %d deleted products, but got %d",
+				test.TestName, test.ExpectedDeleted, body.DeletedProducts)
+		}
+	}
+
+	// shop of the deleted user and its banner file removed
+	if _, ok := repo.shops[1]; ok || len(repo.shops) != 1 {
+		t.Errorf("Expected only shop of user 2 remaining, but got %+v",
+			repo.shops)
+	}
+	_, err = os.Stat(filepath.Join(config.MediaRoot, bannerPath))
+	if !os.IsNotExist(err) {
+		t.Errorf("Expected shop banner file removed, but got %v", err)
+	}
+}
+
+// TestTransferProductsHandler test TransferProductsHandler
+func TestTransferProductsHandler(t *testing.T) {
+	repo := userProductsRepository{fakeRepository: fakeRepository{
+		products: map[string]model.Product{
+			"SKU-A": {ProductInfo: model.ProductInfo{SKU: "SKU-A", UserID: 1}},
+			"SKU-B": {ProductInfo: model.ProductInfo{SKU: "SKU-B", UserID: 1}},
+			"SKU-C": {ProductInfo: model.ProductInfo{SKU: "SKU-C", UserID: 2}},
+		},
+	}}
+	a := API{Repo: repo, FiberApp: fiber.New()}
+	a.FiberApp.Put("/api/admin/products/transfer/",
+		func(c *fiber.Ctx) error {
+			c.Locals("user", middleware.User{ID: 9, Role: c.Query("role")})
+			return c.Next()
+		},
+		a.TransferProductsHandler)
+
+	// create testing table
+	testTable := []struct {
+		TestName           string
+		Role               string
+		Body               string
+		ExpectedStatusCode int
+		ExpectedTransfers  int
+		ExpectedOwners     map[string]int
+	}{
+		{
+			TestName:           "Not admin",
+			Role:               "seller",
+			Body:               `{"from_user_id": 1, "to_user_id": 3}`,
+			ExpectedStatusCode: http.StatusForbidden,
+			ExpectedOwners:     map[string]int{"SKU-A": 1, "SKU-B": 1},
+		},
+		{
+			TestName:           "Same user",
+			Role:               "admin",
+			Body:               `{"from_user_id": 1, "to_user_id": 1}`,
+			ExpectedStatusCode: http.StatusBadRequest,
+			ExpectedOwners:     map[string]int{"SKU-A": 1, "SKU-B": 1},
+		},
+		{
+			TestName: "Transfer a product",
+			Role:     "admin",
+			Body: `{"sku": "SKU-A", "from_user_id": 1, ` +
+				`"to_user_id": 3}`,
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedTransfers:  1,
+			ExpectedOwners:     map[string]int{"SKU-A": 3, "SKU-B": 1},
+		},
+		{
+			TestName:           "Transfer the rest catalog",
+			Role:               "admin",
+			Body:               `{"from_user_id": 1, "to_user_id": 3}`,
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedTransfers:  1,
+			ExpectedOwners:     map[string]int{"SKU-A": 3, "SKU-B": 3},
+		},
+		{
+			TestName:           "No product of user",
+			Role:               "admin",
+			Body:               `{"from_user_id": 1, "to_user_id": 3}`,
+			ExpectedStatusCode: http.StatusNotFound,
+			ExpectedOwners:     map[string]int{"SKU-A": 3, "SKU-B": 3},
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		req, _ := http.NewRequest("PUT",
+			"/api/admin/products/transfer/?role="+test.Role,
+			strings.NewReader(test.Body))
+		response, err := a.FiberApp.Test(req)
+		if err != nil {
+			t.Fatalf("[%s] There's an error serve http testing => %s",
+				test.TestName, err.Error())
+		}
+		defer response.Body.Close()
+
+		if response.StatusCode != test.ExpectedStatusCode {
+			t.Errorf("[%s] Expected status %d got %d", test.TestName,
+				test.ExpectedStatusCode, response.StatusCode)
+		}
+		for SKU, userID := range test.ExpectedOwners {
+			if repo.products[SKU].ProductInfo.UserID != userID {
+				t.Errorf("[%s] Expected %s owned by user %d, but got %d",
+					test.TestName, SKU, userID,
+					repo.products[SKU].ProductInfo.UserID)
+			}
+		}
+		if response.StatusCode != http.StatusOK {
+			continue
+		}
+
+		body := struct {
+			Transfers []model.OwnershipTransfer `json:"transfers"`
+		}{}
+		err = json.NewDecoder(response.Body).Decode(&body)
+		if err != nil {
+			t.Fatalf("[%s] There's an error when decoding response => %s",
+				test.TestName, err.Error())
+		}
+		if len(body.Transfers) != test.ExpectedTransfers ||
+			body.Transfers[0].AdminUserID != 9 {
+			t.Errorf("[%s] Expected %d transfers by admin 9, but got %+v",
+				test.TestName, test.ExpectedTransfers, body.Transfers)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/event"
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/permission"
+)
+
+// GetProductVersionsHandler handling route get product info versions
+// by SKU (method: GET, user: seller owning the product)
+func (a *API) GetProductVersionsHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// check user role is allowed to access this API
+	if !a.isAllowed(u, permission.ProductReadOwn) {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this API",
+		})
+	}
+
+	// check product exist and owned by the seller
+	SKU := c.Params("sku")
+	status, err := a.checkProductOwner(c.UserContext(), SKU, u.ID)
+	if err != nil {
+		return c.Status(status).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	// get product versions from database
+	versions, err := a.Repo.GetProductVersionsBySKU(c.UserContext(), SKU)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": fmt.Sprintf(
+				"There's an error when getting the product versions => %s",
+				err.Error()),
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(versions)
+}
+
+// RollbackProductHandler handling route roll back product info to
+// a previous version by SKU (method: PUT, user: seller owning the product)
+func (a *API) RollbackProductHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// check user role is allowed to access this API
+	if !a.isAllowed(u, permission.ProductUpdate) {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this API",
+		})
+	}
+
+	// get version from url
+	version, err := strconv.Atoi(c.Query("version"))
+	if err != nil || version <= 0 {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": "parameter 'version' empty/invalid, " +
+				"must be positive integer",
+		})
+	}
+
+	// check product exist and owned by the seller
+	SKU := c.Params("sku")
+	status, err := a.checkProductOwner(c.UserContext(), SKU, u.ID)
+	if err != nil {
+		return c.Status(status).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	// roll back product info in database
+	pInfo, err := a.Repo.RollbackProductInfoBySKU(c.UserContext(), SKU,
+		version)
+	if err == sql.ErrNoRows {
+		return c.Status(http.StatusNotFound).JSON(map[string]string{
+			"message": "product version not found",
+		})
+	} else if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	a.PublishEvent(event.NewEvent(event.ProductUpdated, pInfo.SKU,
+		pInfo.UserID, pInfo))
+
+	return c.Status(http.StatusOK).JSON(pInfo)
+}
+
+// checkProductOwner check product by SKU exist and owned by user ID,
+// returning status code of the failed check
+func (a *API) checkProductOwner(ctx context.Context, SKU string,
+	userID int) (int, error) {
+	p, err := a.Repo.GetProductBySKU(ctx, SKU)
+	if err == sql.ErrNoRows {
+		return http.StatusNotFound, fmt.Errorf("product not found")
+	} else if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	if p.ProductInfo.UserID != userID {
+		return http.StatusForbidden,
+			fmt.Errorf("user doesn't have authority to access this product")
+	}
+
+	return http.StatusOK, nil
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/config"
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+)
+
+// RecordProductViewHandler handling route record a view of product by SKU,
+// counted at most once a view window for each user (method: POST, user: any)
+func (a *API) RecordProductViewHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// get SKU from url
+	SKU := c.Query("sku")
+	if strings.TrimSpace(SKU) == "" {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": "parameter 'sku' empty/not found",
+		})
+	}
+
+	// record product view in database
+	_, err := a.Repo.RecordProductView(c.UserContext(), SKU,
+		fmt.Sprintf("user:%d", u.ID), config.ProductViewWindow)
+	if err == sql.ErrNoRows {
+		return c.Status(http.StatusNotFound).JSON(map[string]string{
+			"message": "product not found",
+		})
+	} else if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	return c.SendStatus(http.StatusAccepted)
+}
@@ -0,0 +1,123 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/config"
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+)
+
+// viewRepository product repository in memory counting product views,
+// views within the window are counted once by ignoring the time
+type viewRepository struct {
+	fakeRepository
+	viewers map[string]bool
+	counts  map[string]int
+}
+
+// RecordProductView count view of product in memory once each viewer
+func (r viewRepository) RecordProductView(ctx context.Context, SKU string,
+	viewerKey string, window time.Duration) (bool, error) {
+	if _, ok := r.products[SKU]; !ok {
+		return false, sql.ErrNoRows
+	}
+	// copy SKU since fiber reuses the request buffer it's referring to
+	SKU = string([]byte(SKU))
+	if r.viewers[SKU+"/"+viewerKey] {
+		return false, nil
+	}
+
+	r.viewers[SKU+"/"+viewerKey] = true
+	r.counts[SKU]++
+	return true, nil
+}
+
+// TestRecordProductViewHandler test RecordProductViewHandler
+func TestRecordProductViewHandler(t *testing.T) {
+	config.ProductViewWindow = 30 * time.Minute
+	repo := viewRepository{
+		fakeRepository: fakeRepository{products: map[string]model.Product{
+			"SKU-A": {ProductInfo: model.ProductInfo{SKU: "SKU-A"}},
+		}},
+		viewers: map[string]bool{},
+		counts:  map[string]int{},
+	}
+	a := API{Repo: repo, FiberApp: fiber.New()}
+	a.FiberApp.Post("/api/product/view/",
+		func(c *fiber.Ctx) error {
+			userID, _ := strconv.Atoi(c.Query("user_id"))
+			c.Locals("user", middleware.User{ID: userID, Role: "buyer"})
+			return c.Next()
+		},
+		a.RecordProductViewHandler)
+
+	// create testing table
+	testTable := []struct {
+		TestName           string
+		Query              string
+		ExpectedStatusCode int
+		ExpectedCount      int
+	}{
+		{
+			TestName:           "First view of user",
+			Query:              "sku=SKU-A&user_id=2",
+			ExpectedStatusCode: http.StatusAccepted,
+			ExpectedCount:      1,
+		},
+		{
+			TestName:           "Repeated view of user",
+			Query:              "sku=SKU-A&user_id=2",
+			ExpectedStatusCode: http.StatusAccepted,
+			ExpectedCount:      1,
+		},
+		{
+			TestName:           "View of another user",
+			Query:              "sku=SKU-A&user_id=3",
+			ExpectedStatusCode: http.StatusAccepted,
+			ExpectedCount:      2,
+		},
+		{
+			TestName:           "Product not found",
+			Query:              "sku=SKU-B&user_id=2",
+			ExpectedStatusCode: http.StatusNotFound,
+			ExpectedCount:      2,
+		},
+		{
+			TestName:           "SKU empty",
+			Query:              "user_id=2",
+			ExpectedStatusCode: http.StatusBadRequest,
+			ExpectedCount:      2,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		req, _ := http.NewRequest("POST", "/api/product/view/?"+test.Query,
+			nil)
+		response, err := a.FiberApp.Test(req)
+		if err != nil {
+			t.Fatalf("[%s] There's an error serve http testing => %s",
+				test.TestName, err.Error())
+		}
+		response.Body.Close()
+
+		if response.StatusCode != test.ExpectedStatusCode {
+			t.Errorf("[%s] Expected status %d got %d", test.TestName,
+				test.ExpectedStatusCode, response.StatusCode)
+		}
+		if repo.counts["SKU-A"] != test.ExpectedCount {
+			t.Errorf("[%s] Expected view count %d got %d", test.TestName,
+				test.ExpectedCount, repo.counts["SKU-A"])
+		}
+	}
+}
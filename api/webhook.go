@@ -0,0 +1,152 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+	"github.com/reyhanfikridz/ecom-product-service/internal/permission"
+	"github.com/reyhanfikridz/ecom-product-service/internal/utils"
+	"github.com/reyhanfikridz/ecom-product-service/internal/validator"
+)
+
+// AddWebhookSubscriptionHandler handling route add webhook subscription
+// (method: POST, user: seller)
+func (a *API) AddWebhookSubscriptionHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// check user role is allowed to access this API
+	if !a.isAllowed(u, permission.WebhookManage) {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this API",
+		})
+	}
+
+	// parse webhook subscription from body
+	sub := model.WebhookSubscription{}
+	err := c.BodyParser(&sub)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	// validate webhook subscription data
+	err = validator.IsWebhookSubscriptionValid(sub)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	// generate signing secret, only shown once in this response
+	sub.Secret, err = utils.GetRandomSecret(32)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	// insert webhook subscription into database
+	sub.UserID = u.ID
+	sub, err = model.InsertWebhookSubscription(c.UserContext(), a.DB, sub)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(http.StatusCreated).JSON(sub)
+}
+
+// GetWebhookSubscriptionsHandler handling route get webhook subscriptions
+// (method: GET, user: seller)
+func (a *API) GetWebhookSubscriptionsHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// check user role is allowed to access this API
+	if !a.isAllowed(u, permission.WebhookManage) {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this API",
+		})
+	}
+
+	// get webhook subscriptions from database
+	subs, err := model.GetWebhookSubscriptions(c.UserContext(), a.DB, u.ID, "")
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": fmt.Sprintf(
+				"There's an error when getting the webhook subscriptions => %s",
+				err.Error()),
+		})
+	}
+
+	// hide signing secrets
+	for i := range subs {
+		subs[i].Secret = ""
+	}
+
+	return c.Status(http.StatusOK).JSON(subs)
+}
+
+// DeleteWebhookSubscriptionHandler handling route delete webhook subscription
+// (method: DELETE, user: seller)
+func (a *API) DeleteWebhookSubscriptionHandler(c *fiber.Ctx) error {
+	// get user data
+	tmpU := c.Locals("user")
+	u, ok := tmpU.(middleware.User)
+	if !ok {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": "user data invalid",
+		})
+	}
+
+	// check user role is allowed to access this API
+	if !a.isAllowed(u, permission.WebhookManage) {
+		return c.Status(http.StatusForbidden).JSON(map[string]string{
+			"message": "user doesn't have authority to access this API",
+		})
+	}
+
+	// get ID from url
+	ID, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(map[string]string{
+			"message": "parameter 'id' empty/invalid",
+		})
+	}
+
+	// delete webhook subscription in database
+	err = model.DeleteWebhookSubscription(c.UserContext(), a.DB, ID, u.ID)
+	if err == sql.ErrNoRows {
+		return c.Status(http.StatusNotFound).JSON(map[string]string{
+			"message": "webhook subscription not found",
+		})
+	} else if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(map[string]string{
+		"message": "Delete webhook subscription success!",
+	})
+}
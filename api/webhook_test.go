@@ -0,0 +1,115 @@
+/*
+Package api containing API initialization and API route handler
+*/
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"testing"
+
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+)
+
+// TestWebhookSubscriptionHandlers test AddWebhookSubscriptionHandler,
+// GetWebhookSubscriptionsHandler, and DeleteWebhookSubscriptionHandler
+func TestWebhookSubscriptionHandlers(t *testing.T) {
+	seller := middleware.User{ID: 1, Role: "seller"}
+	a, err := GetTestingAPI(seller)
+	if err != nil {
+		t.Errorf("There's an error when getting testing API => %s",
+			err.Error())
+	}
+
+	// add webhook subscription
+	body, _ := json.Marshal(map[string]interface{}{
+		"url":    "https://example.com/hook",
+		"events": []string{"product.created", "stock.low"},
+	})
+	req, _ := http.NewRequest("POST", "/api/webhooks/", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	response, err := a.FiberApp.Test(req)
+	if err != nil {
+		t.Errorf("There's an error serve http testing => %s", err.Error())
+	}
+	defer response.Body.Close()
+
+	sub := model.WebhookSubscription{}
+	if response.StatusCode != http.StatusCreated {
+		t.Errorf("Expected status %d got %d",
+			http.StatusCreated, response.StatusCode)
+	} else {
+		err = json.NewDecoder(response.Body).Decode(&sub)
+		if err != nil {
+			t.Errorf("There's an error when unmarshal body response => %s",
+				err.Error())
+		}
+		if sub.ID == 0 || sub.Secret == "" {
+			t.Errorf("Expected ID and secret not empty, but got %v", sub)
+		}
+	}
+
+	// get webhook subscriptions
+	req, _ = http.NewRequest("GET", "/api/webhooks/", nil)
+	response, err = a.FiberApp.Test(req)
+	if err != nil {
+		t.Errorf("There's an error serve http testing => %s", err.Error())
+	}
+	defer response.Body.Close()
+
+	subs := []model.WebhookSubscription{}
+	err = json.NewDecoder(response.Body).Decode(&subs)
+	if err != nil {
+		t.Errorf("There's an error when unmarshal body response => %s",
+			err.Error())
+	}
+	if len(subs) != 1 || subs[0].Secret != "" {
+		t.Errorf("Expected one subscription without secret, but got %v", subs)
+	}
+
+	// delete webhook subscription twice
+	for _, expectedStatus := range []int{http.StatusOK, http.StatusNotFound} {
+		req, _ = http.NewRequest("DELETE",
+			fmt.Sprintf("/api/webhooks/?id=%d", sub.ID), nil)
+		response, err = a.FiberApp.Test(req)
+		if err != nil {
+			t.Errorf("There's an error serve http testing => %s", err.Error())
+		}
+		defer response.Body.Close()
+
+		if response.StatusCode != expectedStatus {
+			t.Errorf("Expected status %d got %d",
+				expectedStatus, response.StatusCode)
+		}
+	}
+
+	// get webhook subscriptions as buyer
+	a, err = GetTestingAPI(middleware.User{ID: 2, Role: "buyer"})
+	if err != nil {
+		t.Errorf("There's an error when getting testing API => %s",
+			err.Error())
+	}
+	req, _ = http.NewRequest("GET", "/api/webhooks/", nil)
+	response, err = a.FiberApp.Test(req)
+	if err != nil {
+		t.Errorf("There's an error serve http testing => %s", err.Error())
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status %d got %d",
+			http.StatusForbidden, response.StatusCode)
+	}
+
+	// truncate tables after test
+	_, err = a.DB.Exec("TRUNCATE product_webhooksubscription RESTART IDENTITY")
+	if err != nil {
+		log.Fatalf("There's an error when truncating "+
+			"table product_webhooksubscription => %s",
+			err.Error())
+	}
+}
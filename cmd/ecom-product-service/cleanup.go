@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-product-service/internal/config"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+)
+
+// RunCleanupMedia run command cleanup-media, removing product image files
+// in media folder that no product image in database refers to,
+// then print the removed files into out
+//
+// usage: cleanup-media [-dry-run] [-min-age duration]
+func RunCleanupMedia(args []string, out io.Writer) int {
+	flags := flag.NewFlagSet("cleanup-media", flag.ContinueOnError)
+	flags.SetOutput(out)
+	dryRun := flags.Bool("dry-run", false, "only print files to be removed")
+	minAge := flags.Duration("min-age", time.Hour,
+		"minimum age of removed files, so images of in-progress uploads "+
+			"are kept")
+	err := flags.Parse(args)
+	if err != nil {
+		return 2
+	}
+
+	// connect to database
+	DB, err := openDB()
+	if err != nil {
+		fmt.Fprintln(out, err.Error())
+		return 1
+	}
+	defer DB.Close()
+
+	// remove unused image files
+	removed, err := CleanupMedia(context.Background(), DB, *minAge, *dryRun)
+	for _, imagePath := range removed {
+		fmt.Fprintf(out, "removed %s\n", imagePath)
+	}
+	if err != nil {
+		fmt.Fprintln(out, err.Error())
+		return 1
+	}
+	fmt.Fprintf(out, "%d unused media files\n", len(removed))
+
+	return 0
+}
+
+// CleanupMedia remove product image files in media folder older than
+// minimum age that no product image in database refers to, only list
+// them if dry run, then get paths of the removed files
+func CleanupMedia(ctx context.Context, DB *sql.DB, minAge time.Duration,
+	dryRun bool) ([]string, error) {
+	removed := []string{}
+
+	// get image paths still used
+	used, err := model.GetProductImagePaths(ctx, DB)
+	if err != nil {
+		return removed, fmt.Errorf("There's an error when getting "+
+			"product images => %s", err.Error())
+	}
+
+	// get and remove unused image files
+	mediaDir := config.MediaRoot
+	unused, err := GetUnusedMediaFiles(mediaDir, used,
+		time.Now().Add(-minAge))
+	if err != nil {
+		return removed, fmt.Errorf("There's an error when listing "+
+			"media files => %s", err.Error())
+	}
+
+	for _, imagePath := range unused {
+		if !dryRun {
+			err = model.RemoveProductImageFile(imagePath)
+			if err != nil {
+				return removed, fmt.Errorf("There's an error when "+
+					"removing %s => %s", imagePath, err.Error())
+			}
+		}
+		removed = append(removed, imagePath)
+	}
+
+	return removed, nil
+}
+
+// GetUnusedMediaFiles get paths of product image files in media folder
+// relative to it, which are not in used paths and modified before time
+func GetUnusedMediaFiles(mediaDir string, used map[string]bool,
+	before time.Time) ([]string, error) {
+	unused := []string{}
+
+	root := filepath.Join(mediaDir, "product-image")
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry,
+		err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if !info.ModTime().Before(before) {
+			return nil
+		}
+
+		imagePath, err := filepath.Rel(mediaDir, path)
+		if err != nil {
+			return err
+		}
+		imagePath = filepath.ToSlash(imagePath)
+		if !used[imagePath] {
+			unused = append(unused, imagePath)
+		}
+
+		return nil
+	})
+
+	return unused, err
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	_ "github.com/lib/pq"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/reyhanfikridz/ecom-product-service/internal/cache"
+	"github.com/reyhanfikridz/ecom-product-service/internal/config"
+	"github.com/reyhanfikridz/ecom-product-service/internal/migration"
+)
+
+// doctor check status
+const (
+	CheckStatusOK   = "OK"
+	CheckStatusFail = "FAIL"
+	CheckStatusSkip = "SKIP"
+)
+
+// DoctorCheck contain result of one doctor self-check
+type DoctorCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail"`
+}
+
+// RunDoctor run command doctor, verifying service configuration
+// and its dependencies, then print the report into out
+//
+// return exit code 1 if there's any failed check
+func RunDoctor(args []string, out io.Writer) int {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	fs.SetOutput(out)
+	asJSON := fs.Bool("json", false, "print report as JSON")
+	err := fs.Parse(args)
+	if err != nil {
+		return 2
+	}
+
+	checks := RunDoctorChecks()
+
+	// print report
+	if *asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		enc.Encode(checks)
+	} else {
+		for _, check := range checks {
+			fmt.Fprintf(out, "[%-4s] %-16s %s\n",
+				check.Status, check.Name, check.Detail)
+		}
+	}
+
+	for _, check := range checks {
+		if check.Status == CheckStatusFail {
+			return 1
+		}
+	}
+	return 0
+}
+
+// RunDoctorChecks run all doctor self-checks
+func RunDoctorChecks() []DoctorCheck {
+	checks := []DoctorCheck{}
+
+	// load config first, other checks depend on it
+	err := config.InitConfig()
+	if err != nil {
+		return append(checks, DoctorCheck{
+			Name:   "config",
+			Status: CheckStatusFail,
+			Detail: err.Error(),
+		})
+	}
+	checks = append(checks, checkConfig())
+
+	// check database connectivity and schema
+	DB, dbCheck := checkDatabase(
+		config.GetDBConnString(config.GetDBConfig(config.DBName)))
+	checks = append(checks, dbCheck)
+	if DB != nil {
+		defer DB.Close()
+		checks = append(checks, checkSchema(DB))
+	} else {
+		checks = append(checks, DoctorCheck{
+			Name:   "schema",
+			Status: CheckStatusSkip,
+			Detail: "database unreachable",
+		})
+	}
+
+	checks = append(checks,
+		checkReplica(config.DBReplicaDSN),
+		checkMediaWritable(config.MediaRoot),
+		checkAccountService(config.AccountServiceURL),
+		checkBroker(config.BrokerURL),
+		checkCache(config.RedisURL),
+	)
+
+	return checks
+}
+
+// checkConfig check all required config variable is set
+// and all config variable valid
+func checkConfig() DoctorCheck {
+	err := config.Validate()
+	if err != nil {
+		return DoctorCheck{
+			Name:   "config",
+			Status: CheckStatusFail,
+			Detail: err.Error(),
+		}
+	}
+
+	return DoctorCheck{
+		Name:   "config",
+		Status: CheckStatusOK,
+		Detail: "all required settings present",
+	}
+}
+
+// checkDatabase check database connectivity,
+// returning the connection if it's reachable
+func checkDatabase(connString string) (*sql.DB, DoctorCheck) {
+	check := DoctorCheck{Name: "database"}
+
+	DB, err := sql.Open("postgres", connString)
+	if err == nil {
+		err = DB.Ping()
+	}
+	if err != nil {
+		if DB != nil {
+			DB.Close()
+		}
+		check.Status = CheckStatusFail
+		check.Detail = err.Error()
+		return nil, check
+	}
+
+	check.Status = CheckStatusOK
+	check.Detail = "connected"
+	return DB, check
+}
+
+// checkReplica check database read replica connectivity
+func checkReplica(replicaDSN string) DoctorCheck {
+	if replicaDSN == "" {
+		return DoctorCheck{
+			Name:   "replica",
+			Status: CheckStatusSkip,
+			Detail: "no read replica configured",
+		}
+	}
+
+	DB, check := checkDatabase(replicaDSN)
+	if DB != nil {
+		DB.Close()
+	}
+	check.Name = "replica"
+
+	return check
+}
+
+// checkSchema check all schema migrations are applied
+func checkSchema(DB *sql.DB) DoctorCheck {
+	check := DoctorCheck{Name: "schema"}
+
+	statuses, err := migration.Status(context.Background(), DB)
+	if err != nil {
+		check.Status = CheckStatusFail
+		check.Detail = err.Error()
+		return check
+	}
+
+	pending := []string{}
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			pending = append(pending,
+				fmt.Sprintf("%04d_%s", status.Version, status.Name))
+		}
+	}
+
+	if len(pending) > 0 {
+		check.Status = CheckStatusFail
+		check.Detail = "pending migration " + strings.Join(pending, ", ") +
+			", run command migrate up"
+		return check
+	}
+
+	check.Status = CheckStatusOK
+	check.Detail = fmt.Sprintf("%d migrations applied", len(statuses))
+	return check
+}
+
+// checkMediaWritable check media folder is writable
+// by creating and removing a probe file in it
+func checkMediaWritable(dir string) DoctorCheck {
+	check := DoctorCheck{Name: "storage"}
+
+	err := config.CheckMediaRoot(dir)
+	if err != nil {
+		check.Status = CheckStatusFail
+		check.Detail = err.Error()
+		return check
+	}
+
+	check.Status = CheckStatusOK
+	check.Detail = dir + " writable"
+	return check
+}
+
+// checkAccountService check account service is reachable,
+// any HTTP response is considered reachable
+func checkAccountService(URL string) DoctorCheck {
+	check := DoctorCheck{Name: "account-service"}
+
+	client := http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get(URL)
+	if err != nil {
+		check.Status = CheckStatusFail
+		check.Detail = err.Error()
+		return check
+	}
+	resp.Body.Close()
+
+	check.Status = CheckStatusOK
+	check.Detail = fmt.Sprintf("%s responded %d", URL, resp.StatusCode)
+	return check
+}
+
+// checkBroker check message broker connectivity
+func checkBroker(brokerURL string) DoctorCheck {
+	check := DoctorCheck{Name: "broker"}
+
+	if brokerURL == "" {
+		check.Status = CheckStatusSkip
+		check.Detail = "no message broker configured"
+		return check
+	}
+
+	conn, err := amqp.Dial(brokerURL)
+	if err != nil {
+		check.Status = CheckStatusFail
+		check.Detail = err.Error()
+		return check
+	}
+	conn.Close()
+
+	check.Status = CheckStatusOK
+	check.Detail = "connected"
+	return check
+}
+
+// checkCache check product cache connectivity
+func checkCache(redisURL string) DoctorCheck {
+	check := DoctorCheck{Name: "cache"}
+
+	if redisURL == "" {
+		check.Status = CheckStatusSkip
+		check.Detail = "no cache server configured"
+		return check
+	}
+
+	productCache, err := cache.NewRedisCache(redisURL, time.Minute)
+	if err != nil {
+		check.Status = CheckStatusFail
+		check.Detail = err.Error()
+		return check
+	}
+	productCache.Close()
+
+	check.Status = CheckStatusOK
+	check.Detail = "connected"
+	return check
+}
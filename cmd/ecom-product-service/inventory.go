@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-product-service/api"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+)
+
+// RunInventoryReport run command inventory-report, writing inventory
+// snapshot with stock value of each seller as CSV file into a directory,
+// meant to be scheduled (e.g. by cron) for accounting, then print
+// the written files into out
+//
+// usage: inventory-report [-dir path] [-at timestamp] [-seller id]
+func RunInventoryReport(args []string, out io.Writer) int {
+	flags := flag.NewFlagSet("inventory-report", flag.ContinueOnError)
+	flags.SetOutput(out)
+	dir := flags.String("dir", ".", "directory the CSV files written into")
+	rawAt := flags.String("at", "",
+		"RFC3339 timestamp of the snapshot, default now")
+	sellerID := flags.Int("seller", 0, "only report seller of ID")
+	err := flags.Parse(args)
+	if err != nil {
+		return 2
+	}
+
+	at := time.Now()
+	if *rawAt != "" {
+		at, err = time.Parse(time.RFC3339, *rawAt)
+		if err != nil {
+			fmt.Fprintln(out, "flag -at invalid, must be RFC3339 timestamp")
+			return 2
+		}
+	}
+
+	// connect to database
+	DB, err := openDB()
+	if err != nil {
+		fmt.Fprintln(out, err.Error())
+		return 1
+	}
+	defer DB.Close()
+
+	// write inventory report file of each seller
+	paths, err := ReportInventory(context.Background(), DB, *dir,
+		*sellerID, at)
+	for _, path := range paths {
+		fmt.Fprintf(out, "wrote %s\n", path)
+	}
+	if err != nil {
+		fmt.Fprintln(out, err.Error())
+		return 1
+	}
+	fmt.Fprintf(out, "%d inventory reports\n", len(paths))
+
+	return 0
+}
+
+// ReportInventory write inventory snapshot at instant of each seller,
+// or only seller of sellerID if it's not zero, as CSV file into dir,
+// then get paths of the written files
+func ReportInventory(ctx context.Context, DB *sql.DB, dir string,
+	sellerID int, at time.Time) ([]string, error) {
+	// get inventory snapshot from database
+	items, err := model.GetInventorySnapshot(ctx, DB, sellerID, at)
+	if err != nil {
+		return []string{}, fmt.Errorf("There's an error when getting "+
+			"the inventory snapshot => %s", err.Error())
+	}
+
+	paths, err := WriteInventoryReports(dir, items, at)
+	if err != nil {
+		return paths, fmt.Errorf("There's an error when writing "+
+			"inventory report => %s", err.Error())
+	}
+
+	return paths, nil
+}
+
+// WriteInventoryReports write inventory snapshot items at instant
+// as CSV file of each seller into dir, items of a seller must be
+// consecutive, then get paths of the written files
+func WriteInventoryReports(dir string, items []model.InventorySnapshotItem,
+	at time.Time) ([]string, error) {
+	paths := []string{}
+
+	err := os.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return paths, err
+	}
+
+	for start := 0; start < len(items); {
+		end := start + 1
+		for end < len(items) && items[end].UserID == items[start].UserID {
+			end++
+		}
+
+		path := filepath.Join(dir,
+			api.InventorySnapshotFileName(items[start].UserID, at))
+		err = writeInventoryReport(path, items[start:end])
+		if err != nil {
+			return paths, err
+		}
+		paths = append(paths, path)
+
+		start = end
+	}
+
+	return paths, nil
+}
+
+// writeInventoryReport write inventory snapshot items as CSV file at path
+func writeInventoryReport(path string,
+	items []model.InventorySnapshotItem) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	err = api.WriteInventorySnapshotCSV(f, items)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
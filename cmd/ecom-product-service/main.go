@@ -4,47 +4,98 @@ Package main the executeable file
 package main
 
 import (
-	"log"
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"os"
 
-	"github.com/reyhanfikridz/ecom-product-service/api"
+	_ "github.com/lib/pq"
 	"github.com/reyhanfikridz/ecom-product-service/internal/config"
 )
 
+// command contain a subcommand of the binary
+type command struct {
+	Name        string
+	Description string
+	Run         func(args []string, out io.Writer) int
+}
+
+// commands contain all subcommands of the binary
+var commands = []command{
+	{"serve", "start the HTTP server (default)", RunServe},
+	{"migrate", "apply, revert, or list schema migrations", RunMigrate},
+	{"seed", "create sample products of a seller", RunSeed},
+	{"cleanup-media", "remove product images no longer used", RunCleanupMedia},
+	{"reindex", "rebuild database and search indexes of products", RunReindex},
+	{"inventory-report", "write inventory value report of each seller",
+		RunInventoryReport},
+	{"doctor", "check configuration and dependencies", RunDoctor},
+}
+
 // main
 func main() {
-	// init API
-	a, err := InitAPI()
-	if err != nil {
-		log.Fatal(err)
+	os.Exit(Run(os.Args[1:], os.Stdout))
+}
+
+// Run parse global flags then run subcommand named by the first argument
+// with the rest arguments, command serve is run if no subcommand given
+func Run(args []string, out io.Writer) int {
+	fs := flag.NewFlagSet("ecom-product-service", flag.ContinueOnError)
+	fs.SetOutput(out)
+	fs.Usage = func() { printUsage(out) }
+	fs.StringVar(&config.File, "config", "",
+		"config file of environment variables, "+
+			"default ECOM_PRODUCT_SERVICE_CONFIG_FILE or .env")
+	err := fs.Parse(args)
+	if err == flag.ErrHelp {
+		return 0
+	} else if err != nil {
+		return 2
 	}
 
-	// serve server
-	log.Fatal(a.FiberApp.Listen(":8020"))
-}
+	args = fs.Args()
+	if len(args) == 0 {
+		return RunServe(args, out)
+	}
 
-// InitAPI initialize API
-func InitAPI() (api.API, error) {
-	a := api.API{}
+	for _, cmd := range commands {
+		if cmd.Name == args[0] {
+			return cmd.Run(args[1:], out)
+		}
+	}
 
-	// init all config before can be used
-	err := config.InitConfig()
-	if err != nil {
-		return a, err
+	if args[0] != "help" {
+		fmt.Fprintf(out, "unknown command '%s'\n\n", args[0])
+		printUsage(out)
+		return 2
 	}
 
-	// init database
-	DBConfig := map[string]string{
-		"user":     config.DBUsername,
-		"password": config.DBPassword,
-		"dbname":   config.DBName,
+	printUsage(out)
+	return 0
+}
+
+// printUsage print usage of the binary and its subcommands into out
+func printUsage(out io.Writer) {
+	fmt.Fprintln(out, "usage: ecom-product-service [-config file] [command] [flags]")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-16s %s\n", cmd.Name, cmd.Description)
 	}
-	err = a.InitDB(DBConfig)
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "run 'ecom-product-service [command] -h' for command flags")
+}
+
+// openDB initialize config and open database connection
+// used by maintenance commands
+func openDB() (*sql.DB, error) {
+	err := config.InitConfig()
 	if err != nil {
-		return a, err
+		return nil, fmt.Errorf("There's an error when initialize config => %s",
+			err.Error())
 	}
 
-	// init router
-	a.InitRouter()
-
-	return a, nil
+	return sql.Open("postgres",
+		config.GetDBConnString(config.GetDBConfig(config.DBName)))
 }
@@ -3,7 +3,21 @@ Package main the executeable file
 */
 package main
 
-import "testing"
+import (
+	"image/png"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+	"github.com/reyhanfikridz/ecom-product-service/internal/validator"
+)
 
 // TestInitAPI test InitAPI
 func TestInitAPI(t *testing.T) {
@@ -12,3 +26,233 @@ func TestInitAPI(t *testing.T) {
 		t.Errorf("There's an error when initialize API => " + err.Error())
 	}
 }
+
+// TestCheckMediaWritable test checkMediaWritable
+func TestCheckMediaWritable(t *testing.T) {
+	check := checkMediaWritable(t.TempDir())
+	if check.Status != CheckStatusOK {
+		t.Errorf("Expected status %s, but got %s => %s",
+			CheckStatusOK, check.Status, check.Detail)
+	}
+}
+
+// TestCheckAccountService test checkAccountService
+func TestCheckAccountService(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+	defer server.Close()
+
+	// reachable service
+	check := checkAccountService(server.URL)
+	if check.Status != CheckStatusOK {
+		t.Errorf("Expected status %s, but got %s => %s",
+			CheckStatusOK, check.Status, check.Detail)
+	}
+
+	// unreachable service
+	check = checkAccountService("http://127.0.0.1:1")
+	if check.Status != CheckStatusFail {
+		t.Errorf("Expected status %s, but got %s",
+			CheckStatusFail, check.Status)
+	}
+}
+
+// TestCheckCache test checkCache
+func TestCheckCache(t *testing.T) {
+	// not configured
+	check := checkCache("")
+	if check.Status != CheckStatusSkip {
+		t.Errorf("Expected status %s, but got %s",
+			CheckStatusSkip, check.Status)
+	}
+
+	// unreachable cache server
+	check = checkCache("redis://127.0.0.1:1/0")
+	if check.Status != CheckStatusFail {
+		t.Errorf("Expected status %s, but got %s",
+			CheckStatusFail, check.Status)
+	}
+}
+
+// TestRunMigrate test RunMigrate usage errors
+func TestRunMigrate(t *testing.T) {
+	for _, args := range [][]string{{}, {"up", "-unknown"}} {
+		code := RunMigrate(args, io.Discard)
+		if code != 2 {
+			t.Errorf("Expected exit code 2 for args %v, but got %d",
+				args, code)
+		}
+	}
+}
+
+// TestGetSeedProductInfos test GetSeedProductInfos
+func TestGetSeedProductInfos(t *testing.T) {
+	pInfos := GetSeedProductInfos(rand.New(rand.NewSource(1)), 7, 30)
+	if len(pInfos) != 30 {
+		t.Fatalf("Expected 30 product infos, but got %d", len(pInfos))
+	}
+
+	for _, pInfo := range pInfos {
+		err := validator.IsProductInfoValid(pInfo)
+		if err != nil {
+			t.Errorf("Expected seed product info valid, but got error => %s",
+				err.Error())
+		}
+		if pInfo.UserID != 7 || pInfo.Stock < 0 {
+			t.Errorf("Expected user ID 7 and non-negative stock, but got "+
+				"user ID %d stock %v", pInfo.UserID, pInfo.Stock)
+		}
+	}
+
+	// same random seed should create same products
+	again := GetSeedProductInfos(rand.New(rand.NewSource(1)), 7, 30)
+	if !reflect.DeepEqual(pInfos, again) {
+		t.Errorf("Expected same product infos from same random seed")
+	}
+}
+
+// TestGetSeedImageFileHeaders test getSeedImageFileHeaders
+func TestGetSeedImageFileHeaders(t *testing.T) {
+	fileHeaders, err := getSeedImageFileHeaders("SKU123", 0, 3)
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+	if len(fileHeaders) != 3 {
+		t.Fatalf("Expected 3 file headers, but got %d", len(fileHeaders))
+	}
+
+	file, err := fileHeaders[0].Open()
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+	defer file.Close()
+
+	_, err = png.Decode(file)
+	if err != nil {
+		t.Errorf("Expected valid PNG image, but got error => %s", err.Error())
+	}
+}
+
+// TestRunSeed test RunSeed usage errors
+func TestRunSeed(t *testing.T) {
+	for _, args := range [][]string{{}, {"-seller", "0"},
+		{"-seller", "1", "-count", "0"}, {"-unknown"}} {
+		code := RunSeed(args, io.Discard)
+		if code != 2 {
+			t.Errorf("Expected exit code 2 for args %v, but got %d",
+				args, code)
+		}
+	}
+}
+
+// TestRun test Run subcommand dispatching
+func TestRun(t *testing.T) {
+	testCases := []struct {
+		Args         []string
+		ExpectedCode int
+	}{
+		{Args: []string{"help"}, ExpectedCode: 0},
+		{Args: []string{"-h"}, ExpectedCode: 0},
+		{Args: []string{"unknown"}, ExpectedCode: 2},
+		{Args: []string{"serve", "-unknown"}, ExpectedCode: 2},
+		{Args: []string{"migrate"}, ExpectedCode: 2},
+		{Args: []string{"cleanup-media", "-unknown"}, ExpectedCode: 2},
+		{Args: []string{"reindex", "-unknown"}, ExpectedCode: 2},
+		{Args: []string{"inventory-report", "-at", "today"}, ExpectedCode: 2},
+		{Args: []string{"-unknown"}, ExpectedCode: 2},
+		{Args: []string{"-config", "missing.env", "reindex"}, ExpectedCode: 1},
+	}
+
+	for _, testCase := range testCases {
+		code := Run(testCase.Args, io.Discard)
+		if code != testCase.ExpectedCode {
+			t.Errorf("Expected exit code %d for args %v, but got %d",
+				testCase.ExpectedCode, testCase.Args, code)
+		}
+	}
+}
+
+// TestGetUnusedMediaFiles test GetUnusedMediaFiles
+func TestGetUnusedMediaFiles(t *testing.T) {
+	mediaDir := t.TempDir()
+	err := os.MkdirAll(filepath.Join(mediaDir, "product-image"), os.ModePerm)
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+	for _, name := range []string{"used.png", "unused.png"} {
+		err = os.WriteFile(filepath.Join(mediaDir, "product-image", name),
+			[]byte("image"), 0644)
+		if err != nil {
+			t.Fatalf("Expected error nil, but got error => %s", err.Error())
+		}
+	}
+	used := map[string]bool{"product-image/used.png": true}
+
+	unused, err := GetUnusedMediaFiles(mediaDir, used,
+		time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+	if !reflect.DeepEqual(unused, []string{"product-image/unused.png"}) {
+		t.Errorf("Expected unused file product-image/unused.png, but got %v",
+			unused)
+	}
+
+	// recently modified files are kept
+	unused, err = GetUnusedMediaFiles(mediaDir, used,
+		time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+	if len(unused) != 0 {
+		t.Errorf("Expected no unused file, but got %v", unused)
+	}
+
+	// missing media folder has no unused files
+	unused, err = GetUnusedMediaFiles(filepath.Join(mediaDir, "missing"),
+		used, time.Now())
+	if err != nil || len(unused) != 0 {
+		t.Errorf("Expected no unused file and error nil, but got %v, %v",
+			unused, err)
+	}
+}
+
+// TestWriteInventoryReports test WriteInventoryReports
+func TestWriteInventoryReports(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "reports")
+	at := time.Date(2024, 1, 31, 17, 0, 0, 0, time.UTC)
+	items := []model.InventorySnapshotItem{
+		{ProductID: 1, SKU: "sku-a", Name: "A", UserID: 1, Stock: 2,
+			Unit: "piece", UnitValue: 1000, TotalValue: 2000},
+		{ProductID: 2, SKU: "sku-b", Name: "B", UserID: 1, Stock: 1.5,
+			Unit: "kg", UnitValue: 250, TotalValue: 375},
+		{ProductID: 3, SKU: "sku-c", Name: "C", UserID: 2, Stock: 0,
+			Unit: "piece", UnitValue: 500, TotalValue: 0},
+	}
+
+	paths, err := WriteInventoryReports(dir, items, at)
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+	expectedPaths := []string{
+		filepath.Join(dir, "inventory-snapshot-1-20240131T170000Z.csv"),
+		filepath.Join(dir, "inventory-snapshot-2-20240131T170000Z.csv"),
+	}
+	if !reflect.DeepEqual(paths, expectedPaths) {
+		t.Fatalf("Expected paths %v, but got %v", expectedPaths, paths)
+	}
+
+	b, err := os.ReadFile(paths[0])
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+	expectedCSV := "product_id,sku,name,user_id,stock,unit,unit_value," +
+		"total_value\n" +
+		"1,sku-a,A,1,2,piece,10,20\n" +
+		"2,sku-b,B,1,1.5,kg,2.5,3.75\n"
+	if string(b) != expectedCSV {
+		t.Errorf("Expected CSV %q, but got %q", expectedCSV, string(b))
+	}
+}
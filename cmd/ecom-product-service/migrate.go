@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/reyhanfikridz/ecom-product-service/internal/migration"
+)
+
+// RunMigrate run command migrate, applying or reverting database schema
+// migrations, then print the result into out
+//
+// usage: migrate up | migrate down [-steps n] | migrate status
+func RunMigrate(args []string, out io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(out, "usage: migrate up | down [-steps n] | status")
+		return 2
+	}
+
+	fs := flag.NewFlagSet("migrate "+args[0], flag.ContinueOnError)
+	fs.SetOutput(out)
+	steps := fs.Int("steps", 1, "number of migrations to revert")
+	err := fs.Parse(args[1:])
+	if err != nil {
+		return 2
+	}
+
+	// connect to database
+	DB, err := openDB()
+	if err != nil {
+		fmt.Fprintln(out, err.Error())
+		return 1
+	}
+	defer DB.Close()
+
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		var applied []migration.Migration
+		applied, err = migration.Up(ctx, DB)
+		for _, m := range applied {
+			fmt.Fprintf(out, "applied  %04d_%s\n", m.Version, m.Name)
+		}
+	case "down":
+		var reverted []migration.Migration
+		reverted, err = migration.Down(ctx, DB, *steps)
+		for _, m := range reverted {
+			fmt.Fprintf(out, "reverted %04d_%s\n", m.Version, m.Name)
+		}
+	case "status":
+		var statuses []migration.MigrationStatus
+		statuses, err = migration.Status(ctx, DB)
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05Z07:00")
+			}
+			fmt.Fprintf(out, "%04d_%-40s %s\n",
+				status.Version, status.Name, appliedAt)
+		}
+	default:
+		fmt.Fprintf(out, "unknown migrate command '%s'\n", args[0])
+		return 2
+	}
+
+	if err != nil {
+		fmt.Fprintf(out, "There's an error when migrating => %s\n",
+			err.Error())
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/reyhanfikridz/ecom-product-service/internal/config"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+	"github.com/reyhanfikridz/ecom-product-service/internal/search"
+)
+
+// product tables rebuilt by command reindex
+var reindexTables = []string{
+	"product_productinfo",
+	"product_productimage",
+	"product_stockmovement",
+	"product_productversion",
+}
+
+// RunReindex run command reindex, rebuilding database indexes
+// of product tables, then print the rebuilt tables into out,
+// with -search every product is also indexed in search backend
+//
+// usage: reindex [-search]
+func RunReindex(args []string, out io.Writer) int {
+	fs := flag.NewFlagSet("reindex", flag.ContinueOnError)
+	fs.SetOutput(out)
+	withSearch := fs.Bool("search", false,
+		"also index every product in search backend")
+	err := fs.Parse(args)
+	if err != nil {
+		return 2
+	}
+
+	// connect to database
+	DB, err := openDB()
+	if err != nil {
+		fmt.Fprintln(out, err.Error())
+		return 1
+	}
+	defer DB.Close()
+
+	for _, table := range reindexTables {
+		_, err = DB.ExecContext(context.Background(), "REINDEX TABLE "+table)
+		if err != nil {
+			fmt.Fprintf(out, "There's an error when reindexing %s => %s\n",
+				table, err.Error())
+			return 1
+		}
+		fmt.Fprintf(out, "reindexed %s\n", table)
+	}
+
+	if *withSearch {
+		return reindexSearch(DB, out)
+	}
+
+	return 0
+}
+
+// reindexSearch index every not deleted product in search backend,
+// then print count of indexed products into out
+func reindexSearch(DB *sql.DB, out io.Writer) int {
+	repo := model.NewPostgresRepository(DB)
+	provider, err := search.NewProvider(config.SearchBackend,
+		config.SearchURL, config.SearchIndex, config.SearchAPIKey, repo)
+	if err != nil {
+		fmt.Fprintf(out, "There's an error when initialize search "+
+			"provider => %s\n", err.Error())
+		return 1
+	}
+
+	products, err := repo.GetProducts(context.Background(),
+		model.ProductQuery{})
+	if err != nil {
+		fmt.Fprintf(out, "There's an error when getting products => %s\n",
+			err.Error())
+		return 1
+	}
+
+	for _, p := range products {
+		err = provider.Index(context.Background(), p.ProductInfo.SKU)
+		if err != nil {
+			fmt.Fprintf(out, "There's an error when indexing %s => %s\n",
+				p.ProductInfo.SKU, err.Error())
+			return 1
+		}
+	}
+	fmt.Fprintf(out, "indexed %d products in search backend %s\n",
+		len(products), config.SearchBackend)
+
+	return 0
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math/rand"
+	"mime/multipart"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+)
+
+// seedCatalog contain product names and descriptions the seed products
+// are picked from
+var seedCatalog = []struct {
+	Name        string
+	Description string
+}{
+	{"Wireless Mouse", "Ergonomic 2.4GHz wireless mouse with silent click"},
+	{"Mechanical Keyboard", "Tenkeyless keyboard with hot-swappable switches"},
+	{"USB-C Hub", "7-in-1 hub with HDMI, card reader, and 100W passthrough"},
+	{"Laptop Stand", "Adjustable aluminium stand for 10 to 17 inch laptops"},
+	{"Noise Cancelling Headphones", "Over-ear headphones with 30 hours battery"},
+	{"Cotton T-Shirt", "Regular fit t-shirt made of 100% combed cotton"},
+	{"Denim Jacket", "Classic washed denim jacket with button closure"},
+	{"Running Shoes", "Lightweight running shoes with breathable mesh"},
+	{"Canvas Backpack", "Water resistant 25L backpack with laptop sleeve"},
+	{"Leather Wallet", "Slim bifold wallet made of genuine leather"},
+	{"Ceramic Mug", "350ml ceramic mug, microwave and dishwasher safe"},
+	{"French Press", "1 liter borosilicate glass coffee maker"},
+	{"Non-Stick Frying Pan", "28cm frying pan with granite coating"},
+	{"Bamboo Cutting Board", "Large cutting board with juice groove"},
+	{"Desk Lamp", "LED desk lamp with 5 brightness levels"},
+	{"Yoga Mat", "6mm thick non-slip yoga mat with carry strap"},
+	{"Stainless Water Bottle", "750ml vacuum insulated bottle"},
+	{"Arabica Coffee Beans", "250g medium roast single origin beans"},
+	{"Green Tea", "Box of 25 premium green tea bags"},
+	{"Paperback Notebook", "A5 dotted notebook with 160 pages"},
+}
+
+// seedImageColors contain background colors of seed product images
+var seedImageColors = []color.RGBA{
+	{R: 0xe5, G: 0x73, B: 0x73, A: 0xff},
+	{R: 0x64, G: 0xb5, B: 0xf6, A: 0xff},
+	{R: 0x81, G: 0xc7, B: 0x84, A: 0xff},
+	{R: 0xff, G: 0xd5, B: 0x4f, A: 0xff},
+	{R: 0xba, G: 0x68, B: 0xc8, A: 0xff},
+}
+
+// RunSeed run command seed, creating products with images and stock
+// owned by a seller for local development, then print the result into out
+//
+// usage: seed -seller id [-count n] [-images n] [-rand-seed n]
+func RunSeed(args []string, out io.Writer) int {
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	fs.SetOutput(out)
+	sellerID := fs.Int("seller", 0, "seller user id owning the products")
+	count := fs.Int("count", 20, "number of products created")
+	images := fs.Int("images", 2, "number of images per product")
+	randSeed := fs.Int64("rand-seed", time.Now().UnixNano(),
+		"seed of the random product data")
+	err := fs.Parse(args)
+	if err != nil {
+		return 2
+	}
+	if *sellerID <= 0 || *count <= 0 || *images < 0 {
+		fmt.Fprintln(out, "flag -seller must be positive, "+
+			"-count positive, and -images non-negative")
+		return 2
+	}
+
+	// connect to database
+	DB, err := openDB()
+	if err != nil {
+		fmt.Fprintln(out, err.Error())
+		return 1
+	}
+	defer DB.Close()
+
+	// insert the products and their images
+	ctx := context.Background()
+	r := rand.New(rand.NewSource(*randSeed))
+	for i, pInfo := range GetSeedProductInfos(r, *sellerID, *count) {
+		pInfo, err = model.InsertProductInfo(ctx, DB, pInfo)
+		if err != nil {
+			fmt.Fprintf(out, "There's an error when inserting product "+
+				"=> %s\n", err.Error())
+			return 1
+		}
+
+		fileHeaders, err := getSeedImageFileHeaders(pInfo.SKU, i, *images)
+		if err != nil {
+			fmt.Fprintf(out, "There's an error when creating product "+
+				"images => %s\n", err.Error())
+			return 1
+		}
+		err = model.InsertProductImages(ctx, DB, fileHeaders, pInfo)
+		if err != nil {
+			fmt.Fprintf(out, "There's an error when inserting product "+
+				"images => %s\n", err.Error())
+			return 1
+		}
+
+		fmt.Fprintf(out, "created %s %-28s stock %v\n",
+			pInfo.SKU, pInfo.Name, pInfo.Stock)
+	}
+
+	return 0
+}
+
+// GetSeedProductInfos get n random product infos owned by seller ID
+func GetSeedProductInfos(r *rand.Rand, sellerID int,
+	n int) []model.ProductInfo {
+	pInfos := []model.ProductInfo{}
+	for i := 0; i < n; i++ {
+		item := seedCatalog[r.Intn(len(seedCatalog))]
+
+		pInfos = append(pInfos, model.ProductInfo{
+			Name:        item.Name,
+			Price:       model.Money(r.Intn(500)+1) * 100000,
+			Weight:      float32(r.Intn(2000)+50) / 1000,
+			Description: item.Description,
+			Stock:       float64(r.Intn(200)),
+			UserID:      sellerID,
+		})
+	}
+
+	return pInfos
+}
+
+// getSeedImageFileHeaders get n generated PNG product images
+// as multipart file headers, ready to be inserted as product images
+func getSeedImageFileHeaders(SKU string, i int, n int) (
+	[]*multipart.FileHeader, error) {
+	var b bytes.Buffer
+	w := multipart.NewWriter(&b)
+	for j := 0; j < n; j++ {
+		part, err := w.CreateFormFile("product_images",
+			fmt.Sprintf("%s-%d.png", SKU, j+1))
+		if err != nil {
+			return nil, err
+		}
+
+		c := seedImageColors[(i+j)%len(seedImageColors)]
+		img := image.NewRGBA(image.Rect(0, 0, 400, 400))
+		for x := 0; x < 400; x++ {
+			for y := 0; y < 400; y++ {
+				img.SetRGBA(x, y, c)
+			}
+		}
+		err = png.Encode(part, img)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err := w.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	form, err := multipart.NewReader(&b, w.Boundary()).ReadForm(32 << 20)
+	if err != nil {
+		return nil, err
+	}
+
+	return form.File["product_images"], nil
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"log"
+	"net"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-product-service/api"
+	"github.com/reyhanfikridz/ecom-product-service/internal/config"
+	"github.com/reyhanfikridz/ecom-product-service/internal/event"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+	"github.com/reyhanfikridz/ecom-product-service/internal/productpb"
+	"github.com/reyhanfikridz/ecom-product-service/internal/scheduler"
+	"github.com/reyhanfikridz/ecom-product-service/internal/watch"
+	"google.golang.org/grpc"
+)
+
+// RunServe run command serve, initializing the API and serving it
+// until the server stopped
+//
+// usage: serve [-addr address]
+func RunServe(args []string, out io.Writer) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.SetOutput(out)
+	addr := fs.String("addr", ":8020", "address the server listen on")
+	err := fs.Parse(args)
+	if err != nil {
+		return 2
+	}
+
+	// init API
+	a, err := InitAPI()
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+
+	// consume order events in background
+	err = StartOrderConsumer(&a)
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+
+	// serve gRPC product watch service in background
+	err = StartGRPCServer(&a)
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+
+	// run scheduled tasks in background
+	StartScheduler(&a)
+
+	// serve server
+	log.Print(a.FiberApp.Listen(*addr))
+	return 1
+}
+
+// StartOrderConsumer start consuming order events in background
+// to adjust stock, reconnecting whenever the message broker connection
+// closed, skipped if no message broker configured
+func StartOrderConsumer(a *api.API) error {
+	if config.BrokerURL == "" {
+		return nil
+	}
+
+	consumer, err := event.NewRabbitMQConsumer(config.BrokerURL,
+		config.BrokerOrderExchange, config.BrokerOrderQueue)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		consumer.Consume(context.Background(), a.HandleOrderEvent)
+		log.Print("Order event consumer stopped")
+	}()
+
+	return nil
+}
+
+// StartGRPCServer start serving gRPC product watch service in background,
+// skipped if no gRPC server address configured
+func StartGRPCServer(a *api.API) error {
+	if config.GRPCAddr == "" {
+		return nil
+	}
+
+	lis, err := net.Listen("tcp", config.GRPCAddr)
+	if err != nil {
+		return err
+	}
+
+	server := grpc.NewServer(
+		grpc.StreamInterceptor(watch.StreamAuthorizationInterceptor))
+	productpb.RegisterProductWatchServer(server, watch.NewServer(a.Repo))
+
+	go func() {
+		err := server.Serve(lis)
+		if err != nil {
+			log.Printf("There's an error when serving gRPC server => %s",
+				err.Error())
+		}
+		log.Print("gRPC server stopped")
+	}()
+
+	return nil
+}
+
+// StartScheduler start running enabled scheduled tasks in background,
+// their run metrics are served by the API
+func StartScheduler(a *api.API) {
+	a.Scheduler = scheduler.NewScheduler(
+		scheduler.Task{
+			Name:     "cleanup-media",
+			Interval: config.ScheduleCleanupMedia,
+			Run: func(ctx context.Context) error {
+				removed, err := CleanupMedia(ctx, a.DB, time.Hour, false)
+				log.Printf("Scheduled cleanup-media removed %d unused media "+
+					"files", len(removed))
+				return err
+			},
+		},
+		scheduler.Task{
+			Name:     "inventory-report",
+			Interval: config.ScheduleInventoryReport,
+			Run: func(ctx context.Context) error {
+				paths, err := ReportInventory(ctx, a.DB,
+					config.InventoryReportDir, 0, time.Now())
+				log.Printf("Scheduled inventory-report wrote %d inventory "+
+					"reports", len(paths))
+				return err
+			},
+		},
+		scheduler.Task{
+			Name:     "purge-product-events",
+			Interval: config.SchedulePurgeProductEvents,
+			Run: func(ctx context.Context) error {
+				purged, err := model.DeleteProductEventsBefore(ctx, a.DB,
+					time.Now().Add(-config.ProductEventRetention))
+				log.Printf("Scheduled purge-product-events purged %d "+
+					"product events", purged)
+				return err
+			},
+		},
+	)
+	a.Scheduler.Start(context.Background())
+}
+
+// InitAPI initialize API
+func InitAPI() (api.API, error) {
+	a := api.API{}
+
+	// init all config before can be used
+	err := config.InitConfig()
+	if err != nil {
+		return a, err
+	}
+	err = config.Validate()
+	if err != nil {
+		return a, err
+	}
+	err = config.CheckMediaRoot(config.MediaRoot)
+	if err != nil {
+		return a, err
+	}
+
+	// init database
+	err = a.InitDB(config.GetDBConfig(config.DBName))
+	if err != nil {
+		return a, err
+	}
+
+	// init event publisher
+	err = a.InitPublisher(config.BrokerURL, config.BrokerExchange)
+	if err != nil {
+		return a, err
+	}
+
+	// init webhook dispatcher
+	a.InitWebhooks(config.WebhookLowStockThreshold)
+
+	// init journal of product events
+	a.InitJournal()
+
+	// init product cache
+	err = a.InitCache(config.RedisURL, config.ProductCacheTTL)
+	if err != nil {
+		return a, err
+	}
+
+	// init search provider
+	err = a.InitSearch(config.SearchBackend, config.SearchURL,
+		config.SearchIndex, config.SearchAPIKey)
+	if err != nil {
+		return a, err
+	}
+
+	// init product image scanner
+	err = a.InitScanner(config.MediaScanner, config.MediaScannerAddress,
+		config.MediaScannerAPIKey)
+	if err != nil {
+		return a, err
+	}
+
+	// init product image watermark
+	err = a.InitWatermark(config.WatermarkPath)
+	if err != nil {
+		return a, err
+	}
+
+	// init account service client
+	a.InitAccounts(config.AccountServiceURL)
+
+	// init export job queue
+	a.InitExportJobs(config.ExportJobDir, config.ExportJobTTL)
+
+	// init maintenance mode
+	a.InitMaintenance(config.MaintenanceMode, config.MaintenanceMessage)
+
+	// init role-permission matrix
+	err = a.InitPermissions(config.Permissions)
+	if err != nil {
+		return a, err
+	}
+
+	// init router
+	a.InitRouter()
+
+	return a, nil
+}
@@ -0,0 +1,162 @@
+/*
+Package account containing client of account service authorizing
+user tokens and getting user data
+*/
+package account
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errors of account service responses
+var (
+	ErrUnauthorized = errors.New("token authorization invalid")
+	ErrUserNotFound = errors.New("user not found")
+)
+
+// StatusError error of account service responding unexpected status code
+type StatusError struct {
+	Method     string
+	Path       string
+	StatusCode int
+}
+
+// Error get error message of unexpected status code
+func (e StatusError) Error() string {
+	return fmt.Sprintf("account service responded %s %s with status %d",
+		e.Method, e.Path, e.StatusCode)
+}
+
+// AuthorizeRequest contain request data of authorizing token
+type AuthorizeRequest struct {
+	Token string
+}
+
+// User contain user data from account service, Role is the primary
+// role of the user and Roles are all roles of the user, Verified is
+// whether the seller is verified, nil if account service doesn't tell
+type User struct {
+	ID          int      `json:"id"`
+	Email       string   `json:"email"`
+	Password    string   `json:"password"`
+	FullName    string   `json:"full_name"`
+	Address     string   `json:"address"`
+	PhoneNumber string   `json:"phone_number"`
+	Role        string   `json:"role"`
+	Roles       []string `json:"roles"`
+	Verified    *bool    `json:"verified"`
+}
+
+// Client client of account service at base URL
+type Client struct {
+	client  *http.Client
+	BaseURL string
+}
+
+// NewClient create client of account service at base URL
+func NewClient(baseURL string) *Client {
+	return &Client{
+		client:  &http.Client{Timeout: 10 * time.Second},
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// Authorize authorize token of request, then get user of the token,
+// return ErrUnauthorized if account service rejects the token
+func (c *Client) Authorize(ctx context.Context, reqData AuthorizeRequest) (
+	User, error) {
+	// transform form data to bytes buffer
+	var body bytes.Buffer
+	bodyWriter := multipart.NewWriter(&body)
+	err := bodyWriter.WriteField("token", reqData.Token)
+	if err != nil {
+		return User{}, err
+	}
+	err = bodyWriter.Close()
+	if err != nil {
+		return User{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		c.BaseURL+"/api/authorize/", &body)
+	if err != nil {
+		return User{}, err
+	}
+	req.Header.Set("Content-Type", bodyWriter.FormDataContentType())
+
+	u := User{}
+	err = c.do(req, &u)
+	if status := (StatusError{}); errors.As(err, &status) {
+		return u, ErrUnauthorized
+	} else if err != nil {
+		return u, err
+	}
+
+	return normalizeRoles(u), nil
+}
+
+// GetUser get user of ID, return ErrUserNotFound if account service
+// doesn't find the user
+func (c *Client) GetUser(ctx context.Context, ID int) (User, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		c.BaseURL+"/api/user/?"+url.Values{"id": {strconv.Itoa(ID)}}.Encode(),
+		nil)
+	if err != nil {
+		return User{}, err
+	}
+
+	u := User{}
+	err = c.do(req, &u)
+	if status := (StatusError{}); errors.As(err, &status) &&
+		status.StatusCode == http.StatusNotFound {
+		return u, ErrUserNotFound
+	} else if err != nil {
+		return u, err
+	}
+
+	return normalizeRoles(u), nil
+}
+
+// do send request to account service, then decode JSON response
+// into result, response status other than OK is StatusError
+func (c *Client) do(req *http.Request, result interface{}) error {
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 1024))
+		return StatusError{
+			Method:     req.Method,
+			Path:       req.URL.Path,
+			StatusCode: resp.StatusCode,
+		}
+	}
+
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// normalizeRoles set both role and roles of user,
+// since account service may respond roles, role, or both
+func normalizeRoles(u User) User {
+	if len(u.Roles) == 0 && u.Role != "" {
+		u.Roles = []string{u.Role}
+	} else if u.Role == "" && len(u.Roles) > 0 {
+		u.Role = u.Roles[0]
+	}
+
+	return u
+}
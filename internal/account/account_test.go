@@ -0,0 +1,123 @@
+/*
+Package account containing client of account service authorizing
+user tokens and getting user data
+*/
+package account
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// newAccountServer create fake account service authorizing token
+// as user of role or roles responded by body, and getting user of ID 1
+func newAccountServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/authorize/":
+				token := r.FormValue("token")
+				if r.Method != http.MethodPost || token == "invalid" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				w.Write([]byte(token))
+			case "/api/user/":
+				switch r.URL.Query().Get("id") {
+				case "1":
+					w.Write([]byte(`{"id":1,"email":"seller@gmail.com",` +
+						`"full_name":"seller","role":"seller"}`))
+				case "2":
+					w.WriteHeader(http.StatusNotFound)
+				default:
+					w.WriteHeader(http.StatusInternalServerError)
+				}
+			}
+		}))
+}
+
+// TestAuthorize test Client.Authorize getting roles of user from
+// response of role, roles, or both
+func TestAuthorize(t *testing.T) {
+	server := newAccountServer()
+	defer server.Close()
+	client := NewClient(server.URL + "/")
+	unverified := false
+
+	// create testing table
+	testTable := []struct {
+		TestName     string
+		Token        string
+		ExpectedUser User
+		ExpectedErr  error
+	}{
+		{"Role", `{"id":1,"email":"buyer@gmail.com","role":"buyer"}`,
+			User{ID: 1, Email: "buyer@gmail.com", Role: "buyer",
+				Roles: []string{"buyer"}}, nil},
+		{"Roles", `{"id":1,"roles":["seller","buyer"]}`,
+			User{ID: 1, Role: "seller", Roles: []string{"seller", "buyer"}},
+			nil},
+		{"Role And Roles", `{"id":1,"role":"buyer","roles":["seller","buyer"]}`,
+			User{ID: 1, Role: "buyer", Roles: []string{"seller", "buyer"}},
+			nil},
+		{"Unverified", `{"id":1,"role":"seller","verified":false}`,
+			User{ID: 1, Role: "seller", Roles: []string{"seller"},
+				Verified: &unverified}, nil},
+		{"Invalid", "invalid", User{}, ErrUnauthorized},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		u, err := client.Authorize(context.Background(),
+			AuthorizeRequest{Token: test.Token})
+		if err != test.ExpectedErr {
+			t.Errorf("[%s] Expected error %v, but got %v", test.TestName,
+				test.ExpectedErr, err)
+			continue
+		}
+		if err == nil && !reflect.DeepEqual(u, test.ExpectedUser) {
+			t.Errorf("[%s] Expected user %+v, but got %+v", test.TestName,
+				test.ExpectedUser, u)
+		}
+	}
+}
+
+// TestGetUser test Client.GetUser
+func TestGetUser(t *testing.T) {
+	server := newAccountServer()
+	defer server.Close()
+	client := NewClient(server.URL)
+
+	u, err := client.GetUser(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+	if u.Email != "seller@gmail.com" || u.FullName != "seller" ||
+		!reflect.DeepEqual(u.Roles, []string{"seller"}) {
+		t.Errorf("Expected user seller, but got %+v", u)
+	}
+
+	// user not found
+	_, err = client.GetUser(context.Background(), 2)
+	if err != ErrUserNotFound {
+		t.Errorf("Expected error user not found, but got %v", err)
+	}
+
+	// account service failure
+	_, err = client.GetUser(context.Background(), 3)
+	status := StatusError{}
+	if !errors.As(err, &status) ||
+		status.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Expected status error 500, but got %v", err)
+	}
+
+	// account service unreachable
+	_, err = NewClient("http://127.0.0.1:1").GetUser(context.Background(), 1)
+	if err == nil || errors.As(err, &status) {
+		t.Errorf("Expected connection error, but got %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+/*
+Package cache containing cache of product detail by SKU
+*/
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+)
+
+// ProductCache cache product detail by SKU
+type ProductCache interface {
+	// Get get cached product, ok is false on cache miss
+	Get(SKU string) (p model.Product, ok bool, err error)
+	Set(p model.Product) error
+	Delete(SKU string) error
+	Close() error
+}
+
+// NopCache cache that never store anything,
+// used when no cache server configured
+type NopCache struct{}
+
+// Get always miss
+func (NopCache) Get(SKU string) (model.Product, bool, error) {
+	return model.Product{}, false, nil
+}
+
+// Set do nothing
+func (NopCache) Set(p model.Product) error {
+	return nil
+}
+
+// Delete do nothing
+func (NopCache) Delete(SKU string) error {
+	return nil
+}
+
+// Close do nothing
+func (NopCache) Close() error {
+	return nil
+}
+
+// RedisCache cache product detail as JSON in Redis
+type RedisCache struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// NewRedisCache connect to Redis by URL,
+// cached products expire after ttl
+func NewRedisCache(URL string, ttl time.Duration) (*RedisCache, error) {
+	opt, err := redis.ParseURL(URL)
+	if err != nil {
+		return nil, err
+	}
+
+	client := redis.NewClient(opt)
+	err = client.Ping(context.Background()).Err()
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	return &RedisCache{client: client, ttl: ttl}, nil
+}
+
+// Key get Redis key of product by SKU
+func Key(SKU string) string {
+	return "product:sku:" + SKU
+}
+
+// Get get cached product from Redis
+func (c *RedisCache) Get(SKU string) (model.Product, bool, error) {
+	p := model.Product{}
+
+	b, err := c.client.Get(context.Background(), Key(SKU)).Bytes()
+	if err == redis.Nil {
+		return p, false, nil
+	} else if err != nil {
+		return p, false, err
+	}
+
+	err = json.Unmarshal(b, &p)
+	if err != nil {
+		return p, false, err
+	}
+
+	return p, true, nil
+}
+
+// Set cache product in Redis without seller info,
+// since it's owned by account service
+func (c *RedisCache) Set(p model.Product) error {
+	p.SellerInfo = model.SellerInfo{}
+
+	b, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	return c.client.Set(context.Background(), Key(p.ProductInfo.SKU),
+		b, c.ttl).Err()
+}
+
+// Delete invalidate cached product in Redis
+func (c *RedisCache) Delete(SKU string) error {
+	return c.client.Del(context.Background(), Key(SKU)).Err()
+}
+
+// Close close connection to Redis
+func (c *RedisCache) Close() error {
+	return c.client.Close()
+}
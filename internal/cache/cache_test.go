@@ -0,0 +1,52 @@
+/*
+Package cache containing cache of product detail by SKU
+*/
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+)
+
+// TestNopCache test NopCache always miss
+func TestNopCache(t *testing.T) {
+	c := NopCache{}
+
+	err := c.Set(model.Product{ProductInfo: model.ProductInfo{SKU: "SKU-A"}})
+	if err != nil {
+		t.Errorf("Expected error nil, but got error => %s", err.Error())
+	}
+
+	_, ok, err := c.Get("SKU-A")
+	if ok || err != nil {
+		t.Errorf("Expected cache miss without error, but got %t, %v", ok, err)
+	}
+}
+
+// TestNewRedisCache test NewRedisCache
+func TestNewRedisCache(t *testing.T) {
+	// create testing table
+	testTable := []struct {
+		TestName string
+		URL      string
+	}{
+		{
+			TestName: "URL invalid",
+			URL:      "http://127.0.0.1:6379",
+		},
+		{
+			TestName: "Server unreachable",
+			URL:      "redis://127.0.0.1:1/0",
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		_, err := NewRedisCache(test.URL, time.Minute)
+		if err == nil {
+			t.Errorf("[%s] Expected error, but got nil", test.TestName)
+		}
+	}
+}
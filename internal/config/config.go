@@ -4,18 +4,42 @@ Package config collection of configuration
 package config
 
 import (
+	"errors"
+	"fmt"
+	"net/url"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/joho/godotenv"
 )
 
 var (
+	File string
+
+	Env string
+
 	DBName             string
 	DBNameForAPITest   string
 	DBNameForModelTest string
 	DBUsername         string
 	DBPassword         string
+	DBHost             string
+	DBPort             string
+	DBSSLMode          string
+	DBDSN              string
+	DBReplicaDSN       string
+
+	DBMaxOpenConns    int
+	DBMaxIdleConns    int
+	DBConnMaxLifetime time.Duration
+	DBConnMaxIdleTime time.Duration
+
+	DBSlowQueryThreshold time.Duration
 
 	JWTSecretKey     string
 	JWTSigningMethod *jwt.SigningMethodHMAC
@@ -23,25 +47,143 @@ var (
 	FrontendURL       string
 	AccountServiceURL string
 
-	MediaFolder string
+	DevAuth       bool
+	DevAuthUserID int
+	DevAuthRole   string
+
+	MediaRoot        string
+	MediaCDNURL      string
+	MediaResizeSizes []ImageSize
+	MediaCacheMaxAge time.Duration
+	BodyLimit        int
+
+	MaxProductImages     int
+	MaxProductImagesSize int64
+
+	ProductImageAspectRatio ImageSize
+	ProductImageFit         string
+	ProductImageMinSize     ImageSize
+
+	MediaScanner        string
+	MediaScannerAddress string
+	MediaScannerAPIKey  string
+
+	WatermarkPath    string
+	WatermarkSellers []int
+
+	VolumetricWeightDivisor int
+
+	DefaultLocale string
+	Currency      string
+
+	FeedToken            string
+	InternalServiceToken string
+
+	// Permissions roles allowed to do each API action replacing
+	// the default role-permission matrix
+	Permissions map[string][]string
+
+	// MaintenanceMode whether mutating routes reply service unavailable
+	// with MaintenanceMessage at startup, toggled at runtime by admin
+	MaintenanceMode    bool
+	MaintenanceMessage string
+
+	BrokerURL           string
+	BrokerExchange      string
+	BrokerOrderExchange string
+	BrokerOrderQueue    string
+
+	// GRPCAddr address gRPC server of product watch service listen on,
+	// disabled if empty, ProductEventRetention how long product events
+	// can be resumed from
+	GRPCAddr              string
+	ProductEventRetention time.Duration
+
+	WebhookLowStockThreshold int
+
+	RedisURL        string
+	ProductCacheTTL time.Duration
+
+	ProductViewWindow time.Duration
+
+	SearchSynonyms map[string][]string
+	SearchBackend  string
+	SearchURL      string
+	SearchIndex    string
+	SearchAPIKey   string
+
+	ScheduleCleanupMedia       time.Duration
+	ScheduleInventoryReport    time.Duration
+	SchedulePurgeProductEvents time.Duration
+	InventoryReportDir         string
+
+	// ExportJobDir directory files of export jobs written to,
+	// ExportJobTTL how long finished export jobs can be downloaded
+	ExportJobDir string
+	ExportJobTTL time.Duration
 )
 
+// ImageSize maximum width and height of an image
+type ImageSize struct {
+	Width  int
+	Height int
+}
+
 // InitConfig initialize all config variable from environment variable
+//
+// environment variables are also loaded from config file File, or
+// ECOM_PRODUCT_SERVICE_CONFIG_FILE if File is empty, without overriding
+// the ones already set, if no config file given the first .env file found
+// in working directory or project root directory is loaded if any
 func InitConfig() error {
-	// load all values from .env file into the system
-	// .env file must be at root directory (same level as go.mod file)
-	err := godotenv.Load(os.ExpandEnv(
-		"$GOPATH/src/github.com/reyhanfikridz/ecom-product-service/.env"))
+	err := loadConfigFile()
 	if err != nil {
 		return err
 	}
 
 	// set all config variable after all environment variable loaded
+	Env = os.Getenv("ECOM_PRODUCT_SERVICE_ENV")
+	if Env == "" {
+		Env = "development"
+	}
+
 	DBName = os.Getenv("ECOM_PRODUCT_SERVICE_DB_NAME")
 	DBNameForAPITest = os.Getenv("ECOM_PRODUCT_SERVICE_DB_NAME_FOR_API_TEST")
 	DBNameForModelTest = os.Getenv("ECOM_PRODUCT_SERVICE_DB_NAME_FOR_MODEL_TEST")
 	DBUsername = os.Getenv("ECOM_PRODUCT_SERVICE_DB_USERNAME")
 	DBPassword = os.Getenv("ECOM_PRODUCT_SERVICE_DB_PASSWORD")
+	DBHost = os.Getenv("ECOM_PRODUCT_SERVICE_DB_HOST")
+	DBPort = os.Getenv("ECOM_PRODUCT_SERVICE_DB_PORT")
+	DBSSLMode = os.Getenv("ECOM_PRODUCT_SERVICE_DB_SSLMODE")
+	if DBSSLMode == "" {
+		DBSSLMode = "disable"
+	}
+	DBDSN = os.Getenv("ECOM_PRODUCT_SERVICE_DB_DSN")
+	DBReplicaDSN = os.Getenv("ECOM_PRODUCT_SERVICE_DB_REPLICA_DSN")
+
+	DBMaxOpenConns, err = getEnvInt("ECOM_PRODUCT_SERVICE_DB_MAX_OPEN_CONNS", 25)
+	if err != nil {
+		return err
+	}
+	DBMaxIdleConns, err = getEnvInt("ECOM_PRODUCT_SERVICE_DB_MAX_IDLE_CONNS", 25)
+	if err != nil {
+		return err
+	}
+	DBConnMaxLifetime, err = getEnvDuration(
+		"ECOM_PRODUCT_SERVICE_DB_CONN_MAX_LIFETIME", 30*time.Minute)
+	if err != nil {
+		return err
+	}
+	DBConnMaxIdleTime, err = getEnvDuration(
+		"ECOM_PRODUCT_SERVICE_DB_CONN_MAX_IDLE_TIME", 5*time.Minute)
+	if err != nil {
+		return err
+	}
+	DBSlowQueryThreshold, err = getEnvDuration(
+		"ECOM_PRODUCT_SERVICE_DB_SLOW_QUERY_THRESHOLD", 500*time.Millisecond)
+	if err != nil {
+		return err
+	}
 
 	JWTSecretKey = os.Getenv("ECOM_PRODUCT_SERVICE_JWT_SECRET_KEY")
 	JWTSigningMethod = jwt.SigningMethodHS256
@@ -49,7 +191,675 @@ func InitConfig() error {
 	FrontendURL = os.Getenv("ECOM_PRODUCT_SERVICE_FRONTEND_URL")
 	AccountServiceURL = os.Getenv("ECOM_PRODUCT_SERVICE_ACCOUNT_SERVICE_URL")
 
-	MediaFolder = "/media/"
+	DevAuth, err = getEnvBool("ECOM_PRODUCT_SERVICE_DEV_AUTH", false)
+	if err != nil {
+		return err
+	}
+	DevAuthUserID, err = getEnvInt("ECOM_PRODUCT_SERVICE_DEV_AUTH_USER_ID", 1)
+	if err != nil {
+		return err
+	}
+	DevAuthRole = os.Getenv("ECOM_PRODUCT_SERVICE_DEV_AUTH_ROLE")
+	if DevAuthRole == "" {
+		DevAuthRole = "seller"
+	}
+
+	MediaRoot = os.Getenv("ECOM_PRODUCT_SERVICE_MEDIA_ROOT")
+	if MediaRoot == "" {
+		MediaRoot, err = filepath.Abs("./../media")
+		if err != nil {
+			return err
+		}
+	}
+	MediaCDNURL = strings.TrimRight(
+		os.Getenv("ECOM_PRODUCT_SERVICE_MEDIA_CDN_URL"), "/")
+	MediaResizeSizes, err = getEnvImageSizes(
+		"ECOM_PRODUCT_SERVICE_MEDIA_RESIZE_SIZES",
+		"160x160,320x320,640x640,1280x1280")
+	if err != nil {
+		return err
+	}
+	MediaCacheMaxAge, err = getEnvDuration(
+		"ECOM_PRODUCT_SERVICE_MEDIA_CACHE_MAX_AGE", 30*24*time.Hour)
+	if err != nil {
+		return err
+	}
+	BodyLimit, err = getEnvInt("ECOM_PRODUCT_SERVICE_BODY_LIMIT", 4*1024*1024)
+	if err != nil {
+		return err
+	}
+	MaxProductImages, err = getEnvInt(
+		"ECOM_PRODUCT_SERVICE_MAX_PRODUCT_IMAGES", 10)
+	if err != nil {
+		return err
+	}
+	maxProductImagesSize, err := getEnvInt(
+		"ECOM_PRODUCT_SERVICE_MAX_PRODUCT_IMAGES_SIZE", 4*1024*1024)
+	if err != nil {
+		return err
+	}
+	MaxProductImagesSize = int64(maxProductImagesSize)
+	ProductImageAspectRatio, err = getEnvImageSize(
+		"ECOM_PRODUCT_SERVICE_PRODUCT_IMAGE_ASPECT_RATIO", ":")
+	if err != nil {
+		return err
+	}
+	ProductImageFit = os.Getenv("ECOM_PRODUCT_SERVICE_PRODUCT_IMAGE_FIT")
+	if ProductImageFit == "" {
+		ProductImageFit = "crop"
+	}
+	ProductImageMinSize, err = getEnvImageSize(
+		"ECOM_PRODUCT_SERVICE_PRODUCT_IMAGE_MIN_SIZE", "x")
+	if err != nil {
+		return err
+	}
+	MediaScanner = strings.ToLower(os.Getenv("ECOM_PRODUCT_SERVICE_MEDIA_SCANNER"))
+	if MediaScanner == "" {
+		MediaScanner = "none"
+	}
+	MediaScannerAddress = os.Getenv("ECOM_PRODUCT_SERVICE_MEDIA_SCANNER_ADDRESS")
+	MediaScannerAPIKey = os.Getenv("ECOM_PRODUCT_SERVICE_MEDIA_SCANNER_API_KEY")
+	WatermarkPath = os.Getenv("ECOM_PRODUCT_SERVICE_WATERMARK_PATH")
+	WatermarkSellers, err = getEnvInts("ECOM_PRODUCT_SERVICE_WATERMARK_SELLERS")
+	if err != nil {
+		return err
+	}
+	VolumetricWeightDivisor, err = getEnvInt(
+		"ECOM_PRODUCT_SERVICE_VOLUMETRIC_WEIGHT_DIVISOR", 5000)
+	if err != nil {
+		return err
+	}
+
+	DefaultLocale = strings.ToLower(os.Getenv("ECOM_PRODUCT_SERVICE_DEFAULT_LOCALE"))
+	if DefaultLocale == "" {
+		DefaultLocale = "en"
+	}
+	Currency = strings.ToUpper(os.Getenv("ECOM_PRODUCT_SERVICE_CURRENCY"))
+	if Currency == "" {
+		Currency = "IDR"
+	}
+
+	FeedToken = os.Getenv("ECOM_PRODUCT_SERVICE_FEED_TOKEN")
+	InternalServiceToken = os.Getenv(
+		"ECOM_PRODUCT_SERVICE_INTERNAL_SERVICE_TOKEN")
+	Permissions, err = getEnvPermissions("ECOM_PRODUCT_SERVICE_PERMISSIONS")
+	if err != nil {
+		return err
+	}
+
+	MaintenanceMode, err = getEnvBool("ECOM_PRODUCT_SERVICE_MAINTENANCE_MODE",
+		false)
+	if err != nil {
+		return err
+	}
+	MaintenanceMessage = os.Getenv(
+		"ECOM_PRODUCT_SERVICE_MAINTENANCE_MESSAGE")
+
+	BrokerURL = os.Getenv("ECOM_PRODUCT_SERVICE_BROKER_URL")
+	BrokerExchange = os.Getenv("ECOM_PRODUCT_SERVICE_BROKER_EXCHANGE")
+	if BrokerExchange == "" {
+		BrokerExchange = "product.events"
+	}
+	BrokerOrderExchange = os.Getenv("ECOM_PRODUCT_SERVICE_BROKER_ORDER_EXCHANGE")
+	if BrokerOrderExchange == "" {
+		BrokerOrderExchange = "order.events"
+	}
+	BrokerOrderQueue = os.Getenv("ECOM_PRODUCT_SERVICE_BROKER_ORDER_QUEUE")
+	if BrokerOrderQueue == "" {
+		BrokerOrderQueue = "product-service.order-events"
+	}
+
+	GRPCAddr = os.Getenv("ECOM_PRODUCT_SERVICE_GRPC_ADDR")
+	ProductEventRetention, err = getEnvDuration(
+		"ECOM_PRODUCT_SERVICE_PRODUCT_EVENT_RETENTION", 7*24*time.Hour)
+	if err != nil {
+		return err
+	}
+
+	WebhookLowStockThreshold, err = getEnvInt(
+		"ECOM_PRODUCT_SERVICE_WEBHOOK_LOW_STOCK_THRESHOLD", 5)
+	if err != nil {
+		return err
+	}
+
+	RedisURL = os.Getenv("ECOM_PRODUCT_SERVICE_REDIS_URL")
+	ProductCacheTTL, err = getEnvDuration(
+		"ECOM_PRODUCT_SERVICE_PRODUCT_CACHE_TTL", 5*time.Minute)
+	if err != nil {
+		return err
+	}
+
+	ProductViewWindow, err = getEnvDuration(
+		"ECOM_PRODUCT_SERVICE_PRODUCT_VIEW_WINDOW", 30*time.Minute)
+	if err != nil {
+		return err
+	}
+
+	SearchSynonyms, err = getEnvSynonyms(
+		"ECOM_PRODUCT_SERVICE_SEARCH_SYNONYMS")
+	if err != nil {
+		return err
+	}
+	SearchBackend = strings.ToLower(os.Getenv("ECOM_PRODUCT_SERVICE_SEARCH_BACKEND"))
+	if SearchBackend == "" {
+		SearchBackend = "postgres"
+	}
+	SearchURL = os.Getenv("ECOM_PRODUCT_SERVICE_SEARCH_URL")
+	SearchIndex = os.Getenv("ECOM_PRODUCT_SERVICE_SEARCH_INDEX")
+	if SearchIndex == "" {
+		SearchIndex = "products"
+	}
+	SearchAPIKey = os.Getenv("ECOM_PRODUCT_SERVICE_SEARCH_API_KEY")
+
+	ScheduleCleanupMedia, err = getEnvDuration(
+		"ECOM_PRODUCT_SERVICE_SCHEDULE_CLEANUP_MEDIA", 0)
+	if err != nil {
+		return err
+	}
+	ScheduleInventoryReport, err = getEnvDuration(
+		"ECOM_PRODUCT_SERVICE_SCHEDULE_INVENTORY_REPORT", 0)
+	if err != nil {
+		return err
+	}
+	SchedulePurgeProductEvents, err = getEnvDuration(
+		"ECOM_PRODUCT_SERVICE_SCHEDULE_PURGE_PRODUCT_EVENTS", 0)
+	if err != nil {
+		return err
+	}
+	InventoryReportDir = os.Getenv("ECOM_PRODUCT_SERVICE_INVENTORY_REPORT_DIR")
+	if InventoryReportDir == "" {
+		InventoryReportDir = "./../inventory-reports"
+	}
+
+	ExportJobDir = os.Getenv("ECOM_PRODUCT_SERVICE_EXPORT_JOB_DIR")
+	if ExportJobDir == "" {
+		ExportJobDir = "./../export-jobs"
+	}
+	ExportJobTTL, err = getEnvDuration(
+		"ECOM_PRODUCT_SERVICE_EXPORT_JOB_TTL", 24*time.Hour)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// loadConfigFile load environment variables from config file
+func loadConfigFile() error {
+	path := File
+	if path == "" {
+		path = os.Getenv("ECOM_PRODUCT_SERVICE_CONFIG_FILE")
+	}
+	if path != "" {
+		err := godotenv.Load(path)
+		if err != nil {
+			return fmt.Errorf("config file %s cannot be loaded => %s",
+				path, err.Error())
+		}
+		return nil
+	}
+
+	// .env file at root directory (same level as go.mod file) is loaded
+	// for local development
+	for _, path := range []string{".env", os.ExpandEnv(
+		"$GOPATH/src/github.com/reyhanfikridz/ecom-product-service/.env")} {
+		_, err := os.Stat(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+
+		err = godotenv.Load(path)
+		if err != nil {
+			return fmt.Errorf("config file %s cannot be loaded => %s",
+				path, err.Error())
+		}
+		return nil
+	}
 
 	return nil
 }
+
+// Validate check all required settings are set and all settings valid,
+// must be called after InitConfig
+//
+// return error listing every invalid setting, nil if all valid
+func Validate() error {
+	problems := []string{}
+
+	required := map[string]string{
+		"ECOM_PRODUCT_SERVICE_DB_NAME":             DBName,
+		"ECOM_PRODUCT_SERVICE_DB_USERNAME":         DBUsername,
+		"ECOM_PRODUCT_SERVICE_JWT_SECRET_KEY":      JWTSecretKey,
+		"ECOM_PRODUCT_SERVICE_FRONTEND_URL":        FrontendURL,
+		"ECOM_PRODUCT_SERVICE_ACCOUNT_SERVICE_URL": AccountServiceURL,
+	}
+	if DevAuth { // account service not used with mock authentication
+		delete(required, "ECOM_PRODUCT_SERVICE_ACCOUNT_SERVICE_URL")
+	}
+	if DBDSN != "" { // full DSN contain database name and username
+		delete(required, "ECOM_PRODUCT_SERVICE_DB_NAME")
+		delete(required, "ECOM_PRODUCT_SERVICE_DB_USERNAME")
+	}
+	missing := []string{}
+	for key, value := range required {
+		if strings.TrimSpace(value) == "" {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		problems = append(problems,
+			"missing required setting "+strings.Join(missing, ", "))
+	}
+
+	if Env != "development" && Env != "production" {
+		problems = append(problems, fmt.Sprintf("ECOM_PRODUCT_SERVICE_ENV "+
+			"'%s' invalid, must be 'development' or 'production'", Env))
+	}
+	if DevAuth && Env == "production" {
+		problems = append(problems, "ECOM_PRODUCT_SERVICE_DEV_AUTH "+
+			"must not be enabled in production")
+	}
+	switch DBSSLMode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		problems = append(problems, fmt.Sprintf("ECOM_PRODUCT_SERVICE_DB_SSLMODE "+
+			"'%s' invalid, must be one of disable, allow, prefer, require, "+
+			"verify-ca, verify-full", DBSSLMode))
+	}
+	if DBPort != "" {
+		port, err := strconv.Atoi(DBPort)
+		if err != nil || port <= 0 || port > 65535 {
+			problems = append(problems, fmt.Sprintf(
+				"ECOM_PRODUCT_SERVICE_DB_PORT '%s' invalid", DBPort))
+		}
+	}
+	if DBMaxOpenConns < 0 || DBMaxIdleConns < 0 {
+		problems = append(problems, "ECOM_PRODUCT_SERVICE_DB_MAX_OPEN_CONNS "+
+			"and ECOM_PRODUCT_SERVICE_DB_MAX_IDLE_CONNS must be non-negative")
+	}
+	if DBSlowQueryThreshold < 0 {
+		problems = append(problems, "ECOM_PRODUCT_SERVICE_DB_SLOW_QUERY_THRESHOLD "+
+			"must be non-negative, zero disables slow query logging")
+	}
+	if !filepath.IsAbs(MediaRoot) {
+		problems = append(problems, fmt.Sprintf("ECOM_PRODUCT_SERVICE_MEDIA_ROOT "+
+			"'%s' invalid, must be absolute path", MediaRoot))
+	}
+	if MediaCDNURL != "" {
+		u, err := url.Parse(MediaCDNURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") ||
+			u.Host == "" {
+			problems = append(problems, fmt.Sprintf(
+				"ECOM_PRODUCT_SERVICE_MEDIA_CDN_URL '%s' invalid, "+
+					"must be http or https URL", MediaCDNURL))
+		}
+	}
+	if BodyLimit <= 0 {
+		problems = append(problems,
+			"ECOM_PRODUCT_SERVICE_BODY_LIMIT must be positive bytes")
+	}
+	if MaxProductImages <= 0 || MaxProductImagesSize <= 0 {
+		problems = append(problems, "ECOM_PRODUCT_SERVICE_MAX_PRODUCT_IMAGES "+
+			"and ECOM_PRODUCT_SERVICE_MAX_PRODUCT_IMAGES_SIZE must be positive")
+	}
+	if ProductImageFit != "crop" && ProductImageFit != "pad" {
+		problems = append(problems, fmt.Sprintf(
+			"ECOM_PRODUCT_SERVICE_PRODUCT_IMAGE_FIT '%s' invalid, "+
+				"must be 'crop' or 'pad'", ProductImageFit))
+	}
+	switch MediaScanner {
+	case "none":
+	case "clamav":
+		if strings.TrimSpace(MediaScannerAddress) == "" {
+			problems = append(problems, "ECOM_PRODUCT_SERVICE_MEDIA_SCANNER_ADDRESS "+
+				"required by media scanner clamav")
+		}
+	case "http":
+		u, err := url.Parse(MediaScannerAddress)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") ||
+			u.Host == "" {
+			problems = append(problems, fmt.Sprintf(
+				"ECOM_PRODUCT_SERVICE_MEDIA_SCANNER_ADDRESS '%s' invalid, "+
+					"must be http or https URL of scanning API",
+				MediaScannerAddress))
+		}
+	default:
+		problems = append(problems, fmt.Sprintf(
+			"ECOM_PRODUCT_SERVICE_MEDIA_SCANNER '%s' invalid, must be "+
+				"none, clamav, or http", MediaScanner))
+	}
+	if VolumetricWeightDivisor <= 0 {
+		problems = append(problems,
+			"ECOM_PRODUCT_SERVICE_VOLUMETRIC_WEIGHT_DIVISOR must be positive")
+	}
+	if ProductCacheTTL <= 0 {
+		problems = append(problems,
+			"ECOM_PRODUCT_SERVICE_PRODUCT_CACHE_TTL must be positive")
+	}
+	if len(Currency) != 3 ||
+		strings.Trim(Currency, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		problems = append(problems, fmt.Sprintf("ECOM_PRODUCT_SERVICE_CURRENCY "+
+			"'%s' invalid, must be ISO 4217 code like 'IDR'", Currency))
+	}
+	if ProductViewWindow <= 0 {
+		problems = append(problems,
+			"ECOM_PRODUCT_SERVICE_PRODUCT_VIEW_WINDOW must be positive")
+	}
+	switch SearchBackend {
+	case "postgres":
+	case "elasticsearch", "meilisearch":
+		if strings.TrimSpace(SearchURL) == "" {
+			problems = append(problems, fmt.Sprintf(
+				"ECOM_PRODUCT_SERVICE_SEARCH_URL required by search backend %s",
+				SearchBackend))
+		}
+	default:
+		problems = append(problems, fmt.Sprintf(
+			"ECOM_PRODUCT_SERVICE_SEARCH_BACKEND '%s' invalid, must be "+
+				"postgres, elasticsearch, or meilisearch", SearchBackend))
+	}
+
+	if GRPCAddr != "" && strings.TrimSpace(InternalServiceToken) == "" {
+		problems = append(problems, "ECOM_PRODUCT_SERVICE_INTERNAL_SERVICE_TOKEN "+
+			"required by gRPC server ECOM_PRODUCT_SERVICE_GRPC_ADDR")
+	}
+	if ProductEventRetention <= 0 {
+		problems = append(problems,
+			"ECOM_PRODUCT_SERVICE_PRODUCT_EVENT_RETENTION must be positive")
+	}
+	if ExportJobTTL <= 0 {
+		problems = append(problems,
+			"ECOM_PRODUCT_SERVICE_EXPORT_JOB_TTL must be positive")
+	}
+
+	if ScheduleCleanupMedia < 0 || ScheduleInventoryReport < 0 ||
+		SchedulePurgeProductEvents < 0 {
+		problems = append(problems, "ECOM_PRODUCT_SERVICE_SCHEDULE_CLEANUP_MEDIA, "+
+			"ECOM_PRODUCT_SERVICE_SCHEDULE_INVENTORY_REPORT, and "+
+			"ECOM_PRODUCT_SERVICE_SCHEDULE_PURGE_PRODUCT_EVENTS must be "+
+			"non-negative, zero disables the task")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("config invalid => %s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// CheckMediaRoot check media root directory exists and is writable,
+// so the service fails at startup instead of at the first image upload
+func CheckMediaRoot(dir string) error {
+	info, err := os.Stat(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("media root %s not found, "+
+			"create it or set ECOM_PRODUCT_SERVICE_MEDIA_ROOT", dir)
+	} else if err != nil {
+		return fmt.Errorf("media root %s invalid => %s", dir, err.Error())
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("media root %s is not a directory", dir)
+	}
+
+	probe, err := os.CreateTemp(dir, ".write-check-*")
+	if err != nil {
+		return fmt.Errorf("media root %s not writable => %s",
+			dir, err.Error())
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+
+	return nil
+}
+
+// GetDBConfig get database connection config of database name,
+// full DSN override and read replica DSN only applied to the service
+// database DBName so testing databases are never connected through it
+func GetDBConfig(dbname string) map[string]string {
+	DBConfig := map[string]string{
+		"user":     DBUsername,
+		"password": DBPassword,
+		"dbname":   dbname,
+		"host":     DBHost,
+		"port":     DBPort,
+		"sslmode":  DBSSLMode,
+	}
+	if dbname == DBName {
+		DBConfig["dsn"] = DBDSN
+		DBConfig["replica_dsn"] = DBReplicaDSN
+	}
+
+	return DBConfig
+}
+
+// GetDBConnString get lib/pq connection string of database connection
+// config, config "dsn" is used as is if it's set, and host and port
+// are left to lib/pq defaults (local socket or localhost:5432) if empty
+func GetDBConnString(DBConfig map[string]string) string {
+	if DBConfig["dsn"] != "" {
+		return DBConfig["dsn"]
+	}
+
+	sslmode := DBConfig["sslmode"]
+	if sslmode == "" {
+		sslmode = "disable"
+	}
+
+	params := []string{
+		"user=" + quoteConnValue(DBConfig["user"]),
+		"password=" + quoteConnValue(DBConfig["password"]),
+		"dbname=" + quoteConnValue(DBConfig["dbname"]),
+		"sslmode=" + quoteConnValue(sslmode),
+	}
+	for _, key := range []string{"host", "port"} {
+		if DBConfig[key] != "" {
+			params = append(params, key+"="+quoteConnValue(DBConfig[key]))
+		}
+	}
+
+	return strings.Join(params, " ")
+}
+
+// quoteConnValue quote connection string value if it's empty
+// or contain space, quote, or backslash
+func quoteConnValue(v string) string {
+	if v != "" && !strings.ContainsAny(v, ` '\`) {
+		return v
+	}
+
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `'`, `\'`)
+	return "'" + v + "'"
+}
+
+// getEnvInt get integer environment variable, or default value if not set
+func getEnvInt(key string, defaultValue int) (int, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return defaultValue, nil
+	}
+
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s invalid => %s", key, err.Error())
+	}
+
+	return i, nil
+}
+
+// getEnvBool get boolean environment variable (e.g. "true", "1"),
+// or default value if not set
+func getEnvBool(key string, defaultValue bool) (bool, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return defaultValue, nil
+	}
+
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("%s invalid => %s", key, err.Error())
+	}
+
+	return b, nil
+}
+
+// getEnvDuration get duration environment variable (e.g. "5m"),
+// or default value if not set
+func getEnvDuration(key string, defaultValue time.Duration) (
+	time.Duration, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return defaultValue, nil
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s invalid => %s", key, err.Error())
+	}
+
+	return d, nil
+}
+
+// getEnvInts get environment variable of comma separated integers,
+// or empty slice if not set
+func getEnvInts(key string) ([]int, error) {
+	ints := []int{}
+	v := os.Getenv(key)
+	if strings.TrimSpace(v) == "" {
+		return ints, nil
+	}
+
+	for _, rawInt := range strings.Split(v, ",") {
+		i, err := strconv.Atoi(strings.TrimSpace(rawInt))
+		if err != nil {
+			return nil, fmt.Errorf("%s invalid, must be comma separated "+
+				"integers => %s", key, err.Error())
+		}
+
+		ints = append(ints, i)
+	}
+
+	return ints, nil
+}
+
+// getEnvSynonyms get synonym dictionary environment variable of
+// comma separated word and its pipe separated synonyms
+// (e.g. "hp:handphone|smartphone,tv:televisi"), words are lowercased,
+// or empty dictionary if not set
+func getEnvSynonyms(key string) (map[string][]string, error) {
+	synonyms := map[string][]string{}
+	v := os.Getenv(key)
+	if strings.TrimSpace(v) == "" {
+		return synonyms, nil
+	}
+
+	for _, entry := range strings.Split(v, ",") {
+		word, rawSynonyms, ok := strings.Cut(entry, ":")
+		word = strings.ToLower(strings.TrimSpace(word))
+		if !ok || word == "" || strings.ContainsAny(word, " \t") {
+			return nil, fmt.Errorf("%s invalid => entry '%s' must be "+
+				"a word and its synonyms like 'hp:handphone'", key, entry)
+		}
+
+		for _, synonym := range strings.Split(rawSynonyms, "|") {
+			synonym = strings.TrimSpace(synonym)
+			if synonym == "" {
+				return nil, fmt.Errorf("%s invalid => entry '%s' has "+
+					"empty synonym", key, entry)
+			}
+			synonyms[word] = append(synonyms[word], synonym)
+		}
+	}
+
+	return synonyms, nil
+}
+
+// getEnvPermissions get permissions environment variable of comma
+// separated action and its pipe separated allowed roles
+// (e.g. "stock.decrease:seller|service,stats.read:admin"), action
+// without roles (e.g. "stats.read:") is allowed to no role,
+// or empty permissions if not set
+func getEnvPermissions(key string) (map[string][]string, error) {
+	permissions := map[string][]string{}
+	v := os.Getenv(key)
+	if strings.TrimSpace(v) == "" {
+		return permissions, nil
+	}
+
+	for _, entry := range strings.Split(v, ",") {
+		action, rawRoles, ok := strings.Cut(entry, ":")
+		action = strings.TrimSpace(action)
+		if !ok || action == "" {
+			return nil, fmt.Errorf("%s invalid => entry '%s' must be "+
+				"an action and its roles like 'stats.read:admin'", key, entry)
+		}
+
+		roles := []string{}
+		if strings.TrimSpace(rawRoles) != "" {
+			for _, role := range strings.Split(rawRoles, "|") {
+				role = strings.TrimSpace(role)
+				if role == "" {
+					return nil, fmt.Errorf("%s invalid => entry '%s' has "+
+						"empty role", key, entry)
+				}
+				roles = append(roles, role)
+			}
+		}
+		permissions[action] = roles
+	}
+
+	return permissions, nil
+}
+
+// getEnvImageSizes get image sizes environment variable of comma
+// separated sizes like "320x320", or default value if not set
+func getEnvImageSizes(key string, defaultValue string) ([]ImageSize,
+	error) {
+	v := os.Getenv(key)
+	if strings.TrimSpace(v) == "" {
+		v = defaultValue
+	}
+
+	sizes := []ImageSize{}
+	for _, rawSize := range strings.Split(v, ",") {
+		size, err := parseImageSize(rawSize, "x")
+		if err != nil {
+			return nil, fmt.Errorf("%s invalid => %s", key, err.Error())
+		}
+
+		sizes = append(sizes, size)
+	}
+
+	return sizes, nil
+}
+
+// getEnvImageSize get image size or aspect ratio environment variable
+// of width and height separated by sep (e.g. "320x320" or "1:1"),
+// or zero size if not set
+func getEnvImageSize(key string, sep string) (ImageSize, error) {
+	v := os.Getenv(key)
+	if strings.TrimSpace(v) == "" {
+		return ImageSize{}, nil
+	}
+
+	size, err := parseImageSize(v, sep)
+	if err != nil {
+		return ImageSize{}, fmt.Errorf("%s invalid => %s", key, err.Error())
+	}
+
+	return size, nil
+}
+
+// parseImageSize parse positive width and height separated by sep
+func parseImageSize(v string, sep string) (ImageSize, error) {
+	rawWidth, rawHeight, _ := strings.Cut(strings.TrimSpace(v), sep)
+	width, errW := strconv.Atoi(rawWidth)
+	height, errH := strconv.Atoi(rawHeight)
+	if errW != nil || errH != nil || width <= 0 || height <= 0 {
+		return ImageSize{}, fmt.Errorf("'%s' must be positive width "+
+			"and height like '2%s1'", v, sep)
+	}
+
+	return ImageSize{Width: width, Height: height}, nil
+}
@@ -3,7 +3,14 @@ Package config collection of configuration
 */
 package config
 
-import "testing"
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
 
 // TestInitConfig test InitConfig
 func TestInitConfig(t *testing.T) {
@@ -13,3 +20,617 @@ func TestInitConfig(t *testing.T) {
 			err.Error())
 	}
 }
+
+// TestGetEnv test getEnvInt and getEnvDuration
+func TestGetEnv(t *testing.T) {
+	t.Setenv("ECOM_PRODUCT_SERVICE_TEST_INT", "10")
+	t.Setenv("ECOM_PRODUCT_SERVICE_TEST_DURATION", "90s")
+	t.Setenv("ECOM_PRODUCT_SERVICE_TEST_INVALID", "ten")
+
+	// create testing table
+	testTable := []struct {
+		TestName            string
+		Key                 string
+		ExpectedInt         int
+		ExpectedDuration    time.Duration
+		ExpectedIntErr      bool
+		ExpectedDurationErr bool
+	}{
+		{
+			TestName:         "Not set",
+			Key:              "ECOM_PRODUCT_SERVICE_TEST_NOT_SET",
+			ExpectedInt:      1,
+			ExpectedDuration: time.Minute,
+		},
+		{
+			TestName:            "Integer",
+			Key:                 "ECOM_PRODUCT_SERVICE_TEST_INT",
+			ExpectedInt:         10,
+			ExpectedDurationErr: true,
+		},
+		{
+			TestName:         "Duration",
+			Key:              "ECOM_PRODUCT_SERVICE_TEST_DURATION",
+			ExpectedDuration: 90 * time.Second,
+			ExpectedIntErr:   true,
+		},
+		{
+			TestName:            "Invalid",
+			Key:                 "ECOM_PRODUCT_SERVICE_TEST_INVALID",
+			ExpectedIntErr:      true,
+			ExpectedDurationErr: true,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		i, err := getEnvInt(test.Key, 1)
+		if (err != nil) != test.ExpectedIntErr {
+			t.Errorf("[%s] Expected integer error %t, but got %v",
+				test.TestName, test.ExpectedIntErr, err)
+		} else if err == nil && i != test.ExpectedInt {
+			t.Errorf("[%s] Expected integer %d, but got %d",
+				test.TestName, test.ExpectedInt, i)
+		}
+
+		d, err := getEnvDuration(test.Key, time.Minute)
+		if (err != nil) != test.ExpectedDurationErr {
+			t.Errorf("[%s] Expected duration error %t, but got %v",
+				test.TestName, test.ExpectedDurationErr, err)
+		} else if err == nil && d != test.ExpectedDuration {
+			t.Errorf("[%s] Expected duration %s, but got %s",
+				test.TestName, test.ExpectedDuration, d)
+		}
+	}
+}
+
+// TestLoadConfigFile test InitConfig loading config file
+// without overriding environment variables
+func TestLoadConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.env")
+	err := os.WriteFile(path, []byte(
+		"ECOM_PRODUCT_SERVICE_DB_NAME=from_file\n"+
+			"ECOM_PRODUCT_SERVICE_DB_USERNAME=from_file\n"), 0600)
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+	t.Setenv("ECOM_PRODUCT_SERVICE_DB_NAME", "")
+	os.Unsetenv("ECOM_PRODUCT_SERVICE_DB_NAME")
+	t.Setenv("ECOM_PRODUCT_SERVICE_DB_USERNAME", "from_env")
+
+	File = path
+	defer func() { File = "" }()
+	err = InitConfig()
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+	if DBName != "from_file" || DBUsername != "from_env" {
+		t.Errorf("Expected DB name from_file and DB username from_env, "+
+			"but got %s and %s", DBName, DBUsername)
+	}
+
+	// missing config file given explicitly is an error
+	File = filepath.Join(t.TempDir(), "missing.env")
+	err = InitConfig()
+	if err == nil {
+		t.Errorf("Expected error for missing config file, but got nil")
+	}
+}
+
+// TestValidate test Validate
+func TestValidate(t *testing.T) {
+	// create testing table
+	testTable := []struct {
+		TestName    string
+		Modify      func()
+		ExpectedErr string
+	}{
+		{
+			TestName: "Valid",
+			Modify:   func() {},
+		},
+		{
+			TestName: "Missing required settings",
+			Modify: func() {
+				DBName = ""
+				JWTSecretKey = " "
+			},
+			ExpectedErr: "missing required setting " +
+				"ECOM_PRODUCT_SERVICE_DB_NAME, " +
+				"ECOM_PRODUCT_SERVICE_JWT_SECRET_KEY",
+		},
+		{
+			TestName:    "Unknown environment",
+			Modify:      func() { Env = "staging" },
+			ExpectedErr: "ECOM_PRODUCT_SERVICE_ENV 'staging' invalid",
+		},
+		{
+			TestName: "Mock authentication without account service",
+			Modify: func() {
+				DevAuth = true
+				AccountServiceURL = ""
+			},
+		},
+		{
+			TestName: "Mock authentication in production",
+			Modify: func() {
+				DevAuth = true
+				Env = "production"
+			},
+			ExpectedErr: "ECOM_PRODUCT_SERVICE_DEV_AUTH must not be enabled",
+		},
+		{
+			TestName:    "Negative pool size",
+			Modify:      func() { DBMaxOpenConns = -1 },
+			ExpectedErr: "must be non-negative",
+		},
+		{
+			TestName:    "Zero product view window",
+			Modify:      func() { ProductViewWindow = 0 },
+			ExpectedErr: "PRODUCT_VIEW_WINDOW must be positive",
+		},
+		{
+			TestName:    "Search backend unknown",
+			Modify:      func() { SearchBackend = "solr" },
+			ExpectedErr: "ECOM_PRODUCT_SERVICE_SEARCH_BACKEND 'solr' invalid",
+		},
+		{
+			TestName:    "Search engine without URL",
+			Modify:      func() { SearchBackend = "meilisearch" },
+			ExpectedErr: "ECOM_PRODUCT_SERVICE_SEARCH_URL required",
+		},
+		{
+			TestName:    "Negative slow query threshold",
+			Modify:      func() { DBSlowQueryThreshold = -time.Second },
+			ExpectedErr: "zero disables slow query logging",
+		},
+		{
+			TestName:    "Negative schedule interval",
+			Modify:      func() { ScheduleInventoryReport = -time.Hour },
+			ExpectedErr: "zero disables the task",
+		},
+		{
+			TestName:    "Relative media root",
+			Modify:      func() { MediaRoot = "./../media" },
+			ExpectedErr: "ECOM_PRODUCT_SERVICE_MEDIA_ROOT './../media' invalid",
+		},
+		{
+			TestName: "Media CDN URL",
+			Modify:   func() { MediaCDNURL = "https://cdn.example.com" },
+		},
+		{
+			TestName:    "Media CDN URL invalid",
+			Modify:      func() { MediaCDNURL = "cdn.example.com" },
+			ExpectedErr: "ECOM_PRODUCT_SERVICE_MEDIA_CDN_URL 'cdn.example.com' invalid",
+		},
+		{
+			TestName:    "Product image fit invalid",
+			Modify:      func() { ProductImageFit = "stretch" },
+			ExpectedErr: "ECOM_PRODUCT_SERVICE_PRODUCT_IMAGE_FIT 'stretch' invalid",
+		},
+		{
+			TestName: "Media scanner clamav",
+			Modify: func() {
+				MediaScanner = "clamav"
+				MediaScannerAddress = "localhost:3310"
+			},
+		},
+		{
+			TestName:    "Media scanner clamav without address",
+			Modify:      func() { MediaScanner = "clamav" },
+			ExpectedErr: "ECOM_PRODUCT_SERVICE_MEDIA_SCANNER_ADDRESS required",
+		},
+		{
+			TestName: "Media scanner http invalid",
+			Modify: func() {
+				MediaScanner = "http"
+				MediaScannerAddress = "localhost:8080"
+			},
+			ExpectedErr: "ECOM_PRODUCT_SERVICE_MEDIA_SCANNER_ADDRESS 'localhost:8080' invalid",
+		},
+		{
+			TestName:    "Media scanner invalid",
+			Modify:      func() { MediaScanner = "antivirus" },
+			ExpectedErr: "ECOM_PRODUCT_SERVICE_MEDIA_SCANNER 'antivirus' invalid",
+		},
+		{
+			TestName:    "Currency invalid",
+			Modify:      func() { Currency = "RP" },
+			ExpectedErr: "ECOM_PRODUCT_SERVICE_CURRENCY 'RP' invalid",
+		},
+		{
+			TestName: "gRPC server",
+			Modify: func() {
+				GRPCAddr = ":9020"
+				InternalServiceToken = "token"
+			},
+		},
+		{
+			TestName:    "gRPC server without service token",
+			Modify:      func() { GRPCAddr = ":9020" },
+			ExpectedErr: "ECOM_PRODUCT_SERVICE_INTERNAL_SERVICE_TOKEN required",
+		},
+		{
+			TestName:    "Zero product event retention",
+			Modify:      func() { ProductEventRetention = 0 },
+			ExpectedErr: "PRODUCT_EVENT_RETENTION must be positive",
+		},
+		{
+			TestName:    "Zero export job TTL",
+			Modify:      func() { ExportJobTTL = 0 },
+			ExpectedErr: "EXPORT_JOB_TTL must be positive",
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		Env = "development"
+		DBName = "db"
+		DBUsername = "user"
+		JWTSecretKey = "secret"
+		FrontendURL = "http://localhost:3000"
+		AccountServiceURL = "http://localhost:8010"
+		DBMaxOpenConns = 25
+		DBMaxIdleConns = 25
+		ProductCacheTTL = time.Minute
+		ProductViewWindow = 30 * time.Minute
+		Currency = "IDR"
+		SearchBackend = "postgres"
+		SearchURL = ""
+		DBSSLMode = "disable"
+		DBPort = ""
+		DevAuth = false
+		ScheduleInventoryReport = 0
+		GRPCAddr = ""
+		InternalServiceToken = ""
+		ProductEventRetention = 7 * 24 * time.Hour
+		ExportJobTTL = 24 * time.Hour
+		DBSlowQueryThreshold = 0
+		MediaRoot = "/srv/media"
+		MediaCDNURL = ""
+		ProductImageFit = "crop"
+		MediaScanner = "none"
+		MediaScannerAddress = ""
+		test.Modify()
+
+		err := Validate()
+		if test.ExpectedErr == "" && err != nil {
+			t.Errorf("[%s] Expected error nil, but got error => %s",
+				test.TestName, err.Error())
+		} else if test.ExpectedErr != "" && (err == nil ||
+			!strings.Contains(err.Error(), test.ExpectedErr)) {
+			t.Errorf("[%s] Expected error containing %q, but got %v",
+				test.TestName, test.ExpectedErr, err)
+		}
+	}
+}
+
+// TestCheckMediaRoot test CheckMediaRoot
+func TestCheckMediaRoot(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	err := os.WriteFile(file, []byte("x"), 0600)
+	if err != nil {
+		t.Fatalf("There's an error when creating file => %s", err.Error())
+	}
+
+	// create testing table
+	testTable := []struct {
+		TestName    string
+		Dir         string
+		ExpectedErr string
+	}{
+		{TestName: "Writable directory", Dir: dir},
+		{
+			TestName:    "Not found",
+			Dir:         filepath.Join(dir, "missing"),
+			ExpectedErr: "not found",
+		},
+		{
+			TestName:    "Not a directory",
+			Dir:         file,
+			ExpectedErr: "is not a directory",
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		err := CheckMediaRoot(test.Dir)
+		if test.ExpectedErr == "" && err != nil {
+			t.Errorf("[%s] Expected error nil, but got error => %s",
+				test.TestName, err.Error())
+		} else if test.ExpectedErr != "" && (err == nil ||
+			!strings.Contains(err.Error(), test.ExpectedErr)) {
+			t.Errorf("[%s] Expected error containing %q, but got %v",
+				test.TestName, test.ExpectedErr, err)
+		}
+	}
+}
+
+// TestGetDBConnString test GetDBConnString
+func TestGetDBConnString(t *testing.T) {
+	// create testing table
+	testTable := []struct {
+		TestName           string
+		DBConfig           map[string]string
+		ExpectedConnString string
+	}{
+		{
+			TestName: "Local socket",
+			DBConfig: map[string]string{
+				"user": "postgres", "password": "secret", "dbname": "product",
+			},
+			ExpectedConnString: "user=postgres password=secret " +
+				"dbname=product sslmode=disable",
+		},
+		{
+			TestName: "Managed instance",
+			DBConfig: map[string]string{
+				"user": "postgres", "password": "it's secret",
+				"dbname": "product", "host": "db.example.com",
+				"port": "6432", "sslmode": "require",
+			},
+			ExpectedConnString: `user=postgres password='it\'s secret' ` +
+				"dbname=product sslmode=require host=db.example.com port=6432",
+		},
+		{
+			TestName: "Empty password",
+			DBConfig: map[string]string{"user": "postgres", "dbname": "product"},
+			ExpectedConnString: "user=postgres password='' " +
+				"dbname=product sslmode=disable",
+		},
+		{
+			TestName: "Full DSN override",
+			DBConfig: map[string]string{
+				"user": "postgres", "dbname": "product",
+				"dsn": "postgres://u:p@db.example.com/product?sslmode=require",
+			},
+			ExpectedConnString: "postgres://u:p@db.example.com/product" +
+				"?sslmode=require",
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		connString := GetDBConnString(test.DBConfig)
+		if connString != test.ExpectedConnString {
+			t.Errorf("[%s] Expected connection string %q, but got %q",
+				test.TestName, test.ExpectedConnString, connString)
+		}
+	}
+}
+
+// TestGetEnvInts test getEnvInts
+func TestGetEnvInts(t *testing.T) {
+	// create testing table
+	testTable := []struct {
+		TestName     string
+		Value        string
+		ExpectedInts []int
+		ExpectedErr  bool
+	}{
+		{
+			TestName:     "Not set",
+			ExpectedInts: []int{},
+		},
+		{
+			TestName:     "Integers",
+			Value:        "1, 20,3",
+			ExpectedInts: []int{1, 20, 3},
+		},
+		{
+			TestName:    "Not integer",
+			Value:       "1,two",
+			ExpectedErr: true,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		t.Setenv("ECOM_PRODUCT_SERVICE_TEST_INTS", test.Value)
+
+		ints, err := getEnvInts("ECOM_PRODUCT_SERVICE_TEST_INTS")
+		if (err != nil) != test.ExpectedErr {
+			t.Errorf("[%s] Expected error %t, but got %v",
+				test.TestName, test.ExpectedErr, err)
+		} else if err == nil && !reflect.DeepEqual(ints, test.ExpectedInts) {
+			t.Errorf("[%s] Expected integers %v, but got %v",
+				test.TestName, test.ExpectedInts, ints)
+		}
+	}
+}
+
+// TestGetEnvSynonyms test getEnvSynonyms
+func TestGetEnvSynonyms(t *testing.T) {
+	// create testing table
+	testTable := []struct {
+		TestName         string
+		Value            string
+		ExpectedSynonyms map[string][]string
+		ExpectedErr      bool
+	}{
+		{
+			TestName:         "Not set",
+			ExpectedSynonyms: map[string][]string{},
+		},
+		{
+			TestName: "Synonyms",
+			Value:    "HP:handphone|smartphone, tv:televisi",
+			ExpectedSynonyms: map[string][]string{
+				"hp": {"handphone", "smartphone"},
+				"tv": {"televisi"},
+			},
+		},
+		{
+			TestName:    "Synonyms missing",
+			Value:       "hp",
+			ExpectedErr: true,
+		},
+		{
+			TestName:    "Synonym empty",
+			Value:       "hp:handphone|",
+			ExpectedErr: true,
+		},
+		{
+			TestName:    "Word with space",
+			Value:       "hand phone:handphone",
+			ExpectedErr: true,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		t.Setenv("ECOM_PRODUCT_SERVICE_TEST_SYNONYMS", test.Value)
+
+		synonyms, err := getEnvSynonyms("ECOM_PRODUCT_SERVICE_TEST_SYNONYMS")
+		if (err != nil) != test.ExpectedErr {
+			t.Errorf("[%s] Expected error %t, but got %v",
+				test.TestName, test.ExpectedErr, err)
+		} else if err == nil && !reflect.DeepEqual(synonyms,
+			test.ExpectedSynonyms) {
+			t.Errorf("[%s] Expected synonyms %v, but got %v",
+				test.TestName, test.ExpectedSynonyms, synonyms)
+		}
+	}
+}
+
+// TestGetEnvPermissions test getEnvPermissions
+func TestGetEnvPermissions(t *testing.T) {
+	// create testing table
+	testTable := []struct {
+		TestName            string
+		Value               string
+		ExpectedPermissions map[string][]string
+		ExpectedErr         bool
+	}{
+		{
+			TestName:            "Not set",
+			ExpectedPermissions: map[string][]string{},
+		},
+		{
+			TestName: "Permissions",
+			Value:    "stock.decrease:seller|service, stats.read:",
+			ExpectedPermissions: map[string][]string{
+				"stock.decrease": {"seller", "service"},
+				"stats.read":     {},
+			},
+		},
+		{
+			TestName:    "Roles missing",
+			Value:       "stats.read",
+			ExpectedErr: true,
+		},
+		{
+			TestName:    "Role empty",
+			Value:       "stock.decrease:seller|",
+			ExpectedErr: true,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		t.Setenv("ECOM_PRODUCT_SERVICE_TEST_PERMISSIONS", test.Value)
+
+		permissions, err := getEnvPermissions(
+			"ECOM_PRODUCT_SERVICE_TEST_PERMISSIONS")
+		if (err != nil) != test.ExpectedErr {
+			t.Errorf("[%s] Expected error %t, but got %v",
+				test.TestName, test.ExpectedErr, err)
+		} else if err == nil && !reflect.DeepEqual(permissions,
+			test.ExpectedPermissions) {
+			t.Errorf("[%s] Expected permissions %v, but got %v",
+				test.TestName, test.ExpectedPermissions, permissions)
+		}
+	}
+}
+
+// TestGetEnvImageSizes test getEnvImageSizes
+func TestGetEnvImageSizes(t *testing.T) {
+	// create testing table
+	testTable := []struct {
+		TestName      string
+		Value         string
+		ExpectedSizes []ImageSize
+		ExpectedErr   bool
+	}{
+		{
+			TestName:      "Not set",
+			ExpectedSizes: []ImageSize{{160, 160}},
+		},
+		{
+			TestName:      "Sizes",
+			Value:         "320x240, 640x480",
+			ExpectedSizes: []ImageSize{{320, 240}, {640, 480}},
+		},
+		{
+			TestName:    "Height missing",
+			Value:       "320",
+			ExpectedErr: true,
+		},
+		{
+			TestName:    "Zero width",
+			Value:       "0x320",
+			ExpectedErr: true,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		t.Setenv("ECOM_PRODUCT_SERVICE_TEST_SIZES", test.Value)
+
+		sizes, err := getEnvImageSizes("ECOM_PRODUCT_SERVICE_TEST_SIZES",
+			"160x160")
+		if (err != nil) != test.ExpectedErr {
+			t.Errorf("[%s] Expected error %t, but got %v",
+				test.TestName, test.ExpectedErr, err)
+		} else if err == nil && !reflect.DeepEqual(sizes,
+			test.ExpectedSizes) {
+			t.Errorf("[%s] Expected sizes %v, but got %v",
+				test.TestName, test.ExpectedSizes, sizes)
+		}
+	}
+}
+
+// TestGetEnvImageSize test getEnvImageSize
+func TestGetEnvImageSize(t *testing.T) {
+	// create testing table
+	testTable := []struct {
+		TestName     string
+		Value        string
+		ExpectedSize ImageSize
+		ExpectedErr  bool
+	}{
+		{
+			TestName: "Not set",
+		},
+		{
+			TestName:     "Aspect ratio",
+			Value:        "4:3",
+			ExpectedSize: ImageSize{4, 3},
+		},
+		{
+			TestName:    "Wrong separator",
+			Value:       "4x3",
+			ExpectedErr: true,
+		},
+		{
+			TestName:    "Negative",
+			Value:       "-1:1",
+			ExpectedErr: true,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		t.Setenv("ECOM_PRODUCT_SERVICE_TEST_RATIO", test.Value)
+
+		size, err := getEnvImageSize("ECOM_PRODUCT_SERVICE_TEST_RATIO", ":")
+		if (err != nil) != test.ExpectedErr {
+			t.Errorf("[%s] Expected error %t, but got %v",
+				test.TestName, test.ExpectedErr, err)
+		} else if size != test.ExpectedSize {
+			t.Errorf("[%s] Expected size %v, but got %v",
+				test.TestName, test.ExpectedSize, size)
+		}
+	}
+}
@@ -0,0 +1,280 @@
+/*
+Package dbmetrics containing database driver wrapper recording
+latency of every query by query name and logging slow queries
+*/
+package dbmetrics
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maximum length of logged slow query
+const maxLoggedQueryLength = 1000
+
+// QueryStats contain latency metrics of queries of a name
+type QueryStats struct {
+	Name         string  `json:"name"`
+	Count        int     `json:"count"`
+	Errors       int     `json:"errors"`
+	Slow         int     `json:"slow"`
+	TotalSeconds float64 `json:"total_seconds"`
+	AvgSeconds   float64 `json:"avg_seconds"`
+	MaxSeconds   float64 `json:"max_seconds"`
+}
+
+// Recorder record latency of every query by query name, and log query
+// taking at least slow threshold, slow queries aren't logged
+// if threshold is not positive
+type Recorder struct {
+	SlowThreshold time.Duration
+
+	mu    sync.Mutex
+	stats map[string]*QueryStats
+}
+
+// NewRecorder create query recorder logging query taking
+// at least slow threshold
+func NewRecorder(slowThreshold time.Duration) *Recorder {
+	return &Recorder{
+		SlowThreshold: slowThreshold,
+		stats:         map[string]*QueryStats{},
+	}
+}
+
+// Record record latency of query with count of its arguments,
+// query arguments are never logged since they may contain user data
+func (r *Recorder) Record(query string, argCount int,
+	duration time.Duration, err error) {
+	name := QueryName(query)
+	slow := r.SlowThreshold > 0 && duration >= r.SlowThreshold
+
+	r.mu.Lock()
+	stats, ok := r.stats[name]
+	if !ok {
+		stats = &QueryStats{Name: name}
+		r.stats[name] = stats
+	}
+	stats.Count++
+	if err != nil {
+		stats.Errors++
+	}
+	if slow {
+		stats.Slow++
+	}
+	stats.TotalSeconds += duration.Seconds()
+	if duration.Seconds() > stats.MaxSeconds {
+		stats.MaxSeconds = duration.Seconds()
+	}
+	r.mu.Unlock()
+
+	if slow {
+		log.Printf("Slow query %s took %s => %s (%d arguments redacted)",
+			name, duration, normalizeQuery(query), argCount)
+	}
+}
+
+// Stats get latency metrics of every query name recorded,
+// most total time first
+func (r *Recorder) Stats() []QueryStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := []QueryStats{}
+	for _, s := range r.stats {
+		s := *s
+		s.AvgSeconds = s.TotalSeconds / float64(s.Count)
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].TotalSeconds != stats[j].TotalSeconds {
+			return stats[i].TotalSeconds > stats[j].TotalSeconds
+		}
+		return stats[i].Name < stats[j].Name
+	})
+
+	return stats
+}
+
+// QueryName get name of query from its statement and first table,
+// e.g. "SELECT product_productinfo"
+func QueryName(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	name := strings.ToUpper(fields[0])
+	for i, field := range fields[:len(fields)-1] {
+		switch strings.ToUpper(field) {
+		case "FROM", "INTO", "UPDATE", "TABLE":
+		default:
+			continue
+		}
+
+		table := fields[i+1]
+		if end := strings.IndexAny(table, "(),;"); end >= 0 {
+			table = table[:end]
+		}
+		if isIdentifier(table) {
+			return name + " " + strings.ToLower(table)
+		}
+	}
+
+	return name
+}
+
+// isIdentifier check if s is SQL identifier, optionally schema qualified
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, c := range s {
+		if !(c == '_' || c == '.' || (c >= 'a' && c <= 'z') ||
+			(c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// normalizeQuery get query in one line with collapsed whitespaces,
+// truncated to maximum logged length
+func normalizeQuery(query string) string {
+	query = strings.Join(strings.Fields(query), " ")
+	if len(query) > maxLoggedQueryLength {
+		query = query[:maxLoggedQueryLength] + "…"
+	}
+
+	return query
+}
+
+// Connector get database connector recording queries of connections
+// connected by connector
+func (r *Recorder) Connector(connector driver.Connector) driver.Connector {
+	return recordingConnector{connector: connector, recorder: r}
+}
+
+// recordingConnector database connector of recording connections
+type recordingConnector struct {
+	connector driver.Connector
+	recorder  *Recorder
+}
+
+// Connect connect recording connection
+func (c recordingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &recordingConn{Conn: conn, recorder: c.recorder}, nil
+}
+
+// Driver get driver of the wrapped connector
+func (c recordingConnector) Driver() driver.Driver {
+	return c.connector.Driver()
+}
+
+// recordingConn database connection recording latency of queries,
+// until their rows are ready to read
+type recordingConn struct {
+	driver.Conn
+	recorder *Recorder
+}
+
+// QueryContext run query and record its latency
+func (c *recordingConn) QueryContext(ctx context.Context, query string,
+	args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	start := time.Now()
+	rows, err := queryer.QueryContext(ctx, query, args)
+	if err != driver.ErrSkip {
+		c.recorder.Record(query, len(args), time.Since(start), err)
+	}
+
+	return rows, err
+}
+
+// ExecContext run statement and record its latency
+func (c *recordingConn) ExecContext(ctx context.Context, query string,
+	args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	start := time.Now()
+	result, err := execer.ExecContext(ctx, query, args)
+	if err != driver.ErrSkip {
+		c.recorder.Record(query, len(args), time.Since(start), err)
+	}
+
+	return result, err
+}
+
+// PrepareContext prepare statement by the wrapped connection
+func (c *recordingConn) PrepareContext(ctx context.Context,
+	query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+
+	return c.Conn.Prepare(query)
+}
+
+// BeginTx begin transaction by the wrapped connection
+func (c *recordingConn) BeginTx(ctx context.Context,
+	opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	if opts.Isolation != driver.IsolationLevel(sql.LevelDefault) ||
+		opts.ReadOnly {
+		return nil, errors.New("driver doesn't support isolation level " +
+			"and read-only transaction")
+	}
+
+	return c.Conn.Begin()
+}
+
+// Ping ping database by the wrapped connection
+func (c *recordingConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+
+	return nil
+}
+
+// ResetSession reset session of the wrapped connection
+// before it's reused
+func (c *recordingConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+
+	return nil
+}
+
+// IsValid check if the wrapped connection is still valid to be reused
+func (c *recordingConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+
+	return true
+}
@@ -0,0 +1,139 @@
+/*
+Package dbmetrics containing database driver wrapper recording
+latency of every query by query name and logging slow queries
+*/
+package dbmetrics
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeConnector database connector of fake connections
+type fakeConnector struct{}
+
+// Connect connect fake connection
+func (fakeConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return fakeConn{}, nil
+}
+
+// Driver not used
+func (fakeConnector) Driver() driver.Driver {
+	return nil
+}
+
+// fakeConn database connection taking duration of query first argument
+// if any, failing statements containing 'fail'
+type fakeConn struct{}
+
+// Prepare not supported
+func (fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+// Close do nothing
+func (fakeConn) Close() error {
+	return nil
+}
+
+// Begin not supported
+func (fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+// ExecContext sleep duration of the first argument then fail
+// if query contains 'fail'
+func (fakeConn) ExecContext(ctx context.Context, query string,
+	args []driver.NamedValue) (driver.Result, error) {
+	if len(args) > 0 {
+		time.Sleep(time.Duration(args[0].Value.(int64)))
+	}
+	if strings.Contains(query, "fail") {
+		return nil, errors.New("failed")
+	}
+
+	return driver.RowsAffected(1), nil
+}
+
+// TestRecorder test Recorder recording queries run through its connector
+func TestRecorder(t *testing.T) {
+	logs := bytes.Buffer{}
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	recorder := NewRecorder(20 * time.Millisecond)
+	DB := sql.OpenDB(recorder.Connector(fakeConnector{}))
+	defer DB.Close()
+
+	for _, statement := range []struct {
+		Query    string
+		Duration time.Duration
+	}{
+		{"UPDATE product_productinfo SET stock = $1", 0},
+		{"UPDATE product_productinfo SET stock = $1", 30 * time.Millisecond},
+		{"DELETE FROM product_productimage WHERE fail = $1", 0},
+	} {
+		DB.ExecContext(context.Background(), statement.Query,
+			int64(statement.Duration))
+	}
+
+	// check recorded stats
+	stats := recorder.Stats()
+	if len(stats) != 2 {
+		t.Fatalf("Expected stats of 2 query names, but got %+v", stats)
+	}
+	if stats[0].Name != "UPDATE product_productinfo" || stats[0].Count != 2 ||
+		stats[0].Slow != 1 || stats[0].Errors != 0 ||
+		stats[0].MaxSeconds < 0.03 || stats[0].AvgSeconds < 0.015 {
+		t.Errorf("Expected 2 updates with 1 slow, but got %+v", stats[0])
+	}
+	if stats[1].Name != "DELETE product_productimage" || stats[1].Count != 1 ||
+		stats[1].Errors != 1 || stats[1].Slow != 0 {
+		t.Errorf("Expected 1 failed delete, but got %+v", stats[1])
+	}
+
+	// check slow query logged without its arguments
+	logged := logs.String()
+	if strings.Count(logged, "Slow query") != 1 ||
+		!strings.Contains(logged, "UPDATE product_productinfo SET stock = $1 "+
+			"(1 arguments redacted)") ||
+		strings.Contains(logged, "30000000") {
+		t.Errorf("Expected one slow query logged with arguments redacted, "+
+			"but got %s", logged)
+	}
+}
+
+// TestQueryName test QueryName
+func TestQueryName(t *testing.T) {
+	testCases := []struct {
+		Query        string
+		ExpectedName string
+	}{
+		{"SELECT id, sku FROM product_productinfo WHERE sku = $1",
+			"SELECT product_productinfo"},
+		{"INSERT INTO\n\t\tproduct_stockmovement(delta) VALUES($1)",
+			"INSERT product_stockmovement"},
+		{"update product_productinfo set stock = 0",
+			"UPDATE product_productinfo"},
+		{"SELECT COUNT(*) FROM (SELECT 1) t", "SELECT"},
+		{"REINDEX TABLE product_productimage", "REINDEX product_productimage"},
+		{"SELECT NOW()", "SELECT"},
+		{"", ""},
+	}
+
+	for _, testCase := range testCases {
+		name := QueryName(testCase.Query)
+		if name != testCase.ExpectedName {
+			t.Errorf("Expected name %q of query %q, but got %q",
+				testCase.ExpectedName, testCase.Query, name)
+		}
+	}
+}
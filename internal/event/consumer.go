@@ -0,0 +1,241 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// order event types consumed from order service
+const (
+	OrderPlaced    = "OrderPlaced"
+	OrderCancelled = "OrderCancelled"
+	OrderCompleted = "OrderCompleted"
+)
+
+// OrderItem contain ordered product and its quantity
+type OrderItem struct {
+	SKU string  `json:"sku"`
+	Qty float64 `json:"qty"`
+}
+
+// OrderEvent contain an order event from order service
+type OrderEvent struct {
+	Type    string      `json:"type"`
+	OrderID string      `json:"order_id"`
+	Items   []OrderItem `json:"items"`
+}
+
+// OrderEventHandler handle one order event
+type OrderEventHandler func(e OrderEvent) error
+
+// ParseOrderEvent parse order event from message body,
+// event type is taken from message type if not in the body
+func ParseOrderEvent(body []byte, messageType string) (OrderEvent, error) {
+	e := OrderEvent{}
+
+	err := json.Unmarshal(body, &e)
+	if err != nil {
+		return e, err
+	}
+
+	if e.Type == "" {
+		e.Type = messageType
+	}
+	if e.Type != OrderPlaced && e.Type != OrderCancelled &&
+		e.Type != OrderCompleted {
+		return e, fmt.Errorf("order event type '%s' unknown", e.Type)
+	}
+
+	for _, item := range e.Items {
+		if item.SKU == "" || item.Qty <= 0 {
+			return e, fmt.Errorf("order event item invalid => sku '%s' qty %v",
+				item.SKU, item.Qty)
+		}
+	}
+
+	return e, nil
+}
+
+// RabbitMQConsumer consumer of order events from RabbitMQ,
+// reconnecting whenever the connection to RabbitMQ closed
+type RabbitMQConsumer struct {
+	connect  func() (consumerSession, error)
+	session  *consumerSession
+	retryMin time.Duration
+	retryMax time.Duration
+}
+
+// consumerSession deliveries of one connection to message broker,
+// the deliveries channel closed when the connection or channel closed
+type consumerSession struct {
+	deliveries <-chan amqp.Delivery
+	closed     <-chan *amqp.Error
+	close      func() error
+}
+
+// NewRabbitMQConsumer connect to RabbitMQ, declare the order topic exchange
+// and a durable queue bound to order event types, the same is done again
+// on every reconnect
+func NewRabbitMQConsumer(URL string, exchange string, queue string) (
+	*RabbitMQConsumer, error) {
+	connect := func() (consumerSession, error) {
+		return dialRabbitMQ(URL, exchange, queue)
+	}
+
+	session, err := connect()
+	if err != nil {
+		return nil, err
+	}
+
+	return &RabbitMQConsumer{
+		connect:  connect,
+		session:  &session,
+		retryMin: time.Second,
+		retryMax: time.Minute,
+	}, nil
+}
+
+// dialRabbitMQ connect to RabbitMQ, declare the order topic exchange
+// and a durable queue bound to order event types, and start consuming it
+func dialRabbitMQ(URL string, exchange string, queue string) (
+	consumerSession, error) {
+	conn, err := amqp.Dial(URL)
+	if err != nil {
+		return consumerSession{}, err
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return consumerSession{}, err
+	}
+
+	err = ch.ExchangeDeclare(exchange, "topic", true, false, false, false, nil)
+	if err != nil {
+		conn.Close()
+		return consumerSession{}, err
+	}
+
+	_, err = ch.QueueDeclare(queue, true, false, false, false, nil)
+	if err != nil {
+		conn.Close()
+		return consumerSession{}, err
+	}
+
+	for _, key := range []string{OrderPlaced, OrderCancelled, OrderCompleted} {
+		err = ch.QueueBind(queue, key, exchange, false, nil)
+		if err != nil {
+			conn.Close()
+			return consumerSession{}, err
+		}
+	}
+
+	closed := ch.NotifyClose(make(chan *amqp.Error, 1))
+	deliveries, err := ch.Consume(queue, "", false, false, false, false, nil)
+	if err != nil {
+		conn.Close()
+		return consumerSession{}, err
+	}
+
+	return consumerSession{
+		deliveries: deliveries,
+		closed:     closed,
+		close:      conn.Close,
+	}, nil
+}
+
+// Consume consume order events until the context cancelled,
+// reconnecting with backoff whenever the connection closed
+//
+// message is acknowledged if handled successfully, dropped if invalid,
+// and requeued once if the handler failed
+func (c *RabbitMQConsumer) Consume(ctx context.Context,
+	handler OrderEventHandler) {
+	session := c.session
+	c.session = nil
+
+	wait := c.retryMin
+	for {
+		if session == nil {
+			s, err := c.connect()
+			if err != nil {
+				log.Printf("There's an error when reconnecting to message "+
+					"broker, retrying in %s => %s", wait, err.Error())
+
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(wait):
+				}
+
+				wait *= 2
+				if wait > c.retryMax {
+					wait = c.retryMax
+				}
+				continue
+			}
+
+			session = &s
+			wait = c.retryMin
+		}
+
+		consumeDeliveries(ctx, session.deliveries, handler)
+		session.close()
+		if ctx.Err() != nil {
+			return
+		}
+
+		reason := "connection closed"
+		select {
+		case closeErr := <-session.closed:
+			if closeErr != nil {
+				reason = closeErr.Error()
+			}
+		default:
+		}
+		log.Printf("Order event consumer lost connection to message broker, "+
+			"reconnecting => %s", reason)
+		session = nil
+	}
+}
+
+// consumeDeliveries handle deliveries until the deliveries channel closed
+// or the context cancelled
+func consumeDeliveries(ctx context.Context, deliveries <-chan amqp.Delivery,
+	handler OrderEventHandler) {
+	for {
+		var d amqp.Delivery
+		var ok bool
+		select {
+		case <-ctx.Done():
+			return
+		case d, ok = <-deliveries:
+			if !ok {
+				return
+			}
+		}
+
+		e, err := ParseOrderEvent(d.Body, d.Type)
+		if err != nil {
+			log.Printf("There's an error when parsing order event => %s",
+				err.Error())
+			d.Nack(false, false)
+			continue
+		}
+
+		err = handler(e)
+		if err != nil {
+			log.Printf("There's an error when handling order event %s "+
+				"of order %s => %s", e.Type, e.OrderID, err.Error())
+			d.Nack(false, !d.Redelivered)
+			continue
+		}
+
+		d.Ack(false)
+	}
+}
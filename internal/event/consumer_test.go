@@ -0,0 +1,181 @@
+/*
+Package event containing product domain events and their publisher
+to message broker
+*/
+package event
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// TestParseOrderEvent test ParseOrderEvent
+func TestParseOrderEvent(t *testing.T) {
+	// initialize testing table
+	testTable := []struct {
+		TestName     string
+		Body         string
+		MessageType  string
+		ExpectedType string
+		ExpectedErr  bool
+	}{
+		{
+			TestName:     "Order Placed",
+			Body:         `{"type":"OrderPlaced","order_id":"1","items":[{"sku":"a","qty":2}]}`,
+			ExpectedType: OrderPlaced,
+		},
+		{
+			TestName:     "Type From Message",
+			Body:         `{"order_id":"1","items":[{"sku":"a","qty":2}]}`,
+			MessageType:  OrderCancelled,
+			ExpectedType: OrderCancelled,
+		},
+		{
+			TestName:     "Order Completed",
+			Body:         `{"type":"OrderCompleted","order_id":"1","items":[{"sku":"a","qty":1}]}`,
+			ExpectedType: OrderCompleted,
+		},
+		{
+			TestName:    "Unknown Type",
+			Body:        `{"type":"OrderShipped","order_id":"1","items":[]}`,
+			ExpectedErr: true,
+		},
+		{
+			TestName:    "Invalid Qty",
+			Body:        `{"type":"OrderPlaced","order_id":"1","items":[{"sku":"a","qty":0}]}`,
+			ExpectedErr: true,
+		},
+		{
+			TestName:    "Invalid JSON",
+			Body:        `{"type":`,
+			ExpectedErr: true,
+		},
+	}
+
+	// do the test
+	for _, test := range testTable {
+		e, err := ParseOrderEvent([]byte(test.Body), test.MessageType)
+		if test.ExpectedErr {
+			if err == nil {
+				t.Errorf("[%s] Expected error, but got no error", test.TestName)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("[%s] Expected error nil, but got error => %s",
+				test.TestName, err.Error())
+		} else if e.Type != test.ExpectedType {
+			t.Errorf("[%s] Expected type %s, but got %s",
+				test.TestName, test.ExpectedType, e.Type)
+		}
+	}
+}
+
+// ackRecorder acknowledger recording acknowledged delivery tags
+type ackRecorder struct {
+	mu    sync.Mutex
+	acked []uint64
+}
+
+// Ack record the acknowledged delivery tag
+func (r *ackRecorder) Ack(tag uint64, multiple bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.acked = append(r.acked, tag)
+	return nil
+}
+
+// Nack do nothing
+func (r *ackRecorder) Nack(tag uint64, multiple bool, requeue bool) error {
+	return nil
+}
+
+// Reject do nothing
+func (r *ackRecorder) Reject(tag uint64, requeue bool) error {
+	return nil
+}
+
+// TestRabbitMQConsumerReconnect test RabbitMQConsumer.Consume keep
+// consuming after the connection closed and a reconnect failed
+func TestRabbitMQConsumerReconnect(t *testing.T) {
+	acks := &ackRecorder{}
+	delivery := func(tag uint64) amqp.Delivery {
+		return amqp.Delivery{
+			Acknowledger: acks,
+			DeliveryTag:  tag,
+			Body: []byte(`{"type":"OrderPlaced","order_id":"1",` +
+				`"items":[{"sku":"a","qty":1}]}`),
+		}
+	}
+
+	// first connection closed by broker after one delivery
+	first := make(chan amqp.Delivery, 1)
+	first <- delivery(1)
+	close(first)
+	firstClosed := make(chan *amqp.Error, 1)
+	firstClosed <- &amqp.Error{Code: amqp.ConnectionForced,
+		Reason: "broker restarted"}
+
+	// reconnect after one failed attempt
+	second := make(chan amqp.Delivery, 1)
+	second <- delivery(2)
+	connects := 0
+	connect := func() (consumerSession, error) {
+		connects++
+		if connects == 1 {
+			return consumerSession{}, errors.New("connection refused")
+		}
+		return consumerSession{deliveries: second,
+			close: func() error { return nil }}, nil
+	}
+
+	c := &RabbitMQConsumer{
+		connect: connect,
+		session: &consumerSession{deliveries: first, closed: firstClosed,
+			close: func() error { return nil }},
+		retryMin: time.Millisecond,
+		retryMax: time.Millisecond,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	handled := make(chan OrderEvent, 2)
+	done := make(chan struct{})
+	go func() {
+		c.Consume(ctx, func(e OrderEvent) error {
+			handled <- e
+			return nil
+		})
+		close(done)
+	}()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-handled:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected 2 order events handled, but got %d", i)
+		}
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected consumer stopped after context cancelled")
+	}
+
+	if connects != 2 {
+		t.Errorf("Expected 2 reconnect attempts, but got %d", connects)
+	}
+	acks.mu.Lock()
+	defer acks.mu.Unlock()
+	if len(acks.acked) != 2 || acks.acked[0] != 1 || acks.acked[1] != 2 {
+		t.Errorf("Expected deliveries 1 and 2 acknowledged, but got %v",
+			acks.acked)
+	}
+}
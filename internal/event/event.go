@@ -0,0 +1,129 @@
+/*
+Package event containing product domain events and their publisher
+to message broker
+*/
+package event
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// product domain event types, also used as broker routing key
+const (
+	ProductCreated  = "ProductCreated"
+	ProductUpdated  = "ProductUpdated"
+	ProductDeleted  = "ProductDeleted"
+	ProductRestored = "ProductRestored"
+	StockChanged    = "StockChanged"
+)
+
+// Event contain a product domain event
+type Event struct {
+	Type       string      `json:"type"`
+	SKU        string      `json:"sku"`
+	UserID     int         `json:"user_id"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Payload    interface{} `json:"payload,omitempty"`
+}
+
+// StockChangedPayload contain payload of event StockChanged
+type StockChangedPayload struct {
+	Stock float64 `json:"stock"`
+	Delta float64 `json:"delta"`
+}
+
+// NewEvent create new event of a product owned by user ID occurred now
+func NewEvent(eventType string, SKU string, userID int,
+	payload interface{}) Event {
+	return Event{
+		Type:       eventType,
+		SKU:        SKU,
+		UserID:     userID,
+		OccurredAt: time.Now().UTC(),
+		Payload:    payload,
+	}
+}
+
+// Publisher publish events to message broker
+type Publisher interface {
+	Publish(e Event) error
+	Close() error
+}
+
+// NopPublisher publisher that discard all events,
+// used when no message broker configured
+type NopPublisher struct{}
+
+// Publish discard event
+func (NopPublisher) Publish(e Event) error {
+	return nil
+}
+
+// Close do nothing
+func (NopPublisher) Close() error {
+	return nil
+}
+
+// RabbitMQPublisher publisher to RabbitMQ topic exchange
+// with event type as routing key
+type RabbitMQPublisher struct {
+	mu       sync.Mutex
+	conn     *amqp.Connection
+	ch       *amqp.Channel
+	exchange string
+}
+
+// NewRabbitMQPublisher connect to RabbitMQ and declare the topic exchange
+func NewRabbitMQPublisher(URL string, exchange string) (*RabbitMQPublisher, error) {
+	conn, err := amqp.Dial(URL)
+	if err != nil {
+		return nil, err
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	err = ch.ExchangeDeclare(exchange, "topic", true, false, false, false, nil)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &RabbitMQPublisher{
+		conn:     conn,
+		ch:       ch,
+		exchange: exchange,
+	}, nil
+}
+
+// Publish publish event as persistent JSON message
+func (p *RabbitMQPublisher) Publish(e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.ch.Publish(p.exchange, e.Type, false, false, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		Timestamp:    e.OccurredAt,
+		Type:         e.Type,
+		Body:         body,
+	})
+}
+
+// Close close channel and connection to RabbitMQ
+func (p *RabbitMQPublisher) Close() error {
+	p.ch.Close()
+	return p.conn.Close()
+}
@@ -0,0 +1,56 @@
+/*
+Package event containing product domain events and their publisher
+to message broker
+*/
+package event
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestNewEvent test NewEvent
+func TestNewEvent(t *testing.T) {
+	e := NewEvent(StockChanged, "abc123", 1, StockChangedPayload{
+		Stock: 90,
+		Delta: -10,
+	})
+
+	if e.Type != StockChanged {
+		t.Errorf("Expected type %s, but got %s", StockChanged, e.Type)
+	}
+	if e.SKU != "abc123" {
+		t.Errorf("Expected SKU abc123, but got %s", e.SKU)
+	}
+	if e.OccurredAt.IsZero() {
+		t.Errorf("Expected occurred at not zero, but got zero")
+	}
+
+	// check event JSON encoding
+	body, err := json.Marshal(e)
+	if err != nil {
+		t.Errorf("There's an error when marshal event => %s", err.Error())
+	}
+
+	var result map[string]interface{}
+	err = json.Unmarshal(body, &result)
+	if err != nil {
+		t.Errorf("There's an error when unmarshal event => %s", err.Error())
+	}
+	payload, ok := result["payload"].(map[string]interface{})
+	if !ok {
+		t.Errorf("Expected payload object, but got %v", result["payload"])
+	} else if payload["delta"] != float64(-10) {
+		t.Errorf("Expected delta -10, but got %v", payload["delta"])
+	}
+}
+
+// TestNopPublisher test NopPublisher
+func TestNopPublisher(t *testing.T) {
+	var p Publisher = NopPublisher{}
+
+	err := p.Publish(NewEvent(ProductDeleted, "abc123", 1, nil))
+	if err != nil {
+		t.Errorf("Expected error nil, but got error => %s", err.Error())
+	}
+}
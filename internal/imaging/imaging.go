@@ -0,0 +1,183 @@
+/*
+Package imaging containing image decoding, encoding, resizing,
+aspect ratio fitting, and watermarking of product images using only
+the standard library
+*/
+package imaging
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+)
+
+// JPEGQuality quality of encoded JPEG images
+const JPEGQuality = 85
+
+// ErrFormatUnsupported error of image format not supported
+var ErrFormatUnsupported = errors.New("image format not supported, " +
+	"must be JPEG, PNG, or GIF")
+
+// Decode decode JPEG, PNG, or GIF image, returning the image
+// and its format name
+func Decode(r io.Reader) (image.Image, string, error) {
+	img, format, err := image.Decode(r)
+	if err == image.ErrFormat {
+		return nil, "", ErrFormatUnsupported
+	}
+
+	return img, format, err
+}
+
+// Encode encode image into w in format name returned by Decode
+func Encode(w io.Writer, img image.Image, format string) error {
+	switch format {
+	case "jpeg":
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: JPEGQuality})
+	case "png":
+		return png.Encode(w, img)
+	case "gif":
+		return gif.Encode(w, img, nil)
+	}
+
+	return ErrFormatUnsupported
+}
+
+// FitSize get size of image of width x height scaled down to fit within
+// maxWidth x maxHeight keeping its aspect ratio, never scaled up
+func FitSize(width, height, maxWidth, maxHeight int) (int, int) {
+	if width <= maxWidth && height <= maxHeight {
+		return width, height
+	}
+
+	// scale by the most constrained side
+	if width*maxHeight > height*maxWidth {
+		return maxWidth, max(1, (height*maxWidth+width/2)/width)
+	}
+	return max(1, (width*maxHeight+height/2)/height), maxHeight
+}
+
+// Resize scale image down to fit within maxWidth x maxHeight keeping its
+// aspect ratio, each pixel is the average of source pixels it covers,
+// image already fitting is returned as is
+func Resize(img image.Image, maxWidth, maxHeight int) image.Image {
+	b := img.Bounds()
+	width, height := FitSize(b.Dx(), b.Dy(), maxWidth, maxHeight)
+	if width == b.Dx() && height == b.Dy() {
+		return img
+	}
+
+	dst := image.NewRGBA64(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		sy0 := b.Min.Y + y*b.Dy()/height
+		sy1 := b.Min.Y + (y+1)*b.Dy()/height
+		for x := 0; x < width; x++ {
+			sx0 := b.Min.X + x*b.Dx()/width
+			sx1 := b.Min.X + (x+1)*b.Dx()/width
+
+			var r, g, bl, a, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r += uint64(cr)
+					g += uint64(cg)
+					bl += uint64(cb)
+					a += uint64(ca)
+					n++
+				}
+			}
+			dst.SetRGBA64(x, y, color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(bl / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+
+	return dst
+}
+
+// HasAspectRatio check image has aspect ratio width:height
+func HasAspectRatio(img image.Image, width, height int) bool {
+	size := img.Bounds().Size()
+	return size.X*height == size.Y*width
+}
+
+// CropToAspectRatio crop the center of image to aspect ratio width:height
+func CropToAspectRatio(img image.Image, width, height int) image.Image {
+	b := img.Bounds()
+	crop := b
+	if b.Dx()*height > b.Dy()*width { // too wide
+		cropWidth := max(1, b.Dy()*width/height)
+		crop.Min.X += (b.Dx() - cropWidth) / 2
+		crop.Max.X = crop.Min.X + cropWidth
+	} else { // too tall
+		cropHeight := max(1, b.Dx()*height/width)
+		crop.Min.Y += (b.Dy() - cropHeight) / 2
+		crop.Max.Y = crop.Min.Y + cropHeight
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, crop.Dx(), crop.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, crop.Min, draw.Src)
+	return dst
+}
+
+// PadToAspectRatio pad image centered on background to aspect ratio
+// width:height
+func PadToAspectRatio(img image.Image, width, height int,
+	background color.Color) image.Image {
+	b := img.Bounds()
+	padded := image.Rect(0, 0, b.Dx(), b.Dy())
+	if b.Dx()*height > b.Dy()*width { // too wide
+		padded.Max.Y = (b.Dx()*height + width - 1) / width
+	} else { // too tall
+		padded.Max.X = (b.Dy()*width + height - 1) / height
+	}
+
+	dst := image.NewRGBA(padded)
+	draw.Draw(dst, padded, image.NewUniform(background), image.Point{},
+		draw.Src)
+	offset := image.Pt((padded.Dx()-b.Dx())/2, (padded.Dy()-b.Dy())/2)
+	draw.Draw(dst, image.Rectangle{Min: offset, Max: offset.Add(b.Size())},
+		img, b.Min, draw.Over)
+	return dst
+}
+
+// Watermark draw watermark over bottom right corner of image, the
+// watermark is shrunk to fit within a quarter of image width and height
+// and its transparency is kept
+func Watermark(img image.Image, watermark image.Image) image.Image {
+	b := img.Bounds()
+	watermark = Resize(watermark, max(1, b.Dx()/4), max(1, b.Dy()/4))
+	margin := min(b.Dx(), b.Dy()) / 50
+
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+	size := watermark.Bounds().Size()
+	corner := image.Pt(b.Dx()-margin, b.Dy()-margin)
+	draw.Draw(dst, image.Rectangle{Min: corner.Sub(size), Max: corner},
+		watermark, watermark.Bounds().Min, draw.Over)
+	return dst
+}
+
+// max get the larger of a and b
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// min get the smaller of a and b
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
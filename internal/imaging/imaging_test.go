@@ -0,0 +1,201 @@
+/*
+Package imaging containing image decoding, encoding, resizing,
+aspect ratio fitting, and watermarking of product images using only
+the standard library
+*/
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+// TestFitSize test FitSize
+func TestFitSize(t *testing.T) {
+	testCases := []struct {
+		Width, Height, MaxWidth, MaxHeight int
+		ExpectedWidth, ExpectedHeight      int
+	}{
+		{800, 600, 400, 400, 400, 300},
+		{600, 800, 400, 400, 300, 400},
+		{800, 600, 1000, 1000, 800, 600},
+		{1000, 10, 100, 100, 100, 1},
+		{300, 200, 300, 100, 150, 100},
+	}
+
+	for _, testCase := range testCases {
+		width, height := FitSize(testCase.Width, testCase.Height,
+			testCase.MaxWidth, testCase.MaxHeight)
+		if width != testCase.ExpectedWidth || height != testCase.ExpectedHeight {
+			t.Errorf("Expected %dx%d fit into %dx%d is %dx%d, but got %dx%d",
+				testCase.Width, testCase.Height, testCase.MaxWidth,
+				testCase.MaxHeight, testCase.ExpectedWidth,
+				testCase.ExpectedHeight, width, height)
+		}
+	}
+}
+
+// TestResize test Resize averaging source pixels
+func TestResize(t *testing.T) {
+	// left half black, right half white
+	img := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 4; x++ {
+			c := color.RGBA{A: 255}
+			if x >= 2 {
+				c = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+
+	resized := Resize(img, 2, 2)
+	if resized.Bounds().Dx() != 2 || resized.Bounds().Dy() != 1 {
+		t.Fatalf("Expected size 2x1, but got %v", resized.Bounds())
+	}
+	left := color.RGBAModel.Convert(resized.At(0, 0)).(color.RGBA)
+	right := color.RGBAModel.Convert(resized.At(1, 0)).(color.RGBA)
+	if left != (color.RGBA{A: 255}) ||
+		right != (color.RGBA{R: 255, G: 255, B: 255, A: 255}) {
+		t.Errorf("Expected black and white pixels, but got %v and %v",
+			left, right)
+	}
+
+	// image already fitting is not resized
+	if Resize(img, 10, 10) != image.Image(img) {
+		t.Errorf("Expected image fitting returned as is")
+	}
+}
+
+// TestEncodeDecode test Encode and Decode of every supported format
+func TestEncodeDecode(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	for _, format := range []string{"jpeg", "png", "gif"} {
+		var b bytes.Buffer
+		err := Encode(&b, img, format)
+		if err != nil {
+			t.Fatalf("[%s] Expected error nil, but got error => %s",
+				format, err.Error())
+		}
+
+		decoded, decodedFormat, err := Decode(&b)
+		if err != nil || decodedFormat != format ||
+			decoded.Bounds() != img.Bounds() {
+			t.Errorf("[%s] Expected %s image of %v, but got %s (%v)",
+				format, format, img.Bounds(), decodedFormat, err)
+		}
+	}
+
+	_, _, err := Decode(bytes.NewReader([]byte("not an image")))
+	if err != ErrFormatUnsupported {
+		t.Errorf("Expected error %v, but got %v", ErrFormatUnsupported, err)
+	}
+}
+
+// TestFitAspectRatio test CropToAspectRatio and PadToAspectRatio
+func TestFitAspectRatio(t *testing.T) {
+	// red image with white center column
+	img := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 40; x++ {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= 15 && x < 25 {
+				c = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+
+	// create testing table
+	testTable := []struct {
+		TestName       string
+		Image          image.Image
+		ExpectedWidth  int
+		ExpectedHeight int
+		ExpectedCenter color.RGBA
+		ExpectedCorner color.RGBA
+	}{
+		{
+			TestName:       "Crop",
+			Image:          CropToAspectRatio(img, 1, 1),
+			ExpectedWidth:  20,
+			ExpectedHeight: 20,
+			ExpectedCenter: color.RGBA{R: 255, G: 255, B: 255, A: 255},
+			ExpectedCorner: color.RGBA{R: 255, A: 255},
+		},
+		{
+			TestName:       "Pad",
+			Image:          PadToAspectRatio(img, 1, 1, color.Black),
+			ExpectedWidth:  40,
+			ExpectedHeight: 40,
+			ExpectedCenter: color.RGBA{R: 255, G: 255, B: 255, A: 255},
+			ExpectedCorner: color.RGBA{A: 255},
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		b := test.Image.Bounds()
+		if b.Dx() != test.ExpectedWidth || b.Dy() != test.ExpectedHeight ||
+			!HasAspectRatio(test.Image, 1, 1) {
+			t.Errorf("[%s] Expected size %dx%d, but got %v", test.TestName,
+				test.ExpectedWidth, test.ExpectedHeight, b)
+			continue
+		}
+
+		center := color.RGBAModel.Convert(
+			test.Image.At(b.Dx()/2, b.Dy()/2)).(color.RGBA)
+		corner := color.RGBAModel.Convert(test.Image.At(0, 0)).(color.RGBA)
+		if center != test.ExpectedCenter || corner != test.ExpectedCorner {
+			t.Errorf("[%s] Expected center %v and corner %v, "+
+				"but got %v and %v", test.TestName, test.ExpectedCenter,
+				test.ExpectedCorner, center, corner)
+		}
+	}
+}
+
+// TestWatermark test Watermark drawing shrunk watermark over bottom
+// right corner of image
+func TestWatermark(t *testing.T) {
+	// white image and red watermark larger than a quarter of it
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	mark := image.NewRGBA(image.Rect(0, 0, 50, 50))
+	draw.Draw(mark, mark.Bounds(), image.NewUniform(color.RGBA{R: 255, A: 255}),
+		image.Point{}, draw.Src)
+
+	watermarked := Watermark(img, mark)
+	if watermarked.Bounds() != img.Bounds() {
+		t.Fatalf("Expected bounds %v, but got %v", img.Bounds(),
+			watermarked.Bounds())
+	}
+
+	// watermark is 25x25 with 2 pixels margin
+	red := color.RGBA{R: 255, A: 255}
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	for _, test := range []struct {
+		Point    image.Point
+		Expected color.RGBA
+	}{
+		{image.Pt(97, 97), red},
+		{image.Pt(73, 73), red},
+		{image.Pt(72, 72), white},
+		{image.Pt(98, 98), white},
+		{image.Pt(0, 0), white},
+	} {
+		c := color.RGBAModel.Convert(
+			watermarked.At(test.Point.X, test.Point.Y)).(color.RGBA)
+		if c != test.Expected {
+			t.Errorf("Expected color %v at %v, but got %v", test.Expected,
+				test.Point, c)
+		}
+	}
+
+	// original image is kept clean
+	if c := color.RGBAModel.Convert(img.At(97, 97)).(color.RGBA); c != white {
+		t.Errorf("Expected original image unchanged, but got color %v", c)
+	}
+}
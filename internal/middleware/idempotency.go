@@ -0,0 +1,231 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"hash"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+)
+
+// IdempotencyKeyHeader request header containing idempotency key
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// ServiceNameHeader request header containing name of the calling service,
+// required for service principal sending idempotency key since all
+// services share the same user ID
+const ServiceNameHeader = "X-Service-Name"
+
+// IdempotencyMiddleware replay stored response of POST/PUT request
+// retried with the same Idempotency-Key header, so retries don't
+// create duplicate products or double-decrement stock
+//
+// must be used after authorization middleware since keys are per user,
+// or per calling service for service principal
+func IdempotencyMiddleware(DB *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// only mutating request with idempotency key handled
+		key := strings.TrimSpace(c.Get(IdempotencyKeyHeader))
+		if key == "" || (c.Method() != http.MethodPost &&
+			c.Method() != http.MethodPut) {
+			return c.Next()
+		}
+		if len(key) > 255 {
+			return c.Status(http.StatusBadRequest).JSON(map[string]string{
+				"message": "Idempotency-Key too long, maximum 255 characters",
+			})
+		}
+
+		// get user data
+		u, ok := c.Locals("user").(User)
+		if !ok {
+			return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+				"message": "user data invalid",
+			})
+		}
+
+		// keys of service principal are scoped by the calling service
+		client := ""
+		if u.Role == RoleService {
+			client = strings.TrimSpace(c.Get(ServiceNameHeader))
+			if client == "" || len(client) > 100 {
+				return c.Status(http.StatusBadRequest).JSON(map[string]string{
+					"message": ServiceNameHeader + " required with " +
+						"Idempotency-Key for service, maximum 100 characters",
+				})
+			}
+		}
+
+		fingerprint, err := GetRequestFingerprint(c)
+		if err != nil {
+			return c.Status(http.StatusBadRequest).JSON(map[string]string{
+				"message": err.Error(),
+			})
+		}
+
+		ik := model.IdempotencyKey{
+			Key:         key,
+			UserID:      u.ID,
+			Client:      client,
+			Fingerprint: fingerprint,
+		}
+
+		// mark request as in progress, or replay the stored one
+		inserted, err := model.InsertIdempotencyKey(c.UserContext(), DB, ik)
+		if err != nil {
+			return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+				"message": err.Error(),
+			})
+		}
+		if !inserted {
+			return replayIdempotentResponse(c, DB, ik)
+		}
+
+		// run the request, its result is stored even if the request
+		// context is cancelled since the request work already done
+		ctx := context.Background()
+		err = c.Next()
+		if err != nil {
+			model.DeleteIdempotencyKey(ctx, DB, ik.Key, ik.UserID, ik.Client)
+			return err
+		}
+
+		// server errors are not stored so the request can be retried
+		status := c.Response().StatusCode()
+		if status >= http.StatusInternalServerError {
+			err = model.DeleteIdempotencyKey(ctx, DB, ik.Key, ik.UserID,
+				ik.Client)
+		} else {
+			ik.StatusCode = sql.NullInt64{Int64: int64(status), Valid: true}
+			ik.ContentType = string(c.Response().Header.ContentType())
+			ik.ResponseBody = append([]byte{}, c.Response().Body()...)
+			err = model.SaveIdempotencyResponse(ctx, DB, ik)
+		}
+		if err != nil {
+			return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+				"message": err.Error(),
+			})
+		}
+
+		return nil
+	}
+}
+
+// replayIdempotentResponse send stored response of idempotency key
+func replayIdempotentResponse(c *fiber.Ctx, DB *sql.DB,
+	ik model.IdempotencyKey) error {
+	stored, err := model.GetIdempotencyKey(c.UserContext(), DB, ik.Key,
+		ik.UserID, ik.Client)
+	if err == sql.ErrNoRows { // expired or released between the queries
+		return c.Status(http.StatusConflict).JSON(map[string]string{
+			"message": "request with this Idempotency-Key conflicted, retry it",
+		})
+	} else if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(map[string]string{
+			"message": err.Error(),
+		})
+	}
+
+	if stored.Fingerprint != ik.Fingerprint {
+		return c.Status(http.StatusUnprocessableEntity).JSON(map[string]string{
+			"message": "Idempotency-Key already used for a different request",
+		})
+	}
+
+	if !stored.StatusCode.Valid {
+		return c.Status(http.StatusConflict).JSON(map[string]string{
+			"message": "request with this Idempotency-Key still in progress",
+		})
+	}
+
+	c.Set("Idempotent-Replayed", "true")
+	c.Set(fiber.HeaderContentType, stored.ContentType)
+	return c.Status(int(stored.StatusCode.Int64)).Send(stored.ResponseBody)
+}
+
+// GetRequestFingerprint get SHA-256 fingerprint of request method,
+// path, query, and body
+//
+// multipart body is fingerprinted by its form values and file contents,
+// so a retry encoding the same form with another boundary is the same
+func GetRequestFingerprint(c *fiber.Ctx) (string, error) {
+	h := sha256.New()
+	h.Write([]byte(c.Method()))
+	h.Write([]byte{0})
+	h.Write([]byte(c.Path()))
+	h.Write([]byte{0})
+	h.Write(c.Request().URI().QueryString())
+	h.Write([]byte{0})
+
+	if strings.HasPrefix(string(c.Request().Header.ContentType()),
+		fiber.MIMEMultipartForm) {
+		form, err := c.MultipartForm()
+		if err != nil {
+			return "", err
+		}
+
+		err = writeMultipartFingerprint(h, form)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		h.Write(c.Body())
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeMultipartFingerprint write form values, and file names and
+// SHA-256 of file contents of multipart form to hash, ordered by field name
+func writeMultipartFingerprint(h hash.Hash, form *multipart.Form) error {
+	keys := make([]string, 0, len(form.Value))
+	for key := range form.Value {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range form.Value[key] {
+			h.Write([]byte(key))
+			h.Write([]byte{0})
+			h.Write([]byte(value))
+			h.Write([]byte{0})
+		}
+	}
+
+	keys = make([]string, 0, len(form.File))
+	for key := range form.File {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, fh := range form.File[key] {
+			f, err := fh.Open()
+			if err != nil {
+				return err
+			}
+
+			fileHash := sha256.New()
+			_, err = io.Copy(fileHash, f)
+			f.Close()
+			if err != nil {
+				return err
+			}
+
+			h.Write([]byte(key))
+			h.Write([]byte{0})
+			h.Write([]byte(fh.Filename))
+			h.Write([]byte{0})
+			h.Write(fileHash.Sum(nil))
+		}
+	}
+
+	return nil
+}
@@ -4,30 +4,71 @@ Package middleware collection of middleware used for API
 package middleware
 
 import (
-	"bytes"
-	"encoding/json"
-	"io"
-	"mime/multipart"
+	"crypto/subtle"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/account"
 	"github.com/reyhanfikridz/ecom-product-service/internal/config"
 )
 
-// User containing user data after authorization
+// User containing user data after authorization, Role is the primary
+// role of the user and Roles are all roles of the user (e.g. seller
+// and buyer), user with Role only has the one role, Verified is whether
+// the seller is verified, nil if account service doesn't tell
 type User struct {
-	ID          int    `json:"id"`
-	Email       string `json:"email"`
-	Password    string `json:"password"`
-	FullName    string `json:"full_name"`
-	Address     string `json:"address"`
-	PhoneNumber string `json:"phone_number"`
-	Role        string `json:"role"`
+	ID          int      `json:"id"`
+	Email       string   `json:"email"`
+	Password    string   `json:"password"`
+	FullName    string   `json:"full_name"`
+	Address     string   `json:"address"`
+	PhoneNumber string   `json:"phone_number"`
+	Role        string   `json:"role"`
+	Roles       []string `json:"roles"`
+	Verified    *bool    `json:"verified"`
 }
 
+// IsVerified check if user is verified seller, user not told
+// unverified by account service is verified
+func (u User) IsVerified() bool {
+	return u.Verified == nil || *u.Verified
+}
+
+// GetRoles get all roles of user
+func (u User) GetRoles() []string {
+	if len(u.Roles) > 0 {
+		return u.Roles
+	}
+	if u.Role != "" {
+		return []string{u.Role}
+	}
+
+	return []string{}
+}
+
+// ParseRoles get roles of comma separated roles, e.g. "seller,buyer"
+func ParseRoles(rawRoles string) []string {
+	roles := []string{}
+	for _, role := range strings.Split(rawRoles, ",") {
+		role = strings.TrimSpace(role)
+		if role != "" {
+			roles = append(roles, role)
+		}
+	}
+
+	return roles
+}
+
+// RoleService role of internal service principal, e.g. order service
+// decreasing stock at checkout, authorized by internal service token
+const RoleService = "service"
+
 // AuthorizationMiddleware authorize each API route by checking JWT Token
-func AuthorizationMiddleware() fiber.Handler {
+// to account service of accounts client, or internal service token
+// authorized as service principal without asking account service
+func AuthorizationMiddleware(accounts *account.Client) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		// get token
 		token := GetTokenFromHeader(c.GetReqHeaders())
@@ -37,57 +78,85 @@ func AuthorizationMiddleware() fiber.Handler {
 			})
 		}
 
-		// set form data
-		formData := map[string]io.Reader{
-			"token": strings.NewReader(token),
+		if IsServiceToken(token) {
+			c.Locals("user", User{Role: RoleService,
+				Roles: []string{RoleService}})
+			return c.Next()
 		}
 
-		// transform form data to bytes buffer
-		var bFormData bytes.Buffer
-		bFormDataWriter := multipart.NewWriter(&bFormData)
-		for key, formDataReader := range formData {
-			fieldWriter, err := bFormDataWriter.CreateFormField(key)
-			if err != nil {
-				return c.Status(http.StatusInternalServerError).JSON(map[string]string{
-					"message": err.Error(),
-				})
-			}
-
-			_, err = io.Copy(fieldWriter, formDataReader)
-			if err != nil {
-				return c.Status(http.StatusInternalServerError).JSON(map[string]string{
-					"message": err.Error(),
-				})
-			}
-		}
-		bFormDataWriter.Close()
-
 		// authorize to account service
-		resp, err := http.Post(config.AccountServiceURL+"/api/authorize/",
-			bFormDataWriter.FormDataContentType(),
-			&bFormData)
-		if err != nil { // if error occured
+		accountUser, err := accounts.Authorize(c.UserContext(),
+			account.AuthorizeRequest{Token: token})
+		if err == account.ErrUnauthorized {
+			return c.Status(http.StatusForbidden).JSON("Token authorization invalid")
+		} else if err != nil {
 			return c.Status(http.StatusInternalServerError).JSON(map[string]string{
 				"message": err.Error(),
 			})
 		}
-		if resp.StatusCode != http.StatusOK { // if unauthorized
-			return c.Status(http.StatusForbidden).JSON("Token authorization invalid")
+
+		c.Locals("user", User(accountUser))
+		return c.Next()
+	}
+}
+
+// dev authorization request headers overriding the fake user
+const (
+	DevUserIDHeader   = "X-Dev-User-ID"
+	DevUserRoleHeader = "X-Dev-User-Role"
+)
+
+// DevAuthorizationMiddleware authorize each API route as fake user u
+// without account service, for local development only
+//
+// user ID and roles can be overridden per request with headers
+// X-Dev-User-ID and X-Dev-User-Role (comma separated roles, the first
+// is the primary role) to act as another user
+func DevAuthorizationMiddleware(u User) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user := u
+		if rawID := c.Get(DevUserIDHeader); rawID != "" {
+			ID, err := strconv.Atoi(rawID)
+			if err != nil || ID <= 0 {
+				return c.Status(http.StatusBadRequest).JSON(map[string]string{
+					"message": DevUserIDHeader + " invalid, must be positive integer",
+				})
+			}
+			user.ID = ID
+		}
+		if roles := ParseRoles(c.Get(DevUserRoleHeader)); len(roles) > 0 {
+			user.Role = roles[0]
+			user.Roles = roles
 		}
 
-		// get user data from authorization response
-		user, err := GetUserFromAuthorizationResp(resp)
-		if err != nil {
-			return c.Status(http.StatusInternalServerError).JSON(map[string]string{
-				"message": err.Error(),
+		c.Locals("user", user)
+		return c.Next()
+	}
+}
+
+// ServiceAuthorizationMiddleware authorize internal API route called by
+// other services by checking bearer token is the internal service token,
+// all requests are rejected if the internal service token is not set
+func ServiceAuthorizationMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token := GetTokenFromHeader(c.GetReqHeaders())
+		if !IsServiceToken(token) {
+			return c.Status(http.StatusForbidden).JSON(map[string]string{
+				"message": "service token invalid",
 			})
 		}
 
-		c.Locals("user", user)
 		return c.Next()
 	}
 }
 
+// IsServiceToken check if token is the internal service token,
+// always false if the internal service token is not set
+func IsServiceToken(token string) bool {
+	return config.InternalServiceToken != "" && subtle.ConstantTimeCompare(
+		[]byte(token), []byte(config.InternalServiceToken)) == 1
+}
+
 // GetTokenFromHeader getting token (bearer) from request header
 func GetTokenFromHeader(headers map[string]string) string {
 	rawToken := headers["Authorization"]
@@ -103,15 +172,3 @@ func GetTokenFromHeader(headers map[string]string) string {
 	token := splitToken[1]
 	return token
 }
-
-// GetUserFromAuthorizationResp get user data from authorization response
-func GetUserFromAuthorizationResp(resp *http.Response) (User, error) {
-	user := User{}
-
-	err := json.NewDecoder(resp.Body).Decode(&user)
-	if err != nil {
-		return user, err
-	}
-
-	return user, nil
-}
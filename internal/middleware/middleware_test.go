@@ -6,9 +6,16 @@ package middleware
 import (
 	"bytes"
 	"encoding/json"
-	"io/ioutil"
+	"mime/multipart"
 	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/reyhanfikridz/ecom-product-service/internal/account"
+	"github.com/reyhanfikridz/ecom-product-service/internal/config"
 )
 
 // TestGetTokenFromHeader test GetTokenFromHeader
@@ -25,50 +32,393 @@ func TestGetTokenFromHeader(t *testing.T) {
 	}
 }
 
-// TestGetUserDataFromAuthorizationResp test GetUserFromAuthorizationResp
-func TestGetUserDataFromAuthorizationResp(t *testing.T) {
-	expectedU := User{
-		ID:          1,
-		Email:       "buyer@gmail.com",
-		FullName:    "buyer",
-		Address:     "address",
-		PhoneNumber: "08111111111",
-		Role:        "buyer",
+// TestGetRequestFingerprint test GetRequestFingerprint
+func TestGetRequestFingerprint(t *testing.T) {
+	fingerprints := []string{}
+
+	app := fiber.New()
+	app.All("/*", func(c *fiber.Ctx) error {
+		fingerprint, err := GetRequestFingerprint(c)
+		if err != nil {
+			t.Errorf("Expected error nil, but got error => %s", err.Error())
+		}
+		fingerprints = append(fingerprints, fingerprint)
+		return nil
+	})
+
+	// run requests, the first two are identical
+	requests := []struct {
+		Method string
+		Target string
+		Body   string
+	}{
+		{"POST", "/api/product/", "name=a"},
+		{"POST", "/api/product/", "name=a"},
+		{"POST", "/api/product/", "name=b"},
+		{"PUT", "/api/product/", "name=a"},
+		{"POST", "/api/product/?sku=x", "name=a"},
+	}
+	for _, r := range requests {
+		req := httptest.NewRequest(r.Method, r.Target, strings.NewReader(r.Body))
+		_, err := app.Test(req)
+		if err != nil {
+			t.Errorf("There's an error serve http testing => %s", err.Error())
+		}
 	}
 
-	// create testing body response
-	body, err := json.Marshal(expectedU)
-	if err != nil {
-		t.Errorf("There's an error when marshal user data to json => %s",
-			err.Error())
+	if len(fingerprints) != len(requests) {
+		t.Fatalf("Expected %d fingerprints, but got %d",
+			len(requests), len(fingerprints))
+	}
+	if fingerprints[0] != fingerprints[1] {
+		t.Errorf("Expected identical requests have same fingerprint")
+	}
+	for i := 2; i < len(fingerprints); i++ {
+		if fingerprints[i] == fingerprints[0] {
+			t.Errorf("Expected request %d has different fingerprint", i)
+		}
 	}
+}
+
+// TestGetRequestFingerprintMultipart test GetRequestFingerprint of
+// the same multipart form encoded with different boundaries
+func TestGetRequestFingerprintMultipart(t *testing.T) {
+	fingerprints := []string{}
+
+	app := fiber.New()
+	app.All("/*", func(c *fiber.Ctx) error {
+		fingerprint, err := GetRequestFingerprint(c)
+		if err != nil {
+			t.Errorf("Expected error nil, but got error => %s", err.Error())
+		}
+		fingerprints = append(fingerprints, fingerprint)
+		return nil
+	})
 
-	// create testing response
-	resp := &http.Response{
-		Body: ioutil.NopCloser(bytes.NewBufferString(string(body))),
+	// the first two forms are the same, retried with a new boundary
+	forms := []struct {
+		Name  string
+		Image string
+	}{
+		{"Product 1", "image-1"},
+		{"Product 1", "image-1"},
+		{"Product 2", "image-1"},
+		{"Product 1", "image-2"},
 	}
+	boundaries := map[string]bool{}
+	for _, form := range forms {
+		var body bytes.Buffer
+		w := multipart.NewWriter(&body)
+		w.WriteField("name", form.Name)
+		fw, _ := w.CreateFormFile("images", "image.png")
+		fw.Write([]byte(form.Image))
+		w.Close()
+		boundaries[w.Boundary()] = true
 
-	// get user data
-	u, err := GetUserFromAuthorizationResp(resp)
+		req := httptest.NewRequest("POST", "/api/product/", &body)
+		req.Header.Set("Content-Type", w.FormDataContentType())
+		_, err := app.Test(req)
+		if err != nil {
+			t.Errorf("There's an error serve http testing => %s", err.Error())
+		}
+	}
+
+	if len(boundaries) != len(forms) {
+		t.Fatalf("Expected %d different boundaries, but got %d",
+			len(forms), len(boundaries))
+	}
+	if len(fingerprints) != len(forms) {
+		t.Fatalf("Expected %d fingerprints, but got %d",
+			len(forms), len(fingerprints))
+	}
+	if fingerprints[0] != fingerprints[1] {
+		t.Errorf("Expected same form with different boundary has " +
+			"same fingerprint")
+	}
+	for i := 2; i < len(fingerprints); i++ {
+		if fingerprints[i] == fingerprints[0] {
+			t.Errorf("Expected form %d has different fingerprint", i)
+		}
+	}
+}
+
+// TestIdempotencyMiddlewareServiceName test IdempotencyMiddleware
+// rejecting idempotency key of service principal without service name
+func TestIdempotencyMiddlewareServiceName(t *testing.T) {
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user", User{Role: RoleService, Roles: []string{RoleService}})
+		return c.Next()
+	})
+	app.Use(IdempotencyMiddleware(nil))
+	app.Post("/", func(c *fiber.Ctx) error {
+		return c.SendStatus(http.StatusOK)
+	})
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader("sku=a"))
+	req.Header.Set(IdempotencyKeyHeader, "key-1")
+	resp, err := app.Test(req)
 	if err != nil {
-		t.Errorf("Expected error nil, but got error => %s", err.Error())
+		t.Fatalf("There's an error serve http testing => %s", err.Error())
+	}
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, but got %d",
+			http.StatusBadRequest, resp.StatusCode)
+	}
+}
+
+// TestDevAuthorizationMiddleware test DevAuthorizationMiddleware
+func TestDevAuthorizationMiddleware(t *testing.T) {
+	app := fiber.New()
+	app.Use(DevAuthorizationMiddleware(User{ID: 1, Role: "seller"}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.JSON(c.Locals("user"))
+	})
+
+	// create testing table
+	testTable := []struct {
+		TestName           string
+		Headers            map[string]string
+		ExpectedStatusCode int
+		ExpectedUser       User
+	}{
+		{
+			TestName:           "Default user",
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedUser:       User{ID: 1, Role: "seller"},
+		},
+		{
+			TestName: "Overridden user",
+			Headers: map[string]string{
+				DevUserIDHeader:   "7",
+				DevUserRoleHeader: "admin",
+			},
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedUser: User{ID: 7, Role: "admin",
+				Roles: []string{"admin"}},
+		},
+		{
+			TestName: "Overridden multiple roles",
+			Headers: map[string]string{
+				DevUserRoleHeader: "seller, buyer",
+			},
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedUser: User{ID: 1, Role: "seller",
+				Roles: []string{"seller", "buyer"}},
+		},
+		{
+			TestName:           "Invalid user ID",
+			Headers:            map[string]string{DevUserIDHeader: "x"},
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		req := httptest.NewRequest("GET", "/", nil)
+		for key, value := range test.Headers {
+			req.Header.Set(key, value)
+		}
+
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("[%s] There's an error serve http testing => %s",
+				test.TestName, err.Error())
+		}
+		if resp.StatusCode != test.ExpectedStatusCode {
+			t.Errorf("[%s] Expected status code %d, but got %d",
+				test.TestName, test.ExpectedStatusCode, resp.StatusCode)
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			continue
+		}
+
+		u := User{}
+		err = json.NewDecoder(resp.Body).Decode(&u)
+		if err != nil {
+			t.Fatalf("[%s] There's an error when decoding user => %s",
+				test.TestName, err.Error())
+		}
+		if !reflect.DeepEqual(u, test.ExpectedUser) {
+			t.Errorf("[%s] Expected user %+v, but got %+v",
+				test.TestName, test.ExpectedUser, u)
+		}
 	}
-	if expectedU.ID != u.ID {
-		t.Errorf("Expected ID %d, but got %d", expectedU.ID, u.ID)
+}
+
+// TestServiceAuthorizationMiddleware test ServiceAuthorizationMiddleware
+func TestServiceAuthorizationMiddleware(t *testing.T) {
+	app := fiber.New()
+	app.Use(ServiceAuthorizationMiddleware())
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendStatus(http.StatusOK)
+	})
+
+	// create testing table
+	testTable := []struct {
+		TestName           string
+		ServiceToken       string
+		Authorization      string
+		ExpectedStatusCode int
+	}{
+		{
+			TestName:           "Valid service token",
+			ServiceToken:       "service-secret",
+			Authorization:      "Bearer service-secret",
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			TestName:           "Invalid service token",
+			ServiceToken:       "service-secret",
+			Authorization:      "Bearer user-token",
+			ExpectedStatusCode: http.StatusForbidden,
+		},
+		{
+			TestName:           "Service token not set",
+			Authorization:      "Bearer ",
+			ExpectedStatusCode: http.StatusForbidden,
+		},
 	}
-	if expectedU.Email != u.Email {
-		t.Errorf("Expected Email %s, but got %s", expectedU.Email, u.Email)
+
+	// loop test in test table
+	for _, test := range testTable {
+		config.InternalServiceToken = test.ServiceToken
+
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", test.Authorization)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("[%s] There's an error serve http testing => %s",
+				test.TestName, err.Error())
+		}
+		if resp.StatusCode != test.ExpectedStatusCode {
+			t.Errorf("[%s] Expected status code %d, but got %d",
+				test.TestName, test.ExpectedStatusCode, resp.StatusCode)
+		}
 	}
-	if expectedU.FullName != u.FullName {
-		t.Errorf("Expected FullName %s, but got %s", expectedU.FullName, u.FullName)
+	config.InternalServiceToken = ""
+}
+
+// TestAuthorizationMiddleware test AuthorizationMiddleware authorizing
+// token to account service
+func TestAuthorizationMiddleware(t *testing.T) {
+	// fake account service authorizing token 'valid' only
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/api/authorize/" ||
+				r.FormValue("token") != "valid" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"id":1,"roles":["seller","buyer"]}`))
+		}))
+	defer server.Close()
+
+	app := fiber.New()
+	app.Use(AuthorizationMiddleware(account.NewClient(server.URL)))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.JSON(c.Locals("user"))
+	})
+
+	// create testing table
+	testTable := []struct {
+		TestName           string
+		Authorization      string
+		ExpectedStatusCode int
+		ExpectedUser       User
+	}{
+		{"Valid", "Bearer valid", http.StatusOK, User{ID: 1, Role: "seller",
+			Roles: []string{"seller", "buyer"}}},
+		{"Invalid", "Bearer invalid", http.StatusForbidden, User{}},
+		{"Empty", "", http.StatusForbidden, User{}},
 	}
-	if expectedU.Address != u.Address {
-		t.Errorf("Expected Address %s, but got %s", expectedU.Address, u.Address)
+
+	// loop test in test table
+	for _, test := range testTable {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", test.Authorization)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("[%s] There's an error serve http testing => %s",
+				test.TestName, err.Error())
+		}
+		if resp.StatusCode != test.ExpectedStatusCode {
+			t.Errorf("[%s] Expected status code %d, but got %d",
+				test.TestName, test.ExpectedStatusCode, resp.StatusCode)
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			continue
+		}
+
+		u := User{}
+		err = json.NewDecoder(resp.Body).Decode(&u)
+		if err != nil {
+			t.Fatalf("[%s] Expected error nil, but got error => %s",
+				test.TestName, err.Error())
+		}
+		if !reflect.DeepEqual(u, test.ExpectedUser) {
+			t.Errorf("[%s] Expected user %+v, but got %+v", test.TestName,
+				test.ExpectedUser, u)
+		}
 	}
-	if expectedU.PhoneNumber != u.PhoneNumber {
-		t.Errorf("Expected PhoneNumber %s, but got %s", expectedU.PhoneNumber, u.PhoneNumber)
+}
+
+// TestAuthorizationMiddlewareServiceToken test AuthorizationMiddleware
+// authorizing internal service token as service principal without
+// asking account service
+func TestAuthorizationMiddlewareServiceToken(t *testing.T) {
+	config.InternalServiceToken = "service-secret"
+	defer func() { config.InternalServiceToken = "" }()
+
+	app := fiber.New()
+	app.Use(AuthorizationMiddleware(account.NewClient("http://127.0.0.1:1")))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.JSON(c.Locals("user"))
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer service-secret")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("There's an error serve http testing => %s", err.Error())
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status code %d, but got %d", http.StatusOK,
+			resp.StatusCode)
 	}
-	if expectedU.Role != u.Role {
-		t.Errorf("Expected Role %s, but got %s", expectedU.Role, u.Role)
+
+	u := User{}
+	err = json.NewDecoder(resp.Body).Decode(&u)
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+	if !reflect.DeepEqual(u, User{Role: RoleService,
+		Roles: []string{RoleService}}) {
+		t.Errorf("Expected service principal, but got %+v", u)
+	}
+}
+
+// TestIsVerified test User.IsVerified
+func TestIsVerified(t *testing.T) {
+	verified := true
+	unverified := false
+
+	testCases := []struct {
+		Verified *bool
+		Expected bool
+	}{
+		{nil, true},
+		{&verified, true},
+		{&unverified, false},
+	}
+
+	for _, testCase := range testCases {
+		u := User{ID: 1, Role: "seller", Verified: testCase.Verified}
+		if u.IsVerified() != testCase.Expected {
+			t.Errorf("Expected verified %t of %v, but got %t",
+				testCase.Expected, testCase.Verified, !testCase.Expected)
+		}
 	}
 }
@@ -0,0 +1,273 @@
+/*
+Package migration containing versioned database schema migrations
+and their runner
+*/
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrationFS embed.FS
+
+// key of PostgreSQL advisory lock held while migrating,
+// so service instances starting together don't migrate at once
+const lockKey = 7209151
+
+// migration file name format: <version>_<name>.<up|down>.sql
+var fileNameRegexp = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration contain a versioned schema change and its revert
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus contain a migration and when it's applied,
+// AppliedAt is nil if it's still pending
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// GetMigrations get all migrations sorted by version
+func GetMigrations() ([]Migration, error) {
+	entries, err := migrationFS.ReadDir("migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := fileNameRegexp.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration file name '%s' invalid",
+				entry.Name())
+		}
+
+		b, err := migrationFS.ReadFile(path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d has two names "+
+				"'%s' and '%s'", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(b)
+		} else {
+			m.Down = string(b)
+		}
+	}
+
+	migrations := []Migration{}
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration version %d has no up file",
+				m.Version)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Up apply all pending migrations in version order,
+// each in its own transaction, returning the applied migrations
+func Up(ctx context.Context, DB *sql.DB) ([]Migration, error) {
+	applied := []Migration{}
+
+	err := withLock(ctx, DB, func(conn *sql.Conn) error {
+		statuses, err := getStatus(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, status := range statuses {
+			if status.AppliedAt != nil {
+				continue
+			}
+
+			err = apply(ctx, conn, status.Migration, true)
+			if err != nil {
+				return err
+			}
+			applied = append(applied, status.Migration)
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down revert the latest n applied migrations in reverse version order,
+// each in its own transaction, returning the reverted migrations
+func Down(ctx context.Context, DB *sql.DB, n int) ([]Migration, error) {
+	reverted := []Migration{}
+
+	err := withLock(ctx, DB, func(conn *sql.Conn) error {
+		statuses, err := getStatus(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(statuses) - 1; i >= 0 && len(reverted) < n; i-- {
+			if statuses[i].AppliedAt == nil {
+				continue
+			}
+
+			err = apply(ctx, conn, statuses[i].Migration, false)
+			if err != nil {
+				return err
+			}
+			reverted = append(reverted, statuses[i].Migration)
+		}
+
+		return nil
+	})
+
+	return reverted, err
+}
+
+// Status get all migrations with when they're applied
+func Status(ctx context.Context, DB *sql.DB) ([]MigrationStatus, error) {
+	conn, err := DB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	return getStatus(ctx, conn)
+}
+
+// withLock run f with a connection holding the migration advisory lock
+func withLock(ctx context.Context, DB *sql.DB,
+	f func(conn *sql.Conn) error) error {
+	conn, err := DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockKey)
+	if err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(),
+		`SELECT pg_advisory_unlock($1)`, lockKey)
+
+	return f(conn)
+}
+
+// getStatus get all migrations with when they're applied,
+// creating the migration history table if not exist
+func getStatus(ctx context.Context, conn *sql.Conn) (
+	[]MigrationStatus, error) {
+	migrations, err := GetMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS product_schemamigration
+		(
+			version INT PRIMARY KEY NOT NULL,
+			name VARCHAR(100) NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)`)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.QueryContext(ctx, `
+		SELECT version, applied_at FROM product_schemamigration`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	appliedAt := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var t time.Time
+		err = rows.Scan(&version, &t)
+		if err != nil {
+			return nil, err
+		}
+		appliedAt[version] = t
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	statuses := []MigrationStatus{}
+	for _, m := range migrations {
+		status := MigrationStatus{Migration: m}
+		if t, ok := appliedAt[m.Version]; ok {
+			status.AppliedAt = &t
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// apply run up or down of a migration and record it
+// into migration history in one transaction
+func apply(ctx context.Context, conn *sql.Conn, m Migration, up bool) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // rollback transaction if fail
+
+	if up {
+		_, err = tx.ExecContext(ctx, m.Up)
+		if err == nil {
+			_, err = tx.ExecContext(ctx, `
+				INSERT INTO product_schemamigration(version, name)
+				VALUES($1,$2)`,
+				m.Version, m.Name)
+		}
+	} else {
+		if m.Down == "" {
+			return fmt.Errorf("migration %d_%s can't be reverted",
+				m.Version, m.Name)
+		}
+
+		_, err = tx.ExecContext(ctx, m.Down)
+		if err == nil {
+			_, err = tx.ExecContext(ctx, `
+				DELETE FROM product_schemamigration WHERE version = $1`,
+				m.Version)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("migration %d_%s failed => %s",
+			m.Version, m.Name, err.Error())
+	}
+
+	return tx.Commit()
+}
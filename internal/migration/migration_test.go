@@ -0,0 +1,31 @@
+/*
+Package migration containing versioned database schema migrations
+and their runner
+*/
+package migration
+
+import "testing"
+
+// TestGetMigrations test GetMigrations
+func TestGetMigrations(t *testing.T) {
+	migrations, err := GetMigrations()
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+
+	if len(migrations) == 0 {
+		t.Fatalf("Expected migrations exist, but got none")
+	}
+
+	// check versions are sequential from 1 and all revertable
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("Expected migration version %d, but got %d",
+				i+1, m.Version)
+		}
+		if m.Up == "" || m.Down == "" {
+			t.Errorf("Expected migration %d_%s has up and down, "+
+				"but one of them empty", m.Version, m.Name)
+		}
+	}
+}
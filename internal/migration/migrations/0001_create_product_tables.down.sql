@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS product_productimage;
+DROP TABLE IF EXISTS product_productinfo;
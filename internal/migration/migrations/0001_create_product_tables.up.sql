@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS product_productinfo (
+	id SERIAL PRIMARY KEY NOT NULL,
+	sku VARCHAR(15) UNIQUE NOT NULL,
+	name VARCHAR(100) NOT NULL,
+	price NUMERIC NOT NULL,
+	weight REAL NOT NULL,
+	description TEXT,
+	stock INT NOT NULL,
+	account_user_id INT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS product_productimage
+(
+	id SERIAL PRIMARY KEY NOT NULL,
+	image_path VARCHAR(250) NOT NULL,
+	product_productinfo_id INT NOT NULL,
+	CONSTRAINT fk_product_productinfo
+		FOREIGN KEY(product_productinfo_id)
+			REFERENCES product_productinfo(id)
+			ON DELETE CASCADE
+);
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS product_stockmovement
+(
+	id SERIAL PRIMARY KEY NOT NULL,
+	delta INT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	product_productinfo_id INT NOT NULL,
+	CONSTRAINT fk_product_productinfo
+		FOREIGN KEY(product_productinfo_id)
+			REFERENCES product_productinfo(id)
+			ON DELETE CASCADE
+);
+
+ALTER TABLE product_stockmovement
+	ADD COLUMN IF NOT EXISTS reason VARCHAR(50) NOT NULL DEFAULT '',
+	ADD COLUMN IF NOT EXISTS order_id VARCHAR(100) NOT NULL DEFAULT '',
+	ADD COLUMN IF NOT EXISTS account_user_id INT NOT NULL DEFAULT 0;
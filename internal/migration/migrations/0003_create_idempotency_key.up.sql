@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS product_idempotencykey
+(
+	key VARCHAR(255) NOT NULL,
+	account_user_id INT NOT NULL,
+	fingerprint CHAR(64) NOT NULL,
+	status_code INT,
+	content_type VARCHAR(100) NOT NULL DEFAULT '',
+	response_body BYTEA,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	PRIMARY KEY(key, account_user_id)
+);
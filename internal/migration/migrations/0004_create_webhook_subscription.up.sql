@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS product_webhooksubscription
+(
+	id SERIAL PRIMARY KEY NOT NULL,
+	url VARCHAR(500) NOT NULL,
+	events TEXT[] NOT NULL,
+	secret VARCHAR(64) NOT NULL,
+	account_user_id INT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,6 @@
+ALTER TABLE product_productimage
+	DROP COLUMN IF EXISTS created_at;
+
+ALTER TABLE product_productinfo
+	DROP COLUMN IF EXISTS updated_at,
+	DROP COLUMN IF EXISTS created_at;
@@ -0,0 +1,4 @@
+DELETE FROM product_productinfo WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE product_productinfo
+	DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,2 @@
+ALTER TABLE product_productinfo
+	ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ NULL;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS product_productversion;
+
+ALTER TABLE product_productinfo
+	DROP COLUMN IF EXISTS version;
@@ -0,0 +1,31 @@
+ALTER TABLE product_productinfo
+	ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+
+CREATE TABLE IF NOT EXISTS product_productversion
+(
+	id SERIAL PRIMARY KEY NOT NULL,
+	version INT NOT NULL,
+	name VARCHAR(100) NOT NULL,
+	price NUMERIC NOT NULL,
+	weight REAL NOT NULL,
+	description TEXT,
+	stock INT NOT NULL,
+	account_user_id INT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	product_productinfo_id INT NOT NULL,
+	CONSTRAINT fk_product_productinfo
+		FOREIGN KEY(product_productinfo_id)
+			REFERENCES product_productinfo(id)
+			ON DELETE CASCADE,
+	UNIQUE(product_productinfo_id, version)
+);
+
+-- record current product info as their first known version
+INSERT INTO product_productversion(
+	version, name, price, weight, description, stock,
+	account_user_id, created_at, product_productinfo_id)
+SELECT
+	version, name, price, weight, description, stock,
+	account_user_id, updated_at, id
+FROM product_productinfo
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,2 @@
+ALTER TABLE product_productinfo
+	DROP COLUMN IF EXISTS hidden;
@@ -0,0 +1,2 @@
+ALTER TABLE product_productinfo
+	ADD COLUMN IF NOT EXISTS hidden BOOLEAN NOT NULL DEFAULT FALSE;
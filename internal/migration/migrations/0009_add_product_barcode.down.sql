@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS product_productinfo_barcode_idx;
+
+ALTER TABLE product_productinfo
+	DROP COLUMN IF EXISTS barcode;
@@ -0,0 +1,5 @@
+ALTER TABLE product_productinfo
+	ADD COLUMN IF NOT EXISTS barcode VARCHAR(14);
+
+CREATE INDEX IF NOT EXISTS product_productinfo_barcode_idx
+	ON product_productinfo (barcode) WHERE barcode IS NOT NULL;
@@ -0,0 +1,4 @@
+ALTER TABLE product_productinfo
+	DROP COLUMN IF EXISTS length,
+	DROP COLUMN IF EXISTS width,
+	DROP COLUMN IF EXISTS height;
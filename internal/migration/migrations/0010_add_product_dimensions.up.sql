@@ -0,0 +1,4 @@
+ALTER TABLE product_productinfo
+	ADD COLUMN IF NOT EXISTS length REAL NOT NULL DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS width REAL NOT NULL DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS height REAL NOT NULL DEFAULT 0;
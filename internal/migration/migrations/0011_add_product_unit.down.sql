@@ -0,0 +1,9 @@
+ALTER TABLE product_stockmovement
+	ALTER COLUMN delta TYPE INT USING ROUND(delta);
+
+ALTER TABLE product_productversion
+	ALTER COLUMN stock TYPE INT USING FLOOR(stock);
+
+ALTER TABLE product_productinfo
+	ALTER COLUMN stock TYPE INT USING FLOOR(stock),
+	DROP COLUMN IF EXISTS unit;
@@ -0,0 +1,9 @@
+ALTER TABLE product_productinfo
+	ADD COLUMN IF NOT EXISTS unit VARCHAR(20) NOT NULL DEFAULT 'piece',
+	ALTER COLUMN stock TYPE NUMERIC(15,3);
+
+ALTER TABLE product_productversion
+	ALTER COLUMN stock TYPE NUMERIC(15,3);
+
+ALTER TABLE product_stockmovement
+	ALTER COLUMN delta TYPE NUMERIC(15,3);
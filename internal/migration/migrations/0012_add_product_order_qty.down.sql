@@ -0,0 +1,3 @@
+ALTER TABLE product_productinfo
+	DROP COLUMN IF EXISTS min_order_qty,
+	DROP COLUMN IF EXISTS max_order_qty;
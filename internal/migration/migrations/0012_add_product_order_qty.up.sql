@@ -0,0 +1,3 @@
+ALTER TABLE product_productinfo
+	ADD COLUMN IF NOT EXISTS min_order_qty NUMERIC(15,3) NOT NULL DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS max_order_qty NUMERIC(15,3) NOT NULL DEFAULT 0;
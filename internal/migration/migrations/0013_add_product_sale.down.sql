@@ -0,0 +1,4 @@
+ALTER TABLE product_productinfo
+	DROP COLUMN IF EXISTS sale_price,
+	DROP COLUMN IF EXISTS sale_starts_at,
+	DROP COLUMN IF EXISTS sale_ends_at;
@@ -0,0 +1,4 @@
+ALTER TABLE product_productinfo
+	ADD COLUMN IF NOT EXISTS sale_price NUMERIC NOT NULL DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS sale_starts_at TIMESTAMPTZ NULL,
+	ADD COLUMN IF NOT EXISTS sale_ends_at TIMESTAMPTZ NULL;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS product_pricetier
+(
+	id SERIAL PRIMARY KEY NOT NULL,
+	min_qty NUMERIC(15,3) NOT NULL,
+	price NUMERIC NOT NULL,
+	product_productinfo_id INT NOT NULL,
+	CONSTRAINT fk_product_productinfo
+		FOREIGN KEY(product_productinfo_id)
+			REFERENCES product_productinfo(id)
+			ON DELETE CASCADE,
+	UNIQUE(product_productinfo_id, min_qty)
+);
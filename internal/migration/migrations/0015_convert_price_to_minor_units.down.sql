@@ -0,0 +1,9 @@
+ALTER TABLE product_pricetier
+	ALTER COLUMN price TYPE NUMERIC USING price / 100.0;
+ALTER TABLE product_productversion
+	ALTER COLUMN price TYPE NUMERIC USING price / 100.0;
+ALTER TABLE product_productinfo
+	ALTER COLUMN price TYPE NUMERIC USING price / 100.0,
+	ALTER COLUMN sale_price DROP DEFAULT,
+	ALTER COLUMN sale_price TYPE NUMERIC USING sale_price / 100.0,
+	ALTER COLUMN sale_price SET DEFAULT 0;
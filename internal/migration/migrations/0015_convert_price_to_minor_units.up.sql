@@ -0,0 +1,9 @@
+ALTER TABLE product_productinfo
+	ALTER COLUMN price TYPE BIGINT USING ROUND(price * 100),
+	ALTER COLUMN sale_price DROP DEFAULT,
+	ALTER COLUMN sale_price TYPE BIGINT USING ROUND(sale_price * 100),
+	ALTER COLUMN sale_price SET DEFAULT 0;
+ALTER TABLE product_productversion
+	ALTER COLUMN price TYPE BIGINT USING ROUND(price * 100);
+ALTER TABLE product_pricetier
+	ALTER COLUMN price TYPE BIGINT USING ROUND(price * 100);
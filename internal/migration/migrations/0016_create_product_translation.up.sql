@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS product_producttranslation
+(
+	id SERIAL PRIMARY KEY NOT NULL,
+	locale VARCHAR(35) NOT NULL,
+	name VARCHAR(100) NOT NULL,
+	description TEXT,
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	product_productinfo_id INT NOT NULL,
+	CONSTRAINT fk_product_productinfo
+		FOREIGN KEY(product_productinfo_id)
+			REFERENCES product_productinfo(id)
+			ON DELETE CASCADE,
+	UNIQUE(product_productinfo_id, locale)
+);
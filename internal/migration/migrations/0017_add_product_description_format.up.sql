@@ -0,0 +1,3 @@
+ALTER TABLE product_productinfo
+	ADD COLUMN IF NOT EXISTS description_format VARCHAR(10) NOT NULL
+		DEFAULT 'plain';
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS product_productinfo_created_at_idx;
@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS product_productinfo_created_at_idx
+	ON product_productinfo (created_at);
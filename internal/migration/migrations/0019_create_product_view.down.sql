@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS product_productview;
+
+ALTER TABLE product_productinfo
+	DROP COLUMN IF EXISTS view_count;
@@ -0,0 +1,14 @@
+ALTER TABLE product_productinfo
+	ADD COLUMN IF NOT EXISTS view_count BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS product_productview
+(
+	viewer_key VARCHAR(100) NOT NULL,
+	viewed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	product_productinfo_id INT NOT NULL,
+	CONSTRAINT fk_product_productinfo
+		FOREIGN KEY(product_productinfo_id)
+			REFERENCES product_productinfo(id)
+			ON DELETE CASCADE,
+	PRIMARY KEY(product_productinfo_id, viewer_key)
+);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS product_ownershiptransfer
+(
+	id SERIAL PRIMARY KEY NOT NULL,
+	from_user_id INT NOT NULL,
+	to_user_id INT NOT NULL,
+	admin_user_id INT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	product_productinfo_id INT NOT NULL,
+	CONSTRAINT fk_product_productinfo
+		FOREIGN KEY(product_productinfo_id)
+			REFERENCES product_productinfo(id)
+			ON DELETE CASCADE
+);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS product_productinfo_name_trgm_idx;
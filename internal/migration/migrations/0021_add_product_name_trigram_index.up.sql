@@ -0,0 +1,3 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX IF NOT EXISTS product_productinfo_name_trgm_idx
+	ON product_productinfo USING GIN (name gin_trgm_ops);
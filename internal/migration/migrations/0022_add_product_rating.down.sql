@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS product_productinfo_rating_idx;
+
+ALTER TABLE product_productinfo
+	DROP COLUMN IF EXISTS avg_rating,
+	DROP COLUMN IF EXISTS review_count;
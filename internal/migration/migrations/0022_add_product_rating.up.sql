@@ -0,0 +1,6 @@
+ALTER TABLE product_productinfo
+	ADD COLUMN IF NOT EXISTS avg_rating NUMERIC(3,2) NOT NULL DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS review_count INT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS product_productinfo_rating_idx
+	ON product_productinfo (avg_rating DESC, id DESC);
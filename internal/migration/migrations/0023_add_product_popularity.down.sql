@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS product_productinfo_popularity_idx;
+
+ALTER TABLE product_productinfo
+	DROP COLUMN IF EXISTS wishlist_count,
+	DROP COLUMN IF EXISTS order_count;
@@ -0,0 +1,6 @@
+ALTER TABLE product_productinfo
+	ADD COLUMN IF NOT EXISTS wishlist_count BIGINT NOT NULL DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS order_count BIGINT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS product_productinfo_popularity_idx
+	ON product_productinfo (order_count DESC, wishlist_count DESC, id DESC);
@@ -0,0 +1,5 @@
+ALTER TABLE product_productimage
+	DROP COLUMN IF EXISTS width,
+	DROP COLUMN IF EXISTS height,
+	DROP COLUMN IF EXISTS size,
+	DROP COLUMN IF EXISTS content_type;
@@ -0,0 +1,5 @@
+ALTER TABLE product_productimage
+	ADD COLUMN IF NOT EXISTS width INT NOT NULL DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS height INT NOT NULL DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS size BIGINT NOT NULL DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS content_type VARCHAR(100) NOT NULL DEFAULT '';
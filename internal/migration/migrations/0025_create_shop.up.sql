@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS product_shop
+(
+	id SERIAL PRIMARY KEY NOT NULL,
+	account_user_id INT NOT NULL UNIQUE,
+	slug VARCHAR(50) NOT NULL UNIQUE,
+	display_name VARCHAR(100) NOT NULL,
+	banner_image_path VARCHAR(250) NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS product_productreport
+(
+	id SERIAL PRIMARY KEY NOT NULL,
+	reason VARCHAR(30) NOT NULL,
+	note TEXT NOT NULL DEFAULT '',
+	reporter_user_id INT NOT NULL,
+	status VARCHAR(20) NOT NULL DEFAULT 'open',
+	reviewer_user_id INT,
+	reviewed_at TIMESTAMPTZ,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	product_productinfo_id INT NOT NULL,
+	CONSTRAINT fk_product_productinfo
+		FOREIGN KEY(product_productinfo_id)
+			REFERENCES product_productinfo(id)
+			ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS product_productreport_open_key
+	ON product_productreport (product_productinfo_id, reporter_user_id)
+	WHERE status = 'open';
+
+CREATE INDEX IF NOT EXISTS product_productreport_status_idx
+	ON product_productreport (status, created_at);
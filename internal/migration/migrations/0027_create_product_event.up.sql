@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS product_productevent
+(
+	id BIGSERIAL PRIMARY KEY NOT NULL,
+	name VARCHAR(30) NOT NULL,
+	sku VARCHAR(15) NOT NULL,
+	user_id INT NOT NULL,
+	payload JSONB,
+	occurred_at TIMESTAMPTZ NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS product_productevent_created_at_idx
+	ON product_productevent (created_at);
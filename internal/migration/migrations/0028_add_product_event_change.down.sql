@@ -0,0 +1,3 @@
+ALTER TABLE product_productevent
+	DROP COLUMN IF EXISTS entity,
+	DROP COLUMN IF EXISTS operation;
@@ -0,0 +1,15 @@
+ALTER TABLE product_productevent
+	ADD COLUMN IF NOT EXISTS entity VARCHAR(30) NOT NULL DEFAULT 'product',
+	ADD COLUMN IF NOT EXISTS operation VARCHAR(10) NOT NULL DEFAULT 'update';
+
+UPDATE product_productevent
+SET entity = 'stock'
+WHERE name = 'StockChanged';
+
+UPDATE product_productevent
+SET operation = 'create'
+WHERE name IN ('ProductCreated', 'ProductRestored');
+
+UPDATE product_productevent
+SET operation = 'delete'
+WHERE name = 'ProductDeleted';
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS product_stockmovement_order_idx;
@@ -0,0 +1,3 @@
+CREATE UNIQUE INDEX IF NOT EXISTS product_stockmovement_order_idx
+	ON product_stockmovement (order_id, reason, product_productinfo_id)
+	WHERE order_id <> '' AND reason IN ('order_placed', 'order_cancelled');
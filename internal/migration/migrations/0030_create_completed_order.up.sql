@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS product_completedorder
+(
+	id SERIAL PRIMARY KEY NOT NULL,
+	order_id VARCHAR(100) NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	product_productinfo_id INT NOT NULL,
+	CONSTRAINT fk_product_productinfo
+		FOREIGN KEY(product_productinfo_id)
+			REFERENCES product_productinfo(id)
+			ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS product_completedorder_order_idx
+	ON product_completedorder (order_id, product_productinfo_id);
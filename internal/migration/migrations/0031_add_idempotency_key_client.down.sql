@@ -0,0 +1,8 @@
+DELETE FROM product_idempotencykey WHERE client <> '';
+
+ALTER TABLE product_idempotencykey
+	DROP CONSTRAINT IF EXISTS product_idempotencykey_pkey,
+	ADD PRIMARY KEY(key, account_user_id);
+
+ALTER TABLE product_idempotencykey
+	DROP COLUMN IF EXISTS client;
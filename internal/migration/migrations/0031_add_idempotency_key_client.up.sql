@@ -0,0 +1,6 @@
+ALTER TABLE product_idempotencykey
+	ADD COLUMN IF NOT EXISTS client VARCHAR(100) NOT NULL DEFAULT '';
+
+ALTER TABLE product_idempotencykey
+	DROP CONSTRAINT IF EXISTS product_idempotencykey_pkey,
+	ADD PRIMARY KEY(key, account_user_id, client);
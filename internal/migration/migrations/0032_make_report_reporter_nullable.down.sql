@@ -0,0 +1,5 @@
+UPDATE product_productreport SET reporter_user_id = 0
+	WHERE reporter_user_id IS NULL;
+
+ALTER TABLE product_productreport
+	ALTER COLUMN reporter_user_id SET NOT NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE product_productreport
+	ALTER COLUMN reporter_user_id DROP NOT NULL;
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrProductCursorInvalid returned by DecodeProductCursor
+// if the cursor is malformed
+var ErrProductCursorInvalid = errors.New("cursor invalid")
+
+// ProductCursor contain position after a product in products sorted
+// by sort order, used for keyset pagination of GetProducts
+type ProductCursor struct {
+	Sort      string    `json:"s"`
+	ID        int       `json:"i"`
+	CreatedAt time.Time `json:"c"`
+	Rating    float64   `json:"r,omitempty"`
+
+	OrderCount    int64 `json:"o,omitempty"`
+	WishlistCount int64 `json:"w,omitempty"`
+}
+
+// NewProductCursor create cursor positioned after product info
+// in products sorted by sort order
+func NewProductCursor(sort string, pInfo ProductInfo) ProductCursor {
+	return ProductCursor{
+		Sort:      sort,
+		ID:        pInfo.ID,
+		CreatedAt: pInfo.CreatedAt,
+		Rating:    pInfo.AvgRating,
+
+		OrderCount:    pInfo.OrderCount,
+		WishlistCount: pInfo.WishlistCount,
+	}
+}
+
+// Encode encode cursor into opaque URL safe string
+func (pc ProductCursor) Encode() string {
+	b, _ := json.Marshal(pc)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeProductCursor decode cursor from string encoded by Encode
+func DecodeProductCursor(s string) (ProductCursor, error) {
+	pc := ProductCursor{}
+
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return pc, ErrProductCursorInvalid
+	}
+
+	err = json.Unmarshal(b, &pc)
+	if err != nil || pc.ID <= 0 {
+		return pc, ErrProductCursorInvalid
+	}
+
+	return pc, nil
+}
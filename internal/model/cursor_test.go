@@ -0,0 +1,282 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"context"
+	"log"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestDecodeProductCursor test ProductCursor Encode and DecodeProductCursor
+func TestDecodeProductCursor(t *testing.T) {
+	pc := NewProductCursor(ProductSortNewest, ProductInfo{
+		ID:        10,
+		CreatedAt: time.Date(2022, 1, 1, 0, 0, 0, 123000, time.UTC),
+	})
+
+	// create testing table
+	testTable := []struct {
+		TestName       string
+		Cursor         string
+		ExpectedResult ProductCursor
+		ExpectedError  error
+	}{
+		{
+			TestName:       "Encoded cursor",
+			Cursor:         pc.Encode(),
+			ExpectedResult: pc,
+			ExpectedError:  nil,
+		},
+		{
+			TestName:      "Not base64",
+			Cursor:        "not base64!",
+			ExpectedError: ErrProductCursorInvalid,
+		},
+		{
+			TestName:      "Not JSON",
+			Cursor:        "bm90IGpzb24",
+			ExpectedError: ErrProductCursorInvalid,
+		},
+		{
+			TestName:      "Without ID",
+			Cursor:        "e30",
+			ExpectedError: ErrProductCursorInvalid,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		result, err := DecodeProductCursor(test.Cursor)
+		if err != test.ExpectedError {
+			t.Errorf("[%s] Expected error %v, but got %v",
+				test.TestName, test.ExpectedError, err)
+		}
+		if err == nil && (result.Sort != test.ExpectedResult.Sort ||
+			result.ID != test.ExpectedResult.ID ||
+			!result.CreatedAt.Equal(test.ExpectedResult.CreatedAt)) {
+			t.Errorf("[%s] Expected cursor %v, but got %v",
+				test.TestName, test.ExpectedResult, result)
+		}
+	}
+}
+
+// TestGetProductsAfterCursor test GetProducts paginated by cursor
+//
+// Required for the test: InsertProductInfo, SetProductRatingBySKU,
+// IncrementPopularityCounters
+func TestGetProductsAfterCursor(t *testing.T) {
+	// get testing DB connection
+	DB, err := getTestDBConnection()
+	if err != nil {
+		t.Errorf("There's an error when initialize "+
+			"testing database connection => %s", err.Error())
+	}
+
+	// insert products with rating into database
+	ratings := map[string]ProductRating{
+		"PRODUCT A": {AvgRating: 4.5, ReviewCount: 2},
+		"PRODUCT B": {AvgRating: 3, ReviewCount: 1},
+		"PRODUCT C": {AvgRating: 4.5, ReviewCount: 4},
+	}
+	popularity := map[string]PopularityCounter{
+		"PRODUCT A": {WishlistAdds: 5, CompletedOrders: 2},
+		"PRODUCT B": {WishlistAdds: 1, CompletedOrders: 2},
+		"PRODUCT C": {WishlistAdds: 9},
+	}
+	for _, name := range []string{"PRODUCT A", "PRODUCT B", "PRODUCT C"} {
+		pInfo, err := InsertProductInfo(context.Background(), DB, ProductInfo{
+			Name: name, Price: 1000, Weight: 1, Stock: 10, UserID: 1,
+		})
+		if err != nil {
+			t.Errorf("There's an error when insert data product info => %s",
+				err.Error())
+		}
+
+		err = SetProductRatingBySKU(context.Background(), DB, pInfo.SKU,
+			ratings[name])
+		if err != nil {
+			t.Errorf("There's an error when set product rating => %s",
+				err.Error())
+		}
+
+		counter := popularity[name]
+		counter.SKU = pInfo.SKU
+		_, err = IncrementPopularityCounters(context.Background(), DB,
+			[]PopularityCounter{counter, {SKU: "NOT-FOUND", WishlistAdds: 1}})
+		if err != nil {
+			t.Errorf("There's an error when increment popularity => %s",
+				err.Error())
+		}
+	}
+
+	// create testing table
+	testTable := []struct {
+		TestName      string
+		Sort          string
+		ExpectedNames []string
+	}{
+		{
+			TestName:      "Sort default",
+			Sort:          ProductSortDefault,
+			ExpectedNames: []string{"PRODUCT A", "PRODUCT B", "PRODUCT C"},
+		},
+		{
+			TestName:      "Sort newest",
+			Sort:          ProductSortNewest,
+			ExpectedNames: []string{"PRODUCT C", "PRODUCT B", "PRODUCT A"},
+		},
+		{
+			TestName:      "Sort rating",
+			Sort:          ProductSortRating,
+			ExpectedNames: []string{"PRODUCT C", "PRODUCT A", "PRODUCT B"},
+		},
+		{
+			TestName:      "Sort popular",
+			Sort:          ProductSortPopular,
+			ExpectedNames: []string{"PRODUCT A", "PRODUCT B", "PRODUCT C"},
+		},
+	}
+
+	// loop test in test table, getting products one by one
+	for _, test := range testTable {
+		query := ProductQuery{Sort: test.Sort, Limit: 1}
+		for _, expectedName := range test.ExpectedNames {
+			result, err := GetProducts(context.Background(), DB, query)
+			if err != nil {
+				t.Fatalf("[%s] Expected error nil, but got error => %s",
+					test.TestName, err.Error())
+			}
+			if len(result) != 1 || result[0].ProductInfo.Name != expectedName {
+				t.Fatalf("[%s] Expected product %s, but got %v",
+					test.TestName, expectedName, result)
+			}
+
+			cursor := NewProductCursor(test.Sort, result[0].ProductInfo)
+			query.After = &cursor
+		}
+
+		// check no product after the last one
+		result, err := GetProducts(context.Background(), DB, query)
+		if err != nil || len(result) != 0 {
+			t.Errorf("[%s] Expected no product after the last one, "+
+				"but got %v, %v", test.TestName, result, err)
+		}
+
+		// check cursor of other sort order rejected
+		query.Sort = ProductSortNewest
+		if test.Sort == ProductSortNewest {
+			query.Sort = ProductSortDefault
+		}
+		_, err = GetProducts(context.Background(), DB, query)
+		if err != ErrProductCursorInvalid {
+			t.Errorf("[%s] Expected error %v, but got %v",
+				test.TestName, ErrProductCursorInvalid, err)
+		}
+	}
+
+	// truncate tables after test
+	_, err = DB.Exec("TRUNCATE product_productinfo RESTART IDENTITY CASCADE")
+	if err != nil {
+		log.Fatalf("There's an error when truncating "+
+			"table product_productinfo => %s",
+			err.Error())
+	}
+}
+
+// TestGetProductsRankedSKUs test GetProducts keeping order of SKUs
+// ranked by search engine, paginated by cursor
+//
+// Required for the test: InsertProductInfo
+func TestGetProductsRankedSKUs(t *testing.T) {
+	// get testing DB connection
+	DB, err := getTestDBConnection()
+	if err != nil {
+		t.Errorf("There's an error when initialize "+
+			"testing database connection => %s", err.Error())
+	}
+
+	// insert products into database
+	SKUs := map[string]string{}
+	for _, name := range []string{"PRODUCT A", "PRODUCT B", "PRODUCT C"} {
+		pInfo, err := InsertProductInfo(context.Background(), DB, ProductInfo{
+			Name: name, Price: 1000, Weight: 1, Stock: 10, UserID: 1,
+		})
+		if err != nil {
+			t.Errorf("There's an error when insert data product info => %s",
+				err.Error())
+		}
+		SKUs[name] = pInfo.SKU
+	}
+	ranked := []string{SKUs["PRODUCT C"], SKUs["PRODUCT A"], SKUs["PRODUCT B"]}
+
+	// create testing table
+	testTable := []struct {
+		TestName      string
+		Query         ProductQuery
+		ExpectedNames []string
+	}{
+		{
+			TestName:      "Ranked",
+			Query:         ProductQuery{SKUs: ranked, RankedSKUs: true},
+			ExpectedNames: []string{"PRODUCT C", "PRODUCT A", "PRODUCT B"},
+		},
+		{
+			TestName:      "Not Ranked",
+			Query:         ProductQuery{SKUs: ranked},
+			ExpectedNames: []string{"PRODUCT A", "PRODUCT B", "PRODUCT C"},
+		},
+		{
+			TestName: "Ranked Sorted Newest",
+			Query: ProductQuery{SKUs: ranked, RankedSKUs: true,
+				Sort: ProductSortNewest},
+			ExpectedNames: []string{"PRODUCT C", "PRODUCT B", "PRODUCT A"},
+		},
+	}
+
+	// loop test in test table, getting all products then one by one
+	for _, test := range testTable {
+		result, err := GetProducts(context.Background(), DB, test.Query)
+		if err != nil {
+			t.Fatalf("[%s] Expected error nil, but got error => %s",
+				test.TestName, err.Error())
+		}
+		names := []string{}
+		for _, p := range result {
+			names = append(names, p.ProductInfo.Name)
+		}
+		if !reflect.DeepEqual(names, test.ExpectedNames) {
+			t.Errorf("[%s] Expected products %v, but got %v",
+				test.TestName, test.ExpectedNames, names)
+		}
+
+		query := test.Query
+		query.Limit = 1
+		for _, expectedName := range test.ExpectedNames {
+			result, err = GetProducts(context.Background(), DB, query)
+			if err != nil {
+				t.Fatalf("[%s] Expected error nil, but got error => %s",
+					test.TestName, err.Error())
+			}
+			if len(result) != 1 || result[0].ProductInfo.Name != expectedName {
+				t.Fatalf("[%s] Expected product %s after cursor, but got %v",
+					test.TestName, expectedName, result)
+			}
+
+			cursor := NewProductCursor(query.Sort, result[0].ProductInfo)
+			query.After = &cursor
+		}
+	}
+
+	// truncate tables after test
+	_, err = DB.Exec("TRUNCATE product_productinfo RESTART IDENTITY CASCADE")
+	if err != nil {
+		log.Fatalf("There's an error when truncating "+
+			"table product_productinfo => %s",
+			err.Error())
+	}
+}
@@ -0,0 +1,100 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+)
+
+// IdempotencyKey contain a request fingerprint and its stored response
+// for an Idempotency-Key sent by a user, Client is the calling service
+// for service principal whose requests all have user ID 0
+type IdempotencyKey struct {
+	Key          string
+	UserID       int
+	Client       string
+	Fingerprint  string
+	StatusCode   sql.NullInt64
+	ContentType  string
+	ResponseBody []byte
+}
+
+// GetIdempotencyKey get unexpired idempotency key of a user or client
+//
+// return sql.ErrNoRows if not found
+func GetIdempotencyKey(ctx context.Context, DB *sql.DB, key string,
+	userID int, client string) (IdempotencyKey, error) {
+	ik := IdempotencyKey{}
+
+	err := DB.QueryRowContext(ctx, `
+		SELECT key, account_user_id, client, fingerprint, status_code,
+			content_type, response_body
+		FROM product_idempotencykey
+		WHERE key = $1 AND account_user_id = $2 AND client = $3
+			AND created_at > NOW() - INTERVAL '24 hours'`,
+		key, userID, client).Scan(&ik.Key, &ik.UserID, &ik.Client,
+		&ik.Fingerprint,
+		&ik.StatusCode, &ik.ContentType, &ik.ResponseBody)
+	if err != nil {
+		return ik, err
+	}
+
+	return ik, nil
+}
+
+// InsertIdempotencyKey insert idempotency key of a user or client without
+// response, marking the request as in progress, replacing it if already
+// expired
+//
+// return false if the key already exists and unexpired
+func InsertIdempotencyKey(ctx context.Context, DB *sql.DB,
+	ik IdempotencyKey) (bool, error) {
+	result, err := DB.ExecContext(ctx, `INSERT INTO
+		product_idempotencykey(key, account_user_id, client, fingerprint)
+		VALUES($1,$2,$3,$4)
+		ON CONFLICT (key, account_user_id, client) DO UPDATE
+		SET fingerprint = EXCLUDED.fingerprint, status_code = NULL,
+			content_type = '', response_body = NULL, created_at = NOW()
+		WHERE product_idempotencykey.created_at <= NOW() - INTERVAL '24 hours'`,
+		ik.Key, ik.UserID, ik.Client, ik.Fingerprint)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+// SaveIdempotencyResponse save response of an idempotency key request
+func SaveIdempotencyResponse(ctx context.Context, DB *sql.DB,
+	ik IdempotencyKey) error {
+	_, err := DB.ExecContext(ctx, `
+		UPDATE product_idempotencykey
+		SET status_code = $1, content_type = $2, response_body = $3
+		WHERE key = $4 AND account_user_id = $5 AND client = $6`,
+		ik.StatusCode, ik.ContentType, ik.ResponseBody, ik.Key, ik.UserID,
+		ik.Client)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteIdempotencyKey delete idempotency key of a user or client,
+// so the request can be retried
+func DeleteIdempotencyKey(ctx context.Context, DB *sql.DB, key string,
+	userID int, client string) error {
+	_, err := DB.ExecContext(ctx, `
+		DELETE FROM product_idempotencykey
+		WHERE key = $1 AND account_user_id = $2 AND client = $3`,
+		key, userID, client)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
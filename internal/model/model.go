@@ -5,95 +5,315 @@ database transaction
 package model
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"image"
+	"image/color"
 	"io"
+	"log"
 	"mime/multipart"
+	"net/http"
 	"os"
-	"strconv"
+	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/reyhanfikridz/ecom-product-service/internal/config"
+	"github.com/reyhanfikridz/ecom-product-service/internal/imaging"
+	"github.com/reyhanfikridz/ecom-product-service/internal/richtext"
+	"github.com/reyhanfikridz/ecom-product-service/internal/scanner"
 	"github.com/reyhanfikridz/ecom-product-service/internal/utils"
 )
 
 // ProductInfo contain basic information of a product
 type ProductInfo struct {
-	ID          int     `json:"id" form:"id"`
-	SKU         string  `json:"sku" form:"sku"`
-	Name        string  `json:"name" form:"name"`
-	Price       float64 `json:"price" form:"price"`
-	Weight      float32 `json:"weight" form:"weight"`
-	Description string  `json:"description" form:"description"`
-	Stock       int     `json:"stock" form:"stock"`
-	UserID      int     `json:"user_id" form:"user_id"`
+	ID          int        `json:"id" form:"id"`
+	SKU         string     `json:"sku" form:"sku"`
+	Name        string     `json:"name" form:"name"`
+	Price       Money      `json:"price" form:"price"`
+	Weight      float32    `json:"weight" form:"weight"`
+	Description string     `json:"description" form:"description"`
+	Stock       float64    `json:"stock" form:"stock"`
+	UserID      int        `json:"user_id" form:"user_id"`
+	CreatedAt   time.Time  `json:"created_at" form:"-"`
+	UpdatedAt   time.Time  `json:"updated_at" form:"-"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty" form:"-"`
+	Version     int        `json:"version" form:"-"`
+	Hidden      bool       `json:"hidden" form:"-"`
+	Barcode     string     `json:"barcode" form:"barcode"`
+	Length      float32    `json:"length" form:"length"`
+	Width       float32    `json:"width" form:"width"`
+	Height      float32    `json:"height" form:"height"`
+	Unit        string     `json:"unit" form:"unit"`
+	MinOrderQty float64    `json:"min_order_qty" form:"min_order_qty"`
+	MaxOrderQty float64    `json:"max_order_qty" form:"max_order_qty"`
+	ViewCount   int64      `json:"view_count" form:"-"`
+	AvgRating   float64    `json:"avg_rating" form:"-"`
+	ReviewCount int        `json:"review_count" form:"-"`
+
+	// WishlistCount and OrderCount popularity counters reported
+	// by wishlist and order service
+	WishlistCount int64 `json:"wishlist_count" form:"-"`
+	OrderCount    int64 `json:"order_count" form:"-"`
+
+	// DescriptionFormat format of description, plain, markdown, or html,
+	// DescriptionHTML is description rendered as safe HTML
+	DescriptionFormat string `json:"description_format" form:"description_format"`
+	DescriptionHTML   string `json:"description_html" form:"-"`
+
+	SalePrice    Money      `json:"sale_price" form:"sale_price"`
+	SaleStartsAt *time.Time `json:"sale_starts_at" form:"-"`
+	SaleEndsAt   *time.Time `json:"sale_ends_at" form:"-"`
+
+	VolumetricWeight float32 `json:"volumetric_weight" form:"-"`
+	EffectivePrice   Money   `json:"effective_price" form:"-"`
+
+	// Locale locale of name and description, only set when
+	// the product is localized
+	Locale string `json:"locale,omitempty" form:"-"`
+}
+
+// GetVolumetricWeight get volumetric weight in kg of product dimensions
+// in cm, 0 if the dimensions are not set
+func (pi ProductInfo) GetVolumetricWeight() float32 {
+	if config.VolumetricWeightDivisor <= 0 {
+		return 0
+	}
+
+	return pi.Length * pi.Width * pi.Height /
+		float32(config.VolumetricWeightDivisor)
+}
+
+// IsOnSale check if sale price is set and the instant is within
+// sale schedule, unset start or end of the schedule means no limit
+func (pi ProductInfo) IsOnSale(at time.Time) bool {
+	return pi.SalePrice > 0 &&
+		(pi.SaleStartsAt == nil || !at.Before(*pi.SaleStartsAt)) &&
+		(pi.SaleEndsAt == nil || at.Before(*pi.SaleEndsAt))
+}
+
+// GetEffectivePrice get price of product at the instant,
+// sale price if it's on sale at the instant
+func (pi ProductInfo) GetEffectivePrice(at time.Time) Money {
+	if pi.IsOnSale(at) {
+		return pi.SalePrice
+	}
+
+	return pi.Price
+}
+
+// setComputedFields set fields computed from the other product info fields
+func (pi *ProductInfo) setComputedFields() {
+	pi.VolumetricWeight = pi.GetVolumetricWeight()
+	pi.EffectivePrice = pi.GetEffectivePrice(time.Now())
+	pi.DescriptionHTML = richtext.Render(pi.DescriptionFormat, pi.Description)
+}
+
+// cleanDescription set default description format and sanitize
+// description in the format before it's stored
+func (pi *ProductInfo) cleanDescription() {
+	if pi.DescriptionFormat == "" {
+		pi.DescriptionFormat = richtext.FormatPlain
+	}
+	pi.Description = richtext.Clean(pi.DescriptionFormat, pi.Description)
+}
+
+// IsOrderQtyAllowed check if quantity is at least minimum order quantity
+// and at most maximum order quantity, 0 means no limit
+func (pi ProductInfo) IsOrderQtyAllowed(qty float64) bool {
+	return qty >= pi.MinOrderQty &&
+		(pi.MaxOrderQty == 0 || qty <= pi.MaxOrderQty)
+}
+
+// product info columns selected by product queries, in scan order
+const productInfoColumns = `id, sku, name, price, weight, description,
+	stock, account_user_id, created_at, updated_at, deleted_at, version,
+	hidden, COALESCE(barcode, ''), length, width, height, unit,
+	min_order_qty, max_order_qty, sale_price, sale_starts_at, sale_ends_at,
+	description_format, view_count, avg_rating, review_count,
+	wishlist_count, order_count`
+
+// rowScanner scan a result row, implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanProductInfo scan product info row selected with productInfoColumns
+func scanProductInfo(row rowScanner, pInfo *ProductInfo) error {
+	err := row.Scan(
+		&pInfo.ID, &pInfo.SKU, &pInfo.Name, &pInfo.Price, &pInfo.Weight,
+		&pInfo.Description, &pInfo.Stock, &pInfo.UserID,
+		&pInfo.CreatedAt, &pInfo.UpdatedAt, &pInfo.DeletedAt, &pInfo.Version,
+		&pInfo.Hidden, &pInfo.Barcode, &pInfo.Length, &pInfo.Width,
+		&pInfo.Height, &pInfo.Unit, &pInfo.MinOrderQty, &pInfo.MaxOrderQty,
+		&pInfo.SalePrice, &pInfo.SaleStartsAt, &pInfo.SaleEndsAt,
+		&pInfo.DescriptionFormat, &pInfo.ViewCount, &pInfo.AvgRating,
+		&pInfo.ReviewCount, &pInfo.WishlistCount, &pInfo.OrderCount)
+	if err != nil {
+		return err
+	}
+
+	pInfo.setComputedFields()
+	return nil
 }
 
 // ProductImage contain image of a product
 type ProductImage struct {
 	ID          int         `json:"id" form:"id"`
 	ImagePath   string      `json:"image_path" form:"image_path"`
+	ImageURL    string      `json:"image_url,omitempty" form:"-"`
+	Width       int         `json:"width" form:"-"`
+	Height      int         `json:"height" form:"-"`
+	Size        int64       `json:"size" form:"-"`
+	ContentType string      `json:"content_type" form:"-"`
+	CreatedAt   time.Time   `json:"created_at" form:"-"`
 	ProductInfo ProductInfo `json:"product_info" form:"product_info"`
 }
 
+// SellerInfo contain seller data from account service
+type SellerInfo struct {
+	Email       string `json:"email"`
+	FullName    string `json:"full_name"`
+	Address     string `json:"address"`
+	PhoneNumber string `json:"phone_number"`
+}
+
 // Product contain product info, product images, and seller info
 type Product struct {
-	ProductInfo   ProductInfo    `json:"product_info"`
-	ProductImages []ProductImage `json:"product_images"`
-	SellerInfo    struct {
-		Email       string `json:"email"`
-		FullName    string `json:"full_name"`
-		Address     string `json:"address"`
-		PhoneNumber string `json:"phone_number"`
-	} `json:"seller_info"`
+	ProductInfo   ProductInfo       `json:"product_info"`
+	ProductImages []ProductImage    `json:"product_images"`
+	PriceTiers    []PriceTier       `json:"price_tiers"`
+	SellerInfo    SellerInfo        `json:"seller_info"`
+	Highlights    []SearchHighlight `json:"highlights,omitempty"`
+}
+
+// WithTransaction run fn in one transaction, committed if fn succeed
+// and rolled back otherwise, so everything fn writes is atomic
+func WithTransaction(ctx context.Context, DB *sql.DB,
+	fn func(tx *sql.Tx) error) error {
+	// begin transaction
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // rollback transaction if fail
+
+	err = fn(tx)
+	if err != nil {
+		return err
+	}
+
+	// commit transaction
+	return tx.Commit()
 }
 
 // InsertProductInfo insert a product info into database
-func InsertProductInfo(DB *sql.DB, pInfo ProductInfo) (ProductInfo, error) {
+func InsertProductInfo(ctx context.Context, DB *sql.DB,
+	pInfo ProductInfo) (ProductInfo, error) {
+	return InsertProductWithImages(ctx, DB, pInfo, nil)
+}
+
+// InsertProductWithImages insert a product info and its images of image
+// files already saved into media folder into database in one transaction
+func InsertProductWithImages(ctx context.Context, DB *sql.DB,
+	pInfo ProductInfo, images []ProductImage) (ProductInfo, error) {
+	err := WithTransaction(ctx, DB, func(tx *sql.Tx) error {
+		var err error
+		pInfo, err = insertProductInfo(ctx, tx, pInfo)
+		if err != nil {
+			return err
+		}
+
+		for _, pImage := range images {
+			err = insertProductImage(ctx, tx, pInfo.ID, pImage)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return pInfo, err
+}
+
+// ProductBatchItem contain a product info and its images of image files
+// already saved into media folder, inserted by InsertProducts
+type ProductBatchItem struct {
+	ProductInfo   ProductInfo
+	ProductImages []ProductImage
+}
+
+// InsertProducts insert product infos and their images into database
+// in one transaction, returning inserted product infos in items order
+func InsertProducts(ctx context.Context, DB *sql.DB,
+	items []ProductBatchItem) ([]ProductInfo, error) {
+	pInfos := []ProductInfo{}
+
 	// begin transaction
-	tx, err := DB.Begin()
+	tx, err := DB.BeginTx(ctx, nil)
 	if err != nil {
-		return pInfo, err
+		return pInfos, err
 	}
 	defer tx.Rollback()
 
-	// get unique random SKU
-	var SKU string
-	for {
-		SKU = utils.GetRandomSKU()
-
-		var tmpID int
-		err := tx.QueryRow(`
-			SELECT id 
-			FROM product_productinfo
-			WHERE sku = $1
-		`, SKU).Scan(&tmpID)
-		if err != nil && err != sql.ErrNoRows {
-			return pInfo, err
-		} else if err != nil && err == sql.ErrNoRows {
-			break
+	for _, item := range items {
+		pInfo, err := insertProductInfo(ctx, tx, item.ProductInfo)
+		if err != nil {
+			return pInfos, err
+		}
+
+		for _, pImage := range item.ProductImages {
+			err = insertProductImage(ctx, tx, pInfo.ID, pImage)
+			if err != nil {
+				return pInfos, err
+			}
 		}
+
+		pInfos = append(pInfos, pInfo)
 	}
 
-	// insert product info, returning product info ID and SKU
-	row := tx.QueryRow(`INSERT INTO 
-		product_productinfo(
-			sku, name, weight, price, description, stock, account_user_id) 
-		VALUES($1,$2,$3,$4,$5,$6,$7) returning id, sku`,
-		SKU, pInfo.Name, pInfo.Weight, pInfo.Price,
-		pInfo.Description, pInfo.Stock, pInfo.UserID)
+	// commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return pInfos, err
+	}
 
-	if row.Err() != nil {
-		return pInfo, row.Err()
+	return pInfos, nil
+}
+
+// insertProductInfo insert a product info with unique random SKU
+// in transaction, recording its first version and initial stock
+func insertProductInfo(ctx context.Context, tx *sql.Tx,
+	pInfo ProductInfo) (ProductInfo, error) {
+	// insert product info with random SKU, retrying once with another SKU
+	// if it's already used
+	err := insertProductInfoRow(ctx, tx, &pInfo)
+	if isSKUConflict(err) {
+		err = insertProductInfoRow(ctx, tx, &pInfo)
+	}
+	if err != nil {
+		return pInfo, err
 	}
 
-	err = row.Scan(&pInfo.ID, &pInfo.SKU)
+	// record first version of product info
+	err = insertProductVersion(ctx, tx, pInfo)
 	if err != nil {
 		return pInfo, err
 	}
 
-	// commit transaction
-	err = tx.Commit()
+	// record initial stock into stock movement ledger
+	err = insertStockMovement(ctx, tx, pInfo.ID, StockMovement{
+		Delta:  pInfo.Stock,
+		Reason: StockReasonCreated,
+		UserID: pInfo.UserID,
+	})
 	if err != nil {
 		return pInfo, err
 	}
@@ -101,242 +321,1108 @@ func InsertProductInfo(DB *sql.DB, pInfo ProductInfo) (ProductInfo, error) {
 	return pInfo, nil
 }
 
-// InsertProductImages insert product images into database
-// and save the product image files into media folder
-func InsertProductImages(DB *sql.DB, fileHeaders []*multipart.FileHeader,
-	pInfo ProductInfo) error {
-	// begin transaction
-	tx, err := DB.Begin()
+// insertProductInfoRow insert product info row with random SKU
+// in transaction, setting product info ID, SKU, timestamps, and version
+//
+// the insert is done in a savepoint so the transaction still usable
+// if the SKU already used
+func insertProductInfoRow(ctx context.Context, tx *sql.Tx,
+	pInfo *ProductInfo) error {
+	SKU, err := utils.GetRandomSKU()
 	if err != nil {
 		return err
 	}
-	defer tx.Rollback() // rollback transaction if fail
+	if pInfo.Unit == "" {
+		pInfo.Unit = UnitPiece
+	}
+	pInfo.cleanDescription()
+
+	_, err = tx.ExecContext(ctx, "SAVEPOINT insert_product_info")
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRowContext(ctx, `INSERT INTO 
+		product_productinfo(
+			sku, name, weight, price, description, stock, account_user_id,
+			barcode, length, width, height, unit, min_order_qty,
+			max_order_qty, sale_price, sale_starts_at, sale_ends_at,
+			description_format, hidden) 
+		VALUES($1,$2,$3,$4,$5,$6,$7,NULLIF($8, ''),$9,$10,$11,$12,$13,$14,
+			$15,$16,$17,$18,$19)
+		returning id, sku, created_at, updated_at, version`,
+		SKU, pInfo.Name, pInfo.Weight, pInfo.Price,
+		pInfo.Description, pInfo.Stock, pInfo.UserID, pInfo.Barcode,
+		pInfo.Length, pInfo.Width, pInfo.Height, pInfo.Unit,
+		pInfo.MinOrderQty, pInfo.MaxOrderQty, pInfo.SalePrice,
+		pInfo.SaleStartsAt, pInfo.SaleEndsAt,
+		pInfo.DescriptionFormat, pInfo.Hidden).Scan(
+		&pInfo.ID, &pInfo.SKU, &pInfo.CreatedAt, &pInfo.UpdatedAt,
+		&pInfo.Version)
+	if err != nil {
+		_, rbErr := tx.ExecContext(ctx,
+			"ROLLBACK TO SAVEPOINT insert_product_info")
+		if rbErr != nil {
+			return rbErr
+		}
+		return err
+	}
 
+	pInfo.setComputedFields()
+
+	_, err = tx.ExecContext(ctx, "RELEASE SAVEPOINT insert_product_info")
+	return err
+}
+
+// isSKUConflict check error is unique violation of product SKU
+func isSKUConflict(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" &&
+		pqErr.Constraint == "product_productinfo_sku_key"
+}
+
+// InsertProductImages insert product images into database
+// and save the product image files into media folder,
+// replacing existing images of the product
+func InsertProductImages(ctx context.Context, DB *sql.DB,
+	fileHeaders []*multipart.FileHeader, pInfo ProductInfo) error {
+	return WithTransaction(ctx, DB, func(tx *sql.Tx) error {
+		// loop the image file headers
+		images := []ProductImage{}
+		for _, fileHeader := range fileHeaders {
+			// save the image file into media folder
+			pImage, err := SaveProductImage(fileHeader, pInfo.UserID)
+			if err != nil {
+				return err
+			}
+			images = append(images, pImage)
+		}
+
+		return replaceProductImages(ctx, tx, pInfo.ID, images)
+	})
+}
+
+// replaceProductImages replace images of product with images of image
+// files already saved into media folder in transaction
+func replaceProductImages(ctx context.Context, tx *sql.Tx, productID int,
+	images []ProductImage) error {
 	// delete existed images first
-	_, err = tx.Exec(`DELETE FROM product_productimage 
+	_, err := tx.ExecContext(ctx, `DELETE FROM product_productimage 
 		WHERE product_productinfo_id = $1`,
-		pInfo.ID)
+		productID)
 	if err != nil {
 		return err
 	}
 
-	// loop the image file headers
-	for _, fileHeader := range fileHeaders {
-		// save the image file into media folder
-		imagePath, err := SaveProductImage(fileHeader)
+	for _, pImage := range images {
+		err = insertProductImage(ctx, tx, productID, pImage)
 		if err != nil {
 			return err
 		}
+	}
+
+	return nil
+}
+
+// insertProductImage insert a product image in transaction
+func insertProductImage(ctx context.Context, tx *sql.Tx, productID int,
+	pImage ProductImage) error {
+	_, err := tx.ExecContext(ctx, `INSERT INTO 
+		product_productimage(image_path, width, height, size, content_type,
+			product_productinfo_id)
+		VALUES($1,$2,$3,$4,$5,$6)`,
+		pImage.ImagePath, pImage.Width, pImage.Height, pImage.Size,
+		pImage.ContentType, productID)
+	return err
+}
+
+// SaveProductImage save product image file of seller of user ID
+// into media folder
+func SaveProductImage(fileHeader *multipart.FileHeader, userID int) (
+	ProductImage, error) {
+	// open the file
+	file, err := fileHeader.Open()
+	if err != nil {
+		return ProductImage{}, err
+	}
+	defer file.Close()
+
+	return SaveProductImageFile(fileHeader.Filename, file, userID)
+}
+
+// MaxImagePathLength maximum length of product image path
+const MaxImagePathLength = 250
+
+// imageHashLength length of content hash in product image path
+const imageHashLength = 16
+
+// MaxImageFilenameLength maximum file name length of product image,
+// so its image path prefixed with folder, timestamp, and content hash
+// fits MaxImagePathLength
+const MaxImageFilenameLength = MaxImagePathLength - len("product-image/") -
+	20 - imageHashLength - 1
+
+// ErrProductImageInvalid error of product image not decodable or below
+// minimum resolution when product images are processed
+var ErrProductImageInvalid = errors.New("product image invalid")
+
+// ErrProductImageMalicious error of product image rejected by scanner
+// as malware or other suspicious content
+var ErrProductImageMalicious = errors.New("product image rejected " +
+	"by malware scan")
+
+// ProductImageScanner scanner of saved product image files,
+// files aren't scanned if it's nil
+var ProductImageScanner scanner.Scanner
+
+// ProductImageWatermark watermark stamped onto saved product images
+// of sellers in config.WatermarkSellers, or of every seller if it's
+// empty, images aren't watermarked if it's nil
+var ProductImageWatermark image.Image
+
+// SaveProductImageFile save product image file content of file name
+// of seller of user ID into media folder, returning its image path
+// containing hash of the content, so its URL changes whenever
+// the content changes
+//
+// the image is fit into required aspect ratio if configured, scanned
+// by product image scanner if any, then watermarked if the seller's
+// images are watermarked
+func SaveProductImageFile(filename string, r io.Reader, userID int) (
+	ProductImage, error) {
+	r, err := processProductImage(filename, r)
+	if err != nil {
+		return ProductImage{}, err
+	}
+
+	return saveImageFile(filename, r, isWatermarkedSeller(userID))
+}
+
+// saveImageFile save image file content of file name into product image
+// folder of media folder, watermarked if watermarked is true, returning
+// its image path containing hash of the content
+func saveImageFile(filename string, r io.Reader, watermarked bool) (
+	ProductImage, error) {
+	// create the product image folder first if not exist
+	dir := filepath.Join(config.MediaRoot, "product-image")
+	err := os.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return ProductImage{}, err
+	}
+
+	// copy the uploaded image file into temporary file first
+	// since its path depends on the content
+	tmp, err := os.CreateTemp(dir, ".upload-*")
+	if err != nil {
+		return ProductImage{}, err
+	}
+	defer os.Remove(tmp.Name()) // remove temporary file if fail
+
+	h := sha256.New()
+	_, err = io.Copy(tmp, io.TeeReader(r, h))
+	if err != nil {
+		tmp.Close()
+		return ProductImage{}, err
+	}
+	// temporary file is created readable only by owner
+	err = tmp.Chmod(0644)
+	if err != nil {
+		tmp.Close()
+		return ProductImage{}, err
+	}
+	err = tmp.Close()
+	if err != nil {
+		return ProductImage{}, err
+	}
+
+	// scan the image file before it's served
+	err = scanProductImageFile(tmp.Name(), filename)
+	if err != nil {
+		return ProductImage{}, err
+	}
 
-		// insert product image into database
-		_, err = tx.Exec(`INSERT INTO 
-			product_productimage(image_path, product_productinfo_id)
-			VALUES($1,$2)`,
-			imagePath, pInfo.ID)
+	// move the image file to its path in the product image directory
+	imagePath := fmt.Sprintf("product-image/%d-%s-%s",
+		time.Now().UnixNano(),
+		hex.EncodeToString(h.Sum(nil))[:imageHashLength],
+		filename)
+	if watermarked {
+		err = watermarkProductImageFile(tmp.Name(), imagePath, filename)
 		if err != nil {
-			return err
+			return ProductImage{}, err
 		}
 	}
+	pImage, err := getProductImageFileInfo(tmp.Name())
+	if err != nil {
+		RemoveProductImageFile(imagePath)
+		return ProductImage{}, err
+	}
+	pImage.ImagePath = imagePath
+	err = os.Rename(tmp.Name(), filepath.Join(config.MediaRoot, imagePath))
+	if err != nil {
+		RemoveProductImageFile(imagePath)
+		return ProductImage{}, err
+	}
 
-	// commit transaction
-	err = tx.Commit()
+	return pImage, nil
+}
+
+// getProductImageFileInfo get size, content type, and dimensions
+// of product image file at path, dimensions are zero if the image
+// format isn't decodable
+func getProductImageFileInfo(path string) (ProductImage, error) {
+	pImage := ProductImage{}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return pImage, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return pImage, err
+	}
+	pImage.Size = info.Size()
+
+	// content type is detected from at most the first 512 bytes
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return pImage, err
+	}
+	pImage.ContentType = http.DetectContentType(head[:n])
+
+	_, err = f.Seek(0, io.SeekStart)
+	if err != nil {
+		return pImage, err
+	}
+	imgConfig, _, err := image.DecodeConfig(f)
+	if err == nil {
+		pImage.Width = imgConfig.Width
+		pImage.Height = imgConfig.Height
+	}
+
+	return pImage, nil
+}
+
+// isWatermarkedSeller check images of seller of user ID are watermarked
+func isWatermarkedSeller(userID int) bool {
+	if ProductImageWatermark == nil {
+		return false
+	}
+	if len(config.WatermarkSellers) == 0 {
+		return true
+	}
+
+	for _, sellerID := range config.WatermarkSellers {
+		if sellerID == userID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// watermarkProductImageFile stamp product image watermark onto product
+// image file at path uploaded as file name, moving its clean original
+// to the original path of image path
+func watermarkProductImageFile(path string, imagePath string,
+	filename string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	img, format, err := imaging.Decode(f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("%w, '%s' => %s", ErrProductImageInvalid,
+			filename, err.Error())
+	}
+
+	// keep the clean original out of the served product image folder
+	originalPath := filepath.Join(config.MediaRoot,
+		GetOriginalImagePath(imagePath))
+	err = os.MkdirAll(filepath.Dir(originalPath), os.ModePerm)
+	if err != nil {
+		return err
+	}
+	err = os.Rename(path, originalPath)
 	if err != nil {
 		return err
 	}
 
+	f, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		os.Remove(originalPath)
+		return err
+	}
+	err = imaging.Encode(f, imaging.Watermark(img, ProductImageWatermark),
+		format)
+	if err != nil {
+		f.Close()
+		os.Remove(originalPath)
+		return err
+	}
+	err = f.Close()
+	if err != nil {
+		os.Remove(originalPath)
+		return err
+	}
+
 	return nil
 }
 
-// SaveProductImage save product image file into media folder
-func SaveProductImage(fileHeader *multipart.FileHeader) (string, error) {
-	// open the file
-	file, err := fileHeader.Open()
+// scanProductImageFile scan product image file at path uploaded as file
+// name by product image scanner if any, moving it into quarantine folder
+// if it's suspicious
+func scanProductImageFile(path string, filename string) error {
+	if ProductImageScanner == nil {
+		return nil
+	}
+
+	f, err := os.Open(path)
 	if err != nil {
-		return "", err
+		return err
+	}
+	threat, err := ProductImageScanner.Scan(context.Background(), f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("There's an error when scanning product image "+
+			"'%s' => %s", filename, err.Error())
+	}
+	if threat == "" {
+		return nil
 	}
-	defer file.Close()
 
-	// create the product image folder first if not exist
-	err = os.MkdirAll(fmt.Sprintf("./../%s/product-image/", config.MediaFolder),
-		os.ModePerm)
+	// keep the suspicious file for inspection, readable only by owner
+	dir := filepath.Join(config.MediaRoot, "quarantine")
+	err = os.MkdirAll(dir, 0700)
 	if err != nil {
-		return "", err
+		return err
+	}
+	quarantinePath := filepath.Join(dir,
+		fmt.Sprintf("%d-%s", time.Now().UnixNano(), filename))
+	err = os.Chmod(path, 0600)
+	if err != nil {
+		return err
 	}
+	err = os.Rename(path, quarantinePath)
+	if err != nil {
+		return err
+	}
+	log.Printf("Product image '%s' quarantined into %s => %s",
+		filename, quarantinePath, threat)
 
-	// create a new image file in the product image directory
-	image_path := fmt.Sprintf("product-image/%d-%s",
-		time.Now().UnixNano(),
-		fileHeader.Filename)
-	dst, err := os.Create(fmt.Sprintf("./../%s/%s", config.MediaFolder, image_path))
+	return fmt.Errorf("%w, '%s' => %s", ErrProductImageMalicious,
+		filename, threat)
+}
+
+// processProductImage check resolution of product image content of file
+// name is at least the minimum, then center crop or pad it into required
+// aspect ratio, if configured, returning the processed content
+//
+// the content is returned as is if it needn't be processed
+func processProductImage(filename string, r io.Reader) (io.Reader, error) {
+	ratio := config.ProductImageAspectRatio
+	minSize := config.ProductImageMinSize
+	if ratio == (config.ImageSize{}) && minSize == (config.ImageSize{}) {
+		return r, nil
+	}
+
+	b, err := io.ReadAll(r)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	img, format, err := imaging.Decode(bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("%w, '%s' => %s", ErrProductImageInvalid,
+			filename, err.Error())
+	}
+
+	// check the original resolution
+	size := img.Bounds().Size()
+	if size.X < minSize.Width || size.Y < minSize.Height {
+		return nil, fmt.Errorf("%w, '%s' resolution %dx%d below "+
+			"minimum %dx%d", ErrProductImageInvalid, filename, size.X,
+			size.Y, minSize.Width, minSize.Height)
 	}
-	defer dst.Close()
 
-	// copy the uploaded image file to the image file
-	// at the specified destination
-	_, err = io.Copy(dst, file)
+	if ratio == (config.ImageSize{}) ||
+		imaging.HasAspectRatio(img, ratio.Width, ratio.Height) {
+		return bytes.NewReader(b), nil
+	}
+
+	// fit into the aspect ratio
+	if config.ProductImageFit == "pad" {
+		img = imaging.PadToAspectRatio(img, ratio.Width, ratio.Height,
+			color.White)
+	} else {
+		img = imaging.CropToAspectRatio(img, ratio.Width, ratio.Height)
+	}
+
+	processed := bytes.Buffer{}
+	err = imaging.Encode(&processed, img, format)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return image_path, nil
+	return &processed, nil
 }
 
-// GetProducts get products from database by key filter and/or search
-func GetProducts(DB *sql.DB, filter ProductInfo, search string) ([]Product, error) {
-	sop := []Product{}
+// GetResizedImagePath get path of product image of image path resized
+// to fit within width x height, relative to media folder
+func GetResizedImagePath(imagePath string, width, height int) string {
+	return fmt.Sprintf("resized/%dx%d/%s", width, height, imagePath)
+}
+
+// GetOriginalImagePath get path of the clean original of watermarked
+// product image of image path, relative to media folder
+func GetOriginalImagePath(imagePath string) string {
+	return "original/" + imagePath
+}
+
+// RemoveProductImageFile remove product image file of image path,
+// its clean original, and its resized images from media folder,
+// already removed file is not an error
+func RemoveProductImageFile(imagePath string) error {
+	paths := []string{
+		filepath.Join(config.MediaRoot, imagePath),
+		filepath.Join(config.MediaRoot, GetOriginalImagePath(imagePath)),
+	}
+	sizes, err := os.ReadDir(filepath.Join(config.MediaRoot, "resized"))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, size := range sizes {
+		paths = append(paths, filepath.Join(config.MediaRoot, "resized",
+			size.Name(), imagePath))
+	}
 
+	for _, path := range paths {
+		err = os.Remove(path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// product sort orders of GetProducts
+const (
+	ProductSortDefault = ""
+	ProductSortNewest  = "newest"
+	ProductSortRating  = "rating"
+	ProductSortPopular = "popular"
+)
+
+// FuzzySearchThreshold minimum trigram word similarity between search and
+// product name of products matched by fuzzy search, from 0 to 1
+const FuzzySearchThreshold = 0.4
+
+// ProductQuery contain filters, sort order, and page of GetProducts,
+// soft deleted products only returned if Deleted is true, or returned
+// with the others if IncludeDeleted is true, hidden products not returned
+// if ExcludeHidden is true, only hidden products returned if OnlyHidden
+// is true, only products with the barcode returned if Barcode is not empty,
+// only products with SKU in SKUs returned if SKUs is not nil,
+// only products on sale now returned if OnSale is true, and only products
+// with stock returned if InStock is true
+//
+// only products with name or description containing Search returned if
+// it's not empty, or containing the search with its words replaced by
+// their synonyms in config.SearchSynonyms, or with name similar to them
+// at least FuzzySearchThreshold if Fuzzy is true so typos still match
+//
+// only products with price within MinPrice and MaxPrice, and created
+// from CreatedFrom until before CreatedTo, and with stock at least
+// MinStock returned, zero or nil means no limit
+//
+// products sorted in order of SKUs if RankedSKUs is true and Sort is
+// default, so products searched by search engine keep its ranking
+//
+// only products after cursor After returned if it's not nil,
+// and at most Limit products returned if Limit is not 0,
+// skipping the first Offset products
+type ProductQuery struct {
+	UserID         int
+	Search         string
+	Fuzzy          bool
+	Barcode        string
+	SKUs           []string
+	RankedSKUs     bool
+	OnSale         bool
+	InStock        bool
+	MinStock       float64
+	Sort           string
+	Deleted        bool
+	IncludeDeleted bool
+	ExcludeHidden  bool
+	OnlyHidden     bool
+	MinPrice       Money
+	MaxPrice       Money
+	CreatedFrom    *time.Time
+	CreatedTo      *time.Time
+	After          *ProductCursor
+	Limit          int
+	Offset         int
+}
+
+// ErrProductSortInvalid returned by GetProducts if sort order unknown
+var ErrProductSortInvalid = errors.New(
+	"sort order invalid, must be empty, 'newest', 'rating', or 'popular'")
+
+// GetProducts get products from database by key filter and/or search
+func GetProducts(ctx context.Context, DB *sql.DB,
+	query ProductQuery) ([]Product, error) {
 	// get query string
-	q := `
-		SELECT 
-			id, sku, name, price, weight, description, stock, account_user_id 
-		FROM product_productinfo
-	`
+	q := `SELECT ` + productInfoColumns + ` FROM product_productinfo`
 
-	if filter.UserID != 0 {
-		q += ` WHERE account_user_id = ` + strconv.Itoa(filter.UserID)
-		if search != "" {
-			q += ` AND (name ILIKE '%` + search + `%'
-					OR description ILIKE '%` + search + `%')`
+	conds := []string{`deleted_at IS NULL`}
+	if query.Deleted {
+		conds[0] = `deleted_at IS NOT NULL`
+	} else if query.IncludeDeleted {
+		conds[0] = `TRUE`
+	}
+	if query.ExcludeHidden {
+		conds = append(conds, `hidden = FALSE`)
+	}
+	if query.OnlyHidden {
+		conds = append(conds, `hidden = TRUE`)
+	}
+	args := []interface{}{}
+	if query.UserID != 0 {
+		args = append(args, query.UserID)
+		conds = append(conds, fmt.Sprintf(`account_user_id = $%d`, len(args)))
+	}
+	if query.MinPrice > 0 {
+		args = append(args, query.MinPrice)
+		conds = append(conds, fmt.Sprintf(`price >= $%d`, len(args)))
+	}
+	if query.MaxPrice > 0 {
+		args = append(args, query.MaxPrice)
+		conds = append(conds, fmt.Sprintf(`price <= $%d`, len(args)))
+	}
+	if query.InStock {
+		conds = append(conds, `stock > 0`)
+	}
+	if query.MinStock > 0 {
+		args = append(args, query.MinStock)
+		conds = append(conds, fmt.Sprintf(`stock >= $%d`, len(args)))
+	}
+	if query.CreatedFrom != nil {
+		args = append(args, *query.CreatedFrom)
+		conds = append(conds, fmt.Sprintf(`created_at >= $%d`, len(args)))
+	}
+	if query.CreatedTo != nil {
+		args = append(args, *query.CreatedTo)
+		conds = append(conds, fmt.Sprintf(`created_at < $%d`, len(args)))
+	}
+	if query.Search != "" {
+		searchConds := []string{}
+		for _, term := range ExpandSearchSynonyms(query.Search,
+			config.SearchSynonyms) {
+			args = append(args, "%"+term+"%")
+			searchConds = append(searchConds, fmt.Sprintf(
+				`name ILIKE $%d OR description ILIKE $%d`, len(args), len(args)))
+			if query.Fuzzy {
+				args = append(args, term)
+				searchConds = append(searchConds, fmt.Sprintf(
+					`word_similarity($%d, name) >= %g`, len(args),
+					FuzzySearchThreshold))
+			}
 		}
-	} else {
-		if search != "" {
-			q += ` WHERE (name ILIKE '%` + search + `%'
-				OR description ILIKE '%` + search + `%')`
+		conds = append(conds, `(`+strings.Join(searchConds, ` OR `)+`)`)
+	}
+	SKUsArg := 0
+	if query.SKUs != nil {
+		args = append(args, pq.Array(query.SKUs))
+		SKUsArg = len(args)
+		conds = append(conds, fmt.Sprintf(`sku = ANY($%d::TEXT[])`, SKUsArg))
+	}
+	if query.Barcode != "" {
+		args = append(args, query.Barcode)
+		conds = append(conds, fmt.Sprintf(`barcode = $%d`, len(args)))
+	}
+	if query.OnSale {
+		conds = append(conds, `sale_price > 0
+			AND (sale_starts_at IS NULL OR sale_starts_at <= NOW())
+			AND (sale_ends_at IS NULL OR sale_ends_at > NOW())`)
+	}
+
+	// get sort order, and products after cursor in the sort order
+	orderBy := ""
+	switch query.Sort {
+	case ProductSortDefault:
+		if query.RankedSKUs && SKUsArg != 0 {
+			// rank of product is position of its SKU,
+			// products after cursor ranked after the cursor product
+			rank := fmt.Sprintf(`array_position($%d::TEXT[], sku::TEXT)`,
+				SKUsArg)
+			orderBy = ` ORDER BY ` + rank
+			if query.After != nil {
+				args = append(args, query.After.ID)
+				conds = append(conds, fmt.Sprintf(`%s > (
+					SELECT %s FROM product_productinfo WHERE id = $%d)`,
+					rank, rank, len(args)))
+			}
+		} else {
+			orderBy = ` ORDER BY id`
+			if query.After != nil {
+				args = append(args, query.After.ID)
+				conds = append(conds, fmt.Sprintf(`id > $%d`, len(args)))
+			}
+		}
+	case ProductSortNewest:
+		orderBy = ` ORDER BY created_at DESC, id DESC`
+		if query.After != nil {
+			args = append(args, query.After.CreatedAt, query.After.ID)
+			conds = append(conds, fmt.Sprintf(`(created_at, id) < ($%d, $%d)`,
+				len(args)-1, len(args)))
 		}
+	case ProductSortRating:
+		orderBy = ` ORDER BY avg_rating DESC, id DESC`
+		if query.After != nil {
+			args = append(args, query.After.Rating, query.After.ID)
+			conds = append(conds, fmt.Sprintf(`(avg_rating, id) < ($%d, $%d)`,
+				len(args)-1, len(args)))
+		}
+	case ProductSortPopular:
+		orderBy = ` ORDER BY order_count DESC, wishlist_count DESC, id DESC`
+		if query.After != nil {
+			args = append(args, query.After.OrderCount,
+				query.After.WishlistCount, query.After.ID)
+			conds = append(conds, fmt.Sprintf(
+				`(order_count, wishlist_count, id) < ($%d, $%d, $%d)`,
+				len(args)-2, len(args)-1, len(args)))
+		}
+	default:
+		return []Product{}, ErrProductSortInvalid
+	}
+	if query.After != nil && query.After.Sort != query.Sort {
+		return []Product{}, ErrProductCursorInvalid
+	}
+
+	q += ` WHERE ` + strings.Join(conds, ` AND `) + orderBy
+	if query.Limit > 0 {
+		args = append(args, query.Limit)
+		q += fmt.Sprintf(` LIMIT $%d`, len(args))
+	}
+	if query.Offset > 0 {
+		args = append(args, query.Offset)
+		q += fmt.Sprintf(` OFFSET $%d`, len(args))
 	}
 
-	q += ` ORDER BY id`
+	return queryProducts(ctx, DB, q, args...)
+}
+
+// queryProducts get products with their images from database
+// by query selecting product info columns
+//
+// images of all products are fetched in one query, so listing
+// products always issue two queries
+func queryProducts(ctx context.Context, DB *sql.DB, q string,
+	args ...interface{}) ([]Product, error) {
+	sop := []Product{}
 
 	// get product info rows
-	rows, err := DB.Query(q)
+	rows, err := DB.QueryContext(ctx, q, args...)
 	if err != nil {
 		return []Product{}, err
 	}
 	defer rows.Close()
 
 	// loop product info rows
+	productIDs := []int64{}
+	indexByID := map[int]int{}
 	for rows.Next() {
 		p := Product{}
 
 		// scan product info row
-		err = rows.Scan(
-			&p.ProductInfo.ID, &p.ProductInfo.SKU,
-			&p.ProductInfo.Name, &p.ProductInfo.Price,
-			&p.ProductInfo.Weight, &p.ProductInfo.Description,
-			&p.ProductInfo.Stock, &p.ProductInfo.UserID)
+		err = scanProductInfo(rows, &p.ProductInfo)
 		if err != nil {
 			return []Product{}, err
 		}
 
-		// get product image rows
-		imageRows, err := DB.Query(`
-			SELECT 
-				id, image_path
-			FROM product_productimage
-			WHERE product_productinfo_id = $1`,
-			p.ProductInfo.ID)
+		productIDs = append(productIDs, int64(p.ProductInfo.ID))
+		indexByID[p.ProductInfo.ID] = len(sop)
+		sop = append(sop, p)
+	}
+	if rows.Err() != nil {
+		return []Product{}, rows.Err()
+	}
+	rows.Close()
+
+	if len(sop) == 0 {
+		return sop, nil
+	}
+
+	// get product images of all products
+	imageRows, err := DB.QueryContext(ctx, `
+		SELECT 
+			id, image_path, width, height, size, content_type, created_at,
+			product_productinfo_id
+		FROM product_productimage
+		WHERE product_productinfo_id = ANY($1)
+		ORDER BY id`,
+		pq.Array(productIDs))
+	if err != nil {
+		return []Product{}, err
+	}
+	defer imageRows.Close()
+
+	// put product images into their product
+	for imageRows.Next() {
+		pImage := ProductImage{}
+		var productID int
+		err = imageRows.Scan(&pImage.ID, &pImage.ImagePath, &pImage.Width,
+			&pImage.Height, &pImage.Size, &pImage.ContentType,
+			&pImage.CreatedAt, &productID)
 		if err != nil {
 			return []Product{}, err
 		}
 
-		// loop product image rows
-		for imageRows.Next() {
-			// scan product image row
-			pImage := ProductImage{}
-			err = imageRows.Scan(&pImage.ID, &pImage.ImagePath)
-			if err != nil {
-				return []Product{}, err
-			}
-
-			p.ProductImages = append(p.ProductImages, pImage)
-		}
-		imageRows.Close()
+		i := indexByID[productID]
+		sop[i].ProductImages = append(sop[i].ProductImages, pImage)
+	}
+	if imageRows.Err() != nil {
+		return []Product{}, imageRows.Err()
+	}
 
-		// put product info and product images into product
-		sop = append(sop, p)
+	// get price tiers of all products
+	tiers, err := getPriceTiers(ctx, DB, productIDs)
+	if err != nil {
+		return []Product{}, err
+	}
+	for productID, productTiers := range tiers {
+		sop[indexByID[productID]].PriceTiers = productTiers
 	}
 
 	return sop, nil
 }
 
 // GetProductBySKU get one product from database by key SKU
-func GetProductBySKU(DB *sql.DB, SKU string) (Product, error) {
+func GetProductBySKU(ctx context.Context, DB *sql.DB,
+	SKU string) (Product, error) {
 	p := Product{}
 
 	// get product info
-	row := DB.QueryRow(`
-		SELECT
-			id, sku, name, price, weight, description, stock, account_user_id
+	row := DB.QueryRowContext(ctx, `SELECT `+productInfoColumns+`
 		FROM product_productinfo
-		WHERE sku = $1
+		WHERE sku = $1 AND deleted_at IS NULL
 	`, SKU)
 	if row.Err() != nil {
 		return p, row.Err()
 	}
 
-	err := row.Scan(
-		&p.ProductInfo.ID, &p.ProductInfo.SKU, &p.ProductInfo.Name,
-		&p.ProductInfo.Price, &p.ProductInfo.Weight,
-		&p.ProductInfo.Description, &p.ProductInfo.Stock, &p.ProductInfo.UserID)
+	err := scanProductInfo(row, &p.ProductInfo)
 	if err != nil {
 		return p, err
 	}
 
 	// get product images
-	imageRows, err := DB.Query(`
+	p.ProductImages, err = getProductImages(ctx, DB, p.ProductInfo.ID)
+	if err != nil {
+		return Product{}, err
+	}
+
+	// get price tiers
+	tiers, err := getPriceTiers(ctx, DB, []int64{int64(p.ProductInfo.ID)})
+	if err != nil {
+		return Product{}, err
+	}
+	p.PriceTiers = tiers[p.ProductInfo.ID]
+
+	return p, nil
+}
+
+// getProductImages get images of a product from database
+func getProductImages(ctx context.Context, DB *sql.DB,
+	productID int) ([]ProductImage, error) {
+	var images []ProductImage
+
+	rows, err := DB.QueryContext(ctx, `
 		SELECT 
-			id, image_path
+			id, image_path, width, height, size, content_type, created_at
 		FROM product_productimage
-		WHERE product_productinfo_id = $1`,
-		p.ProductInfo.ID)
+		WHERE product_productinfo_id = $1
+		ORDER BY id`,
+		productID)
 	if err != nil {
-		return Product{}, err
+		return nil, err
 	}
+	defer rows.Close()
 
-	for imageRows.Next() {
+	for rows.Next() {
 		pImage := ProductImage{}
-		err = imageRows.Scan(&pImage.ID, &pImage.ImagePath)
+		err = rows.Scan(&pImage.ID, &pImage.ImagePath, &pImage.Width,
+			&pImage.Height, &pImage.Size, &pImage.ContentType,
+			&pImage.CreatedAt)
 		if err != nil {
-			return Product{}, err
+			return nil, err
 		}
 
-		p.ProductImages = append(p.ProductImages, pImage)
+		images = append(images, pImage)
 	}
-	imageRows.Close()
 
-	return p, nil
+	return images, rows.Err()
+}
+
+// ProductImageAccess contain owner of product of an image and whether
+// the product is published, i.e. visible and not deleted
+type ProductImageAccess struct {
+	UserID    int
+	Published bool
+}
+
+// GetProductImageAccess get owner and publication of product of image
+// path, shop banner is always published, sql.ErrNoRows if the image path
+// isn't an image of any product nor a shop banner
+func GetProductImageAccess(ctx context.Context, DB *sql.DB,
+	imagePath string) (ProductImageAccess, error) {
+	access := ProductImageAccess{}
+	err := DB.QueryRowContext(ctx, `
+		SELECT p.account_user_id, NOT p.hidden AND p.deleted_at IS NULL
+		FROM product_productimage i
+		JOIN product_productinfo p ON p.id = i.product_productinfo_id
+		WHERE i.image_path = $1
+		UNION ALL
+		SELECT account_user_id, TRUE
+		FROM product_shop
+		WHERE banner_image_path = $1
+		LIMIT 1`,
+		imagePath).Scan(&access.UserID, &access.Published)
+
+	return access, err
+}
+
+// GetProductImagePaths get image paths of all product images and shop
+// banners in database, including images of soft deleted products
+func GetProductImagePaths(ctx context.Context, DB *sql.DB) (
+	map[string]bool, error) {
+	paths := map[string]bool{}
+
+	rows, err := DB.QueryContext(ctx, `
+		SELECT image_path FROM product_productimage
+		UNION
+		SELECT banner_image_path FROM product_shop
+		WHERE banner_image_path <> ''`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var imagePath string
+		err = rows.Scan(&imagePath)
+		if err != nil {
+			return nil, err
+		}
+
+		paths[imagePath] = true
+	}
+
+	return paths, rows.Err()
 }
 
 // UpdateProductInfoBySKU update product info in database by key SKU
-func UpdateProductInfoBySKU(DB *sql.DB, pInfo ProductInfo) (ProductInfo, error) {
-	// begin transaction
-	tx, err := DB.Begin()
+func UpdateProductInfoBySKU(ctx context.Context, DB *sql.DB,
+	pInfo ProductInfo) (ProductInfo, error) {
+	return UpdateProductWithImages(ctx, DB, pInfo, nil, ImageModeReplace,
+		nil)
+}
+
+// image modes of UpdateProductWithImages
+const (
+	ImageModeReplace = "replace"
+	ImageModeAppend  = "append"
+)
+
+// ErrTooManyProductImages error of product images appended exceeding
+// config.MaxProductImages
+var ErrTooManyProductImages = errors.New("too many product images")
+
+// ErrProductImageNotFound error of kept image ID not an image
+// of the product
+var ErrProductImageNotFound = errors.New("product image not found")
+
+// UpdateProductWithImages update product info in database by key SKU
+// and, if there's any image, replace its images with images of image
+// files already saved into media folder, or append them after its images
+// if image mode is ImageModeAppend, in one transaction
+//
+// if keep image IDs is not nil, images of the product not in it are
+// removed before the images are appended
+//
+// return ErrTooManyProductImages if appended images exceed
+// config.MaxProductImages, and ErrProductImageNotFound if a kept image ID
+// isn't an image of the product
+func UpdateProductWithImages(ctx context.Context, DB *sql.DB,
+	pInfo ProductInfo, images []ProductImage, imageMode string,
+	keepImageIDs []int) (ProductInfo, error) {
+	err := WithTransaction(ctx, DB, func(tx *sql.Tx) error {
+		var err error
+		pInfo, err = updateProductInfo(ctx, tx, pInfo)
+		if err != nil {
+			return err
+		}
+
+		if keepImageIDs != nil {
+			err = keepProductImages(ctx, tx, pInfo.ID, keepImageIDs)
+			if err != nil {
+				return err
+			}
+		}
+		if len(images) == 0 {
+			return nil
+		}
+
+		if imageMode == ImageModeAppend {
+			return appendProductImages(ctx, tx, pInfo.ID, images)
+		}
+		return replaceProductImages(ctx, tx, pInfo.ID, images)
+	})
+
+	return pInfo, err
+}
+
+// keepProductImages remove images of product other than images of kept
+// image IDs in transaction
+//
+// return ErrProductImageNotFound if a kept image ID isn't an image
+// of the product
+func keepProductImages(ctx context.Context, tx *sql.Tx, productID int,
+	keepImageIDs []int) error {
+	imageIDs := map[int]bool{}
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id
+		FROM product_productimage
+		WHERE product_productinfo_id = $1`,
+		productID)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var imageID int
+		err = rows.Scan(&imageID)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		imageIDs[imageID] = true
+	}
+	rows.Close()
+	if rows.Err() != nil {
+		return rows.Err()
+	}
+
+	for _, imageID := range keepImageIDs {
+		if !imageIDs[imageID] {
+			return fmt.Errorf("%w, ID %d", ErrProductImageNotFound, imageID)
+		}
+	}
+
+	// image files of removed images are removed later by cleanup-media
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM product_productimage
+		WHERE product_productinfo_id = $1 AND NOT (id = ANY($2))`,
+		productID, pq.Array(keepImageIDs))
+	return err
+}
+
+// appendProductImages append images of image files already saved
+// into media folder after images of product in transaction, the product
+// row must be locked so concurrent appends can't exceed the maximum
+func appendProductImages(ctx context.Context, tx *sql.Tx, productID int,
+	images []ProductImage) error {
+	var count int
+	err := tx.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM product_productimage
+		WHERE product_productinfo_id = $1`,
+		productID).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count+len(images) > config.MaxProductImages {
+		return fmt.Errorf("%w, maximum %d images but product has %d "+
+			"and got %d more", ErrTooManyProductImages,
+			config.MaxProductImages, count, len(images))
+	}
+
+	for _, pImage := range images {
+		err = insertProductImage(ctx, tx, productID, pImage)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// updateProductInfo update product info by key SKU in transaction,
+// recording its new version and stock change
+func updateProductInfo(ctx context.Context, tx *sql.Tx,
+	pInfo ProductInfo) (ProductInfo, error) {
+	if pInfo.Unit == "" {
+		pInfo.Unit = UnitPiece
+	}
+	pInfo.cleanDescription()
+
+	// get current stock, locking the row until transaction end
+	var oldStock float64
+	err := tx.QueryRowContext(ctx, `
+		SELECT stock
+		FROM product_productinfo
+		WHERE sku = $1 AND deleted_at IS NULL
+		FOR UPDATE`,
+		pInfo.SKU).Scan(&oldStock)
 	if err != nil {
 		return pInfo, err
 	}
-	defer tx.Rollback() // rollback transaction if fail
 
-	// execute query update, returning product info ID
-	row := tx.QueryRow(`
+	// execute query update, returning product info ID, timestamps,
+	// and new version
+	row := tx.QueryRowContext(ctx, `
 		UPDATE product_productinfo 
 		SET name = $1, price = $2, weight = $3, description = $4, 
-			stock = $5, account_user_id = $6 WHERE sku = $7
-		RETURNING id`,
+			stock = $5, account_user_id = $6, barcode = NULLIF($7, ''),
+			length = $8, width = $9, height = $10, unit = $11,
+			min_order_qty = $12, max_order_qty = $13, sale_price = $14,
+			sale_starts_at = $15, sale_ends_at = $16,
+			description_format = $17,
+			updated_at = NOW(), version = version + 1
+		WHERE sku = $18 AND deleted_at IS NULL
+		RETURNING id, created_at, updated_at, version`,
 		pInfo.Name, pInfo.Price, pInfo.Weight, pInfo.Description,
-		pInfo.Stock, pInfo.UserID, pInfo.SKU)
+		pInfo.Stock, pInfo.UserID, pInfo.Barcode, pInfo.Length,
+		pInfo.Width, pInfo.Height, pInfo.Unit, pInfo.MinOrderQty,
+		pInfo.MaxOrderQty, pInfo.SalePrice, pInfo.SaleStartsAt,
+		pInfo.SaleEndsAt, pInfo.DescriptionFormat, pInfo.SKU)
 	if row.Err() != nil {
 		return pInfo, row.Err()
 	}
 
-	err = row.Scan(&pInfo.ID)
+	err = row.Scan(&pInfo.ID, &pInfo.CreatedAt, &pInfo.UpdatedAt,
+		&pInfo.Version)
 	if err != nil {
 		return pInfo, err
 	}
+	pInfo.setComputedFields()
 
-	// commit transaction
-	err = tx.Commit()
+	// record new version of product info
+	err = insertProductVersion(ctx, tx, pInfo)
+	if err != nil {
+		return pInfo, err
+	}
+
+	// record stock change into stock movement ledger
+	err = insertStockMovement(ctx, tx, pInfo.ID, StockMovement{
+		Delta:  pInfo.Stock - oldStock,
+		Reason: StockReasonUpdated,
+		UserID: pInfo.UserID,
+	})
 	if err != nil {
 		return pInfo, err
 	}
@@ -344,17 +1430,22 @@ func UpdateProductInfoBySKU(DB *sql.DB, pInfo ProductInfo) (ProductInfo, error)
 	return pInfo, nil
 }
 
-// DeleteProductBySKU delete product in database with key SKU
-func DeleteProductBySKU(DB *sql.DB, SKU string) error {
+// DeleteProductBySKU soft delete product in database with key SKU,
+// keeping the row so orders and stock ledger still reference it
+func DeleteProductBySKU(ctx context.Context, DB *sql.DB, SKU string) error {
 	// begin transaction
-	tx, err := DB.Begin()
+	tx, err := DB.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback() // rollback transaction if fail
 
-	// delete product
-	_, err = tx.Exec(`DELETE FROM product_productinfo WHERE sku = $1`, SKU)
+	// mark product as deleted
+	_, err = tx.ExecContext(ctx, `
+		UPDATE product_productinfo
+		SET deleted_at = NOW()
+		WHERE sku = $1 AND deleted_at IS NULL`,
+		SKU)
 	if err != nil {
 		return err
 	}
@@ -367,3 +1458,212 @@ func DeleteProductBySKU(DB *sql.DB, SKU string) error {
 
 	return nil
 }
+
+// DeleteProductsByUserID permanently delete all products of user ID
+// including soft deleted ones, with their images, versions, stock history,
+// and other data of the products, then anonymize stock movements and
+// product reports the user made on other products and delete the user's
+// shop, product views, webhook subscriptions, and idempotency keys,
+// all in one transaction
+//
+// return SKUs of the deleted products and their image paths with the shop
+// banner path, so the image files can be removed from media folder
+func DeleteProductsByUserID(ctx context.Context, DB *sql.DB, userID int) (
+	[]string, []string, error) {
+	SKUs := []string{}
+	imagePaths := []string{}
+
+	// begin transaction
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return SKUs, imagePaths, err
+	}
+	defer tx.Rollback() // rollback transaction if fail
+
+	// get image paths of the products before they're deleted
+	rows, err := tx.QueryContext(ctx, `
+		SELECT i.image_path
+		FROM product_productimage i
+		JOIN product_productinfo p ON p.id = i.product_productinfo_id
+		WHERE p.account_user_id = $1`,
+		userID)
+	if err != nil {
+		return []string{}, []string{}, err
+	}
+	for rows.Next() {
+		var imagePath string
+		err = rows.Scan(&imagePath)
+		if err != nil {
+			rows.Close()
+			return []string{}, []string{}, err
+		}
+		imagePaths = append(imagePaths, imagePath)
+	}
+	rows.Close()
+
+	// delete products, their other data deleted by cascade
+	rows, err = tx.QueryContext(ctx, `
+		DELETE FROM product_productinfo
+		WHERE account_user_id = $1
+		RETURNING sku`,
+		userID)
+	if err != nil {
+		return []string{}, []string{}, err
+	}
+	for rows.Next() {
+		var SKU string
+		err = rows.Scan(&SKU)
+		if err != nil {
+			rows.Close()
+			return []string{}, []string{}, err
+		}
+		SKUs = append(SKUs, SKU)
+	}
+	rows.Close()
+
+	// delete shop of the user, keeping its banner path
+	var bannerPath string
+	err = tx.QueryRowContext(ctx, `
+		DELETE FROM product_shop
+		WHERE account_user_id = $1
+		RETURNING banner_image_path`,
+		userID).Scan(&bannerPath)
+	if err != nil && err != sql.ErrNoRows {
+		return []string{}, []string{}, err
+	}
+	if bannerPath != "" {
+		imagePaths = append(imagePaths, bannerPath)
+	}
+
+	// anonymize and delete the other data of the user
+	for _, q := range []string{
+		`UPDATE product_stockmovement SET account_user_id = 0
+			WHERE account_user_id = $1`,
+		`UPDATE product_productreport SET reporter_user_id = NULL
+			WHERE reporter_user_id = $1`,
+		`UPDATE product_productreport SET reviewer_user_id = NULL
+			WHERE reviewer_user_id = $1`,
+		`DELETE FROM product_productview
+			WHERE viewer_key = 'user:' || $1::INT`,
+		`DELETE FROM product_webhooksubscription WHERE account_user_id = $1`,
+		`DELETE FROM product_idempotencykey WHERE account_user_id = $1`,
+	} {
+		_, err = tx.ExecContext(ctx, q, userID)
+		if err != nil {
+			return []string{}, []string{}, err
+		}
+	}
+
+	// commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return []string{}, []string{}, err
+	}
+
+	return SKUs, imagePaths, nil
+}
+
+// AddProductImagesBySKU append images of image files already saved
+// into media folder after images of product of user ID with key SKU
+// in database, returning the product with all its images
+//
+// return sql.ErrNoRows if no product of the user found,
+// and ErrTooManyProductImages if the images exceed config.MaxProductImages
+func AddProductImagesBySKU(ctx context.Context, DB *sql.DB, SKU string,
+	userID int, images []ProductImage) (Product, error) {
+	p := Product{}
+
+	err := WithTransaction(ctx, DB, func(tx *sql.Tx) error {
+		// lock the product row so concurrent uploads can't exceed
+		// the maximum images
+		row := tx.QueryRowContext(ctx, `
+			UPDATE product_productinfo
+			SET updated_at = NOW()
+			WHERE sku = $1 AND account_user_id = $2 AND deleted_at IS NULL
+			RETURNING `+productInfoColumns,
+			SKU, userID)
+		if row.Err() != nil {
+			return row.Err()
+		}
+		err := scanProductInfo(row, &p.ProductInfo)
+		if err != nil {
+			return err
+		}
+
+		return appendProductImages(ctx, tx, p.ProductInfo.ID, images)
+	})
+	if err != nil {
+		return Product{}, err
+	}
+
+	p.ProductImages, err = getProductImages(ctx, DB, p.ProductInfo.ID)
+	if err != nil {
+		return Product{}, err
+	}
+
+	return p, nil
+}
+
+// SetProductVisibilityBySKU hide or show product of user ID in database
+// with key SKU, without creating a new product version
+//
+// return sql.ErrNoRows if no product of the user found, and
+// ErrProductTakenDown if showing product taken down by admin
+func SetProductVisibilityBySKU(ctx context.Context, DB *sql.DB, SKU string,
+	userID int, hidden bool) (ProductInfo, error) {
+	pInfo := ProductInfo{}
+
+	if !hidden {
+		takenDown, err := isProductTakenDown(ctx, DB, SKU)
+		if err != nil {
+			return pInfo, err
+		}
+		if takenDown {
+			return pInfo, ErrProductTakenDown
+		}
+	}
+
+	row := DB.QueryRowContext(ctx, `
+		UPDATE product_productinfo
+		SET hidden = $1, updated_at = NOW()
+		WHERE sku = $2 AND account_user_id = $3 AND deleted_at IS NULL
+		RETURNING `+productInfoColumns,
+		hidden, SKU, userID)
+	if row.Err() != nil {
+		return pInfo, row.Err()
+	}
+
+	err := scanProductInfo(row, &pInfo)
+	if err != nil {
+		return pInfo, err
+	}
+
+	return pInfo, nil
+}
+
+// RestoreProductBySKU restore soft deleted product in database with key SKU,
+// only product of user ID restored if user ID not 0
+//
+// return sql.ErrNoRows if no deleted product found
+func RestoreProductBySKU(ctx context.Context, DB *sql.DB, SKU string,
+	userID int) (ProductInfo, error) {
+	pInfo := ProductInfo{}
+
+	row := DB.QueryRowContext(ctx, `
+		UPDATE product_productinfo
+		SET deleted_at = NULL, updated_at = NOW()
+		WHERE sku = $1 AND deleted_at IS NOT NULL
+			AND ($2 = 0 OR account_user_id = $2)
+		RETURNING `+productInfoColumns,
+		SKU, userID)
+	if row.Err() != nil {
+		return pInfo, row.Err()
+	}
+
+	err := scanProductInfo(row, &pInfo)
+	if err != nil {
+		return pInfo, err
+	}
+
+	return pInfo, nil
+}
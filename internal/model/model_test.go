@@ -5,13 +5,26 @@ database transaction
 package model
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
 	"database/sql"
-	"fmt"
+	"encoding/hex"
+	"errors"
+	"image"
+	"image/draw"
+	"image/png"
+	"io"
 	"log"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"github.com/reyhanfikridz/ecom-product-service/internal/config"
+	"github.com/reyhanfikridz/ecom-product-service/internal/migration"
 )
 
 // TestMain do some test before and after all testing in the package
@@ -62,7 +75,7 @@ func TestInsertProductInfo(t *testing.T) {
 	// create product info
 	pInfo := ProductInfo{
 		Name:        "ABC Product",
-		Price:       1200000.55,
+		Price:       120000055,
 		Weight:      1.5,
 		Description: "Decription 123",
 		Stock:       100,
@@ -70,7 +83,7 @@ func TestInsertProductInfo(t *testing.T) {
 	}
 
 	// insert product info into database
-	pInfo, err = InsertProductInfo(DB, pInfo)
+	pInfo, err = InsertProductInfo(context.Background(), DB, pInfo)
 
 	// check result
 	if err != nil {
@@ -93,6 +106,79 @@ func TestInsertProductInfo(t *testing.T) {
 	}
 }
 
+// TestInsertProducts test for InsertProducts
+//
+// Required for the test: GetProductBySKU
+func TestInsertProducts(t *testing.T) {
+	// get testing DB connection
+	DB, err := getTestDBConnection()
+	if err != nil {
+		t.Errorf("There's an error when initialize "+
+			"testing database connection => %s", err.Error())
+	}
+
+	// insert product infos with images into database
+	items := []ProductBatchItem{
+		{
+			ProductInfo: ProductInfo{Name: "Product A", Price: 1000,
+				Weight: 1, Stock: 5, UserID: 1},
+			ProductImages: []ProductImage{
+				{ImagePath: "product-image/a-1.png", Width: 640, Height: 480,
+					Size: 2048, ContentType: "image/png"},
+				{ImagePath: "product-image/a-2.png"},
+			},
+		},
+		{
+			ProductInfo: ProductInfo{Name: "Product B", Price: 2000,
+				Weight: 2, Stock: 0, UserID: 1},
+		},
+	}
+	pInfos, err := InsertProducts(context.Background(), DB, items)
+
+	// check result
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error not nil => %s", err.Error())
+	}
+	if len(pInfos) != len(items) {
+		t.Fatalf("Expected %d product infos, but got %d",
+			len(items), len(pInfos))
+	}
+	for i, pInfo := range pInfos {
+		p, err := GetProductBySKU(context.Background(), DB, pInfo.SKU)
+		if err != nil {
+			t.Fatalf("Expected error nil, but got error not nil => %s",
+				err.Error())
+		}
+		if p.ProductInfo.Name != items[i].ProductInfo.Name ||
+			len(p.ProductImages) != len(items[i].ProductImages) {
+			t.Errorf("Expected product %s with %d images, but got %s with %d",
+				items[i].ProductInfo.Name, len(items[i].ProductImages),
+				p.ProductInfo.Name, len(p.ProductImages))
+			continue
+		}
+
+		// check image info stored
+		for j, pImage := range p.ProductImages {
+			expected := items[i].ProductImages[j]
+			if pImage.ImagePath != expected.ImagePath ||
+				pImage.Width != expected.Width ||
+				pImage.Height != expected.Height ||
+				pImage.Size != expected.Size ||
+				pImage.ContentType != expected.ContentType {
+				t.Errorf("Expected image %+v, but got %+v", expected, pImage)
+			}
+		}
+	}
+
+	// truncate tables after test
+	_, err = DB.Exec("TRUNCATE product_productinfo RESTART IDENTITY CASCADE")
+	if err != nil {
+		log.Fatalf("There's an error when truncating "+
+			"table product_productinfo => %s",
+			err.Error())
+	}
+}
+
 // TestGetProducts test for GetProducts
 //
 // Required for the test: InsertProductInfo
@@ -109,7 +195,7 @@ func TestGetProducts(t *testing.T) {
 		{
 			ProductInfo: ProductInfo{
 				Name:        "PRODUCT A",
-				Price:       1200000.55,
+				Price:       120000055,
 				Weight:      1.5,
 				Description: "Description PRODUCT A",
 				Stock:       100,
@@ -130,7 +216,7 @@ func TestGetProducts(t *testing.T) {
 		{
 			ProductInfo: ProductInfo{
 				Name:        "PRODUCT B",
-				Price:       1200000.55,
+				Price:       120000055,
 				Weight:      1.5,
 				Description: "Description PRODUCT B",
 				Stock:       100,
@@ -145,7 +231,7 @@ func TestGetProducts(t *testing.T) {
 		{
 			ProductInfo: ProductInfo{
 				Name:        "PRODUCT B",
-				Price:       1200000.55,
+				Price:       120000055,
 				Weight:      1.5,
 				Description: "Description PRODUCT B",
 				Stock:       100,
@@ -158,7 +244,8 @@ func TestGetProducts(t *testing.T) {
 	// loop products
 	for i := range sop {
 		// insert product info into database
-		sop[i].ProductInfo, err = InsertProductInfo(DB, sop[i].ProductInfo)
+		sop[i].ProductInfo, err = InsertProductInfo(context.Background(), DB,
+			sop[i].ProductInfo)
 		if err != nil {
 			t.Errorf("There's an error when insert data product info => %s",
 				err.Error())
@@ -180,43 +267,81 @@ func TestGetProducts(t *testing.T) {
 	// create testing table
 	testTable := []struct {
 		TestName       string
-		Filter         ProductInfo
-		Search         string
+		Query          ProductQuery
 		ExpectedResult []Product
 	}{
 		{
 			TestName:       "Get All",
-			Filter:         ProductInfo{},
-			Search:         "",
+			Query:          ProductQuery{},
 			ExpectedResult: sop,
 		},
 		{
 			TestName: "Get By User ID <1>",
-			Filter: ProductInfo{
+			Query: ProductQuery{
 				UserID: 1,
 			},
-			Search:         "",
 			ExpectedResult: []Product{sop[0], sop[2]},
 		},
 		{
-			TestName:       "Get By Search <product b>",
-			Filter:         ProductInfo{},
-			Search:         "product b",
+			TestName: "Get By Search <product b>",
+			Query: ProductQuery{
+				Search: "product b",
+			},
 			ExpectedResult: []Product{sop[1], sop[2]},
 		},
 		{
 			TestName: "Get By UserID <1> and Search <product b>",
-			Filter: ProductInfo{
+			Query: ProductQuery{
 				UserID: 1,
+				Search: "product b",
 			},
-			Search:         "product b",
 			ExpectedResult: []Product{sop[2]},
 		},
+		{
+			TestName: "Get By SKUs",
+			Query: ProductQuery{
+				SKUs: []string{sop[0].ProductInfo.SKU, sop[2].ProductInfo.SKU},
+			},
+			ExpectedResult: []Product{sop[0], sop[2]},
+		},
+		{
+			TestName:       "Get By Empty SKUs",
+			Query:          ProductQuery{SKUs: []string{}},
+			ExpectedResult: []Product{},
+		},
+		{
+			TestName: "Get By Search <prodcut>",
+			Query: ProductQuery{
+				Search: "prodcut",
+			},
+			ExpectedResult: []Product{},
+		},
+		{
+			TestName: "Get By Fuzzy Search <prodcut>",
+			Query: ProductQuery{
+				Search: "prodcut",
+				Fuzzy:  true,
+			},
+			ExpectedResult: sop,
+		},
+		{
+			TestName: "Get By Search <' OR 1=1 -->",
+			Query: ProductQuery{
+				Search: "' OR 1=1 --",
+			},
+			ExpectedResult: []Product{},
+		},
 	}
 
 	// loop test in test table
 	for _, test := range testTable {
-		result, err := GetProducts(DB, test.Filter, test.Search)
+		result, err := GetProducts(context.Background(), DB, test.Query)
+
+		// check result count
+		if len(result) != len(test.ExpectedResult) {
+			t.Errorf("[%s] Expected %d products, but got %d",
+				test.TestName, len(test.ExpectedResult), len(result))
+		}
 
 		// check err result
 		if err != nil {
@@ -263,6 +388,23 @@ func TestGetProducts(t *testing.T) {
 		}
 	}
 
+	// check sort newest, last inserted product first
+	result, err := GetProducts(context.Background(), DB,
+		ProductQuery{Sort: ProductSortNewest})
+	if err != nil {
+		t.Errorf("Expected error nil, but got not nil => %s", err.Error())
+	} else if len(result) != len(sop) ||
+		result[0].ProductInfo.SKU != sop[len(sop)-1].ProductInfo.SKU {
+		t.Errorf("Expected newest product first with SKU %s",
+			sop[len(sop)-1].ProductInfo.SKU)
+	}
+
+	// check sort invalid
+	_, err = GetProducts(context.Background(), DB, ProductQuery{Sort: "oldest"})
+	if err != ErrProductSortInvalid {
+		t.Errorf("Expected error %v, but got %v", ErrProductSortInvalid, err)
+	}
+
 	// truncate tables after test
 	_, err = DB.Exec("TRUNCATE product_productinfo RESTART IDENTITY CASCADE")
 	if err != nil {
@@ -288,7 +430,7 @@ func TestGetProductBySKU(t *testing.T) {
 		{
 			ProductInfo: ProductInfo{
 				Name:        "PRODUCT A",
-				Price:       1200000.55,
+				Price:       120000055,
 				Weight:      1.5,
 				Description: "Description PRODUCT A",
 				Stock:       100,
@@ -309,7 +451,7 @@ func TestGetProductBySKU(t *testing.T) {
 		{
 			ProductInfo: ProductInfo{
 				Name:        "PRODUCT B",
-				Price:       1200000.55,
+				Price:       120000055,
 				Weight:      1.5,
 				Description: "Description PRODUCT B",
 				Stock:       100,
@@ -324,7 +466,7 @@ func TestGetProductBySKU(t *testing.T) {
 		{
 			ProductInfo: ProductInfo{
 				Name:        "PRODUCT B",
-				Price:       1200000.55,
+				Price:       120000055,
 				Weight:      1.5,
 				Description: "Description PRODUCT B",
 				Stock:       100,
@@ -337,7 +479,8 @@ func TestGetProductBySKU(t *testing.T) {
 	// loop products
 	for i := range sop {
 		// insert product info into database
-		sop[i].ProductInfo, err = InsertProductInfo(DB, sop[i].ProductInfo)
+		sop[i].ProductInfo, err = InsertProductInfo(context.Background(), DB,
+			sop[i].ProductInfo)
 		if err != nil {
 			t.Errorf("There's an error when insert data product info => %s",
 				err.Error())
@@ -356,7 +499,8 @@ func TestGetProductBySKU(t *testing.T) {
 		}
 
 		// test get product by SKU
-		pResult, err := GetProductBySKU(DB, sop[i].ProductInfo.SKU)
+		pResult, err := GetProductBySKU(context.Background(), DB,
+			sop[i].ProductInfo.SKU)
 
 		// check err result
 		if err != nil {
@@ -373,7 +517,7 @@ func TestGetProductBySKU(t *testing.T) {
 				sop[i].ProductInfo.Name, pResult.ProductInfo.Name)
 		}
 		if sop[i].ProductInfo.Price != pResult.ProductInfo.Price {
-			t.Errorf("Expected Price %f, but got Price %f",
+			t.Errorf("Expected Price %s, but got Price %s",
 				sop[i].ProductInfo.Price, pResult.ProductInfo.Price)
 		}
 		if sop[i].ProductInfo.Weight != pResult.ProductInfo.Weight {
@@ -385,7 +529,7 @@ func TestGetProductBySKU(t *testing.T) {
 				sop[i].ProductInfo.Description, pResult.ProductInfo.Description)
 		}
 		if sop[i].ProductInfo.Stock != pResult.ProductInfo.Stock {
-			t.Errorf("Expected Stock %d, but got Stock %d",
+			t.Errorf("Expected Stock %v, but got Stock %v",
 				sop[i].ProductInfo.Stock, pResult.ProductInfo.Stock)
 		}
 		if sop[i].ProductInfo.UserID != pResult.ProductInfo.UserID {
@@ -474,7 +618,7 @@ func TestUpdateProductInfoBySKU(t *testing.T) {
 			ExpectedResult: Product{
 				ProductInfo: ProductInfo{
 					Name:        "Before update",
-					Price:       333333.3333,
+					Price:       33333333,
 					Weight:      1,
 					Description: "Before update",
 					Stock:       1,
@@ -579,7 +723,7 @@ func TestUpdateProductInfoBySKU(t *testing.T) {
 			ExpectedResult: Product{
 				ProductInfo: ProductInfo{
 					Name:        "After update",
-					Price:       1111111112.231344,
+					Price:       111111111223,
 					Weight:      11.1231313131,
 					Description: "After update",
 					Stock:       1000,
@@ -600,7 +744,7 @@ func TestUpdateProductInfoBySKU(t *testing.T) {
 			ExpectedResult: Product{
 				ProductInfo: ProductInfo{
 					Name:        "Before update",
-					Price:       1111111112.231344,
+					Price:       111111111223,
 					Weight:      11.1231313131,
 					Description: "After update",
 					Stock:       1000,
@@ -676,7 +820,9 @@ func TestUpdateProductInfoBySKU(t *testing.T) {
 	// do the test
 	for _, test := range testTable {
 		// insert product info to database
-		test.ProductInfoBeforeUpdate, err = InsertProductInfo(DB, test.ProductInfoBeforeUpdate)
+		test.ProductInfoBeforeUpdate,
+			err = InsertProductInfo(context.Background(), DB,
+			test.ProductInfoBeforeUpdate)
 		if err != nil {
 			t.Errorf("[%s] There's an error "+
 				"when creating product data => %s",
@@ -685,7 +831,8 @@ func TestUpdateProductInfoBySKU(t *testing.T) {
 
 		// update product info by SKU
 		test.ExpectedResult.ProductInfo.SKU = test.ProductInfoBeforeUpdate.SKU
-		_, err = UpdateProductInfoBySKU(DB, test.ExpectedResult.ProductInfo)
+		_, err = UpdateProductInfoBySKU(context.Background(), DB,
+			test.ExpectedResult.ProductInfo)
 		if err != nil {
 			t.Errorf("[%s] There's an error "+
 				"when updating product data => %s",
@@ -693,7 +840,8 @@ func TestUpdateProductInfoBySKU(t *testing.T) {
 		}
 
 		// get product info by SKU and check the result
-		result, err := GetProductBySKU(DB, test.ProductInfoBeforeUpdate.SKU)
+		result, err := GetProductBySKU(context.Background(), DB,
+			test.ProductInfoBeforeUpdate.SKU)
 
 		if err != nil {
 			t.Errorf("[%s] Expected error nil, but got not nil => %s",
@@ -711,7 +859,7 @@ func TestUpdateProductInfoBySKU(t *testing.T) {
 				result.ProductInfo.Name)
 		}
 		if test.ExpectedResult.ProductInfo.Price != result.ProductInfo.Price {
-			t.Errorf("[%s] Expected Price '%f', but got Price '%f'",
+			t.Errorf("[%s] Expected Price '%s', but got Price '%s'",
 				test.TestName, test.ExpectedResult.ProductInfo.Price,
 				result.ProductInfo.Price)
 		}
@@ -726,7 +874,7 @@ func TestUpdateProductInfoBySKU(t *testing.T) {
 				result.ProductInfo.Description)
 		}
 		if test.ExpectedResult.ProductInfo.Stock != result.ProductInfo.Stock {
-			t.Errorf("[%s] Expected Stock '%d', but got Stock '%d'",
+			t.Errorf("[%s] Expected Stock '%v', but got Stock '%v'",
 				test.TestName, test.ExpectedResult.ProductInfo.Stock,
 				result.ProductInfo.Stock)
 		}
@@ -747,6 +895,282 @@ func TestUpdateProductInfoBySKU(t *testing.T) {
 	}
 }
 
+// TestUpdateProductWithImages test UpdateProductWithImages updating
+// product info and replacing or appending its images atomically
+//
+// Required for the test:
+//
+// - InsertProductWithImages
+//
+// - GetProductBySKU
+func TestUpdateProductWithImages(t *testing.T) {
+	// get testing DB connection
+	DB, err := getTestDBConnection()
+	if err != nil {
+		t.Fatalf("There's an error when initialize "+
+			"testing database connection => %s", err.Error())
+	}
+
+	// insert product info with an image into database
+	pInfo, err := InsertProductWithImages(context.Background(), DB,
+		ProductInfo{Name: "Product A", Price: 1000, Weight: 1, Stock: 5,
+			UserID: 1}, []ProductImage{{ImagePath: "product-image/a-1.png"}})
+	if err != nil {
+		t.Fatalf("There's an error when creating product data => %s",
+			err.Error())
+	}
+
+	// create test table
+	testTable := []struct {
+		TestName       string
+		Name           string
+		Images         []ProductImage
+		ImageMode      string
+		KeepImagePaths []string
+		ExpectedErr    bool
+		ExpectedName   string
+		ExpectedImages []string
+	}{
+		{
+			TestName:       "Keep Images",
+			Name:           "Product B",
+			ExpectedName:   "Product B",
+			ExpectedImages: []string{"product-image/a-1.png"},
+		},
+		{
+			TestName: "Replace Images",
+			Name:     "Product C",
+			Images: []ProductImage{{ImagePath: "product-image/c-1.png"},
+				{ImagePath: "product-image/c-2.png"}},
+			ExpectedName: "Product C",
+			ExpectedImages: []string{"product-image/c-1.png",
+				"product-image/c-2.png"},
+		},
+		{
+			TestName: "Image Failed Rolled Back",
+			Name:     "Product D",
+			Images: []ProductImage{{ImagePath: "product-image/d-1.png"},
+				{ImagePath: "product-image/" + strings.Repeat("d", 300)}},
+			ExpectedErr:  true,
+			ExpectedName: "Product C",
+			ExpectedImages: []string{"product-image/c-1.png",
+				"product-image/c-2.png"},
+		},
+		{
+			TestName:     "Append Images",
+			Name:         "Product E",
+			Images:       []ProductImage{{ImagePath: "product-image/e-1.png"}},
+			ImageMode:    ImageModeAppend,
+			ExpectedName: "Product E",
+			ExpectedImages: []string{"product-image/c-1.png",
+				"product-image/c-2.png", "product-image/e-1.png"},
+		},
+		{
+			TestName:     "Append Too Many Images",
+			Name:         "Product F",
+			Images:       []ProductImage{{ImagePath: "product-image/f-1.png"}},
+			ImageMode:    ImageModeAppend,
+			ExpectedErr:  true,
+			ExpectedName: "Product E",
+			ExpectedImages: []string{"product-image/c-1.png",
+				"product-image/c-2.png", "product-image/e-1.png"},
+		},
+		{
+			TestName:  "Keep Images",
+			Name:      "Product G",
+			Images:    []ProductImage{{ImagePath: "product-image/g-1.png"}},
+			ImageMode: ImageModeAppend,
+			KeepImagePaths: []string{"product-image/e-1.png",
+				"product-image/c-2.png"},
+			ExpectedName: "Product G",
+			ExpectedImages: []string{"product-image/c-2.png",
+				"product-image/e-1.png", "product-image/g-1.png"},
+		},
+		{
+			TestName:  "Keep Image Not Found",
+			Name:      "Product H",
+			ImageMode: ImageModeAppend,
+			KeepImagePaths: []string{"product-image/c-2.png",
+				"product-image/not-found.png"},
+			ExpectedErr:  true,
+			ExpectedName: "Product G",
+			ExpectedImages: []string{"product-image/c-2.png",
+				"product-image/e-1.png", "product-image/g-1.png"},
+		},
+		{
+			TestName:       "Keep No Image",
+			Name:           "Product I",
+			ImageMode:      ImageModeAppend,
+			KeepImagePaths: []string{},
+			ExpectedName:   "Product I",
+			ExpectedImages: []string{},
+		},
+	}
+
+	// product has at most 3 images
+	maxProductImages := config.MaxProductImages
+	config.MaxProductImages = 3
+	defer func() { config.MaxProductImages = maxProductImages }()
+
+	// do the test
+	for _, test := range testTable {
+		// get image IDs of kept image paths, 0 if not found
+		var keepImageIDs []int
+		if test.KeepImagePaths != nil {
+			p, err := GetProductBySKU(context.Background(), DB, pInfo.SKU)
+			if err != nil {
+				t.Fatalf("[%s] Expected error nil, but got error not nil => %s",
+					test.TestName, err.Error())
+			}
+			keepImageIDs = []int{}
+			for _, imagePath := range test.KeepImagePaths {
+				imageID := 0
+				for _, image := range p.ProductImages {
+					if image.ImagePath == imagePath {
+						imageID = image.ID
+					}
+				}
+				keepImageIDs = append(keepImageIDs, imageID)
+			}
+		}
+
+		update := pInfo
+		update.Name = test.Name
+		_, err = UpdateProductWithImages(context.Background(), DB, update,
+			test.Images, test.ImageMode, keepImageIDs)
+		if (err != nil) != test.ExpectedErr {
+			t.Errorf("[%s] Expected error %t, but got %v",
+				test.TestName, test.ExpectedErr, err)
+		}
+
+		p, err := GetProductBySKU(context.Background(), DB, pInfo.SKU)
+		if err != nil {
+			t.Fatalf("[%s] Expected error nil, but got error not nil => %s",
+				test.TestName, err.Error())
+		}
+		imagePaths := []string{}
+		for _, image := range p.ProductImages {
+			imagePaths = append(imagePaths, image.ImagePath)
+		}
+		if p.ProductInfo.Name != test.ExpectedName ||
+			strings.Join(imagePaths, ",") !=
+				strings.Join(test.ExpectedImages, ",") {
+			t.Errorf("[%s] Expected product %s with images %v, "+
+				"but got %s with images %v", test.TestName,
+				test.ExpectedName, test.ExpectedImages, p.ProductInfo.Name,
+				imagePaths)
+		}
+	}
+
+	// truncate tables after test
+	_, err = DB.Exec("TRUNCATE product_productinfo RESTART IDENTITY CASCADE")
+	if err != nil {
+		log.Fatalf("There's an error when truncating "+
+			"table product_productinfo => %s",
+			err.Error())
+	}
+}
+
+// TestAddProductImagesBySKU test AddProductImagesBySKU appending images
+// to product of the user
+//
+// Required for the test:
+//
+// - InsertProductWithImages
+func TestAddProductImagesBySKU(t *testing.T) {
+	// get testing DB connection
+	DB, err := getTestDBConnection()
+	if err != nil {
+		t.Fatalf("There's an error when initialize "+
+			"testing database connection => %s", err.Error())
+	}
+
+	maxProductImages := config.MaxProductImages
+	config.MaxProductImages = 3
+	defer func() { config.MaxProductImages = maxProductImages }()
+
+	// insert product info with an image into database
+	pInfo, err := InsertProductWithImages(context.Background(), DB,
+		ProductInfo{Name: "Product A", Price: 1000, Weight: 1, Stock: 5,
+			UserID: 1}, []ProductImage{{ImagePath: "product-image/a-1.png"}})
+	if err != nil {
+		t.Fatalf("There's an error when creating product data => %s",
+			err.Error())
+	}
+
+	// create test table
+	testTable := []struct {
+		TestName       string
+		SKU            string
+		UserID         int
+		Images         []ProductImage
+		ExpectedErr    error
+		ExpectedImages []string
+	}{
+		{
+			TestName: "Success",
+			SKU:      pInfo.SKU,
+			UserID:   1,
+			Images:   []ProductImage{{ImagePath: "product-image/a-2.png"}},
+			ExpectedImages: []string{"product-image/a-1.png",
+				"product-image/a-2.png"},
+		},
+		{
+			TestName: "Too Many Images",
+			SKU:      pInfo.SKU,
+			UserID:   1,
+			Images: []ProductImage{{ImagePath: "product-image/a-3.png"},
+				{ImagePath: "product-image/a-4.png"}},
+			ExpectedErr: ErrTooManyProductImages,
+		},
+		{
+			TestName:    "Not Owner",
+			SKU:         pInfo.SKU,
+			UserID:      2,
+			Images:      []ProductImage{{ImagePath: "product-image/b-1.png"}},
+			ExpectedErr: sql.ErrNoRows,
+		},
+		{
+			TestName:    "Not Found",
+			SKU:         "not-found",
+			UserID:      1,
+			Images:      []ProductImage{{ImagePath: "product-image/c-1.png"}},
+			ExpectedErr: sql.ErrNoRows,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		p, err := AddProductImagesBySKU(context.Background(), DB, test.SKU,
+			test.UserID, test.Images)
+		if !errors.Is(err, test.ExpectedErr) {
+			t.Errorf("[%s] Expected error %v, but got %v", test.TestName,
+				test.ExpectedErr, err)
+			continue
+		} else if err != nil {
+			continue
+		}
+
+		imagePaths := []string{}
+		for _, image := range p.ProductImages {
+			imagePaths = append(imagePaths, image.ImagePath)
+		}
+		if strings.Join(imagePaths, ",") !=
+			strings.Join(test.ExpectedImages, ",") {
+			t.Errorf("[%s] Expected images %v, but got %v", test.TestName,
+				test.ExpectedImages, imagePaths)
+		}
+	}
+
+	// truncate tables after test
+	_, err = DB.Exec("TRUNCATE product_productinfo RESTART IDENTITY CASCADE")
+	if err != nil {
+		log.Fatalf("There's an error when truncating "+
+			"table product_productinfo => %s",
+			err.Error())
+	}
+}
+
 // TestDeleteProductBySKU test DeleteProductBySKU
 //
 // Required for the test:
@@ -765,14 +1189,14 @@ func TestDeleteProductBySKU(t *testing.T) {
 	// insert product info into database
 	p := ProductInfo{
 		Name:        "AAA",
-		Price:       100000.00,
+		Price:       10000000,
 		Weight:      1.5,
 		Description: "BBB",
 		Stock:       100,
 		UserID:      1,
 	}
 
-	p, err = InsertProductInfo(DB, p)
+	p, err = InsertProductInfo(context.Background(), DB, p)
 	if err != nil {
 		t.Errorf("There's an error "+
 			"when creating product data => %s",
@@ -780,57 +1204,713 @@ func TestDeleteProductBySKU(t *testing.T) {
 	}
 
 	// delete product by key SKU
-	err = DeleteProductBySKU(DB, p.SKU)
+	err = DeleteProductBySKU(context.Background(), DB, p.SKU)
 	if err != nil {
 		t.Errorf("Expected error nil when deleting data, "+
 			"but got error => %s", err.Error())
 	}
 
 	// get product by SKU and check the result
-	_, err = GetProductBySKU(DB, p.SKU)
+	_, err = GetProductBySKU(context.Background(), DB, p.SKU)
 	if err == nil {
 		t.Errorf("Expected an error when getting data, but got no error")
 	}
 }
 
+// TestRestoreProductBySKU test RestoreProductBySKU
+//
+// Required for the test: InsertProductInfo, DeleteProductBySKU, GetProductBySKU
+func TestRestoreProductBySKU(t *testing.T) {
+	// get testing DB connection
+	DB, err := getTestDBConnection()
+	if err != nil {
+		t.Errorf("There's an error when initialize "+
+			"testing database connection => %s", err.Error())
+	}
+
+	// insert and soft delete product
+	p, err := InsertProductInfo(context.Background(), DB, ProductInfo{
+		Name:        "AAA",
+		Price:       10000000,
+		Weight:      1.5,
+		Description: "BBB",
+		Stock:       100,
+		UserID:      1,
+	})
+	if err != nil {
+		t.Errorf("There's an error "+
+			"when creating product data => %s",
+			err.Error())
+	}
+
+	err = DeleteProductBySKU(context.Background(), DB, p.SKU)
+	if err != nil {
+		t.Errorf("There's an error when deleting data => %s", err.Error())
+	}
+
+	// create testing table
+	testTable := []struct {
+		TestName      string
+		UserID        int
+		ExpectedError error
+	}{
+		{
+			TestName:      "Restore by other user",
+			UserID:        2,
+			ExpectedError: sql.ErrNoRows,
+		},
+		{
+			TestName:      "Restore by owner",
+			UserID:        1,
+			ExpectedError: nil,
+		},
+		{
+			TestName:      "Restore not deleted product",
+			UserID:        0,
+			ExpectedError: sql.ErrNoRows,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		_, err = RestoreProductBySKU(context.Background(), DB, p.SKU,
+			test.UserID)
+		if err != test.ExpectedError {
+			t.Errorf("[%s] Expected error %v, but got %v",
+				test.TestName, test.ExpectedError, err)
+		}
+	}
+
+	// get product by SKU and check the result
+	_, err = GetProductBySKU(context.Background(), DB, p.SKU)
+	if err != nil {
+		t.Errorf("Expected error nil when getting data, "+
+			"but got error => %s", err.Error())
+	}
+
+	// truncate tables after test
+	_, err = DB.Exec("TRUNCATE product_productinfo RESTART IDENTITY CASCADE")
+	if err != nil {
+		log.Fatalf("There's an error when truncating "+
+			"table product_productinfo => %s",
+			err.Error())
+	}
+}
+
+// TestSetProductVisibilityBySKU test SetProductVisibilityBySKU
+//
+// Required for the test: InsertProductInfo
+func TestSetProductVisibilityBySKU(t *testing.T) {
+	// get testing DB connection
+	DB, err := getTestDBConnection()
+	if err != nil {
+		t.Errorf("There's an error when initialize "+
+			"testing database connection => %s", err.Error())
+	}
+
+	// insert product
+	p, err := InsertProductInfo(context.Background(), DB, ProductInfo{
+		Name:        "AAA",
+		Price:       10000000,
+		Weight:      1.5,
+		Description: "BBB",
+		Stock:       100,
+		UserID:      1,
+	})
+	if err != nil {
+		t.Errorf("There's an error "+
+			"when creating product data => %s",
+			err.Error())
+	}
+
+	// create testing table
+	testTable := []struct {
+		TestName      string
+		UserID        int
+		Hidden        bool
+		ExpectedError error
+	}{
+		{
+			TestName:      "Hide by other user",
+			UserID:        2,
+			Hidden:        true,
+			ExpectedError: sql.ErrNoRows,
+		},
+		{
+			TestName:      "Hide by owner",
+			UserID:        1,
+			Hidden:        true,
+			ExpectedError: nil,
+		},
+		{
+			TestName:      "Show by owner",
+			UserID:        1,
+			Hidden:        false,
+			ExpectedError: nil,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		pInfo, err := SetProductVisibilityBySKU(context.Background(), DB,
+			p.SKU, test.UserID, test.Hidden)
+		if err != test.ExpectedError {
+			t.Errorf("[%s] Expected error %v, but got %v",
+				test.TestName, test.ExpectedError, err)
+		} else if err == nil && pInfo.Hidden != test.Hidden {
+			t.Errorf("[%s] Expected hidden %v, but got %v",
+				test.TestName, test.Hidden, pInfo.Hidden)
+		}
+	}
+
+	// hidden product excluded from query excluding hidden products
+	_, err = SetProductVisibilityBySKU(context.Background(), DB, p.SKU, 1,
+		true)
+	if err != nil {
+		t.Errorf("There's an error when hiding product => %s", err.Error())
+	}
+	products, err := GetProducts(context.Background(), DB,
+		ProductQuery{ExcludeHidden: true})
+	if err != nil {
+		t.Errorf("There's an error when getting products => %s", err.Error())
+	}
+	if len(products) != 0 {
+		t.Errorf("Expected 0 products, but got %d", len(products))
+	}
+
+	// truncate tables after test
+	_, err = DB.Exec("TRUNCATE product_productinfo RESTART IDENTITY CASCADE")
+	if err != nil {
+		log.Fatalf("There's an error when truncating "+
+			"table product_productinfo => %s",
+			err.Error())
+	}
+}
+
+// TestIsSKUConflict test isSKUConflict
+func TestIsSKUConflict(t *testing.T) {
+	// create testing table
+	testTable := []struct {
+		TestName       string
+		Err            error
+		ExpectedResult bool
+	}{
+		{
+			TestName: "SKU unique violation",
+			Err: &pq.Error{Code: "23505",
+				Constraint: "product_productinfo_sku_key"},
+			ExpectedResult: true,
+		},
+		{
+			TestName: "Other unique violation",
+			Err: &pq.Error{Code: "23505",
+				Constraint: "product_productinfo_pkey"},
+			ExpectedResult: false,
+		},
+		{
+			TestName:       "Other error",
+			Err:            sql.ErrNoRows,
+			ExpectedResult: false,
+		},
+		{
+			TestName:       "No error",
+			Err:            nil,
+			ExpectedResult: false,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		result := isSKUConflict(test.Err)
+		if result != test.ExpectedResult {
+			t.Errorf("[%s] Expected %v, but got %v",
+				test.TestName, test.ExpectedResult, result)
+		}
+	}
+}
+
+// TestGetVolumetricWeight test ProductInfo.GetVolumetricWeight
+func TestGetVolumetricWeight(t *testing.T) {
+	divisor := config.VolumetricWeightDivisor
+	config.VolumetricWeightDivisor = 5000
+	defer func() { config.VolumetricWeightDivisor = divisor }()
+
+	// create testing table
+	testTable := []struct {
+		TestName       string
+		ProductInfo    ProductInfo
+		ExpectedResult float32
+	}{
+		{
+			TestName:       "Dimensions set",
+			ProductInfo:    ProductInfo{Length: 50, Width: 40, Height: 30},
+			ExpectedResult: 12,
+		},
+		{
+			TestName:       "Dimensions not set",
+			ProductInfo:    ProductInfo{},
+			ExpectedResult: 0,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		result := test.ProductInfo.GetVolumetricWeight()
+		if result != test.ExpectedResult {
+			t.Errorf("[%s] Expected volumetric weight %v, but got %v",
+				test.TestName, test.ExpectedResult, result)
+		}
+	}
+}
+
+// TestIsOrderQtyAllowed test ProductInfo.IsOrderQtyAllowed
+func TestIsOrderQtyAllowed(t *testing.T) {
+	// create testing table
+	testTable := []struct {
+		TestName       string
+		ProductInfo    ProductInfo
+		Qty            float64
+		ExpectedResult bool
+	}{
+		{
+			TestName:       "No limit",
+			ProductInfo:    ProductInfo{},
+			Qty:            1000,
+			ExpectedResult: true,
+		},
+		{
+			TestName:       "Below minimum",
+			ProductInfo:    ProductInfo{MinOrderQty: 10, MaxOrderQty: 100},
+			Qty:            9,
+			ExpectedResult: false,
+		},
+		{
+			TestName:       "Within limit",
+			ProductInfo:    ProductInfo{MinOrderQty: 10, MaxOrderQty: 100},
+			Qty:            100,
+			ExpectedResult: true,
+		},
+		{
+			TestName:       "Above maximum",
+			ProductInfo:    ProductInfo{MinOrderQty: 10, MaxOrderQty: 100},
+			Qty:            101,
+			ExpectedResult: false,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		result := test.ProductInfo.IsOrderQtyAllowed(test.Qty)
+		if result != test.ExpectedResult {
+			t.Errorf("[%s] Expected %v, but got %v",
+				test.TestName, test.ExpectedResult, result)
+		}
+	}
+}
+
+// TestGetEffectivePrice test ProductInfo.GetEffectivePrice
+func TestGetEffectivePrice(t *testing.T) {
+	now := time.Date(2022, 1, 10, 0, 0, 0, 0, time.UTC)
+	before := now.Add(-24 * time.Hour)
+	after := now.Add(24 * time.Hour)
+
+	// create testing table
+	testTable := []struct {
+		TestName       string
+		ProductInfo    ProductInfo
+		ExpectedResult Money
+	}{
+		{
+			TestName:       "No sale",
+			ProductInfo:    ProductInfo{Price: 1000},
+			ExpectedResult: 1000,
+		},
+		{
+			TestName:       "Sale without schedule",
+			ProductInfo:    ProductInfo{Price: 1000, SalePrice: 800},
+			ExpectedResult: 800,
+		},
+		{
+			TestName: "Sale in schedule",
+			ProductInfo: ProductInfo{Price: 1000, SalePrice: 800,
+				SaleStartsAt: &before, SaleEndsAt: &after},
+			ExpectedResult: 800,
+		},
+		{
+			TestName: "Sale not started",
+			ProductInfo: ProductInfo{Price: 1000, SalePrice: 800,
+				SaleStartsAt: &after},
+			ExpectedResult: 1000,
+		},
+		{
+			TestName: "Sale ended",
+			ProductInfo: ProductInfo{Price: 1000, SalePrice: 800,
+				SaleEndsAt: &before},
+			ExpectedResult: 1000,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		result := test.ProductInfo.GetEffectivePrice(now)
+		if result != test.ExpectedResult {
+			t.Errorf("[%s] Expected effective price %v, but got %v",
+				test.TestName, test.ExpectedResult, result)
+		}
+	}
+}
+
 // getTestDBConnection get testing DB connection for package model testing
 func getTestDBConnection() (*sql.DB, error) {
 	// connect to DB
-	connString := fmt.Sprintf("user=%s password=%s dbname=%s sslmode=disable",
-		config.DBUsername, config.DBPassword, config.DBNameForModelTest)
-	DB, err := sql.Open("postgres", connString)
+	DB, err := sql.Open("postgres", config.GetDBConnString(
+		config.GetDBConfig(config.DBNameForModelTest)))
 	if err != nil {
 		return DB, err
 	}
 
-	// create tables if not exists
-	tableCreationQuery := `
-		CREATE TABLE IF NOT EXISTS product_productinfo (
-			id SERIAL PRIMARY KEY NOT NULL,
-			sku VARCHAR(15) UNIQUE NOT NULL,
-			name VARCHAR(100) NOT NULL,
-			price NUMERIC NOT NULL,
-			weight REAL NOT NULL,
-			description TEXT,
-			stock INT NOT NULL,
-			account_user_id INT NOT NULL
-		);
-
-		CREATE TABLE IF NOT EXISTS product_productimage
-		(
-			id SERIAL PRIMARY KEY NOT NULL,
-			image_path VARCHAR(250) NOT NULL,
-			product_productinfo_id INT NOT NULL,
-			CONSTRAINT fk_product_productinfo
-				FOREIGN KEY(product_productinfo_id) 
-					REFERENCES product_productinfo(id)
-					ON DELETE CASCADE
-		);
-	`
-	_, err = DB.Exec(tableCreationQuery)
+	// migrate database schema
+	_, err = migration.Up(context.Background(), DB)
 	if err != nil {
 		return DB, err
 	}
 
 	return DB, nil
 }
+
+// TestSaveProductImageFile test SaveProductImageFile naming image path
+// by content hash and getting its file info
+func TestSaveProductImageFile(t *testing.T) {
+	mediaRoot := config.MediaRoot
+	config.MediaRoot = t.TempDir()
+	defer func() { config.MediaRoot = mediaRoot }()
+
+	// save the same file name with different contents
+	imagePaths := []string{}
+	for _, content := range []string{"image a", "image b"} {
+		pImage, err := SaveProductImageFile("a.png",
+			strings.NewReader(content), 1)
+		if err != nil {
+			t.Fatalf("Expected error nil, but got error => %s", err.Error())
+		}
+		imagePath := pImage.ImagePath
+		imagePaths = append(imagePaths, imagePath)
+
+		// content isn't an image, so it has no dimensions
+		if pImage.Size != int64(len(content)) ||
+			pImage.ContentType != "text/plain; charset=utf-8" ||
+			pImage.Width != 0 || pImage.Height != 0 {
+			t.Errorf("Expected %d bytes text image without dimensions, "+
+				"but got %+v", len(content), pImage)
+		}
+
+		b, err := os.ReadFile(filepath.Join(config.MediaRoot, imagePath))
+		if err != nil || string(b) != content {
+			t.Errorf("Expected file %s content '%s', but got '%s' (%v)",
+				imagePath, content, b, err)
+		}
+	}
+
+	// check image paths contain content hash
+	sum := sha256.Sum256([]byte("image a"))
+	if !strings.Contains(imagePaths[0],
+		"-"+hex.EncodeToString(sum[:])[:imageHashLength]+"-a.png") ||
+		!strings.HasPrefix(imagePaths[0], "product-image/") ||
+		imagePaths[0] == imagePaths[1] {
+		t.Errorf("Expected distinct image paths with content hash, "+
+			"but got %v", imagePaths)
+	}
+
+	// check no temporary file left
+	entries, err := os.ReadDir(filepath.Join(config.MediaRoot,
+		"product-image"))
+	if err != nil || len(entries) != 2 {
+		t.Errorf("Expected 2 image files, but got %d (%v)", len(entries), err)
+	}
+}
+
+// fakeScanner scanner finding threat in content containing 'virus'
+type fakeScanner struct{}
+
+// Scan find threat 'Test.Virus' if content contains 'virus'
+func (fakeScanner) Scan(ctx context.Context, r io.Reader) (string, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	if strings.Contains(string(b), "virus") {
+		return "Test.Virus", nil
+	}
+
+	return "", nil
+}
+
+// TestSaveProductImageFileScanned test SaveProductImageFile rejecting
+// and quarantining image files the scanner finds suspicious
+func TestSaveProductImageFileScanned(t *testing.T) {
+	mediaRoot := config.MediaRoot
+	config.MediaRoot = t.TempDir()
+	ProductImageScanner = fakeScanner{}
+	defer func() {
+		config.MediaRoot = mediaRoot
+		ProductImageScanner = nil
+	}()
+
+	_, err := SaveProductImageFile("a.png", strings.NewReader("clean"), 1)
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+
+	_, err = SaveProductImageFile("b.png", strings.NewReader("virus"), 1)
+	if !errors.Is(err, ErrProductImageMalicious) ||
+		!strings.Contains(err.Error(), "'b.png' => Test.Virus") {
+		t.Fatalf("Expected error %v of b.png, but got %v",
+			ErrProductImageMalicious, err)
+	}
+
+	// check only the clean file saved and the suspicious file quarantined
+	entries, err := os.ReadDir(filepath.Join(config.MediaRoot,
+		"product-image"))
+	if err != nil || len(entries) != 1 ||
+		!strings.HasSuffix(entries[0].Name(), "-a.png") {
+		t.Errorf("Expected only a.png saved, but got %v (%v)", entries, err)
+	}
+	entries, err = os.ReadDir(filepath.Join(config.MediaRoot, "quarantine"))
+	if err != nil || len(entries) != 1 ||
+		!strings.HasSuffix(entries[0].Name(), "-b.png") {
+		t.Errorf("Expected b.png quarantined, but got %v (%v)", entries, err)
+	}
+}
+
+// TestSaveProductImageFileWatermarked test SaveProductImageFile
+// watermarking images of watermarked sellers, keeping clean originals
+func TestSaveProductImageFileWatermarked(t *testing.T) {
+	mediaRoot := config.MediaRoot
+	sellers := config.WatermarkSellers
+	config.MediaRoot = t.TempDir()
+	config.WatermarkSellers = []int{1}
+	watermark := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	draw.Draw(watermark, watermark.Bounds(), image.Black, image.Point{},
+		draw.Src)
+	ProductImageWatermark = watermark
+	defer func() {
+		config.MediaRoot = mediaRoot
+		config.WatermarkSellers = sellers
+		ProductImageWatermark = nil
+	}()
+
+	// white 40x40 PNG image
+	img := image.NewRGBA(image.Rect(0, 0, 40, 40))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	b := bytes.Buffer{}
+	err := png.Encode(&b, img)
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+
+	// create testing table
+	testTable := []struct {
+		TestName            string
+		UserID              int
+		Content             []byte
+		ExpectedErr         error
+		ExpectedWatermarked bool
+	}{
+		{"Watermarked Seller", 1, b.Bytes(), nil, true},
+		{"Other Seller", 2, b.Bytes(), nil, false},
+		{"Not An Image", 1, []byte("not an image"),
+			ErrProductImageInvalid, false},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		pImage, err := SaveProductImageFile("a.png",
+			bytes.NewReader(test.Content), test.UserID)
+		if !errors.Is(err, test.ExpectedErr) {
+			t.Errorf("[%s] Expected error %v, but got %v",
+				test.TestName, test.ExpectedErr, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		imagePath := pImage.ImagePath
+		if pImage.Width != 40 || pImage.Height != 40 ||
+			pImage.ContentType != "image/png" || pImage.Size == 0 {
+			t.Errorf("[%s] Expected 40x40 PNG image, but got %+v",
+				test.TestName, pImage)
+		}
+
+		// check bottom right corner of the served image is watermarked
+		f, err := os.Open(filepath.Join(config.MediaRoot, imagePath))
+		if err != nil {
+			t.Fatalf("[%s] Expected error nil, but got error => %s",
+				test.TestName, err.Error())
+		}
+		saved, err := png.Decode(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("[%s] Expected error nil, but got error => %s",
+				test.TestName, err.Error())
+		}
+		r, _, _, _ := saved.At(35, 35).RGBA()
+		if (r == 0) != test.ExpectedWatermarked {
+			t.Errorf("[%s] Expected watermarked %t, but got color %v",
+				test.TestName, test.ExpectedWatermarked, saved.At(35, 35))
+		}
+
+		// check clean original kept only if watermarked
+		original, err := os.ReadFile(filepath.Join(config.MediaRoot,
+			GetOriginalImagePath(imagePath)))
+		if test.ExpectedWatermarked &&
+			(err != nil || !bytes.Equal(original, test.Content)) {
+			t.Errorf("[%s] Expected clean original kept, but got error %v",
+				test.TestName, err)
+		} else if !test.ExpectedWatermarked && !os.IsNotExist(err) {
+			t.Errorf("[%s] Expected no original, but got error %v",
+				test.TestName, err)
+		}
+
+		// check removing the image also removes its original
+		err = RemoveProductImageFile(imagePath)
+		if err != nil {
+			t.Errorf("[%s] Expected error nil, but got error => %s",
+				test.TestName, err.Error())
+		}
+		_, err = os.Stat(filepath.Join(config.MediaRoot,
+			GetOriginalImagePath(imagePath)))
+		if !os.IsNotExist(err) {
+			t.Errorf("[%s] Expected original removed, but got error %v",
+				test.TestName, err)
+		}
+	}
+
+	// check no temporary file left
+	entries, err := os.ReadDir(filepath.Join(config.MediaRoot,
+		"product-image"))
+	if err != nil || len(entries) != 0 {
+		t.Errorf("Expected no image files, but got %v (%v)", entries, err)
+	}
+}
+
+// TestProcessProductImage test processProductImage checking resolution
+// and fitting aspect ratio of product images
+func TestProcessProductImage(t *testing.T) {
+	aspectRatio := config.ProductImageAspectRatio
+	fit := config.ProductImageFit
+	minSize := config.ProductImageMinSize
+	defer func() {
+		config.ProductImageAspectRatio = aspectRatio
+		config.ProductImageFit = fit
+		config.ProductImageMinSize = minSize
+	}()
+
+	// create 40x20 and 20x20 PNG images
+	encodePNG := func(width, height int) []byte {
+		b := bytes.Buffer{}
+		err := png.Encode(&b, image.NewRGBA(image.Rect(0, 0, width, height)))
+		if err != nil {
+			t.Fatalf("Expected error nil, but got error => %s", err.Error())
+		}
+		return b.Bytes()
+	}
+	wide := encodePNG(40, 20)
+	square := encodePNG(20, 20)
+
+	// create testing table
+	testTable := []struct {
+		TestName       string
+		AspectRatio    config.ImageSize
+		Fit            string
+		MinSize        config.ImageSize
+		Content        []byte
+		ExpectedErr    error
+		ExpectedSize   image.Point
+		ExpectedAsIs   bool
+		ExpectedNotPNG bool
+	}{
+		{
+			TestName:       "Not Configured",
+			Content:        []byte("not an image"),
+			ExpectedAsIs:   true,
+			ExpectedNotPNG: true,
+		},
+		{
+			TestName:    "Not An Image",
+			MinSize:     config.ImageSize{Width: 10, Height: 10},
+			Content:     []byte("not an image"),
+			ExpectedErr: ErrProductImageInvalid,
+		},
+		{
+			TestName:    "Below Minimum Resolution",
+			MinSize:     config.ImageSize{Width: 30, Height: 30},
+			Content:     wide,
+			ExpectedErr: ErrProductImageInvalid,
+		},
+		{
+			TestName:     "Minimum Resolution",
+			MinSize:      config.ImageSize{Width: 40, Height: 20},
+			Content:      wide,
+			ExpectedSize: image.Pt(40, 20),
+			ExpectedAsIs: true,
+		},
+		{
+			TestName:     "Crop",
+			AspectRatio:  config.ImageSize{Width: 1, Height: 1},
+			Fit:          "crop",
+			Content:      wide,
+			ExpectedSize: image.Pt(20, 20),
+		},
+		{
+			TestName:     "Pad",
+			AspectRatio:  config.ImageSize{Width: 1, Height: 1},
+			Fit:          "pad",
+			Content:      wide,
+			ExpectedSize: image.Pt(40, 40),
+		},
+		{
+			TestName:     "Aspect Ratio Already",
+			AspectRatio:  config.ImageSize{Width: 1, Height: 1},
+			Fit:          "crop",
+			Content:      square,
+			ExpectedSize: image.Pt(20, 20),
+			ExpectedAsIs: true,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		config.ProductImageAspectRatio = test.AspectRatio
+		config.ProductImageFit = test.Fit
+		config.ProductImageMinSize = test.MinSize
+
+		r, err := processProductImage("a.png", bytes.NewReader(test.Content))
+		if !errors.Is(err, test.ExpectedErr) {
+			t.Errorf("[%s] Expected error %v, but got %v",
+				test.TestName, test.ExpectedErr, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		b, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("[%s] Expected error nil, but got error => %s",
+				test.TestName, err.Error())
+		}
+		if bytes.Equal(b, test.Content) != test.ExpectedAsIs {
+			t.Errorf("[%s] Expected content as is %t, but got %t",
+				test.TestName, test.ExpectedAsIs, !test.ExpectedAsIs)
+		}
+		if test.ExpectedNotPNG {
+			continue
+		}
+
+		img, err := png.Decode(bytes.NewReader(b))
+		if err != nil || img.Bounds().Size() != test.ExpectedSize {
+			t.Errorf("[%s] Expected PNG image of size %v, but got %v (%v)",
+				test.TestName, test.ExpectedSize, img, err)
+		}
+	}
+}
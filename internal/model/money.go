@@ -0,0 +1,114 @@
+package model
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrMoneyInvalid returned by ParseMoney if the amount is not a decimal
+// number with at most 2 decimal places
+var ErrMoneyInvalid = errors.New("amount invalid, must be decimal number " +
+	"with at most 2 decimal places")
+
+// number of minor units in one major unit of money
+const minorUnits = 100
+
+// Money contain amount of money in minor units (1/100 of major unit),
+// encoded as decimal number of major units in JSON, form, and CSV
+type Money int64
+
+// ParseMoney parse amount of money from decimal number of major units
+// without floating point rounding, e.g. "12.5" is 1250 minor units
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	whole, fraction, _ := strings.Cut(s, ".")
+	if whole == "" || len(fraction) > 2 ||
+		strings.Trim(whole+fraction, "0123456789") != "" {
+		return 0, ErrMoneyInvalid
+	}
+	fraction += strings.Repeat("0", 2-len(fraction))
+
+	amount, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return 0, ErrMoneyInvalid
+	}
+	if negative {
+		amount = -amount
+	}
+
+	return Money(amount), nil
+}
+
+// String get amount as decimal number of major units
+// without trailing zero decimals, e.g. 1250 minor units is "12.5"
+func (m Money) String() string {
+	sign := ""
+	amount := int64(m)
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	s := sign + strconv.FormatInt(amount/minorUnits, 10)
+	fraction := strings.TrimRight(
+		strconv.FormatInt(minorUnits+amount%minorUnits, 10)[1:], "0")
+	if fraction != "" {
+		s += "." + fraction
+	}
+
+	return s
+}
+
+// Float get amount in major units as float64,
+// only for display, never for calculation
+func (m Money) Float() float64 {
+	return float64(m) / minorUnits
+}
+
+// Mul get amount multiplied by quantity, rounded to nearest minor unit
+func (m Money) Mul(qty float64) Money {
+	return Money(math.Round(float64(m) * qty))
+}
+
+// MarshalJSON encode amount as JSON number of major units
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON decode amount from JSON number or string of major units
+func (m *Money) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+
+	amount, err := ParseMoney(strings.Trim(string(b), `"`))
+	if err != nil {
+		return err
+	}
+
+	*m = amount
+	return nil
+}
+
+// UnmarshalText decode amount from text of major units,
+// used when parsing form values, empty text is zero amount
+func (m *Money) UnmarshalText(b []byte) error {
+	if strings.TrimSpace(string(b)) == "" {
+		*m = 0
+		return nil
+	}
+
+	amount, err := ParseMoney(string(b))
+	if err != nil {
+		return err
+	}
+
+	*m = amount
+	return nil
+}
@@ -0,0 +1,140 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestParseMoney test ParseMoney and Money.String
+func TestParseMoney(t *testing.T) {
+	// create testing table
+	testTable := []struct {
+		TestName       string
+		Amount         string
+		ExpectedResult Money
+		ExpectedString string
+		ExpectedError  error
+	}{
+		{
+			TestName:       "Whole amount",
+			Amount:         "1000",
+			ExpectedResult: 100000,
+			ExpectedString: "1000",
+		},
+		{
+			TestName:       "Amount with 1 decimal place",
+			Amount:         "12.5",
+			ExpectedResult: 1250,
+			ExpectedString: "12.5",
+		},
+		{
+			TestName:       "Amount with 2 decimal places",
+			Amount:         "1000000.05",
+			ExpectedResult: 100000005,
+			ExpectedString: "1000000.05",
+		},
+		{
+			TestName:       "Amount below 1",
+			Amount:         "0.1",
+			ExpectedResult: 10,
+			ExpectedString: "0.1",
+		},
+		{
+			TestName:       "Negative amount",
+			Amount:         "-3.25",
+			ExpectedResult: -325,
+			ExpectedString: "-3.25",
+		},
+		{
+			TestName:      "Amount with 3 decimal places",
+			Amount:        "333333.333",
+			ExpectedError: ErrMoneyInvalid,
+		},
+		{
+			TestName:      "Amount not a number",
+			Amount:        "12,5",
+			ExpectedError: ErrMoneyInvalid,
+		},
+		{
+			TestName:      "Amount empty",
+			Amount:        "",
+			ExpectedError: ErrMoneyInvalid,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		result, err := ParseMoney(test.Amount)
+		if err != test.ExpectedError {
+			t.Errorf("[%s] Expected error %v, but got %v",
+				test.TestName, test.ExpectedError, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		if result != test.ExpectedResult {
+			t.Errorf("[%s] Expected amount %d, but got %d",
+				test.TestName, int64(test.ExpectedResult), int64(result))
+		}
+		if result.String() != test.ExpectedString {
+			t.Errorf("[%s] Expected string '%s', but got '%s'",
+				test.TestName, test.ExpectedString, result.String())
+		}
+	}
+}
+
+// TestMoneyJSON test Money JSON encoding round trip
+func TestMoneyJSON(t *testing.T) {
+	pInfo := ProductInfo{}
+	err := json.Unmarshal([]byte(`{"price": 1000000.50, "sale_price": "0.99"}`),
+		&pInfo)
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+	if pInfo.Price != 100000050 || pInfo.SalePrice != 99 {
+		t.Errorf("Expected price 100000050 and sale price 99, but got %d "+
+			"and %d", int64(pInfo.Price), int64(pInfo.SalePrice))
+	}
+
+	b, err := json.Marshal(struct {
+		Price Money `json:"price"`
+	}{Price: pInfo.Price})
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+	if string(b) != `{"price":1000000.5}` {
+		t.Errorf("Expected JSON '%s', but got '%s'",
+			`{"price":1000000.5}`, string(b))
+	}
+
+	err = json.Unmarshal([]byte(`{"price": 0.001}`), &pInfo)
+	if err == nil {
+		t.Errorf("Expected error for amount with 3 decimal places, " +
+			"but got nil")
+	}
+}
+
+func TestMoneyUnmarshalText(t *testing.T) {
+	m := Money(100)
+	err := m.UnmarshalText([]byte(" "))
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+	if m != 0 {
+		t.Errorf("Expected empty text as amount 0, but got %d", int64(m))
+	}
+
+	err = m.UnmarshalText([]byte("12.50"))
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+	if m != 1250 {
+		t.Errorf("Expected amount 1250, but got %d", int64(m))
+	}
+}
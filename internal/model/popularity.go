@@ -0,0 +1,92 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+// PopularityCounter contain increments of popularity counters
+// of product by SKU reported by other services
+type PopularityCounter struct {
+	SKU             string `json:"sku"`
+	WishlistAdds    int64  `json:"wishlist_adds"`
+	CompletedOrders int64  `json:"completed_orders"`
+}
+
+// IncrementPopularityCounters increment wishlist and order counters of
+// products by SKU, counters of the same SKU are summed and counters of
+// products not found are ignored
+//
+// return number of products updated
+func IncrementPopularityCounters(ctx context.Context, DB *sql.DB,
+	counters []PopularityCounter) (int, error) {
+	SKUs := make([]string, len(counters))
+	wishlistAdds := make([]int64, len(counters))
+	completedOrders := make([]int64, len(counters))
+	for i, counter := range counters {
+		SKUs[i] = counter.SKU
+		wishlistAdds[i] = counter.WishlistAdds
+		completedOrders[i] = counter.CompletedOrders
+	}
+
+	res, err := DB.ExecContext(ctx, `
+		UPDATE product_productinfo AS p
+		SET
+			wishlist_count = p.wishlist_count + c.wishlist_adds,
+			order_count = p.order_count + c.completed_orders
+		FROM (
+			SELECT sku, SUM(wishlist_adds) AS wishlist_adds,
+				SUM(completed_orders) AS completed_orders
+			FROM UNNEST($1::TEXT[], $2::BIGINT[], $3::BIGINT[])
+				AS u(sku, wishlist_adds, completed_orders)
+			GROUP BY sku
+		) AS c
+		WHERE p.sku = c.sku AND p.deleted_at IS NULL`,
+		pq.Array(SKUs), pq.Array(wishlistAdds), pq.Array(completedOrders))
+	if err != nil {
+		return 0, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(affected), nil
+}
+
+// CountCompletedOrder increment completed order counter of products
+// by SKU once per order, products already counted for the order
+// and products not found are ignored, so redelivered order completion
+// isn't counted again
+//
+// return number of products counted
+func CountCompletedOrder(ctx context.Context, DB *sql.DB, orderID string,
+	SKUs []string) (int, error) {
+	res, err := DB.ExecContext(ctx, `
+		WITH counted AS (
+			INSERT INTO product_completedorder(
+				order_id, product_productinfo_id)
+			SELECT $1, id FROM product_productinfo
+			WHERE sku = ANY($2::TEXT[]) AND deleted_at IS NULL
+			ON CONFLICT (order_id, product_productinfo_id) DO NOTHING
+			RETURNING product_productinfo_id
+		)
+		UPDATE product_productinfo AS p
+		SET order_count = p.order_count + 1
+		FROM counted AS c
+		WHERE p.id = c.product_productinfo_id`,
+		orderID, pq.Array(SKUs))
+	if err != nil {
+		return 0, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(affected), nil
+}
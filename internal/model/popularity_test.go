@@ -0,0 +1,76 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"context"
+	"log"
+	"testing"
+)
+
+// TestCountCompletedOrder test CountCompletedOrder counting products
+// of the same completed order delivered twice only once
+//
+// Required for the test: InsertProductInfo, GetProductBySKU
+func TestCountCompletedOrder(t *testing.T) {
+	// get testing DB connection
+	DB, err := getTestDBConnection()
+	if err != nil {
+		t.Errorf("There's an error when initialize "+
+			"testing database connection => %s", err.Error())
+	}
+
+	// insert product into database
+	pInfo, err := InsertProductInfo(context.Background(), DB, ProductInfo{
+		Name: "PRODUCT A", Price: 1000, Weight: 1, Stock: 10, UserID: 1,
+	})
+	if err != nil {
+		t.Errorf("There's an error when insert data product info => %s",
+			err.Error())
+	}
+
+	// create testing table
+	testTable := []struct {
+		TestName           string
+		OrderID            string
+		ExpectedCounted    int
+		ExpectedOrderCount int64
+	}{
+		{"Test Order Completed", "ORDER-1", 1, 1},
+		{"Test Order Redelivered", "ORDER-1", 0, 1},
+		{"Test Other Order Completed", "ORDER-2", 1, 2},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		counted, err := CountCompletedOrder(context.Background(), DB,
+			test.OrderID, []string{pInfo.SKU, "NOT-FOUND"})
+		if err != nil {
+			t.Errorf("[%s] Expected error nil, but got error => %s",
+				test.TestName, err.Error())
+		} else if counted != test.ExpectedCounted {
+			t.Errorf("[%s] Expected %d products counted, but got %d",
+				test.TestName, test.ExpectedCounted, counted)
+		}
+
+		p, err := GetProductBySKU(context.Background(), DB, pInfo.SKU)
+		if err != nil {
+			t.Errorf("[%s] There's an error when get data product => %s",
+				test.TestName, err.Error())
+		} else if p.ProductInfo.OrderCount != test.ExpectedOrderCount {
+			t.Errorf("[%s] Expected order count %d, but got %d",
+				test.TestName, test.ExpectedOrderCount,
+				p.ProductInfo.OrderCount)
+		}
+	}
+
+	// truncate tables after test
+	_, err = DB.Exec("TRUNCATE product_productinfo RESTART IDENTITY CASCADE")
+	if err != nil {
+		log.Fatalf("There's an error when truncating "+
+			"table product_productinfo => %s",
+			err.Error())
+	}
+}
@@ -0,0 +1,151 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// PriceTier contain unit price of a product when ordered
+// at least MinQty
+type PriceTier struct {
+	MinQty float64 `json:"min_qty"`
+	Price  Money   `json:"price"`
+}
+
+// PriceQuote contain price of a product for a quantity
+type PriceQuote struct {
+	SKU       string  `json:"sku"`
+	Qty       float64 `json:"qty"`
+	UnitPrice Money   `json:"unit_price"`
+	Total     Money   `json:"total"`
+}
+
+// GetUnitPrice get unit price of product when ordered qty at the instant,
+// the lowest of its effective price and price of the tier with
+// the highest minimum quantity reached
+func (p Product) GetUnitPrice(qty float64, at time.Time) Money {
+	price := p.ProductInfo.GetEffectivePrice(at)
+
+	var tier *PriceTier
+	for i := range p.PriceTiers {
+		if qty >= p.PriceTiers[i].MinQty &&
+			(tier == nil || p.PriceTiers[i].MinQty > tier.MinQty) {
+			tier = &p.PriceTiers[i]
+		}
+	}
+	if tier != nil && tier.Price < price {
+		price = tier.Price
+	}
+
+	return price
+}
+
+// GetPriceQuote get price quote of product when ordered qty
+// at the instant, total is rounded to nearest minor unit
+func (p Product) GetPriceQuote(qty float64, at time.Time) PriceQuote {
+	unitPrice := p.GetUnitPrice(qty, at)
+
+	return PriceQuote{
+		SKU:       p.ProductInfo.SKU,
+		Qty:       qty,
+		UnitPrice: unitPrice,
+		Total:     unitPrice.Mul(qty),
+	}
+}
+
+// getPriceTiers get price tiers of products from database,
+// by product ID and sorted by minimum quantity
+func getPriceTiers(ctx context.Context, DB *sql.DB,
+	productIDs []int64) (map[int][]PriceTier, error) {
+	tiers := map[int][]PriceTier{}
+
+	rows, err := DB.QueryContext(ctx, `
+		SELECT min_qty, price, product_productinfo_id
+		FROM product_pricetier
+		WHERE product_productinfo_id = ANY($1)
+		ORDER BY min_qty`,
+		pq.Array(productIDs))
+	if err != nil {
+		return tiers, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		tier := PriceTier{}
+		var productID int
+		err = rows.Scan(&tier.MinQty, &tier.Price, &productID)
+		if err != nil {
+			return tiers, err
+		}
+
+		tiers[productID] = append(tiers[productID], tier)
+	}
+
+	return tiers, rows.Err()
+}
+
+// SetPriceTiersBySKU replace price tiers of product by SKU owned by user ID
+// in one transaction, empty tiers remove all price tiers
+//
+// return sql.ErrNoRows if product not found or not owned by the user
+func SetPriceTiersBySKU(ctx context.Context, DB *sql.DB, SKU string,
+	userID int, tiers []PriceTier) (Product, error) {
+	p := Product{}
+
+	// begin transaction
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return p, err
+	}
+	defer tx.Rollback() // rollback transaction if fail
+
+	// touch product so its cache and ETag are invalidated
+	row := tx.QueryRowContext(ctx, `
+		UPDATE product_productinfo
+		SET updated_at = NOW()
+		WHERE sku = $1 AND account_user_id = $2 AND deleted_at IS NULL
+		RETURNING `+productInfoColumns,
+		SKU, userID)
+	if row.Err() != nil {
+		return p, row.Err()
+	}
+
+	err = scanProductInfo(row, &p.ProductInfo)
+	if err != nil {
+		return p, err
+	}
+
+	// replace price tiers
+	_, err = tx.ExecContext(ctx, `DELETE FROM product_pricetier
+		WHERE product_productinfo_id = $1`,
+		p.ProductInfo.ID)
+	if err != nil {
+		return p, err
+	}
+
+	p.PriceTiers = append([]PriceTier{}, tiers...)
+	sort.Slice(p.PriceTiers, func(i, j int) bool {
+		return p.PriceTiers[i].MinQty < p.PriceTiers[j].MinQty
+	})
+	for _, tier := range p.PriceTiers {
+		_, err = tx.ExecContext(ctx, `INSERT INTO
+			product_pricetier(min_qty, price, product_productinfo_id)
+			VALUES($1,$2,$3)`,
+			tier.MinQty, tier.Price, p.ProductInfo.ID)
+		if err != nil {
+			return p, err
+		}
+	}
+
+	// commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
@@ -0,0 +1,83 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+// TestGetPriceQuote test GetPriceQuote
+func TestGetPriceQuote(t *testing.T) {
+	now := time.Now()
+	saleStartsAt := now.Add(-time.Hour)
+	p := Product{
+		ProductInfo: ProductInfo{SKU: "SKU-A", Price: 1000},
+		PriceTiers: []PriceTier{
+			{MinQty: 10, Price: 900},
+			{MinQty: 100, Price: 800},
+		},
+	}
+	pOnSale := p
+	pOnSale.ProductInfo.SalePrice = 850
+	pOnSale.ProductInfo.SaleStartsAt = &saleStartsAt
+
+	// create testing table
+	testTable := []struct {
+		TestName          string
+		Product           Product
+		Qty               float64
+		ExpectedUnitPrice Money
+		ExpectedTotal     Money
+	}{
+		{
+			TestName:          "Below first tier",
+			Product:           p,
+			Qty:               9,
+			ExpectedUnitPrice: 1000,
+			ExpectedTotal:     9000,
+		},
+		{
+			TestName:          "At first tier",
+			Product:           p,
+			Qty:               10,
+			ExpectedUnitPrice: 900,
+			ExpectedTotal:     9000,
+		},
+		{
+			TestName:          "Above last tier",
+			Product:           p,
+			Qty:               250,
+			ExpectedUnitPrice: 800,
+			ExpectedTotal:     200000,
+		},
+		{
+			TestName:          "Sale price lower than tier",
+			Product:           pOnSale,
+			Qty:               10,
+			ExpectedUnitPrice: 850,
+			ExpectedTotal:     8500,
+		},
+		{
+			TestName:          "Tier lower than sale price",
+			Product:           pOnSale,
+			Qty:               100,
+			ExpectedUnitPrice: 800,
+			ExpectedTotal:     80000,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		quote := test.Product.GetPriceQuote(test.Qty, now)
+		if quote.UnitPrice != test.ExpectedUnitPrice ||
+			quote.Total != test.ExpectedTotal {
+			t.Errorf("[%s] Expected unit price %v total %v, but got "+
+				"unit price %v total %v", test.TestName,
+				test.ExpectedUnitPrice, test.ExpectedTotal,
+				quote.UnitPrice, quote.Total)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// entities changed by product events
+const (
+	ChangeEntityProduct = "product"
+	ChangeEntityStock   = "stock"
+)
+
+// operations of product events on their entity
+const (
+	ChangeOperationCreate = "create"
+	ChangeOperationUpdate = "update"
+	ChangeOperationDelete = "delete"
+)
+
+// ProductEvent contain product event recorded in append-only event
+// journal as change of entity by operation, ID is sequence number
+// of the change increasing in order of recording
+type ProductEvent struct {
+	ID         int64           `json:"sequence"`
+	Entity     string          `json:"entity"`
+	Operation  string          `json:"operation"`
+	Name       string          `json:"name"`
+	SKU        string          `json:"sku"`
+	UserID     int             `json:"user_id"`
+	Payload    json.RawMessage `json:"payload"`
+	OccurredAt time.Time       `json:"occurred_at"`
+}
+
+// InsertProductEvent insert product event into event journal
+func InsertProductEvent(ctx context.Context, DB *sql.DB, e ProductEvent) (
+	ProductEvent, error) {
+	var payload interface{}
+	if len(e.Payload) > 0 {
+		payload = []byte(e.Payload)
+	}
+
+	err := DB.QueryRowContext(ctx, `
+		INSERT INTO product_productevent(entity, operation, name, sku,
+			user_id, payload, occurred_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`,
+		e.Entity, e.Operation, e.Name, e.SKU, e.UserID, payload,
+		e.OccurredAt).Scan(&e.ID)
+
+	return e, err
+}
+
+// GetProductEventsAfter get at most limit product events after ID
+// recorded at least settle ago, oldest first
+//
+// events recorded within settle are skipped since events recorded
+// concurrently may be committed out of ID order
+func GetProductEventsAfter(ctx context.Context, DB *sql.DB, afterID int64,
+	settle time.Duration, limit int) ([]ProductEvent, error) {
+	events := []ProductEvent{}
+
+	rows, err := DB.QueryContext(ctx, `
+		SELECT id, entity, operation, name, sku, user_id, payload,
+			occurred_at
+		FROM product_productevent
+		WHERE id > $1 AND created_at <= NOW() - make_interval(secs => $2)
+		ORDER BY id
+		LIMIT $3`,
+		afterID, settle.Seconds(), limit)
+	if err != nil {
+		return []ProductEvent{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e := ProductEvent{}
+		var payload []byte
+		err = rows.Scan(&e.ID, &e.Entity, &e.Operation, &e.Name, &e.SKU,
+			&e.UserID, &payload, &e.OccurredAt)
+		if err != nil {
+			return []ProductEvent{}, err
+		}
+		e.Payload = payload
+
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
+
+// GetProductEventIDRange get ID of the first and the last product event
+// in event journal, both zero if the journal is empty
+func GetProductEventIDRange(ctx context.Context, DB *sql.DB) (int64, int64,
+	error) {
+	var first, last int64
+	err := DB.QueryRowContext(ctx, `
+		SELECT COALESCE(MIN(id), 0), COALESCE(MAX(id), 0)
+		FROM product_productevent`).Scan(&first, &last)
+
+	return first, last, err
+}
+
+// DeleteProductEventsBefore delete product events recorded before
+// the instant from event journal, then get count of deleted events
+func DeleteProductEventsBefore(ctx context.Context, DB *sql.DB,
+	before time.Time) (int64, error) {
+	res, err := DB.ExecContext(ctx, `
+		DELETE FROM product_productevent
+		WHERE created_at < $1`,
+		before)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
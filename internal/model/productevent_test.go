@@ -0,0 +1,72 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// TestProductEvents test InsertProductEvent, GetProductEventsAfter,
+// GetProductEventIDRange, and DeleteProductEventsBefore
+func TestProductEvents(t *testing.T) {
+	// get testing DB connection
+	DB, err := getTestDBConnection()
+	if err != nil {
+		t.Fatalf("There's an error when initialize "+
+			"testing database connection => %s", err.Error())
+	}
+	_, err = DB.Exec("TRUNCATE product_productevent RESTART IDENTITY")
+	if err != nil {
+		t.Fatalf("There's an error when truncating table "+
+			"product_productevent => %s", err.Error())
+	}
+
+	// insert events with and without payload
+	for _, e := range []ProductEvent{
+		{Entity: ChangeEntityProduct, Operation: ChangeOperationCreate,
+			Name: "ProductCreated", SKU: "SKU-A", UserID: 1,
+			Payload: json.RawMessage(`{"sku":"SKU-A"}`)},
+		{Entity: ChangeEntityProduct, Operation: ChangeOperationDelete,
+			Name: "ProductDeleted", SKU: "SKU-A", UserID: 1},
+		{Entity: ChangeEntityStock, Operation: ChangeOperationUpdate,
+			Name: "StockChanged", SKU: "SKU-B", UserID: 2},
+	} {
+		e.OccurredAt = time.Now()
+		_, err = InsertProductEvent(context.Background(), DB, e)
+		if err != nil {
+			t.Fatalf("Expected error nil, but got error => %s", err.Error())
+		}
+	}
+
+	first, last, err := GetProductEventIDRange(context.Background(), DB)
+	if err != nil || first != 1 || last != 3 {
+		t.Errorf("Expected event ID range 1 to 3, but got %d to %d (%v)",
+			first, last, err)
+	}
+
+	// get events after the first one, and none within settle duration
+	events, err := GetProductEventsAfter(context.Background(), DB, 1, 0, 10)
+	if err != nil || len(events) != 2 || events[0].ID != 2 ||
+		events[0].Operation != ChangeOperationDelete ||
+		events[0].Payload != nil || events[1].SKU != "SKU-B" ||
+		events[1].Entity != ChangeEntityStock {
+		t.Errorf("Expected events 2 and 3, but got %+v (%v)", events, err)
+	}
+	events, err = GetProductEventsAfter(context.Background(), DB, 0,
+		time.Hour, 10)
+	if err != nil || len(events) != 0 {
+		t.Errorf("Expected no settled events, but got %+v (%v)", events, err)
+	}
+
+	// purge all events
+	purged, err := DeleteProductEventsBefore(context.Background(), DB,
+		time.Now().Add(time.Minute))
+	if err != nil || purged != 3 {
+		t.Errorf("Expected 3 events purged, but got %d (%v)", purged, err)
+	}
+}
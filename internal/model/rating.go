@@ -0,0 +1,42 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+)
+
+// maximum average rating of a product
+const MaxRating = 5
+
+// ProductRating contain rating aggregate of a product computed from
+// its reviews, average rating is rounded to 2 decimal places
+type ProductRating struct {
+	AvgRating   float64 `json:"avg_rating"`
+	ReviewCount int     `json:"review_count"`
+}
+
+// SetProductRatingBySKU set rating aggregate of product by SKU,
+// version and update time are kept since the product itself not changed
+//
+// return sql.ErrNoRows if product not found
+func SetProductRatingBySKU(ctx context.Context, DB *sql.DB, SKU string,
+	rating ProductRating) error {
+	res, err := DB.ExecContext(ctx, `
+		UPDATE product_productinfo
+		SET avg_rating = $1, review_count = $2
+		WHERE sku = $3 AND deleted_at IS NULL`,
+		rating.AvgRating, rating.ReviewCount, SKU)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// ReplicaRetryInterval duration read replica is skipped after it failed,
+// so every read doesn't wait for an unavailable replica
+var ReplicaRetryInterval = 30 * time.Second
+
+// primaryReadKey context key of reading from primary database
+type primaryReadKey struct{}
+
+// ReadFromPrimary get context reading from primary database even if
+// read replica configured, used when reading right after writing
+// since the replica may lag behind
+func ReadFromPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryReadKey{}, true)
+}
+
+// NewPostgresRepositoryWithReplica create product repository
+// with PostgreSQL database connection and its read replica connection
+func NewPostgresRepositoryWithReplica(DB *sql.DB,
+	replica *sql.DB) *PostgresRepository {
+	return &PostgresRepository{DB: DB, Replica: replica}
+}
+
+// read run read query on read replica, on primary database instead
+// if there's no replica, ctx reads from primary, or the replica
+// recently failed, retried while it failed with transient error
+//
+// query failed on replica other than no rows is retried on primary
+// and the replica is skipped for ReplicaRetryInterval
+func (r *PostgresRepository) read(ctx context.Context,
+	query func(DB *sql.DB) error) error {
+	return retry(ctx, true, func() error {
+		if r.Replica == nil || ctx.Value(primaryReadKey{}) != nil ||
+			time.Now().UnixNano() < atomic.LoadInt64(&r.replicaDownUntil) {
+			return query(r.DB)
+		}
+
+		err := query(r.Replica)
+		if err == nil || err == sql.ErrNoRows || ctx.Err() != nil {
+			return err
+		}
+
+		log.Printf("There's an error when reading from database replica, "+
+			"reading from primary for %s => %s", ReplicaRetryInterval,
+			err.Error())
+		atomic.StoreInt64(&r.replicaDownUntil,
+			time.Now().Add(ReplicaRetryInterval).UnixNano())
+
+		return query(r.DB)
+	})
+}
@@ -0,0 +1,109 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestPostgresRepositoryReplica test PostgresRepository reading
+// from read replica and falling back to primary database
+//
+// Required for the test:
+//
+// - InsertProductInfo
+//
+// - GetProductBySKU
+//
+// - GetProducts
+func TestPostgresRepositoryReplica(t *testing.T) {
+	// get testing DB connection
+	DB, err := getTestDBConnection()
+	if err != nil {
+		t.Errorf("There's an error when initialize "+
+			"testing database connection => %s", err.Error())
+	}
+
+	// insert product info into database
+	pInfo, err := InsertProductInfo(context.Background(), DB, ProductInfo{
+		Name:   "PRODUCT A",
+		Price:  1000,
+		Weight: 1.5,
+		Stock:  10,
+		UserID: 1,
+	})
+	if err != nil {
+		t.Errorf("There's an error when insert data product info => %s",
+			err.Error())
+	}
+
+	// unavailable replica as closed database connection
+	unavailable, err := sql.Open("postgres", "")
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+	unavailable.Close()
+
+	// create testing table
+	testTable := []struct {
+		TestName            string
+		Replica             *sql.DB
+		ExpectedReplicaDown bool
+	}{
+		{
+			TestName: "Available Replica",
+			Replica:  DB,
+		},
+		{
+			TestName:            "Unavailable Replica",
+			Replica:             unavailable,
+			ExpectedReplicaDown: true,
+		},
+	}
+
+	// do the test
+	for _, test := range testTable {
+		repo := NewPostgresRepositoryWithReplica(DB, test.Replica)
+
+		p, err := repo.GetProductBySKU(context.Background(), pInfo.SKU)
+		if err != nil || p.ProductInfo.ID != pInfo.ID {
+			t.Errorf("[%s] Expected product %d, but got %d (error %v)",
+				test.TestName, pInfo.ID, p.ProductInfo.ID, err)
+		}
+
+		products, err := repo.GetProducts(context.Background(),
+			ProductQuery{UserID: 1})
+		if err != nil || len(products) != 1 {
+			t.Errorf("[%s] Expected 1 product, but got %d (error %v)",
+				test.TestName, len(products), err)
+		}
+
+		_, err = repo.GetProductBySKU(context.Background(), "unknown")
+		if err != sql.ErrNoRows {
+			t.Errorf("[%s] Expected error %v, but got %v",
+				test.TestName, sql.ErrNoRows, err)
+		}
+
+		replicaDown := time.Now().UnixNano() <
+			atomic.LoadInt64(&repo.replicaDownUntil)
+		if replicaDown != test.ExpectedReplicaDown {
+			t.Errorf("[%s] Expected replica down %t, but got %t",
+				test.TestName, test.ExpectedReplicaDown, replicaDown)
+		}
+	}
+
+	// truncate tables after test
+	_, err = DB.Exec("TRUNCATE product_productinfo RESTART IDENTITY CASCADE")
+	if err != nil {
+		log.Fatalf("There's an error when truncating "+
+			"table product_productinfo => %s",
+			err.Error())
+	}
+}
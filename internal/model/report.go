@@ -0,0 +1,210 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// reasons of product report
+const (
+	ReportReasonCounterfeit = "counterfeit"
+	ReportReasonProhibited  = "prohibited"
+	ReportReasonMisleading  = "misleading"
+	ReportReasonOther       = "other"
+)
+
+// ReportReasons all reasons of product report
+var ReportReasons = []string{
+	ReportReasonCounterfeit,
+	ReportReasonProhibited,
+	ReportReasonMisleading,
+	ReportReasonOther,
+}
+
+// statuses of product report, report is open until admin reviews
+// the reported product
+const (
+	ReportStatusOpen      = "open"
+	ReportStatusDismissed = "dismissed"
+	ReportStatusTakenDown = "taken_down"
+)
+
+// actions of admin reviewing reported product
+const (
+	ReportActionDismiss  = "dismiss"
+	ReportActionTakeDown = "take_down"
+)
+
+// ProductReport contain report of a product listing flagged by buyer,
+// reviewer user ID is zero while the report is open, and reporter or
+// reviewer user ID is zero once the user deleted
+type ProductReport struct {
+	ID             int        `json:"id"`
+	SKU            string     `json:"sku"`
+	ProductName    string     `json:"product_name"`
+	Reason         string     `json:"reason"`
+	Note           string     `json:"note"`
+	ReporterUserID int        `json:"reporter_user_id"`
+	Status         string     `json:"status"`
+	ReviewerUserID int        `json:"reviewer_user_id"`
+	ReviewedAt     *time.Time `json:"reviewed_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// ErrReportDuplicate error of product already reported by the user
+// and the report still open
+var ErrReportDuplicate = errors.New("product already reported by the user")
+
+// ErrProductTakenDown error of publishing product taken down by admin
+var ErrProductTakenDown = errors.New("product taken down by admin")
+
+// InsertProductReport insert report of published product by SKU
+//
+// return sql.ErrNoRows if no published product found, and
+// ErrReportDuplicate if the reporter already has open report of it
+func InsertProductReport(ctx context.Context, DB *sql.DB,
+	report ProductReport) (ProductReport, error) {
+	err := DB.QueryRowContext(ctx, `
+		INSERT INTO product_productreport(reason, note, reporter_user_id,
+			product_productinfo_id)
+		SELECT $1, $2, $3, id
+		FROM product_productinfo
+		WHERE sku = $4 AND hidden = FALSE AND deleted_at IS NULL
+		RETURNING id, status, created_at`,
+		report.Reason, report.Note, report.ReporterUserID, report.SKU).Scan(
+		&report.ID, &report.Status, &report.CreatedAt)
+	if isReportConflict(err) {
+		return report, ErrReportDuplicate
+	}
+
+	return report, err
+}
+
+// isReportConflict check error is unique violation of open report
+// of a product by a user
+func isReportConflict(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" &&
+		pqErr.Constraint == "product_productreport_open_key"
+}
+
+// GetProductReports get product reports of status, or of every status
+// if status is empty, oldest first so the review queue is first in
+// first out
+func GetProductReports(ctx context.Context, DB *sql.DB, status string) (
+	[]ProductReport, error) {
+	reports := []ProductReport{}
+
+	rows, err := DB.QueryContext(ctx, `
+		SELECT
+			r.id, p.sku, p.name, r.reason, r.note,
+			COALESCE(r.reporter_user_id, 0), r.status,
+			COALESCE(r.reviewer_user_id, 0), r.reviewed_at, r.created_at
+		FROM product_productreport r
+		JOIN product_productinfo p ON p.id = r.product_productinfo_id
+		WHERE $1 = '' OR r.status = $1
+		ORDER BY r.created_at, r.id`,
+		status)
+	if err != nil {
+		return []ProductReport{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		r := ProductReport{}
+		err = rows.Scan(&r.ID, &r.SKU, &r.ProductName, &r.Reason, &r.Note,
+			&r.ReporterUserID, &r.Status, &r.ReviewerUserID, &r.ReviewedAt,
+			&r.CreatedAt)
+		if err != nil {
+			return []ProductReport{}, err
+		}
+
+		reports = append(reports, r)
+	}
+
+	return reports, rows.Err()
+}
+
+// ReviewProductReportsBySKU resolve open reports of product by SKU
+// by action of reviewer user ID in one transaction, the product is hidden
+// if action is ReportActionTakeDown, then get the product and count
+// of reports resolved
+//
+// return sql.ErrNoRows if the product has no open reports
+func ReviewProductReportsBySKU(ctx context.Context, DB *sql.DB, SKU string,
+	action string, reviewerUserID int) (ProductInfo, int, error) {
+	pInfo := ProductInfo{}
+
+	status := ReportStatusDismissed
+	if action == ReportActionTakeDown {
+		status = ReportStatusTakenDown
+	}
+
+	// begin transaction
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return pInfo, 0, err
+	}
+	defer tx.Rollback() // rollback transaction if fail
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE product_productreport
+		SET status = $1, reviewer_user_id = $2, reviewed_at = NOW()
+		WHERE status = $3 AND product_productinfo_id = (
+			SELECT id FROM product_productinfo WHERE sku = $4)`,
+		status, reviewerUserID, ReportStatusOpen, SKU)
+	if err != nil {
+		return pInfo, 0, err
+	}
+	resolved, err := res.RowsAffected()
+	if err != nil {
+		return pInfo, 0, err
+	}
+	if resolved == 0 {
+		return pInfo, 0, sql.ErrNoRows
+	}
+
+	// take the product down by hiding it, so it stays
+	// for its seller to review
+	query := `
+		SELECT ` + productInfoColumns + `
+		FROM product_productinfo
+		WHERE sku = $1`
+	if action == ReportActionTakeDown {
+		query = `
+		UPDATE product_productinfo
+		SET hidden = TRUE, updated_at = NOW()
+		WHERE sku = $1
+		RETURNING ` + productInfoColumns
+	}
+	row := tx.QueryRowContext(ctx, query, SKU)
+	if row.Err() != nil {
+		return pInfo, 0, row.Err()
+	}
+	err = scanProductInfo(row, &pInfo)
+	if err != nil {
+		return pInfo, 0, err
+	}
+
+	return pInfo, int(resolved), tx.Commit()
+}
+
+// isProductTakenDown check if product by SKU has report
+// taken down by admin
+func isProductTakenDown(ctx context.Context, DB *sql.DB, SKU string) (bool,
+	error) {
+	var takenDown bool
+	err := DB.QueryRowContext(ctx, `
+		SELECT EXISTS(
+			SELECT 1
+			FROM product_productreport r
+			JOIN product_productinfo p ON p.id = r.product_productinfo_id
+			WHERE p.sku = $1 AND r.status = $2)`,
+		SKU, ReportStatusTakenDown).Scan(&takenDown)
+
+	return takenDown, err
+}
@@ -0,0 +1,95 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+// TestProductReports test InsertProductReport, GetProductReports,
+// and ReviewProductReportsBySKU
+func TestProductReports(t *testing.T) {
+	// get testing DB connection
+	DB, err := getTestDBConnection()
+	if err != nil {
+		t.Fatalf("There's an error when initialize "+
+			"testing database connection => %s", err.Error())
+	}
+	_, err = DB.Exec("TRUNCATE product_productreport RESTART IDENTITY")
+	if err != nil {
+		t.Fatalf("There's an error when truncating table "+
+			"product_productreport => %s", err.Error())
+	}
+
+	// insert product
+	p, err := InsertProductInfo(context.Background(), DB, ProductInfo{
+		Name:   "AAA",
+		Price:  10000000,
+		Weight: 1.5,
+		Stock:  100,
+		UserID: 1,
+	})
+	if err != nil {
+		t.Fatalf("There's an error when creating product data => %s",
+			err.Error())
+	}
+
+	// report product, then report it again while the report still open
+	report, err := InsertProductReport(context.Background(), DB,
+		ProductReport{SKU: p.SKU, Reason: ReportReasonCounterfeit,
+			ReporterUserID: 2})
+	if err != nil || report.Status != ReportStatusOpen {
+		t.Fatalf("Expected open report, but got %+v (%v)", report, err)
+	}
+	_, err = InsertProductReport(context.Background(), DB,
+		ProductReport{SKU: p.SKU, Reason: ReportReasonOther,
+			ReporterUserID: 2})
+	if err != ErrReportDuplicate {
+		t.Errorf("Expected error report duplicate, but got %v", err)
+	}
+	_, err = InsertProductReport(context.Background(), DB,
+		ProductReport{SKU: "SKU-X", Reason: ReportReasonOther,
+			ReporterUserID: 2})
+	if err != sql.ErrNoRows {
+		t.Errorf("Expected error no rows of unknown product, but got %v", err)
+	}
+
+	// get open reports
+	reports, err := GetProductReports(context.Background(), DB,
+		ReportStatusOpen)
+	if err != nil || len(reports) != 1 || reports[0].ProductName != "AAA" {
+		t.Errorf("Expected 1 open report of product AAA, but got %+v (%v)",
+			reports, err)
+	}
+
+	// take the product down, then it can't be published again
+	pInfo, resolved, err := ReviewProductReportsBySKU(context.Background(), DB,
+		p.SKU, ReportActionTakeDown, 3)
+	if err != nil || resolved != 1 || !pInfo.Hidden {
+		t.Errorf("Expected 1 report resolved and product hidden, "+
+			"but got %d %+v (%v)", resolved, pInfo, err)
+	}
+	_, _, err = ReviewProductReportsBySKU(context.Background(), DB, p.SKU,
+		ReportActionDismiss, 3)
+	if err != sql.ErrNoRows {
+		t.Errorf("Expected error no rows of no open reports, but got %v", err)
+	}
+	_, err = SetProductVisibilityBySKU(context.Background(), DB, p.SKU, 1,
+		false)
+	if err != ErrProductTakenDown {
+		t.Errorf("Expected error product taken down, but got %v", err)
+	}
+
+	// get reports of every status
+	reports, err = GetProductReports(context.Background(), DB, "")
+	if err != nil || len(reports) != 1 ||
+		reports[0].Status != ReportStatusTakenDown ||
+		reports[0].ReviewerUserID != 3 || reports[0].ReviewedAt == nil {
+		t.Errorf("Expected 1 report taken down by user ID 3, but got %+v (%v)",
+			reports, err)
+	}
+}
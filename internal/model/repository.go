@@ -0,0 +1,685 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// ProductRepository store of products and their stock,
+// injected into API so handlers don't depend on the database directly
+type ProductRepository interface {
+	InsertProductInfo(ctx context.Context, pInfo ProductInfo) (
+		ProductInfo, error)
+	InsertProductWithImages(ctx context.Context, pInfo ProductInfo,
+		images []ProductImage) (ProductInfo, error)
+	InsertProducts(ctx context.Context, items []ProductBatchItem) (
+		[]ProductInfo, error)
+	GetProducts(ctx context.Context, query ProductQuery) ([]Product, error)
+	GetProductBySKU(ctx context.Context, SKU string) (Product, error)
+	GetProductImageAccess(ctx context.Context, imagePath string) (
+		ProductImageAccess, error)
+	UpdateProductInfoBySKU(ctx context.Context, pInfo ProductInfo) (
+		ProductInfo, error)
+	UpdateProductWithImages(ctx context.Context, pInfo ProductInfo,
+		images []ProductImage, imageMode string, keepImageIDs []int) (
+		ProductInfo, error)
+	DeleteProductBySKU(ctx context.Context, SKU string) error
+	DeleteProductsByUserID(ctx context.Context, userID int) ([]string,
+		[]string, error)
+	RestoreProductBySKU(ctx context.Context, SKU string, userID int) (
+		ProductInfo, error)
+	AddProductImagesBySKU(ctx context.Context, SKU string, userID int,
+		images []ProductImage) (Product, error)
+	SetProductVisibilityBySKU(ctx context.Context, SKU string, userID int,
+		hidden bool) (ProductInfo, error)
+	SetPriceTiersBySKU(ctx context.Context, SKU string, userID int,
+		tiers []PriceTier) (Product, error)
+	TransferProducts(ctx context.Context, t ProductTransfer,
+		adminUserID int) ([]OwnershipTransfer, error)
+
+	LocalizeProducts(ctx context.Context, products []Product,
+		locales []string) error
+	GetProductTranslationsBySKU(ctx context.Context, SKU string) (
+		[]ProductTranslation, error)
+	SetProductTranslationBySKU(ctx context.Context, SKU string, userID int,
+		t ProductTranslation) (ProductTranslation, error)
+	DeleteProductTranslationBySKU(ctx context.Context, SKU string,
+		userID int, locale string) error
+
+	GetProductVersionsBySKU(ctx context.Context, SKU string) (
+		[]ProductVersion, error)
+	RollbackProductInfoBySKU(ctx context.Context, SKU string, version int) (
+		ProductInfo, error)
+
+	GetStockMovementsBySKU(ctx context.Context, SKU string) (
+		[]StockMovement, error)
+	GetInventorySnapshot(ctx context.Context, userID int, at time.Time) (
+		[]InventorySnapshotItem, error)
+	AdjustStocks(ctx context.Context, adjustments []StockAdjustment) (
+		[]StockAdjustment, error)
+	DecreaseStockBySKU(ctx context.Context, SKU string, qty float64,
+		audit StockMovement) (ProductInfo, error)
+	GetLowStockProducts(ctx context.Context, userID int, threshold int) (
+		[]Product, error)
+	GetProductSnapshots(ctx context.Context, SKUs []string) (
+		[]ProductSnapshot, error)
+	SetStocks(ctx context.Context, userID int, updates []StockUpdate) (
+		[]StockUpdateResult, error)
+	DecreaseStocks(ctx context.Context, decreases []StockDecrease,
+		audit StockMovement) ([]StockDecreaseResult, error)
+
+	RecordProductView(ctx context.Context, SKU string, viewerKey string,
+		window time.Duration) (bool, error)
+	GetMarketplaceStats(ctx context.Context, days int, now time.Time) (
+		MarketplaceStats, error)
+	SetProductRatingBySKU(ctx context.Context, SKU string,
+		rating ProductRating) error
+	IncrementPopularityCounters(ctx context.Context,
+		counters []PopularityCounter) (int, error)
+	CountCompletedOrder(ctx context.Context, orderID string,
+		SKUs []string) (int, error)
+
+	SaveShop(ctx context.Context, shop Shop) (Shop, error)
+	GetShopBySlug(ctx context.Context, slug string) (Shop, error)
+	GetShopByUserID(ctx context.Context, userID int) (Shop, error)
+
+	InsertProductReport(ctx context.Context, report ProductReport) (
+		ProductReport, error)
+	GetProductReports(ctx context.Context, status string) (
+		[]ProductReport, error)
+	ReviewProductReportsBySKU(ctx context.Context, SKU string, action string,
+		reviewerUserID int) (ProductInfo, int, error)
+
+	InsertProductEvent(ctx context.Context, e ProductEvent) (ProductEvent,
+		error)
+	GetProductEventsAfter(ctx context.Context, afterID int64,
+		settle time.Duration, limit int) ([]ProductEvent, error)
+	GetProductEventIDRange(ctx context.Context) (int64, int64, error)
+}
+
+// PostgresRepository product repository stored in PostgreSQL database,
+// products and product by SKU are read from read replica if any,
+// and queries failed with transient error are retried
+type PostgresRepository struct {
+	// replicaDownUntil unix nanoseconds until replica is skipped
+	// after it failed, accessed atomically
+	replicaDownUntil int64
+
+	DB      *sql.DB
+	Replica *sql.DB
+}
+
+// NewPostgresRepository create product repository
+// with PostgreSQL database connection
+func NewPostgresRepository(DB *sql.DB) *PostgresRepository {
+	return &PostgresRepository{DB: DB}
+}
+
+// InsertProductInfo insert a product info into database
+func (r *PostgresRepository) InsertProductInfo(ctx context.Context,
+	pInfo ProductInfo) (ProductInfo, error) {
+	var result ProductInfo
+	err := r.write(ctx, func(DB *sql.DB) error {
+		var err error
+		result, err = InsertProductInfo(ctx, DB, pInfo)
+		return err
+	})
+
+	return result, err
+}
+
+// InsertProductWithImages insert a product info and its saved images
+// into database in one transaction
+func (r *PostgresRepository) InsertProductWithImages(ctx context.Context,
+	pInfo ProductInfo, images []ProductImage) (ProductInfo, error) {
+	var result ProductInfo
+	err := r.write(ctx, func(DB *sql.DB) error {
+		var err error
+		result, err = InsertProductWithImages(ctx, DB, pInfo, images)
+		return err
+	})
+
+	return result, err
+}
+
+// InsertProducts insert product infos and their saved images
+// into database in one transaction
+func (r *PostgresRepository) InsertProducts(ctx context.Context,
+	items []ProductBatchItem) ([]ProductInfo, error) {
+	var result []ProductInfo
+	err := r.write(ctx, func(DB *sql.DB) error {
+		var err error
+		result, err = InsertProducts(ctx, DB, items)
+		return err
+	})
+
+	return result, err
+}
+
+// GetProducts get products from database by query
+func (r *PostgresRepository) GetProducts(ctx context.Context,
+	query ProductQuery) ([]Product, error) {
+	var products []Product
+	err := r.read(ctx, func(DB *sql.DB) error {
+		var err error
+		products, err = GetProducts(ctx, DB, query)
+		return err
+	})
+
+	return products, err
+}
+
+// GetProductBySKU get one product from database by key SKU
+func (r *PostgresRepository) GetProductBySKU(ctx context.Context,
+	SKU string) (Product, error) {
+	var p Product
+	err := r.read(ctx, func(DB *sql.DB) error {
+		var err error
+		p, err = GetProductBySKU(ctx, DB, SKU)
+		return err
+	})
+
+	return p, err
+}
+
+// GetProductImageAccess get owner and publication of product of image path
+func (r *PostgresRepository) GetProductImageAccess(ctx context.Context,
+	imagePath string) (ProductImageAccess, error) {
+	var result ProductImageAccess
+	err := r.read(ctx, func(DB *sql.DB) error {
+		var err error
+		result, err = GetProductImageAccess(ctx, DB, imagePath)
+		return err
+	})
+
+	return result, err
+}
+
+// UpdateProductInfoBySKU update product info in database by key SKU
+func (r *PostgresRepository) UpdateProductInfoBySKU(ctx context.Context,
+	pInfo ProductInfo) (ProductInfo, error) {
+	var result ProductInfo
+	err := r.write(ctx, func(DB *sql.DB) error {
+		var err error
+		result, err = UpdateProductInfoBySKU(ctx, DB, pInfo)
+		return err
+	})
+
+	return result, err
+}
+
+// UpdateProductWithImages update product info in database by key SKU,
+// remove its images not kept if kept image IDs not nil, and replace
+// or append its images with saved images if any in one transaction
+func (r *PostgresRepository) UpdateProductWithImages(ctx context.Context,
+	pInfo ProductInfo, images []ProductImage, imageMode string,
+	keepImageIDs []int) (ProductInfo, error) {
+	var result ProductInfo
+	err := r.write(ctx, func(DB *sql.DB) error {
+		var err error
+		result, err = UpdateProductWithImages(ctx, DB, pInfo, images,
+			imageMode, keepImageIDs)
+		return err
+	})
+
+	return result, err
+}
+
+// DeleteProductBySKU soft delete product in database with key SKU
+func (r *PostgresRepository) DeleteProductBySKU(ctx context.Context,
+	SKU string) error {
+	return r.write(ctx, func(DB *sql.DB) error {
+		return DeleteProductBySKU(ctx, DB, SKU)
+	})
+}
+
+// DeleteProductsByUserID permanently delete all products and other data
+// of user ID, anonymizing the user's stock movements and product reports,
+// returning SKUs and image paths of the deleted products and shop banner
+func (r *PostgresRepository) DeleteProductsByUserID(ctx context.Context,
+	userID int) ([]string, []string, error) {
+	var SKUs, imagePaths []string
+	err := r.write(ctx, func(DB *sql.DB) error {
+		var err error
+		SKUs, imagePaths, err = DeleteProductsByUserID(ctx, DB, userID)
+		return err
+	})
+
+	return SKUs, imagePaths, err
+}
+
+// RestoreProductBySKU restore soft deleted product in database with key SKU
+func (r *PostgresRepository) RestoreProductBySKU(ctx context.Context,
+	SKU string, userID int) (ProductInfo, error) {
+	var result ProductInfo
+	err := r.write(ctx, func(DB *sql.DB) error {
+		var err error
+		result, err = RestoreProductBySKU(ctx, DB, SKU, userID)
+		return err
+	})
+
+	return result, err
+}
+
+// AddProductImagesBySKU append saved images after images of product
+// of user in database with key SKU
+func (r *PostgresRepository) AddProductImagesBySKU(ctx context.Context,
+	SKU string, userID int, images []ProductImage) (Product, error) {
+	var result Product
+	err := r.write(ctx, func(DB *sql.DB) error {
+		var err error
+		result, err = AddProductImagesBySKU(ctx, DB, SKU, userID, images)
+		return err
+	})
+
+	return result, err
+}
+
+// SetProductVisibilityBySKU hide or show product of user in database
+// by key SKU
+func (r *PostgresRepository) SetProductVisibilityBySKU(ctx context.Context,
+	SKU string, userID int, hidden bool) (ProductInfo, error) {
+	var result ProductInfo
+	err := r.write(ctx, func(DB *sql.DB) error {
+		var err error
+		result, err = SetProductVisibilityBySKU(ctx, DB, SKU, userID, hidden)
+		return err
+	})
+
+	return result, err
+}
+
+// SetPriceTiersBySKU replace price tiers of product by SKU owned by user ID
+func (r *PostgresRepository) SetPriceTiersBySKU(ctx context.Context,
+	SKU string, userID int, tiers []PriceTier) (Product, error) {
+	var result Product
+	err := r.write(ctx, func(DB *sql.DB) error {
+		var err error
+		result, err = SetPriceTiersBySKU(ctx, DB, SKU, userID, tiers)
+		return err
+	})
+
+	return result, err
+}
+
+// TransferProducts transfer product of SKU, or all products,
+// of a user to another user
+func (r *PostgresRepository) TransferProducts(ctx context.Context,
+	t ProductTransfer, adminUserID int) ([]OwnershipTransfer, error) {
+	var result []OwnershipTransfer
+	err := r.write(ctx, func(DB *sql.DB) error {
+		var err error
+		result, err = TransferProducts(ctx, DB, t, adminUserID)
+		return err
+	})
+
+	return result, err
+}
+
+// LocalizeProducts localize products into the first available locale
+// of locales by preference
+func (r *PostgresRepository) LocalizeProducts(ctx context.Context,
+	products []Product, locales []string) error {
+	return r.read(ctx, func(DB *sql.DB) error {
+		return LocalizeProducts(ctx, DB, products, locales)
+	})
+}
+
+// GetProductTranslationsBySKU get translations of product by SKU
+func (r *PostgresRepository) GetProductTranslationsBySKU(ctx context.Context,
+	SKU string) ([]ProductTranslation, error) {
+	var result []ProductTranslation
+	err := r.read(ctx, func(DB *sql.DB) error {
+		var err error
+		result, err = GetProductTranslationsBySKU(ctx, DB, SKU)
+		return err
+	})
+
+	return result, err
+}
+
+// SetProductTranslationBySKU insert or replace translation of product
+// by SKU owned by user ID
+func (r *PostgresRepository) SetProductTranslationBySKU(ctx context.Context,
+	SKU string, userID int, t ProductTranslation) (ProductTranslation, error) {
+	var result ProductTranslation
+	err := r.write(ctx, func(DB *sql.DB) error {
+		var err error
+		result, err = SetProductTranslationBySKU(ctx, DB, SKU, userID, t)
+		return err
+	})
+
+	return result, err
+}
+
+// DeleteProductTranslationBySKU delete translation in locale of product
+// by SKU owned by user ID
+func (r *PostgresRepository) DeleteProductTranslationBySKU(
+	ctx context.Context, SKU string, userID int, locale string) error {
+	return r.write(ctx, func(DB *sql.DB) error {
+		return DeleteProductTranslationBySKU(ctx, DB, SKU, userID, locale)
+	})
+}
+
+// GetProductVersionsBySKU get all versions of product info by SKU
+func (r *PostgresRepository) GetProductVersionsBySKU(ctx context.Context,
+	SKU string) ([]ProductVersion, error) {
+	var result []ProductVersion
+	err := r.read(ctx, func(DB *sql.DB) error {
+		var err error
+		result, err = GetProductVersionsBySKU(ctx, DB, SKU)
+		return err
+	})
+
+	return result, err
+}
+
+// RollbackProductInfoBySKU roll back product by SKU to a previous version
+func (r *PostgresRepository) RollbackProductInfoBySKU(ctx context.Context,
+	SKU string, version int) (ProductInfo, error) {
+	var result ProductInfo
+	err := r.write(ctx, func(DB *sql.DB) error {
+		var err error
+		result, err = RollbackProductInfoBySKU(ctx, DB, SKU, version)
+		return err
+	})
+
+	return result, err
+}
+
+// GetStockMovementsBySKU get stock movement ledger of a product by SKU
+func (r *PostgresRepository) GetStockMovementsBySKU(ctx context.Context,
+	SKU string) ([]StockMovement, error) {
+	var result []StockMovement
+	err := r.read(ctx, func(DB *sql.DB) error {
+		var err error
+		result, err = GetStockMovementsBySKU(ctx, DB, SKU)
+		return err
+	})
+
+	return result, err
+}
+
+// GetInventorySnapshot get stock level of all products, or products
+// of user if userID is not zero, at a past instant
+func (r *PostgresRepository) GetInventorySnapshot(ctx context.Context,
+	userID int, at time.Time) ([]InventorySnapshotItem, error) {
+	var result []InventorySnapshotItem
+	err := r.read(ctx, func(DB *sql.DB) error {
+		var err error
+		result, err = GetInventorySnapshot(ctx, DB, userID, at)
+		return err
+	})
+
+	return result, err
+}
+
+// AdjustStocks apply stock adjustments in one transaction
+func (r *PostgresRepository) AdjustStocks(ctx context.Context,
+	adjustments []StockAdjustment) ([]StockAdjustment, error) {
+	var result []StockAdjustment
+	err := r.write(ctx, func(DB *sql.DB) error {
+		var err error
+		result, err = AdjustStocks(ctx, DB, adjustments)
+		return err
+	})
+
+	return result, err
+}
+
+// DecreaseStockBySKU decrease product stock by SKU atomically
+func (r *PostgresRepository) DecreaseStockBySKU(ctx context.Context,
+	SKU string, qty float64, audit StockMovement) (ProductInfo, error) {
+	var result ProductInfo
+	err := r.write(ctx, func(DB *sql.DB) error {
+		var err error
+		result, err = DecreaseStockBySKU(ctx, DB, SKU, qty, audit)
+		return err
+	})
+
+	return result, err
+}
+
+// GetLowStockProducts get products of a user with stock at or below
+// threshold
+func (r *PostgresRepository) GetLowStockProducts(ctx context.Context,
+	userID int, threshold int) ([]Product, error) {
+	var result []Product
+	err := r.read(ctx, func(DB *sql.DB) error {
+		var err error
+		result, err = GetLowStockProducts(ctx, DB, userID, threshold)
+		return err
+	})
+
+	return result, err
+}
+
+// GetProductSnapshots get current effective price and stock
+// of products by SKUs
+func (r *PostgresRepository) GetProductSnapshots(ctx context.Context,
+	SKUs []string) ([]ProductSnapshot, error) {
+	var result []ProductSnapshot
+	err := r.read(ctx, func(DB *sql.DB) error {
+		var err error
+		result, err = GetProductSnapshots(ctx, DB, SKUs)
+		return err
+	})
+
+	return result, err
+}
+
+// SetStocks set stock of many products of a user in one transaction
+func (r *PostgresRepository) SetStocks(ctx context.Context, userID int,
+	updates []StockUpdate) ([]StockUpdateResult, error) {
+	var result []StockUpdateResult
+	err := r.write(ctx, func(DB *sql.DB) error {
+		var err error
+		result, err = SetStocks(ctx, DB, userID, updates)
+		return err
+	})
+
+	return result, err
+}
+
+// DecreaseStocks decrease stock of many products in one transaction
+func (r *PostgresRepository) DecreaseStocks(ctx context.Context,
+	decreases []StockDecrease, audit StockMovement) (
+	[]StockDecreaseResult, error) {
+	var result []StockDecreaseResult
+	err := r.write(ctx, func(DB *sql.DB) error {
+		var err error
+		result, err = DecreaseStocks(ctx, DB, decreases, audit)
+		return err
+	})
+
+	return result, err
+}
+
+// RecordProductView record a view of product by SKU from a viewer,
+// counted at most once a window
+func (r *PostgresRepository) RecordProductView(ctx context.Context,
+	SKU string, viewerKey string, window time.Duration) (bool, error) {
+	var result bool
+	err := r.write(ctx, func(DB *sql.DB) error {
+		var err error
+		result, err = RecordProductView(ctx, DB, SKU, viewerKey, window)
+		return err
+	})
+
+	return result, err
+}
+
+// GetMarketplaceStats get marketplace-wide aggregates of products
+func (r *PostgresRepository) GetMarketplaceStats(ctx context.Context,
+	days int, now time.Time) (MarketplaceStats, error) {
+	var result MarketplaceStats
+	err := r.read(ctx, func(DB *sql.DB) error {
+		var err error
+		result, err = GetMarketplaceStats(ctx, DB, days, now)
+		return err
+	})
+
+	return result, err
+}
+
+// SetProductRatingBySKU set rating aggregate of product by SKU
+func (r *PostgresRepository) SetProductRatingBySKU(ctx context.Context,
+	SKU string, rating ProductRating) error {
+	return r.write(ctx, func(DB *sql.DB) error {
+		return SetProductRatingBySKU(ctx, DB, SKU, rating)
+	})
+}
+
+// IncrementPopularityCounters increment wishlist and order counters
+// of products by SKU
+func (r *PostgresRepository) IncrementPopularityCounters(ctx context.Context,
+	counters []PopularityCounter) (int, error) {
+	var result int
+	err := r.write(ctx, func(DB *sql.DB) error {
+		var err error
+		result, err = IncrementPopularityCounters(ctx, DB, counters)
+		return err
+	})
+
+	return result, err
+}
+
+// CountCompletedOrder increment completed order counter of products
+// by SKU once per order
+func (r *PostgresRepository) CountCompletedOrder(ctx context.Context,
+	orderID string, SKUs []string) (int, error) {
+	var result int
+	err := r.write(ctx, func(DB *sql.DB) error {
+		var err error
+		result, err = CountCompletedOrder(ctx, DB, orderID, SKUs)
+		return err
+	})
+
+	return result, err
+}
+
+// SaveShop insert shop of seller, or update it if the seller already
+// has a shop
+func (r *PostgresRepository) SaveShop(ctx context.Context, shop Shop) (
+	Shop, error) {
+	var result Shop
+	err := r.write(ctx, func(DB *sql.DB) error {
+		var err error
+		result, err = SaveShop(ctx, DB, shop)
+		return err
+	})
+
+	return result, err
+}
+
+// GetShopBySlug get shop by slug
+func (r *PostgresRepository) GetShopBySlug(ctx context.Context,
+	slug string) (Shop, error) {
+	var result Shop
+	err := r.read(ctx, func(DB *sql.DB) error {
+		var err error
+		result, err = GetShopBySlug(ctx, DB, slug)
+		return err
+	})
+
+	return result, err
+}
+
+// GetShopByUserID get shop of seller of user ID
+func (r *PostgresRepository) GetShopByUserID(ctx context.Context,
+	userID int) (Shop, error) {
+	var result Shop
+	err := r.read(ctx, func(DB *sql.DB) error {
+		var err error
+		result, err = GetShopByUserID(ctx, DB, userID)
+		return err
+	})
+
+	return result, err
+}
+
+// InsertProductReport insert report of published product by SKU
+func (r *PostgresRepository) InsertProductReport(ctx context.Context,
+	report ProductReport) (ProductReport, error) {
+	var result ProductReport
+	err := r.write(ctx, func(DB *sql.DB) error {
+		var err error
+		result, err = InsertProductReport(ctx, DB, report)
+		return err
+	})
+
+	return result, err
+}
+
+// GetProductReports get product reports of status, or of every status
+// if status is empty
+func (r *PostgresRepository) GetProductReports(ctx context.Context,
+	status string) ([]ProductReport, error) {
+	var result []ProductReport
+	err := r.read(ctx, func(DB *sql.DB) error {
+		var err error
+		result, err = GetProductReports(ctx, DB, status)
+		return err
+	})
+
+	return result, err
+}
+
+// ReviewProductReportsBySKU resolve open reports of product by SKU
+// by action of reviewer user ID
+func (r *PostgresRepository) ReviewProductReportsBySKU(ctx context.Context,
+	SKU string, action string, reviewerUserID int) (ProductInfo, int, error) {
+	var result ProductInfo
+	var resolved int
+	err := r.write(ctx, func(DB *sql.DB) error {
+		var err error
+		result, resolved, err = ReviewProductReportsBySKU(ctx, DB, SKU, action,
+			reviewerUserID)
+		return err
+	})
+
+	return result, resolved, err
+}
+
+// InsertProductEvent insert product event into event journal
+func (r *PostgresRepository) InsertProductEvent(ctx context.Context,
+	e ProductEvent) (ProductEvent, error) {
+	var result ProductEvent
+	err := r.write(ctx, func(DB *sql.DB) error {
+		var err error
+		result, err = InsertProductEvent(ctx, DB, e)
+		return err
+	})
+
+	return result, err
+}
+
+// GetProductEventsAfter get product events after ID recorded at least
+// settle ago, always read from primary since read replica may lag
+// behind the settle duration
+func (r *PostgresRepository) GetProductEventsAfter(ctx context.Context,
+	afterID int64, settle time.Duration, limit int) ([]ProductEvent, error) {
+	var result []ProductEvent
+	err := r.write(ctx, func(DB *sql.DB) error {
+		var err error
+		result, err = GetProductEventsAfter(ctx, DB, afterID, settle, limit)
+		return err
+	})
+
+	return result, err
+}
+
+// GetProductEventIDRange get ID of the first and the last product event
+// in event journal, read from primary like GetProductEventsAfter
+func (r *PostgresRepository) GetProductEventIDRange(ctx context.Context) (
+	int64, int64, error) {
+	var first, last int64
+	err := r.write(ctx, func(DB *sql.DB) error {
+		var err error
+		first, last, err = GetProductEventIDRange(ctx, DB)
+		return err
+	})
+
+	return first, last, err
+}
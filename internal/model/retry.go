@@ -0,0 +1,91 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// attempts and first backoff of query failed with transient error,
+// backoff is doubled every retry
+var (
+	RetryAttempts = 3
+	RetryBackoff  = 50 * time.Millisecond
+)
+
+// IsRetryableError check if err is transient database error after which
+// the failed query was surely not applied, e.g. serialization failure,
+// deadlock, or database not accepting connection yet
+func IsRetryableError(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+
+	switch pqErr.Code {
+	case "40001", // serialization_failure
+		"40P01", // deadlock_detected
+		"57P03", // cannot_connect_now
+		"08001", // sqlclient_unable_to_establish_sqlconnection
+		"08004": // sqlserver_rejected_establishment_of_sqlconnection
+		return true
+	}
+
+	return false
+}
+
+// isConnectionError check if err is lost database connection (e.g. reset
+// by database failover), after which a write may or may not be applied
+func isConnectionError(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code.Class() == "08" || pqErr.Code == "57P01" ||
+			pqErr.Code == "57P02"
+	}
+
+	var netErr net.Error
+	return errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.As(err, &netErr)
+}
+
+// retry run query, then retry it with backoff while it failed with
+// transient error, lost connection is only retried if query is read only
+// since a write may be applied before the connection lost
+func retry(ctx context.Context, readOnly bool, query func() error) error {
+	backoff := RetryBackoff
+	for attempt := 1; ; attempt++ {
+		err := query()
+		if err == nil || attempt >= RetryAttempts || ctx.Err() != nil ||
+			!(IsRetryableError(err) || (readOnly && isConnectionError(err))) {
+			return err
+		}
+
+		log.Printf("There's a transient database error, retrying in %s "+
+			"=> %s", backoff, err.Error())
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// write run write query on primary database,
+// retried while it failed with transient error
+func (r *PostgresRepository) write(ctx context.Context,
+	query func(DB *sql.DB) error) error {
+	return retry(ctx, false, func() error {
+		return query(r.DB)
+	})
+}
@@ -0,0 +1,105 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"context"
+	"errors"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// TestRetry test retry
+func TestRetry(t *testing.T) {
+	backoff := RetryBackoff
+	RetryBackoff = time.Millisecond
+	defer func() { RetryBackoff = backoff }()
+
+	// create testing table
+	testTable := []struct {
+		TestName         string
+		ReadOnly         bool
+		Errors           []error
+		ExpectedAttempts int
+		ExpectedErr      bool
+	}{
+		{
+			TestName:         "Succeed",
+			Errors:           []error{nil},
+			ExpectedAttempts: 1,
+		},
+		{
+			TestName: "Serialization Failure",
+			Errors: []error{&pq.Error{Code: "40001"},
+				&pq.Error{Code: "40P01"}, nil},
+			ExpectedAttempts: 3,
+		},
+		{
+			TestName: "Too Many Failures",
+			Errors: []error{&pq.Error{Code: "40001"},
+				&pq.Error{Code: "40001"}, &pq.Error{Code: "40001"}, nil},
+			ExpectedAttempts: 3,
+			ExpectedErr:      true,
+		},
+		{
+			TestName:         "Not Transient",
+			Errors:           []error{&pq.Error{Code: "23505"}, nil},
+			ExpectedAttempts: 1,
+			ExpectedErr:      true,
+		},
+		{
+			TestName:         "Connection Reset Write",
+			Errors:           []error{syscall.ECONNRESET, nil},
+			ExpectedAttempts: 1,
+			ExpectedErr:      true,
+		},
+		{
+			TestName:         "Connection Reset Read",
+			ReadOnly:         true,
+			Errors:           []error{syscall.ECONNRESET, nil},
+			ExpectedAttempts: 2,
+		},
+		{
+			TestName:         "Admin Shutdown Read",
+			ReadOnly:         true,
+			Errors:           []error{&pq.Error{Code: "57P01"}, nil},
+			ExpectedAttempts: 2,
+		},
+	}
+
+	// do the test
+	for _, test := range testTable {
+		attempts := 0
+		err := retry(context.Background(), test.ReadOnly, func() error {
+			attempts++
+			return test.Errors[attempts-1]
+		})
+
+		if attempts != test.ExpectedAttempts {
+			t.Errorf("[%s] Expected %d attempts, but got %d",
+				test.TestName, test.ExpectedAttempts, attempts)
+		}
+		if (err != nil) != test.ExpectedErr {
+			t.Errorf("[%s] Expected error %t, but got %v",
+				test.TestName, test.ExpectedErr, err)
+		}
+	}
+
+	// canceled context is not retried
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	attempts := 0
+	err := retry(ctx, true, func() error {
+		attempts++
+		return errors.New("canceled")
+	})
+	if attempts != 1 || err == nil {
+		t.Errorf("Expected 1 attempt with error, but got %d attempts (error %v)",
+			attempts, err)
+	}
+}
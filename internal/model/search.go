@@ -0,0 +1,161 @@
+package model
+
+import (
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/reyhanfikridz/ecom-product-service/internal/config"
+	"github.com/reyhanfikridz/ecom-product-service/internal/richtext"
+)
+
+// maximum search terms a search is expanded into by synonyms
+const maxSearchTerms = 16
+
+// maximum bytes of description kept around the first match
+// on each side of description highlight snippet
+const highlightContext = 60
+
+// SearchHighlight contain snippet of product field matching search,
+// HTML escaped with matches wrapped in <mark> tag
+type SearchHighlight struct {
+	Field   string `json:"field"`
+	Snippet string `json:"snippet"`
+}
+
+// ExpandSearchSynonyms get search terms matched for a search, the search
+// itself first followed by the search with its words replaced by their
+// synonyms (case insensitive), at most maxSearchTerms terms
+func ExpandSearchSynonyms(search string,
+	synonyms map[string][]string) []string {
+	terms := []string{search}
+	if len(synonyms) == 0 {
+		return terms
+	}
+
+	// expand each word into itself and its synonyms
+	expanded := [][]string{{}}
+	for _, word := range strings.Fields(search) {
+		alternatives := append([]string{word},
+			synonyms[strings.ToLower(word)]...)
+
+		next := [][]string{}
+		for _, words := range expanded {
+			for _, alternative := range alternatives {
+				if len(next) == maxSearchTerms {
+					break
+				}
+
+				nextWords := append(append([]string{}, words...), alternative)
+				next = append(next, nextWords)
+			}
+		}
+		expanded = next
+	}
+
+	// the first expanded term is the search itself
+	for _, words := range expanded[1:] {
+		terms = append(terms, strings.Join(words, " "))
+	}
+
+	return terms
+}
+
+// HighlightProducts set highlights of where the search or its synonyms
+// matched name and description of products, products only matched by
+// fuzzy search have no highlights
+func HighlightProducts(products []Product, search string) {
+	terms := ExpandSearchSynonyms(search, config.SearchSynonyms)
+
+	// match longer terms first so they're preferred over their prefixes
+	sort.SliceStable(terms, func(i, j int) bool {
+		return len(terms[i]) > len(terms[j])
+	})
+	patterns := []string{}
+	for _, term := range terms {
+		if strings.TrimSpace(term) != "" {
+			patterns = append(patterns, regexp.QuoteMeta(term))
+		}
+	}
+	if len(patterns) == 0 {
+		return
+	}
+	re := regexp.MustCompile(`(?i)` + strings.Join(patterns, `|`))
+
+	for i := range products {
+		pInfo := products[i].ProductInfo
+		highlights := []SearchHighlight{}
+
+		if snippet, ok := getHighlightSnippet(re, pInfo.Name, 0); ok {
+			highlights = append(highlights,
+				SearchHighlight{Field: "name", Snippet: snippet})
+		}
+
+		description := richtext.PlainText(pInfo.DescriptionFormat,
+			pInfo.Description)
+		if snippet, ok := getHighlightSnippet(re, description,
+			highlightContext); ok {
+			highlights = append(highlights,
+				SearchHighlight{Field: "description", Snippet: snippet})
+		}
+
+		products[i].Highlights = highlights
+	}
+}
+
+// getHighlightSnippet get HTML escaped snippet of text with matches
+// wrapped in <mark> tag, the whole text if context is 0, otherwise
+// at most context bytes around the first match cut on word boundaries
+// with ellipsis marking cut text, false if there's no match
+func getHighlightSnippet(re *regexp.Regexp, text string,
+	context int) (string, bool) {
+	first := re.FindStringIndex(text)
+	if first == nil {
+		return "", false
+	}
+
+	// cut text around the first match on word, or else rune, boundaries
+	start, end := 0, len(text)
+	if context > 0 {
+		if first[0]-context > start {
+			start = first[0] - context
+			if i := strings.IndexByte(text[start:first[0]], ' '); i >= 0 {
+				start += i + 1
+			}
+			for start < first[0] && !utf8.RuneStart(text[start]) {
+				start++
+			}
+		}
+		if first[1]+context < end {
+			end = first[1] + context
+			if i := strings.LastIndexByte(text[first[1]:end], ' '); i > 0 {
+				end = first[1] + i
+			}
+			for end > first[1] && !utf8.RuneStart(text[end]) {
+				end--
+			}
+		}
+	}
+	cutEnd := end < len(text)
+	text = text[start:end]
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	last := 0
+	for _, match := range re.FindAllStringIndex(text, -1) {
+		b.WriteString(html.EscapeString(text[last:match[0]]))
+		b.WriteString("<mark>" + html.EscapeString(text[match[0]:match[1]]) +
+			"</mark>")
+		last = match[1]
+	}
+	b.WriteString(html.EscapeString(text[last:]))
+	if cutEnd {
+		b.WriteString("…")
+	}
+
+	return b.String(), true
+}
@@ -0,0 +1,144 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestExpandSearchSynonyms test ExpandSearchSynonyms
+func TestExpandSearchSynonyms(t *testing.T) {
+	synonyms := map[string][]string{
+		"hp": {"handphone", "smartphone"},
+		"tv": {"televisi"},
+	}
+
+	// create testing table
+	testTable := []struct {
+		TestName      string
+		Search        string
+		Synonyms      map[string][]string
+		ExpectedTerms []string
+	}{
+		{
+			TestName:      "No synonyms",
+			Search:        "hp samsung",
+			ExpectedTerms: []string{"hp samsung"},
+		},
+		{
+			TestName:      "No word with synonyms",
+			Search:        "laptop",
+			Synonyms:      synonyms,
+			ExpectedTerms: []string{"laptop"},
+		},
+		{
+			TestName: "Word with synonyms",
+			Search:   "HP samsung",
+			Synonyms: synonyms,
+			ExpectedTerms: []string{
+				"HP samsung", "handphone samsung", "smartphone samsung",
+			},
+		},
+		{
+			TestName: "Words with synonyms",
+			Search:   "hp tv",
+			Synonyms: synonyms,
+			ExpectedTerms: []string{
+				"hp tv", "hp televisi", "handphone tv", "handphone televisi",
+				"smartphone tv", "smartphone televisi",
+			},
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		terms := ExpandSearchSynonyms(test.Search, test.Synonyms)
+		if !reflect.DeepEqual(terms, test.ExpectedTerms) {
+			t.Errorf("[%s] Expected terms %q, but got %q", test.TestName,
+				test.ExpectedTerms, terms)
+		}
+	}
+
+	// search terms are limited
+	terms := ExpandSearchSynonyms("hp hp hp hp", synonyms)
+	if len(terms) != maxSearchTerms {
+		t.Errorf("Expected %d terms, but got %d", maxSearchTerms, len(terms))
+	}
+}
+
+// TestHighlightProducts test HighlightProducts
+func TestHighlightProducts(t *testing.T) {
+	products := []Product{
+		{
+			ProductInfo: ProductInfo{
+				Name: "Laptop <Pro> 14",
+				Description: "A light laptop for work. " +
+					strings.Repeat("Long battery life. ", 10) +
+					"Comes with a LAPTOP sleeve.",
+				DescriptionFormat: "plain",
+			},
+		},
+		{
+			ProductInfo: ProductInfo{
+				Name:              "Sleeve",
+				Description:       "<p>Fits any <b>laptop</b></p>",
+				DescriptionFormat: "html",
+			},
+		},
+		{
+			ProductInfo: ProductInfo{
+				Name:              "Mouse",
+				Description:       "Wireless mouse",
+				DescriptionFormat: "plain",
+			},
+		},
+	}
+	HighlightProducts(products, "laptop")
+
+	// create testing table
+	testTable := []struct {
+		TestName           string
+		Product            Product
+		ExpectedHighlights []SearchHighlight
+	}{
+		{
+			TestName: "Name and description matched",
+			Product:  products[0],
+			ExpectedHighlights: []SearchHighlight{
+				{Field: "name", Snippet: "<mark>Laptop</mark> &lt;Pro&gt; 14"},
+				{
+					Field: "description",
+					Snippet: "A light <mark>laptop</mark> for work. " +
+						"Long battery life. Long battery life. " +
+						"Long…",
+				},
+			},
+		},
+		{
+			TestName: "HTML description matched",
+			Product:  products[1],
+			ExpectedHighlights: []SearchHighlight{
+				{Field: "description", Snippet: "Fits any <mark>laptop</mark>"},
+			},
+		},
+		{
+			TestName:           "Not matched",
+			Product:            products[2],
+			ExpectedHighlights: []SearchHighlight{},
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		if !reflect.DeepEqual(test.Product.Highlights,
+			test.ExpectedHighlights) {
+			t.Errorf("[%s] Expected highlights %+v, but got %+v",
+				test.TestName, test.ExpectedHighlights,
+				test.Product.Highlights)
+		}
+	}
+}
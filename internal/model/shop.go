@@ -0,0 +1,119 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Shop contain shop profile of a seller, shown on buyer shop pages
+// without getting the seller from account service
+type Shop struct {
+	UserID          int       `json:"user_id" form:"-"`
+	Slug            string    `json:"slug" form:"slug"`
+	DisplayName     string    `json:"display_name" form:"display_name"`
+	BannerImagePath string    `json:"banner_image_path" form:"-"`
+	BannerImageURL  string    `json:"banner_image_url" form:"-"`
+	CreatedAt       time.Time `json:"created_at" form:"-"`
+	UpdatedAt       time.Time `json:"updated_at" form:"-"`
+}
+
+// ErrShopSlugTaken error of shop slug already used by shop
+// of another seller
+var ErrShopSlugTaken = errors.New("shop slug already taken")
+
+// shopColumns columns of shop selected into Shop by scanShop
+const shopColumns = `account_user_id, slug, display_name,
+	banner_image_path, created_at, updated_at`
+
+// scanShop scan row of shopColumns into shop
+func scanShop(row *sql.Row) (Shop, error) {
+	shop := Shop{}
+	err := row.Scan(&shop.UserID, &shop.Slug, &shop.DisplayName,
+		&shop.BannerImagePath, &shop.CreatedAt, &shop.UpdatedAt)
+
+	return shop, err
+}
+
+// SaveShop insert shop of seller, or update it if the seller already
+// has a shop, banner image is kept if banner image path is empty
+//
+// return ErrShopSlugTaken if slug is used by shop of another seller
+func SaveShop(ctx context.Context, DB *sql.DB, shop Shop) (Shop, error) {
+	saved, err := scanShop(DB.QueryRowContext(ctx, `
+		INSERT INTO product_shop(account_user_id, slug, display_name,
+			banner_image_path)
+		VALUES($1,$2,$3,$4)
+		ON CONFLICT (account_user_id) DO UPDATE
+		SET slug = EXCLUDED.slug,
+			display_name = EXCLUDED.display_name,
+			banner_image_path = COALESCE(
+				NULLIF(EXCLUDED.banner_image_path, ''),
+				product_shop.banner_image_path),
+			updated_at = NOW()
+		RETURNING `+shopColumns,
+		shop.UserID, shop.Slug, shop.DisplayName, shop.BannerImagePath))
+	if isShopSlugConflict(err) {
+		return saved, ErrShopSlugTaken
+	}
+
+	return saved, err
+}
+
+// isShopSlugConflict check error is unique violation of shop slug
+func isShopSlugConflict(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" &&
+		pqErr.Constraint == "product_shop_slug_key"
+}
+
+// GetShopBySlug get shop by slug, sql.ErrNoRows if shop not found
+func GetShopBySlug(ctx context.Context, DB *sql.DB, slug string) (Shop,
+	error) {
+	return scanShop(DB.QueryRowContext(ctx, `
+		SELECT `+shopColumns+`
+		FROM product_shop
+		WHERE slug = $1`,
+		slug))
+}
+
+// GetShopByUserID get shop of seller of user ID,
+// sql.ErrNoRows if the seller has no shop
+func GetShopByUserID(ctx context.Context, DB *sql.DB, userID int) (Shop,
+	error) {
+	return scanShop(DB.QueryRowContext(ctx, `
+		SELECT `+shopColumns+`
+		FROM product_shop
+		WHERE account_user_id = $1`,
+		userID))
+}
+
+// SaveShopBanner save uploaded shop banner image file into media folder,
+// the banner isn't fit into product image aspect ratio nor watermarked
+//
+// return ErrProductImageInvalid if the banner isn't decodable image
+func SaveShopBanner(fileHeader *multipart.FileHeader) (ProductImage,
+	error) {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return ProductImage{}, err
+	}
+	defer file.Close()
+
+	banner, err := saveImageFile(fileHeader.Filename, file, false)
+	if err != nil {
+		return banner, err
+	}
+	if banner.Width == 0 || banner.Height == 0 {
+		RemoveProductImageFile(banner.ImagePath)
+		return ProductImage{}, fmt.Errorf("%w, '%s' isn't decodable image",
+			ErrProductImageInvalid, fileHeader.Filename)
+	}
+
+	return banner, nil
+}
@@ -0,0 +1,183 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestSaveShop test SaveShop, GetShopBySlug, and GetShopByUserID
+func TestSaveShop(t *testing.T) {
+	// get testing DB connection
+	DB, err := getTestDBConnection()
+	if err != nil {
+		t.Fatalf("There's an error when initialize "+
+			"testing database connection => %s", err.Error())
+	}
+	_, err = DB.Exec("TRUNCATE product_shop RESTART IDENTITY")
+	if err != nil {
+		t.Fatalf("There's an error when truncating table product_shop => %s",
+			err.Error())
+	}
+
+	// insert shop, then update it keeping its banner
+	_, err = SaveShop(context.Background(), DB, Shop{UserID: 1,
+		Slug: "toko-budi", DisplayName: "Toko Budi",
+		BannerImagePath: "product-image/banner.png"})
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+	shop, err := SaveShop(context.Background(), DB, Shop{UserID: 1,
+		Slug: "budi-store", DisplayName: "Budi Store"})
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+	if shop.Slug != "budi-store" || shop.DisplayName != "Budi Store" ||
+		shop.BannerImagePath != "product-image/banner.png" {
+		t.Errorf("Expected shop updated keeping banner, but got %+v", shop)
+	}
+
+	// slug of another seller's shop can't be used
+	_, err = SaveShop(context.Background(), DB, Shop{UserID: 2,
+		Slug: "budi-store", DisplayName: "Ani Store"})
+	if err != ErrShopSlugTaken {
+		t.Errorf("Expected error shop slug taken, but got %v", err)
+	}
+
+	// get shop by slug and user ID
+	shop, err = GetShopBySlug(context.Background(), DB, "budi-store")
+	if err != nil || shop.UserID != 1 {
+		t.Errorf("Expected shop of user ID 1, but got %+v (%v)", shop, err)
+	}
+	shop, err = GetShopByUserID(context.Background(), DB, 1)
+	if err != nil || shop.Slug != "budi-store" {
+		t.Errorf("Expected shop budi-store, but got %+v (%v)", shop, err)
+	}
+	_, err = GetShopBySlug(context.Background(), DB, "toko-budi")
+	if err != sql.ErrNoRows {
+		t.Errorf("Expected error no rows of old slug, but got %v", err)
+	}
+
+	// banner is accessible as public media and used by media cleanup
+	access, err := GetProductImageAccess(context.Background(), DB,
+		"product-image/banner.png")
+	if err != nil || access.UserID != 1 || !access.Published {
+		t.Errorf("Expected public banner of user ID 1, but got %+v (%v)",
+			access, err)
+	}
+	paths, err := GetProductImagePaths(context.Background(), DB)
+	if err != nil || !paths["product-image/banner.png"] {
+		t.Errorf("Expected banner in used image paths, but got %v (%v)",
+			paths, err)
+	}
+}
+
+// TestDeleteProductsByUserIDShop test DeleteProductsByUserID deleting
+// shop of the user and returning its banner path, and erasing the user
+// from product reports and views of other user's products
+func TestDeleteProductsByUserIDShop(t *testing.T) {
+	// get testing DB connection
+	DB, err := getTestDBConnection()
+	if err != nil {
+		t.Fatalf("There's an error when initialize "+
+			"testing database connection => %s", err.Error())
+	}
+	_, err = DB.Exec("TRUNCATE product_shop RESTART IDENTITY")
+	if err != nil {
+		t.Fatalf("There's an error when truncating table product_shop => %s",
+			err.Error())
+	}
+
+	for _, shop := range []Shop{
+		{UserID: 1, Slug: "toko-budi", DisplayName: "Toko Budi",
+			BannerImagePath: "product-image/banner.png"},
+		{UserID: 2, Slug: "toko-ani", DisplayName: "Toko Ani"},
+	} {
+		_, err = SaveShop(context.Background(), DB, shop)
+		if err != nil {
+			t.Fatalf("Expected error nil, but got error => %s", err.Error())
+		}
+	}
+
+	// product of user ID 2 reported and viewed by user ID 1 and 3
+	_, err = DB.Exec(`TRUNCATE product_productinfo, product_productreport
+		RESTART IDENTITY CASCADE`)
+	if err != nil {
+		t.Fatalf("There's an error when truncating tables => %s", err.Error())
+	}
+	p, err := InsertProductInfo(context.Background(), DB, ProductInfo{
+		Name: "AAA", Price: 1000, Weight: 1, Stock: 10, UserID: 2,
+	})
+	if err != nil {
+		t.Fatalf("There's an error when creating product data => %s",
+			err.Error())
+	}
+	for _, reporterID := range []int{1, 3} {
+		_, err = InsertProductReport(context.Background(), DB, ProductReport{
+			SKU: p.SKU, Reason: ReportReasonOther, ReporterUserID: reporterID,
+		})
+		if err != nil {
+			t.Fatalf("Expected error nil, but got error => %s", err.Error())
+		}
+		_, err = RecordProductView(context.Background(), DB, p.SKU,
+			fmt.Sprintf("user:%d", reporterID), time.Hour)
+		if err != nil {
+			t.Fatalf("Expected error nil, but got error => %s", err.Error())
+		}
+	}
+
+	_, imagePaths, err := DeleteProductsByUserID(context.Background(), DB, 1)
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+	if len(imagePaths) != 1 || imagePaths[0] != "product-image/banner.png" {
+		t.Errorf("Expected banner path returned, but got %v", imagePaths)
+	}
+
+	// shop of the user deleted, its banner no longer served
+	_, err = GetShopByUserID(context.Background(), DB, 1)
+	if err != sql.ErrNoRows {
+		t.Errorf("Expected error no rows of deleted shop, but got %v", err)
+	}
+	_, err = GetProductImageAccess(context.Background(), DB,
+		"product-image/banner.png")
+	if err != sql.ErrNoRows {
+		t.Errorf("Expected error no rows of deleted banner, but got %v", err)
+	}
+	_, err = GetShopByUserID(context.Background(), DB, 2)
+	if err != nil {
+		t.Errorf("Expected shop of user ID 2 kept, but got error => %s",
+			err.Error())
+	}
+
+	// reports of the user kept without the user, views of the user deleted
+	reports, err := GetProductReports(context.Background(), DB, "")
+	if err != nil || len(reports) != 2 || reports[0].ReporterUserID != 0 ||
+		reports[1].ReporterUserID != 3 {
+		t.Errorf("Expected reports of user ID 0 and 3, but got %+v (%v)",
+			reports, err)
+	}
+	viewerKeys := []string{}
+	rows, err := DB.Query(`SELECT viewer_key FROM product_productview
+		ORDER BY viewer_key`)
+	if err != nil {
+		t.Fatalf("There's an error when getting product views => %s",
+			err.Error())
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var viewerKey string
+		rows.Scan(&viewerKey)
+		viewerKeys = append(viewerKeys, viewerKey)
+	}
+	if len(viewerKeys) != 1 || viewerKeys[0] != "user:3" {
+		t.Errorf("Expected only view of user ID 3 kept, but got %v",
+			viewerKeys)
+	}
+}
@@ -0,0 +1,97 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// MarketplaceStats contain marketplace-wide aggregates of products,
+// deleted products are excluded except in products created per day
+type MarketplaceStats struct {
+	ProductCount          int                 `json:"product_count"`
+	HiddenProductCount    int                 `json:"hidden_product_count"`
+	SellerCount           int                 `json:"seller_count"`
+	AveragePrice          Money               `json:"average_price"`
+	ProductsCreatedPerDay []DailyProductCount `json:"products_created_per_day"`
+}
+
+// DailyProductCount contain count of products created on a UTC date
+type DailyProductCount struct {
+	Date  string `json:"date"`
+	Count int    `json:"count"`
+}
+
+// GetMarketplaceStats get marketplace-wide aggregates of products,
+// with products created per day of the last days including today
+func GetMarketplaceStats(ctx context.Context, DB *sql.DB, days int,
+	now time.Time) (MarketplaceStats, error) {
+	stats := MarketplaceStats{}
+
+	err := DB.QueryRowContext(ctx, `
+		SELECT
+			COUNT(*),
+			COUNT(*) FILTER (WHERE hidden),
+			COUNT(DISTINCT account_user_id),
+			COALESCE(ROUND(AVG(price)), 0)::BIGINT
+		FROM product_productinfo
+		WHERE deleted_at IS NULL`).Scan(&stats.ProductCount,
+		&stats.HiddenProductCount, &stats.SellerCount, &stats.AveragePrice)
+	if err != nil {
+		return MarketplaceStats{}, err
+	}
+
+	since := getDaysSince(now, days)
+	rows, err := DB.QueryContext(ctx, `
+		SELECT
+			TO_CHAR(created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD') AS day,
+			COUNT(*)
+		FROM product_productinfo
+		WHERE created_at >= $1
+		GROUP BY day`,
+		since)
+	if err != nil {
+		return MarketplaceStats{}, err
+	}
+	defer rows.Close()
+
+	counts := map[string]int{}
+	for rows.Next() {
+		var day string
+		var count int
+		err = rows.Scan(&day, &count)
+		if err != nil {
+			return MarketplaceStats{}, err
+		}
+
+		counts[day] = count
+	}
+	err = rows.Err()
+	if err != nil {
+		return MarketplaceStats{}, err
+	}
+
+	stats.ProductsCreatedPerDay = getDailyProductCounts(counts, since, days)
+
+	return stats, nil
+}
+
+// getDaysSince get start of UTC date of the first of the last days
+// including today
+func getDaysSince(now time.Time, days int) time.Time {
+	y, m, d := now.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1-days)
+}
+
+// getDailyProductCounts get counts of every day since the date,
+// oldest first with zero count on days without created product
+func getDailyProductCounts(counts map[string]int, since time.Time,
+	days int) []DailyProductCount {
+	dailyCounts := make([]DailyProductCount, days)
+	for i := range dailyCounts {
+		day := since.AddDate(0, 0, i).Format("2006-01-02")
+		dailyCounts[i] = DailyProductCount{Date: day, Count: counts[day]}
+	}
+
+	return dailyCounts
+}
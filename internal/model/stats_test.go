@@ -0,0 +1,33 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestGetDailyProductCounts test getDaysSince and getDailyProductCounts
+func TestGetDailyProductCounts(t *testing.T) {
+	now := time.Date(2022, 3, 1, 23, 30, 0, 0,
+		time.FixedZone("UTC+7", 7*60*60))
+	since := getDaysSince(now, 3)
+	if !since.Equal(time.Date(2022, 2, 27, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Expected since 2022-02-27, but got %s", since)
+	}
+
+	dailyCounts := getDailyProductCounts(
+		map[string]int{"2022-02-27": 2, "2022-03-01": 5}, since, 3)
+	expected := []DailyProductCount{
+		{Date: "2022-02-27", Count: 2},
+		{Date: "2022-02-28", Count: 0},
+		{Date: "2022-03-01", Count: 5},
+	}
+	if !reflect.DeepEqual(dailyCounts, expected) {
+		t.Errorf("Expected daily counts %+v, but got %+v", expected,
+			dailyCounts)
+	}
+}
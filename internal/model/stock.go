@@ -0,0 +1,623 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ErrInsufficientStock error when stock is not enough for a decrease
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// ErrOrderQtyNotAllowed error when quantity is outside minimum and maximum
+// order quantity of the product
+var ErrOrderQtyNotAllowed = errors.New("order quantity not allowed")
+
+// ErrBatchItemInvalid error when any item of a batch failed,
+// so the whole batch is rolled back
+var ErrBatchItemInvalid = errors.New("one or more batch items invalid")
+
+// InventorySnapshotItem contain stock level of a product at a point in time,
+// valued at its unit price at that time
+type InventorySnapshotItem struct {
+	ProductID  int     `json:"product_id"`
+	SKU        string  `json:"sku"`
+	Name       string  `json:"name"`
+	UserID     int     `json:"user_id"`
+	Stock      float64 `json:"stock"`
+	Unit       string  `json:"unit"`
+	UnitValue  Money   `json:"unit_value"`
+	TotalValue Money   `json:"total_value"`
+}
+
+// stock movement reasons
+const (
+	StockReasonCreated        = "created"
+	StockReasonUpdated        = "updated"
+	StockReasonDecreased      = "decreased"
+	StockReasonOrderPlaced    = "order_placed"
+	StockReasonOrderCancelled = "order_cancelled"
+	StockReasonBatchUpdated   = "batch_updated"
+)
+
+// StockMovement contain one stock change of a product,
+// UserID is who made the change, zero if made by system
+type StockMovement struct {
+	ID        int       `json:"id"`
+	SKU       string    `json:"sku"`
+	Delta     float64   `json:"delta"`
+	Reason    string    `json:"reason"`
+	OrderID   string    `json:"order_id"`
+	UserID    int       `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// insertStockMovement record a stock change of a product into
+// stock movement ledger, zero delta is not recorded
+func insertStockMovement(ctx context.Context, tx *sql.Tx, productID int,
+	m StockMovement) error {
+	if m.Delta == 0 {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, `INSERT INTO
+		product_stockmovement(
+			delta, reason, order_id, account_user_id, product_productinfo_id)
+		VALUES($1,$2,$3,$4,$5)`,
+		m.Delta, m.Reason, m.OrderID, m.UserID, productID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetStockMovementsBySKU get stock movement ledger of a product by SKU,
+// newest first
+func GetStockMovementsBySKU(ctx context.Context, DB *sql.DB,
+	SKU string) ([]StockMovement, error) {
+	movements := []StockMovement{}
+
+	rows, err := DB.QueryContext(ctx, `
+		SELECT
+			m.id, p.sku, m.delta, m.reason, m.order_id,
+			m.account_user_id, m.created_at
+		FROM product_stockmovement m
+		JOIN product_productinfo p ON p.id = m.product_productinfo_id
+		WHERE p.sku = $1
+		ORDER BY m.created_at DESC, m.id DESC`,
+		SKU)
+	if err != nil {
+		return []StockMovement{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		m := StockMovement{}
+		err = rows.Scan(&m.ID, &m.SKU, &m.Delta, &m.Reason, &m.OrderID,
+			&m.UserID, &m.CreatedAt)
+		if err != nil {
+			return []StockMovement{}, err
+		}
+
+		movements = append(movements, m)
+	}
+
+	return movements, nil
+}
+
+// GetInventorySnapshot get stock level of all products at a past instant,
+// reconstructed from current stock minus stock movements after the instant,
+// only products of user if userID is not zero
+//
+// products created after the instant or deleted before it are excluded,
+// unit value is the price of the product version current at the instant
+func GetInventorySnapshot(ctx context.Context, DB *sql.DB, userID int,
+	at time.Time) ([]InventorySnapshotItem, error) {
+	items := []InventorySnapshotItem{}
+
+	rows, err := DB.QueryContext(ctx, `
+		SELECT
+			p.id, p.sku, p.name, p.account_user_id,
+			p.stock - COALESCE(SUM(m.delta) FILTER (WHERE m.created_at > $1), 0),
+			p.unit,
+			COALESCE((
+				SELECT v.price FROM product_productversion v
+				WHERE v.product_productinfo_id = p.id AND v.created_at <= $1
+				ORDER BY v.version DESC
+				LIMIT 1), p.price)
+		FROM product_productinfo p
+		LEFT JOIN product_stockmovement m ON m.product_productinfo_id = p.id
+		WHERE p.created_at <= $1
+			AND (p.deleted_at IS NULL OR p.deleted_at > $1)
+			AND ($2 = 0 OR p.account_user_id = $2)
+		GROUP BY p.id
+		ORDER BY p.account_user_id, p.id`,
+		at, userID)
+	if err != nil {
+		return []InventorySnapshotItem{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item := InventorySnapshotItem{}
+		err = rows.Scan(&item.ProductID, &item.SKU, &item.Name,
+			&item.UserID, &item.Stock, &item.Unit, &item.UnitValue)
+		if err != nil {
+			return []InventorySnapshotItem{}, err
+		}
+
+		item.TotalValue = item.UnitValue.Mul(item.Stock)
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// StockAdjustment contain stock change of a product by SKU with its reason
+// and related order ID, Stock and UserID (product owner) are filled
+// after adjustment applied
+type StockAdjustment struct {
+	SKU     string  `json:"sku"`
+	Delta   float64 `json:"delta"`
+	Reason  string  `json:"reason"`
+	OrderID string  `json:"order_id"`
+	Stock   float64 `json:"stock"`
+	UserID  int     `json:"user_id"`
+}
+
+// hasOrderStockMovement check whether stock of product already changed
+// for the order by the reason
+func hasOrderStockMovement(ctx context.Context, tx *sql.Tx, productID int,
+	orderID string, reason string) (bool, error) {
+	var exist bool
+	err := tx.QueryRowContext(ctx, `
+		SELECT EXISTS(
+			SELECT 1 FROM product_stockmovement
+			WHERE product_productinfo_id = $1 AND order_id = $2
+				AND reason = $3)`,
+		productID, orderID, reason).Scan(&exist)
+
+	return exist, err
+}
+
+// AdjustStocks apply stock adjustments in one transaction,
+// rolling back all of them if any product not found,
+// its stock would become negative, or the delta is fractional
+// for its unit
+//
+// adjustment of an order is applied once per product and reason,
+// and cancellation only after the order placed, so redelivered order
+// events don't change stock again
+//
+// return applied adjustments, skipped ones are omitted
+func AdjustStocks(ctx context.Context, DB *sql.DB,
+	adjustments []StockAdjustment) ([]StockAdjustment, error) {
+	// begin transaction
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return adjustments, err
+	}
+	defer tx.Rollback() // rollback transaction if fail
+
+	skipped := make([]bool, len(adjustments))
+	for i, adj := range adjustments {
+		// get current stock, locking the row until transaction end
+		var productID int
+		var stock float64
+		var unit string
+		err = tx.QueryRowContext(ctx, `
+			SELECT id, stock, account_user_id, unit
+			FROM product_productinfo
+			WHERE sku = $1 AND deleted_at IS NULL
+			FOR UPDATE`,
+			adj.SKU).Scan(&productID, &stock, &adjustments[i].UserID, &unit)
+		if err == sql.ErrNoRows {
+			return adjustments, fmt.Errorf("product with SKU %s not found", adj.SKU)
+		} else if err != nil {
+			return adjustments, err
+		}
+
+		if adj.OrderID != "" {
+			// skip adjustment already applied for the order,
+			// or cancellation of order never placed
+			applied, err := hasOrderStockMovement(ctx, tx, productID,
+				adj.OrderID, adj.Reason)
+			if err != nil {
+				return adjustments, err
+			}
+			placed := true
+			if adj.Reason == StockReasonOrderCancelled {
+				placed, err = hasOrderStockMovement(ctx, tx, productID,
+					adj.OrderID, StockReasonOrderPlaced)
+				if err != nil {
+					return adjustments, err
+				}
+			}
+			if applied || !placed {
+				skipped[i] = true
+				continue
+			}
+		}
+
+		if !IsQuantityValid(unit, adj.Delta) {
+			return adjustments, fmt.Errorf("%w: SKU %s sold by %s",
+				ErrQuantityInvalid, adj.SKU, unit)
+		}
+		if stock+adj.Delta < 0 {
+			return adjustments, fmt.Errorf("%w: SKU %s has %v left",
+				ErrInsufficientStock, adj.SKU, stock)
+		}
+
+		// update stock
+		err = tx.QueryRowContext(ctx, `
+			UPDATE product_productinfo
+			SET stock = stock + $1, updated_at = NOW()
+			WHERE id = $2
+			RETURNING stock`,
+			adj.Delta, productID).Scan(&adjustments[i].Stock)
+		if err != nil {
+			return adjustments, err
+		}
+
+		// record stock change into stock movement ledger
+		err = insertStockMovement(ctx, tx, productID, StockMovement{
+			Delta:   adj.Delta,
+			Reason:  adj.Reason,
+			OrderID: adj.OrderID,
+		})
+		if err != nil {
+			return adjustments, err
+		}
+	}
+
+	// commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return adjustments, err
+	}
+
+	applied := []StockAdjustment{}
+	for i, adj := range adjustments {
+		if !skipped[i] {
+			applied = append(applied, adj)
+		}
+	}
+
+	return applied, nil
+}
+
+// DecreaseStockBySKU decrease product stock by SKU atomically,
+// only if the stock is enough for the quantity, recording who,
+// reason, and related order ID from audit into the ledger
+//
+// return sql.ErrNoRows if product not found, ErrInsufficientStock
+// if the stock is not enough, ErrQuantityInvalid if the quantity
+// is fractional for the product unit, and ErrOrderQtyNotAllowed if
+// the quantity outside product minimum and maximum order quantity
+func DecreaseStockBySKU(ctx context.Context, DB *sql.DB, SKU string,
+	qty float64, audit StockMovement) (ProductInfo, error) {
+	pInfo := ProductInfo{SKU: SKU}
+
+	// begin transaction
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return pInfo, err
+	}
+	defer tx.Rollback() // rollback transaction if fail
+
+	// decrease stock in single statement so concurrent orders can't oversell
+	err = tx.QueryRowContext(ctx, `
+		UPDATE product_productinfo
+		SET stock = stock - $1, updated_at = NOW()
+		WHERE sku = $2 AND stock >= $1 AND deleted_at IS NULL
+		RETURNING id, stock, account_user_id, unit, min_order_qty,
+			max_order_qty`,
+		qty, SKU).Scan(&pInfo.ID, &pInfo.Stock, &pInfo.UserID, &pInfo.Unit,
+		&pInfo.MinOrderQty, &pInfo.MaxOrderQty)
+	if err == sql.ErrNoRows {
+		// check whether product not found or stock not enough
+		var exist bool
+		err = tx.QueryRowContext(ctx, `
+			SELECT EXISTS(
+				SELECT 1 FROM product_productinfo
+				WHERE sku = $1 AND deleted_at IS NULL)`,
+			SKU).Scan(&exist)
+		if err != nil {
+			return pInfo, err
+		}
+		if !exist {
+			return pInfo, sql.ErrNoRows
+		}
+		return pInfo, ErrInsufficientStock
+	} else if err != nil {
+		return pInfo, err
+	}
+
+	// the decrease rolled back if quantity fractional for the unit
+	// or not allowed to be ordered
+	if !IsQuantityValid(pInfo.Unit, qty) {
+		return pInfo, ErrQuantityInvalid
+	}
+	if !pInfo.IsOrderQtyAllowed(qty) {
+		return pInfo, ErrOrderQtyNotAllowed
+	}
+
+	// record stock change into stock movement ledger
+	audit.Delta = -qty
+	err = insertStockMovement(ctx, tx, pInfo.ID, audit)
+	if err != nil {
+		return pInfo, err
+	}
+
+	// commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return pInfo, err
+	}
+
+	return pInfo, nil
+}
+
+// GetLowStockProducts get products of a user with stock at or below
+// threshold, lowest stock first
+func GetLowStockProducts(ctx context.Context, DB *sql.DB, userID int,
+	threshold int) ([]Product, error) {
+	return queryProducts(ctx, DB, `SELECT `+productInfoColumns+`
+		FROM product_productinfo
+		WHERE account_user_id = $1 AND stock <= $2 AND deleted_at IS NULL
+		ORDER BY stock, id`,
+		userID, threshold)
+}
+
+// ProductSnapshot contain current effective price and available stock
+// of a product by SKU
+type ProductSnapshot struct {
+	SKU            string  `json:"sku"`
+	EffectivePrice Money   `json:"effective_price"`
+	Stock          float64 `json:"stock"`
+}
+
+// GetProductSnapshots get current effective price and stock of products
+// by SKUs in one query, in order of SKUs, products not found, hidden,
+// or deleted are omitted
+func GetProductSnapshots(ctx context.Context, DB *sql.DB,
+	SKUs []string) ([]ProductSnapshot, error) {
+	snapshots := []ProductSnapshot{}
+
+	rows, err := DB.QueryContext(ctx, `
+		SELECT sku, price, sale_price, sale_starts_at, sale_ends_at, stock
+		FROM product_productinfo
+		WHERE sku = ANY($1::TEXT[]) AND hidden = FALSE
+			AND deleted_at IS NULL
+		ORDER BY array_position($1::TEXT[], sku::TEXT)`,
+		pq.Array(SKUs))
+	if err != nil {
+		return []ProductSnapshot{}, err
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	for rows.Next() {
+		pInfo := ProductInfo{}
+		err = rows.Scan(&pInfo.SKU, &pInfo.Price, &pInfo.SalePrice,
+			&pInfo.SaleStartsAt, &pInfo.SaleEndsAt, &pInfo.Stock)
+		if err != nil {
+			return []ProductSnapshot{}, err
+		}
+
+		snapshots = append(snapshots, ProductSnapshot{
+			SKU:            pInfo.SKU,
+			EffectivePrice: pInfo.GetEffectivePrice(now),
+			Stock:          pInfo.Stock,
+		})
+	}
+
+	return snapshots, rows.Err()
+}
+
+// StockUpdate contain new stock of a product by SKU
+type StockUpdate struct {
+	SKU   string  `json:"sku"`
+	Stock float64 `json:"stock"`
+}
+
+// StockUpdateResult contain result of one stock update in a batch
+type StockUpdateResult struct {
+	SKU           string  `json:"sku"`
+	PreviousStock float64 `json:"previous_stock"`
+	Stock         float64 `json:"stock"`
+	Error         string  `json:"error,omitempty"`
+}
+
+// SetStocks set stock of products owned by a user in one transaction
+//
+// return per-item results, and ErrBatchItemInvalid with all updates
+// rolled back if any product not found, not owned by the user,
+// or the stock is fractional for its unit
+func SetStocks(ctx context.Context, DB *sql.DB, userID int,
+	updates []StockUpdate) ([]StockUpdateResult, error) {
+	results := make([]StockUpdateResult, len(updates))
+
+	// begin transaction
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return results, err
+	}
+	defer tx.Rollback() // rollback transaction if fail
+
+	failed := false
+	for i, update := range updates {
+		results[i] = StockUpdateResult{SKU: update.SKU, Stock: update.Stock}
+
+		// get current stock, locking the row until transaction end
+		var productID, ownerID int
+		var unit string
+		err = tx.QueryRowContext(ctx, `
+			SELECT id, stock, account_user_id, unit
+			FROM product_productinfo
+			WHERE sku = $1 AND deleted_at IS NULL
+			FOR UPDATE`,
+			update.SKU).Scan(&productID, &results[i].PreviousStock, &ownerID,
+			&unit)
+		if err == sql.ErrNoRows || (err == nil && ownerID != userID) {
+			results[i].Error = "product not found"
+			failed = true
+			continue
+		} else if err != nil {
+			return results, err
+		}
+		if !IsQuantityValid(unit, update.Stock) {
+			results[i].Error = fmt.Sprintf("stock must be whole number "+
+				"for unit %s", unit)
+			failed = true
+			continue
+		}
+
+		// update stock
+		_, err = tx.ExecContext(ctx, `
+			UPDATE product_productinfo
+			SET stock = $1, updated_at = NOW()
+			WHERE id = $2`,
+			update.Stock, productID)
+		if err != nil {
+			return results, err
+		}
+
+		// record stock change into stock movement ledger
+		err = insertStockMovement(ctx, tx, productID, StockMovement{
+			Delta:  update.Stock - results[i].PreviousStock,
+			Reason: StockReasonBatchUpdated,
+			UserID: userID,
+		})
+		if err != nil {
+			return results, err
+		}
+	}
+
+	if failed {
+		return results, ErrBatchItemInvalid
+	}
+
+	// commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return results, err
+	}
+
+	return results, nil
+}
+
+// StockDecrease contain ordered quantity of a product by SKU
+type StockDecrease struct {
+	SKU string  `json:"sku"`
+	Qty float64 `json:"qty"`
+}
+
+// StockDecreaseResult contain result of one stock decrease in a batch,
+// UserID is the product owner
+type StockDecreaseResult struct {
+	SKU    string  `json:"sku"`
+	Qty    float64 `json:"qty"`
+	Stock  float64 `json:"stock"`
+	UserID int     `json:"user_id"`
+	Error  string  `json:"error,omitempty"`
+}
+
+// DecreaseStocks decrease stock of products by SKU in one transaction,
+// recording who, reason, and related order ID from audit into the ledger
+//
+// return per-item results, and ErrBatchItemInvalid with all decreases
+// rolled back if any product not found, its stock is not enough,
+// or the quantity is fractional for its unit or not allowed to be ordered
+func DecreaseStocks(ctx context.Context, DB *sql.DB,
+	decreases []StockDecrease, audit StockMovement) (
+	[]StockDecreaseResult, error) {
+	results := make([]StockDecreaseResult, len(decreases))
+
+	// begin transaction
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return results, err
+	}
+	defer tx.Rollback() // rollback transaction if fail
+
+	failed := false
+	for i, decrease := range decreases {
+		results[i] = StockDecreaseResult{SKU: decrease.SKU, Qty: decrease.Qty}
+
+		// get current stock, locking the row until transaction end
+		pInfo := ProductInfo{}
+		err = tx.QueryRowContext(ctx, `
+			SELECT id, stock, account_user_id, unit, min_order_qty,
+				max_order_qty
+			FROM product_productinfo
+			WHERE sku = $1 AND deleted_at IS NULL
+			FOR UPDATE`,
+			decrease.SKU).Scan(&pInfo.ID, &pInfo.Stock, &pInfo.UserID,
+			&pInfo.Unit, &pInfo.MinOrderQty, &pInfo.MaxOrderQty)
+		if err == sql.ErrNoRows {
+			results[i].Error = "product not found"
+			failed = true
+			continue
+		} else if err != nil {
+			return results, err
+		}
+		results[i].Stock = pInfo.Stock
+		results[i].UserID = pInfo.UserID
+
+		switch {
+		case !IsQuantityValid(pInfo.Unit, decrease.Qty):
+			results[i].Error = fmt.Sprintf("qty must be whole number "+
+				"for unit %s", pInfo.Unit)
+		case decrease.Qty < pInfo.MinOrderQty:
+			results[i].Error = fmt.Sprintf("qty must be at least %v",
+				pInfo.MinOrderQty)
+		case !pInfo.IsOrderQtyAllowed(decrease.Qty):
+			results[i].Error = fmt.Sprintf("qty must be at most %v",
+				pInfo.MaxOrderQty)
+		case pInfo.Stock < decrease.Qty:
+			results[i].Error = fmt.Sprintf("product stock insufficient, "+
+				"%v left", pInfo.Stock)
+		}
+		if results[i].Error != "" {
+			failed = true
+			continue
+		}
+
+		// decrease stock
+		err = tx.QueryRowContext(ctx, `
+			UPDATE product_productinfo
+			SET stock = stock - $1, updated_at = NOW()
+			WHERE id = $2
+			RETURNING stock`,
+			decrease.Qty, pInfo.ID).Scan(&results[i].Stock)
+		if err != nil {
+			return results, err
+		}
+
+		// record stock change into stock movement ledger
+		movement := audit
+		movement.Delta = -decrease.Qty
+		err = insertStockMovement(ctx, tx, pInfo.ID, movement)
+		if err != nil {
+			return results, err
+		}
+	}
+
+	if failed {
+		return results, ErrBatchItemInvalid
+	}
+
+	// commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return results, err
+	}
+
+	return results, nil
+}
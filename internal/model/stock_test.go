@@ -0,0 +1,757 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestGetInventorySnapshot test GetInventorySnapshot
+//
+// Required for the test:
+//
+// - InsertProductInfo
+//
+// - UpdateProductInfoBySKU
+func TestGetInventorySnapshot(t *testing.T) {
+	// get testing DB connection
+	DB, err := getTestDBConnection()
+	if err != nil {
+		t.Errorf("There's an error when initialize "+
+			"testing database connection => %s", err.Error())
+	}
+
+	// get instant before product created
+	var beforeCreated time.Time
+	err = DB.QueryRow("SELECT NOW()").Scan(&beforeCreated)
+	if err != nil {
+		t.Errorf("There's an error when getting database time => %s",
+			err.Error())
+	}
+
+	// insert product info into database
+	pInfo, err := InsertProductInfo(context.Background(), DB, ProductInfo{
+		Name:        "PRODUCT A",
+		Price:       120000055,
+		Weight:      1.5,
+		Description: "Description PRODUCT A",
+		Stock:       100,
+		UserID:      1,
+	})
+	if err != nil {
+		t.Errorf("There's an error when insert data product info => %s",
+			err.Error())
+	}
+
+	// get instant before product updated
+	var beforeUpdated time.Time
+	err = DB.QueryRow("SELECT NOW()").Scan(&beforeUpdated)
+	if err != nil {
+		t.Errorf("There's an error when getting database time => %s",
+			err.Error())
+	}
+
+	// update product stock
+	pInfo.Stock = 60
+	_, err = UpdateProductInfoBySKU(context.Background(), DB, pInfo)
+	if err != nil {
+		t.Errorf("There's an error when update data product info => %s",
+			err.Error())
+	}
+
+	// insert product of other seller after the instants
+	// without stock movements in the ledger
+	pInfoB, err := InsertProductInfo(context.Background(), DB, ProductInfo{
+		Name: "PRODUCT B", Price: 1000, Weight: 1, Stock: 7, UserID: 3,
+	})
+	if err != nil {
+		t.Errorf("There's an error when insert data product info => %s",
+			err.Error())
+	}
+	_, err = DB.Exec(`DELETE FROM product_stockmovement
+		WHERE product_productinfo_id = $1`, pInfoB.ID)
+	if err != nil {
+		t.Errorf("There's an error when deleting stock movements => %s",
+			err.Error())
+	}
+
+	// create testing table
+	testTable := []struct {
+		TestName       string
+		UserID         int
+		At             time.Time
+		ExpectedLength int
+		ExpectedStock  float64
+	}{
+		{
+			TestName:       "Before Product Created",
+			At:             beforeCreated,
+			ExpectedLength: 0,
+		},
+		{
+			TestName:       "Before Product Updated And Other Created",
+			At:             beforeUpdated,
+			ExpectedLength: 1,
+			ExpectedStock:  100,
+		},
+		{
+			TestName:       "Now",
+			At:             time.Now().Add(time.Hour),
+			ExpectedLength: 2,
+			ExpectedStock:  60,
+		},
+		{
+			TestName:       "Seller",
+			UserID:         1,
+			At:             time.Now().Add(time.Hour),
+			ExpectedLength: 1,
+			ExpectedStock:  60,
+		},
+		{
+			TestName:       "Other Seller",
+			UserID:         2,
+			At:             time.Now().Add(time.Hour),
+			ExpectedLength: 0,
+		},
+	}
+
+	// do the test
+	for _, test := range testTable {
+		items, err := GetInventorySnapshot(context.Background(), DB,
+			test.UserID, test.At)
+		if err != nil {
+			t.Errorf("[%s] Expected error nil, but got error => %s",
+				test.TestName, err.Error())
+		}
+
+		if len(items) != test.ExpectedLength {
+			t.Errorf("[%s] Expected length %d, but got %d",
+				test.TestName, test.ExpectedLength, len(items))
+		} else if len(items) > 0 && items[0].Stock != test.ExpectedStock {
+			t.Errorf("[%s] Expected stock %v, but got %v",
+				test.TestName, test.ExpectedStock, items[0].Stock)
+		} else if len(items) > 0 && (items[0].UnitValue != pInfo.Price ||
+			items[0].TotalValue != pInfo.Price.Mul(test.ExpectedStock)) {
+			t.Errorf("[%s] Expected unit value %s and total value %s, "+
+				"but got %s and %s", test.TestName, pInfo.Price,
+				pInfo.Price.Mul(test.ExpectedStock), items[0].UnitValue,
+				items[0].TotalValue)
+		}
+	}
+
+	// truncate tables after test
+	_, err = DB.Exec("TRUNCATE product_productinfo RESTART IDENTITY CASCADE")
+	if err != nil {
+		log.Fatalf("There's an error when truncating "+
+			"table product_productinfo => %s",
+			err.Error())
+	}
+}
+
+// TestAdjustStocks test AdjustStocks
+//
+// Required for the test:
+//
+// - InsertProductInfo
+//
+// - GetProductBySKU
+func TestAdjustStocks(t *testing.T) {
+	// get testing DB connection
+	DB, err := getTestDBConnection()
+	if err != nil {
+		t.Errorf("There's an error when initialize "+
+			"testing database connection => %s", err.Error())
+	}
+
+	// insert products into database
+	pInfoA, err := InsertProductInfo(context.Background(), DB, ProductInfo{
+		Name: "PRODUCT A", Price: 1000, Weight: 1, Stock: 10, UserID: 1,
+	})
+	if err != nil {
+		t.Errorf("There's an error when insert data product info => %s",
+			err.Error())
+	}
+	pInfoB, err := InsertProductInfo(context.Background(), DB, ProductInfo{
+		Name: "PRODUCT B", Price: 1000, Weight: 1, Stock: 5, UserID: 1,
+	})
+	if err != nil {
+		t.Errorf("There's an error when insert data product info => %s",
+			err.Error())
+	}
+
+	// adjust stocks successfully
+	result, err := AdjustStocks(context.Background(), DB, []StockAdjustment{
+		{SKU: pInfoA.SKU, Delta: -4},
+		{SKU: pInfoB.SKU, Delta: 3},
+	})
+	if err != nil {
+		t.Errorf("Expected error nil, but got error => %s", err.Error())
+	} else if result[0].Stock != 6 || result[1].Stock != 8 {
+		t.Errorf("Expected stocks 6 and 8, but got %v and %v",
+			result[0].Stock, result[1].Stock)
+	}
+
+	// adjust stocks with insufficient stock, all must be rolled back
+	_, err = AdjustStocks(context.Background(), DB, []StockAdjustment{
+		{SKU: pInfoA.SKU, Delta: -1},
+		{SKU: pInfoB.SKU, Delta: -100},
+	})
+	if !errors.Is(err, ErrInsufficientStock) {
+		t.Errorf("Expected error insufficient stock, but got %v", err)
+	}
+
+	p, err := GetProductBySKU(context.Background(), DB, pInfoA.SKU)
+	if err != nil {
+		t.Errorf("There's an error when get data product => %s", err.Error())
+	} else if p.ProductInfo.Stock != 6 {
+		t.Errorf("Expected stock 6 after rollback, but got %v",
+			p.ProductInfo.Stock)
+	}
+
+	// adjust stocks with unknown SKU
+	_, err = AdjustStocks(context.Background(), DB,
+		[]StockAdjustment{{SKU: "unknown", Delta: 1}})
+	if err == nil {
+		t.Errorf("Expected error product not found, but got no error")
+	}
+
+	// truncate tables after test
+	_, err = DB.Exec("TRUNCATE product_productinfo RESTART IDENTITY CASCADE")
+	if err != nil {
+		log.Fatalf("There's an error when truncating "+
+			"table product_productinfo => %s",
+			err.Error())
+	}
+}
+
+// TestAdjustStocksOrderRedelivered test AdjustStocks applying stock
+// adjustments of the same order event delivered twice only once
+//
+// Required for the test:
+//
+// - InsertProductInfo
+//
+// - GetProductBySKU
+func TestAdjustStocksOrderRedelivered(t *testing.T) {
+	// get testing DB connection
+	DB, err := getTestDBConnection()
+	if err != nil {
+		t.Errorf("There's an error when initialize "+
+			"testing database connection => %s", err.Error())
+	}
+
+	// insert product into database
+	pInfo, err := InsertProductInfo(context.Background(), DB, ProductInfo{
+		Name: "PRODUCT A", Price: 1000, Weight: 1, Stock: 10, UserID: 1,
+	})
+	if err != nil {
+		t.Errorf("There's an error when insert data product info => %s",
+			err.Error())
+	}
+
+	// create testing table
+	testTable := []struct {
+		TestName        string
+		Adjustment      StockAdjustment
+		ExpectedApplied int
+		ExpectedStock   float64
+	}{
+		{
+			TestName: "Test Cancelled Before Placed",
+			Adjustment: StockAdjustment{SKU: pInfo.SKU, Delta: 3,
+				Reason: StockReasonOrderCancelled, OrderID: "ORDER-1"},
+			ExpectedApplied: 0,
+			ExpectedStock:   10,
+		},
+		{
+			TestName: "Test Placed",
+			Adjustment: StockAdjustment{SKU: pInfo.SKU, Delta: -3,
+				Reason: StockReasonOrderPlaced, OrderID: "ORDER-1"},
+			ExpectedApplied: 1,
+			ExpectedStock:   7,
+		},
+		{
+			TestName: "Test Placed Redelivered",
+			Adjustment: StockAdjustment{SKU: pInfo.SKU, Delta: -3,
+				Reason: StockReasonOrderPlaced, OrderID: "ORDER-1"},
+			ExpectedApplied: 0,
+			ExpectedStock:   7,
+		},
+		{
+			TestName: "Test Cancelled",
+			Adjustment: StockAdjustment{SKU: pInfo.SKU, Delta: 3,
+				Reason: StockReasonOrderCancelled, OrderID: "ORDER-1"},
+			ExpectedApplied: 1,
+			ExpectedStock:   10,
+		},
+		{
+			TestName: "Test Cancelled Redelivered",
+			Adjustment: StockAdjustment{SKU: pInfo.SKU, Delta: 3,
+				Reason: StockReasonOrderCancelled, OrderID: "ORDER-1"},
+			ExpectedApplied: 0,
+			ExpectedStock:   10,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		applied, err := AdjustStocks(context.Background(), DB,
+			[]StockAdjustment{test.Adjustment})
+		if err != nil {
+			t.Errorf("[%s] Expected error nil, but got error => %s",
+				test.TestName, err.Error())
+		} else if len(applied) != test.ExpectedApplied {
+			t.Errorf("[%s] Expected %d adjustments applied, but got %d",
+				test.TestName, test.ExpectedApplied, len(applied))
+		}
+
+		p, err := GetProductBySKU(context.Background(), DB, pInfo.SKU)
+		if err != nil {
+			t.Errorf("[%s] There's an error when get data product => %s",
+				test.TestName, err.Error())
+		} else if p.ProductInfo.Stock != test.ExpectedStock {
+			t.Errorf("[%s] Expected stock %v, but got %v", test.TestName,
+				test.ExpectedStock, p.ProductInfo.Stock)
+		}
+	}
+
+	// truncate tables after test
+	_, err = DB.Exec("TRUNCATE product_productinfo RESTART IDENTITY CASCADE")
+	if err != nil {
+		log.Fatalf("There's an error when truncating "+
+			"table product_productinfo => %s",
+			err.Error())
+	}
+}
+
+// TestDecreaseStockBySKU test DecreaseStockBySKU
+//
+// Required for the test: InsertProductInfo
+func TestDecreaseStockBySKU(t *testing.T) {
+	// get testing DB connection
+	DB, err := getTestDBConnection()
+	if err != nil {
+		t.Errorf("There's an error when initialize "+
+			"testing database connection => %s", err.Error())
+	}
+
+	// insert product into database
+	pInfo, err := InsertProductInfo(context.Background(), DB, ProductInfo{
+		Name: "PRODUCT A", Price: 1000, Weight: 1, Stock: 10, UserID: 1,
+	})
+	if err != nil {
+		t.Errorf("There's an error when insert data product info => %s",
+			err.Error())
+	}
+
+	// create testing table
+	testTable := []struct {
+		TestName      string
+		SKU           string
+		Qty           float64
+		ExpectedStock float64
+		ExpectedErr   error
+	}{
+		{
+			TestName:      "Decrease Success",
+			SKU:           pInfo.SKU,
+			Qty:           4,
+			ExpectedStock: 6,
+		},
+		{
+			TestName:      "Decrease All Remaining",
+			SKU:           pInfo.SKU,
+			Qty:           6,
+			ExpectedStock: 0,
+		},
+		{
+			TestName:    "Insufficient Stock",
+			SKU:         pInfo.SKU,
+			Qty:         1,
+			ExpectedErr: ErrInsufficientStock,
+		},
+		{
+			TestName:    "Product Not Found",
+			SKU:         "unknown",
+			Qty:         1,
+			ExpectedErr: sql.ErrNoRows,
+		},
+	}
+
+	// do the test
+	for _, test := range testTable {
+		result, err := DecreaseStockBySKU(context.Background(), DB, test.SKU,
+			test.Qty,
+			StockMovement{Reason: StockReasonDecreased, UserID: 1})
+		if test.ExpectedErr != nil {
+			if !errors.Is(err, test.ExpectedErr) {
+				t.Errorf("[%s] Expected error %v, but got %v",
+					test.TestName, test.ExpectedErr, err)
+			}
+		} else if err != nil {
+			t.Errorf("[%s] Expected error nil, but got error => %s",
+				test.TestName, err.Error())
+		} else if result.Stock != test.ExpectedStock {
+			t.Errorf("[%s] Expected stock %v, but got %v",
+				test.TestName, test.ExpectedStock, result.Stock)
+		}
+	}
+
+	// truncate tables after test
+	_, err = DB.Exec("TRUNCATE product_productinfo RESTART IDENTITY CASCADE")
+	if err != nil {
+		log.Fatalf("There's an error when truncating "+
+			"table product_productinfo => %s",
+			err.Error())
+	}
+}
+
+// TestDecreaseStocks test DecreaseStocks
+//
+// Required for the test:
+//
+// - InsertProductInfo
+//
+// - GetProductBySKU
+func TestDecreaseStocks(t *testing.T) {
+	// get testing DB connection
+	DB, err := getTestDBConnection()
+	if err != nil {
+		t.Fatalf("There's an error when initialize "+
+			"testing database connection => %s", err.Error())
+	}
+
+	// insert products into database
+	SKUs := []string{}
+	for _, pInfo := range []ProductInfo{
+		{Name: "PRODUCT A", Price: 1000, Weight: 1, Stock: 10, UserID: 1},
+		{Name: "PRODUCT B", Price: 1000, Weight: 1, Stock: 3, UserID: 2},
+	} {
+		pInfo, err = InsertProductInfo(context.Background(), DB, pInfo)
+		if err != nil {
+			t.Fatalf("There's an error when insert data product info => %s",
+				err.Error())
+		}
+		SKUs = append(SKUs, pInfo.SKU)
+	}
+
+	// create testing table, stocks are kept between tests
+	testTable := []struct {
+		TestName       string
+		Decreases      []StockDecrease
+		ExpectedErr    error
+		ExpectedErrors []string
+		ExpectedStocks []float64
+	}{
+		{
+			TestName: "Decrease Success",
+			Decreases: []StockDecrease{{SKU: SKUs[0], Qty: 4},
+				{SKU: SKUs[1], Qty: 3}},
+			ExpectedErrors: []string{"", ""},
+			ExpectedStocks: []float64{6, 0},
+		},
+		{
+			TestName: "Insufficient Stock Rolled Back",
+			Decreases: []StockDecrease{{SKU: SKUs[0], Qty: 1},
+				{SKU: SKUs[1], Qty: 1}},
+			ExpectedErr: ErrBatchItemInvalid,
+			ExpectedErrors: []string{"",
+				"product stock insufficient, 0 left"},
+			ExpectedStocks: []float64{6, 0},
+		},
+		{
+			TestName: "Product Not Found Rolled Back",
+			Decreases: []StockDecrease{{SKU: SKUs[0], Qty: 1},
+				{SKU: "unknown", Qty: 1}},
+			ExpectedErr:    ErrBatchItemInvalid,
+			ExpectedErrors: []string{"", "product not found"},
+			ExpectedStocks: []float64{6, 0},
+		},
+	}
+
+	// do the test
+	for _, test := range testTable {
+		results, err := DecreaseStocks(context.Background(), DB,
+			test.Decreases, StockMovement{Reason: StockReasonDecreased,
+				OrderID: "order-1", UserID: 1})
+		if !errors.Is(err, test.ExpectedErr) {
+			t.Errorf("[%s] Expected error %v, but got %v", test.TestName,
+				test.ExpectedErr, err)
+		}
+		for i, result := range results {
+			if result.Error != test.ExpectedErrors[i] {
+				t.Errorf("[%s] Expected item %d error '%s', but got '%s'",
+					test.TestName, i, test.ExpectedErrors[i], result.Error)
+			}
+		}
+
+		for i, SKU := range SKUs {
+			p, err := GetProductBySKU(context.Background(), DB, SKU)
+			if err != nil {
+				t.Fatalf("[%s] Expected error nil, but got error => %s",
+					test.TestName, err.Error())
+			}
+			if p.ProductInfo.Stock != test.ExpectedStocks[i] {
+				t.Errorf("[%s] Expected stock of %s %v, but got %v",
+					test.TestName, SKU, test.ExpectedStocks[i],
+					p.ProductInfo.Stock)
+			}
+		}
+	}
+
+	// truncate tables after test
+	_, err = DB.Exec("TRUNCATE product_productinfo RESTART IDENTITY CASCADE")
+	if err != nil {
+		log.Fatalf("There's an error when truncating "+
+			"table product_productinfo => %s",
+			err.Error())
+	}
+}
+
+// TestGetStockMovementsBySKU test GetStockMovementsBySKU
+//
+// Required for the test:
+//
+// - InsertProductInfo
+//
+// - DecreaseStockBySKU
+func TestGetStockMovementsBySKU(t *testing.T) {
+	// get testing DB connection
+	DB, err := getTestDBConnection()
+	if err != nil {
+		t.Errorf("There's an error when initialize "+
+			"testing database connection => %s", err.Error())
+	}
+
+	// insert product into database and decrease its stock
+	pInfo, err := InsertProductInfo(context.Background(), DB, ProductInfo{
+		Name: "PRODUCT A", Price: 1000, Weight: 1, Stock: 10, UserID: 1,
+	})
+	if err != nil {
+		t.Errorf("There's an error when insert data product info => %s",
+			err.Error())
+	}
+	_, err = DecreaseStockBySKU(context.Background(), DB, pInfo.SKU, 3,
+		StockMovement{
+			Reason:  StockReasonDecreased,
+			OrderID: "order-1",
+			UserID:  2,
+		})
+	if err != nil {
+		t.Errorf("There's an error when decrease stock => %s", err.Error())
+	}
+
+	// get stock movements
+	movements, err := GetStockMovementsBySKU(context.Background(), DB,
+		pInfo.SKU)
+	if err != nil {
+		t.Errorf("Expected error nil, but got error => %s", err.Error())
+	}
+
+	// check result, newest first
+	if len(movements) != 2 {
+		t.Fatalf("Expected 2 movements, but got %d", len(movements))
+	}
+	if movements[0].Delta != -3 || movements[0].Reason != StockReasonDecreased ||
+		movements[0].OrderID != "order-1" || movements[0].UserID != 2 {
+		t.Errorf("Expected decrease movement, but got %v", movements[0])
+	}
+	if movements[1].Delta != 10 || movements[1].Reason != StockReasonCreated ||
+		movements[1].UserID != 1 {
+		t.Errorf("Expected created movement, but got %v", movements[1])
+	}
+
+	// truncate tables after test
+	_, err = DB.Exec("TRUNCATE product_productinfo RESTART IDENTITY CASCADE")
+	if err != nil {
+		log.Fatalf("There's an error when truncating "+
+			"table product_productinfo => %s",
+			err.Error())
+	}
+}
+
+// TestGetLowStockProducts test GetLowStockProducts
+//
+// Required for the test: InsertProductInfo
+func TestGetLowStockProducts(t *testing.T) {
+	// get testing DB connection
+	DB, err := getTestDBConnection()
+	if err != nil {
+		t.Errorf("There's an error when initialize "+
+			"testing database connection => %s", err.Error())
+	}
+
+	// insert products into database
+	for _, pInfo := range []ProductInfo{
+		{Name: "PRODUCT A", Price: 1000, Weight: 1, Stock: 5, UserID: 1},
+		{Name: "PRODUCT B", Price: 1000, Weight: 1, Stock: 0, UserID: 1},
+		{Name: "PRODUCT C", Price: 1000, Weight: 1, Stock: 50, UserID: 1},
+		{Name: "PRODUCT D", Price: 1000, Weight: 1, Stock: 1, UserID: 2},
+	} {
+		_, err = InsertProductInfo(context.Background(), DB, pInfo)
+		if err != nil {
+			t.Errorf("There's an error when insert data product info => %s",
+				err.Error())
+		}
+	}
+
+	// get low stock products
+	products, err := GetLowStockProducts(context.Background(), DB, 1, 5)
+	if err != nil {
+		t.Errorf("Expected error nil, but got error => %s", err.Error())
+	}
+
+	// check result, lowest stock first
+	if len(products) != 2 {
+		t.Fatalf("Expected 2 products, but got %d", len(products))
+	}
+	if products[0].ProductInfo.Name != "PRODUCT B" ||
+		products[1].ProductInfo.Name != "PRODUCT A" {
+		t.Errorf("Expected PRODUCT B then PRODUCT A, but got %s then %s",
+			products[0].ProductInfo.Name, products[1].ProductInfo.Name)
+	}
+
+	// truncate tables after test
+	_, err = DB.Exec("TRUNCATE product_productinfo RESTART IDENTITY CASCADE")
+	if err != nil {
+		log.Fatalf("There's an error when truncating "+
+			"table product_productinfo => %s",
+			err.Error())
+	}
+}
+
+// TestGetProductSnapshots test GetProductSnapshots
+//
+// Required for the test:
+//
+// - InsertProductInfo
+//
+// - SetProductVisibilityBySKU
+func TestGetProductSnapshots(t *testing.T) {
+	// get testing DB connection
+	DB, err := getTestDBConnection()
+	if err != nil {
+		t.Fatalf("There's an error when initialize "+
+			"testing database connection => %s", err.Error())
+	}
+
+	// insert products into database, the second on sale
+	// and the third hidden
+	SKUs := []string{}
+	for _, pInfo := range []ProductInfo{
+		{Name: "PRODUCT A", Price: 1000, Weight: 1, Stock: 5, UserID: 1},
+		{Name: "PRODUCT B", Price: 1000, SalePrice: 800, Weight: 1,
+			Stock: 2, UserID: 1},
+		{Name: "PRODUCT C", Price: 1000, Weight: 1, Stock: 7, UserID: 1},
+	} {
+		pInfo, err = InsertProductInfo(context.Background(), DB, pInfo)
+		if err != nil {
+			t.Fatalf("There's an error when insert data product info => %s",
+				err.Error())
+		}
+		SKUs = append(SKUs, pInfo.SKU)
+	}
+	_, err = SetProductVisibilityBySKU(context.Background(), DB, SKUs[2], 1,
+		true)
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+
+	// get snapshots in requested order, omitting hidden and not found
+	snapshots, err := GetProductSnapshots(context.Background(), DB,
+		[]string{SKUs[1], "not-found", SKUs[2], SKUs[0]})
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+	expected := []ProductSnapshot{
+		{SKU: SKUs[1], EffectivePrice: 800, Stock: 2},
+		{SKU: SKUs[0], EffectivePrice: 1000, Stock: 5},
+	}
+	if !reflect.DeepEqual(snapshots, expected) {
+		t.Errorf("Expected snapshots %+v, but got %+v", expected, snapshots)
+	}
+
+	// truncate tables after test
+	_, err = DB.Exec("TRUNCATE product_productinfo RESTART IDENTITY CASCADE")
+	if err != nil {
+		log.Fatalf("There's an error when truncating "+
+			"table product_productinfo => %s",
+			err.Error())
+	}
+}
+
+// TestSetStocks test SetStocks
+//
+// Required for the test:
+//
+// - InsertProductInfo
+//
+// - GetProductBySKU
+func TestSetStocks(t *testing.T) {
+	// get testing DB connection
+	DB, err := getTestDBConnection()
+	if err != nil {
+		t.Errorf("There's an error when initialize "+
+			"testing database connection => %s", err.Error())
+	}
+
+	// insert products into database
+	pInfoA, err := InsertProductInfo(context.Background(), DB, ProductInfo{
+		Name: "PRODUCT A", Price: 1000, Weight: 1, Stock: 10, UserID: 1,
+	})
+	if err != nil {
+		t.Errorf("There's an error when insert data product info => %s",
+			err.Error())
+	}
+	pInfoB, err := InsertProductInfo(context.Background(), DB, ProductInfo{
+		Name: "PRODUCT B", Price: 1000, Weight: 1, Stock: 10, UserID: 2,
+	})
+	if err != nil {
+		t.Errorf("There's an error when insert data product info => %s",
+			err.Error())
+	}
+
+	// set stocks successfully
+	results, err := SetStocks(context.Background(), DB, 1,
+		[]StockUpdate{{SKU: pInfoA.SKU, Stock: 25}})
+	if err != nil {
+		t.Errorf("Expected error nil, but got error => %s", err.Error())
+	} else if results[0].PreviousStock != 10 || results[0].Stock != 25 {
+		t.Errorf("Expected stock 10 to 25, but got %v", results[0])
+	}
+
+	// set stocks including product of another user, all rolled back
+	results, err = SetStocks(context.Background(), DB, 1, []StockUpdate{
+		{SKU: pInfoA.SKU, Stock: 1},
+		{SKU: pInfoB.SKU, Stock: 1},
+	})
+	if !errors.Is(err, ErrBatchItemInvalid) {
+		t.Errorf("Expected error batch item invalid, but got %v", err)
+	} else if results[0].Error != "" || results[1].Error == "" {
+		t.Errorf("Expected only second item error, but got %v", results)
+	}
+
+	p, err := GetProductBySKU(context.Background(), DB, pInfoA.SKU)
+	if err != nil {
+		t.Errorf("There's an error when get data product => %s", err.Error())
+	} else if p.ProductInfo.Stock != 25 {
+		t.Errorf("Expected stock 25 after rollback, but got %v",
+			p.ProductInfo.Stock)
+	}
+
+	// truncate tables after test
+	_, err = DB.Exec("TRUNCATE product_productinfo RESTART IDENTITY CASCADE")
+	if err != nil {
+		log.Fatalf("There's an error when truncating "+
+			"table product_productinfo => %s",
+			err.Error())
+	}
+}
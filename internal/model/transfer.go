@@ -0,0 +1,106 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// ProductTransfer contain request to transfer product of SKU,
+// or all products if SKU empty, from a user to another user
+type ProductTransfer struct {
+	SKU        string `json:"sku"`
+	FromUserID int    `json:"from_user_id"`
+	ToUserID   int    `json:"to_user_id"`
+}
+
+// OwnershipTransfer contain audit entry of a product transferred
+// by an admin from a user to another user
+type OwnershipTransfer struct {
+	ID          int       `json:"id"`
+	SKU         string    `json:"sku"`
+	FromUserID  int       `json:"from_user_id"`
+	ToUserID    int       `json:"to_user_id"`
+	AdminUserID int       `json:"admin_user_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TransferProducts transfer product of SKU, or all products including
+// soft deleted ones if SKU empty, of a user to another user in one
+// transaction, each recorded as new version and audit entry by admin
+//
+// return sql.ErrNoRows if no product of the user found
+func TransferProducts(ctx context.Context, DB *sql.DB, t ProductTransfer,
+	adminUserID int) ([]OwnershipTransfer, error) {
+	// begin transaction
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return []OwnershipTransfer{}, err
+	}
+	defer tx.Rollback() // rollback transaction if fail
+
+	// transfer the products
+	rows, err := tx.QueryContext(ctx, `
+		UPDATE product_productinfo
+		SET account_user_id = $1, updated_at = NOW(), version = version + 1
+		WHERE account_user_id = $2 AND ($3 = '' OR sku = $3)
+		RETURNING `+productInfoColumns,
+		t.ToUserID, t.FromUserID, t.SKU)
+	if err != nil {
+		return []OwnershipTransfer{}, err
+	}
+
+	pInfos := []ProductInfo{}
+	for rows.Next() {
+		pInfo := ProductInfo{}
+		err = scanProductInfo(rows, &pInfo)
+		if err != nil {
+			rows.Close()
+			return []OwnershipTransfer{}, err
+		}
+		pInfos = append(pInfos, pInfo)
+	}
+	rows.Close()
+	if rows.Err() != nil {
+		return []OwnershipTransfer{}, rows.Err()
+	}
+	if len(pInfos) == 0 {
+		return []OwnershipTransfer{}, sql.ErrNoRows
+	}
+
+	// record transferred products as new versions and audit entries
+	transfers := []OwnershipTransfer{}
+	for _, pInfo := range pInfos {
+		err = insertProductVersion(ctx, tx, pInfo)
+		if err != nil {
+			return []OwnershipTransfer{}, err
+		}
+
+		transfer := OwnershipTransfer{
+			SKU:         pInfo.SKU,
+			FromUserID:  t.FromUserID,
+			ToUserID:    t.ToUserID,
+			AdminUserID: adminUserID,
+		}
+		err = tx.QueryRowContext(ctx, `INSERT INTO
+			product_ownershiptransfer(
+				from_user_id, to_user_id, admin_user_id,
+				product_productinfo_id)
+			VALUES($1,$2,$3,$4)
+			RETURNING id, created_at`,
+			t.FromUserID, t.ToUserID, adminUserID, pInfo.ID).Scan(
+			&transfer.ID, &transfer.CreatedAt)
+		if err != nil {
+			return []OwnershipTransfer{}, err
+		}
+		transfers = append(transfers, transfer)
+	}
+
+	// commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return []OwnershipTransfer{}, err
+	}
+
+	return transfers, nil
+}
@@ -0,0 +1,236 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/reyhanfikridz/ecom-product-service/internal/config"
+	"github.com/reyhanfikridz/ecom-product-service/internal/richtext"
+)
+
+// ProductTranslation contain name and description of a product
+// in a locale other than the default locale
+type ProductTranslation struct {
+	Locale      string    `json:"locale" form:"-"`
+	Name        string    `json:"name" form:"name"`
+	Description string    `json:"description" form:"description"`
+	UpdatedAt   time.Time `json:"updated_at" form:"-"`
+}
+
+// localePattern BCP 47 like locale, e.g. id or en-us, after normalized
+var localePattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})*$`)
+
+// NormalizeLocale get locale in lower case with hyphen separator,
+// e.g. en_US is en-us
+func NormalizeLocale(locale string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(locale)),
+		"_", "-")
+}
+
+// IsLocaleValid check if normalized locale is valid
+func IsLocaleValid(locale string) bool {
+	return len(locale) <= 35 && localePattern.MatchString(locale)
+}
+
+// Localize set name and description of product info from the translation
+// of the first locale found in locales by preference, stopping at
+// the default locale which is the product info itself
+func (pi *ProductInfo) Localize(translations map[string]ProductTranslation,
+	locales []string, defaultLocale string) {
+	pi.Locale = defaultLocale
+	for _, locale := range locales {
+		if locale == defaultLocale {
+			return
+		}
+
+		t, ok := translations[locale]
+		if ok {
+			pi.Name = t.Name
+			pi.Description = t.Description
+			pi.DescriptionHTML = richtext.Render(pi.DescriptionFormat,
+				t.Description)
+			pi.Locale = locale
+			return
+		}
+	}
+}
+
+// LocalizeProducts localize name and description of products into
+// the first available locale of locales by preference, falling back to
+// the default locale
+func LocalizeProducts(ctx context.Context, DB *sql.DB, products []Product,
+	locales []string) error {
+	productIDs := []int64{}
+	for _, p := range products {
+		productIDs = append(productIDs, int64(p.ProductInfo.ID))
+	}
+
+	rows, err := DB.QueryContext(ctx, `
+		SELECT locale, name, COALESCE(description, ''), updated_at,
+			product_productinfo_id
+		FROM product_producttranslation
+		WHERE product_productinfo_id = ANY($1) AND locale = ANY($2)`,
+		pq.Array(productIDs), pq.Array(locales))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	translations := map[int]map[string]ProductTranslation{}
+	for rows.Next() {
+		t := ProductTranslation{}
+		var productID int
+		err = rows.Scan(&t.Locale, &t.Name, &t.Description, &t.UpdatedAt,
+			&productID)
+		if err != nil {
+			return err
+		}
+
+		if translations[productID] == nil {
+			translations[productID] = map[string]ProductTranslation{}
+		}
+		translations[productID][t.Locale] = t
+	}
+	if rows.Err() != nil {
+		return rows.Err()
+	}
+
+	for i := range products {
+		products[i].ProductInfo.Localize(
+			translations[products[i].ProductInfo.ID], locales,
+			config.DefaultLocale)
+	}
+
+	return nil
+}
+
+// GetProductTranslationsBySKU get translations of product by SKU
+// from database, sorted by locale
+func GetProductTranslationsBySKU(ctx context.Context, DB *sql.DB,
+	SKU string) ([]ProductTranslation, error) {
+	translations := []ProductTranslation{}
+
+	rows, err := DB.QueryContext(ctx, `
+		SELECT t.locale, t.name, COALESCE(t.description, ''), t.updated_at
+		FROM product_producttranslation t
+		JOIN product_productinfo p ON p.id = t.product_productinfo_id
+		WHERE p.sku = $1 AND p.deleted_at IS NULL
+		ORDER BY t.locale`,
+		SKU)
+	if err != nil {
+		return translations, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		t := ProductTranslation{}
+		err = rows.Scan(&t.Locale, &t.Name, &t.Description, &t.UpdatedAt)
+		if err != nil {
+			return translations, err
+		}
+
+		translations = append(translations, t)
+	}
+
+	return translations, rows.Err()
+}
+
+// SetProductTranslationBySKU insert or replace translation of product
+// by SKU owned by user ID, its description is sanitized in
+// the product description format
+//
+// return sql.ErrNoRows if product not found or not owned by the user
+func SetProductTranslationBySKU(ctx context.Context, DB *sql.DB, SKU string,
+	userID int, t ProductTranslation) (ProductTranslation, error) {
+	// begin transaction
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return t, err
+	}
+	defer tx.Rollback() // rollback transaction if fail
+
+	// touch product so its cache and ETag are invalidated
+	var productID int
+	var descriptionFormat string
+	err = tx.QueryRowContext(ctx, `
+		UPDATE product_productinfo
+		SET updated_at = NOW()
+		WHERE sku = $1 AND account_user_id = $2 AND deleted_at IS NULL
+		RETURNING id, description_format`,
+		SKU, userID).Scan(&productID, &descriptionFormat)
+	if err != nil {
+		return t, err
+	}
+	t.Description = richtext.Clean(descriptionFormat, t.Description)
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO product_producttranslation(
+			locale, name, description, product_productinfo_id)
+		VALUES($1,$2,$3,$4)
+		ON CONFLICT (product_productinfo_id, locale) DO UPDATE
+		SET name = EXCLUDED.name, description = EXCLUDED.description,
+			updated_at = NOW()
+		RETURNING updated_at`,
+		t.Locale, t.Name, t.Description, productID).Scan(&t.UpdatedAt)
+	if err != nil {
+		return t, err
+	}
+
+	// commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return t, err
+	}
+
+	return t, nil
+}
+
+// DeleteProductTranslationBySKU delete translation in locale of product
+// by SKU owned by user ID
+//
+// return sql.ErrNoRows if product or its translation not found,
+// or product not owned by the user
+func DeleteProductTranslationBySKU(ctx context.Context, DB *sql.DB,
+	SKU string, userID int, locale string) error {
+	// begin transaction
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // rollback transaction if fail
+
+	// touch product so its cache and ETag are invalidated
+	var productID int
+	err = tx.QueryRowContext(ctx, `
+		UPDATE product_productinfo
+		SET updated_at = NOW()
+		WHERE sku = $1 AND account_user_id = $2 AND deleted_at IS NULL
+		RETURNING id`,
+		SKU, userID).Scan(&productID)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		DELETE FROM product_producttranslation
+		WHERE product_productinfo_id = $1 AND locale = $2`,
+		productID, locale)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	// commit transaction
+	return tx.Commit()
+}
@@ -0,0 +1,71 @@
+package model
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// product units of measure
+const (
+	UnitPiece = "piece"
+	UnitPack  = "pack"
+	UnitBox   = "box"
+	UnitDozen = "dozen"
+	UnitKg    = "kg"
+	UnitGram  = "gram"
+	UnitLiter = "liter"
+	UnitMl    = "ml"
+	UnitMeter = "meter"
+	UnitCm    = "cm"
+)
+
+// productUnits allowed product units of measure,
+// true if the unit can be sold in fractional quantity
+var productUnits = map[string]bool{
+	UnitPiece: false,
+	UnitPack:  false,
+	UnitBox:   false,
+	UnitDozen: false,
+	UnitKg:    true,
+	UnitGram:  true,
+	UnitLiter: true,
+	UnitMl:    true,
+	UnitMeter: true,
+	UnitCm:    true,
+}
+
+// maximum decimal places of a fractional quantity
+const quantityDecimals = 3
+
+// ErrQuantityInvalid error when quantity is fractional for a unit
+// sold only in whole quantity
+var ErrQuantityInvalid = errors.New("quantity invalid for the product unit")
+
+// GetProductUnits get allowed product units of measure, sorted
+func GetProductUnits() []string {
+	units := []string{}
+	for unit := range productUnits {
+		units = append(units, unit)
+	}
+	sort.Strings(units)
+
+	return units
+}
+
+// IsProductUnitValid check if unit is an allowed product unit of measure
+func IsProductUnitValid(unit string) bool {
+	_, ok := productUnits[unit]
+	return ok
+}
+
+// IsQuantityValid check if quantity has at most 3 decimal places,
+// and is a whole number if the unit can't be sold in fractional quantity
+func IsQuantityValid(unit string, qty float64) bool {
+	scale := math.Pow10(quantityDecimals)
+	if !productUnits[unit] {
+		scale = 1
+	}
+
+	return math.Abs(qty*scale-math.Round(qty*scale)) < 1e-6
+}
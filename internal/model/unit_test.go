@@ -0,0 +1,58 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import "testing"
+
+// TestIsQuantityValid test IsQuantityValid
+func TestIsQuantityValid(t *testing.T) {
+	// create testing table
+	testTable := []struct {
+		TestName       string
+		Unit           string
+		Qty            float64
+		ExpectedResult bool
+	}{
+		{
+			TestName:       "Whole quantity of piece",
+			Unit:           UnitPiece,
+			Qty:            3,
+			ExpectedResult: true,
+		},
+		{
+			TestName:       "Fractional quantity of piece",
+			Unit:           UnitPiece,
+			Qty:            2.5,
+			ExpectedResult: false,
+		},
+		{
+			TestName:       "Fractional quantity of kg",
+			Unit:           UnitKg,
+			Qty:            0.125,
+			ExpectedResult: true,
+		},
+		{
+			TestName:       "Too precise quantity of kg",
+			Unit:           UnitKg,
+			Qty:            0.1255,
+			ExpectedResult: false,
+		},
+		{
+			TestName:       "Fractional quantity of unknown unit",
+			Unit:           "",
+			Qty:            1.5,
+			ExpectedResult: false,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		result := IsQuantityValid(test.Unit, test.Qty)
+		if result != test.ExpectedResult {
+			t.Errorf("[%s] Expected %v, but got %v",
+				test.TestName, test.ExpectedResult, result)
+		}
+	}
+}
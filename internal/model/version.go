@@ -0,0 +1,131 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// ProductVersion contain a full version of product info,
+// recorded on every product info insert, update, and rollback
+type ProductVersion struct {
+	Version     int         `json:"version"`
+	ProductInfo ProductInfo `json:"product_info"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+// insertProductVersion record version of product info in transaction
+func insertProductVersion(ctx context.Context, tx *sql.Tx,
+	pInfo ProductInfo) error {
+	_, err := tx.ExecContext(ctx, `INSERT INTO
+		product_productversion(
+			version, name, price, weight, description, stock,
+			account_user_id, product_productinfo_id)
+		VALUES($1,$2,$3,$4,$5,$6,$7,$8)`,
+		pInfo.Version, pInfo.Name, pInfo.Price, pInfo.Weight,
+		pInfo.Description, pInfo.Stock, pInfo.UserID, pInfo.ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetProductVersionsBySKU get all versions of product info by SKU,
+// newest first
+func GetProductVersionsBySKU(ctx context.Context, DB *sql.DB,
+	SKU string) ([]ProductVersion, error) {
+	versions := []ProductVersion{}
+
+	rows, err := DB.QueryContext(ctx, `
+		SELECT
+			v.version, p.id, p.sku, v.name, v.price, v.weight,
+			v.description, v.stock, v.account_user_id, v.created_at
+		FROM product_productversion v
+		JOIN product_productinfo p ON p.id = v.product_productinfo_id
+		WHERE p.sku = $1
+		ORDER BY v.version DESC`,
+		SKU)
+	if err != nil {
+		return []ProductVersion{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		v := ProductVersion{}
+		err = rows.Scan(&v.Version, &v.ProductInfo.ID, &v.ProductInfo.SKU,
+			&v.ProductInfo.Name, &v.ProductInfo.Price, &v.ProductInfo.Weight,
+			&v.ProductInfo.Description, &v.ProductInfo.Stock,
+			&v.ProductInfo.UserID, &v.CreatedAt)
+		if err != nil {
+			return []ProductVersion{}, err
+		}
+
+		v.ProductInfo.Version = v.Version
+		versions = append(versions, v)
+	}
+
+	return versions, rows.Err()
+}
+
+// RollbackProductInfoBySKU roll back name, price, weight, and description
+// of product by SKU to a previous version, recorded as a new version
+//
+// stock is kept as is since it's changed by orders, not by listing edits
+//
+// return sql.ErrNoRows if product or version not found
+func RollbackProductInfoBySKU(ctx context.Context, DB *sql.DB, SKU string,
+	version int) (ProductInfo, error) {
+	pInfo := ProductInfo{}
+
+	// begin transaction
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return pInfo, err
+	}
+	defer tx.Rollback() // rollback transaction if fail
+
+	// get previous version, locking the product row until transaction end
+	old := ProductInfo{}
+	err = tx.QueryRowContext(ctx, `
+		SELECT v.name, v.price, v.weight, v.description
+		FROM product_productversion v
+		JOIN product_productinfo p ON p.id = v.product_productinfo_id
+		WHERE p.sku = $1 AND p.deleted_at IS NULL AND v.version = $2
+		FOR UPDATE OF p`,
+		SKU, version).Scan(&old.Name, &old.Price, &old.Weight, &old.Description)
+	if err != nil {
+		return pInfo, err
+	}
+
+	// update product info with the previous version
+	row := tx.QueryRowContext(ctx, `
+		UPDATE product_productinfo
+		SET name = $1, price = $2, weight = $3, description = $4,
+			updated_at = NOW(), version = version + 1
+		WHERE sku = $5
+		RETURNING `+productInfoColumns,
+		old.Name, old.Price, old.Weight, old.Description, SKU)
+	if row.Err() != nil {
+		return pInfo, row.Err()
+	}
+
+	err = scanProductInfo(row, &pInfo)
+	if err != nil {
+		return pInfo, err
+	}
+
+	// record rolled back product info as new version
+	err = insertProductVersion(ctx, tx, pInfo)
+	if err != nil {
+		return pInfo, err
+	}
+
+	// commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return pInfo, err
+	}
+
+	return pInfo, nil
+}
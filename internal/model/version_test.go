@@ -0,0 +1,90 @@
+/*
+Package model containing structs and functions for
+database transaction
+*/
+package model
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"testing"
+)
+
+// TestRollbackProductInfoBySKU test GetProductVersionsBySKU
+// and RollbackProductInfoBySKU
+//
+// Required for the test:
+//
+// - InsertProductInfo
+//
+// - UpdateProductInfoBySKU
+func TestRollbackProductInfoBySKU(t *testing.T) {
+	// get testing DB connection
+	DB, err := getTestDBConnection()
+	if err != nil {
+		t.Errorf("There's an error when initialize "+
+			"testing database connection => %s", err.Error())
+	}
+
+	// insert product into database and update it
+	pInfo, err := InsertProductInfo(context.Background(), DB, ProductInfo{
+		Name: "PRODUCT A", Price: 1000, Weight: 1, Stock: 10, UserID: 1,
+	})
+	if err != nil {
+		t.Errorf("There's an error when insert data product info => %s",
+			err.Error())
+	}
+	pInfo.Name = "PRODUCT A v2"
+	pInfo.Price = 2000
+	pInfo.Stock = 8
+	pInfo, err = UpdateProductInfoBySKU(context.Background(), DB, pInfo)
+	if err != nil {
+		t.Errorf("There's an error when update data product info => %s",
+			err.Error())
+	}
+
+	// roll back to not existing version
+	_, err = RollbackProductInfoBySKU(context.Background(), DB, pInfo.SKU, 10)
+	if err != sql.ErrNoRows {
+		t.Errorf("Expected error %v, but got %v", sql.ErrNoRows, err)
+	}
+
+	// roll back to first version, stock must be kept
+	result, err := RollbackProductInfoBySKU(context.Background(), DB, pInfo.SKU,
+		1)
+	if err != nil {
+		t.Errorf("Expected error nil, but got error => %s", err.Error())
+	}
+	if result.Name != "PRODUCT A" || result.Price != 1000 ||
+		result.Stock != 8 || result.Version != 3 {
+		t.Errorf("Expected product info rolled back to version 1 "+
+			"as version 3, but got %v", result)
+	}
+
+	// get product versions and check result, newest first
+	versions, err := GetProductVersionsBySKU(context.Background(), DB,
+		pInfo.SKU)
+	if err != nil {
+		t.Errorf("Expected error nil, but got error => %s", err.Error())
+	}
+	if len(versions) != 3 {
+		t.Fatalf("Expected 3 versions, but got %d", len(versions))
+	}
+	for i, expectedName := range []string{
+		"PRODUCT A", "PRODUCT A v2", "PRODUCT A"} {
+		if versions[i].Version != 3-i ||
+			versions[i].ProductInfo.Name != expectedName {
+			t.Errorf("Expected version %d with name %s, but got %v",
+				3-i, expectedName, versions[i])
+		}
+	}
+
+	// truncate tables after test
+	_, err = DB.Exec("TRUNCATE product_productinfo RESTART IDENTITY CASCADE")
+	if err != nil {
+		log.Fatalf("There's an error when truncating "+
+			"table product_productinfo => %s",
+			err.Error())
+	}
+}
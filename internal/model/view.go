@@ -0,0 +1,46 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// RecordProductView record a view of product by SKU from a viewer,
+// its view count is only increased if the viewer hasn't viewed
+// the product within the window
+//
+// return true if the view is counted, sql.ErrNoRows if product not found
+func RecordProductView(ctx context.Context, DB *sql.DB, SKU string,
+	viewerKey string, window time.Duration) (bool, error) {
+	var found, counted int
+	err := DB.QueryRowContext(ctx, `
+		WITH p AS (
+			SELECT id FROM product_productinfo
+			WHERE sku = $1 AND deleted_at IS NULL
+		), v AS (
+			INSERT INTO product_productview AS pv(
+				viewer_key, viewed_at, product_productinfo_id)
+			SELECT $2, NOW(), id FROM p
+			ON CONFLICT (product_productinfo_id, viewer_key) DO UPDATE
+				SET viewed_at = EXCLUDED.viewed_at
+				WHERE pv.viewed_at <=
+					EXCLUDED.viewed_at - $3 * INTERVAL '1 millisecond'
+			RETURNING product_productinfo_id
+		), u AS (
+			UPDATE product_productinfo
+			SET view_count = view_count + 1
+			WHERE id IN (SELECT product_productinfo_id FROM v)
+			RETURNING id
+		)
+		SELECT (SELECT COUNT(*) FROM p), (SELECT COUNT(*) FROM u)`,
+		SKU, viewerKey, window.Milliseconds()).Scan(&found, &counted)
+	if err != nil {
+		return false, err
+	}
+	if found == 0 {
+		return false, sql.ErrNoRows
+	}
+
+	return counted > 0, nil
+}
@@ -0,0 +1,107 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// webhook event names which can be subscribed
+const (
+	WebhookEventProductCreated = "product.created"
+	WebhookEventProductUpdated = "product.updated"
+	WebhookEventProductDeleted = "product.deleted"
+	WebhookEventStockChanged   = "stock.changed"
+	WebhookEventStockLow       = "stock.low"
+)
+
+// WebhookEvents all webhook event names which can be subscribed
+var WebhookEvents = []string{
+	WebhookEventProductCreated,
+	WebhookEventProductUpdated,
+	WebhookEventProductDeleted,
+	WebhookEventStockChanged,
+	WebhookEventStockLow,
+}
+
+// WebhookSubscription contain a seller webhook URL subscribed to events
+type WebhookSubscription struct {
+	ID        int       `json:"id" form:"id"`
+	URL       string    `json:"url" form:"url"`
+	Events    []string  `json:"events" form:"events"`
+	Secret    string    `json:"secret,omitempty" form:"-"`
+	UserID    int       `json:"user_id" form:"-"`
+	CreatedAt time.Time `json:"created_at" form:"-"`
+}
+
+// InsertWebhookSubscription insert a webhook subscription into database
+func InsertWebhookSubscription(ctx context.Context, DB *sql.DB,
+	sub WebhookSubscription) (WebhookSubscription, error) {
+	err := DB.QueryRowContext(ctx, `INSERT INTO
+		product_webhooksubscription(url, events, secret, account_user_id)
+		VALUES($1,$2,$3,$4) RETURNING id, created_at`,
+		sub.URL, pq.Array(sub.Events), sub.Secret, sub.UserID,
+	).Scan(&sub.ID, &sub.CreatedAt)
+	if err != nil {
+		return sub, err
+	}
+
+	return sub, nil
+}
+
+// GetWebhookSubscriptions get webhook subscriptions of a user,
+// filtered by subscribed event if not empty
+func GetWebhookSubscriptions(ctx context.Context, DB *sql.DB, userID int,
+	event string) ([]WebhookSubscription, error) {
+	subs := []WebhookSubscription{}
+
+	rows, err := DB.QueryContext(ctx, `
+		SELECT id, url, events, secret, account_user_id, created_at
+		FROM product_webhooksubscription
+		WHERE account_user_id = $1 AND ($2 = '' OR $2 = ANY(events))
+		ORDER BY id`,
+		userID, event)
+	if err != nil {
+		return []WebhookSubscription{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		sub := WebhookSubscription{}
+		err = rows.Scan(&sub.ID, &sub.URL, pq.Array(&sub.Events),
+			&sub.Secret, &sub.UserID, &sub.CreatedAt)
+		if err != nil {
+			return []WebhookSubscription{}, err
+		}
+
+		subs = append(subs, sub)
+	}
+
+	return subs, nil
+}
+
+// DeleteWebhookSubscription delete webhook subscription of a user by ID
+//
+// return sql.ErrNoRows if the subscription not found
+func DeleteWebhookSubscription(ctx context.Context, DB *sql.DB, ID int,
+	userID int) error {
+	result, err := DB.ExecContext(ctx, `
+		DELETE FROM product_webhooksubscription
+		WHERE id = $1 AND account_user_id = $2`,
+		ID, userID)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+/*
+Package netguard containing HTTP client for requests to URLs given by
+users, refusing to connect to loopback, private, link-local, and
+unspecified addresses so those requests can't reach internal hosts
+*/
+package netguard
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// maximum redirects followed by the client
+const maxRedirects = 10
+
+// ErrAddressNotAllowed error of connection to address of internal network
+var ErrAddressNotAllowed = errors.New("address not allowed")
+
+// IsIPAllowed check IP is a public address, not loopback, private,
+// link-local, multicast, or unspecified
+func IsIPAllowed(ip net.IP) bool {
+	return ip != nil && !ip.IsLoopback() && !ip.IsPrivate() &&
+		!ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() &&
+		!ip.IsUnspecified()
+}
+
+// IsHostAllowed check host of URL isn't an IP address not allowed,
+// host names are only checked after resolved when connecting
+func IsHostAllowed(host string) bool {
+	ip := net.ParseIP(host)
+	return ip == nil || IsIPAllowed(ip)
+}
+
+// NewClient create HTTP client with timeout, connecting only to
+// allowed addresses after host names resolved, including on redirects,
+// and never through proxy
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if !IsIPAllowed(net.ParseIP(host)) {
+				return fmt.Errorf("%w: %s", ErrAddressNotAllowed, host)
+			}
+
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   timeout,
+			ResponseHeaderTimeout: timeout,
+			MaxIdleConns:          100,
+			IdleConnTimeout:       90 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("redirect to scheme '%s' not allowed",
+					req.URL.Scheme)
+			}
+			if !IsHostAllowed(req.URL.Hostname()) {
+				return fmt.Errorf("%w: %s", ErrAddressNotAllowed,
+					req.URL.Hostname())
+			}
+
+			return nil
+		},
+	}
+}
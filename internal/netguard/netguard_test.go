@@ -0,0 +1,73 @@
+/*
+Package netguard containing HTTP client for requests to URLs given by
+users, refusing to connect to loopback, private, link-local, and
+unspecified addresses so those requests can't reach internal hosts
+*/
+package netguard
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestIsIPAllowed test IsIPAllowed
+func TestIsIPAllowed(t *testing.T) {
+	for ip, expected := range map[string]bool{
+		"93.184.216.34":    true,
+		"2606:4700::1111":  true,
+		"127.0.0.1":        false,
+		"::1":              false,
+		"10.1.2.3":         false,
+		"172.16.0.1":       false,
+		"192.168.1.1":      false,
+		"169.254.169.254":  false,
+		"fe80::1":          false,
+		"fd00::1":          false,
+		"0.0.0.0":          false,
+		"::":               false,
+		"::ffff:127.0.0.1": false,
+		"224.0.0.1":        false,
+	} {
+		if IsIPAllowed(net.ParseIP(ip)) != expected {
+			t.Errorf("Expected IP %s allowed %v, but got %v", ip, expected,
+				!expected)
+		}
+	}
+}
+
+// TestNewClient test client of NewClient refusing to connect
+// to loopback server and redirect to internal address
+func TestNewClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("internal"))
+		}))
+	defer server.Close()
+
+	client := NewClient(5 * time.Second)
+	resp, err := client.Get(server.URL)
+	if err == nil {
+		resp.Body.Close()
+	}
+	if !errors.Is(err, ErrAddressNotAllowed) {
+		t.Errorf("Expected error address not allowed, but got %v", err)
+	}
+
+	for redirectURL, allowed := range map[string]bool{
+		"https://example.com/a.png":                true,
+		"http://169.254.169.254/latest/meta-data/": false,
+		"http://[::1]:8080/":                       false,
+		"file:///etc/passwd":                       false,
+	} {
+		req, _ := http.NewRequest("GET", redirectURL, nil)
+		err = client.CheckRedirect(req, []*http.Request{{}})
+		if (err == nil) != allowed {
+			t.Errorf("Expected redirect to %s allowed %v, but got error %v",
+				redirectURL, allowed, err)
+		}
+	}
+}
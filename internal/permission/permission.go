@@ -0,0 +1,128 @@
+/*
+Package permission containing role-permission matrix declaring which
+user roles are allowed to do each action of the API
+*/
+package permission
+
+import (
+	"fmt"
+	"sort"
+)
+
+// actions of the API authorized by role, ownership of the product
+// an action applied to is still checked by its handler
+const (
+	ProductCreate         = "product.create"
+	ProductUpdate         = "product.update"
+	ProductDelete         = "product.delete"
+	ProductRestore        = "product.restore"
+	ProductRestoreAny     = "product.restore_any"
+	ProductList           = "product.list"
+	ProductListAny        = "product.list_any"
+	ProductListDeleted    = "product.list_deleted"
+	ProductListDeletedAny = "product.list_deleted_any"
+	ProductReadOwn        = "product.read_own"
+	ProductReadHidden     = "product.read_hidden"
+	ProductImport         = "product.import"
+	ProductTransfer       = "product.transfer"
+	StockRead             = "stock.read"
+	StockReadAny          = "stock.read_any"
+	StockUpdate           = "stock.update"
+	StockDecrease         = "stock.decrease"
+	StatsRead             = "stats.read"
+	WebhookManage         = "webhook.manage"
+	MaintenanceManage     = "maintenance.manage"
+	ShopManage            = "shop.manage"
+	ProductReport         = "product.report"
+	ReportReview          = "report.review"
+)
+
+// Matrix roles allowed to do each action
+type Matrix map[string][]string
+
+// defaultMatrix roles allowed to do each action by default
+var defaultMatrix = Matrix{
+	ProductCreate:         {"seller"},
+	ProductUpdate:         {"seller"},
+	ProductDelete:         {"seller"},
+	ProductRestore:        {"seller"},
+	ProductRestoreAny:     {"admin"},
+	ProductList:           {"buyer"},
+	ProductListAny:        {"admin"},
+	ProductListDeleted:    {"seller"},
+	ProductListDeletedAny: {"admin"},
+	ProductReadOwn:        {"seller"},
+	ProductReadHidden:     {"admin"},
+	ProductImport:         {"seller"},
+	ProductTransfer:       {"admin"},
+	StockRead:             {"seller"},
+	StockReadAny:          {"admin"},
+	StockUpdate:           {"seller"},
+	StockDecrease:         {"seller", "service"},
+	StatsRead:             {"admin"},
+	WebhookManage:         {"seller"},
+	MaintenanceManage:     {"admin"},
+	ShopManage:            {"seller"},
+	ProductReport:         {"buyer"},
+	ReportReview:          {"admin"},
+}
+
+// DefaultMatrix get copy of the default role-permission matrix
+func DefaultMatrix() Matrix {
+	m := Matrix{}
+	for action, roles := range defaultMatrix {
+		m[action] = append([]string{}, roles...)
+	}
+
+	return m
+}
+
+// NewMatrix create role-permission matrix of the default matrix with
+// roles of actions replaced by overrides, return error if any action
+// of overrides unknown
+func NewMatrix(overrides map[string][]string) (Matrix, error) {
+	m := DefaultMatrix()
+	for action, roles := range overrides {
+		if _, ok := m[action]; !ok {
+			return nil, fmt.Errorf("permission action '%s' unknown, "+
+				"must be one of %v", action, Actions())
+		}
+		m[action] = append([]string{}, roles...)
+	}
+
+	return m, nil
+}
+
+// Actions get all actions authorized by role, sorted
+func Actions() []string {
+	actions := []string{}
+	for action := range defaultMatrix {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+
+	return actions
+}
+
+// Allows check if role is allowed to do action, unknown action
+// is allowed to no role
+func (m Matrix) Allows(role string, action string) bool {
+	for _, allowedRole := range m[action] {
+		if allowedRole == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AllowsAny check if any of roles is allowed to do action
+func (m Matrix) AllowsAny(roles []string, action string) bool {
+	for _, role := range roles {
+		if m.Allows(role, action) {
+			return true
+		}
+	}
+
+	return false
+}
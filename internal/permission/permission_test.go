@@ -0,0 +1,60 @@
+/*
+Package permission containing role-permission matrix declaring which
+user roles are allowed to do each action of the API
+*/
+package permission
+
+import (
+	"testing"
+)
+
+// TestNewMatrix test NewMatrix replacing roles of the default matrix
+func TestNewMatrix(t *testing.T) {
+	m, err := NewMatrix(map[string][]string{
+		StockDecrease: {"service"},
+		StatsRead:     {},
+	})
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+
+	// create testing table
+	testTable := []struct {
+		Role     string
+		Action   string
+		Expected bool
+	}{
+		{"service", StockDecrease, true},
+		{"seller", StockDecrease, false},
+		{"admin", StatsRead, false},
+		{"seller", ProductCreate, true},
+		{"buyer", ProductCreate, false},
+		{"admin", "unknown.action", false},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		if m.Allows(test.Role, test.Action) != test.Expected {
+			t.Errorf("Expected role %s allowed to %s %t, but got %t",
+				test.Role, test.Action, test.Expected, !test.Expected)
+		}
+	}
+
+	// user of many roles is allowed if any of them is allowed
+	if !m.AllowsAny([]string{"buyer", "seller"}, ProductCreate) ||
+		m.AllowsAny([]string{"buyer", "admin"}, ProductCreate) {
+		t.Errorf("Expected only roles including seller allowed to %s",
+			ProductCreate)
+	}
+
+	// the default matrix isn't changed by the overrides
+	if !DefaultMatrix().Allows("seller", StockDecrease) {
+		t.Errorf("Expected default matrix unchanged, but got changed")
+	}
+
+	// unknown action can't be overridden
+	_, err = NewMatrix(map[string][]string{"product.fly": {"admin"}})
+	if err == nil {
+		t.Errorf("Expected error of unknown action, but got nil")
+	}
+}
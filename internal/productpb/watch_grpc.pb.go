@@ -0,0 +1,154 @@
+// Product watch service streaming product events to internal consumers
+// (search indexer, cache warmers), authorized by internal service token
+// sent as metadata "authorization: Bearer <token>".
+//
+// Go code in internal/productpb is generated by:
+//
+//	protoc --go_out=. --go_opt=module=github.com/reyhanfikridz/ecom-product-service \
+//		--go-grpc_out=. --go-grpc_opt=module=github.com/reyhanfikridz/ecom-product-service \
+//		proto/product/v1/watch.proto
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: proto/product/v1/watch.proto
+
+package productpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	ProductWatch_WatchProducts_FullMethodName = "/product.v1.ProductWatch/WatchProducts"
+)
+
+// ProductWatchClient is the client API for ProductWatch service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ProductWatchClient interface {
+	// WatchProducts stream product events occurred after resume token,
+	// or occurred from now if resume token is empty. Stream fails with
+	// OUT_OF_RANGE if events after resume token were already purged,
+	// so the consumer must resync all products.
+	WatchProducts(ctx context.Context, in *WatchProductsRequest, opts ...grpc.CallOption) (ProductWatch_WatchProductsClient, error)
+}
+
+type productWatchClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewProductWatchClient(cc grpc.ClientConnInterface) ProductWatchClient {
+	return &productWatchClient{cc}
+}
+
+func (c *productWatchClient) WatchProducts(ctx context.Context, in *WatchProductsRequest, opts ...grpc.CallOption) (ProductWatch_WatchProductsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ProductWatch_ServiceDesc.Streams[0], ProductWatch_WatchProducts_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &productWatchWatchProductsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ProductWatch_WatchProductsClient interface {
+	Recv() (*ProductEvent, error)
+	grpc.ClientStream
+}
+
+type productWatchWatchProductsClient struct {
+	grpc.ClientStream
+}
+
+func (x *productWatchWatchProductsClient) Recv() (*ProductEvent, error) {
+	m := new(ProductEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ProductWatchServer is the server API for ProductWatch service.
+// All implementations must embed UnimplementedProductWatchServer
+// for forward compatibility
+type ProductWatchServer interface {
+	// WatchProducts stream product events occurred after resume token,
+	// or occurred from now if resume token is empty. Stream fails with
+	// OUT_OF_RANGE if events after resume token were already purged,
+	// so the consumer must resync all products.
+	WatchProducts(*WatchProductsRequest, ProductWatch_WatchProductsServer) error
+	mustEmbedUnimplementedProductWatchServer()
+}
+
+// UnimplementedProductWatchServer must be embedded to have forward compatible implementations.
+type UnimplementedProductWatchServer struct {
+}
+
+func (UnimplementedProductWatchServer) WatchProducts(*WatchProductsRequest, ProductWatch_WatchProductsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchProducts not implemented")
+}
+func (UnimplementedProductWatchServer) mustEmbedUnimplementedProductWatchServer() {}
+
+// UnsafeProductWatchServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ProductWatchServer will
+// result in compilation errors.
+type UnsafeProductWatchServer interface {
+	mustEmbedUnimplementedProductWatchServer()
+}
+
+func RegisterProductWatchServer(s grpc.ServiceRegistrar, srv ProductWatchServer) {
+	s.RegisterService(&ProductWatch_ServiceDesc, srv)
+}
+
+func _ProductWatch_WatchProducts_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchProductsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ProductWatchServer).WatchProducts(m, &productWatchWatchProductsServer{stream})
+}
+
+type ProductWatch_WatchProductsServer interface {
+	Send(*ProductEvent) error
+	grpc.ServerStream
+}
+
+type productWatchWatchProductsServer struct {
+	grpc.ServerStream
+}
+
+func (x *productWatchWatchProductsServer) Send(m *ProductEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// ProductWatch_ServiceDesc is the grpc.ServiceDesc for ProductWatch service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ProductWatch_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "product.v1.ProductWatch",
+	HandlerType: (*ProductWatchServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchProducts",
+			Handler:       _ProductWatch_WatchProducts_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "proto/product/v1/watch.proto",
+}
@@ -0,0 +1,346 @@
+/*
+Package richtext containing sanitizing and rendering of product
+descriptions written in plain text, Markdown, or limited HTML
+into HTML safe to be embedded in a page
+*/
+package richtext
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+
+	xhtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// description formats
+const (
+	FormatPlain    = "plain"
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
+// IsFormatValid check if description format is known
+func IsFormatValid(format string) bool {
+	return format == FormatPlain || format == FormatMarkdown ||
+		format == FormatHTML
+}
+
+// allowedTags HTML tags kept by Sanitize, other tags are removed
+// while keeping their text
+var allowedTags = map[atom.Atom]bool{
+	atom.P: true, atom.Br: true, atom.Strong: true, atom.B: true,
+	atom.Em: true, atom.I: true, atom.U: true, atom.S: true,
+	atom.Ul: true, atom.Ol: true, atom.Li: true, atom.Blockquote: true,
+	atom.Code: true, atom.Pre: true, atom.H1: true, atom.H2: true,
+	atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true, atom.A: true,
+}
+
+// droppedTags HTML tags removed by Sanitize together with their content
+var droppedTags = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Iframe: true,
+	atom.Object: true, atom.Embed: true, atom.Noscript: true,
+	atom.Template: true, atom.Textarea: true, atom.Title: true,
+	atom.Svg: true, atom.Math: true, atom.Select: true,
+}
+
+// Sanitize get HTML with only allowed tags, link href limited to
+// http, https, and mailto URL, every other attribute removed,
+// and unclosed tags closed
+func Sanitize(s string) string {
+	var b strings.Builder
+	z := xhtml.NewTokenizer(strings.NewReader(s))
+	open := []atom.Atom{}
+	dropped := 0
+
+	for {
+		tt := z.Next()
+		switch tt {
+		case xhtml.ErrorToken:
+			for i := len(open) - 1; i >= 0; i-- {
+				b.WriteString("</" + open[i].String() + ">")
+			}
+			return b.String()
+
+		case xhtml.TextToken:
+			if dropped == 0 {
+				b.WriteString(html.EscapeString(string(z.Text())))
+			}
+
+		case xhtml.StartTagToken, xhtml.SelfClosingTagToken:
+			tok := z.Token()
+			if droppedTags[tok.DataAtom] {
+				if tt == xhtml.StartTagToken {
+					dropped++
+				}
+				continue
+			}
+			if dropped > 0 || !allowedTags[tok.DataAtom] {
+				continue
+			}
+			if tok.DataAtom == atom.Br {
+				b.WriteString("<br>")
+				continue
+			}
+
+			b.WriteString("<" + tok.DataAtom.String())
+			if tok.DataAtom == atom.A {
+				for _, attr := range tok.Attr {
+					if attr.Key == "href" && isURLSafe(attr.Val) {
+						b.WriteString(` href="` + html.EscapeString(attr.Val) +
+							`" rel="nofollow noopener noreferrer"`)
+						break
+					}
+				}
+			}
+			b.WriteString(">")
+
+			if tt == xhtml.SelfClosingTagToken {
+				b.WriteString("</" + tok.DataAtom.String() + ">")
+			} else {
+				open = append(open, tok.DataAtom)
+			}
+
+		case xhtml.EndTagToken:
+			tok := z.Token()
+			if droppedTags[tok.DataAtom] {
+				if dropped > 0 {
+					dropped--
+				}
+				continue
+			}
+			if dropped > 0 || !allowedTags[tok.DataAtom] {
+				continue
+			}
+
+			// close the tag with the tags opened inside it,
+			// end tag without start tag is ignored
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] != tok.DataAtom {
+					continue
+				}
+				for j := len(open) - 1; j >= i; j-- {
+					b.WriteString("</" + open[j].String() + ">")
+				}
+				open = open[:i]
+				break
+			}
+		}
+	}
+}
+
+// isURLSafe check if link URL is absolute http, https, or mailto URL
+func isURLSafe(rawURL string) bool {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return false
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		return u.Host != ""
+	case "mailto":
+		return u.Opaque != ""
+	}
+
+	return false
+}
+
+// Clean get description in format ready to be stored,
+// HTML description is sanitized while other formats are kept as is
+// because they are escaped when rendered
+func Clean(format string, text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	if format == FormatHTML {
+		return Sanitize(text)
+	}
+
+	return text
+}
+
+// Render render description in format as safe HTML,
+// unknown format is rendered as plain text
+func Render(format string, text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	switch format {
+	case FormatHTML:
+		return Sanitize(text)
+	case FormatMarkdown:
+		return Sanitize(renderMarkdown(text))
+	}
+
+	return renderPlain(text)
+}
+
+// paragraphSeparator blank lines separating paragraphs
+var paragraphSeparator = regexp.MustCompile(`\n[ \t]*\n+`)
+
+// renderPlain render plain text as HTML paragraphs with line breaks
+func renderPlain(text string) string {
+	var b strings.Builder
+	for _, para := range paragraphSeparator.Split(strings.TrimSpace(text), -1) {
+		if para == "" {
+			continue
+		}
+		b.WriteString("<p>" + strings.ReplaceAll(html.EscapeString(para),
+			"\n", "<br>") + "</p>")
+	}
+
+	return b.String()
+}
+
+// Markdown block patterns
+var (
+	mdHeading     = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*$`)
+	mdBulletItem  = regexp.MustCompile(`^[-*+]\s+(.*)$`)
+	mdOrderedItem = regexp.MustCompile(`^\d+[.)]\s+(.*)$`)
+	mdQuote       = regexp.MustCompile(`^>\s?(.*)$`)
+)
+
+// renderMarkdown render Markdown subset as HTML: paragraphs, headings,
+// bullet and ordered lists, block quotes, fenced code blocks, and inline
+// code, strong, emphasis, and links
+func renderMarkdown(text string) string {
+	var b strings.Builder
+	para := []string{}
+	quote := []string{}
+	list := ""
+	inCode := false
+
+	flush := func() {
+		if len(para) > 0 {
+			b.WriteString("<p>" + renderInline(strings.Join(para, "\n")) +
+				"</p>")
+			para = para[:0]
+		}
+		if len(quote) > 0 {
+			b.WriteString("<blockquote><p>" +
+				renderInline(strings.Join(quote, "\n")) + "</p></blockquote>")
+			quote = quote[:0]
+		}
+		if list != "" {
+			b.WriteString("</" + list + ">")
+			list = ""
+		}
+	}
+	startList := func(tag string) {
+		if list == tag {
+			return
+		}
+		flush()
+		b.WriteString("<" + tag + ">")
+		list = tag
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if inCode {
+			if strings.HasPrefix(trimmed, "```") {
+				b.WriteString("</code></pre>")
+				inCode = false
+			} else {
+				b.WriteString(html.EscapeString(line) + "\n")
+			}
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "```") {
+			flush()
+			b.WriteString("<pre><code>")
+			inCode = true
+		} else if trimmed == "" {
+			flush()
+		} else if m := mdHeading.FindStringSubmatch(trimmed); m != nil {
+			flush()
+			tag := "h" + string(rune('0'+len(m[1])))
+			b.WriteString("<" + tag + ">" + renderInline(m[2]) +
+				"</" + tag + ">")
+		} else if m := mdBulletItem.FindStringSubmatch(trimmed); m != nil {
+			startList("ul")
+			b.WriteString("<li>" + renderInline(m[1]) + "</li>")
+		} else if m := mdOrderedItem.FindStringSubmatch(trimmed); m != nil {
+			startList("ol")
+			b.WriteString("<li>" + renderInline(m[1]) + "</li>")
+		} else if m := mdQuote.FindStringSubmatch(trimmed); m != nil {
+			if len(quote) == 0 {
+				flush()
+			}
+			quote = append(quote, m[1])
+		} else {
+			if list != "" || len(quote) > 0 {
+				flush()
+			}
+			para = append(para, trimmed)
+		}
+	}
+	if inCode {
+		b.WriteString("</code></pre>")
+	}
+	flush()
+
+	return b.String()
+}
+
+// Markdown inline patterns, matched on escaped text
+var (
+	mdLink     = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	mdStrong   = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+	mdEmphasis = regexp.MustCompile(`\*([^*]+)\*`)
+)
+
+// renderInline render Markdown inline code, strong, emphasis,
+// and links of text as HTML, the rest of text is escaped
+func renderInline(text string) string {
+	var b strings.Builder
+	parts := strings.Split(text, "`")
+	for i, part := range parts {
+		// odd parts are inside backticks, except unclosed last one
+		if i%2 == 1 && i < len(parts)-1 {
+			b.WriteString("<code>" + html.EscapeString(part) + "</code>")
+			continue
+		}
+		if i%2 == 1 {
+			part = "`" + part
+		}
+
+		s := html.EscapeString(part)
+		s = mdLink.ReplaceAllString(s, `<a href="$2">$1</a>`)
+		s = mdStrong.ReplaceAllString(s, "<strong>$1</strong>")
+		s = mdEmphasis.ReplaceAllString(s, "<em>$1</em>")
+		b.WriteString(s)
+	}
+
+	return b.String()
+}
+
+// inlineTags HTML tags not separating their text from surrounding text
+var inlineTags = map[atom.Atom]bool{
+	atom.Strong: true, atom.B: true, atom.Em: true, atom.I: true,
+	atom.U: true, atom.S: true, atom.Code: true, atom.A: true,
+}
+
+// PlainText get text of description in format without HTML tags,
+// whitespaces collapsed into single space
+func PlainText(format string, text string) string {
+	if format != FormatHTML {
+		return strings.Join(strings.Fields(text), " ")
+	}
+
+	var b strings.Builder
+	z := xhtml.NewTokenizer(strings.NewReader(text))
+	for {
+		switch z.Next() {
+		case xhtml.ErrorToken:
+			return strings.Join(strings.Fields(b.String()), " ")
+		case xhtml.TextToken:
+			b.WriteString(html.UnescapeString(string(z.Text())))
+		case xhtml.StartTagToken, xhtml.EndTagToken, xhtml.SelfClosingTagToken:
+			name, _ := z.TagName()
+			if !inlineTags[atom.Lookup(name)] {
+				b.WriteString(" ")
+			}
+		}
+	}
+}
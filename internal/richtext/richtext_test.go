@@ -0,0 +1,159 @@
+/*
+Package richtext containing sanitizing and rendering of product
+descriptions written in plain text, Markdown, or limited HTML
+into HTML safe to be embedded in a page
+*/
+package richtext
+
+import "testing"
+
+// TestSanitize test Sanitize
+func TestSanitize(t *testing.T) {
+	// create testing table
+	testTable := []struct {
+		TestName       string
+		HTML           string
+		ExpectedResult string
+	}{
+		{
+			TestName:       "Allowed tags kept, attributes removed",
+			HTML:           `<p class="x" onclick="steal()">Hi <b>there</b></p>`,
+			ExpectedResult: `<p>Hi <b>there</b></p>`,
+		},
+		{
+			TestName:       "Script removed with its content",
+			HTML:           `<p>a<script>alert(1)</script>b</p>`,
+			ExpectedResult: `<p>ab</p>`,
+		},
+		{
+			TestName:       "Unknown tags removed, text kept",
+			HTML:           `<div>text<img src=x onerror=alert(1)></div>`,
+			ExpectedResult: `text`,
+		},
+		{
+			TestName: "Safe link kept",
+			HTML:     `<a href="https://example.com/?a=1&b=2" target="_blank">x</a>`,
+			ExpectedResult: `<a href="https://example.com/?a=1&amp;b=2" ` +
+				`rel="nofollow noopener noreferrer">x</a>`,
+		},
+		{
+			TestName:       "Javascript link href removed",
+			HTML:           `<a href=" javascript:alert(1)">x</a>`,
+			ExpectedResult: `<a>x</a>`,
+		},
+		{
+			TestName:       "Unclosed tags closed, stray end tags ignored",
+			HTML:           `</ul><ul><li><em>one</li>`,
+			ExpectedResult: `<ul><li><em>one</em></li></ul>`,
+		},
+		{
+			TestName:       "Text escaped",
+			HTML:           `1 < 2 & "3"`,
+			ExpectedResult: `1 &lt; 2 &amp; &#34;3&#34;`,
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		result := Sanitize(test.HTML)
+		if result != test.ExpectedResult {
+			t.Errorf("[%s] Expected '%s', but got '%s'",
+				test.TestName, test.ExpectedResult, result)
+		}
+	}
+}
+
+// TestRender test Render
+func TestRender(t *testing.T) {
+	// create testing table
+	testTable := []struct {
+		TestName       string
+		Format         string
+		Text           string
+		ExpectedResult string
+	}{
+		{
+			TestName:       "Plain text",
+			Format:         FormatPlain,
+			Text:           "Line <1>\r\nLine 2\n\n\nNext",
+			ExpectedResult: "<p>Line &lt;1&gt;<br>Line 2</p><p>Next</p>",
+		},
+		{
+			TestName: "Markdown blocks",
+			Format:   FormatMarkdown,
+			Text:     "## Specs\n\n- **Fast**\n- *Quiet*\n\n1. one\n\n> note",
+			ExpectedResult: "<h2>Specs</h2><ul><li><strong>Fast</strong>" +
+				"</li><li><em>Quiet</em></li></ul><ol><li>one</li></ol>" +
+				"<blockquote><p>note</p></blockquote>",
+		},
+		{
+			TestName: "Markdown inline code and links",
+			Format:   FormatMarkdown,
+			Text: "Use `<b>` [docs](https://example.com) " +
+				"[bad](javascript:x)",
+			ExpectedResult: `<p>Use <code>&lt;b&gt;</code> ` +
+				`<a href="https://example.com" ` +
+				`rel="nofollow noopener noreferrer">docs</a> <a>bad</a></p>`,
+		},
+		{
+			TestName:       "Markdown raw HTML escaped",
+			Format:         FormatMarkdown,
+			Text:           "<script>alert(1)</script>",
+			ExpectedResult: "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>",
+		},
+		{
+			TestName:       "Markdown fenced code",
+			Format:         FormatMarkdown,
+			Text:           "```\n<x> *y*\n```",
+			ExpectedResult: "<pre><code>&lt;x&gt; *y*\n</code></pre>",
+		},
+		{
+			TestName:       "HTML sanitized",
+			Format:         FormatHTML,
+			Text:           `<h3 style="x">Hi</h3><iframe src="x"></iframe>`,
+			ExpectedResult: "<h3>Hi</h3>",
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		result := Render(test.Format, test.Text)
+		if result != test.ExpectedResult {
+			t.Errorf("[%s] Expected '%s', but got '%s'",
+				test.TestName, test.ExpectedResult, result)
+		}
+	}
+}
+
+// TestPlainText test PlainText
+func TestPlainText(t *testing.T) {
+	// create testing table
+	testTable := []struct {
+		TestName       string
+		Format         string
+		Text           string
+		ExpectedResult string
+	}{
+		{
+			TestName:       "Plain text",
+			Format:         FormatPlain,
+			Text:           "Line <1>\r\nLine  2",
+			ExpectedResult: "Line <1> Line 2",
+		},
+		{
+			TestName:       "HTML",
+			Format:         FormatHTML,
+			Text:           "<p>Fast &amp; <b>wire</b>less</p><p>Quiet</p>",
+			ExpectedResult: "Fast & wireless Quiet",
+		},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		result := PlainText(test.Format, test.Text)
+		if result != test.ExpectedResult {
+			t.Errorf("[%s] Expected '%s', but got '%s'",
+				test.TestName, test.ExpectedResult, result)
+		}
+	}
+}
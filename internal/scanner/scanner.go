@@ -0,0 +1,188 @@
+/*
+Package scanner containing scanners checking uploaded files for malware
+or other suspicious content, either by ClamAV daemon or by external
+scanning API
+*/
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// scanner backends selectable by config
+const (
+	BackendNone   = "none"
+	BackendClamAV = "clamav"
+	BackendHTTP   = "http"
+)
+
+// maximum time of scanning a file
+const scanTimeout = 30 * time.Second
+
+// Scanner scan file content for malware or other suspicious content
+type Scanner interface {
+	// Scan scan content read from r, returning name of the threat found
+	// or empty string if the content is clean
+	Scan(ctx context.Context, r io.Reader) (string, error)
+}
+
+// NewScanner create scanner of backend, ClamAV daemon listening at
+// address or scanning API at address URL with API key, return nil
+// scanner if backend is none
+func NewScanner(backend string, address string, apiKey string) (Scanner,
+	error) {
+	switch backend {
+	case "", BackendNone:
+		return nil, nil
+	case BackendClamAV:
+		return ClamAVScanner{Address: address}, nil
+	case BackendHTTP:
+		return HTTPScanner{
+			client: &http.Client{Timeout: scanTimeout},
+			URL:    address,
+			APIKey: apiKey,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("scanner backend '%s' unknown", backend)
+}
+
+// ClamAVScanner scanner streaming content to ClamAV daemon (clamd)
+// listening at TCP address host:port, or at unix socket path
+// if address starts with '/'
+type ClamAVScanner struct {
+	Address string
+}
+
+// clamAVChunkSize size of content chunks streamed to ClamAV daemon
+const clamAVChunkSize = 32 * 1024
+
+// Scan scan content by command INSTREAM of ClamAV daemon
+func (s ClamAVScanner) Scan(ctx context.Context, r io.Reader) (string,
+	error) {
+	network := "tcp"
+	if strings.HasPrefix(s.Address, "/") {
+		network = "unix"
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, scanTimeout)
+	defer cancel()
+	conn, err := (&net.Dialer{}).DialContext(ctx, network, s.Address)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	err = conn.SetDeadline(deadline)
+	if err != nil {
+		return "", err
+	}
+
+	// stream content in chunks prefixed by their length,
+	// terminated by zero length chunk
+	_, err = conn.Write([]byte("zINSTREAM\x00"))
+	if err != nil {
+		return "", err
+	}
+	chunk := make([]byte, 4+clamAVChunkSize)
+	for {
+		n, err := io.ReadFull(r, chunk[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(chunk, uint32(n))
+			_, werr := conn.Write(chunk[:4+n])
+			if werr != nil {
+				return "", werr
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		} else if err != nil {
+			return "", err
+		}
+	}
+	_, err = conn.Write([]byte{0, 0, 0, 0})
+	if err != nil {
+		return "", err
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil {
+		return "", err
+	}
+
+	return parseClamAVReply(reply)
+}
+
+// parseClamAVReply get threat name from ClamAV daemon reply
+// e.g. "stream: Eicar-Signature FOUND", empty if reply is "stream: OK"
+func parseClamAVReply(reply string) (string, error) {
+	reply = strings.TrimSpace(strings.TrimSuffix(reply, "\x00"))
+	result := strings.TrimSpace(strings.TrimPrefix(reply, "stream:"))
+
+	switch {
+	case result == "OK":
+		return "", nil
+	case strings.HasSuffix(result, " FOUND"):
+		return strings.TrimSuffix(result, " FOUND"), nil
+	}
+
+	return "", fmt.Errorf("ClamAV scan failed => %s", reply)
+}
+
+// HTTPScanner scanner posting content to external scanning API at URL
+// with API key as bearer token if any, the API must respond
+// with JSON {"infected": bool, "threat": string}
+type HTTPScanner struct {
+	client *http.Client
+	URL    string
+	APIKey string
+}
+
+// Scan scan content by posting it to scanning API
+func (s HTTPScanner) Scan(ctx context.Context, r io.Reader) (string,
+	error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, r)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if s.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.APIKey)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("scanning API responded %s", resp.Status)
+	}
+
+	result := struct {
+		Infected bool   `json:"infected"`
+		Threat   string `json:"threat"`
+	}{}
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		return "", err
+	}
+	if !result.Infected {
+		return "", nil
+	}
+	if result.Threat == "" {
+		return "unknown threat", nil
+	}
+
+	return result.Threat, nil
+}
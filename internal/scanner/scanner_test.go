@@ -0,0 +1,162 @@
+/*
+Package scanner containing scanners checking uploaded files for malware
+or other suspicious content, either by ClamAV daemon or by external
+scanning API
+*/
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// eicar content of EICAR test file, detected by every malware scanner
+const eicar = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// serveClamAV serve one INSTREAM command of ClamAV daemon on listener,
+// replying threat found if streamed content contains EICAR test file
+func serveClamAV(t *testing.T, l net.Listener) {
+	conn, err := l.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	command, err := r.ReadString(0)
+	if err != nil || command != "zINSTREAM\x00" {
+		t.Errorf("Expected command zINSTREAM, but got %q (%v)", command, err)
+		return
+	}
+
+	content := bytes.Buffer{}
+	for {
+		size := uint32(0)
+		err = binary.Read(r, binary.BigEndian, &size)
+		if err != nil {
+			t.Errorf("Expected error nil, but got error => %s", err.Error())
+			return
+		}
+		if size == 0 {
+			break
+		}
+		_, err = io.CopyN(&content, r, int64(size))
+		if err != nil {
+			t.Errorf("Expected error nil, but got error => %s", err.Error())
+			return
+		}
+	}
+
+	if strings.Contains(content.String(), eicar) {
+		conn.Write([]byte("stream: Eicar-Signature FOUND\x00"))
+	} else {
+		conn.Write([]byte("stream: OK\x00"))
+	}
+}
+
+// TestScanner test ClamAVScanner and HTTPScanner scanning content
+func TestScanner(t *testing.T) {
+	// fake ClamAV daemon serving each content once
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+	defer l.Close()
+	go func() {
+		for i := 0; i < 3; i++ {
+			serveClamAV(t, l)
+		}
+	}()
+
+	// fake scanning API
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer key" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			b, _ := io.ReadAll(r.Body)
+			if strings.Contains(string(b), eicar) {
+				w.Write([]byte(`{"infected":true,"threat":"EICAR"}`))
+				return
+			}
+			w.Write([]byte(`{"infected":false}`))
+		}))
+	defer server.Close()
+
+	clamAV, err := NewScanner(BackendClamAV, l.Addr().String(), "")
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+	httpScanner, err := NewScanner(BackendHTTP, server.URL, "key")
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+
+	// large content is streamed in multiple chunks
+	large := strings.Repeat("a", 3*clamAVChunkSize) + eicar
+
+	testTable := []struct {
+		TestName       string
+		Scanner        Scanner
+		Content        string
+		ExpectedThreat string
+	}{
+		{"ClamAV Clean", clamAV, "clean image", ""},
+		{"ClamAV Infected", clamAV, eicar, "Eicar-Signature"},
+		{"ClamAV Infected Large", clamAV, large, "Eicar-Signature"},
+		{"HTTP Clean", httpScanner, "clean image", ""},
+		{"HTTP Infected", httpScanner, eicar, "EICAR"},
+	}
+
+	for _, test := range testTable {
+		threat, err := test.Scanner.Scan(context.Background(),
+			strings.NewReader(test.Content))
+		if err != nil {
+			t.Errorf("[%s] Expected error nil, but got error => %s",
+				test.TestName, err.Error())
+			continue
+		}
+		if threat != test.ExpectedThreat {
+			t.Errorf("[%s] Expected threat '%s', but got '%s'",
+				test.TestName, test.ExpectedThreat, threat)
+		}
+	}
+
+	// scanning API refusing the request fails the scan
+	wrongKey, _ := NewScanner(BackendHTTP, server.URL, "wrong")
+	_, err = wrongKey.Scan(context.Background(), strings.NewReader(eicar))
+	if err == nil {
+		t.Errorf("Expected error of unauthorized scanning API, but got nil")
+	}
+}
+
+// TestParseClamAVReply test parseClamAVReply
+func TestParseClamAVReply(t *testing.T) {
+	testCases := []struct {
+		Reply          string
+		ExpectedThreat string
+		ExpectedErr    bool
+	}{
+		{"stream: OK\x00", "", false},
+		{"stream: Win.Test.EICAR_HDB-1 FOUND\x00", "Win.Test.EICAR_HDB-1", false},
+		{"INSTREAM size limit exceeded. ERROR\x00", "", true},
+	}
+
+	for _, testCase := range testCases {
+		threat, err := parseClamAVReply(testCase.Reply)
+		if threat != testCase.ExpectedThreat || (err != nil) != testCase.ExpectedErr {
+			t.Errorf("Expected threat '%s' and error %t of reply %q, "+
+				"but got '%s' and %v", testCase.ExpectedThreat,
+				testCase.ExpectedErr, testCase.Reply, threat, err)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+/*
+Package scheduler containing scheduler running recurring tasks
+in background and recording run metrics of each task
+*/
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Task contain a recurring task run every interval,
+// the task is disabled if interval is not positive
+type Task struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// TaskStats contain run metrics of a task
+type TaskStats struct {
+	Name                string     `json:"name"`
+	Interval            string     `json:"interval"`
+	Running             bool       `json:"running"`
+	Runs                int        `json:"runs"`
+	Failures            int        `json:"failures"`
+	LastRunAt           *time.Time `json:"last_run_at"`
+	LastDurationSeconds float64    `json:"last_duration_seconds"`
+	LastError           string     `json:"last_error"`
+}
+
+// Scheduler run enabled tasks every their interval, a task never
+// overlaps with its own previous run
+type Scheduler struct {
+	mu    sync.Mutex
+	tasks []Task
+	stats map[string]*TaskStats
+}
+
+// NewScheduler create scheduler of enabled tasks
+func NewScheduler(tasks ...Task) *Scheduler {
+	s := &Scheduler{stats: map[string]*TaskStats{}}
+	for _, task := range tasks {
+		if task.Interval <= 0 {
+			continue
+		}
+
+		s.tasks = append(s.tasks, task)
+		s.stats[task.Name] = &TaskStats{
+			Name:     task.Name,
+			Interval: task.Interval.String(),
+		}
+	}
+
+	return s
+}
+
+// Start run every task in background, first after one interval,
+// until ctx canceled
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, task := range s.tasks {
+		go func(task Task) {
+			ticker := time.NewTicker(task.Interval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					s.run(ctx, task)
+				}
+			}
+		}(task)
+	}
+}
+
+// run run task once and record its run metrics,
+// panic of the task is recorded as failure
+func (s *Scheduler) run(ctx context.Context, task Task) {
+	s.mu.Lock()
+	s.stats[task.Name].Running = true
+	s.mu.Unlock()
+
+	start := time.Now()
+	err := runTask(ctx, task)
+	duration := time.Since(start)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := s.stats[task.Name]
+	stats.Running = false
+	stats.Runs++
+	stats.LastRunAt = &start
+	stats.LastDurationSeconds = duration.Seconds()
+	stats.LastError = ""
+	if err != nil {
+		stats.Failures++
+		stats.LastError = err.Error()
+		log.Printf("There's an error when running scheduled task %s => %s",
+			task.Name, err.Error())
+	}
+}
+
+// runTask run task once, recovering its panic as error
+func runTask(ctx context.Context, task Task) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("task panicked => %v", r)
+		}
+	}()
+
+	return task.Run(ctx)
+}
+
+// Stats get run metrics of every enabled task
+func (s *Scheduler) Stats() []TaskStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := []TaskStats{}
+	for _, task := range s.tasks {
+		stats = append(stats, *s.stats[task.Name])
+	}
+
+	return stats
+}
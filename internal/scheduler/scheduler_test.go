@@ -0,0 +1,86 @@
+/*
+Package scheduler containing scheduler running recurring tasks
+in background and recording run metrics of each task
+*/
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestScheduler test Scheduler running tasks and recording their stats
+func TestScheduler(t *testing.T) {
+	s := NewScheduler(
+		Task{
+			Name:     "succeed",
+			Interval: 10 * time.Millisecond,
+			Run:      func(ctx context.Context) error { return nil },
+		},
+		Task{
+			Name:     "fail",
+			Interval: 10 * time.Millisecond,
+			Run: func(ctx context.Context) error {
+				return errors.New("broken")
+			},
+		},
+		Task{
+			Name:     "panic",
+			Interval: 10 * time.Millisecond,
+			Run:      func(ctx context.Context) error { panic("boom") },
+		},
+		Task{
+			Name: "disabled",
+			Run: func(ctx context.Context) error {
+				t.Errorf("Expected disabled task never run")
+				return nil
+			},
+		},
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Start(ctx)
+
+	// wait until every task run at least twice
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		done := true
+		for _, stats := range s.Stats() {
+			if stats.Runs < 2 {
+				done = false
+			}
+		}
+		if done {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+
+	// check stats of every enabled task
+	stats := s.Stats()
+	if len(stats) != 3 {
+		t.Fatalf("Expected stats of 3 enabled tasks, but got %+v", stats)
+	}
+	expectedErrors := []string{"", "broken", "task panicked => boom"}
+	for i, taskStats := range stats {
+		if taskStats.Runs < 2 || taskStats.LastRunAt == nil ||
+			taskStats.Interval != "10ms" {
+			t.Errorf("Expected task %s run at least twice every 10ms, "+
+				"but got %+v", taskStats.Name, taskStats)
+		}
+
+		expectedFailures := 0
+		if expectedErrors[i] != "" {
+			expectedFailures = taskStats.Runs
+		}
+		if taskStats.LastError != expectedErrors[i] ||
+			taskStats.Failures != expectedFailures {
+			t.Errorf("Expected task %s failures %d with last error '%s', "+
+				"but got %+v", taskStats.Name, expectedFailures,
+				expectedErrors[i], taskStats)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package search
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/reyhanfikridz/ecom-product-service/internal/config"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+)
+
+// ElasticsearchProvider search provider indexing products as documents
+// of Elasticsearch index with SKU as document ID
+type ElasticsearchProvider struct {
+	searchEngine
+}
+
+// Index add or replace product document of SKU
+func (p *ElasticsearchProvider) Index(ctx context.Context, SKU string) error {
+	doc, ok, err := p.getDocument(ctx, SKU)
+	if err != nil {
+		return err
+	} else if !ok {
+		return p.Delete(ctx, SKU)
+	}
+
+	return p.do(ctx, http.MethodPut, p.documentPath(SKU), doc, nil)
+}
+
+// Delete remove product document of SKU, not indexed product is ignored
+func (p *ElasticsearchProvider) Delete(ctx context.Context, SKU string) error {
+	return p.do(ctx, http.MethodDelete, p.documentPath(SKU), nil, nil,
+		http.StatusNotFound)
+}
+
+// Query resolve search of product query into SKUs of matching products
+// ranked by relevance, name weighted twice description and fuzziness
+// enabled if query is fuzzy, matching the search or any of its synonym
+// expansions like searched by the database
+func (p *ElasticsearchProvider) Query(ctx context.Context,
+	query model.ProductQuery) (model.ProductQuery, error) {
+	if !isSearched(query) {
+		return query, nil
+	}
+
+	matches := []interface{}{}
+	for _, term := range model.ExpandSearchSynonyms(query.Search,
+		config.SearchSynonyms) {
+		match := map[string]interface{}{
+			"query":  term,
+			"fields": []string{"name^2", "description"},
+		}
+		if query.Fuzzy {
+			match["fuzziness"] = "AUTO"
+		}
+		matches = append(matches, map[string]interface{}{"multi_match": match})
+	}
+	filters := []interface{}{}
+	if query.UserID != 0 {
+		filters = append(filters, map[string]interface{}{
+			"term": map[string]interface{}{"user_id": query.UserID},
+		})
+	}
+	if query.ExcludeHidden {
+		filters = append(filters, map[string]interface{}{
+			"term": map[string]interface{}{"hidden": false},
+		})
+	}
+	body := map[string]interface{}{
+		"size":    maxSearchResults,
+		"_source": false,
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"should":               matches,
+				"minimum_should_match": 1,
+				"filter":               filters,
+			},
+		},
+	}
+
+	result := struct {
+		Hits struct {
+			Hits []struct {
+				ID string `json:"_id"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}{}
+	err := p.do(ctx, http.MethodPost, "/"+url.PathEscape(p.index)+"/_search",
+		body, &result)
+	if err != nil {
+		return model.ProductQuery{}, err
+	}
+
+	query.SKUs = make([]string, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		query.SKUs = append(query.SKUs, hit.ID)
+	}
+	query.RankedSKUs = true
+	query.Search = ""
+
+	return query, nil
+}
+
+// documentPath get URL path of product document by SKU
+func (p *ElasticsearchProvider) documentPath(SKU string) string {
+	return "/" + url.PathEscape(p.index) + "/_doc/" + url.PathEscape(SKU)
+}
@@ -0,0 +1,107 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+)
+
+// MeilisearchProvider search provider indexing products as documents
+// of Meilisearch index with SKU as primary key
+//
+// Meilisearch search is always typo tolerant, so fuzzy flag of
+// product query is ignored
+type MeilisearchProvider struct {
+	searchEngine
+}
+
+// Index add or replace product document of SKU
+func (p *MeilisearchProvider) Index(ctx context.Context, SKU string) error {
+	doc, ok, err := p.getDocument(ctx, SKU)
+	if err != nil {
+		return err
+	} else if !ok {
+		return p.Delete(ctx, SKU)
+	}
+
+	return p.do(ctx, http.MethodPut, p.indexPath()+"/documents?primaryKey=sku",
+		[]Document{doc}, nil)
+}
+
+// Delete remove product document of SKU, not indexed product is ignored
+func (p *MeilisearchProvider) Delete(ctx context.Context, SKU string) error {
+	return p.do(ctx, http.MethodDelete,
+		p.indexPath()+"/documents/"+url.PathEscape(SKU), nil, nil)
+}
+
+// Query resolve search of product query into SKUs of matching products
+// ranked by relevance
+func (p *MeilisearchProvider) Query(ctx context.Context,
+	query model.ProductQuery) (model.ProductQuery, error) {
+	if !isSearched(query) {
+		return query, nil
+	}
+
+	filters := []string{}
+	if query.UserID != 0 {
+		filters = append(filters, fmt.Sprintf("user_id = %d", query.UserID))
+	}
+	if query.ExcludeHidden {
+		filters = append(filters, "hidden = false")
+	}
+	body := map[string]interface{}{
+		"q":                    query.Search,
+		"limit":                maxSearchResults,
+		"filter":               filters,
+		"attributesToRetrieve": []string{"sku"},
+	}
+
+	result := struct {
+		Hits []struct {
+			SKU string `json:"sku"`
+		} `json:"hits"`
+	}{}
+	err := p.do(ctx, http.MethodPost, p.indexPath()+"/search", body, &result)
+	if err != nil {
+		return model.ProductQuery{}, err
+	}
+
+	query.SKUs = make([]string, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		query.SKUs = append(query.SKUs, hit.SKU)
+	}
+	query.RankedSKUs = true
+	query.Search = ""
+
+	return query, nil
+}
+
+// setFilterableAttributes set index attributes filtered by Query,
+// Meilisearch only filter by attributes set filterable
+func (p *MeilisearchProvider) setFilterableAttributes(
+	ctx context.Context) error {
+	return p.do(ctx, http.MethodPut,
+		p.indexPath()+"/settings/filterable-attributes",
+		[]string{"user_id", "hidden"}, nil)
+}
+
+// setSynonyms set synonyms of the index to the search synonyms
+// dictionary, so words are matched by their synonyms like searched
+// by the database
+func (p *MeilisearchProvider) setSynonyms(ctx context.Context,
+	synonyms map[string][]string) error {
+	if synonyms == nil {
+		synonyms = map[string][]string{}
+	}
+
+	return p.do(ctx, http.MethodPut, p.indexPath()+"/settings/synonyms",
+		synonyms, nil)
+}
+
+// indexPath get URL path of the index
+func (p *MeilisearchProvider) indexPath() string {
+	return "/indexes/" + url.PathEscape(p.index)
+}
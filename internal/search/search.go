@@ -0,0 +1,212 @@
+/*
+Package search containing search providers indexing products and
+resolving product search, either by the database itself or by
+external search engine Elasticsearch or Meilisearch
+*/
+package search
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-product-service/internal/config"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+	"github.com/reyhanfikridz/ecom-product-service/internal/richtext"
+)
+
+// search backends selectable by config
+const (
+	BackendPostgres      = "postgres"
+	BackendElasticsearch = "elasticsearch"
+	BackendMeilisearch   = "meilisearch"
+)
+
+// maximum products matched by search of external search engine,
+// most relevant first
+const maxSearchResults = 1000
+
+// SearchProvider index products and resolve product search
+type SearchProvider interface {
+	// Index add or replace product of SKU in search index,
+	// or remove it if product not found (e.g. soft deleted)
+	Index(ctx context.Context, SKU string) error
+	// Delete remove product of SKU from search index
+	Delete(ctx context.Context, SKU string) error
+	// Query resolve search of product query into product query
+	// the database can apply
+	Query(ctx context.Context, query model.ProductQuery) (
+		model.ProductQuery, error)
+}
+
+// NewProvider create search provider of backend, external search engine
+// at URL with index and API key indexes products got from repository
+func NewProvider(backend string, URL string, index string, apiKey string,
+	repo model.ProductRepository) (SearchProvider, error) {
+	engine := searchEngine{
+		client: &http.Client{Timeout: 5 * time.Second},
+		URL:    strings.TrimSuffix(URL, "/"),
+		index:  index,
+		apiKey: apiKey,
+		repo:   repo,
+	}
+
+	switch backend {
+	case "", BackendPostgres:
+		return PostgresProvider{}, nil
+	case BackendElasticsearch:
+		engine.authScheme = "ApiKey"
+		return &ElasticsearchProvider{engine}, nil
+	case BackendMeilisearch:
+		engine.authScheme = "Bearer"
+		p := &MeilisearchProvider{engine}
+		err := p.setFilterableAttributes(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		err = p.setSynonyms(context.Background(), config.SearchSynonyms)
+		if err != nil {
+			return nil, err
+		}
+		return p, nil
+	}
+
+	return nil, fmt.Errorf("search backend '%s' unknown", backend)
+}
+
+// PostgresProvider search provider searching products by the database
+// itself, so products need no indexing
+type PostgresProvider struct{}
+
+// Index do nothing
+func (PostgresProvider) Index(ctx context.Context, SKU string) error {
+	return nil
+}
+
+// Delete do nothing
+func (PostgresProvider) Delete(ctx context.Context, SKU string) error {
+	return nil
+}
+
+// Query get product query as is, searched by GetProducts
+func (PostgresProvider) Query(ctx context.Context,
+	query model.ProductQuery) (model.ProductQuery, error) {
+	return query, nil
+}
+
+// Document contain product fields indexed in external search engine
+type Document struct {
+	SKU         string      `json:"sku"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	UserID      int         `json:"user_id"`
+	Hidden      bool        `json:"hidden"`
+	Price       model.Money `json:"price"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+// NewDocument create search document of product info,
+// description is indexed as plain text
+func NewDocument(pInfo model.ProductInfo) Document {
+	return Document{
+		SKU:  pInfo.SKU,
+		Name: pInfo.Name,
+		Description: richtext.PlainText(pInfo.DescriptionFormat,
+			pInfo.Description),
+		UserID:    pInfo.UserID,
+		Hidden:    pInfo.Hidden,
+		Price:     pInfo.Price,
+		CreatedAt: pInfo.CreatedAt,
+	}
+}
+
+// searchEngine contain connection to external search engine,
+// API key is sent in header Authorization with auth scheme
+type searchEngine struct {
+	client     *http.Client
+	URL        string
+	index      string
+	apiKey     string
+	authScheme string
+	repo       model.ProductRepository
+}
+
+// getDocument get search document of product by SKU,
+// ok is false if product not found
+//
+// product is read from primary database since it's indexed right after
+// written, before read replica may catch up
+func (e searchEngine) getDocument(ctx context.Context, SKU string) (
+	Document, bool, error) {
+	p, err := e.repo.GetProductBySKU(model.ReadFromPrimary(ctx), SKU)
+	if err == sql.ErrNoRows {
+		return Document{}, false, nil
+	} else if err != nil {
+		return Document{}, false, err
+	}
+
+	return NewDocument(p.ProductInfo), true, nil
+}
+
+// isSearched check if search of product query should be resolved by
+// search engine, deleted products are not indexed so their search
+// is left to the database
+func isSearched(query model.ProductQuery) bool {
+	return query.Search != "" && !query.Deleted && !query.IncludeDeleted
+}
+
+// do send request with JSON body if body is not nil to search engine,
+// then decode JSON response into result if it's not nil, response
+// status in allowed statuses is not an error
+func (e searchEngine) do(ctx context.Context, method string, path string,
+	body interface{}, result interface{}, allowedStatuses ...int) error {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, e.URL+path, reqBody)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if e.apiKey != "" {
+		req.Header.Set("Authorization", e.authScheme+" "+e.apiKey)
+	}
+
+	res, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		for _, status := range allowedStatuses {
+			if res.StatusCode == status {
+				return nil
+			}
+		}
+
+		b, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("search engine responded %s %s with status %d => %s",
+			method, path, res.StatusCode, string(b))
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	return json.NewDecoder(res.Body).Decode(result)
+}
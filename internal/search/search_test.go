@@ -0,0 +1,267 @@
+/*
+Package search containing search providers indexing products and
+resolving product search, either by the database itself or by
+external search engine Elasticsearch or Meilisearch
+*/
+package search
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/reyhanfikridz/ecom-product-service/internal/config"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+)
+
+// fakeRepository product repository in memory for testing indexing,
+// not implemented methods panic
+type fakeRepository struct {
+	model.ProductRepository
+	products map[string]model.Product
+}
+
+// GetProductBySKU get product from memory
+func (r fakeRepository) GetProductBySKU(ctx context.Context,
+	SKU string) (model.Product, error) {
+	p, ok := r.products[SKU]
+	if !ok {
+		return model.Product{}, sql.ErrNoRows
+	}
+
+	return p, nil
+}
+
+// request contain method, path, authorization, and JSON body
+// of request received by fake search engine
+type request struct {
+	Method        string
+	Path          string
+	Authorization string
+	Body          interface{}
+}
+
+// newFakeEngine create fake search engine recording its requests
+// and responding response to every request
+func newFakeEngine(t *testing.T, requests *[]request,
+	response interface{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			req := request{
+				Method:        r.Method,
+				Path:          r.URL.RequestURI(),
+				Authorization: r.Header.Get("Authorization"),
+			}
+			if r.ContentLength > 0 {
+				err := json.NewDecoder(r.Body).Decode(&req.Body)
+				if err != nil {
+					t.Errorf("Expected JSON request body, but got error => %s",
+						err.Error())
+				}
+			}
+			*requests = append(*requests, req)
+
+			if r.Method == http.MethodDelete && r.URL.Path == "/products/_doc/gone" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			json.NewEncoder(w).Encode(response)
+		}))
+}
+
+// TestNewProvider test NewProvider
+func TestNewProvider(t *testing.T) {
+	provider, err := NewProvider("", "", "", "", nil)
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+	if _, ok := provider.(PostgresProvider); !ok {
+		t.Errorf("Expected postgres provider by default, but got %T", provider)
+	}
+
+	// postgres provider leave search to the database
+	query := model.ProductQuery{Search: "laptop", Fuzzy: true}
+	result, err := provider.Query(context.Background(), query)
+	if err != nil || !reflect.DeepEqual(result, query) {
+		t.Errorf("Expected query %+v, but got %+v (error %v)",
+			query, result, err)
+	}
+
+	_, err = NewProvider("solr", "http://localhost:8983", "products", "", nil)
+	if err == nil {
+		t.Errorf("Expected error for unknown backend, but got nil")
+	}
+}
+
+// TestElasticsearchProvider test ElasticsearchProvider
+func TestElasticsearchProvider(t *testing.T) {
+	config.SearchSynonyms = map[string][]string{"laptop": {"notebook"}}
+	defer func() { config.SearchSynonyms = nil }()
+
+	requests := []request{}
+	server := newFakeEngine(t, &requests, map[string]interface{}{
+		"hits": map[string]interface{}{
+			"hits": []map[string]interface{}{
+				{"_id": "sku-b"}, {"_id": "sku-a"},
+			},
+		},
+	})
+	defer server.Close()
+
+	repo := fakeRepository{products: map[string]model.Product{
+		"sku-a": {ProductInfo: model.ProductInfo{
+			SKU: "sku-a", Name: "Laptop", UserID: 3,
+			Description: "<p>Fast <b>laptop</b></p>", DescriptionFormat: "html",
+		}},
+	}}
+	provider, err := NewProvider(BackendElasticsearch, server.URL+"/",
+		"products", "key", repo)
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+
+	// index existing and deleted products
+	for _, SKU := range []string{"sku-a", "gone"} {
+		err = provider.Index(context.Background(), SKU)
+		if err != nil {
+			t.Errorf("Expected error nil indexing %s, but got error => %s",
+				SKU, err.Error())
+		}
+	}
+
+	// query search
+	query, err := provider.Query(context.Background(), model.ProductQuery{
+		Search: "laptop", UserID: 3, ExcludeHidden: true, Fuzzy: true,
+	})
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+	expectedQuery := model.ProductQuery{
+		SKUs: []string{"sku-b", "sku-a"}, RankedSKUs: true, UserID: 3,
+		ExcludeHidden: true, Fuzzy: true,
+	}
+	if !reflect.DeepEqual(query, expectedQuery) {
+		t.Errorf("Expected query %+v, but got %+v", expectedQuery, query)
+	}
+
+	// check requests received
+	if len(requests) != 3 {
+		t.Fatalf("Expected 3 requests, but got %d", len(requests))
+	}
+	doc, _ := requests[0].Body.(map[string]interface{})
+	if requests[0].Method != http.MethodPut ||
+		requests[0].Path != "/products/_doc/sku-a" ||
+		requests[0].Authorization != "ApiKey key" ||
+		doc["description"] != "Fast laptop" || doc["user_id"] != 3.0 {
+		t.Errorf("Expected product indexed, but got request %+v", requests[0])
+	}
+	if requests[1].Method != http.MethodDelete ||
+		requests[1].Path != "/products/_doc/gone" {
+		t.Errorf("Expected deleted product removed, but got request %+v",
+			requests[1])
+	}
+	body, _ := json.Marshal(requests[2].Body)
+	expectedBody := `{"_source":false,"query":{"bool":{"filter":[` +
+		`{"term":{"user_id":3}},{"term":{"hidden":false}}],` +
+		`"minimum_should_match":1,"should":[` +
+		`{"multi_match":{"fields":["name^2","description"],` +
+		`"fuzziness":"AUTO","query":"laptop"}},` +
+		`{"multi_match":{"fields":["name^2","description"],` +
+		`"fuzziness":"AUTO","query":"notebook"}}]}},"size":1000}`
+	if requests[2].Method != http.MethodPost ||
+		requests[2].Path != "/products/_search" ||
+		string(body) != expectedBody {
+		t.Errorf("Expected search request %s, but got %s %s %s", expectedBody,
+			requests[2].Method, requests[2].Path, string(body))
+	}
+
+	// deleted products are searched by the database
+	query, err = provider.Query(context.Background(),
+		model.ProductQuery{Search: "laptop", Deleted: true})
+	if err != nil || query.Search != "laptop" || query.SKUs != nil ||
+		len(requests) != 3 {
+		t.Errorf("Expected deleted products search left to the database, "+
+			"but got query %+v (error %v)", query, err)
+	}
+}
+
+// TestMeilisearchProvider test MeilisearchProvider
+func TestMeilisearchProvider(t *testing.T) {
+	config.SearchSynonyms = map[string][]string{"laptop": {"notebook"}}
+	defer func() { config.SearchSynonyms = nil }()
+
+	requests := []request{}
+	server := newFakeEngine(t, &requests, map[string]interface{}{
+		"hits": []map[string]interface{}{{"sku": "sku-a"}},
+	})
+	defer server.Close()
+
+	repo := fakeRepository{products: map[string]model.Product{
+		"sku-a": {ProductInfo: model.ProductInfo{SKU: "sku-a", Name: "Laptop"}},
+	}}
+	provider, err := NewProvider(BackendMeilisearch, server.URL,
+		"products", "key", repo)
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+
+	err = provider.Index(context.Background(), "sku-a")
+	if err != nil {
+		t.Errorf("Expected error nil, but got error => %s", err.Error())
+	}
+	query, err := provider.Query(context.Background(),
+		model.ProductQuery{Search: "lapotp", UserID: 3})
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+	if !reflect.DeepEqual(query.SKUs, []string{"sku-a"}) ||
+		!query.RankedSKUs || query.Search != "" {
+		t.Errorf("Expected query of SKU sku-a, but got %+v", query)
+	}
+
+	// check requests received
+	expectedRequests := []request{
+		{
+			Method:        http.MethodPut,
+			Path:          "/indexes/products/settings/filterable-attributes",
+			Authorization: "Bearer key",
+			Body:          []interface{}{"user_id", "hidden"},
+		},
+		{
+			Method:        http.MethodPut,
+			Path:          "/indexes/products/settings/synonyms",
+			Authorization: "Bearer key",
+			Body: map[string]interface{}{
+				"laptop": []interface{}{"notebook"},
+			},
+		},
+		{
+			Method:        http.MethodPut,
+			Path:          "/indexes/products/documents?primaryKey=sku",
+			Authorization: "Bearer key",
+			Body: []interface{}{map[string]interface{}{
+				"sku": "sku-a", "name": "Laptop", "description": "",
+				"user_id": 0.0, "hidden": false, "price": 0.0,
+				"created_at": "0001-01-01T00:00:00Z",
+			}},
+		},
+		{
+			Method:        http.MethodPost,
+			Path:          "/indexes/products/search",
+			Authorization: "Bearer key",
+			Body: map[string]interface{}{
+				"q": "lapotp", "limit": 1000.0,
+				"filter":               []interface{}{"user_id = 3"},
+				"attributesToRetrieve": []interface{}{"sku"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(requests, expectedRequests) {
+		t.Errorf("Expected requests %+v, but got %+v", expectedRequests,
+			requests)
+	}
+}
@@ -0,0 +1,26 @@
+/*
+Package utils containing utilities function
+
+This package cannot have import from another package except for config package
+*/
+package utils
+
+import "testing"
+
+// TestGetRandomSecret test GetRandomSecret
+func TestGetRandomSecret(t *testing.T) {
+	secrets := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		secret, err := GetRandomSecret(32)
+		if err != nil {
+			t.Errorf("Expected error nil, but got error => %s", err.Error())
+		}
+		if len(secret) != 64 {
+			t.Errorf("Expected secret length 64, but got %d", len(secret))
+		}
+		if secrets[secret] {
+			t.Errorf("Expected unique secret, but got duplicate %s", secret)
+		}
+		secrets[secret] = true
+	}
+}
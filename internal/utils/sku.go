@@ -6,25 +6,37 @@ This package cannot have import from another package except for config package
 package utils
 
 import (
-	"math/rand"
-	"time"
+	"crypto/rand"
 )
 
-// GetRandomSKU get random SKU
-func GetRandomSKU() string {
-	// change rand seed so the result is different everytime program running
-	rand.Seed(time.Now().UnixNano())
+// SKU length and characters
+const (
+	skuLength = 10
+	skuChars  = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+)
+
+// GetRandomSKU get cryptographically random SKU
+func GetRandomSKU() (string, error) {
+	SKU := make([]byte, 0, skuLength)
 
-	// set SKU length
-	n := 10
+	// random bytes at or above the largest multiple of the characters count
+	// are skipped so every character has the same probability
+	maxByte := byte(256 - 256%len(skuChars))
 
-	// get random sku
-	base := []rune("0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
+	b := make([]byte, skuLength*2)
+	for len(SKU) < skuLength {
+		_, err := rand.Read(b)
+		if err != nil {
+			return "", err
+		}
 
-	runeOfSKU := make([]rune, n)
-	for i := range runeOfSKU {
-		runeOfSKU[i] = base[rand.Intn(len(base))]
+		for _, c := range b {
+			if c >= maxByte || len(SKU) == skuLength {
+				continue
+			}
+			SKU = append(SKU, skuChars[int(c)%len(skuChars)])
+		}
 	}
 
-	return string(runeOfSKU)
+	return string(SKU), nil
 }
@@ -6,15 +6,27 @@ This package cannot have import from another package except for config package
 package utils
 
 import (
+	"strings"
 	"testing"
 )
 
 // TestGetRandomSKU test GetRandomSKU
 func TestGetRandomSKU(t *testing.T) {
+	SKUs := map[string]bool{}
 	for i := 0; i < 1000; i++ {
-		SKU := GetRandomSKU()
+		SKU, err := GetRandomSKU()
+		if err != nil {
+			t.Errorf("Expected error nil, but got error => %s", err.Error())
+		}
 		if len(SKU) != 10 {
 			t.Errorf("Expected SKU length 10, but got %d", len(SKU))
 		}
+		if strings.Trim(SKU, skuChars) != "" {
+			t.Errorf("Expected SKU alphanumeric, but got %s", SKU)
+		}
+		if SKUs[SKU] {
+			t.Errorf("Expected unique SKU, but got duplicate %s", SKU)
+		}
+		SKUs[SKU] = true
 	}
 }
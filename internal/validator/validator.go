@@ -5,26 +5,623 @@ package validator
 
 import (
 	"fmt"
+	"math"
+	"mime/multipart"
+	"net/url"
+	"sort"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+	"github.com/reyhanfikridz/ecom-product-service/internal/netguard"
+	"github.com/reyhanfikridz/ecom-product-service/internal/richtext"
 )
 
+// maximum lengths and numeric bounds of product info
+const (
+	maxNameLength             = 100
+	maxSKULength              = 15
+	maxPrice      model.Money = 1000000000000
+	maxWeight                 = 100000
+	maxDimension              = 10000
+	maxQuantity               = 1000000000
+)
+
+// validation error codes of a field
+const (
+	CodeRequired = "required"
+	CodeTooLong  = "too_long"
+	CodeNegative = "negative"
+	CodeTooLarge = "too_large"
+	CodeInvalid  = "invalid"
+)
+
+// FieldError validation failure of a form/JSON field
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error return message of field error
+func (e FieldError) Error() string {
+	return e.Message
+}
+
+// Errors validation failures of fields, at most one failure each field
+type Errors []FieldError
+
+// Error return messages of field errors joined by semicolon
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldError := range e {
+		messages[i] = fieldError.Message
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// add append field error with formatted message
+func (e *Errors) add(field, code, format string, a ...interface{}) {
+	*e = append(*e, FieldError{
+		Field:   field,
+		Code:    code,
+		Message: fmt.Sprintf(format, a...),
+	})
+}
+
+// err return field errors as error, nil if there's no field error
+func (e Errors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+
+	return e
+}
+
 // IsProductInfoValid check if product info data is valid
 //
-// return error nil if it's valid
+// return error nil if it's valid, otherwise Errors of every invalid field
 func IsProductInfoValid(pi model.ProductInfo) error {
+	errs := Errors{}
+
 	if strings.TrimSpace(pi.Name) == "" {
-		return fmt.Errorf("name empty/not found")
+		errs.add("name", CodeRequired, "name empty/not found")
+	} else if utf8.RuneCountInString(pi.Name) > maxNameLength {
+		errs.add("name", CodeTooLong, "name too long, maximum %d characters",
+			maxNameLength)
+	}
+
+	if len(pi.SKU) > maxSKULength {
+		errs.add("sku", CodeTooLong, "sku too long, maximum %d characters",
+			maxSKULength)
 	}
 
 	if pi.Price == 0 {
-		return fmt.Errorf("price empty/not found")
+		errs.add("price", CodeRequired, "price empty/not found")
+	} else if pi.Price < 0 {
+		errs.add("price", CodeNegative, "price can't be negative")
+	} else if pi.Price > maxPrice {
+		errs.add("price", CodeTooLarge, "price too large, maximum %s",
+			maxPrice)
 	}
 
 	if pi.Weight == 0 {
-		return fmt.Errorf("weight empty/not found")
+		errs.add("weight", CodeRequired, "weight empty/not found")
+	} else if pi.Weight < 0 {
+		errs.add("weight", CodeNegative, "weight can't be negative")
+	} else if pi.Weight > maxWeight {
+		errs.add("weight", CodeTooLarge, "weight too large, maximum %d kg",
+			maxWeight)
+	}
+
+	unit := pi.Unit
+	if unit == "" {
+		unit = model.UnitPiece
+	}
+	unitValid := model.IsProductUnitValid(unit)
+	if !unitValid {
+		errs.add("unit", CodeInvalid, "unit '%s' invalid, must be one of %s",
+			unit, strings.Join(model.GetProductUnits(), ", "))
+	}
+
+	if pi.Stock < 0 {
+		errs.add("stock", CodeNegative, "stock can't be negative")
+	} else if pi.Stock > maxQuantity {
+		errs.add("stock", CodeTooLarge, "stock too large, maximum %d",
+			maxQuantity)
+	} else if unitValid && !model.IsQuantityValid(unit, pi.Stock) {
+		errs.add("stock", CodeInvalid, "stock %v invalid for unit %s",
+			pi.Stock, unit)
+	}
+
+	orderQtys := []struct {
+		Field string
+		Qty   float64
+	}{
+		{Field: "min_order_qty", Qty: pi.MinOrderQty},
+		{Field: "max_order_qty", Qty: pi.MaxOrderQty},
+	}
+	for _, orderQty := range orderQtys {
+		if orderQty.Qty < 0 {
+			errs.add(orderQty.Field, CodeNegative, "%s can't be negative",
+				orderQty.Field)
+		} else if orderQty.Qty > maxQuantity {
+			errs.add(orderQty.Field, CodeTooLarge, "%s too large, maximum %d",
+				orderQty.Field, maxQuantity)
+		} else if unitValid && !model.IsQuantityValid(unit, orderQty.Qty) {
+			errs.add(orderQty.Field, CodeInvalid, "%s invalid for unit %s",
+				orderQty.Field, unit)
+		} else if orderQty.Field == "max_order_qty" && pi.MaxOrderQty != 0 &&
+			pi.MaxOrderQty < pi.MinOrderQty {
+			errs.add(orderQty.Field, CodeInvalid,
+				"max_order_qty can't be less than min_order_qty")
+		}
+	}
+
+	if pi.SalePrice < 0 {
+		errs.add("sale_price", CodeNegative, "sale_price can't be negative")
+	} else if pi.SalePrice > 0 && pi.SalePrice >= pi.Price {
+		errs.add("sale_price", CodeInvalid,
+			"sale_price must be less than price")
+	} else if pi.SalePrice == 0 &&
+		(pi.SaleStartsAt != nil || pi.SaleEndsAt != nil) {
+		errs.add("sale_price", CodeRequired,
+			"sale_price empty/not found for sale schedule")
+	}
+	if pi.SaleStartsAt != nil && pi.SaleEndsAt != nil &&
+		!pi.SaleEndsAt.After(*pi.SaleStartsAt) {
+		errs.add("sale_ends_at", CodeInvalid,
+			"sale_ends_at must be after sale_starts_at")
+	}
+
+	dimensions := []struct {
+		Field string
+		Size  float32
+	}{
+		{Field: "length", Size: pi.Length},
+		{Field: "width", Size: pi.Width},
+		{Field: "height", Size: pi.Height},
+	}
+	dimensionsSet := 0
+	for _, dimension := range dimensions {
+		if dimension.Size > 0 {
+			dimensionsSet++
+		}
+	}
+	for _, dimension := range dimensions {
+		if dimension.Size < 0 {
+			errs.add(dimension.Field, CodeNegative, "%s can't be negative",
+				dimension.Field)
+		} else if dimension.Size > maxDimension {
+			errs.add(dimension.Field, CodeTooLarge,
+				"%s too large, maximum %d cm", dimension.Field, maxDimension)
+		} else if dimension.Size == 0 && dimensionsSet != 0 {
+			errs.add(dimension.Field, CodeRequired, "%s empty/not found, "+
+				"length, width, and height must be all set or all empty",
+				dimension.Field)
+		}
+	}
+
+	if pi.Barcode != "" {
+		err := IsBarcodeValid(pi.Barcode)
+		if err != nil {
+			errs.add("barcode", CodeInvalid, "%s", err.Error())
+		}
+	}
+
+	if pi.DescriptionFormat != "" &&
+		!richtext.IsFormatValid(pi.DescriptionFormat) {
+		errs.add("description_format", CodeInvalid, "description_format "+
+			"'%s' invalid, must be plain, markdown, or html",
+			pi.DescriptionFormat)
+	}
+
+	return errs.err()
+}
+
+// IsBarcodeValid check if barcode is a valid GTIN-8 (EAN-8),
+// GTIN-12 (UPC-A), GTIN-13 (EAN-13), or GTIN-14 with correct check digit
+//
+// return error nil if it's valid
+func IsBarcodeValid(code string) error {
+	switch len(code) {
+	case 8, 12, 13, 14:
+	default:
+		return fmt.Errorf("barcode invalid, must be 8, 12, 13, or 14 digits")
+	}
+
+	// check digit is the last digit, the other digits weighted 3 and 1
+	// alternately from the right
+	sum := 0
+	for i := len(code) - 1; i >= 0; i-- {
+		if code[i] < '0' || code[i] > '9' {
+			return fmt.Errorf("barcode invalid, must be 8, 12, 13, or 14 digits")
+		}
+		if i == len(code)-1 {
+			continue
+		}
+
+		digit := int(code[i] - '0')
+		if (len(code)-1-i)%2 == 1 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	if (10-sum%10)%10 != int(code[len(code)-1]-'0') {
+		return fmt.Errorf("barcode invalid, check digit mismatch")
 	}
 
 	return nil
 }
+
+// IsProductImagesValid check if uploaded product images are at most
+// maxCount images with total size at most maxSize bytes, and their file
+// names fit into image path
+//
+// return error nil if it's valid, otherwise Errors of product_images field
+func IsProductImagesValid(fileHeaders []*multipart.FileHeader, maxCount int,
+	maxSize int64) error {
+	errs := Errors{}
+	if len(fileHeaders) > maxCount {
+		errs.add("product_images", CodeTooLarge, "too many product images, "+
+			"maximum %d images but got %d", maxCount, len(fileHeaders))
+		return errs
+	}
+
+	var size int64
+	for _, fileHeader := range fileHeaders {
+		if len(fileHeader.Filename) > model.MaxImageFilenameLength {
+			errs.add("product_images", CodeTooLong, "product image file name "+
+				"'%s' too long, maximum %d characters", fileHeader.Filename,
+				model.MaxImageFilenameLength)
+			return errs
+		}
+		size += fileHeader.Size
+	}
+	if size > maxSize {
+		errs.add("product_images", CodeTooLarge, "product images too large, "+
+			"maximum %d bytes in total but got %d bytes", maxSize, size)
+	}
+
+	return errs.err()
+}
+
+// IsWebhookSubscriptionValid check if webhook subscription data is valid
+//
+// return error nil if it's valid
+func IsWebhookSubscriptionValid(sub model.WebhookSubscription) error {
+	if strings.TrimSpace(sub.URL) == "" {
+		return fmt.Errorf("url empty/not found")
+	}
+
+	u, err := url.Parse(sub.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url invalid, must be absolute http/https URL")
+	}
+	if !netguard.IsHostAllowed(u.Hostname()) {
+		return fmt.Errorf("url host not allowed, must be public address")
+	}
+
+	if len(sub.URL) > 500 {
+		return fmt.Errorf("url too long, maximum 500 characters")
+	}
+
+	if len(sub.Events) == 0 {
+		return fmt.Errorf("events empty/not found")
+	}
+
+	for _, e := range sub.Events {
+		known := false
+		for _, webhookEvent := range model.WebhookEvents {
+			if e == webhookEvent {
+				known = true
+				break
+			}
+		}
+
+		if !known {
+			return fmt.Errorf("event '%s' unknown", e)
+		}
+	}
+
+	return nil
+}
+
+// maximum price tiers of a product
+const maxPriceTiers = 20
+
+// IsPriceTiersValid check if price tiers of product with the unit
+// and base price are valid, each tier must be cheaper than base price
+// and the tiers with lower minimum quantity
+//
+// return error nil if it's valid
+func IsPriceTiersValid(tiers []model.PriceTier, unit string,
+	price model.Money) error {
+	if len(tiers) > maxPriceTiers {
+		return fmt.Errorf("too many price tiers, maximum %d tiers",
+			maxPriceTiers)
+	}
+
+	sorted := append([]model.PriceTier{}, tiers...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].MinQty < sorted[j].MinQty
+	})
+	for i, tier := range sorted {
+		if tier.MinQty <= 0 || !model.IsQuantityValid(unit, tier.MinQty) {
+			return fmt.Errorf("min_qty %v invalid for unit %s",
+				tier.MinQty, unit)
+		}
+		if tier.Price <= 0 || tier.Price >= price {
+			return fmt.Errorf("price of min_qty %v must be greater than "+
+				"zero and less than product price", tier.MinQty)
+		}
+		if i > 0 && tier.MinQty == sorted[i-1].MinQty {
+			return fmt.Errorf("min_qty %v duplicated", tier.MinQty)
+		}
+		if i > 0 && tier.Price >= sorted[i-1].Price {
+			return fmt.Errorf("price of min_qty %v must be less than "+
+				"price of min_qty %v", tier.MinQty, sorted[i-1].MinQty)
+		}
+	}
+
+	return nil
+}
+
+// IsProductTranslationValid check if product translation data is valid,
+// its locale must be normalized and not the default locale
+//
+// return error nil if it's valid, otherwise Errors of every invalid field
+func IsProductTranslationValid(t model.ProductTranslation,
+	defaultLocale string) error {
+	errs := Errors{}
+
+	if !model.IsLocaleValid(t.Locale) {
+		errs.add("locale", CodeInvalid, "locale '%s' invalid, must be "+
+			"language code like 'id' or 'en-us'", t.Locale)
+	} else if t.Locale == defaultLocale {
+		errs.add("locale", CodeInvalid, "locale '%s' is the default locale, "+
+			"update the product instead", t.Locale)
+	}
+
+	if strings.TrimSpace(t.Name) == "" {
+		errs.add("name", CodeRequired, "name empty/not found")
+	} else if utf8.RuneCountInString(t.Name) > maxNameLength {
+		errs.add("name", CodeTooLong, "name too long, maximum %d characters",
+			maxNameLength)
+	}
+
+	return errs.err()
+}
+
+// IsProductTransferValid check if product transfer data is valid
+//
+// return error nil if it's valid, otherwise Errors of every invalid field
+func IsProductTransferValid(t model.ProductTransfer) error {
+	errs := Errors{}
+
+	if len(t.SKU) > maxSKULength {
+		errs.add("sku", CodeTooLong, "sku too long, maximum %d characters",
+			maxSKULength)
+	}
+	if t.FromUserID <= 0 {
+		errs.add("from_user_id", CodeRequired, "from_user_id empty/not found")
+	}
+	if t.ToUserID <= 0 {
+		errs.add("to_user_id", CodeRequired, "to_user_id empty/not found")
+	} else if t.ToUserID == t.FromUserID {
+		errs.add("to_user_id", CodeInvalid,
+			"to_user_id must be different from from_user_id")
+	}
+
+	return errs.err()
+}
+
+// IsProductRatingValid check if product rating aggregate is valid,
+// average rating must be 0 without reviews
+//
+// return error nil if it's valid, otherwise Errors of every invalid field
+func IsProductRatingValid(rating model.ProductRating) error {
+	errs := Errors{}
+
+	if rating.AvgRating < 0 || rating.AvgRating > model.MaxRating ||
+		math.IsNaN(rating.AvgRating) {
+		errs.add("avg_rating", CodeInvalid,
+			"avg_rating invalid, must be between 0 and %d", model.MaxRating)
+	} else if rating.AvgRating > 0 && rating.ReviewCount == 0 {
+		errs.add("avg_rating", CodeInvalid,
+			"avg_rating must be 0 when review_count is 0")
+	}
+	if rating.ReviewCount < 0 {
+		errs.add("review_count", CodeNegative, "review_count can't be negative")
+	}
+
+	return errs.err()
+}
+
+// maximum popularity counters reported at once
+const maxPopularityCounters = 1000
+
+// IsPopularityCountersValid check if popularity counters data is valid
+//
+// return error nil if it's valid
+func IsPopularityCountersValid(counters []model.PopularityCounter) error {
+	if len(counters) == 0 {
+		return fmt.Errorf("counters empty/not found")
+	}
+	if len(counters) > maxPopularityCounters {
+		return fmt.Errorf("counters too many, maximum %d",
+			maxPopularityCounters)
+	}
+
+	for i, counter := range counters {
+		if strings.TrimSpace(counter.SKU) == "" {
+			return fmt.Errorf("counter %d sku empty/not found", i+1)
+		}
+		if counter.WishlistAdds < 0 || counter.CompletedOrders < 0 {
+			return fmt.Errorf("counter %d of sku %s can't be negative",
+				i+1, counter.SKU)
+		}
+	}
+
+	return nil
+}
+
+// IsStockUpdatesValid check if batch stock updates data is valid
+//
+// return error nil if it's valid
+func IsStockUpdatesValid(updates []model.StockUpdate) error {
+	if len(updates) == 0 {
+		return fmt.Errorf("stock updates empty/not found")
+	}
+
+	SKUs := map[string]bool{}
+	for i, update := range updates {
+		if strings.TrimSpace(update.SKU) == "" {
+			return fmt.Errorf("item %d: sku empty/not found", i)
+		}
+		if update.Stock < 0 {
+			return fmt.Errorf("item %d: stock can't be negative", i)
+		}
+		if SKUs[update.SKU] {
+			return fmt.Errorf("item %d: sku '%s' duplicated", i, update.SKU)
+		}
+		SKUs[update.SKU] = true
+	}
+
+	return nil
+}
+
+// IsStockDecreasesValid check if batch stock decreases data is valid
+//
+// return error nil if it's valid
+func IsStockDecreasesValid(decreases []model.StockDecrease) error {
+	if len(decreases) == 0 {
+		return fmt.Errorf("stock decreases empty/not found")
+	}
+
+	SKUs := map[string]bool{}
+	for i, decrease := range decreases {
+		if strings.TrimSpace(decrease.SKU) == "" {
+			return fmt.Errorf("item %d: sku empty/not found", i)
+		}
+		if decrease.Qty <= 0 {
+			return fmt.Errorf("item %d: qty must be greater than zero", i)
+		}
+		if SKUs[decrease.SKU] {
+			return fmt.Errorf("item %d: sku '%s' duplicated", i, decrease.SKU)
+		}
+		SKUs[decrease.SKU] = true
+	}
+
+	return nil
+}
+
+// minimum and maximum length of shop slug, and maximum length
+// of shop display name
+const (
+	minShopSlugLength        = 3
+	maxShopSlugLength        = 50
+	maxShopDisplayNameLength = 100
+)
+
+// IsShopValid check if shop data is valid, its slug must be lowercase
+// letters and digits separated by single hyphens, e.g. 'toko-budi'
+//
+// return error nil if it's valid, otherwise Errors of every invalid field
+func IsShopValid(shop model.Shop) error {
+	errs := Errors{}
+
+	if shop.Slug == "" {
+		errs.add("slug", CodeRequired, "slug empty/not found")
+	} else if len(shop.Slug) < minShopSlugLength ||
+		len(shop.Slug) > maxShopSlugLength {
+		errs.add("slug", CodeInvalid, "slug must be %d to %d characters",
+			minShopSlugLength, maxShopSlugLength)
+	} else if !isSlug(shop.Slug) {
+		errs.add("slug", CodeInvalid, "slug invalid, must be lowercase "+
+			"letters and digits separated by hyphens")
+	}
+
+	if strings.TrimSpace(shop.DisplayName) == "" {
+		errs.add("display_name", CodeRequired, "display_name empty/not found")
+	} else if utf8.RuneCountInString(shop.DisplayName) >
+		maxShopDisplayNameLength {
+		errs.add("display_name", CodeTooLong, "display_name too long, "+
+			"maximum %d characters", maxShopDisplayNameLength)
+	}
+
+	return errs.err()
+}
+
+// IsShopBannerValid check if uploaded shop banner is at most maxSize bytes
+// and its file name fits into image path
+//
+// return error nil if it's valid, otherwise Errors of banner field
+func IsShopBannerValid(fileHeader *multipart.FileHeader,
+	maxSize int64) error {
+	errs := Errors{}
+	if len(fileHeader.Filename) > model.MaxImageFilenameLength {
+		errs.add("banner", CodeTooLong, "banner file name '%s' too long, "+
+			"maximum %d characters", fileHeader.Filename,
+			model.MaxImageFilenameLength)
+	} else if fileHeader.Size > maxSize {
+		errs.add("banner", CodeTooLarge, "banner too large, maximum %d "+
+			"bytes but got %d bytes", maxSize, fileHeader.Size)
+	}
+
+	return errs.err()
+}
+
+// isSlug check if s is lowercase letters and digits separated
+// by single hyphens
+func isSlug(s string) bool {
+	for _, part := range strings.Split(s, "-") {
+		if part == "" {
+			return false
+		}
+		for _, c := range part {
+			if !((c >= 'a' && c <= 'z') || (c >= '0' && c <= '9')) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// maxReportNoteLength maximum length of product report note
+const maxReportNoteLength = 1000
+
+// IsProductReportValid check if product report is valid, its reason must be
+// one of model.ReportReasons
+//
+// return error nil if it's valid, otherwise Errors of every invalid field
+func IsProductReportValid(report model.ProductReport) error {
+	errs := Errors{}
+
+	if report.Reason == "" {
+		errs.add("reason", CodeRequired, "reason empty/not found")
+	} else if !isReportReason(report.Reason) {
+		errs.add("reason", CodeInvalid, "reason invalid, must be one of %s",
+			strings.Join(model.ReportReasons, ", "))
+	}
+
+	if utf8.RuneCountInString(report.Note) > maxReportNoteLength {
+		errs.add("note", CodeTooLong, "note too long, maximum %d characters",
+			maxReportNoteLength)
+	}
+
+	return errs.err()
+}
+
+// isReportReason check if reason is one of model.ReportReasons
+func isReportReason(reason string) bool {
+	for _, r := range model.ReportReasons {
+		if r == reason {
+			return true
+		}
+	}
+
+	return false
+}
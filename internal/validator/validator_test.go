@@ -4,14 +4,22 @@ Package validator containing any validator function
 package validator
 
 import (
+	"errors"
 	"fmt"
+	"mime/multipart"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/reyhanfikridz/ecom-product-service/internal/model"
 )
 
 // TestIsProductInfoValid test IsProductInfoValid
 func TestIsProductInfoValid(t *testing.T) {
+	saleStartsAt := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	saleEndsAt := saleStartsAt.Add(7 * 24 * time.Hour)
+
 	// initialize testing table
 	testTable := []struct {
 		TestName       string
@@ -22,7 +30,7 @@ func TestIsProductInfoValid(t *testing.T) {
 			TestName: "Test Form Complete",
 			Product: model.ProductInfo{
 				Name:   "test product",
-				Price:  1000000.50,
+				Price:  100000050,
 				Weight: 1.52,
 				Stock:  100,
 			},
@@ -32,7 +40,7 @@ func TestIsProductInfoValid(t *testing.T) {
 			TestName: "Test Form Incomplete 1",
 			Product: model.ProductInfo{
 				Name:   "",
-				Price:  1000000.50,
+				Price:  100000050,
 				Weight: 1.52,
 				Stock:  100,
 			},
@@ -52,12 +60,277 @@ func TestIsProductInfoValid(t *testing.T) {
 			TestName: "Test Form Incomplete 3",
 			Product: model.ProductInfo{
 				Name:   "test product",
-				Price:  1000000.50,
+				Price:  100000050,
 				Weight: 0,
 				Stock:  100,
 			},
 			ExpectedResult: fmt.Errorf("weight empty/not found"),
 		},
+		{
+			TestName: "Test Name Too Long",
+			Product: model.ProductInfo{
+				Name:   strings.Repeat("n", 101),
+				Price:  100000050,
+				Weight: 1.52,
+				Stock:  100,
+			},
+			ExpectedResult: fmt.Errorf("name too long, maximum 100 characters"),
+		},
+		{
+			TestName: "Test SKU Too Long",
+			Product: model.ProductInfo{
+				SKU:    "SKU-0123456789AB",
+				Name:   "test product",
+				Price:  100000050,
+				Weight: 1.52,
+				Stock:  100,
+			},
+			ExpectedResult: fmt.Errorf("sku too long, maximum 15 characters"),
+		},
+		{
+			TestName: "Test Negative Price",
+			Product: model.ProductInfo{
+				Name:   "test product",
+				Price:  -100,
+				Weight: 1.52,
+				Stock:  100,
+			},
+			ExpectedResult: fmt.Errorf("price can't be negative"),
+		},
+		{
+			TestName: "Test Price Too Large",
+			Product: model.ProductInfo{
+				Name:   "test product",
+				Price:  1000000000001,
+				Weight: 1.52,
+				Stock:  100,
+			},
+			ExpectedResult: fmt.Errorf("price too large, maximum 10000000000"),
+		},
+		{
+			TestName: "Test Negative Weight",
+			Product: model.ProductInfo{
+				Name:   "test product",
+				Price:  100000050,
+				Weight: -1.52,
+				Stock:  100,
+			},
+			ExpectedResult: fmt.Errorf("weight can't be negative"),
+		},
+		{
+			TestName: "Test Weight Too Large",
+			Product: model.ProductInfo{
+				Name:   "test product",
+				Price:  100000050,
+				Weight: 100001,
+				Stock:  100,
+			},
+			ExpectedResult: fmt.Errorf("weight too large, maximum 100000 kg"),
+		},
+		{
+			TestName: "Test Negative Stock",
+			Product: model.ProductInfo{
+				Name:   "test product",
+				Price:  100000050,
+				Weight: 1.52,
+				Stock:  -1,
+			},
+			ExpectedResult: fmt.Errorf("stock can't be negative"),
+		},
+		{
+			TestName: "Test Order Qty Too Large",
+			Product: model.ProductInfo{
+				Name:        "test product",
+				Price:       100000050,
+				Weight:      1.52,
+				Stock:       100,
+				MaxOrderQty: 1000000001,
+			},
+			ExpectedResult: fmt.Errorf("max_order_qty too large, " +
+				"maximum 1000000000"),
+		},
+		{
+			TestName: "Test Dimension Too Large",
+			Product: model.ProductInfo{
+				Name:   "test product",
+				Price:  100000050,
+				Weight: 1.52,
+				Stock:  100,
+				Length: 10001,
+				Width:  20,
+				Height: 10,
+			},
+			ExpectedResult: fmt.Errorf("length too large, maximum 10000 cm"),
+		},
+		{
+			TestName: "Test Unit Fractional Stock",
+			Product: model.ProductInfo{
+				Name:   "test product",
+				Price:  100000050,
+				Weight: 1.52,
+				Stock:  2.5,
+				Unit:   "kg",
+			},
+			ExpectedResult: nil,
+		},
+		{
+			TestName: "Test Unit Invalid",
+			Product: model.ProductInfo{
+				Name:   "test product",
+				Price:  100000050,
+				Weight: 1.52,
+				Stock:  2,
+				Unit:   "bucket",
+			},
+			ExpectedResult: fmt.Errorf("unit 'bucket' invalid, must be one " +
+				"of box, cm, dozen, gram, kg, liter, meter, ml, pack, piece"),
+		},
+		{
+			TestName: "Test Unit Whole Fractional Stock",
+			Product: model.ProductInfo{
+				Name:   "test product",
+				Price:  100000050,
+				Weight: 1.52,
+				Stock:  2.5,
+			},
+			ExpectedResult: fmt.Errorf("stock 2.5 invalid for unit piece"),
+		},
+		{
+			TestName: "Test Order Qty Complete",
+			Product: model.ProductInfo{
+				Name:        "test product",
+				Price:       100000050,
+				Weight:      1.52,
+				MinOrderQty: 10,
+				MaxOrderQty: 100,
+			},
+			ExpectedResult: nil,
+		},
+		{
+			TestName: "Test Order Qty Max Less Than Min",
+			Product: model.ProductInfo{
+				Name:        "test product",
+				Price:       100000050,
+				Weight:      1.52,
+				MinOrderQty: 10,
+				MaxOrderQty: 5,
+			},
+			ExpectedResult: fmt.Errorf(
+				"max_order_qty can't be less than min_order_qty"),
+		},
+		{
+			TestName: "Test Order Qty Fractional For Piece",
+			Product: model.ProductInfo{
+				Name:        "test product",
+				Price:       100000050,
+				Weight:      1.52,
+				MinOrderQty: 0.5,
+			},
+			ExpectedResult: fmt.Errorf("min_order_qty invalid for unit piece"),
+		},
+		{
+			TestName: "Test Sale Complete",
+			Product: model.ProductInfo{
+				Name:         "test product",
+				Price:        1000,
+				Weight:       1.52,
+				SalePrice:    800,
+				SaleStartsAt: &saleStartsAt,
+				SaleEndsAt:   &saleEndsAt,
+			},
+			ExpectedResult: nil,
+		},
+		{
+			TestName: "Test Sale Price Not Less Than Price",
+			Product: model.ProductInfo{
+				Name:      "test product",
+				Price:     1000,
+				Weight:    1.52,
+				SalePrice: 1000,
+			},
+			ExpectedResult: fmt.Errorf("sale_price must be less than price"),
+		},
+		{
+			TestName: "Test Sale Schedule Without Sale Price",
+			Product: model.ProductInfo{
+				Name:         "test product",
+				Price:        1000,
+				Weight:       1.52,
+				SaleStartsAt: &saleStartsAt,
+			},
+			ExpectedResult: fmt.Errorf(
+				"sale_price empty/not found for sale schedule"),
+		},
+		{
+			TestName: "Test Sale Ends Before Starts",
+			Product: model.ProductInfo{
+				Name:         "test product",
+				Price:        1000,
+				Weight:       1.52,
+				SalePrice:    800,
+				SaleStartsAt: &saleEndsAt,
+				SaleEndsAt:   &saleStartsAt,
+			},
+			ExpectedResult: fmt.Errorf(
+				"sale_ends_at must be after sale_starts_at"),
+		},
+		{
+			TestName: "Test Dimensions Complete",
+			Product: model.ProductInfo{
+				Name:   "test product",
+				Price:  100000050,
+				Weight: 1.52,
+				Length: 30,
+				Width:  20,
+				Height: 10,
+			},
+			ExpectedResult: nil,
+		},
+		{
+			TestName: "Test Dimensions Incomplete",
+			Product: model.ProductInfo{
+				Name:   "test product",
+				Price:  100000050,
+				Weight: 1.52,
+				Length: 30,
+				Width:  20,
+			},
+			ExpectedResult: fmt.Errorf("height empty/not found, length, " +
+				"width, and height must be all set or all empty"),
+		},
+		{
+			TestName: "Test Dimensions Negative",
+			Product: model.ProductInfo{
+				Name:   "test product",
+				Price:  100000050,
+				Weight: 1.52,
+				Length: 30,
+				Width:  -20,
+				Height: 10,
+			},
+			ExpectedResult: fmt.Errorf("width can't be negative"),
+		},
+		{
+			TestName: "Test Description Format Markdown",
+			Product: model.ProductInfo{
+				Name:              "test product",
+				Price:             100000050,
+				Weight:            1.52,
+				Description:       "**bold**",
+				DescriptionFormat: "markdown",
+			},
+		},
+		{
+			TestName: "Test Description Format Unknown",
+			Product: model.ProductInfo{
+				Name:              "test product",
+				Price:             100000050,
+				Weight:            1.52,
+				DescriptionFormat: "rtf",
+			},
+			ExpectedResult: fmt.Errorf("description_format 'rtf' invalid, " +
+				"must be plain, markdown, or html"),
+		},
 	}
 
 	// Do the test
@@ -75,3 +348,782 @@ func TestIsProductInfoValid(t *testing.T) {
 		}
 	}
 }
+
+// TestIsWebhookSubscriptionValid test IsWebhookSubscriptionValid
+// TestIsProductInfoValidFieldErrors test IsProductInfoValid return
+// every invalid field
+func TestIsProductInfoValidFieldErrors(t *testing.T) {
+	err := IsProductInfoValid(model.ProductInfo{
+		Price:  -100,
+		Weight: 1.52,
+		Stock:  100,
+		Unit:   "bucket",
+	})
+
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Expected field errors, but got %v", err)
+	}
+
+	expected := Errors{
+		{Field: "name", Code: CodeRequired, Message: "name empty/not found"},
+		{Field: "price", Code: CodeNegative,
+			Message: "price can't be negative"},
+		{Field: "unit", Code: CodeInvalid, Message: "unit 'bucket' invalid, " +
+			"must be one of box, cm, dozen, gram, kg, liter, meter, ml, " +
+			"pack, piece"},
+	}
+	if !reflect.DeepEqual(errs, expected) {
+		t.Errorf("Expected field errors %+v, but got %+v", expected, errs)
+	}
+}
+
+func TestIsWebhookSubscriptionValid(t *testing.T) {
+	// initialize testing table
+	testTable := []struct {
+		TestName       string
+		Subscription   model.WebhookSubscription
+		ExpectedResult error
+	}{
+		{
+			TestName: "Test Form Complete",
+			Subscription: model.WebhookSubscription{
+				URL:    "https://example.com/hook",
+				Events: []string{"product.created", "stock.low"},
+			},
+			ExpectedResult: nil,
+		},
+		{
+			TestName: "Test URL Empty",
+			Subscription: model.WebhookSubscription{
+				Events: []string{"product.created"},
+			},
+			ExpectedResult: fmt.Errorf("url empty/not found"),
+		},
+		{
+			TestName: "Test URL Invalid",
+			Subscription: model.WebhookSubscription{
+				URL:    "ftp://example.com/hook",
+				Events: []string{"product.created"},
+			},
+			ExpectedResult: fmt.Errorf("url invalid, must be absolute http/https URL"),
+		},
+		{
+			TestName: "Test URL Internal Address",
+			Subscription: model.WebhookSubscription{
+				URL:    "http://169.254.169.254/latest/meta-data/",
+				Events: []string{"product.created"},
+			},
+			ExpectedResult: fmt.Errorf("url host not allowed, must be public address"),
+		},
+		{
+			TestName: "Test URL Loopback Address",
+			Subscription: model.WebhookSubscription{
+				URL:    "http://[::1]:8080/hook",
+				Events: []string{"product.created"},
+			},
+			ExpectedResult: fmt.Errorf("url host not allowed, must be public address"),
+		},
+		{
+			TestName: "Test Events Empty",
+			Subscription: model.WebhookSubscription{
+				URL: "https://example.com/hook",
+			},
+			ExpectedResult: fmt.Errorf("events empty/not found"),
+		},
+		{
+			TestName: "Test Event Unknown",
+			Subscription: model.WebhookSubscription{
+				URL:    "https://example.com/hook",
+				Events: []string{"order.placed"},
+			},
+			ExpectedResult: fmt.Errorf("event 'order.placed' unknown"),
+		},
+	}
+
+	// Do the test
+	for _, test := range testTable {
+		err := IsWebhookSubscriptionValid(test.Subscription)
+		if test.ExpectedResult == nil && err != nil {
+			t.Errorf("[%s] Expected subscription valid, but got invalid => %s",
+				test.TestName, err.Error())
+		} else if test.ExpectedResult != nil {
+			if err == nil {
+				t.Errorf("[%s] Expected subscription invalid, but got valid",
+					test.TestName)
+			} else if test.ExpectedResult.Error() != err.Error() {
+				t.Errorf("[%s] Expected error '%s' got '%s'",
+					test.TestName, test.ExpectedResult.Error(), err.Error())
+			}
+		}
+	}
+}
+
+// TestIsPriceTiersValid test IsPriceTiersValid
+func TestIsPriceTiersValid(t *testing.T) {
+	// create testing table
+	testTable := []struct {
+		TestName       string
+		Tiers          []model.PriceTier
+		Unit           string
+		ExpectedResult error
+	}{
+		{
+			TestName: "Test Valid",
+			Tiers: []model.PriceTier{
+				{MinQty: 100, Price: 800},
+				{MinQty: 10, Price: 900},
+			},
+			Unit: model.UnitPiece,
+		},
+		{
+			TestName: "Test Empty Tiers Valid",
+			Tiers:    []model.PriceTier{},
+			Unit:     model.UnitPiece,
+		},
+		{
+			TestName: "Test Fractional Min Qty of Piece",
+			Tiers:    []model.PriceTier{{MinQty: 1.5, Price: 900}},
+			Unit:     model.UnitPiece,
+			ExpectedResult: fmt.Errorf(
+				"min_qty 1.5 invalid for unit piece"),
+		},
+		{
+			TestName: "Test Price Not Less Than Product Price",
+			Tiers:    []model.PriceTier{{MinQty: 10, Price: 1000}},
+			Unit:     model.UnitPiece,
+			ExpectedResult: fmt.Errorf("price of min_qty 10 must be " +
+				"greater than zero and less than product price"),
+		},
+		{
+			TestName: "Test Duplicated Min Qty",
+			Tiers: []model.PriceTier{
+				{MinQty: 10, Price: 900},
+				{MinQty: 10, Price: 800},
+			},
+			Unit:           model.UnitPiece,
+			ExpectedResult: fmt.Errorf("min_qty 10 duplicated"),
+		},
+		{
+			TestName: "Test Price Not Decreasing",
+			Tiers: []model.PriceTier{
+				{MinQty: 10, Price: 800},
+				{MinQty: 100, Price: 900},
+			},
+			Unit: model.UnitPiece,
+			ExpectedResult: fmt.Errorf("price of min_qty 100 must be " +
+				"less than price of min_qty 10"),
+		},
+	}
+
+	// Do the test
+	for _, test := range testTable {
+		err := IsPriceTiersValid(test.Tiers, test.Unit, 1000)
+		if test.ExpectedResult == nil && err != nil {
+			t.Errorf("[%s] Expected price tiers valid, but got invalid => %s",
+				test.TestName, err.Error())
+		} else if test.ExpectedResult != nil {
+			if err == nil {
+				t.Errorf("[%s] Expected price tiers invalid, but got valid",
+					test.TestName)
+			} else if test.ExpectedResult.Error() != err.Error() {
+				t.Errorf("[%s] Expected error '%s' got '%s'",
+					test.TestName, test.ExpectedResult.Error(), err.Error())
+			}
+		}
+	}
+}
+
+// TestIsProductTranslationValid test IsProductTranslationValid
+func TestIsProductTranslationValid(t *testing.T) {
+	// create testing table
+	testTable := []struct {
+		TestName       string
+		Translation    model.ProductTranslation
+		ExpectedResult error
+	}{
+		{
+			TestName: "Test Valid",
+			Translation: model.ProductTranslation{Locale: "id-id",
+				Name: "Mouse Nirkabel"},
+		},
+		{
+			TestName: "Test Locale Invalid",
+			Translation: model.ProductTranslation{Locale: "indonesian",
+				Name: "Mouse Nirkabel"},
+			ExpectedResult: fmt.Errorf("locale 'indonesian' invalid, must be " +
+				"language code like 'id' or 'en-us'"),
+		},
+		{
+			TestName: "Test Default Locale",
+			Translation: model.ProductTranslation{Locale: "en",
+				Name: "Wireless Mouse"},
+			ExpectedResult: fmt.Errorf("locale 'en' is the default locale, " +
+				"update the product instead"),
+		},
+		{
+			TestName:       "Test Name Empty",
+			Translation:    model.ProductTranslation{Locale: "id", Name: " "},
+			ExpectedResult: fmt.Errorf("name empty/not found"),
+		},
+		{
+			TestName: "Test Name Too Long",
+			Translation: model.ProductTranslation{Locale: "id",
+				Name: strings.Repeat("a", 101)},
+			ExpectedResult: fmt.Errorf("name too long, maximum 100 characters"),
+		},
+	}
+
+	// Do the test
+	for _, test := range testTable {
+		err := IsProductTranslationValid(test.Translation, "en")
+		if test.ExpectedResult == nil && err != nil {
+			t.Errorf("[%s] Expected translation valid, but got invalid => %s",
+				test.TestName, err.Error())
+		} else if test.ExpectedResult != nil {
+			if err == nil {
+				t.Errorf("[%s] Expected translation invalid, but got valid",
+					test.TestName)
+			} else if test.ExpectedResult.Error() != err.Error() {
+				t.Errorf("[%s] Expected error '%s' got '%s'",
+					test.TestName, test.ExpectedResult.Error(), err.Error())
+			}
+		}
+	}
+}
+
+// TestIsProductTransferValid test IsProductTransferValid
+func TestIsProductTransferValid(t *testing.T) {
+	// initialize testing table
+	testTable := []struct {
+		TestName       string
+		Transfer       model.ProductTransfer
+		ExpectedResult error
+	}{
+		{
+			TestName:       "Test Transfer Catalog",
+			Transfer:       model.ProductTransfer{FromUserID: 1, ToUserID: 2},
+			ExpectedResult: nil,
+		},
+		{
+			TestName: "Test Transfer Product",
+			Transfer: model.ProductTransfer{SKU: "SKU-A", FromUserID: 1,
+				ToUserID: 2},
+			ExpectedResult: nil,
+		},
+		{
+			TestName: "Test Users Empty",
+			Transfer: model.ProductTransfer{},
+			ExpectedResult: fmt.Errorf("from_user_id empty/not found; " +
+				"to_user_id empty/not found"),
+		},
+		{
+			TestName: "Test Same User",
+			Transfer: model.ProductTransfer{FromUserID: 1, ToUserID: 1},
+			ExpectedResult: fmt.Errorf("to_user_id must be different " +
+				"from from_user_id"),
+		},
+	}
+
+	// Do the test
+	for _, test := range testTable {
+		err := IsProductTransferValid(test.Transfer)
+		if test.ExpectedResult == nil && err != nil {
+			t.Errorf("[%s] Expected product transfer valid, but got "+
+				"invalid => %s", test.TestName, err.Error())
+		} else if test.ExpectedResult != nil {
+			if err == nil {
+				t.Errorf("[%s] Expected product transfer invalid, but got "+
+					"valid", test.TestName)
+			} else if test.ExpectedResult.Error() != err.Error() {
+				t.Errorf("[%s] Expected error '%s' got '%s'",
+					test.TestName, test.ExpectedResult.Error(), err.Error())
+			}
+		}
+	}
+}
+
+// TestIsProductRatingValid test IsProductRatingValid
+func TestIsProductRatingValid(t *testing.T) {
+	// initialize testing table
+	testTable := []struct {
+		TestName       string
+		Rating         model.ProductRating
+		ExpectedResult error
+	}{
+		{
+			TestName:       "Test Rating Valid",
+			Rating:         model.ProductRating{AvgRating: 4.25, ReviewCount: 4},
+			ExpectedResult: nil,
+		},
+		{
+			TestName:       "Test No Reviews",
+			Rating:         model.ProductRating{},
+			ExpectedResult: nil,
+		},
+		{
+			TestName: "Test Rating Invalid",
+			Rating:   model.ProductRating{AvgRating: 6, ReviewCount: -1},
+			ExpectedResult: fmt.Errorf("avg_rating invalid, must be between " +
+				"0 and 5; review_count can't be negative"),
+		},
+		{
+			TestName: "Test Rating Without Reviews",
+			Rating:   model.ProductRating{AvgRating: 3},
+			ExpectedResult: fmt.Errorf("avg_rating must be 0 when " +
+				"review_count is 0"),
+		},
+	}
+
+	// Do the test
+	for _, test := range testTable {
+		err := IsProductRatingValid(test.Rating)
+		if test.ExpectedResult == nil && err != nil {
+			t.Errorf("[%s] Expected product rating valid, but got "+
+				"invalid => %s", test.TestName, err.Error())
+		} else if test.ExpectedResult != nil {
+			if err == nil {
+				t.Errorf("[%s] Expected product rating invalid, but got "+
+					"valid", test.TestName)
+			} else if test.ExpectedResult.Error() != err.Error() {
+				t.Errorf("[%s] Expected error '%s' got '%s'",
+					test.TestName, test.ExpectedResult.Error(), err.Error())
+			}
+		}
+	}
+}
+
+// TestIsPopularityCountersValid test IsPopularityCountersValid
+func TestIsPopularityCountersValid(t *testing.T) {
+	// initialize testing table
+	testTable := []struct {
+		TestName       string
+		Counters       []model.PopularityCounter
+		ExpectedResult error
+	}{
+		{
+			TestName: "Test Counters Valid",
+			Counters: []model.PopularityCounter{
+				{SKU: "SKU-A", WishlistAdds: 2},
+				{SKU: "SKU-B", CompletedOrders: 1},
+			},
+			ExpectedResult: nil,
+		},
+		{
+			TestName:       "Test Counters Empty",
+			Counters:       []model.PopularityCounter{},
+			ExpectedResult: fmt.Errorf("counters empty/not found"),
+		},
+		{
+			TestName:       "Test SKU Empty",
+			Counters:       []model.PopularityCounter{{WishlistAdds: 1}},
+			ExpectedResult: fmt.Errorf("counter 1 sku empty/not found"),
+		},
+		{
+			TestName: "Test Counter Negative",
+			Counters: []model.PopularityCounter{
+				{SKU: "SKU-A", WishlistAdds: 1},
+				{SKU: "SKU-B", CompletedOrders: -1},
+			},
+			ExpectedResult: fmt.Errorf("counter 2 of sku SKU-B " +
+				"can't be negative"),
+		},
+	}
+
+	// Do the test
+	for _, test := range testTable {
+		err := IsPopularityCountersValid(test.Counters)
+		if test.ExpectedResult == nil && err != nil {
+			t.Errorf("[%s] Expected popularity counters valid, but got "+
+				"invalid => %s", test.TestName, err.Error())
+		} else if test.ExpectedResult != nil {
+			if err == nil {
+				t.Errorf("[%s] Expected popularity counters invalid, but got "+
+					"valid", test.TestName)
+			} else if test.ExpectedResult.Error() != err.Error() {
+				t.Errorf("[%s] Expected error '%s' got '%s'",
+					test.TestName, test.ExpectedResult.Error(), err.Error())
+			}
+		}
+	}
+}
+
+// TestIsStockUpdatesValid test IsStockUpdatesValid
+func TestIsStockUpdatesValid(t *testing.T) {
+	// initialize testing table
+	testTable := []struct {
+		TestName       string
+		Updates        []model.StockUpdate
+		ExpectedResult error
+	}{
+		{
+			TestName: "Test Updates Valid",
+			Updates: []model.StockUpdate{
+				{SKU: "a", Stock: 0},
+				{SKU: "b", Stock: 10},
+			},
+			ExpectedResult: nil,
+		},
+		{
+			TestName:       "Test Updates Empty",
+			Updates:        []model.StockUpdate{},
+			ExpectedResult: fmt.Errorf("stock updates empty/not found"),
+		},
+		{
+			TestName: "Test SKU Empty",
+			Updates: []model.StockUpdate{
+				{SKU: " ", Stock: 10},
+			},
+			ExpectedResult: fmt.Errorf("item 0: sku empty/not found"),
+		},
+		{
+			TestName: "Test Stock Negative",
+			Updates: []model.StockUpdate{
+				{SKU: "a", Stock: 10},
+				{SKU: "b", Stock: -1},
+			},
+			ExpectedResult: fmt.Errorf("item 1: stock can't be negative"),
+		},
+		{
+			TestName: "Test SKU Duplicated",
+			Updates: []model.StockUpdate{
+				{SKU: "a", Stock: 10},
+				{SKU: "a", Stock: 5},
+			},
+			ExpectedResult: fmt.Errorf("item 1: sku 'a' duplicated"),
+		},
+	}
+
+	// Do the test
+	for _, test := range testTable {
+		err := IsStockUpdatesValid(test.Updates)
+		if test.ExpectedResult == nil && err != nil {
+			t.Errorf("[%s] Expected stock updates valid, but got invalid => %s",
+				test.TestName, err.Error())
+		} else if test.ExpectedResult != nil {
+			if err == nil {
+				t.Errorf("[%s] Expected stock updates invalid, but got valid",
+					test.TestName)
+			} else if test.ExpectedResult.Error() != err.Error() {
+				t.Errorf("[%s] Expected error '%s' got '%s'",
+					test.TestName, test.ExpectedResult.Error(), err.Error())
+			}
+		}
+	}
+}
+
+// TestIsStockDecreasesValid test IsStockDecreasesValid
+func TestIsStockDecreasesValid(t *testing.T) {
+	// initialize testing table
+	testTable := []struct {
+		TestName       string
+		Decreases      []model.StockDecrease
+		ExpectedResult error
+	}{
+		{
+			TestName: "Test Decreases Valid",
+			Decreases: []model.StockDecrease{
+				{SKU: "a", Qty: 1},
+				{SKU: "b", Qty: 2.5},
+			},
+			ExpectedResult: nil,
+		},
+		{
+			TestName:       "Test Decreases Empty",
+			Decreases:      []model.StockDecrease{},
+			ExpectedResult: fmt.Errorf("stock decreases empty/not found"),
+		},
+		{
+			TestName: "Test SKU Empty",
+			Decreases: []model.StockDecrease{
+				{SKU: " ", Qty: 1},
+			},
+			ExpectedResult: fmt.Errorf("item 0: sku empty/not found"),
+		},
+		{
+			TestName: "Test Qty Zero",
+			Decreases: []model.StockDecrease{
+				{SKU: "a", Qty: 1},
+				{SKU: "b", Qty: 0},
+			},
+			ExpectedResult: fmt.Errorf("item 1: qty must be greater than zero"),
+		},
+		{
+			TestName: "Test SKU Duplicated",
+			Decreases: []model.StockDecrease{
+				{SKU: "a", Qty: 1},
+				{SKU: "a", Qty: 2},
+			},
+			ExpectedResult: fmt.Errorf("item 1: sku 'a' duplicated"),
+		},
+	}
+
+	// Do the test
+	for _, test := range testTable {
+		err := IsStockDecreasesValid(test.Decreases)
+		if test.ExpectedResult == nil && err != nil {
+			t.Errorf("[%s] Expected stock decreases valid, but got invalid "+
+				"=> %s", test.TestName, err.Error())
+		} else if test.ExpectedResult != nil {
+			if err == nil {
+				t.Errorf("[%s] Expected stock decreases invalid, but got valid",
+					test.TestName)
+			} else if test.ExpectedResult.Error() != err.Error() {
+				t.Errorf("[%s] Expected error '%s' got '%s'",
+					test.TestName, test.ExpectedResult.Error(), err.Error())
+			}
+		}
+	}
+}
+
+// TestIsProductImagesValid test IsProductImagesValid
+func TestIsProductImagesValid(t *testing.T) {
+	// initialize testing table
+	testTable := []struct {
+		TestName       string
+		Sizes          []int64
+		Filename       string
+		ExpectedResult error
+	}{
+		{
+			TestName:       "Test Images Valid",
+			Sizes:          []int64{100, 200},
+			ExpectedResult: nil,
+		},
+		{
+			TestName:       "Test No Images",
+			Sizes:          []int64{},
+			ExpectedResult: nil,
+		},
+		{
+			TestName: "Test Too Many Images",
+			Sizes:    []int64{1, 1, 1, 1},
+			ExpectedResult: fmt.Errorf("too many product images, " +
+				"maximum 3 images but got 4"),
+		},
+		{
+			TestName: "Test Images Too Large",
+			Sizes:    []int64{600, 500},
+			ExpectedResult: fmt.Errorf("product images too large, " +
+				"maximum 1000 bytes in total but got 1100 bytes"),
+		},
+		{
+			TestName: "Test Image File Name Too Long",
+			Sizes:    []int64{100},
+			Filename: strings.Repeat("a", 196) + ".png",
+			ExpectedResult: fmt.Errorf("product image file name '%s' too "+
+				"long, maximum 199 characters", strings.Repeat("a", 196)+".png"),
+		},
+	}
+
+	// Do the test
+	for _, test := range testTable {
+		fileHeaders := []*multipart.FileHeader{}
+		for _, size := range test.Sizes {
+			fileHeaders = append(fileHeaders, &multipart.FileHeader{
+				Filename: test.Filename,
+				Size:     size,
+			})
+		}
+
+		err := IsProductImagesValid(fileHeaders, 3, 1000)
+		if test.ExpectedResult == nil && err != nil {
+			t.Errorf("[%s] Expected product images valid, but got invalid => %s",
+				test.TestName, err.Error())
+		} else if test.ExpectedResult != nil {
+			if err == nil {
+				t.Errorf("[%s] Expected product images invalid, but got valid",
+					test.TestName)
+			} else if test.ExpectedResult.Error() != err.Error() {
+				t.Errorf("[%s] Expected error '%s' got '%s'",
+					test.TestName, test.ExpectedResult.Error(), err.Error())
+			}
+		}
+	}
+}
+
+// TestIsBarcodeValid test IsBarcodeValid
+func TestIsBarcodeValid(t *testing.T) {
+	// initialize testing table
+	testTable := []struct {
+		TestName       string
+		Code           string
+		ExpectedResult error
+	}{
+		{
+			TestName:       "Test EAN-8 Valid",
+			Code:           "96385074",
+			ExpectedResult: nil,
+		},
+		{
+			TestName:       "Test UPC-A Valid",
+			Code:           "036000291452",
+			ExpectedResult: nil,
+		},
+		{
+			TestName:       "Test EAN-13 Valid",
+			Code:           "4006381333931",
+			ExpectedResult: nil,
+		},
+		{
+			TestName:       "Test GTIN-14 Valid",
+			Code:           "10012345678902",
+			ExpectedResult: nil,
+		},
+		{
+			TestName:       "Test Check Digit Mismatch",
+			Code:           "4006381333932",
+			ExpectedResult: fmt.Errorf("barcode invalid, check digit mismatch"),
+		},
+		{
+			TestName:       "Test Truncated EAN-13",
+			Code:           "400638133393",
+			ExpectedResult: fmt.Errorf("barcode invalid, check digit mismatch"),
+		},
+		{
+			TestName: "Test Not Digits",
+			Code:     "40063813339A1",
+			ExpectedResult: fmt.Errorf(
+				"barcode invalid, must be 8, 12, 13, or 14 digits"),
+		},
+		{
+			TestName: "Test Length Unknown",
+			Code:     "1234567890",
+			ExpectedResult: fmt.Errorf(
+				"barcode invalid, must be 8, 12, 13, or 14 digits"),
+		},
+	}
+
+	// Do the test
+	for _, test := range testTable {
+		err := IsBarcodeValid(test.Code)
+		if test.ExpectedResult == nil && err != nil {
+			t.Errorf("[%s] Expected barcode valid, but got invalid => %s",
+				test.TestName, err.Error())
+		} else if test.ExpectedResult != nil {
+			if err == nil {
+				t.Errorf("[%s] Expected barcode invalid, but got valid",
+					test.TestName)
+			} else if test.ExpectedResult.Error() != err.Error() {
+				t.Errorf("[%s] Expected error '%s' got '%s'",
+					test.TestName, test.ExpectedResult.Error(), err.Error())
+			}
+		}
+	}
+}
+
+// TestIsShopValid test IsShopValid
+func TestIsShopValid(t *testing.T) {
+	// initialize testing table
+	testTable := []struct {
+		TestName       string
+		Shop           model.Shop
+		ExpectedResult error
+	}{
+		{
+			TestName:       "Test Valid",
+			Shop:           model.Shop{Slug: "toko-budi-2", DisplayName: "Toko Budi"},
+			ExpectedResult: nil,
+		},
+		{
+			TestName: "Test Empty",
+			Shop:     model.Shop{},
+			ExpectedResult: fmt.Errorf("slug empty/not found; " +
+				"display_name empty/not found"),
+		},
+		{
+			TestName: "Test Slug Too Short",
+			Shop:     model.Shop{Slug: "tb", DisplayName: "Toko Budi"},
+			ExpectedResult: fmt.Errorf(
+				"slug must be 3 to 50 characters"),
+		},
+		{
+			TestName: "Test Slug Uppercase",
+			Shop:     model.Shop{Slug: "Toko-Budi", DisplayName: "Toko Budi"},
+			ExpectedResult: fmt.Errorf("slug invalid, must be lowercase " +
+				"letters and digits separated by hyphens"),
+		},
+		{
+			TestName: "Test Slug Double Hyphen",
+			Shop:     model.Shop{Slug: "toko--budi", DisplayName: "Toko Budi"},
+			ExpectedResult: fmt.Errorf("slug invalid, must be lowercase " +
+				"letters and digits separated by hyphens"),
+		},
+		{
+			TestName: "Test Display Name Too Long",
+			Shop: model.Shop{Slug: "toko-budi",
+				DisplayName: strings.Repeat("a", 101)},
+			ExpectedResult: fmt.Errorf("display_name too long, " +
+				"maximum 100 characters"),
+		},
+	}
+
+	// Do the test
+	for _, test := range testTable {
+		err := IsShopValid(test.Shop)
+		if test.ExpectedResult == nil && err != nil {
+			t.Errorf("[%s] Expected shop valid, but got invalid => %s",
+				test.TestName, err.Error())
+		} else if test.ExpectedResult != nil {
+			if err == nil {
+				t.Errorf("[%s] Expected shop invalid, but got valid",
+					test.TestName)
+			} else if test.ExpectedResult.Error() != err.Error() {
+				t.Errorf("[%s] Expected error '%s' got '%s'",
+					test.TestName, test.ExpectedResult.Error(), err.Error())
+			}
+		}
+	}
+}
+
+// TestIsProductReportValid test IsProductReportValid
+func TestIsProductReportValid(t *testing.T) {
+	// initialize testing table
+	testTable := []struct {
+		TestName       string
+		Report         model.ProductReport
+		ExpectedResult error
+	}{
+		{
+			TestName: "Test Valid",
+			Report: model.ProductReport{Reason: model.ReportReasonCounterfeit,
+				Note: "fake brand"},
+			ExpectedResult: nil,
+		},
+		{
+			TestName:       "Test Empty",
+			Report:         model.ProductReport{},
+			ExpectedResult: fmt.Errorf("reason empty/not found"),
+		},
+		{
+			TestName: "Test Reason Invalid",
+			Report:   model.ProductReport{Reason: "ugly"},
+			ExpectedResult: fmt.Errorf("reason invalid, must be one of " +
+				"counterfeit, prohibited, misleading, other"),
+		},
+		{
+			TestName: "Test Note Too Long",
+			Report: model.ProductReport{Reason: model.ReportReasonOther,
+				Note: strings.Repeat("a", 1001)},
+			ExpectedResult: fmt.Errorf("note too long, " +
+				"maximum 1000 characters"),
+		},
+	}
+
+	// Do the test
+	for _, test := range testTable {
+		err := IsProductReportValid(test.Report)
+		if test.ExpectedResult == nil && err != nil {
+			t.Errorf("[%s] Expected report valid, but got invalid => %s",
+				test.TestName, err.Error())
+		} else if test.ExpectedResult != nil {
+			if err == nil {
+				t.Errorf("[%s] Expected report invalid, but got valid",
+					test.TestName)
+			} else if test.ExpectedResult.Error() != err.Error() {
+				t.Errorf("[%s] Expected error '%s' got '%s'",
+					test.TestName, test.ExpectedResult.Error(), err.Error())
+			}
+		}
+	}
+}
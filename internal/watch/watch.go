@@ -0,0 +1,252 @@
+/*
+Package watch containing journal of product events and gRPC server
+streaming the journal to internal consumers with resume tokens
+*/
+package watch
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-product-service/internal/event"
+	"github.com/reyhanfikridz/ecom-product-service/internal/middleware"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+	"github.com/reyhanfikridz/ecom-product-service/internal/productpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// maximum events read from journal at once
+const batchSize = 500
+
+// prefix of resume token, changed if resume token format changes
+const resumeTokenPrefix = "v1:"
+
+// errResumeTokenInvalid error of resume token not issued by the server
+var errResumeTokenInvalid = errors.New("resume token invalid")
+
+// change contain entity and operation of product event
+type change struct {
+	entity    string
+	operation string
+}
+
+// changes of product events by event name, restoring deleted product
+// creates it again for consumers
+var changes = map[string]change{
+	event.ProductCreated:  {model.ChangeEntityProduct, model.ChangeOperationCreate},
+	event.ProductRestored: {model.ChangeEntityProduct, model.ChangeOperationCreate},
+	event.ProductUpdated:  {model.ChangeEntityProduct, model.ChangeOperationUpdate},
+	event.ProductDeleted:  {model.ChangeEntityProduct, model.ChangeOperationDelete},
+	event.StockChanged:    {model.ChangeEntityStock, model.ChangeOperationUpdate},
+}
+
+// types of product events streamed by operation
+var eventTypes = map[string]productpb.ProductEventType{
+	model.ChangeOperationCreate: productpb.ProductEventType_PRODUCT_EVENT_TYPE_CREATED,
+	model.ChangeOperationUpdate: productpb.ProductEventType_PRODUCT_EVENT_TYPE_UPDATED,
+	model.ChangeOperationDelete: productpb.ProductEventType_PRODUCT_EVENT_TYPE_DELETED,
+}
+
+// Journal record product events in product repository so they can be
+// streamed to watchers
+type Journal struct {
+	Repo model.ProductRepository
+}
+
+// NewJournal create journal recording product events in product repository
+func NewJournal(repo model.ProductRepository) *Journal {
+	return &Journal{Repo: repo}
+}
+
+// Record record product event as change of its entity with its payload
+// as JSON, unknown event is recorded as product update
+func (j *Journal) Record(ctx context.Context, e event.Event) error {
+	c, ok := changes[e.Type]
+	if !ok {
+		c = change{model.ChangeEntityProduct, model.ChangeOperationUpdate}
+	}
+
+	pe := model.ProductEvent{
+		Entity:     c.entity,
+		Operation:  c.operation,
+		Name:       e.Type,
+		SKU:        e.SKU,
+		UserID:     e.UserID,
+		OccurredAt: e.OccurredAt,
+	}
+	if e.Payload != nil {
+		payload, err := json.Marshal(e.Payload)
+		if err != nil {
+			return err
+		}
+		pe.Payload = payload
+	}
+
+	_, err := j.Repo.InsertProductEvent(ctx, pe)
+	return err
+}
+
+// Server gRPC server of product watch service streaming journal of
+// product events, polled every PollInterval, events recorded within
+// Settle are streamed on the next poll since events recorded
+// concurrently may be committed out of order
+type Server struct {
+	productpb.UnimplementedProductWatchServer
+
+	Repo         model.ProductRepository
+	PollInterval time.Duration
+	Settle       time.Duration
+}
+
+// NewServer create server of product watch service streaming journal of
+// product events in product repository
+func NewServer(repo model.ProductRepository) *Server {
+	return &Server{
+		Repo:         repo,
+		PollInterval: time.Second,
+		Settle:       time.Second,
+	}
+}
+
+// WatchProducts stream product events of request SKUs, or all products
+// if request has no SKUs, occurred after request resume token, or occurred
+// from now if resume token is empty
+func (s *Server) WatchProducts(req *productpb.WatchProductsRequest,
+	stream productpb.ProductWatch_WatchProductsServer) error {
+	ctx := stream.Context()
+
+	// get ID of the last event received by watcher
+	afterID, err := s.getResumeID(ctx, req.GetResumeToken())
+	if err != nil {
+		return err
+	}
+
+	SKUs := map[string]bool{}
+	for _, SKU := range req.GetSkus() {
+		SKUs[SKU] = true
+	}
+
+	poll := time.NewTicker(s.PollInterval)
+	defer poll.Stop()
+	for {
+		events, err := s.Repo.GetProductEventsAfter(ctx, afterID, s.Settle,
+			batchSize)
+		if err != nil {
+			return status.Errorf(codes.Unavailable, "There's an error when "+
+				"getting product events => %s", err.Error())
+		}
+
+		for _, e := range events {
+			afterID = e.ID
+			if len(SKUs) > 0 && !SKUs[e.SKU] {
+				continue
+			}
+
+			err = stream.Send(NewProductEvent(e))
+			if err != nil {
+				return err
+			}
+		}
+
+		// read the rest of events right away if batch is full
+		if len(events) == batchSize {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-poll.C:
+		}
+	}
+}
+
+// getResumeID get ID of event of resume token, or ID of the last event
+// if resume token is empty
+//
+// return status InvalidArgument if resume token invalid, and OutOfRange
+// if events after resume token were already purged from journal
+func (s *Server) getResumeID(ctx context.Context, resumeToken string) (
+	int64, error) {
+	first, last, err := s.Repo.GetProductEventIDRange(ctx)
+	if err != nil {
+		return 0, status.Errorf(codes.Unavailable, "There's an error when "+
+			"getting product events => %s", err.Error())
+	}
+	if resumeToken == "" {
+		return last, nil
+	}
+
+	ID, err := ParseResumeToken(resumeToken)
+	if err != nil {
+		return 0, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if first > 0 && ID < first-1 {
+		return 0, status.Error(codes.OutOfRange, "resume token expired, "+
+			"events after it were purged, resync all products")
+	}
+
+	return ID, nil
+}
+
+// NewProductEvent create gRPC product event of journal product event
+func NewProductEvent(e model.ProductEvent) *productpb.ProductEvent {
+	return &productpb.ProductEvent{
+		Type:        eventTypes[e.Operation],
+		Sku:         e.SKU,
+		UserId:      int64(e.UserID),
+		OccurredAt:  timestamppb.New(e.OccurredAt),
+		ResumeToken: NewResumeToken(e.ID),
+		Name:        e.Name,
+		Payload:     e.Payload,
+	}
+}
+
+// NewResumeToken create opaque resume token of event ID
+func NewResumeToken(ID int64) string {
+	return base64.RawURLEncoding.EncodeToString(
+		[]byte(resumeTokenPrefix + strconv.FormatInt(ID, 10)))
+}
+
+// ParseResumeToken get event ID of resume token
+func ParseResumeToken(resumeToken string) (int64, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(resumeToken)
+	if err != nil || !strings.HasPrefix(string(decoded), resumeTokenPrefix) {
+		return 0, errResumeTokenInvalid
+	}
+
+	ID, err := strconv.ParseInt(
+		strings.TrimPrefix(string(decoded), resumeTokenPrefix), 10, 64)
+	if err != nil || ID < 0 {
+		return 0, errResumeTokenInvalid
+	}
+
+	return ID, nil
+}
+
+// StreamAuthorizationInterceptor authorize streams called by internal
+// services by checking metadata "authorization" has bearer token of
+// the internal service token
+func StreamAuthorizationInterceptor(srv interface{}, ss grpc.ServerStream,
+	info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	md, _ := metadata.FromIncomingContext(ss.Context())
+	token := ""
+	for _, value := range md.Get("authorization") {
+		if strings.HasPrefix(value, "Bearer ") {
+			token = strings.TrimPrefix(value, "Bearer ")
+		}
+	}
+	if !middleware.IsServiceToken(token) {
+		return status.Error(codes.PermissionDenied, "service token invalid")
+	}
+
+	return handler(srv, ss)
+}
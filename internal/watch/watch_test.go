@@ -0,0 +1,241 @@
+/*
+Package watch containing journal of product events and gRPC server
+streaming the journal to internal consumers with resume tokens
+*/
+package watch
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-product-service/internal/config"
+	"github.com/reyhanfikridz/ecom-product-service/internal/event"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+	"github.com/reyhanfikridz/ecom-product-service/internal/productpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// journalRepository product repository in memory storing journal
+// of product events, first events may be purged
+type journalRepository struct {
+	model.ProductRepository
+	mu     sync.Mutex
+	events []model.ProductEvent
+	lastID int64
+}
+
+// InsertProductEvent insert product event into journal in memory
+func (r *journalRepository) InsertProductEvent(ctx context.Context,
+	e model.ProductEvent) (model.ProductEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastID++
+	e.ID = r.lastID
+	r.events = append(r.events, e)
+	return e, nil
+}
+
+// GetProductEventsAfter get product events after ID from journal in memory
+func (r *journalRepository) GetProductEventsAfter(ctx context.Context,
+	afterID int64, settle time.Duration, limit int) ([]model.ProductEvent,
+	error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	events := []model.ProductEvent{}
+	for _, e := range r.events {
+		if e.ID > afterID && len(events) < limit {
+			events = append(events, e)
+		}
+	}
+
+	return events, nil
+}
+
+// GetProductEventIDRange get ID of the first and the last product event
+// of journal in memory
+func (r *journalRepository) GetProductEventIDRange(ctx context.Context) (
+	int64, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.events) == 0 {
+		return 0, 0, nil
+	}
+	return r.events[0].ID, r.events[len(r.events)-1].ID, nil
+}
+
+// TestResumeToken test NewResumeToken and ParseResumeToken
+func TestResumeToken(t *testing.T) {
+	ID, err := ParseResumeToken(NewResumeToken(42))
+	if err != nil || ID != 42 {
+		t.Errorf("Expected event ID 42, but got %d (%v)", ID, err)
+	}
+
+	// create testing table of invalid resume tokens
+	testTable := []struct {
+		TestName    string
+		ResumeToken string
+	}{
+		{"Not Base64", "!!!"},
+		{"Unknown Version", "djI6NDI"},
+		{"Not Number", "djE6YWJj"},
+		{"Negative", "djE6LTE"},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		_, err := ParseResumeToken(test.ResumeToken)
+		if err != errResumeTokenInvalid {
+			t.Errorf("[%s] Expected error resume token invalid, but got %v",
+				test.TestName, err)
+		}
+	}
+}
+
+// TestJournalRecord test Journal.Record recording payload as JSON
+func TestJournalRecord(t *testing.T) {
+	repo := &journalRepository{}
+	journal := NewJournal(repo)
+
+	err := journal.Record(context.Background(), event.NewEvent(
+		event.StockChanged, "SKU-A", 1,
+		event.StockChangedPayload{Stock: 4, Delta: -1}))
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+	err = journal.Record(context.Background(), event.NewEvent(
+		event.ProductDeleted, "SKU-A", 1, nil))
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+
+	if len(repo.events) != 2 || repo.events[0].Name != event.StockChanged ||
+		repo.events[0].Entity != model.ChangeEntityStock ||
+		repo.events[0].Operation != model.ChangeOperationUpdate ||
+		string(repo.events[0].Payload) != `{"stock":4,"delta":-1}` ||
+		repo.events[1].Operation != model.ChangeOperationDelete ||
+		repo.events[1].Payload != nil {
+		t.Errorf("Expected 2 product events recorded, but got %+v",
+			repo.events)
+	}
+}
+
+// TestWatchProducts test Server.WatchProducts through gRPC client
+// authorized by internal service token
+func TestWatchProducts(t *testing.T) {
+	defaultToken := config.InternalServiceToken
+	config.InternalServiceToken = "token"
+	defer func() { config.InternalServiceToken = defaultToken }()
+
+	// serve product watch service in memory
+	repo := &journalRepository{}
+	watchServer := NewServer(repo)
+	watchServer.PollInterval = 10 * time.Millisecond
+	lis := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(
+		grpc.StreamInterceptor(StreamAuthorizationInterceptor))
+	productpb.RegisterProductWatchServer(server, watchServer)
+	go server.Serve(lis)
+	defer server.Stop()
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, s string) (
+			net.Conn, error) {
+			return lis.Dial()
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("There's an error when dialing gRPC server => %s",
+			err.Error())
+	}
+	defer conn.Close()
+	client := productpb.NewProductWatchClient(conn)
+
+	// record events, the first is purged
+	for _, e := range []event.Event{
+		event.NewEvent(event.ProductCreated, "SKU-A", 1, nil),
+		event.NewEvent(event.ProductCreated, "SKU-B", 1, nil),
+		event.NewEvent(event.ProductUpdated, "SKU-A", 1, nil),
+		event.NewEvent(event.StockChanged, "SKU-B", 1, nil),
+	} {
+		NewJournal(repo).Record(context.Background(), e)
+	}
+	repo.events = repo.events[1:]
+
+	// create testing table of failing watch
+	testTable := []struct {
+		TestName     string
+		Token        string
+		ResumeToken  string
+		ExpectedCode codes.Code
+	}{
+		{"Token Invalid", "invalid", "", codes.PermissionDenied},
+		{"Resume Token Invalid", "token", "invalid", codes.InvalidArgument},
+		{"Resume Token Expired", "token", NewResumeToken(0), codes.OutOfRange},
+	}
+
+	// loop test in test table
+	for _, test := range testTable {
+		ctx := metadata.AppendToOutgoingContext(context.Background(),
+			"authorization", "Bearer "+test.Token)
+		stream, err := client.WatchProducts(ctx,
+			&productpb.WatchProductsRequest{ResumeToken: test.ResumeToken})
+		if err == nil {
+			_, err = stream.Recv()
+		}
+		if status.Code(err) != test.ExpectedCode {
+			t.Errorf("[%s] Expected status code %s, but got %v",
+				test.TestName, test.ExpectedCode, err)
+		}
+	}
+
+	// resume after the first event watching SKU-B only
+	ctx, cancel := context.WithTimeout(metadata.AppendToOutgoingContext(
+		context.Background(), "authorization", "Bearer token"), 5*time.Second)
+	defer cancel()
+	stream, err := client.WatchProducts(ctx, &productpb.WatchProductsRequest{
+		ResumeToken: NewResumeToken(1),
+		Skus:        []string{"SKU-B"},
+	})
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+
+	// events recorded while watching are streamed too
+	go NewJournal(repo).Record(context.Background(),
+		event.NewEvent(event.ProductDeleted, "SKU-B", 1, nil))
+
+	expectedEvents := []struct {
+		Type        productpb.ProductEventType
+		Name        string
+		ResumeToken string
+	}{
+		{productpb.ProductEventType_PRODUCT_EVENT_TYPE_CREATED,
+			event.ProductCreated, NewResumeToken(2)},
+		{productpb.ProductEventType_PRODUCT_EVENT_TYPE_UPDATED,
+			event.StockChanged, NewResumeToken(4)},
+		{productpb.ProductEventType_PRODUCT_EVENT_TYPE_DELETED,
+			event.ProductDeleted, NewResumeToken(5)},
+	}
+	for _, expected := range expectedEvents {
+		e, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Expected error nil, but got error => %s", err.Error())
+		}
+		if e.GetSku() != "SKU-B" || e.GetType() != expected.Type ||
+			e.GetName() != expected.Name ||
+			e.GetResumeToken() != expected.ResumeToken {
+			t.Errorf("Expected event %+v of SKU-B, but got %v", expected, e)
+		}
+	}
+}
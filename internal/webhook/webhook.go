@@ -0,0 +1,178 @@
+/*
+Package webhook containing asynchronous delivery of product events
+to webhook URLs subscribed by sellers
+*/
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/reyhanfikridz/ecom-product-service/internal/event"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+	"github.com/reyhanfikridz/ecom-product-service/internal/netguard"
+)
+
+// maximum delivery attempts for a webhook
+const maxAttempts = 3
+
+// Payload contain body of a webhook delivery
+type Payload struct {
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      event.Event `json:"data"`
+}
+
+// Dispatcher deliver product events to subscribed webhook URLs
+// in background workers
+type Dispatcher struct {
+	DB                *sql.DB
+	Client            *http.Client
+	LowStockThreshold int
+	queue             chan event.Event
+}
+
+// NewDispatcher create webhook dispatcher with buffered event queue,
+// its client refusing internal addresses since URLs are given by sellers
+func NewDispatcher(DB *sql.DB, lowStockThreshold int) *Dispatcher {
+	return &Dispatcher{
+		DB:                DB,
+		Client:            netguard.NewClient(10 * time.Second),
+		LowStockThreshold: lowStockThreshold,
+		queue:             make(chan event.Event, 1000),
+	}
+}
+
+// Start start n background workers delivering queued events
+func (d *Dispatcher) Start(n int) {
+	for i := 0; i < n; i++ {
+		go func() {
+			for e := range d.queue {
+				d.deliverEvent(e)
+			}
+		}()
+	}
+}
+
+// Dispatch queue event for delivery without blocking,
+// event is dropped if the queue is full
+func (d *Dispatcher) Dispatch(e event.Event) {
+	select {
+	case d.queue <- e:
+	default:
+		log.Printf("Webhook queue full, dropping event %s of SKU %s",
+			e.Type, e.SKU)
+	}
+}
+
+// deliverEvent deliver event to all webhook subscriptions
+// of the product owner
+func (d *Dispatcher) deliverEvent(e event.Event) {
+	for _, name := range EventNames(e, d.LowStockThreshold) {
+		subs, err := model.GetWebhookSubscriptions(context.Background(), d.DB,
+			e.UserID, name)
+		if err != nil {
+			log.Printf("There's an error when getting webhook "+
+				"subscriptions => %s", err.Error())
+			continue
+		}
+
+		for _, sub := range subs {
+			err = Deliver(d.Client, sub, name, e)
+			if err != nil {
+				log.Printf("There's an error when delivering webhook %s "+
+					"to %s => %s", name, sub.URL, err.Error())
+			}
+		}
+	}
+}
+
+// EventNames get webhook event names of a product domain event,
+// stock change crossing down to low stock threshold also
+// triggers event stock.low
+func EventNames(e event.Event, lowStockThreshold int) []string {
+	switch e.Type {
+	case event.ProductCreated:
+		return []string{model.WebhookEventProductCreated}
+	case event.ProductUpdated:
+		return []string{model.WebhookEventProductUpdated}
+	case event.ProductDeleted:
+		return []string{model.WebhookEventProductDeleted}
+	case event.StockChanged:
+		names := []string{model.WebhookEventStockChanged}
+
+		payload, ok := e.Payload.(event.StockChangedPayload)
+		threshold := float64(lowStockThreshold)
+		if ok && payload.Stock <= threshold &&
+			payload.Stock-payload.Delta > threshold {
+			names = append(names, model.WebhookEventStockLow)
+		}
+
+		return names
+	}
+
+	return []string{}
+}
+
+// Sign get HMAC-SHA256 signature of body with the subscription secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Deliver POST signed event to webhook subscription URL,
+// retrying with backoff until success status received
+func Deliver(client *http.Client, sub model.WebhookSubscription,
+	name string, e event.Event) error {
+	body, err := json.Marshal(Payload{
+		Event:     name,
+		CreatedAt: time.Now().UTC(),
+		Data:      e,
+	})
+	if err != nil {
+		return err
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = post(client, sub, name, body)
+		if err == nil || attempt == maxAttempts {
+			return err
+		}
+
+		time.Sleep(time.Duration(attempt) * time.Second)
+	}
+}
+
+// post do one webhook delivery attempt
+func post(client *http.Client, sub model.WebhookSubscription,
+	name string, body []byte) error {
+	req, err := http.NewRequest("POST", sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", name)
+	req.Header.Set("X-Webhook-Signature", Sign(sub.Secret, body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("status code invalid => %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+/*
+Package webhook containing asynchronous delivery of product events
+to webhook URLs subscribed by sellers
+*/
+package webhook
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/reyhanfikridz/ecom-product-service/internal/event"
+	"github.com/reyhanfikridz/ecom-product-service/internal/model"
+	"github.com/reyhanfikridz/ecom-product-service/internal/netguard"
+)
+
+// TestEventNames test EventNames
+func TestEventNames(t *testing.T) {
+	// initialize testing table
+	testTable := []struct {
+		TestName      string
+		Event         event.Event
+		ExpectedNames []string
+	}{
+		{
+			TestName:      "Product Created",
+			Event:         event.NewEvent(event.ProductCreated, "a", 1, nil),
+			ExpectedNames: []string{model.WebhookEventProductCreated},
+		},
+		{
+			TestName: "Stock Changed Above Threshold",
+			Event: event.NewEvent(event.StockChanged, "a", 1,
+				event.StockChangedPayload{Stock: 10, Delta: -1}),
+			ExpectedNames: []string{model.WebhookEventStockChanged},
+		},
+		{
+			TestName: "Stock Changed Crossing Threshold",
+			Event: event.NewEvent(event.StockChanged, "a", 1,
+				event.StockChangedPayload{Stock: 4, Delta: -2}),
+			ExpectedNames: []string{model.WebhookEventStockChanged,
+				model.WebhookEventStockLow},
+		},
+		{
+			TestName: "Stock Changed Already Low",
+			Event: event.NewEvent(event.StockChanged, "a", 1,
+				event.StockChangedPayload{Stock: 3, Delta: -1}),
+			ExpectedNames: []string{model.WebhookEventStockChanged},
+		},
+	}
+
+	// do the test
+	for _, test := range testTable {
+		names := EventNames(test.Event, 5)
+		if len(names) != len(test.ExpectedNames) {
+			t.Errorf("[%s] Expected names %v, but got %v",
+				test.TestName, test.ExpectedNames, names)
+			continue
+		}
+
+		for i := range names {
+			if names[i] != test.ExpectedNames[i] {
+				t.Errorf("[%s] Expected names %v, but got %v",
+					test.TestName, test.ExpectedNames, names)
+			}
+		}
+	}
+}
+
+// TestDeliver test Deliver
+func TestDeliver(t *testing.T) {
+	sub := model.WebhookSubscription{Secret: "secret"}
+
+	// create testing webhook receiver checking the signature
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			if r.Header.Get("X-Webhook-Signature") != Sign(sub.Secret, body) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if r.Header.Get("X-Webhook-Event") != model.WebhookEventProductDeleted {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+	defer server.Close()
+	sub.URL = server.URL
+
+	err := Deliver(server.Client(), sub, model.WebhookEventProductDeleted,
+		event.NewEvent(event.ProductDeleted, "a", 1, nil))
+	if err != nil {
+		t.Errorf("Expected error nil, but got error => %s", err.Error())
+	}
+}
+
+// TestDispatcherClient test client of dispatcher refusing to deliver
+// to webhook receiver of internal address
+func TestDispatcherClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+	defer server.Close()
+
+	d := NewDispatcher(nil, 0)
+	err := post(d.Client, model.WebhookSubscription{URL: server.URL},
+		model.WebhookEventProductDeleted, []byte("{}"))
+	if !errors.Is(err, netguard.ErrAddressNotAllowed) {
+		t.Errorf("Expected error address not allowed, but got %v", err)
+	}
+}
@@ -0,0 +1,299 @@
+/*
+Package xlsx containing reader of rows of Excel (.xlsx) workbooks,
+reading cell values of the first worksheet as text without any
+spreadsheet dependency
+*/
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// MIMEType media type of Excel workbook
+const MIMEType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// maximum uncompressed size of a workbook part read, so a small
+// workbook can't expand into huge memory
+const maxPartSize = 64 * 1024 * 1024
+
+// paths of workbook parts inside the zip archive
+const (
+	workbookPath      = "xl/workbook.xml"
+	workbookRelsPath  = "xl/_rels/workbook.xml.rels"
+	sharedStringsPath = "xl/sharedStrings.xml"
+)
+
+// ErrNotWorkbook error of content not an Excel workbook
+var ErrNotWorkbook = errors.New("file is not an Excel (.xlsx) workbook")
+
+// Row contain number (1-based, as shown by Excel) and text of cells
+// of a worksheet row, empty cells between values are empty strings
+type Row struct {
+	Number int
+	Cells  []string
+}
+
+// IsWorkbook check content starting with b is a zip archive,
+// which an Excel workbook is
+func IsWorkbook(b []byte) bool {
+	return bytes.HasPrefix(b, []byte("PK\x03\x04"))
+}
+
+// ReadRows read non-empty rows of the first worksheet of workbook
+// of size bytes read from r
+//
+// return error if it's not an Excel workbook or its parts invalid
+func ReadRows(r io.ReaderAt, size int64) ([]Row, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, ErrNotWorkbook
+	}
+	files := map[string]*zip.File{}
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	sheetPath, err := getFirstSheetPath(files)
+	if err != nil {
+		return nil, err
+	}
+	sharedStrings, err := getSharedStrings(files)
+	if err != nil {
+		return nil, err
+	}
+
+	sheet := worksheet{}
+	err = decodePart(files, sheetPath, &sheet)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []Row{}
+	next := 1
+	for _, sheetRow := range sheet.Rows {
+		// row number is optional, rows without it follow previous row
+		row := Row{Number: sheetRow.Number, Cells: []string{}}
+		if row.Number == 0 {
+			row.Number = next
+		}
+		next = row.Number + 1
+
+		empty := true
+		for j, c := range sheetRow.Cells {
+			column := j
+			if c.Ref != "" {
+				column, err = parseColumn(c.Ref)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			value, err := c.text(sharedStrings)
+			if err != nil {
+				return nil, err
+			}
+			if value == "" {
+				continue
+			}
+			empty = false
+
+			for len(row.Cells) <= column {
+				row.Cells = append(row.Cells, "")
+			}
+			row.Cells[column] = value
+		}
+
+		if !empty {
+			rows = append(rows, row)
+		}
+	}
+
+	return rows, nil
+}
+
+// workbook sheets of workbook part
+type workbook struct {
+	Sheets []struct {
+		RelID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+// relationships targets of workbook relationships part
+type relationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// sharedStrings strings of shared strings part, each string either
+// plain text or rich text runs
+type sharedStrings struct {
+	Items []struct {
+		Text string `xml:"t"`
+		Runs []struct {
+			Text string `xml:"t"`
+		} `xml:"r"`
+	} `xml:"si"`
+}
+
+// worksheet rows of worksheet part
+type worksheet struct {
+	Rows []struct {
+		Number int    `xml:"r,attr"`
+		Cells  []cell `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// cell reference, type, value, and inline string of worksheet cell
+type cell struct {
+	Ref    string `xml:"r,attr"`
+	Type   string `xml:"t,attr"`
+	Value  string `xml:"v"`
+	Inline struct {
+		Text string `xml:"t"`
+		Runs []struct {
+			Text string `xml:"t"`
+		} `xml:"r"`
+	} `xml:"is"`
+}
+
+// text get text of cell by its type, shared string looked up from
+// shared strings, boolean as 'true' or 'false'
+func (c cell) text(shared []string) (string, error) {
+	switch c.Type {
+	case "s":
+		var i int
+		_, err := fmt.Sscan(c.Value, &i)
+		if err != nil || i < 0 || i >= len(shared) {
+			return "", fmt.Errorf("cell %s shared string '%s' invalid",
+				c.Ref, c.Value)
+		}
+		return shared[i], nil
+	case "inlineStr":
+		text := c.Inline.Text
+		for _, run := range c.Inline.Runs {
+			text += run.Text
+		}
+		return text, nil
+	case "b":
+		if c.Value == "1" {
+			return "true", nil
+		}
+		return "false", nil
+	default: // number, formula string, or error
+		return c.Value, nil
+	}
+}
+
+// getFirstSheetPath get path of first worksheet part of workbook files
+func getFirstSheetPath(files map[string]*zip.File) (string, error) {
+	book := workbook{}
+	err := decodePart(files, workbookPath, &book)
+	if err != nil {
+		return "", err
+	}
+	if len(book.Sheets) == 0 {
+		return "", errors.New("workbook has no worksheet")
+	}
+
+	rels := relationships{}
+	err = decodePart(files, workbookRelsPath, &rels)
+	if err != nil {
+		return "", err
+	}
+	for _, rel := range rels.Relationships {
+		if rel.ID != book.Sheets[0].RelID {
+			continue
+		}
+
+		// target is relative to folder xl unless it's absolute
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), nil
+		}
+		return path.Join("xl", rel.Target), nil
+	}
+
+	return "", errors.New("workbook first worksheet not found")
+}
+
+// getSharedStrings get shared strings of workbook files,
+// empty if workbook has no shared strings part
+func getSharedStrings(files map[string]*zip.File) ([]string, error) {
+	if files[sharedStringsPath] == nil {
+		return []string{}, nil
+	}
+
+	sst := sharedStrings{}
+	err := decodePart(files, sharedStringsPath, &sst)
+	if err != nil {
+		return nil, err
+	}
+
+	strs := make([]string, len(sst.Items))
+	for i, item := range sst.Items {
+		strs[i] = item.Text
+		for _, run := range item.Runs {
+			strs[i] += run.Text
+		}
+	}
+
+	return strs, nil
+}
+
+// decodePart decode XML of workbook part of path into v
+func decodePart(files map[string]*zip.File, name string,
+	v interface{}) error {
+	f := files[name]
+	if f == nil {
+		return fmt.Errorf("%s => part %s not found", ErrNotWorkbook.Error(),
+			name)
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("workbook part %s invalid => %s", name, err.Error())
+	}
+	defer rc.Close()
+
+	b, err := io.ReadAll(io.LimitReader(rc, maxPartSize+1))
+	if err != nil {
+		return fmt.Errorf("workbook part %s invalid => %s", name, err.Error())
+	}
+	if len(b) > maxPartSize {
+		return fmt.Errorf("workbook part %s too large, maximum %d bytes",
+			name, maxPartSize)
+	}
+
+	err = xml.Unmarshal(b, v)
+	if err != nil {
+		return fmt.Errorf("workbook part %s invalid => %s", name, err.Error())
+	}
+
+	return nil
+}
+
+// parseColumn parse 0-based column index of cell reference like 'B12'
+func parseColumn(ref string) (int, error) {
+	column := 0
+	letters := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		column = column*26 + int(r-'A'+1)
+		letters++
+	}
+	if letters == 0 || letters > 3 {
+		return 0, fmt.Errorf("cell reference '%s' invalid", ref)
+	}
+
+	return column - 1, nil
+}
@@ -0,0 +1,158 @@
+/*
+Package xlsx containing reader of rows of Excel (.xlsx) workbooks,
+reading cell values of the first worksheet as text without any
+spreadsheet dependency
+*/
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// testWorkbookParts parts of testing workbook, its first worksheet
+// has shared strings, rich text, inline string, number, boolean,
+// skipped columns, empty row, and row without row number
+var testWorkbookParts = map[string]string{
+	"[Content_Types].xml": `<?xml version="1.0" encoding="UTF-8"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"/>`,
+	"xl/workbook.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+	xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+	<sheets>
+		<sheet name="Products" sheetId="1" r:id="rId2"/>
+		<sheet name="Notes" sheetId="2" r:id="rId1"/>
+	</sheets>
+</workbook>`,
+	"xl/_rels/workbook.xml.rels": `<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+	<Relationship Id="rId1" Target="worksheets/sheet1.xml"/>
+	<Relationship Id="rId2" Target="/xl/worksheets/sheet2.xml"/>
+	<Relationship Id="rId3" Target="sharedStrings.xml"/>
+</Relationships>`,
+	"xl/sharedStrings.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+	<si><t>name</t></si>
+	<si><t>price</t></si>
+	<si><r><t>Wireless </t></r><r><t>Mouse</t></r></si>
+</sst>`,
+	"xl/worksheets/sheet1.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+	<sheetData><row r="1"><c r="A1"><v>1</v></c></row></sheetData>
+</worksheet>`,
+	"xl/worksheets/sheet2.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+	<sheetData>
+		<row r="1">
+			<c r="A1" t="s"><v>0</v></c>
+			<c r="B1" t="s"><v>1</v></c>
+			<c r="D1" t="inlineStr"><is><t>active</t></is></c>
+		</row>
+		<row r="2">
+			<c r="A2" t="s"><v>2</v></c>
+			<c r="B2"><v>150000.5</v></c>
+			<c r="D2" t="b"><v>1</v></c>
+		</row>
+		<row r="3"><c r="A3" s="1"/></row>
+		<row r="5"><c r="C5"><v>7</v></c></row>
+		<row><c t="inlineStr"><is><t>Hub</t></is></c></row>
+	</sheetData>
+</worksheet>`,
+}
+
+// newTestWorkbook create workbook zip archive of parts
+func newTestWorkbook(t *testing.T, parts map[string]string) []byte {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for name, content := range parts {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("There's an error when creating workbook => %s",
+				err.Error())
+		}
+		w.Write([]byte(content))
+	}
+
+	err := zw.Close()
+	if err != nil {
+		t.Fatalf("There's an error when creating workbook => %s", err.Error())
+	}
+
+	return buf.Bytes()
+}
+
+// TestReadRows test ReadRows
+func TestReadRows(t *testing.T) {
+	b := newTestWorkbook(t, testWorkbookParts)
+	if !IsWorkbook(b) {
+		t.Errorf("Expected testing workbook detected as workbook")
+	}
+
+	rows, err := ReadRows(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatalf("Expected error nil, but got error => %s", err.Error())
+	}
+
+	expected := []Row{
+		{Number: 1, Cells: []string{"name", "price", "", "active"}},
+		{Number: 2, Cells: []string{"Wireless Mouse", "150000.5", "", "true"}},
+		{Number: 5, Cells: []string{"", "", "7"}},
+		{Number: 6, Cells: []string{"Hub"}},
+	}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("Expected rows %q, but got %q", expected, rows)
+	}
+}
+
+// TestReadRowsInvalid test ReadRows of invalid workbooks
+func TestReadRowsInvalid(t *testing.T) {
+	withoutWorkbook := map[string]string{}
+	withInvalidSharedString := map[string]string{}
+	for name, content := range testWorkbookParts {
+		if name != "xl/workbook.xml" {
+			withoutWorkbook[name] = content
+		}
+		withInvalidSharedString[name] = content
+	}
+	withInvalidSharedString["xl/worksheets/sheet2.xml"] = `<worksheet>
+		<sheetData><row r="1"><c r="A1" t="s"><v>9</v></c></row></sheetData>
+	</worksheet>`
+
+	testTable := []struct {
+		TestName string
+		Workbook []byte
+	}{
+		{"Test Not Zip", []byte("name,price\nMouse,1000\n")},
+		{"Test Workbook Part Not Found", newTestWorkbook(t, withoutWorkbook)},
+		{"Test Shared String Invalid",
+			newTestWorkbook(t, withInvalidSharedString)},
+	}
+
+	for _, test := range testTable {
+		_, err := ReadRows(bytes.NewReader(test.Workbook),
+			int64(len(test.Workbook)))
+		if err == nil {
+			t.Errorf("[%s] Expected error, but got nil", test.TestName)
+		}
+	}
+}
+
+// TestParseColumn test parseColumn
+func TestParseColumn(t *testing.T) {
+	for ref, expected := range map[string]int{
+		"A1": 0, "D12": 3, "Z3": 25, "AA1": 26, "AB7": 27,
+	} {
+		column, err := parseColumn(ref)
+		if err != nil || column != expected {
+			t.Errorf("Expected column of %s %d, but got %d with error %v",
+				ref, expected, column, err)
+		}
+	}
+
+	_, err := parseColumn("12")
+	if err == nil {
+		t.Errorf("Expected error of reference without column, but got nil")
+	}
+}